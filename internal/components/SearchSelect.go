@@ -22,6 +22,7 @@ type SearchSelectProps struct {
 	Selected             string
 	OptionsEndpoint      string // optional: URL to fetch options
 	SearchQueryParamName string // optional: query parameter name, default "SearchText"
+	LoadOptionsOnOpen    bool   // optional: fetch options each time the dropdown opens
 }
 
 func SearchSelectOptions(options []SearchSelectOption) g.Node {
@@ -89,6 +90,10 @@ func SearchSelect(p *SearchSelectProps, children ...g.Node) g.Node {
 			searchQueryParamName != "",
 			h.Data("search-query-param", searchQueryParamName),
 		),
+		g.If(
+			p.LoadOptionsOnOpen && p.OptionsEndpoint != "",
+			h.Data("load-on-open", "true"),
+		),
 
 		h.Div(
 			h.Class("select-input"),
@@ -55,9 +55,18 @@
   const name = selectEl.dataset.name;
   updateHiddenInputs(selectEl, selected, name);
 
+  // Options that depend on other fields of the form are fetched again each
+  // time the dropdown is opened
+  const loadOnOpen = () => {
+    if (selectEl.dataset.loadOnOpen && dropdown.classList.contains("open")) {
+      loadOptions(search.value.trim());
+    }
+  };
+
   input.addEventListener("click", () => {
     dropdown.classList.toggle("open");
     search.focus();
+    loadOnOpen();
   });
 
   input.addEventListener("keydown", (e) => {
@@ -65,6 +74,7 @@
       e.preventDefault();
       dropdown.classList.add("open");
       search.focus();
+      loadOnOpen();
     }
   });
 
@@ -73,9 +83,11 @@
     e.stopPropagation();
   });
 
-  search.addEventListener("input", async () => {
-    const term = search.value.trim();
+  search.addEventListener("input", () => {
+    loadOptions(search.value.trim());
+  });
 
+  async function loadOptions(term) {
     selectEl.dispatchEvent(
       new CustomEvent("load-options", {
         detail: { search: term },
@@ -136,7 +148,7 @@
         ? ""
         : "none";
     });
-  });
+  }
 
   optionsList.addEventListener("click", (e) => {
     e.stopPropagation();
@@ -0,0 +1,28 @@
+package handler
+
+import (
+	"app/pkg/validate"
+	"encoding/json"
+	"net/http"
+)
+
+// APIErrorResponse is the body of every error response of the JSON API.
+// ValidationErrors is only set when the request body was invalid.
+type APIErrorResponse struct {
+	Error            string
+	ValidationErrors validate.ValidationErrors `json:",omitempty"`
+}
+
+func writeAPIJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeAPIError(w http.ResponseWriter, status int, message string) {
+	writeAPIJSON(w, status, APIErrorResponse{Error: message})
+}
+
+func APINotFound(w http.ResponseWriter, r *http.Request) {
+	writeAPIError(w, http.StatusNotFound, "Not found")
+}
@@ -0,0 +1,852 @@
+package handler
+
+import (
+	"app/internal/model"
+	"app/internal/service"
+	"app/internal/views/stockview"
+	"app/pkg/appurl"
+	"app/pkg/reqcontext"
+	"app/pkg/validate"
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type PurchaseOrderHandler struct {
+	purchaseOrderService service.PurchaseOrderService
+	stockItemService     service.StockItemService
+}
+
+func NewPurchaseOrderHandler(
+	purchaseOrderService service.PurchaseOrderService,
+	stockItemService service.StockItemService,
+) *PurchaseOrderHandler {
+	return &PurchaseOrderHandler{
+		purchaseOrderService: purchaseOrderService,
+		stockItemService:     stockItemService,
+	}
+}
+
+func (h *PurchaseOrderHandler) SuppliersPage(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	if !ctx.User.Permissions.SupplyChain.Admin {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	h.renderSuppliersPage(w, r, &stockview.SuppliersPageProps{})
+}
+
+type postSupplierFormData struct {
+	Code                 string
+	Name                 string
+	Email                string
+	Phone                string
+	OverReceiptTolerance decimal.Decimal
+	IsArchived           bool
+}
+
+func (fd *postSupplierFormData) normalise() {
+	fd.Code = strings.ToUpper(strings.TrimSpace(fd.Code))
+	fd.Name = strings.TrimSpace(fd.Name)
+	fd.Email = strings.TrimSpace(fd.Email)
+	fd.Phone = strings.TrimSpace(fd.Phone)
+}
+
+func (h *PurchaseOrderHandler) CreateSupplier(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	if !ctx.User.Permissions.SupplyChain.Admin {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	err := r.ParseForm()
+	if err != nil {
+		http.Error(w, "Error parsing form", http.StatusBadRequest)
+		return
+	}
+
+	var fd postSupplierFormData
+	err = appurl.Unmarshal(r.Form, &fd)
+	if err != nil {
+		http.Error(w, "Error decoding form", http.StatusBadRequest)
+		return
+	}
+
+	fd.normalise()
+
+	supplierID, validationErrors, err := h.purchaseOrderService.CreateSupplier(
+		r.Context(),
+		&model.NewSupplier{
+			Code:                 fd.Code,
+			Name:                 fd.Name,
+			Email:                fd.Email,
+			Phone:                fd.Phone,
+			OverReceiptTolerance: fd.OverReceiptTolerance,
+		},
+		ctx.User.UserID,
+	)
+	if err != nil {
+		h.renderSuppliersPage(w, r, &stockview.SuppliersPageProps{
+			Values:    r.Form,
+			ErrorText: fmt.Sprintf("Error adding supplier: %v", err),
+		})
+		return
+	}
+
+	if len(validationErrors) > 0 {
+		h.renderSuppliersPage(w, r, &stockview.SuppliersPageProps{
+			Values:           r.Form,
+			ValidationErrors: validationErrors,
+			IsSubmission:     true,
+		})
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/stock/suppliers/%d", supplierID), http.StatusSeeOther)
+}
+
+func (h *PurchaseOrderHandler) SupplierPage(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	if !ctx.User.Permissions.SupplyChain.Admin {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	supplierID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid supplier ID", http.StatusBadRequest)
+		return
+	}
+
+	h.renderSupplierPage(w, r, supplierID, &stockview.SupplierPageProps{})
+}
+
+func (h *PurchaseOrderHandler) UpdateSupplier(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	if !ctx.User.Permissions.SupplyChain.Admin {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	supplierID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid supplier ID", http.StatusBadRequest)
+		return
+	}
+
+	err = r.ParseForm()
+	if err != nil {
+		http.Error(w, "Error parsing form", http.StatusBadRequest)
+		return
+	}
+
+	var fd postSupplierFormData
+	err = appurl.Unmarshal(r.Form, &fd)
+	if err != nil {
+		http.Error(w, "Error decoding form", http.StatusBadRequest)
+		return
+	}
+
+	fd.normalise()
+
+	validationErrors, err := h.purchaseOrderService.UpdateSupplier(
+		r.Context(),
+		supplierID,
+		&model.SupplierUpdate{
+			Name:                 fd.Name,
+			Email:                fd.Email,
+			Phone:                fd.Phone,
+			OverReceiptTolerance: fd.OverReceiptTolerance,
+			IsArchived:           fd.IsArchived,
+		},
+		ctx.User.UserID,
+	)
+	if err != nil {
+		h.renderSupplierPage(w, r, supplierID, &stockview.SupplierPageProps{
+			Values:    r.Form,
+			ErrorText: fmt.Sprintf("Error updating supplier: %v", err),
+		})
+		return
+	}
+
+	if len(validationErrors) > 0 {
+		h.renderSupplierPage(w, r, supplierID, &stockview.SupplierPageProps{
+			Values:           r.Form,
+			ValidationErrors: validationErrors,
+			IsSubmission:     true,
+		})
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/stock/suppliers/%d", supplierID), http.StatusSeeOther)
+}
+
+func (h *PurchaseOrderHandler) PurchaseOrdersPage(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	type urlVals struct {
+		Status     string
+		SupplierID int
+		StockCode  string
+		Page       int
+		PageSize   int
+	}
+
+	var uv urlVals
+
+	err := appurl.Unmarshal(r.URL.Query(), &uv)
+	if err != nil {
+		http.Error(w, "Error decoding url values", http.StatusBadRequest)
+		return
+	}
+
+	uv.StockCode = strings.ToUpper(strings.TrimSpace(uv.StockCode))
+
+	if uv.Page == 0 {
+		uv.Page = 1
+	}
+	if uv.PageSize == 0 {
+		uv.PageSize = 50
+	}
+
+	purchaseOrders, count, err := h.purchaseOrderService.GetPurchaseOrders(r.Context(), &model.GetPurchaseOrdersQuery{
+		Status:     model.PurchaseOrderStatus(uv.Status),
+		SupplierID: uv.SupplierID,
+		StockCode:  uv.StockCode,
+		Page:       uv.Page,
+		PageSize:   uv.PageSize,
+	})
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Error fetching purchase orders", http.StatusInternalServerError)
+		return
+	}
+
+	suppliers, _, err := h.purchaseOrderService.GetSuppliers(r.Context(), &model.GetSuppliersQuery{
+		ShowArchived: true,
+		Page:         1,
+		PageSize:     10000,
+	})
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Error fetching suppliers", http.StatusInternalServerError)
+		return
+	}
+
+	_ = stockview.PurchaseOrdersPage(&stockview.PurchaseOrdersPageProps{
+		Ctx:                 ctx,
+		PurchaseOrders:      purchaseOrders,
+		PurchaseOrdersCount: count,
+		Suppliers:           suppliers,
+		Status:              uv.Status,
+		SupplierID:          uv.SupplierID,
+		StockCode:           uv.StockCode,
+		Page:                uv.Page,
+		PageSize:            uv.PageSize,
+	}).Render(w)
+}
+
+func (h *PurchaseOrderHandler) AddPurchaseOrderPage(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	if !ctx.User.Permissions.SupplyChain.Admin {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	values := url.Values{}
+	values.Set("OrderDate", time.Now().Format("2006-01-02"))
+	if supplierID := r.URL.Query().Get("SupplierID"); supplierID != "" {
+		values.Set("SupplierID", supplierID)
+	}
+
+	h.renderAddPurchaseOrderPage(w, r, &stockview.AddPurchaseOrderPageProps{
+		Values: values,
+	})
+}
+
+type postPurchaseOrderFormData struct {
+	SupplierID int
+	Reference  string
+	OrderDate  time.Time
+	Note       string
+}
+
+func (fd *postPurchaseOrderFormData) normalise() {
+	fd.Reference = strings.ToUpper(strings.TrimSpace(fd.Reference))
+	fd.Note = strings.TrimSpace(fd.Note)
+}
+
+func (h *PurchaseOrderHandler) AddPurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	if !ctx.User.Permissions.SupplyChain.Admin {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	err := r.ParseForm()
+	if err != nil {
+		http.Error(w, "Error parsing form", http.StatusBadRequest)
+		return
+	}
+
+	var fd postPurchaseOrderFormData
+	err = appurl.Unmarshal(r.Form, &fd)
+	if err != nil {
+		http.Error(w, "Error decoding form", http.StatusBadRequest)
+		return
+	}
+
+	fd.normalise()
+
+	purchaseOrderID, validationErrors, err := h.purchaseOrderService.CreatePurchaseOrder(
+		r.Context(),
+		&model.NewPurchaseOrder{
+			SupplierID: fd.SupplierID,
+			Reference:  fd.Reference,
+			OrderDate:  fd.OrderDate,
+			Note:       fd.Note,
+		},
+		ctx.User.UserID,
+	)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Error adding purchase order", http.StatusInternalServerError)
+		return
+	}
+
+	if len(validationErrors) > 0 {
+		h.renderAddPurchaseOrderPage(w, r, &stockview.AddPurchaseOrderPageProps{
+			Values:           r.Form,
+			ValidationErrors: validationErrors,
+			IsSubmission:     true,
+		})
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/stock/purchase-orders/%d", purchaseOrderID), http.StatusSeeOther)
+}
+
+func (h *PurchaseOrderHandler) PurchaseOrderPage(w http.ResponseWriter, r *http.Request) {
+
+	purchaseOrderID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid purchase order ID", http.StatusBadRequest)
+		return
+	}
+
+	h.renderPurchaseOrderPage(w, r, purchaseOrderID, &stockview.PurchaseOrderPageProps{})
+}
+
+type postPurchaseOrderLineFormData struct {
+	StockItemID int
+	Qty         decimal.Decimal
+	DueDate     time.Time
+	UnitPrice   *decimal.Decimal
+}
+
+func (h *PurchaseOrderHandler) AddPurchaseOrderLine(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	if !ctx.User.Permissions.SupplyChain.Admin {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	purchaseOrderID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid purchase order ID", http.StatusBadRequest)
+		return
+	}
+
+	err = r.ParseForm()
+	if err != nil {
+		http.Error(w, "Error parsing form", http.StatusBadRequest)
+		return
+	}
+
+	var fd postPurchaseOrderLineFormData
+	err = appurl.Unmarshal(r.Form, &fd)
+	if err != nil {
+		http.Error(w, "Error decoding form", http.StatusBadRequest)
+		return
+	}
+
+	validationErrors, err := h.purchaseOrderService.AddPurchaseOrderLine(
+		r.Context(),
+		purchaseOrderID,
+		&model.NewPurchaseOrderLine{
+			StockItemID: fd.StockItemID,
+			Qty:         fd.Qty,
+			DueDate:     fd.DueDate,
+			UnitPrice:   fd.UnitPrice,
+		},
+	)
+	if err != nil {
+		h.renderPurchaseOrderPage(w, r, purchaseOrderID, &stockview.PurchaseOrderPageProps{
+			LineValues: r.Form,
+			ErrorText:  fmt.Sprintf("Error adding line: %v", err),
+		})
+		return
+	}
+
+	if len(validationErrors) > 0 {
+		h.renderPurchaseOrderPage(w, r, purchaseOrderID, &stockview.PurchaseOrderPageProps{
+			LineValues:           r.Form,
+			LineValidationErrors: validationErrors,
+			IsLineSubmission:     true,
+		})
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/stock/purchase-orders/%d", purchaseOrderID), http.StatusSeeOther)
+}
+
+func (h *PurchaseOrderHandler) DeletePurchaseOrderLine(w http.ResponseWriter, r *http.Request) {
+	h.purchaseOrderLineAction(w, r, "removing line", h.purchaseOrderService.DeletePurchaseOrderLine)
+}
+
+func (h *PurchaseOrderHandler) ClosePurchaseOrderLine(w http.ResponseWriter, r *http.Request) {
+	h.purchaseOrderLineAction(w, r, "closing line", h.purchaseOrderService.ClosePurchaseOrderLine)
+}
+
+func (h *PurchaseOrderHandler) IssuePurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	h.purchaseOrderAction(w, r, "issuing purchase order", h.purchaseOrderService.IssuePurchaseOrder)
+}
+
+func (h *PurchaseOrderHandler) ClosePurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	h.purchaseOrderAction(w, r, "closing purchase order", h.purchaseOrderService.ClosePurchaseOrder)
+}
+
+func (h *PurchaseOrderHandler) CancelPurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	h.purchaseOrderAction(w, r, "cancelling purchase order", h.purchaseOrderService.CancelPurchaseOrder)
+}
+
+// purchaseOrderAction runs a status change on the order in the path, showing
+// any error on the purchase order page
+func (h *PurchaseOrderHandler) purchaseOrderAction(
+	w http.ResponseWriter,
+	r *http.Request,
+	description string,
+	action func(ctx context.Context, purchaseOrderID int, userID int) error,
+) {
+	ctx := reqcontext.GetContext(r)
+
+	if !ctx.User.Permissions.SupplyChain.Admin {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	purchaseOrderID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid purchase order ID", http.StatusBadRequest)
+		return
+	}
+
+	err = action(r.Context(), purchaseOrderID, ctx.User.UserID)
+	if err != nil {
+		h.renderPurchaseOrderPage(w, r, purchaseOrderID, &stockview.PurchaseOrderPageProps{
+			ErrorText: fmt.Sprintf("Error %s: %v", description, err),
+		})
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/stock/purchase-orders/%d", purchaseOrderID), http.StatusSeeOther)
+}
+
+// purchaseOrderLineAction runs a change to the line in the path, showing any
+// error on the purchase order page
+func (h *PurchaseOrderHandler) purchaseOrderLineAction(
+	w http.ResponseWriter,
+	r *http.Request,
+	description string,
+	action func(ctx context.Context, purchaseOrderID int, purchaseOrderLineID int) error,
+) {
+	ctx := reqcontext.GetContext(r)
+
+	if !ctx.User.Permissions.SupplyChain.Admin {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	purchaseOrderID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid purchase order ID", http.StatusBadRequest)
+		return
+	}
+
+	purchaseOrderLineID, err := strconv.Atoi(r.PathValue("lineID"))
+	if err != nil {
+		http.Error(w, "Invalid purchase order line ID", http.StatusBadRequest)
+		return
+	}
+
+	err = action(r.Context(), purchaseOrderID, purchaseOrderLineID)
+	if err != nil {
+		h.renderPurchaseOrderPage(w, r, purchaseOrderID, &stockview.PurchaseOrderPageProps{
+			ErrorText: fmt.Sprintf("Error %s: %v", description, err),
+		})
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/stock/purchase-orders/%d", purchaseOrderID), http.StatusSeeOther)
+}
+
+func (h *PurchaseOrderHandler) ReceivePurchaseOrderPage(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	if !ctx.User.Permissions.SupplyChain.Admin {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	purchaseOrderID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid purchase order ID", http.StatusBadRequest)
+		return
+	}
+
+	values := url.Values{}
+	if lineID := r.URL.Query().Get("PurchaseOrderLineID"); lineID != "" {
+		values.Set("PurchaseOrderLineID", lineID)
+	}
+
+	h.renderReceivePurchaseOrderPage(w, r, purchaseOrderID, &stockview.ReceivePurchaseOrderPageProps{
+		Values: values,
+	})
+}
+
+type receivePurchaseOrderLineFormData struct {
+	PurchaseOrderLineID int
+	Qty                 decimal.Decimal
+	Location            string
+	Bin                 string
+	LotNumber           string
+	SerialNumbers       string
+	TransactionNote     string
+}
+
+func (fd *receivePurchaseOrderLineFormData) normalise() {
+
+	// trim and uppercase
+	fd.Location = strings.ToUpper(strings.TrimSpace(fd.Location))
+	fd.Bin = strings.ToUpper(strings.TrimSpace(fd.Bin))
+	fd.LotNumber = strings.ToUpper(strings.TrimSpace(fd.LotNumber))
+
+	// trim
+	fd.TransactionNote = strings.TrimSpace(fd.TransactionNote)
+}
+
+func (h *PurchaseOrderHandler) ReceivePurchaseOrderLine(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	if !ctx.User.Permissions.SupplyChain.Admin {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	purchaseOrderID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid purchase order ID", http.StatusBadRequest)
+		return
+	}
+
+	err = r.ParseForm()
+	if err != nil {
+		http.Error(w, "Error parsing form", http.StatusBadRequest)
+		return
+	}
+
+	var fd receivePurchaseOrderLineFormData
+	err = appurl.Unmarshal(r.Form, &fd)
+	if err != nil {
+		http.Error(w, "Error decoding form", http.StatusBadRequest)
+		return
+	}
+
+	fd.normalise()
+
+	validationErrors, err := h.purchaseOrderService.ReceivePurchaseOrderLine(
+		r.Context(),
+		purchaseOrderID,
+		&model.ReceivePurchaseOrderLineInput{
+			PurchaseOrderLineID: fd.PurchaseOrderLineID,
+			Qty:                 fd.Qty,
+			Location:            fd.Location,
+			Bin:                 fd.Bin,
+			LotNumber:           fd.LotNumber,
+			SerialNumbers:       splitSerialNumbers(fd.SerialNumbers),
+			TransactionNote:     fd.TransactionNote,
+		},
+		ctx.User.UserID,
+	)
+	if err != nil {
+		h.renderReceivePurchaseOrderPage(w, r, purchaseOrderID, &stockview.ReceivePurchaseOrderPageProps{
+			Values:    r.Form,
+			ErrorText: fmt.Sprintf("Error receiving stock: %v", err),
+		})
+		return
+	}
+
+	if len(validationErrors) > 0 {
+		h.renderReceivePurchaseOrderPage(w, r, purchaseOrderID, &stockview.ReceivePurchaseOrderPageProps{
+			Values:           r.Form,
+			ValidationErrors: validationErrors,
+			IsSubmission:     true,
+		})
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/stock/purchase-orders/%d", purchaseOrderID), http.StatusSeeOther)
+}
+
+func (h *PurchaseOrderHandler) renderSuppliersPage(
+	w http.ResponseWriter,
+	r *http.Request,
+	props *stockview.SuppliersPageProps,
+) {
+	ctx := reqcontext.GetContext(r)
+
+	type urlVals struct {
+		SearchText   string
+		ShowArchived bool
+		Page         int
+		PageSize     int
+	}
+
+	var uv urlVals
+
+	err := appurl.Unmarshal(r.URL.Query(), &uv)
+	if err != nil {
+		http.Error(w, "Error decoding url values", http.StatusBadRequest)
+		return
+	}
+
+	uv.SearchText = strings.TrimSpace(uv.SearchText)
+
+	if uv.Page == 0 {
+		uv.Page = 1
+	}
+	if uv.PageSize == 0 {
+		uv.PageSize = 50
+	}
+
+	suppliers, count, err := h.purchaseOrderService.GetSuppliers(r.Context(), &model.GetSuppliersQuery{
+		SearchText:   uv.SearchText,
+		ShowArchived: uv.ShowArchived,
+		Page:         uv.Page,
+		PageSize:     uv.PageSize,
+	})
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Error fetching suppliers", http.StatusInternalServerError)
+		return
+	}
+
+	if props.Values == nil {
+		props.Values = url.Values{}
+	}
+	if props.ValidationErrors == nil {
+		props.ValidationErrors = validate.ValidationErrors{}
+	}
+
+	props.Ctx = ctx
+	props.Suppliers = suppliers
+	props.SuppliersCount = count
+	props.SearchText = uv.SearchText
+	props.ShowArchived = uv.ShowArchived
+	props.Page = uv.Page
+	props.PageSize = uv.PageSize
+
+	_ = stockview.SuppliersPage(props).Render(w)
+}
+
+func (h *PurchaseOrderHandler) renderSupplierPage(
+	w http.ResponseWriter,
+	r *http.Request,
+	supplierID int,
+	props *stockview.SupplierPageProps,
+) {
+	ctx := reqcontext.GetContext(r)
+
+	supplier, err := h.purchaseOrderService.GetSupplier(r.Context(), supplierID)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Error fetching supplier", http.StatusInternalServerError)
+		return
+	}
+	if supplier == nil {
+		http.Error(w, "Supplier not found", http.StatusNotFound)
+		return
+	}
+
+	if props.Values == nil {
+		props.Values = url.Values{}
+		props.Values.Set("Name", supplier.Name)
+		props.Values.Set("Email", supplier.Email)
+		props.Values.Set("Phone", supplier.Phone)
+		props.Values.Set("OverReceiptTolerance", supplier.OverReceiptTolerance.String())
+		if supplier.IsArchived {
+			props.Values.Set("IsArchived", "true")
+		}
+	}
+	if props.ValidationErrors == nil {
+		props.ValidationErrors = validate.ValidationErrors{}
+	}
+
+	props.Ctx = ctx
+	props.Supplier = *supplier
+
+	_ = stockview.SupplierPage(props).Render(w)
+}
+
+func (h *PurchaseOrderHandler) renderAddPurchaseOrderPage(
+	w http.ResponseWriter,
+	r *http.Request,
+	props *stockview.AddPurchaseOrderPageProps,
+) {
+	ctx := reqcontext.GetContext(r)
+
+	suppliers, _, err := h.purchaseOrderService.GetSuppliers(r.Context(), &model.GetSuppliersQuery{
+		Page:     1,
+		PageSize: 10000,
+	})
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Error fetching suppliers", http.StatusInternalServerError)
+		return
+	}
+
+	if props.ValidationErrors == nil {
+		props.ValidationErrors = validate.ValidationErrors{}
+	}
+
+	props.Ctx = ctx
+	props.Suppliers = suppliers
+
+	_ = stockview.AddPurchaseOrderPage(props).Render(w)
+}
+
+// renderPurchaseOrderPage loads the order, its lines and receipts and renders
+// the detail page. Form state and errors are taken from props.
+func (h *PurchaseOrderHandler) renderPurchaseOrderPage(
+	w http.ResponseWriter,
+	r *http.Request,
+	purchaseOrderID int,
+	props *stockview.PurchaseOrderPageProps,
+) {
+	ctx := reqcontext.GetContext(r)
+
+	purchaseOrder, err := h.purchaseOrderService.GetPurchaseOrder(r.Context(), purchaseOrderID)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Error fetching purchase order", http.StatusInternalServerError)
+		return
+	}
+	if purchaseOrder == nil {
+		http.Error(w, "Purchase order not found", http.StatusNotFound)
+		return
+	}
+
+	lines, err := h.purchaseOrderService.GetPurchaseOrderLines(r.Context(), purchaseOrderID)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Error fetching purchase order lines", http.StatusInternalServerError)
+		return
+	}
+
+	receipts, err := h.purchaseOrderService.GetPurchaseOrderReceipts(r.Context(), purchaseOrderID)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Error fetching purchase order receipts", http.StatusInternalServerError)
+		return
+	}
+
+	canEdit := ctx.User.Permissions.SupplyChain.Admin
+	canAddLines := purchaseOrder.Status == model.DraftPurchaseOrderStatus ||
+		purchaseOrder.Status == model.OpenPurchaseOrderStatus
+
+	var stockItems []model.StockItem
+	if canEdit && canAddLines {
+		stockItems, _, err = h.stockItemService.GetStockItems(r.Context(), &model.GetStockItemsQuery{
+			Page: 1, PageSize: 10000,
+		}, ctx.User.UserID)
+		if err != nil {
+			log.Println(err)
+			http.Error(w, "Error fetching stock items", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if props.LineValues == nil {
+		props.LineValues = url.Values{}
+		props.LineValues.Set("DueDate", time.Now().Format("2006-01-02"))
+	}
+	if props.LineValidationErrors == nil {
+		props.LineValidationErrors = validate.ValidationErrors{}
+	}
+
+	props.Ctx = ctx
+	props.PurchaseOrder = *purchaseOrder
+	props.Lines = lines
+	props.Receipts = receipts
+	props.StockItems = stockItems
+	props.CanEdit = canEdit
+
+	_ = stockview.PurchaseOrderPage(props).Render(w)
+}
+
+// renderReceivePurchaseOrderPage renders the goods receipt form for the lines
+// of an open order that can still be received against
+func (h *PurchaseOrderHandler) renderReceivePurchaseOrderPage(
+	w http.ResponseWriter,
+	r *http.Request,
+	purchaseOrderID int,
+	props *stockview.ReceivePurchaseOrderPageProps,
+) {
+	ctx := reqcontext.GetContext(r)
+
+	purchaseOrder, err := h.purchaseOrderService.GetPurchaseOrder(r.Context(), purchaseOrderID)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Error fetching purchase order", http.StatusInternalServerError)
+		return
+	}
+	if purchaseOrder == nil {
+		http.Error(w, "Purchase order not found", http.StatusNotFound)
+		return
+	}
+
+	lines, err := h.purchaseOrderService.GetPurchaseOrderLines(r.Context(), purchaseOrderID)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Error fetching purchase order lines", http.StatusInternalServerError)
+		return
+	}
+
+	if props.Values == nil {
+		props.Values = url.Values{}
+	}
+	if props.ValidationErrors == nil {
+		props.ValidationErrors = validate.ValidationErrors{}
+	}
+
+	props.Ctx = ctx
+	props.PurchaseOrder = *purchaseOrder
+	props.Lines = lines
+
+	_ = stockview.ReceivePurchaseOrderPage(props).Render(w)
+}
@@ -0,0 +1,1056 @@
+package handler
+
+import (
+	"app/internal/model"
+	"app/internal/pdftemplate"
+	"app/internal/service"
+	"app/internal/views/stockview"
+	"app/pkg/appurl"
+	"app/pkg/nilsafe"
+	"app/pkg/reqcontext"
+	"app/pkg/validate"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type SalesOrderHandler struct {
+	salesOrderService service.SalesOrderService
+	stockItemService  service.StockItemService
+	pdfService        service.PDFService
+}
+
+func NewSalesOrderHandler(
+	salesOrderService service.SalesOrderService,
+	stockItemService service.StockItemService,
+	pdfService service.PDFService,
+) *SalesOrderHandler {
+	return &SalesOrderHandler{
+		salesOrderService: salesOrderService,
+		stockItemService:  stockItemService,
+		pdfService:        pdfService,
+	}
+}
+
+func (h *SalesOrderHandler) CustomersPage(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	if !ctx.User.Permissions.SupplyChain.Admin {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	h.renderCustomersPage(w, r, &stockview.CustomersPageProps{})
+}
+
+type postCustomerFormData struct {
+	Code            string
+	Name            string
+	Email           string
+	Phone           string
+	DeliveryAddress string
+	IsArchived      bool
+}
+
+func (fd *postCustomerFormData) normalise() {
+	fd.Code = strings.ToUpper(strings.TrimSpace(fd.Code))
+	fd.Name = strings.TrimSpace(fd.Name)
+	fd.Email = strings.TrimSpace(fd.Email)
+	fd.Phone = strings.TrimSpace(fd.Phone)
+	fd.DeliveryAddress = strings.TrimSpace(fd.DeliveryAddress)
+}
+
+func (h *SalesOrderHandler) CreateCustomer(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	if !ctx.User.Permissions.SupplyChain.Admin {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	err := r.ParseForm()
+	if err != nil {
+		http.Error(w, "Error parsing form", http.StatusBadRequest)
+		return
+	}
+
+	var fd postCustomerFormData
+	err = appurl.Unmarshal(r.Form, &fd)
+	if err != nil {
+		http.Error(w, "Error decoding form", http.StatusBadRequest)
+		return
+	}
+
+	fd.normalise()
+
+	customerID, validationErrors, err := h.salesOrderService.CreateCustomer(
+		r.Context(),
+		&model.NewCustomer{
+			Code:            fd.Code,
+			Name:            fd.Name,
+			Email:           fd.Email,
+			Phone:           fd.Phone,
+			DeliveryAddress: fd.DeliveryAddress,
+		},
+		ctx.User.UserID,
+	)
+	if err != nil {
+		h.renderCustomersPage(w, r, &stockview.CustomersPageProps{
+			Values:    r.Form,
+			ErrorText: fmt.Sprintf("Error adding customer: %v", err),
+		})
+		return
+	}
+
+	if len(validationErrors) > 0 {
+		h.renderCustomersPage(w, r, &stockview.CustomersPageProps{
+			Values:           r.Form,
+			ValidationErrors: validationErrors,
+			IsSubmission:     true,
+		})
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/stock/customers/%d", customerID), http.StatusSeeOther)
+}
+
+func (h *SalesOrderHandler) CustomerPage(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	if !ctx.User.Permissions.SupplyChain.Admin {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	customerID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid customer ID", http.StatusBadRequest)
+		return
+	}
+
+	h.renderCustomerPage(w, r, customerID, &stockview.CustomerPageProps{})
+}
+
+func (h *SalesOrderHandler) UpdateCustomer(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	if !ctx.User.Permissions.SupplyChain.Admin {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	customerID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid customer ID", http.StatusBadRequest)
+		return
+	}
+
+	err = r.ParseForm()
+	if err != nil {
+		http.Error(w, "Error parsing form", http.StatusBadRequest)
+		return
+	}
+
+	var fd postCustomerFormData
+	err = appurl.Unmarshal(r.Form, &fd)
+	if err != nil {
+		http.Error(w, "Error decoding form", http.StatusBadRequest)
+		return
+	}
+
+	fd.normalise()
+
+	validationErrors, err := h.salesOrderService.UpdateCustomer(
+		r.Context(),
+		customerID,
+		&model.CustomerUpdate{
+			Name:            fd.Name,
+			Email:           fd.Email,
+			Phone:           fd.Phone,
+			DeliveryAddress: fd.DeliveryAddress,
+			IsArchived:      fd.IsArchived,
+		},
+		ctx.User.UserID,
+	)
+	if err != nil {
+		h.renderCustomerPage(w, r, customerID, &stockview.CustomerPageProps{
+			Values:    r.Form,
+			ErrorText: fmt.Sprintf("Error updating customer: %v", err),
+		})
+		return
+	}
+
+	if len(validationErrors) > 0 {
+		h.renderCustomerPage(w, r, customerID, &stockview.CustomerPageProps{
+			Values:           r.Form,
+			ValidationErrors: validationErrors,
+			IsSubmission:     true,
+		})
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/stock/customers/%d", customerID), http.StatusSeeOther)
+}
+
+func (h *SalesOrderHandler) SalesOrdersPage(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	type urlVals struct {
+		Status     string
+		CustomerID int
+		StockCode  string
+		Page       int
+		PageSize   int
+	}
+
+	var uv urlVals
+
+	err := appurl.Unmarshal(r.URL.Query(), &uv)
+	if err != nil {
+		http.Error(w, "Error decoding url values", http.StatusBadRequest)
+		return
+	}
+
+	uv.StockCode = strings.ToUpper(strings.TrimSpace(uv.StockCode))
+
+	if uv.Page == 0 {
+		uv.Page = 1
+	}
+	if uv.PageSize == 0 {
+		uv.PageSize = 50
+	}
+
+	salesOrders, count, err := h.salesOrderService.GetSalesOrders(r.Context(), &model.GetSalesOrdersQuery{
+		Status:     model.SalesOrderStatus(uv.Status),
+		CustomerID: uv.CustomerID,
+		StockCode:  uv.StockCode,
+		Page:       uv.Page,
+		PageSize:   uv.PageSize,
+	})
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Error fetching sales orders", http.StatusInternalServerError)
+		return
+	}
+
+	customers, _, err := h.salesOrderService.GetCustomers(r.Context(), &model.GetCustomersQuery{
+		ShowArchived: true,
+		Page:         1,
+		PageSize:     10000,
+	})
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Error fetching customers", http.StatusInternalServerError)
+		return
+	}
+
+	_ = stockview.SalesOrdersPage(&stockview.SalesOrdersPageProps{
+		Ctx:              ctx,
+		SalesOrders:      salesOrders,
+		SalesOrdersCount: count,
+		Customers:        customers,
+		Status:           uv.Status,
+		CustomerID:       uv.CustomerID,
+		StockCode:        uv.StockCode,
+		Page:             uv.Page,
+		PageSize:         uv.PageSize,
+	}).Render(w)
+}
+
+func (h *SalesOrderHandler) AddSalesOrderPage(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	if !ctx.User.Permissions.SupplyChain.Admin {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	values := url.Values{}
+	values.Set("OrderDate", time.Now().Format("2006-01-02"))
+	if customerID := r.URL.Query().Get("CustomerID"); customerID != "" {
+		values.Set("CustomerID", customerID)
+	}
+
+	h.renderAddSalesOrderPage(w, r, &stockview.AddSalesOrderPageProps{
+		Values: values,
+	})
+}
+
+type postSalesOrderFormData struct {
+	CustomerID        int
+	Reference         string
+	CustomerReference string
+	OrderDate         time.Time
+	Note              string
+}
+
+func (fd *postSalesOrderFormData) normalise() {
+	fd.Reference = strings.ToUpper(strings.TrimSpace(fd.Reference))
+	fd.CustomerReference = strings.TrimSpace(fd.CustomerReference)
+	fd.Note = strings.TrimSpace(fd.Note)
+}
+
+func (h *SalesOrderHandler) AddSalesOrder(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	if !ctx.User.Permissions.SupplyChain.Admin {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	err := r.ParseForm()
+	if err != nil {
+		http.Error(w, "Error parsing form", http.StatusBadRequest)
+		return
+	}
+
+	var fd postSalesOrderFormData
+	err = appurl.Unmarshal(r.Form, &fd)
+	if err != nil {
+		http.Error(w, "Error decoding form", http.StatusBadRequest)
+		return
+	}
+
+	fd.normalise()
+
+	salesOrderID, validationErrors, err := h.salesOrderService.CreateSalesOrder(
+		r.Context(),
+		&model.NewSalesOrder{
+			CustomerID:        fd.CustomerID,
+			Reference:         fd.Reference,
+			CustomerReference: fd.CustomerReference,
+			OrderDate:         fd.OrderDate,
+			Note:              fd.Note,
+		},
+		ctx.User.UserID,
+	)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Error adding sales order", http.StatusInternalServerError)
+		return
+	}
+
+	if len(validationErrors) > 0 {
+		h.renderAddSalesOrderPage(w, r, &stockview.AddSalesOrderPageProps{
+			Values:           r.Form,
+			ValidationErrors: validationErrors,
+			IsSubmission:     true,
+		})
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/stock/sales-orders/%d", salesOrderID), http.StatusSeeOther)
+}
+
+func (h *SalesOrderHandler) SalesOrderPage(w http.ResponseWriter, r *http.Request) {
+
+	salesOrderID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid sales order ID", http.StatusBadRequest)
+		return
+	}
+
+	h.renderSalesOrderPage(w, r, salesOrderID, &stockview.SalesOrderPageProps{})
+}
+
+type postSalesOrderLineFormData struct {
+	StockItemID int
+	Qty         decimal.Decimal
+	DueDate     time.Time
+	UnitPrice   *decimal.Decimal
+}
+
+func (h *SalesOrderHandler) AddSalesOrderLine(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	if !ctx.User.Permissions.SupplyChain.Admin {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	salesOrderID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid sales order ID", http.StatusBadRequest)
+		return
+	}
+
+	err = r.ParseForm()
+	if err != nil {
+		http.Error(w, "Error parsing form", http.StatusBadRequest)
+		return
+	}
+
+	var fd postSalesOrderLineFormData
+	err = appurl.Unmarshal(r.Form, &fd)
+	if err != nil {
+		http.Error(w, "Error decoding form", http.StatusBadRequest)
+		return
+	}
+
+	validationErrors, err := h.salesOrderService.AddSalesOrderLine(
+		r.Context(),
+		salesOrderID,
+		&model.NewSalesOrderLine{
+			StockItemID: fd.StockItemID,
+			Qty:         fd.Qty,
+			DueDate:     fd.DueDate,
+			UnitPrice:   fd.UnitPrice,
+		},
+	)
+	if err != nil {
+		h.renderSalesOrderPage(w, r, salesOrderID, &stockview.SalesOrderPageProps{
+			LineValues: r.Form,
+			ErrorText:  fmt.Sprintf("Error adding line: %v", err),
+		})
+		return
+	}
+
+	if len(validationErrors) > 0 {
+		h.renderSalesOrderPage(w, r, salesOrderID, &stockview.SalesOrderPageProps{
+			LineValues:           r.Form,
+			LineValidationErrors: validationErrors,
+			IsLineSubmission:     true,
+		})
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/stock/sales-orders/%d", salesOrderID), http.StatusSeeOther)
+}
+
+func (h *SalesOrderHandler) DeleteSalesOrderLine(w http.ResponseWriter, r *http.Request) {
+	h.salesOrderLineAction(w, r, "removing line", h.salesOrderService.DeleteSalesOrderLine)
+}
+
+func (h *SalesOrderHandler) CloseSalesOrderLine(w http.ResponseWriter, r *http.Request) {
+	h.salesOrderLineAction(w, r, "closing line", h.salesOrderService.CloseSalesOrderLine)
+}
+
+func (h *SalesOrderHandler) IssueSalesOrder(w http.ResponseWriter, r *http.Request) {
+	h.salesOrderAction(w, r, "issuing sales order", h.salesOrderService.IssueSalesOrder)
+}
+
+func (h *SalesOrderHandler) CloseSalesOrder(w http.ResponseWriter, r *http.Request) {
+	h.salesOrderAction(w, r, "closing sales order", h.salesOrderService.CloseSalesOrder)
+}
+
+func (h *SalesOrderHandler) CancelSalesOrder(w http.ResponseWriter, r *http.Request) {
+	h.salesOrderAction(w, r, "cancelling sales order", h.salesOrderService.CancelSalesOrder)
+}
+
+func (h *SalesOrderHandler) GeneratePickList(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	if !ctx.User.Permissions.SupplyChain.Admin {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	salesOrderID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid sales order ID", http.StatusBadRequest)
+		return
+	}
+
+	pickListID, err := h.salesOrderService.GeneratePickList(r.Context(), salesOrderID, ctx.User.UserID)
+	if err != nil {
+		h.renderSalesOrderPage(w, r, salesOrderID, &stockview.SalesOrderPageProps{
+			ErrorText: fmt.Sprintf("Error generating pick list: %v", err),
+		})
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/stock/pick-lists/%d", pickListID), http.StatusSeeOther)
+}
+
+// salesOrderAction runs a status change on the order in the path, showing
+// any error on the sales order page
+func (h *SalesOrderHandler) salesOrderAction(
+	w http.ResponseWriter,
+	r *http.Request,
+	description string,
+	action func(ctx context.Context, salesOrderID int, userID int) error,
+) {
+	ctx := reqcontext.GetContext(r)
+
+	if !ctx.User.Permissions.SupplyChain.Admin {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	salesOrderID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid sales order ID", http.StatusBadRequest)
+		return
+	}
+
+	err = action(r.Context(), salesOrderID, ctx.User.UserID)
+	if err != nil {
+		h.renderSalesOrderPage(w, r, salesOrderID, &stockview.SalesOrderPageProps{
+			ErrorText: fmt.Sprintf("Error %s: %v", description, err),
+		})
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/stock/sales-orders/%d", salesOrderID), http.StatusSeeOther)
+}
+
+// salesOrderLineAction runs a change to the line in the path, showing any
+// error on the sales order page
+func (h *SalesOrderHandler) salesOrderLineAction(
+	w http.ResponseWriter,
+	r *http.Request,
+	description string,
+	action func(ctx context.Context, salesOrderID int, salesOrderLineID int) error,
+) {
+	ctx := reqcontext.GetContext(r)
+
+	if !ctx.User.Permissions.SupplyChain.Admin {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	salesOrderID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid sales order ID", http.StatusBadRequest)
+		return
+	}
+
+	salesOrderLineID, err := strconv.Atoi(r.PathValue("lineID"))
+	if err != nil {
+		http.Error(w, "Invalid sales order line ID", http.StatusBadRequest)
+		return
+	}
+
+	err = action(r.Context(), salesOrderID, salesOrderLineID)
+	if err != nil {
+		h.renderSalesOrderPage(w, r, salesOrderID, &stockview.SalesOrderPageProps{
+			ErrorText: fmt.Sprintf("Error %s: %v", description, err),
+		})
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/stock/sales-orders/%d", salesOrderID), http.StatusSeeOther)
+}
+
+func (h *SalesOrderHandler) PickListPage(w http.ResponseWriter, r *http.Request) {
+
+	pickListID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid pick list ID", http.StatusBadRequest)
+		return
+	}
+
+	h.renderPickListPage(w, r, pickListID, &stockview.PickListPageProps{})
+}
+
+type dispatchPickListFormData struct {
+	PickListLineID           []int
+	Qty                      []decimal.Decimal
+	SerialNumbers            []string
+	TransactionNote          string
+	AcknowledgeNegativeStock bool
+}
+
+func (fd *dispatchPickListFormData) normalise() {
+	fd.TransactionNote = strings.TrimSpace(fd.TransactionNote)
+}
+
+func (h *SalesOrderHandler) DispatchPickList(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	if !ctx.User.Permissions.SupplyChain.Admin {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	pickListID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid pick list ID", http.StatusBadRequest)
+		return
+	}
+
+	err = r.ParseForm()
+	if err != nil {
+		http.Error(w, "Error parsing form", http.StatusBadRequest)
+		return
+	}
+
+	var fd dispatchPickListFormData
+	err = appurl.Unmarshal(r.Form, &fd)
+	if err != nil {
+		http.Error(w, "Error decoding form", http.StatusBadRequest)
+		return
+	}
+
+	fd.normalise()
+
+	if len(fd.Qty) != len(fd.PickListLineID) || len(fd.SerialNumbers) != len(fd.PickListLineID) {
+		http.Error(w, "Error decoding form", http.StatusBadRequest)
+		return
+	}
+
+	input := &model.DispatchPickListInput{
+		TransactionNote:          fd.TransactionNote,
+		AcknowledgeNegativeStock: fd.AcknowledgeNegativeStock,
+	}
+	for i, pickListLineID := range fd.PickListLineID {
+		input.Lines = append(input.Lines, model.DispatchPickListLineInput{
+			PickListLineID: pickListLineID,
+			Qty:            fd.Qty[i],
+			SerialNumbers:  splitSerialNumbers(fd.SerialNumbers[i]),
+		})
+	}
+
+	err = h.salesOrderService.DispatchPickList(r.Context(), pickListID, input, ctx.User.UserID)
+	if err != nil {
+		var negativeStockErr *service.NegativeStockError
+		h.renderPickListPage(w, r, pickListID, &stockview.PickListPageProps{
+			Values:               r.Form,
+			ErrorText:            fmt.Sprintf("Error dispatching pick list: %v", err),
+			NegativeStockWarning: errors.As(err, &negativeStockErr) && negativeStockErr.IsWarning(),
+		})
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/stock/pick-lists/%d", pickListID), http.StatusSeeOther)
+}
+
+func (h *SalesOrderHandler) CancelPickList(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	if !ctx.User.Permissions.SupplyChain.Admin {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	pickListID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid pick list ID", http.StatusBadRequest)
+		return
+	}
+
+	err = h.salesOrderService.CancelPickList(r.Context(), pickListID, ctx.User.UserID)
+	if err != nil {
+		h.renderPickListPage(w, r, pickListID, &stockview.PickListPageProps{
+			ErrorText: fmt.Sprintf("Error cancelling pick list: %v", err),
+		})
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/stock/pick-lists/%d", pickListID), http.StatusSeeOther)
+}
+
+func (h *SalesOrderHandler) PickListPDF(w http.ResponseWriter, r *http.Request) {
+
+	pickList, salesOrder, lines, ok := h.getPickListForPDF(w, r)
+	if !ok {
+		return
+	}
+
+	data := pdftemplate.PickListData{
+		PickListID:          pickList.PickListID,
+		SalesOrderReference: salesOrder.Reference,
+		CustomerName:        salesOrder.CustomerName,
+		CustomerReference:   salesOrder.CustomerReference,
+		CreatedBy:           nilsafe.Str(pickList.CreatedByUsername),
+		CreatedAt:           pickList.CreatedAt,
+	}
+	for _, l := range lines {
+		data.Lines = append(data.Lines, pdftemplate.PickListLine{
+			StockCode:   l.StockCode,
+			Description: l.Description,
+			Location:    l.Location,
+			Bin:         l.Bin,
+			LotNumber:   l.LotNumber,
+			Qty:         l.Qty,
+			Unit:        l.Unit,
+		})
+	}
+
+	h.writePDF(w, r, pdftemplate.PickListTemplateDefinition.Name, data)
+}
+
+func (h *SalesOrderHandler) DispatchNotePDF(w http.ResponseWriter, r *http.Request) {
+
+	pickList, salesOrder, lines, ok := h.getPickListForPDF(w, r)
+	if !ok {
+		return
+	}
+	if pickList.Status != model.DispatchedPickListStatus || pickList.DispatchedAt == nil {
+		http.Error(w, "Pick list has not been dispatched", http.StatusBadRequest)
+		return
+	}
+
+	data := pdftemplate.DispatchNoteData{
+		PickListID:          pickList.PickListID,
+		SalesOrderReference: salesOrder.Reference,
+		CustomerName:        salesOrder.CustomerName,
+		CustomerReference:   salesOrder.CustomerReference,
+		DeliveryAddress:     salesOrder.DeliveryAddress,
+		DispatchedAt:        *pickList.DispatchedAt,
+	}
+	for _, l := range lines {
+		if l.DispatchedQty == nil || l.DispatchedQty.IsZero() {
+			continue
+		}
+		data.Lines = append(data.Lines, pdftemplate.DispatchNoteLine{
+			StockCode:     l.StockCode,
+			Description:   l.Description,
+			LotNumber:     l.LotNumber,
+			Qty:           *l.DispatchedQty,
+			Unit:          l.Unit,
+			SerialNumbers: l.SerialNumbers,
+		})
+	}
+
+	h.writePDF(w, r, pdftemplate.DispatchNoteTemplateDefinition.Name, data)
+}
+
+// getPickListForPDF loads a pick list with its order and lines, writing an
+// error response and returning false if it cannot
+func (h *SalesOrderHandler) getPickListForPDF(
+	w http.ResponseWriter,
+	r *http.Request,
+) (*model.PickList, *model.SalesOrder, []model.PickListLine, bool) {
+
+	pickListID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid pick list ID", http.StatusBadRequest)
+		return nil, nil, nil, false
+	}
+
+	pickList, err := h.salesOrderService.GetPickList(r.Context(), pickListID)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Error fetching pick list", http.StatusInternalServerError)
+		return nil, nil, nil, false
+	}
+	if pickList == nil {
+		http.Error(w, "Pick list not found", http.StatusNotFound)
+		return nil, nil, nil, false
+	}
+
+	salesOrder, err := h.salesOrderService.GetSalesOrder(r.Context(), pickList.SalesOrderID)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Error fetching sales order", http.StatusInternalServerError)
+		return nil, nil, nil, false
+	}
+
+	lines, err := h.salesOrderService.GetPickListLines(r.Context(), pickListID)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Error fetching pick list lines", http.StatusInternalServerError)
+		return nil, nil, nil, false
+	}
+
+	return pickList, salesOrder, lines, true
+}
+
+// writePDF generates a PDF from a template, records the generation and
+// writes it inline
+func (h *SalesOrderHandler) writePDF(
+	w http.ResponseWriter,
+	r *http.Request,
+	templateName string,
+	data any,
+) {
+	ctx := reqcontext.GetContext(r)
+
+	inputData, err := json.Marshal(data)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "PDF generation failed", http.StatusInternalServerError)
+		return
+	}
+
+	pdfBuf, resolvedTitle, err := h.pdfService.GenerateFromJSON(r.Context(), templateName, inputData)
+	if err != nil {
+		log.Println("An error occurred generating PDF:", err)
+		http.Error(w, "PDF generation failed", http.StatusInternalServerError)
+		return
+	}
+
+	downloadName := h.pdfService.GeneratePDFFilename(resolvedTitle)
+
+	_, err = h.pdfService.RecordGeneration(
+		r.Context(),
+		templateName,
+		string(inputData),
+		pdfBuf,
+		ctx.User.UserID,
+		resolvedTitle,
+	)
+	if err != nil {
+		log.Println("An error occurred recording PDF generation log:", err)
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=\"%s\"", downloadName))
+	w.Write(pdfBuf)
+}
+
+func (h *SalesOrderHandler) renderCustomersPage(
+	w http.ResponseWriter,
+	r *http.Request,
+	props *stockview.CustomersPageProps,
+) {
+	ctx := reqcontext.GetContext(r)
+
+	type urlVals struct {
+		SearchText   string
+		ShowArchived bool
+		Page         int
+		PageSize     int
+	}
+
+	var uv urlVals
+
+	err := appurl.Unmarshal(r.URL.Query(), &uv)
+	if err != nil {
+		http.Error(w, "Error decoding url values", http.StatusBadRequest)
+		return
+	}
+
+	uv.SearchText = strings.TrimSpace(uv.SearchText)
+
+	if uv.Page == 0 {
+		uv.Page = 1
+	}
+	if uv.PageSize == 0 {
+		uv.PageSize = 50
+	}
+
+	customers, count, err := h.salesOrderService.GetCustomers(r.Context(), &model.GetCustomersQuery{
+		SearchText:   uv.SearchText,
+		ShowArchived: uv.ShowArchived,
+		Page:         uv.Page,
+		PageSize:     uv.PageSize,
+	})
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Error fetching customers", http.StatusInternalServerError)
+		return
+	}
+
+	if props.Values == nil {
+		props.Values = url.Values{}
+	}
+	if props.ValidationErrors == nil {
+		props.ValidationErrors = validate.ValidationErrors{}
+	}
+
+	props.Ctx = ctx
+	props.Customers = customers
+	props.CustomersCount = count
+	props.SearchText = uv.SearchText
+	props.ShowArchived = uv.ShowArchived
+	props.Page = uv.Page
+	props.PageSize = uv.PageSize
+
+	_ = stockview.CustomersPage(props).Render(w)
+}
+
+func (h *SalesOrderHandler) renderCustomerPage(
+	w http.ResponseWriter,
+	r *http.Request,
+	customerID int,
+	props *stockview.CustomerPageProps,
+) {
+	ctx := reqcontext.GetContext(r)
+
+	customer, err := h.salesOrderService.GetCustomer(r.Context(), customerID)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Error fetching customer", http.StatusInternalServerError)
+		return
+	}
+	if customer == nil {
+		http.Error(w, "Customer not found", http.StatusNotFound)
+		return
+	}
+
+	if props.Values == nil {
+		props.Values = url.Values{}
+		props.Values.Set("Name", customer.Name)
+		props.Values.Set("Email", customer.Email)
+		props.Values.Set("Phone", customer.Phone)
+		props.Values.Set("DeliveryAddress", customer.DeliveryAddress)
+		if customer.IsArchived {
+			props.Values.Set("IsArchived", "true")
+		}
+	}
+	if props.ValidationErrors == nil {
+		props.ValidationErrors = validate.ValidationErrors{}
+	}
+
+	props.Ctx = ctx
+	props.Customer = *customer
+
+	_ = stockview.CustomerPage(props).Render(w)
+}
+
+func (h *SalesOrderHandler) renderAddSalesOrderPage(
+	w http.ResponseWriter,
+	r *http.Request,
+	props *stockview.AddSalesOrderPageProps,
+) {
+	ctx := reqcontext.GetContext(r)
+
+	customers, _, err := h.salesOrderService.GetCustomers(r.Context(), &model.GetCustomersQuery{
+		Page:     1,
+		PageSize: 10000,
+	})
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Error fetching customers", http.StatusInternalServerError)
+		return
+	}
+
+	if props.ValidationErrors == nil {
+		props.ValidationErrors = validate.ValidationErrors{}
+	}
+
+	props.Ctx = ctx
+	props.Customers = customers
+
+	_ = stockview.AddSalesOrderPage(props).Render(w)
+}
+
+// renderSalesOrderPage loads the order, its lines, pick lists and dispatches
+// and renders the detail page. Form state and errors are taken from props.
+func (h *SalesOrderHandler) renderSalesOrderPage(
+	w http.ResponseWriter,
+	r *http.Request,
+	salesOrderID int,
+	props *stockview.SalesOrderPageProps,
+) {
+	ctx := reqcontext.GetContext(r)
+
+	salesOrder, err := h.salesOrderService.GetSalesOrder(r.Context(), salesOrderID)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Error fetching sales order", http.StatusInternalServerError)
+		return
+	}
+	if salesOrder == nil {
+		http.Error(w, "Sales order not found", http.StatusNotFound)
+		return
+	}
+
+	lines, err := h.salesOrderService.GetSalesOrderLines(r.Context(), salesOrderID)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Error fetching sales order lines", http.StatusInternalServerError)
+		return
+	}
+
+	pickLists, err := h.salesOrderService.GetPickLists(r.Context(), salesOrderID)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Error fetching pick lists", http.StatusInternalServerError)
+		return
+	}
+
+	dispatches, err := h.salesOrderService.GetSalesOrderDispatches(r.Context(), salesOrderID)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Error fetching sales order dispatches", http.StatusInternalServerError)
+		return
+	}
+
+	canEdit := ctx.User.Permissions.SupplyChain.Admin
+	canAddLines := salesOrder.Status == model.DraftSalesOrderStatus ||
+		salesOrder.Status == model.OpenSalesOrderStatus
+
+	var stockItems []model.StockItem
+	if canEdit && canAddLines {
+		stockItems, _, err = h.stockItemService.GetStockItems(r.Context(), &model.GetStockItemsQuery{
+			Page: 1, PageSize: 10000,
+		}, ctx.User.UserID)
+		if err != nil {
+			log.Println(err)
+			http.Error(w, "Error fetching stock items", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if props.LineValues == nil {
+		props.LineValues = url.Values{}
+		props.LineValues.Set("DueDate", time.Now().Format("2006-01-02"))
+	}
+	if props.LineValidationErrors == nil {
+		props.LineValidationErrors = validate.ValidationErrors{}
+	}
+
+	props.Ctx = ctx
+	props.SalesOrder = *salesOrder
+	props.Lines = lines
+	props.PickLists = pickLists
+	props.Dispatches = dispatches
+	props.StockItems = stockItems
+	props.CanEdit = canEdit
+
+	_ = stockview.SalesOrderPage(props).Render(w)
+}
+
+// renderPickListPage loads the pick list, its order and lines and renders the
+// pick list page. The dispatch form defaults to picking each line in full.
+func (h *SalesOrderHandler) renderPickListPage(
+	w http.ResponseWriter,
+	r *http.Request,
+	pickListID int,
+	props *stockview.PickListPageProps,
+) {
+	ctx := reqcontext.GetContext(r)
+
+	pickList, err := h.salesOrderService.GetPickList(r.Context(), pickListID)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Error fetching pick list", http.StatusInternalServerError)
+		return
+	}
+	if pickList == nil {
+		http.Error(w, "Pick list not found", http.StatusNotFound)
+		return
+	}
+
+	salesOrder, err := h.salesOrderService.GetSalesOrder(r.Context(), pickList.SalesOrderID)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Error fetching sales order", http.StatusInternalServerError)
+		return
+	}
+
+	lines, err := h.salesOrderService.GetPickListLines(r.Context(), pickListID)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Error fetching pick list lines", http.StatusInternalServerError)
+		return
+	}
+
+	if props.Values == nil {
+		props.Values = url.Values{}
+		for _, l := range lines {
+			props.Values.Add("Qty", l.Qty.String())
+			props.Values.Add("SerialNumbers", "")
+		}
+	}
+
+	props.Ctx = ctx
+	props.PickList = *pickList
+	props.SalesOrder = *salesOrder
+	props.Lines = lines
+	props.CanEdit = ctx.User.Permissions.SupplyChain.Admin
+
+	_ = stockview.PickListPage(props).Render(w)
+}
@@ -0,0 +1,362 @@
+package handler
+
+import (
+	"app/internal/model"
+	"app/internal/service"
+	"app/pkg/appurl"
+	"app/pkg/reqcontext"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// maxAPIRequestSize is the largest request body the JSON API accepts
+const maxAPIRequestSize = 1 << 20
+
+// StockAPIHandler serves the stock endpoints of the JSON API. Filters are
+// taken from the query string with the same names as the stock pages, and
+// reading needs either supply chain permission while posting needs admin.
+type StockAPIHandler struct {
+	stockItemService        service.StockItemService
+	stockTransactionService service.StockTransactionService
+}
+
+func NewStockAPIHandler(
+	stockItemService service.StockItemService,
+	stockTransactionService service.StockTransactionService,
+) *StockAPIHandler {
+	return &StockAPIHandler{
+		stockItemService:        stockItemService,
+		stockTransactionService: stockTransactionService,
+	}
+}
+
+type apiStockItem struct {
+	StockItemID   int
+	StockCode     string
+	Description   string
+	BaseUnit      string
+	IsSerialised  bool
+	CostingMethod model.StockCostingMethod
+	CreatedAt     time.Time
+}
+
+type apiStockItemsResponse struct {
+	StockItems []apiStockItem
+	Total      int
+	Page       int
+	PageSize   int
+}
+
+type apiStockLevelsResponse struct {
+	StockLevels []model.StockLevel
+	Page        int
+	PageSize    int
+}
+
+type apiStockTransactionsResponse struct {
+	StockTransactions []model.StockTransactionEntry
+	Page              int
+	PageSize          int
+}
+
+func (h *StockAPIHandler) GetStockItems(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	perms := ctx.User.Permissions.SupplyChain
+	if !perms.Admin && !perms.TeamMember {
+		writeAPIError(w, http.StatusForbidden, "Forbidden")
+		return
+	}
+
+	type urlVals struct {
+		Page     int
+		PageSize int
+	}
+
+	var uv urlVals
+
+	err := appurl.Unmarshal(r.URL.Query(), &uv)
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, "Error decoding query parameters")
+		return
+	}
+
+	if uv.Page == 0 {
+		uv.Page = 1
+	}
+	if uv.PageSize == 0 {
+		uv.PageSize = 50
+	}
+
+	stockItems, count, err := h.stockItemService.GetStockItems(r.Context(), &model.GetStockItemsQuery{
+		Page:     uv.Page,
+		PageSize: uv.PageSize,
+	}, ctx.User.UserID)
+	if err != nil {
+		log.Println(err)
+		writeAPIError(w, http.StatusInternalServerError, "Error fetching stock items")
+		return
+	}
+
+	response := apiStockItemsResponse{
+		StockItems: []apiStockItem{},
+		Total:      count,
+		Page:       uv.Page,
+		PageSize:   uv.PageSize,
+	}
+	for _, si := range stockItems {
+		response.StockItems = append(response.StockItems, apiStockItem{
+			StockItemID:   si.StockItemID,
+			StockCode:     si.StockCode,
+			Description:   si.Description,
+			BaseUnit:      si.BaseUnit,
+			IsSerialised:  si.IsSerialised,
+			CostingMethod: si.CostingMethod,
+			CreatedAt:     si.CreatedAt,
+		})
+	}
+
+	writeAPIJSON(w, http.StatusOK, response)
+}
+
+func (h *StockAPIHandler) GetStockLevels(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	perms := ctx.User.Permissions.SupplyChain
+	if !perms.Admin && !perms.TeamMember {
+		writeAPIError(w, http.StatusForbidden, "Forbidden")
+		return
+	}
+
+	var uv stockInputURLVals
+
+	err := appurl.Unmarshal(r.URL.Query(), &uv)
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, "Error decoding query parameters")
+		return
+	}
+
+	uv.normalise()
+
+	stockLevels, err := h.stockTransactionService.GetStockLevels(r.Context(), &model.GetStockLevelsInput{
+		Account:      model.StockAccount(uv.Account),
+		StockCode:    uv.StockCode,
+		Location:     uv.Location,
+		Bin:          uv.Bin,
+		LotNumber:    uv.LotNumber,
+		LTETimestamp: uv.LTETimestamp,
+		Page:         uv.Page,
+		PageSize:     uv.PageSize,
+	})
+	if err != nil {
+		log.Println(err)
+		writeAPIError(w, http.StatusInternalServerError, "Error fetching stock levels")
+		return
+	}
+	if stockLevels == nil {
+		stockLevels = []model.StockLevel{}
+	}
+
+	writeAPIJSON(w, http.StatusOK, apiStockLevelsResponse{
+		StockLevels: stockLevels,
+		Page:        uv.Page,
+		PageSize:    uv.PageSize,
+	})
+}
+
+func (h *StockAPIHandler) GetStockTransactions(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	perms := ctx.User.Permissions.SupplyChain
+	if !perms.Admin && !perms.TeamMember {
+		writeAPIError(w, http.StatusForbidden, "Forbidden")
+		return
+	}
+
+	// sourceURLVals filters by the document or order that posted the
+	// transactions
+	type sourceURLVals struct {
+		StockDocumentID int
+		StockCountID    int
+		PurchaseOrderID int
+		SalesOrderID    int
+	}
+
+	var uv stockInputURLVals
+	var sv sourceURLVals
+
+	err := appurl.Unmarshal(r.URL.Query(), &uv)
+	if err == nil {
+		err = appurl.Unmarshal(r.URL.Query(), &sv)
+	}
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, "Error decoding query parameters")
+		return
+	}
+
+	uv.normalise()
+
+	stockTransactions, err := h.stockTransactionService.GetStockTransactions(r.Context(), &model.GetTransactionsInput{
+		Account:            model.StockAccount(uv.Account),
+		StockCode:          uv.StockCode,
+		Location:           uv.Location,
+		Bin:                uv.Bin,
+		LotNumber:          uv.LotNumber,
+		LTETimestamp:       uv.LTETimestamp,
+		StockDocumentID:    sv.StockDocumentID,
+		StockTransactionID: uv.StockTransactionID,
+		StockCountID:       sv.StockCountID,
+		PurchaseOrderID:    sv.PurchaseOrderID,
+		SalesOrderID:       sv.SalesOrderID,
+		Page:               uv.Page,
+		PageSize:           uv.PageSize,
+	}, ctx.User.UserID)
+	if err != nil {
+		log.Println(err)
+		writeAPIError(w, http.StatusInternalServerError, "Error fetching stock transactions")
+		return
+	}
+	if stockTransactions == nil {
+		stockTransactions = []model.StockTransactionEntry{}
+	}
+
+	writeAPIJSON(w, http.StatusOK, apiStockTransactionsResponse{
+		StockTransactions: stockTransactions,
+		Page:              uv.Page,
+		PageSize:          uv.PageSize,
+	})
+}
+
+type apiPostStockTransactionsRequest struct {
+	Transactions []struct {
+		TransactionType string
+		StockCode       string
+		Qty             decimal.Decimal
+		Unit            string
+		FromLocation    string
+		FromBin         string
+		FromLotNumber   string
+		ToLocation      string
+		ToBin           string
+		ToLotNumber     string
+		SerialNumbers   []string
+		UnitCost        *decimal.Decimal
+		TransactionNote string
+	}
+	AcknowledgeNegativeStock bool
+}
+
+// PostStockTransactions posts the transactions of the request body together,
+// either all of them or none
+func (h *StockAPIHandler) PostStockTransactions(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	if !ctx.User.Permissions.SupplyChain.Admin {
+		writeAPIError(w, http.StatusForbidden, "Forbidden")
+		return
+	}
+
+	var body apiPostStockTransactionsRequest
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxAPIRequestSize)
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, fmt.Sprintf("Error decoding request body: %v", err))
+		return
+	}
+
+	input := &model.PostStockTransactionRequestsInput{
+		AcknowledgeNegativeStock: body.AcknowledgeNegativeStock,
+	}
+	for _, t := range body.Transactions {
+		serialNumbers := []string{}
+		for _, sn := range t.SerialNumbers {
+			sn = strings.ToUpper(strings.TrimSpace(sn))
+			if sn != "" {
+				serialNumbers = append(serialNumbers, sn)
+			}
+		}
+
+		input.Transactions = append(input.Transactions, model.StockTransactionRequest{
+			TransactionType: model.StockTransactionType(strings.TrimSpace(t.TransactionType)),
+			StockCode:       strings.ToUpper(strings.TrimSpace(t.StockCode)),
+			Qty:             t.Qty,
+			Unit:            strings.ToUpper(strings.TrimSpace(t.Unit)),
+			FromLocation:    strings.ToUpper(strings.TrimSpace(t.FromLocation)),
+			FromBin:         strings.ToUpper(strings.TrimSpace(t.FromBin)),
+			FromLotNumber:   strings.ToUpper(strings.TrimSpace(t.FromLotNumber)),
+			ToLocation:      strings.ToUpper(strings.TrimSpace(t.ToLocation)),
+			ToBin:           strings.ToUpper(strings.TrimSpace(t.ToBin)),
+			ToLotNumber:     strings.ToUpper(strings.TrimSpace(t.ToLotNumber)),
+			SerialNumbers:   serialNumbers,
+			UnitCost:        t.UnitCost,
+			TransactionNote: strings.TrimSpace(t.TransactionNote),
+		})
+	}
+
+	validationErrors, err := h.stockTransactionService.PostStockTransactionRequests(
+		r.Context(), input, ctx.User.UserID,
+	)
+	if err != nil {
+		// errors found while posting, such as negative stock or serials that
+		// are not where they are posted from, are the client's to fix
+		var negativeStockErr *service.NegativeStockError
+		if errors.As(err, &negativeStockErr) {
+			writeAPIError(w, http.StatusConflict, negativeStockErr.Error())
+			return
+		}
+		if isStockPostingError(err) {
+			writeAPIError(w, http.StatusUnprocessableEntity, fmt.Sprintf("Error posting transactions: %v", err))
+			return
+		}
+		log.Println(err)
+		writeAPIError(w, http.StatusInternalServerError, "Error posting transactions")
+		return
+	}
+
+	if len(validationErrors) > 0 {
+		writeAPIJSON(w, http.StatusUnprocessableEntity, APIErrorResponse{
+			Error:            "Invalid transactions",
+			ValidationErrors: validationErrors,
+		})
+		return
+	}
+
+	writeAPIJSON(w, http.StatusCreated, struct {
+		PostedCount int
+	}{
+		PostedCount: len(input.Transactions),
+	})
+}
+
+// stockPostingErrors are the errors posting returns for transactions that
+// break a stock rule, which the client can fix
+var stockPostingErrors = []error{
+	service.ErrUnknownTransactionType,
+	service.ErrUnknownStockItemUnit,
+	service.ErrInvalidSerialNumbers,
+	service.ErrInvalidStockPlace,
+	service.ErrStockBinCapacity,
+	service.ErrInvalidUnitCost,
+	service.ErrQuarantineApproval,
+	service.ErrScrapReasonRequired,
+	service.ErrInsufficientQuarantinedStock,
+	service.ErrStockPeriodClosed,
+}
+
+func isStockPostingError(err error) bool {
+	for _, target := range stockPostingErrors {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,411 @@
+package handler
+
+import (
+	"app/internal/model"
+	"app/internal/service"
+	"app/internal/views/stockitemview"
+	"app/pkg/appurl"
+	"app/pkg/reqcontext"
+	"app/pkg/validate"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type StockBOMHandler struct {
+	stockBOMService  service.StockBOMService
+	stockItemService service.StockItemService
+}
+
+func NewStockBOMHandler(
+	stockBOMService service.StockBOMService,
+	stockItemService service.StockItemService,
+) *StockBOMHandler {
+	return &StockBOMHandler{
+		stockBOMService:  stockBOMService,
+		stockItemService: stockItemService,
+	}
+}
+
+type postStockBOMFormData struct {
+	Version       string
+	EffectiveFrom time.Time
+	EffectiveTo   *time.Time
+	Note          string
+}
+
+func (fd *postStockBOMFormData) normalise() {
+	fd.Version = strings.ToUpper(strings.TrimSpace(fd.Version))
+	fd.Note = strings.TrimSpace(fd.Note)
+}
+
+func (h *StockBOMHandler) AddStockBOMPage(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	if !ctx.User.Permissions.Stock.Admin {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	stockItem := h.getStockItem(w, r)
+	if stockItem == nil {
+		return
+	}
+
+	values := url.Values{}
+	values.Set("EffectiveFrom", time.Now().Format("2006-01-02"))
+
+	_ = stockitemview.AddStockBOMPage(&stockitemview.AddStockBOMPageProps{
+		Ctx:              ctx,
+		StockItem:        *stockItem,
+		Values:           values,
+		ValidationErrors: validate.ValidationErrors{},
+	}).Render(w)
+}
+
+func (h *StockBOMHandler) AddStockBOM(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	if !ctx.User.Permissions.Stock.Admin {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	stockItem := h.getStockItem(w, r)
+	if stockItem == nil {
+		return
+	}
+
+	err := r.ParseForm()
+	if err != nil {
+		http.Error(w, "Error parsing form", http.StatusBadRequest)
+		return
+	}
+
+	var fd postStockBOMFormData
+	err = appurl.Unmarshal(r.Form, &fd)
+	if err != nil {
+		http.Error(w, "Error decoding form", http.StatusBadRequest)
+		return
+	}
+
+	fd.normalise()
+
+	stockBOMID, validationErrors, err := h.stockBOMService.CreateStockBOM(
+		r.Context(),
+		stockItem.StockItemID,
+		&model.NewStockBOM{
+			Version:       fd.Version,
+			EffectiveFrom: fd.EffectiveFrom,
+			EffectiveTo:   fd.EffectiveTo,
+			Note:          fd.Note,
+		},
+		ctx.User.UserID,
+	)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Error adding bill of materials", http.StatusInternalServerError)
+		return
+	}
+
+	if len(validationErrors) > 0 {
+		_ = stockitemview.AddStockBOMPage(&stockitemview.AddStockBOMPageProps{
+			Ctx:              ctx,
+			StockItem:        *stockItem,
+			Values:           r.Form,
+			ValidationErrors: validationErrors,
+			IsSubmission:     true,
+		}).Render(w)
+		return
+	}
+
+	http.Redirect(
+		w, r,
+		fmt.Sprintf("/stock-items/%d/boms/%d", stockItem.StockItemID, stockBOMID),
+		http.StatusSeeOther,
+	)
+}
+
+func (h *StockBOMHandler) StockBOMPage(w http.ResponseWriter, r *http.Request) {
+	h.renderStockBOMPage(w, r, &stockitemview.StockBOMPageProps{})
+}
+
+func (h *StockBOMHandler) UpdateStockBOM(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	if !ctx.User.Permissions.Stock.Admin {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	stockItemID, stockBOMID, ok := stockBOMPathIDs(w, r)
+	if !ok {
+		return
+	}
+
+	err := r.ParseForm()
+	if err != nil {
+		http.Error(w, "Error parsing form", http.StatusBadRequest)
+		return
+	}
+
+	var fd postStockBOMFormData
+	err = appurl.Unmarshal(r.Form, &fd)
+	if err != nil {
+		http.Error(w, "Error decoding form", http.StatusBadRequest)
+		return
+	}
+
+	fd.normalise()
+
+	validationErrors, err := h.stockBOMService.UpdateStockBOM(
+		r.Context(),
+		stockBOMID,
+		&model.StockBOMUpdate{
+			EffectiveFrom: fd.EffectiveFrom,
+			EffectiveTo:   fd.EffectiveTo,
+			Note:          fd.Note,
+		},
+		ctx.User.UserID,
+	)
+	if err != nil {
+		h.renderStockBOMPage(w, r, &stockitemview.StockBOMPageProps{
+			Values:    r.Form,
+			ErrorText: fmt.Sprintf("Error saving version: %v", err),
+		})
+		return
+	}
+
+	if len(validationErrors) > 0 {
+		h.renderStockBOMPage(w, r, &stockitemview.StockBOMPageProps{
+			Values:           r.Form,
+			ValidationErrors: validationErrors,
+			IsSubmission:     true,
+		})
+		return
+	}
+
+	http.Redirect(
+		w, r,
+		fmt.Sprintf("/stock-items/%d/boms/%d", stockItemID, stockBOMID),
+		http.StatusSeeOther,
+	)
+}
+
+type postStockBOMLineFormData struct {
+	ComponentStockItemID int
+	QtyPer               decimal.Decimal
+	ScrapFactor          decimal.Decimal
+}
+
+func (h *StockBOMHandler) AddStockBOMLine(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	if !ctx.User.Permissions.Stock.Admin {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	stockItemID, stockBOMID, ok := stockBOMPathIDs(w, r)
+	if !ok {
+		return
+	}
+
+	err := r.ParseForm()
+	if err != nil {
+		http.Error(w, "Error parsing form", http.StatusBadRequest)
+		return
+	}
+
+	var fd postStockBOMLineFormData
+	err = appurl.Unmarshal(r.Form, &fd)
+	if err != nil {
+		http.Error(w, "Error decoding form", http.StatusBadRequest)
+		return
+	}
+
+	validationErrors, err := h.stockBOMService.AddStockBOMLine(
+		r.Context(),
+		stockBOMID,
+		&model.NewStockBOMLine{
+			ComponentStockItemID: fd.ComponentStockItemID,
+			QtyPer:               fd.QtyPer,
+			ScrapFactor:          fd.ScrapFactor,
+		},
+	)
+	if err != nil {
+		h.renderStockBOMPage(w, r, &stockitemview.StockBOMPageProps{
+			LineValues: r.Form,
+			ErrorText:  fmt.Sprintf("Error adding component: %v", err),
+		})
+		return
+	}
+
+	if len(validationErrors) > 0 {
+		h.renderStockBOMPage(w, r, &stockitemview.StockBOMPageProps{
+			LineValues:           r.Form,
+			LineValidationErrors: validationErrors,
+			IsLineSubmission:     true,
+		})
+		return
+	}
+
+	http.Redirect(
+		w, r,
+		fmt.Sprintf("/stock-items/%d/boms/%d", stockItemID, stockBOMID),
+		http.StatusSeeOther,
+	)
+}
+
+func (h *StockBOMHandler) DeleteStockBOMLine(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	if !ctx.User.Permissions.Stock.Admin {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	stockItemID, stockBOMID, ok := stockBOMPathIDs(w, r)
+	if !ok {
+		return
+	}
+
+	stockBOMLineID, err := strconv.Atoi(r.PathValue("lineID"))
+	if err != nil {
+		http.Error(w, "Invalid line ID", http.StatusBadRequest)
+		return
+	}
+
+	err = h.stockBOMService.DeleteStockBOMLine(r.Context(), stockBOMID, stockBOMLineID)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Error removing component", http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(
+		w, r,
+		fmt.Sprintf("/stock-items/%d/boms/%d", stockItemID, stockBOMID),
+		http.StatusSeeOther,
+	)
+}
+
+// stockBOMPathIDs reads the stock item and bill of materials IDs from the
+// path, writing an error if either is invalid
+func stockBOMPathIDs(w http.ResponseWriter, r *http.Request) (int, int, bool) {
+	stockItemID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid stock item ID", http.StatusBadRequest)
+		return 0, 0, false
+	}
+
+	stockBOMID, err := strconv.Atoi(r.PathValue("bomID"))
+	if err != nil {
+		http.Error(w, "Invalid bill of materials ID", http.StatusBadRequest)
+		return 0, 0, false
+	}
+
+	return stockItemID, stockBOMID, true
+}
+
+// getStockItem returns the stock item in the path, writing an error and
+// returning nil if there is none
+func (h *StockBOMHandler) getStockItem(w http.ResponseWriter, r *http.Request) *model.StockItem {
+	ctx := reqcontext.GetContext(r)
+
+	stockItemID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid stock item ID", http.StatusBadRequest)
+		return nil
+	}
+
+	stockItem, err := h.stockItemService.GetStockItem(r.Context(), stockItemID, ctx.User)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Error fetching stock item", http.StatusInternalServerError)
+		return nil
+	}
+	if stockItem == nil {
+		http.Error(w, "Stock item not found", http.StatusNotFound)
+		return nil
+	}
+
+	return stockItem
+}
+
+func (h *StockBOMHandler) renderStockBOMPage(
+	w http.ResponseWriter,
+	r *http.Request,
+	props *stockitemview.StockBOMPageProps,
+) {
+	ctx := reqcontext.GetContext(r)
+
+	stockItem := h.getStockItem(w, r)
+	if stockItem == nil {
+		return
+	}
+
+	_, stockBOMID, ok := stockBOMPathIDs(w, r)
+	if !ok {
+		return
+	}
+
+	bom, err := h.stockBOMService.GetStockBOM(r.Context(), stockBOMID)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Error fetching bill of materials", http.StatusInternalServerError)
+		return
+	}
+	if bom == nil || bom.StockItemID != stockItem.StockItemID {
+		http.Error(w, "Bill of materials not found", http.StatusNotFound)
+		return
+	}
+
+	lines, err := h.stockBOMService.GetStockBOMLines(r.Context(), stockBOMID)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Error fetching bill of materials lines", http.StatusInternalServerError)
+		return
+	}
+
+	stockItems, _, err := h.stockItemService.GetStockItems(r.Context(), &model.GetStockItemsQuery{
+		Page: 1, PageSize: 10000,
+	}, ctx.User.UserID)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Error fetching stock items", http.StatusInternalServerError)
+		return
+	}
+
+	if props.Values == nil {
+		props.Values = url.Values{}
+		props.Values.Set("EffectiveFrom", bom.EffectiveFrom.Format("2006-01-02"))
+		if bom.EffectiveTo != nil {
+			props.Values.Set("EffectiveTo", bom.EffectiveTo.Format("2006-01-02"))
+		}
+		props.Values.Set("Note", bom.Note)
+	}
+	if props.ValidationErrors == nil {
+		props.ValidationErrors = validate.ValidationErrors{}
+	}
+	if props.LineValues == nil {
+		props.LineValues = url.Values{}
+	}
+	if props.LineValidationErrors == nil {
+		props.LineValidationErrors = validate.ValidationErrors{}
+	}
+
+	props.Ctx = ctx
+	props.StockItem = *stockItem
+	props.StockBOM = *bom
+	props.Lines = lines
+	props.StockItems = stockItems
+
+	_ = stockitemview.StockBOMPage(props).Render(w)
+}
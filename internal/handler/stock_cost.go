@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"app/internal/model"
+	"app/internal/service"
+	"app/internal/views/stockview"
+	"app/pkg/appurl"
+	"app/pkg/reqcontext"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type StockCostHandler struct {
+	stockCostService service.StockCostService
+}
+
+func NewStockCostHandler(
+	stockCostService service.StockCostService,
+) *StockCostHandler {
+	return &StockCostHandler{
+		stockCostService: stockCostService,
+	}
+}
+
+func (h *StockCostHandler) StockValuationPage(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	type urlVals struct {
+		StockCode    string
+		Location     string
+		LTETimestamp *time.Time
+		Page         int
+		PageSize     int
+	}
+
+	var uv urlVals
+
+	err := appurl.Unmarshal(r.URL.Query(), &uv)
+	if err != nil {
+		http.Error(w, "Error decoding url values", http.StatusBadRequest)
+		return
+	}
+
+	uv.StockCode = strings.ToUpper(strings.TrimSpace(uv.StockCode))
+	uv.Location = strings.ToUpper(strings.TrimSpace(uv.Location))
+
+	if uv.Page == 0 {
+		uv.Page = 1
+	}
+	if uv.PageSize == 0 {
+		uv.PageSize = 50
+	}
+
+	valuation, summary, err := h.stockCostService.GetStockValuation(r.Context(), &model.GetStockValuationInput{
+		StockCode:    uv.StockCode,
+		Location:     uv.Location,
+		LTETimestamp: uv.LTETimestamp,
+		Page:         uv.Page,
+		PageSize:     uv.PageSize,
+	})
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Error fetching stock valuation", http.StatusInternalServerError)
+		return
+	}
+
+	_ = stockview.StockValuationPage(&stockview.StockValuationPageProps{
+		Ctx:          ctx,
+		Valuation:    valuation,
+		Summary:      summary,
+		StockCode:    uv.StockCode,
+		Location:     uv.Location,
+		LTETimestamp: uv.LTETimestamp,
+		Page:         uv.Page,
+		PageSize:     uv.PageSize,
+	}).Render(w)
+}
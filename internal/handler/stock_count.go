@@ -0,0 +1,606 @@
+package handler
+
+import (
+	"app/internal/model"
+	"app/internal/pdftemplate"
+	"app/internal/service"
+	"app/internal/views/stockview"
+	"app/pkg/appsort"
+	"app/pkg/appurl"
+	"app/pkg/nilsafe"
+	"app/pkg/reqcontext"
+	"app/pkg/validate"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+type StockCountHandler struct {
+	stockCountService service.StockCountService
+	stockItemService  service.StockItemService
+	pdfService        service.PDFService
+}
+
+func NewStockCountHandler(
+	stockCountService service.StockCountService,
+	stockItemService service.StockItemService,
+	pdfService service.PDFService,
+) *StockCountHandler {
+	return &StockCountHandler{
+		stockCountService: stockCountService,
+		stockItemService:  stockItemService,
+		pdfService:        pdfService,
+	}
+}
+
+func (h *StockCountHandler) StockCountsPage(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	type urlVals struct {
+		Status   string
+		Sort     string
+		Page     int
+		PageSize int
+	}
+
+	var uv urlVals
+
+	err := appurl.Unmarshal(r.URL.Query(), &uv)
+	if err != nil {
+		http.Error(w, "Error decoding url values", http.StatusBadRequest)
+		return
+	}
+
+	sort := appsort.Sort{}
+	sort.ParseQueryParam(model.StockCount{}, uv.Sort)
+
+	if uv.Page == 0 {
+		uv.Page = 1
+	}
+	if uv.PageSize == 0 {
+		uv.PageSize = 50
+	}
+
+	stockCounts, count, err := h.stockCountService.GetStockCounts(r.Context(), &model.GetStockCountsQuery{
+		Status:   model.StockCountStatus(uv.Status),
+		Sort:     sort,
+		Page:     uv.Page,
+		PageSize: uv.PageSize,
+	})
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Error fetching stock counts", http.StatusInternalServerError)
+		return
+	}
+
+	_ = stockview.StockCountsPage(&stockview.StockCountsPageProps{
+		Ctx:              ctx,
+		StockCounts:      stockCounts,
+		StockCountsCount: count,
+		Status:           uv.Status,
+		Sort:             sort,
+		Page:             uv.Page,
+		PageSize:         uv.PageSize,
+	}).Render(w)
+}
+
+func (h *StockCountHandler) AddStockCountPage(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	if !ctx.User.Permissions.SupplyChain.Admin {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	h.renderAddStockCountPage(w, r, &stockview.AddStockCountPageProps{
+		Values: url.Values{},
+	})
+}
+
+func (h *StockCountHandler) AddStockCount(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	if !ctx.User.Permissions.SupplyChain.Admin {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	err := r.ParseForm()
+	if err != nil {
+		http.Error(w, "Error parsing form", http.StatusBadRequest)
+		return
+	}
+
+	var fd postStockCountFormData
+	err = appurl.Unmarshal(r.Form, &fd)
+	if err != nil {
+		http.Error(w, "Error decoding form", http.StatusBadRequest)
+		return
+	}
+
+	fd.normalise()
+
+	stockCountID, validationErrors, err := h.stockCountService.CreateStockCount(
+		r.Context(),
+		&model.NewStockCount{
+			Reference:    fd.Reference,
+			Note:         fd.Note,
+			Locations:    splitScopeList(fd.Locations),
+			Bins:         splitScopeList(fd.Bins),
+			StockItemIDs: fd.StockItemIDs,
+			IsBlind:      fd.IsBlind,
+		},
+		ctx.User.UserID,
+	)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Error creating stock count", http.StatusInternalServerError)
+		return
+	}
+
+	if len(validationErrors) > 0 {
+		h.renderAddStockCountPage(w, r, &stockview.AddStockCountPageProps{
+			Values:           r.Form,
+			ValidationErrors: validationErrors,
+			IsSubmission:     true,
+		})
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/stock/counts/%d", stockCountID), http.StatusSeeOther)
+}
+
+func (h *StockCountHandler) StockCountPage(w http.ResponseWriter, r *http.Request) {
+	stockCountID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid stock count ID", http.StatusBadRequest)
+		return
+	}
+
+	h.renderStockCountPage(w, r, stockCountID, &stockview.StockCountPageProps{})
+}
+
+// SaveStockCountCounts records the quantities entered on the count sheet.
+// Inputs are named Counted-<line ID> and blank inputs are left uncounted.
+func (h *StockCountHandler) SaveStockCountCounts(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	if !canCountStock(ctx) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	stockCountID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid stock count ID", http.StatusBadRequest)
+		return
+	}
+
+	err = r.ParseForm()
+	if err != nil {
+		http.Error(w, "Error parsing form", http.StatusBadRequest)
+		return
+	}
+
+	counts := []model.StockCountLineCount{}
+	for key, values := range r.PostForm {
+		lineIDStr, ok := strings.CutPrefix(key, "Counted-")
+		if !ok || len(values) == 0 || strings.TrimSpace(values[0]) == "" {
+			continue
+		}
+
+		stockCountLineID, err := strconv.Atoi(lineIDStr)
+		if err != nil {
+			http.Error(w, "Invalid stock count line ID", http.StatusBadRequest)
+			return
+		}
+
+		countedQty, err := decimal.NewFromString(strings.TrimSpace(values[0]))
+		if err != nil {
+			h.renderStockCountPage(w, r, stockCountID, &stockview.StockCountPageProps{
+				ErrorText: fmt.Sprintf("Error saving counts: %q is not a valid quantity", values[0]),
+			})
+			return
+		}
+
+		counts = append(counts, model.StockCountLineCount{
+			StockCountLineID: stockCountLineID,
+			CountedQty:       countedQty,
+		})
+	}
+
+	err = h.stockCountService.SaveStockCountCounts(r.Context(), stockCountID, counts, ctx.User.UserID)
+	if err != nil {
+		h.renderStockCountPage(w, r, stockCountID, &stockview.StockCountPageProps{
+			ErrorText: fmt.Sprintf("Error saving counts: %v", err),
+		})
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/stock/counts/%d", stockCountID), http.StatusSeeOther)
+}
+
+func (h *StockCountHandler) AddStockCountLine(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	if !canCountStock(ctx) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	stockCountID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid stock count ID", http.StatusBadRequest)
+		return
+	}
+
+	err = r.ParseForm()
+	if err != nil {
+		http.Error(w, "Error parsing form", http.StatusBadRequest)
+		return
+	}
+
+	var fd postStockCountLineFormData
+	err = appurl.Unmarshal(r.Form, &fd)
+	if err != nil {
+		http.Error(w, "Error decoding form", http.StatusBadRequest)
+		return
+	}
+
+	fd.normalise()
+
+	validationErrors, err := h.stockCountService.AddStockCountLine(
+		r.Context(),
+		stockCountID,
+		&model.NewStockCountLine{
+			StockItemID: fd.StockItemID,
+			Location:    fd.Location,
+			Bin:         fd.Bin,
+			LotNumber:   fd.LotNumber,
+			CountedQty:  fd.CountedQty,
+		},
+		ctx.User.UserID,
+	)
+	if err != nil {
+		h.renderStockCountPage(w, r, stockCountID, &stockview.StockCountPageProps{
+			LineValues: r.Form,
+			ErrorText:  fmt.Sprintf("Error adding line: %v", err),
+		})
+		return
+	}
+
+	if len(validationErrors) > 0 {
+		h.renderStockCountPage(w, r, stockCountID, &stockview.StockCountPageProps{
+			LineValues:           r.Form,
+			LineValidationErrors: validationErrors,
+			IsLineSubmission:     true,
+		})
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/stock/counts/%d", stockCountID), http.StatusSeeOther)
+}
+
+func (h *StockCountHandler) ApproveStockCount(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	if !ctx.User.Permissions.SupplyChain.Admin {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	stockCountID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid stock count ID", http.StatusBadRequest)
+		return
+	}
+
+	err = r.ParseForm()
+	if err != nil {
+		http.Error(w, "Error parsing form", http.StatusBadRequest)
+		return
+	}
+
+	var fd postStockCountActionFormData
+	err = appurl.Unmarshal(r.Form, &fd)
+	if err != nil {
+		http.Error(w, "Error decoding form", http.StatusBadRequest)
+		return
+	}
+
+	err = h.stockCountService.ApproveStockCount(
+		r.Context(),
+		stockCountID,
+		fd.AcknowledgeNegativeStock,
+		ctx.User.UserID,
+	)
+	if err != nil {
+		var negativeStockErr *service.NegativeStockError
+		h.renderStockCountPage(w, r, stockCountID, &stockview.StockCountPageProps{
+			ErrorText:            fmt.Sprintf("Error approving count: %v", err),
+			NegativeStockWarning: errors.As(err, &negativeStockErr) && negativeStockErr.IsWarning(),
+		})
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/stock/counts/%d", stockCountID), http.StatusSeeOther)
+}
+
+func (h *StockCountHandler) CancelStockCount(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	if !ctx.User.Permissions.SupplyChain.Admin {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	stockCountID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid stock count ID", http.StatusBadRequest)
+		return
+	}
+
+	err = h.stockCountService.CancelStockCount(r.Context(), stockCountID, ctx.User.UserID)
+	if err != nil {
+		h.renderStockCountPage(w, r, stockCountID, &stockview.StockCountPageProps{
+			ErrorText: fmt.Sprintf("Error cancelling count: %v", err),
+		})
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/stock/counts/%d", stockCountID), http.StatusSeeOther)
+}
+
+// StockCountVarianceReport renders the variance report PDF through the PDF
+// service so that it is logged like any other generated PDF
+func (h *StockCountHandler) StockCountVarianceReport(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	stockCountID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid stock count ID", http.StatusBadRequest)
+		return
+	}
+
+	stockCount, err := h.stockCountService.GetStockCount(r.Context(), stockCountID)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Error fetching stock count", http.StatusInternalServerError)
+		return
+	}
+	if stockCount == nil {
+		http.Error(w, "Stock count not found", http.StatusNotFound)
+		return
+	}
+
+	// the report shows expected quantities, which blind counts hide from
+	// counters until the count is approved
+	if stockCount.IsBlind &&
+		stockCount.Status != model.ApprovedStockCountStatus &&
+		!ctx.User.Permissions.SupplyChain.Admin {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	lines, err := h.stockCountService.GetStockCountLines(r.Context(), stockCountID)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Error fetching stock count lines", http.StatusInternalServerError)
+		return
+	}
+
+	data := pdftemplate.StockCountVarianceData{
+		Reference:  stockCount.Reference,
+		Note:       stockCount.Note,
+		Status:     string(stockCount.Status),
+		Scope:      stockview.StockCountScope(stockCount),
+		SnapshotAt: stockCount.SnapshotAt,
+		ApprovedBy: nilsafe.Str(stockCount.ApprovedByUsername),
+		ApprovedAt: stockCount.ApprovedAt,
+	}
+	for _, l := range lines {
+		data.Lines = append(data.Lines, pdftemplate.StockCountVarianceLine{
+			StockCode:   l.StockCode,
+			Location:    l.Location,
+			Bin:         l.Bin,
+			LotNumber:   l.LotNumber,
+			ExpectedQty: l.ExpectedQty,
+			CountedQty:  l.CountedQty,
+		})
+	}
+
+	inputData, err := json.Marshal(data)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "PDF generation failed", http.StatusInternalServerError)
+		return
+	}
+
+	templateName := pdftemplate.StockCountVarianceTemplateDefinition.Name
+
+	pdfBuf, resolvedTitle, err := h.pdfService.GenerateFromJSON(r.Context(), templateName, inputData)
+	if err != nil {
+		log.Println("An error occurred generating PDF:", err)
+		http.Error(w, "PDF generation failed", http.StatusInternalServerError)
+		return
+	}
+
+	downloadName := h.pdfService.GeneratePDFFilename(resolvedTitle)
+
+	_, err = h.pdfService.RecordGeneration(
+		r.Context(),
+		templateName,
+		string(inputData),
+		pdfBuf,
+		ctx.User.UserID,
+		resolvedTitle,
+	)
+	if err != nil {
+		log.Println("An error occurred recording PDF generation log:", err)
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=\"%s\"", downloadName))
+	w.Write(pdfBuf)
+}
+
+func (h *StockCountHandler) renderAddStockCountPage(
+	w http.ResponseWriter,
+	r *http.Request,
+	props *stockview.AddStockCountPageProps,
+) {
+	ctx := reqcontext.GetContext(r)
+
+	stockItems, _, err := h.stockItemService.GetStockItems(r.Context(), &model.GetStockItemsQuery{
+		Page: 1, PageSize: 10000,
+	}, ctx.User.UserID)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Error fetching stock items", http.StatusInternalServerError)
+		return
+	}
+
+	props.Ctx = ctx
+	props.StockItems = stockItems
+
+	_ = stockview.AddStockCountPage(props).Render(w)
+}
+
+// renderStockCountPage loads the count, its lines and any ledger entries and
+// renders the detail page. Form state and errors are taken from props.
+func (h *StockCountHandler) renderStockCountPage(
+	w http.ResponseWriter,
+	r *http.Request,
+	stockCountID int,
+	props *stockview.StockCountPageProps,
+) {
+	ctx := reqcontext.GetContext(r)
+
+	stockCount, err := h.stockCountService.GetStockCount(r.Context(), stockCountID)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Error fetching stock count", http.StatusInternalServerError)
+		return
+	}
+	if stockCount == nil {
+		http.Error(w, "Stock count not found", http.StatusNotFound)
+		return
+	}
+
+	lines, err := h.stockCountService.GetStockCountLines(r.Context(), stockCountID)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Error fetching stock count lines", http.StatusInternalServerError)
+		return
+	}
+
+	var entries []model.StockTransactionEntry
+	if stockCount.Status == model.ApprovedStockCountStatus {
+		entries, err = h.stockCountService.GetStockCountEntries(r.Context(), stockCountID)
+		if err != nil {
+			log.Println(err)
+			http.Error(w, "Error fetching stock count entries", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	canCount := canCountStock(ctx)
+
+	var stockItems []model.StockItem
+	if canCount && stockCount.Status == model.CountingStockCountStatus {
+		stockItems, _, err = h.stockItemService.GetStockItems(r.Context(), &model.GetStockItemsQuery{
+			Page: 1, PageSize: 10000,
+		}, ctx.User.UserID)
+		if err != nil {
+			log.Println(err)
+			http.Error(w, "Error fetching stock items", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if props.LineValues == nil {
+		props.LineValues = url.Values{}
+	}
+	if props.LineValidationErrors == nil {
+		props.LineValidationErrors = validate.ValidationErrors{}
+	}
+
+	props.Ctx = ctx
+	props.StockCount = *stockCount
+	props.Lines = lines
+	props.Entries = entries
+	props.StockItems = stockItems
+	props.CanCount = canCount
+	props.CanApprove = ctx.User.Permissions.SupplyChain.Admin
+
+	_ = stockview.StockCountPage(props).Render(w)
+}
+
+// canCountStock reports whether the user may enter counted quantities.
+// Approving a count is restricted to supply chain admins.
+func canCountStock(ctx reqcontext.ReqContext) bool {
+	perms := ctx.User.Permissions.SupplyChain
+	return perms.Admin || perms.TeamMember
+}
+
+// splitScopeList splits a comma separated list of locations or bins,
+// normalising each entry as stock postings do
+func splitScopeList(s string) []string {
+	out := []string{}
+	for _, part := range strings.Split(s, ",") {
+		part = strings.ToUpper(strings.TrimSpace(part))
+		if part != "" && !slices.Contains(out, part) {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+type postStockCountFormData struct {
+	Reference    string
+	Note         string
+	Locations    string
+	Bins         string
+	StockItemIDs []int
+	IsBlind      bool
+}
+
+func (fd *postStockCountFormData) normalise() {
+	fd.Reference = strings.ToUpper(strings.TrimSpace(fd.Reference))
+	fd.Note = strings.TrimSpace(fd.Note)
+
+	// SearchSelect submits an empty value when nothing is selected
+	stockItemIDs := []int{}
+	for _, id := range fd.StockItemIDs {
+		if id != 0 && !slices.Contains(stockItemIDs, id) {
+			stockItemIDs = append(stockItemIDs, id)
+		}
+	}
+	fd.StockItemIDs = stockItemIDs
+}
+
+type postStockCountActionFormData struct {
+	AcknowledgeNegativeStock bool
+}
+
+type postStockCountLineFormData struct {
+	StockItemID int
+	Location    string
+	Bin         string
+	LotNumber   string
+	CountedQty  *decimal.Decimal
+}
+
+func (fd *postStockCountLineFormData) normalise() {
+	fd.Location = strings.ToUpper(strings.TrimSpace(fd.Location))
+	fd.Bin = strings.ToUpper(strings.TrimSpace(fd.Bin))
+	fd.LotNumber = strings.ToUpper(strings.TrimSpace(fd.LotNumber))
+}
@@ -0,0 +1,450 @@
+package handler
+
+import (
+	"app/internal/model"
+	"app/internal/service"
+	"app/internal/views/stockview"
+	"app/pkg/appsort"
+	"app/pkg/appurl"
+	"app/pkg/reqcontext"
+	"app/pkg/validate"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type StockDocumentHandler struct {
+	stockDocumentService service.StockDocumentService
+	stockItemService     service.StockItemService
+}
+
+func NewStockDocumentHandler(
+	stockDocumentService service.StockDocumentService,
+	stockItemService service.StockItemService,
+) *StockDocumentHandler {
+	return &StockDocumentHandler{
+		stockDocumentService: stockDocumentService,
+		stockItemService:     stockItemService,
+	}
+}
+
+func (h *StockDocumentHandler) StockDocumentsPage(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	type urlVals struct {
+		Status   string
+		Sort     string
+		Page     int
+		PageSize int
+	}
+
+	var uv urlVals
+
+	err := appurl.Unmarshal(r.URL.Query(), &uv)
+	if err != nil {
+		http.Error(w, "Error decoding url values", http.StatusBadRequest)
+		return
+	}
+
+	sort := appsort.Sort{}
+	sort.ParseQueryParam(model.StockDocument{}, uv.Sort)
+
+	if uv.Page == 0 {
+		uv.Page = 1
+	}
+	if uv.PageSize == 0 {
+		uv.PageSize = 50
+	}
+
+	stockDocuments, count, err := h.stockDocumentService.GetStockDocuments(r.Context(), &model.GetStockDocumentsQuery{
+		Status:   model.StockDocumentStatus(uv.Status),
+		Sort:     sort,
+		Page:     uv.Page,
+		PageSize: uv.PageSize,
+	})
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Error fetching stock documents", http.StatusInternalServerError)
+		return
+	}
+
+	_ = stockview.StockDocumentsPage(&stockview.StockDocumentsPageProps{
+		Ctx:                 ctx,
+		StockDocuments:      stockDocuments,
+		StockDocumentsCount: count,
+		Status:              uv.Status,
+		Sort:                sort,
+		Page:                uv.Page,
+		PageSize:            uv.PageSize,
+	}).Render(w)
+}
+
+func (h *StockDocumentHandler) AddStockDocumentPage(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	if !ctx.User.Permissions.SupplyChain.Admin {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	values := url.Values{}
+	values.Set("DocumentDate", time.Now().Format("2006-01-02T15:04"))
+
+	_ = stockview.AddStockDocumentPage(&stockview.AddStockDocumentPageProps{
+		Ctx:    ctx,
+		Values: values,
+	}).Render(w)
+}
+
+func (h *StockDocumentHandler) AddStockDocument(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	if !ctx.User.Permissions.SupplyChain.Admin {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	err := r.ParseForm()
+	if err != nil {
+		http.Error(w, "Error parsing form", http.StatusBadRequest)
+		return
+	}
+
+	var fd postStockDocumentFormData
+	err = appurl.Unmarshal(r.Form, &fd)
+	if err != nil {
+		http.Error(w, "Error decoding form", http.StatusBadRequest)
+		return
+	}
+
+	fd.normalise()
+
+	input := &model.NewStockDocument{
+		DocumentType: model.StockDocumentType(fd.DocumentType),
+		Reference:    fd.Reference,
+		Note:         fd.Note,
+	}
+	if fd.DocumentDate != nil {
+		input.DocumentDate = *fd.DocumentDate
+	}
+
+	stockDocumentID, validationErrors, err := h.stockDocumentService.CreateStockDocument(
+		r.Context(), input, ctx.User.UserID,
+	)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Error creating stock document", http.StatusInternalServerError)
+		return
+	}
+
+	if len(validationErrors) > 0 {
+		_ = stockview.AddStockDocumentPage(&stockview.AddStockDocumentPageProps{
+			Ctx:              ctx,
+			Values:           r.Form,
+			ValidationErrors: validationErrors,
+			IsSubmission:     true,
+		}).Render(w)
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/stock/documents/%d", stockDocumentID), http.StatusSeeOther)
+}
+
+func (h *StockDocumentHandler) StockDocumentPage(w http.ResponseWriter, r *http.Request) {
+	stockDocumentID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid stock document ID", http.StatusBadRequest)
+		return
+	}
+
+	h.renderStockDocumentPage(w, r, stockDocumentID, &stockview.StockDocumentPageProps{})
+}
+
+func (h *StockDocumentHandler) AddStockDocumentLine(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	if !ctx.User.Permissions.SupplyChain.Admin {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	stockDocumentID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid stock document ID", http.StatusBadRequest)
+		return
+	}
+
+	err = r.ParseForm()
+	if err != nil {
+		http.Error(w, "Error parsing form", http.StatusBadRequest)
+		return
+	}
+
+	var fd postStockDocumentLineFormData
+	err = appurl.Unmarshal(r.Form, &fd)
+	if err != nil {
+		http.Error(w, "Error decoding form", http.StatusBadRequest)
+		return
+	}
+
+	fd.normalise()
+
+	validationErrors, err := h.stockDocumentService.AddStockDocumentLine(
+		r.Context(),
+		stockDocumentID,
+		&model.NewStockDocumentLine{
+			TransactionType: model.StockTransactionType(fd.TransactionType),
+			StockItemID:     fd.StockItemID,
+			Qty:             fd.Qty,
+			Unit:            fd.Unit,
+			FromLocation:    fd.FromLocation,
+			FromBin:         fd.FromBin,
+			FromLotNumber:   fd.FromLotNumber,
+			ToLocation:      fd.ToLocation,
+			ToBin:           fd.ToBin,
+			SerialNumbers:   splitSerialNumbers(fd.SerialNumbers),
+			UnitCost:        fd.UnitCost,
+			LineNote:        fd.LineNote,
+		},
+	)
+	if err != nil {
+		h.renderStockDocumentPage(w, r, stockDocumentID, &stockview.StockDocumentPageProps{
+			LineValues: r.Form,
+			ErrorText:  fmt.Sprintf("Error adding line: %v", err),
+		})
+		return
+	}
+
+	if len(validationErrors) > 0 {
+		h.renderStockDocumentPage(w, r, stockDocumentID, &stockview.StockDocumentPageProps{
+			LineValues:           r.Form,
+			LineValidationErrors: validationErrors,
+			IsLineSubmission:     true,
+		})
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/stock/documents/%d", stockDocumentID), http.StatusSeeOther)
+}
+
+func (h *StockDocumentHandler) DeleteStockDocumentLine(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	if !ctx.User.Permissions.SupplyChain.Admin {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	stockDocumentID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid stock document ID", http.StatusBadRequest)
+		return
+	}
+
+	stockDocumentLineID, err := strconv.Atoi(r.PathValue("lineID"))
+	if err != nil {
+		http.Error(w, "Invalid stock document line ID", http.StatusBadRequest)
+		return
+	}
+
+	err = h.stockDocumentService.DeleteStockDocumentLine(r.Context(), stockDocumentID, stockDocumentLineID)
+	if err != nil {
+		h.renderStockDocumentPage(w, r, stockDocumentID, &stockview.StockDocumentPageProps{
+			ErrorText: fmt.Sprintf("Error removing line: %v", err),
+		})
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/stock/documents/%d", stockDocumentID), http.StatusSeeOther)
+}
+
+func (h *StockDocumentHandler) PostStockDocument(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	if !ctx.User.Permissions.SupplyChain.Admin {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	stockDocumentID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid stock document ID", http.StatusBadRequest)
+		return
+	}
+
+	err = r.ParseForm()
+	if err != nil {
+		http.Error(w, "Error parsing form", http.StatusBadRequest)
+		return
+	}
+
+	var fd postStockDocumentActionFormData
+	err = appurl.Unmarshal(r.Form, &fd)
+	if err != nil {
+		http.Error(w, "Error decoding form", http.StatusBadRequest)
+		return
+	}
+
+	err = h.stockDocumentService.PostStockDocument(
+		r.Context(),
+		stockDocumentID,
+		fd.AcknowledgeNegativeStock,
+		ctx.User.UserID,
+	)
+	if err != nil {
+		var negativeStockErr *service.NegativeStockError
+		h.renderStockDocumentPage(w, r, stockDocumentID, &stockview.StockDocumentPageProps{
+			ErrorText:            fmt.Sprintf("Error posting document: %v", err),
+			NegativeStockWarning: errors.As(err, &negativeStockErr) && negativeStockErr.IsWarning(),
+		})
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/stock/documents/%d", stockDocumentID), http.StatusSeeOther)
+}
+
+func (h *StockDocumentHandler) CancelStockDocument(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	if !ctx.User.Permissions.SupplyChain.Admin {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	stockDocumentID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid stock document ID", http.StatusBadRequest)
+		return
+	}
+
+	err = h.stockDocumentService.CancelStockDocument(r.Context(), stockDocumentID, ctx.User.UserID)
+	if err != nil {
+		h.renderStockDocumentPage(w, r, stockDocumentID, &stockview.StockDocumentPageProps{
+			ErrorText: fmt.Sprintf("Error cancelling document: %v", err),
+		})
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/stock/documents/%d", stockDocumentID), http.StatusSeeOther)
+}
+
+// renderStockDocumentPage loads the document, its lines and any ledger entries
+// and renders the detail page. Form state and errors are taken from props.
+func (h *StockDocumentHandler) renderStockDocumentPage(
+	w http.ResponseWriter,
+	r *http.Request,
+	stockDocumentID int,
+	props *stockview.StockDocumentPageProps,
+) {
+	ctx := reqcontext.GetContext(r)
+
+	stockDocument, err := h.stockDocumentService.GetStockDocument(r.Context(), stockDocumentID)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Error fetching stock document", http.StatusInternalServerError)
+		return
+	}
+	if stockDocument == nil {
+		http.Error(w, "Stock document not found", http.StatusNotFound)
+		return
+	}
+
+	lines, err := h.stockDocumentService.GetStockDocumentLines(r.Context(), stockDocumentID)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Error fetching stock document lines", http.StatusInternalServerError)
+		return
+	}
+
+	var entries []model.StockTransactionEntry
+	if stockDocument.Status == model.PostedStockDocumentStatus {
+		entries, err = h.stockDocumentService.GetStockDocumentEntries(r.Context(), stockDocumentID)
+		if err != nil {
+			log.Println(err)
+			http.Error(w, "Error fetching stock document entries", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	canEdit := ctx.User.Permissions.SupplyChain.Admin
+
+	var stockItems []model.StockItem
+	if canEdit && stockDocument.Status == model.DraftStockDocumentStatus {
+		stockItems, _, err = h.stockItemService.GetStockItems(r.Context(), &model.GetStockItemsQuery{
+			Page: 1, PageSize: 10000,
+		}, ctx.User.UserID)
+		if err != nil {
+			log.Println(err)
+			http.Error(w, "Error fetching stock items", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if props.LineValues == nil {
+		props.LineValues = url.Values{}
+	}
+	if props.LineValidationErrors == nil {
+		props.LineValidationErrors = validate.ValidationErrors{}
+	}
+
+	props.Ctx = ctx
+	props.StockDocument = *stockDocument
+	props.Lines = lines
+	props.Entries = entries
+	props.StockItems = stockItems
+	props.CanEdit = canEdit
+
+	_ = stockview.StockDocumentPage(props).Render(w)
+}
+
+type postStockDocumentFormData struct {
+	DocumentType string
+	Reference    string
+	DocumentDate *time.Time
+	Note         string
+}
+
+func (fd *postStockDocumentFormData) normalise() {
+	fd.Reference = strings.ToUpper(strings.TrimSpace(fd.Reference))
+	fd.Note = strings.TrimSpace(fd.Note)
+}
+
+type postStockDocumentActionFormData struct {
+	AcknowledgeNegativeStock bool
+}
+
+type postStockDocumentLineFormData struct {
+	TransactionType string
+	StockItemID     int
+	Qty             decimal.Decimal
+	Unit            string
+	FromLocation    string
+	FromBin         string
+	FromLotNumber   string
+	ToLocation      string
+	ToBin           string
+	SerialNumbers   string
+	UnitCost        *decimal.Decimal
+	LineNote        string
+}
+
+func (fd *postStockDocumentLineFormData) normalise() {
+
+	// trim and uppercase
+	fd.FromLocation = strings.ToUpper(strings.TrimSpace(fd.FromLocation))
+	fd.FromBin = strings.ToUpper(strings.TrimSpace(fd.FromBin))
+	fd.FromLotNumber = strings.ToUpper(strings.TrimSpace(fd.FromLotNumber))
+	fd.ToLocation = strings.ToUpper(strings.TrimSpace(fd.ToLocation))
+	fd.ToBin = strings.ToUpper(strings.TrimSpace(fd.ToBin))
+	fd.Unit = strings.ToUpper(strings.TrimSpace(fd.Unit))
+
+	// trim
+	fd.LineNote = strings.TrimSpace(fd.LineNote)
+}
@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"app/internal/service"
+	"app/internal/views/stockview"
+	"app/pkg/appurl"
+	"app/pkg/reqcontext"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+)
+
+type StockGenealogyHandler struct {
+	stockGenealogyService service.StockGenealogyService
+}
+
+func NewStockGenealogyHandler(
+	stockGenealogyService service.StockGenealogyService,
+) *StockGenealogyHandler {
+	return &StockGenealogyHandler{
+		stockGenealogyService: stockGenealogyService,
+	}
+}
+
+func (h *StockGenealogyHandler) LotTracePage(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	type urlVals struct {
+		StockCode string
+		LotNumber string
+	}
+
+	var uv urlVals
+
+	err := appurl.Unmarshal(r.URL.Query(), &uv)
+	if err != nil {
+		http.Error(w, "Error decoding url values", http.StatusBadRequest)
+		return
+	}
+
+	uv.StockCode = strings.ToUpper(strings.TrimSpace(uv.StockCode))
+	uv.LotNumber = strings.ToUpper(strings.TrimSpace(uv.LotNumber))
+
+	props := &stockview.LotTracePageProps{
+		Ctx:       ctx,
+		StockCode: uv.StockCode,
+		LotNumber: uv.LotNumber,
+	}
+
+	// nothing to trace until both are given
+	if uv.StockCode == "" || uv.LotNumber == "" {
+		_ = stockview.LotTracePage(props).Render(w)
+		return
+	}
+
+	trace, err := h.stockGenealogyService.GetLotTrace(r.Context(), uv.StockCode, uv.LotNumber)
+	if err != nil {
+		log.Println(err)
+		props.ErrorText = fmt.Sprintf("Error tracing lot: %v", err)
+	} else if trace == nil {
+		props.ErrorText = fmt.Sprintf("Stock code %s does not exist", uv.StockCode)
+	}
+	props.Trace = trace
+
+	_ = stockview.LotTracePage(props).Render(w)
+}
@@ -0,0 +1,292 @@
+package handler
+
+import (
+	"app/internal/model"
+	"app/internal/service"
+	"app/internal/views/stockview"
+	"app/pkg/appurl"
+	"app/pkg/reqcontext"
+	"app/pkg/validate"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+// maxStockImportFileSize is the largest stock import file that can be uploaded
+const maxStockImportFileSize = 10 << 20
+
+type StockImportHandler struct {
+	stockImportService service.StockImportService
+}
+
+func NewStockImportHandler(
+	stockImportService service.StockImportService,
+) *StockImportHandler {
+	return &StockImportHandler{
+		stockImportService: stockImportService,
+	}
+}
+
+func (h *StockImportHandler) StockImportsPage(w http.ResponseWriter, r *http.Request) {
+	h.renderStockImportsPage(w, r, &stockview.StockImportsPageProps{})
+}
+
+func (h *StockImportHandler) UploadStockImport(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	if !ctx.User.Permissions.SupplyChain.Admin {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxStockImportFileSize)
+	err := r.ParseMultipartForm(maxStockImportFileSize)
+	if err != nil {
+		http.Error(w, "Error parsing form, the file may be too large", http.StatusBadRequest)
+		return
+	}
+
+	var validationErrors validate.ValidationErrors = make(map[string][]string)
+
+	file, header, err := r.FormFile("File")
+	if errors.Is(err, http.ErrMissingFile) {
+		validationErrors.Add("File", "is required")
+		h.renderStockImportsPage(w, r, &stockview.StockImportsPageProps{
+			ValidationErrors: validationErrors,
+		})
+		return
+	} else if err != nil {
+		http.Error(w, "Error reading file", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	content, err := io.ReadAll(file)
+	if err != nil {
+		http.Error(w, "Error reading file", http.StatusBadRequest)
+		return
+	}
+
+	stockImportID, validationErrors, err := h.stockImportService.CreateStockImport(
+		r.Context(), header.Filename, content, ctx.User.UserID,
+	)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Error creating stock import", http.StatusInternalServerError)
+		return
+	}
+
+	if len(validationErrors) > 0 {
+		h.renderStockImportsPage(w, r, &stockview.StockImportsPageProps{
+			ValidationErrors: validationErrors,
+		})
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/stock/imports/%d", stockImportID), http.StatusSeeOther)
+}
+
+// DownloadStockImportTemplate writes a CSV file with the column headings of a
+// stock import and an example opening balance row
+func (h *StockImportHandler) DownloadStockImportTemplate(w http.ResponseWriter, r *http.Request) {
+
+	example := map[string]string{
+		"Transaction Type": string(model.StockAdjustUpTransactionType),
+		"Stock Code":       "ABC123",
+		"Qty":              "10",
+		"From Location":    "MAIN",
+		"From Bin":         "A1",
+		"Unit Cost":        "2.50",
+		"Note":             "Opening balance",
+	}
+
+	exampleRow := []string{}
+	for _, column := range model.StockImportColumns {
+		exampleRow = append(exampleRow, example[column])
+	}
+
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", "attachment; filename=\"stock_import_template.csv\"")
+
+	writer := csv.NewWriter(w)
+	_ = writer.Write(model.StockImportColumns)
+	_ = writer.Write(exampleRow)
+	writer.Flush()
+}
+
+func (h *StockImportHandler) StockImportPage(w http.ResponseWriter, r *http.Request) {
+	stockImportID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid stock import ID", http.StatusBadRequest)
+		return
+	}
+
+	h.renderStockImportPage(w, r, stockImportID, &stockview.StockImportPageProps{})
+}
+
+func (h *StockImportHandler) PostStockImport(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	if !ctx.User.Permissions.SupplyChain.Admin {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	stockImportID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid stock import ID", http.StatusBadRequest)
+		return
+	}
+
+	err = r.ParseForm()
+	if err != nil {
+		http.Error(w, "Error parsing form", http.StatusBadRequest)
+		return
+	}
+
+	var fd postStockImportFormData
+	err = appurl.Unmarshal(r.Form, &fd)
+	if err != nil {
+		http.Error(w, "Error decoding form", http.StatusBadRequest)
+		return
+	}
+
+	err = h.stockImportService.PostStockImport(
+		r.Context(),
+		stockImportID,
+		fd.AcknowledgeNegativeStock,
+		ctx.User.UserID,
+	)
+	if err != nil {
+		var negativeStockErr *service.NegativeStockError
+		h.renderStockImportPage(w, r, stockImportID, &stockview.StockImportPageProps{
+			ErrorText:            fmt.Sprintf("Error posting import: %v", err),
+			NegativeStockWarning: errors.As(err, &negativeStockErr) && negativeStockErr.IsWarning(),
+		})
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/stock/imports/%d", stockImportID), http.StatusSeeOther)
+}
+
+func (h *StockImportHandler) CancelStockImport(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	if !ctx.User.Permissions.SupplyChain.Admin {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	stockImportID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid stock import ID", http.StatusBadRequest)
+		return
+	}
+
+	err = h.stockImportService.CancelStockImport(r.Context(), stockImportID)
+	if err != nil {
+		h.renderStockImportPage(w, r, stockImportID, &stockview.StockImportPageProps{
+			ErrorText: fmt.Sprintf("Error cancelling import: %v", err),
+		})
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/stock/imports/%d", stockImportID), http.StatusSeeOther)
+}
+
+func (h *StockImportHandler) renderStockImportsPage(
+	w http.ResponseWriter,
+	r *http.Request,
+	props *stockview.StockImportsPageProps,
+) {
+	ctx := reqcontext.GetContext(r)
+
+	type urlVals struct {
+		Status   string
+		Page     int
+		PageSize int
+	}
+
+	var uv urlVals
+
+	err := appurl.Unmarshal(r.URL.Query(), &uv)
+	if err != nil {
+		http.Error(w, "Error decoding url values", http.StatusBadRequest)
+		return
+	}
+
+	if uv.Page == 0 {
+		uv.Page = 1
+	}
+	if uv.PageSize == 0 {
+		uv.PageSize = 50
+	}
+
+	stockImports, count, err := h.stockImportService.GetStockImports(r.Context(), &model.GetStockImportsQuery{
+		Status:   model.StockImportStatus(uv.Status),
+		Page:     uv.Page,
+		PageSize: uv.PageSize,
+	})
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Error fetching stock imports", http.StatusInternalServerError)
+		return
+	}
+
+	if props.ValidationErrors == nil {
+		props.ValidationErrors = validate.ValidationErrors{}
+	}
+
+	props.Ctx = ctx
+	props.StockImports = stockImports
+	props.StockImportsCount = count
+	props.Status = uv.Status
+	props.Page = uv.Page
+	props.PageSize = uv.PageSize
+
+	_ = stockview.StockImportsPage(props).Render(w)
+}
+
+// renderStockImportPage loads the import and the rows of its file for the
+// preview. Errors are taken from props.
+func (h *StockImportHandler) renderStockImportPage(
+	w http.ResponseWriter,
+	r *http.Request,
+	stockImportID int,
+	props *stockview.StockImportPageProps,
+) {
+	ctx := reqcontext.GetContext(r)
+
+	stockImport, err := h.stockImportService.GetStockImport(r.Context(), stockImportID)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Error fetching stock import", http.StatusInternalServerError)
+		return
+	}
+	if stockImport == nil {
+		http.Error(w, "Stock import not found", http.StatusNotFound)
+		return
+	}
+
+	rows, err := h.stockImportService.GetStockImportRows(r.Context(), stockImport)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Error reading stock import file", http.StatusInternalServerError)
+		return
+	}
+
+	props.Ctx = ctx
+	props.Rows = rows
+	props.StockImport = *stockImport
+	props.CanEdit = ctx.User.Permissions.SupplyChain.Admin
+
+	_ = stockview.StockImportPage(props).Render(w)
+}
+
+type postStockImportFormData struct {
+	AcknowledgeNegativeStock bool
+}
@@ -15,15 +15,18 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/shopspring/decimal"
 	"github.com/skip2/go-qrcode"
 )
 
 type StockItemHandler struct {
 	stockItemService service.StockItemService
+	stockBOMService  service.StockBOMService
 	commentService   service.CommentService
 	galleryService   service.GalleryService
 	appHMAC          apphmac.AppHMAC
@@ -31,12 +34,14 @@ type StockItemHandler struct {
 
 func NewStockItemHandler(
 	stockItemService service.StockItemService,
+	stockBOMService service.StockBOMService,
 	commentService service.CommentService,
 	galleryService service.GalleryService,
 	appHMAC apphmac.AppHMAC,
 ) *StockItemHandler {
 	return &StockItemHandler{
 		stockItemService: stockItemService,
+		stockBOMService:  stockBOMService,
 		commentService:   commentService,
 		galleryService:   galleryService,
 		appHMAC:          appHMAC,
@@ -118,6 +123,26 @@ func (h *StockItemHandler) StockItemPage(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	stockItemUnits, err := h.stockItemService.GetStockItemUnits(r.Context(), stockItemID)
+	if err != nil {
+		http.Error(w, "Error fetching Stock item units", http.StatusInternalServerError)
+		return
+	}
+
+	stockBOMs, err := h.stockBOMService.GetStockBOMs(r.Context(), stockItemID)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Error fetching Stock item bills of materials", http.StatusInternalServerError)
+		return
+	}
+
+	whereUsed, err := h.stockBOMService.GetStockBOMWhereUsed(r.Context(), stockItemID)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Error fetching Stock item where used", http.StatusInternalServerError)
+		return
+	}
+
 	comments, err := h.commentService.GetComments(r.Context(), stockItem.CommentThreadID, ctx.User.UserID)
 	if err != nil {
 		log.Println(err)
@@ -194,6 +219,9 @@ func (h *StockItemHandler) StockItemPage(w http.ResponseWriter, r *http.Request)
 	_ = stockitemview.StockItemPage(&stockitemview.StockItemPageProps{
 		Ctx:                     ctx,
 		StockItem:               *stockItem,
+		StockItemUnits:          stockItemUnits,
+		StockBOMs:               stockBOMs,
+		WhereUsed:               whereUsed,
 		QRCode:                  qrCodeURI,
 		GalleryURL:              galleryURL,
 		GalleryImageURLs:        galleryImgURLs,
@@ -260,8 +288,11 @@ func (h *StockItemHandler) AddStockItem(w http.ResponseWriter, r *http.Request)
 	}
 
 	validationErrors, err = h.stockItemService.CreateStockItem(r.Context(), &model.PostStockItem{
-		StockCode:   formData.StockCode,
-		Description: formData.Description,
+		StockCode:     formData.StockCode,
+		Description:   formData.Description,
+		BaseUnit:      formData.BaseUnit,
+		IsSerialised:  formData.IsSerialised,
+		CostingMethod: model.StockCostingMethod(formData.CostingMethod),
 	}, ctx.User.UserID)
 	if err != nil {
 		http.Error(w, "Error adding Stock item", http.StatusInternalServerError)
@@ -314,11 +345,11 @@ func (h *StockItemHandler) EditStockItemPage(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	_ = stockitemview.EditStockItemPage(&stockitemview.EditStockItemPageProps{
+	h.renderEditStockItemPage(w, r, &stockitemview.EditStockItemPageProps{
 		Ctx:       ctx,
 		StockItem: *stockItem,
 		Values:    r.Form,
-	}).Render(w)
+	})
 }
 
 func (h *StockItemHandler) EditStockItem(w http.ResponseWriter, r *http.Request) {
@@ -367,8 +398,11 @@ func (h *StockItemHandler) EditStockItem(w http.ResponseWriter, r *http.Request)
 	formData.normalise()
 
 	validationErrors, err := h.stockItemService.UpdateStockItem(r.Context(), stockItemID, &model.PostStockItem{
-		StockCode:   formData.StockCode,
-		Description: formData.Description,
+		StockCode:     formData.StockCode,
+		Description:   formData.Description,
+		BaseUnit:      formData.BaseUnit,
+		IsSerialised:  formData.IsSerialised,
+		CostingMethod: model.StockCostingMethod(formData.CostingMethod),
 	}, ctx.User.UserID)
 
 	if err != nil {
@@ -378,32 +412,162 @@ func (h *StockItemHandler) EditStockItem(w http.ResponseWriter, r *http.Request)
 	}
 
 	if len(validationErrors) > 0 {
-		_ = stockitemview.EditStockItemPage(&stockitemview.EditStockItemPageProps{
+		h.renderEditStockItemPage(w, r, &stockitemview.EditStockItemPageProps{
 			Ctx:              ctx,
 			StockItem:        *stockItem,
 			Values:           values,
 			ValidationErrors: validationErrors,
 			IsSubmission:     true,
-		}).Render(w)
+		})
 		return
 	}
 
 	http.Redirect(w, r, fmt.Sprintf("/stock-items/%d", stockItemID), http.StatusSeeOther)
 }
 
+// renderEditStockItemPage renders the edit page with the alternate units of
+// the stock item, which are managed from the same page
+func (h *StockItemHandler) renderEditStockItemPage(
+	w http.ResponseWriter,
+	r *http.Request,
+	props *stockitemview.EditStockItemPageProps,
+) {
+	stockItemUnits, err := h.stockItemService.GetStockItemUnits(r.Context(), props.StockItem.StockItemID)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Error fetching Stock item units", http.StatusInternalServerError)
+		return
+	}
+
+	props.StockItemUnits = stockItemUnits
+
+	_ = stockitemview.EditStockItemPage(props).Render(w)
+}
+
+func (h *StockItemHandler) AddStockItemUnit(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	hasPermission := ctx.User.Permissions.Stock.Admin
+	if !hasPermission {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	stockItemID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid stock item ID", http.StatusBadRequest)
+		return
+	}
+
+	stockItem, err := h.stockItemService.GetStockItem(r.Context(), stockItemID, ctx.User)
+	if err != nil {
+		http.Error(w, "Error getting Stock item", http.StatusInternalServerError)
+		return
+	}
+
+	if stockItem == nil {
+		http.Error(w, "Stock item does not exist", http.StatusBadRequest)
+		return
+	}
+
+	err = r.ParseForm()
+	if err != nil {
+		http.Error(w, "Error parsing form", http.StatusBadRequest)
+		return
+	}
+
+	var fd postStockItemUnitFormData
+	err = appurl.Unmarshal(r.Form, &fd)
+	if err != nil {
+		http.Error(w, "Error decoding form", http.StatusBadRequest)
+		return
+	}
+
+	fd.normalise()
+
+	validationErrors, err := h.stockItemService.AddStockItemUnit(r.Context(), stockItemID, &model.NewStockItemUnit{
+		Unit:             fd.Unit,
+		ConversionFactor: fd.ConversionFactor,
+	}, ctx.User.UserID)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Error adding Stock item unit", http.StatusInternalServerError)
+		return
+	}
+
+	if len(validationErrors) > 0 {
+		h.renderEditStockItemPage(w, r, &stockitemview.EditStockItemPageProps{
+			Ctx:                  ctx,
+			StockItem:            *stockItem,
+			Values:               url.Values{},
+			UnitValues:           r.Form,
+			UnitValidationErrors: validationErrors,
+			IsUnitSubmission:     true,
+		})
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/stock-items/%d/edit", stockItemID), http.StatusSeeOther)
+}
+
+func (h *StockItemHandler) DeleteStockItemUnit(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	hasPermission := ctx.User.Permissions.Stock.Admin
+	if !hasPermission {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	stockItemID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid stock item ID", http.StatusBadRequest)
+		return
+	}
+
+	stockItemUnitID, err := strconv.Atoi(r.PathValue("unitID"))
+	if err != nil {
+		http.Error(w, "Invalid unit ID", http.StatusBadRequest)
+		return
+	}
+
+	err = h.stockItemService.DeleteStockItemUnit(r.Context(), stockItemID, stockItemUnitID)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Error removing Stock item unit", http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/stock-items/%d/edit", stockItemID), http.StatusSeeOther)
+}
+
 type postStockItemFormData struct {
-	StockCode   string
-	Description string
+	StockCode     string
+	Description   string
+	BaseUnit      string
+	IsSerialised  bool
+	CostingMethod string
 }
 
 func (fd *postStockItemFormData) normalise() {
 	// trim and uppercase
 	fd.StockCode = strings.ToUpper(strings.TrimSpace(fd.StockCode))
+	fd.BaseUnit = strings.ToUpper(strings.TrimSpace(fd.BaseUnit))
 
 	// trim
 	fd.Description = strings.TrimSpace(fd.Description)
 }
 
+type postStockItemUnitFormData struct {
+	Unit             string
+	ConversionFactor decimal.Decimal
+}
+
+func (fd *postStockItemUnitFormData) normalise() {
+	// trim and uppercase
+	fd.Unit = strings.ToUpper(strings.TrimSpace(fd.Unit))
+}
+
 func (h *StockItemHandler) GetStockCodes(w http.ResponseWriter, r *http.Request) {
 
 	type urlVals struct {
@@ -0,0 +1,266 @@
+package handler
+
+import (
+	"app/internal/model"
+	"app/internal/pdftemplate"
+	"app/internal/service"
+	"app/internal/views/stockitemview"
+	"app/pkg/appurl"
+	"app/pkg/reqcontext"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+type StockLabelHandler struct {
+	stockItemService service.StockItemService
+	pdfService       service.PDFService
+}
+
+func NewStockLabelHandler(
+	stockItemService service.StockItemService,
+	pdfService service.PDFService,
+) *StockLabelHandler {
+	return &StockLabelHandler{
+		stockItemService: stockItemService,
+		pdfService:       pdfService,
+	}
+}
+
+// StockLabelsPage takes what to print on labels for the stock item. The lot,
+// qty and unit can be given in the url, as they are after a posting.
+func (h *StockLabelHandler) StockLabelsPage(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	if !model.CanPrintStockLabels(ctx.User.Permissions) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	type urlVals struct {
+		LotNumber string
+		Qty       *decimal.Decimal
+		Unit      string
+	}
+
+	var uv urlVals
+
+	err := appurl.Unmarshal(r.URL.Query(), &uv)
+	if err != nil {
+		http.Error(w, "Error decoding url values", http.StatusBadRequest)
+		return
+	}
+
+	h.renderStockLabelsPage(w, r, &stockitemview.StockLabelsPageProps{
+		Values: model.LabelGenerator{
+			LabelCount: 1,
+			LotNumber:  uv.LotNumber,
+			Qty:        uv.Qty,
+			Unit:       uv.Unit,
+		},
+	})
+}
+
+func (h *StockLabelHandler) PrintStockLabels(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	if !model.CanPrintStockLabels(ctx.User.Permissions) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	err := r.ParseForm()
+	if err != nil {
+		http.Error(w, "Error parsing form", http.StatusBadRequest)
+		return
+	}
+
+	var fd printStockLabelsFormData
+	err = appurl.Unmarshal(r.Form, &fd)
+	if err != nil {
+		http.Error(w, "Error decoding form", http.StatusBadRequest)
+		return
+	}
+
+	fd.normalise()
+
+	props := &stockitemview.StockLabelsPageProps{
+		Values: model.LabelGenerator{
+			LabelCount:      fd.LabelCount,
+			LotNumber:       fd.LotNumber,
+			Qty:             fd.Qty,
+			Unit:            fd.Unit,
+			LabelSize:       fd.LabelSize,
+			Barcode:         fd.Barcode,
+			RequirementName: fd.RequirementName,
+		},
+	}
+
+	errorText := fd.validate()
+	if errorText != "" {
+		props.ErrorText = errorText
+		h.renderStockLabelsPage(w, r, props)
+		return
+	}
+
+	stockItem, ok := h.getStockItem(w, r)
+	if !ok {
+		return
+	}
+
+	unit := fd.Unit
+	if fd.Qty != nil && unit == "" {
+		unit = stockItem.BaseUnit
+	}
+
+	inputData, err := json.Marshal(pdftemplate.StockLabelData{
+		StockCode:   stockItem.StockCode,
+		Description: stockItem.Description,
+		LotNumber:   fd.LotNumber,
+		Qty:         fd.Qty,
+		Unit:        unit,
+		LabelSize:   fd.LabelSize,
+		Barcode:     pdftemplate.StockLabelBarcode(fd.Barcode),
+		LabelCount:  fd.LabelCount,
+	})
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Label generation failed", http.StatusInternalServerError)
+		return
+	}
+
+	_, _, err = h.pdfService.PrintAndLog(
+		r.Context(),
+		pdftemplate.StockLabelTemplateDefinition.Name,
+		string(inputData),
+		fd.RequirementName,
+		ctx.User.UserID,
+	)
+	if err != nil {
+		log.Println("An error occurred printing stock labels:", err)
+		props.ErrorText = fmt.Sprintf("Error printing labels: %v", err)
+		h.renderStockLabelsPage(w, r, props)
+		return
+	}
+
+	props.SuccessText = fmt.Sprintf("Sent %d labels to %s", fd.LabelCount, fd.RequirementName)
+	h.renderStockLabelsPage(w, r, props)
+}
+
+func (h *StockLabelHandler) getStockItem(
+	w http.ResponseWriter,
+	r *http.Request,
+) (*model.StockItem, bool) {
+	ctx := reqcontext.GetContext(r)
+
+	stockItemID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid stock item ID", http.StatusBadRequest)
+		return nil, false
+	}
+
+	stockItem, err := h.stockItemService.GetStockItem(r.Context(), stockItemID, ctx.User)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Error fetching Stock item", http.StatusInternalServerError)
+		return nil, false
+	}
+	if stockItem == nil {
+		http.Error(w, "Stock item not found", http.StatusNotFound)
+		return nil, false
+	}
+
+	return stockItem, true
+}
+
+// renderStockLabelsPage loads the stock item and the print requirements to
+// choose from. Form values and messages are taken from props.
+func (h *StockLabelHandler) renderStockLabelsPage(
+	w http.ResponseWriter,
+	r *http.Request,
+	props *stockitemview.StockLabelsPageProps,
+) {
+	ctx := reqcontext.GetContext(r)
+
+	stockItem, ok := h.getStockItem(w, r)
+	if !ok {
+		return
+	}
+
+	printRequirements, err := h.pdfService.ListPrintRequirements(r.Context())
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Error fetching print requirements", http.StatusInternalServerError)
+		return
+	}
+
+	props.Ctx = ctx
+	props.StockItem = *stockItem
+	props.PrintRequirements = printRequirements
+
+	_ = stockitemview.StockLabelsPage(props).Render(w)
+}
+
+type printStockLabelsFormData struct {
+	LabelCount      int
+	LotNumber       string
+	Qty             *decimal.Decimal
+	Unit            string
+	LabelSize       string
+	Barcode         string
+	RequirementName string
+}
+
+func (fd *printStockLabelsFormData) normalise() {
+
+	// trim and uppercase
+	fd.LotNumber = strings.ToUpper(strings.TrimSpace(fd.LotNumber))
+	fd.Unit = strings.ToUpper(strings.TrimSpace(fd.Unit))
+
+	// trim
+	fd.RequirementName = strings.TrimSpace(fd.RequirementName)
+
+}
+
+func (fd *printStockLabelsFormData) validate() string {
+
+	if fd.LabelCount < 1 || fd.LabelCount > 500 {
+		return "Number of labels must be between 1 and 500"
+	}
+	if fd.Qty != nil && fd.Qty.IsNegative() {
+		return "Qty cannot be negative"
+	}
+	if !slices.ContainsFunc(pdftemplate.StockLabelSizes, func(s pdftemplate.StockLabelSize) bool {
+		return s.Name == fd.LabelSize
+	}) {
+		return "Label size is invalid"
+	}
+	if !slices.Contains(pdftemplate.StockLabelBarcodes, pdftemplate.StockLabelBarcode(fd.Barcode)) {
+		return "Barcode is invalid"
+	}
+	if fd.RequirementName == "" {
+		return "Print requirement cannot be empty"
+	}
+
+	return ""
+}
+
+// stockLabelsURL links to printing labels for stock just posted
+func stockLabelsURL(stockItemID int, lotNumber string, qty decimal.Decimal, unit string) string {
+	values := url.Values{}
+	if lotNumber != "" {
+		values.Set("LotNumber", lotNumber)
+	}
+	values.Set("Qty", qty.String())
+	if unit != "" {
+		values.Set("Unit", unit)
+	}
+	return fmt.Sprintf("/stock-items/%d/labels?%s", stockItemID, values.Encode())
+}
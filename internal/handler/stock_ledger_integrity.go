@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"app/internal/service"
+	"app/internal/views/stockview"
+	"app/pkg/appurl"
+	"app/pkg/reqcontext"
+	"fmt"
+	"log"
+	"net/http"
+)
+
+type StockLedgerIntegrityHandler struct {
+	stockLedgerIntegrityService service.StockLedgerIntegrityService
+}
+
+func NewStockLedgerIntegrityHandler(
+	stockLedgerIntegrityService service.StockLedgerIntegrityService,
+) *StockLedgerIntegrityHandler {
+	return &StockLedgerIntegrityHandler{
+		stockLedgerIntegrityService: stockLedgerIntegrityService,
+	}
+}
+
+func (h *StockLedgerIntegrityHandler) StockLedgerIntegrityPage(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	if !ctx.User.Permissions.SupplyChain.Admin {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	type urlVals struct {
+		Run bool
+	}
+
+	var uv urlVals
+
+	err := appurl.Unmarshal(r.URL.Query(), &uv)
+	if err != nil {
+		http.Error(w, "Error decoding url values", http.StatusBadRequest)
+		return
+	}
+
+	props := &stockview.StockLedgerIntegrityPageProps{
+		Ctx: ctx,
+	}
+
+	// The check reads the whole ledger so only run it on request
+	if uv.Run {
+		report, err := h.stockLedgerIntegrityService.CheckStockLedger(r.Context())
+		if err != nil {
+			log.Println(err)
+			props.ErrorText = fmt.Sprintf("Error checking stock ledger: %v", err)
+		}
+		props.Report = report
+	}
+
+	_ = stockview.StockLedgerIntegrityPage(props).Render(w)
+}
+
+func (h *StockLedgerIntegrityHandler) RebuildStockLedger(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	if !ctx.User.Permissions.SupplyChain.Admin {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	props := &stockview.StockLedgerIntegrityPageProps{
+		Ctx: ctx,
+	}
+
+	result, err := h.stockLedgerIntegrityService.RebuildStockLedger(r.Context())
+	if err != nil {
+		log.Println(err)
+		props.ErrorText = fmt.Sprintf("Error rebuilding stock ledger: %v", err)
+		_ = stockview.StockLedgerIntegrityPage(props).Render(w)
+		return
+	}
+	props.RebuildResult = result
+
+	// Check again so the page shows the state after the rebuild
+	report, err := h.stockLedgerIntegrityService.CheckStockLedger(r.Context())
+	if err != nil {
+		log.Println(err)
+		props.ErrorText = fmt.Sprintf("Error checking stock ledger: %v", err)
+	}
+	props.Report = report
+
+	_ = stockview.StockLedgerIntegrityPage(props).Render(w)
+}
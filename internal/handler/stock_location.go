@@ -0,0 +1,404 @@
+package handler
+
+import (
+	"app/internal/components"
+	"app/internal/model"
+	"app/internal/service"
+	"app/internal/views/stockview"
+	"app/pkg/appurl"
+	"app/pkg/reqcontext"
+	"app/pkg/validate"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+type StockLocationHandler struct {
+	stockLocationService service.StockLocationService
+}
+
+func NewStockLocationHandler(
+	stockLocationService service.StockLocationService,
+) *StockLocationHandler {
+	return &StockLocationHandler{
+		stockLocationService: stockLocationService,
+	}
+}
+
+func (h *StockLocationHandler) StockLocationsPage(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	if !ctx.User.Permissions.SupplyChain.Admin {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	h.renderStockLocationsPage(w, r, &stockview.StockLocationsPageProps{})
+}
+
+type postStockLocationFormData struct {
+	Location     string
+	Description  string
+	LocationType string
+	IsArchived   bool
+}
+
+func (fd *postStockLocationFormData) normalise() {
+	fd.Location = strings.ToUpper(strings.TrimSpace(fd.Location))
+	fd.Description = strings.TrimSpace(fd.Description)
+}
+
+func (h *StockLocationHandler) CreateStockLocation(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	if !ctx.User.Permissions.SupplyChain.Admin {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	err := r.ParseForm()
+	if err != nil {
+		http.Error(w, "Error parsing form", http.StatusBadRequest)
+		return
+	}
+
+	var fd postStockLocationFormData
+	err = appurl.Unmarshal(r.Form, &fd)
+	if err != nil {
+		http.Error(w, "Error decoding form", http.StatusBadRequest)
+		return
+	}
+
+	fd.normalise()
+
+	stockLocationID, validationErrors, err := h.stockLocationService.CreateStockLocation(
+		r.Context(),
+		&model.NewStockLocation{
+			Location:     fd.Location,
+			Description:  fd.Description,
+			LocationType: model.StockLocationType(fd.LocationType),
+		},
+		ctx.User.UserID,
+	)
+	if err != nil {
+		h.renderStockLocationsPage(w, r, &stockview.StockLocationsPageProps{
+			Values:    r.Form,
+			ErrorText: fmt.Sprintf("Error adding location: %v", err),
+		})
+		return
+	}
+
+	if len(validationErrors) > 0 {
+		h.renderStockLocationsPage(w, r, &stockview.StockLocationsPageProps{
+			Values:           r.Form,
+			ValidationErrors: validationErrors,
+			IsSubmission:     true,
+		})
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/stock/locations/%d", stockLocationID), http.StatusSeeOther)
+}
+
+func (h *StockLocationHandler) StockLocationPage(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	if !ctx.User.Permissions.SupplyChain.Admin {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	stockLocationID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid location ID", http.StatusBadRequest)
+		return
+	}
+
+	h.renderStockLocationPage(w, r, stockLocationID, &stockview.StockLocationPageProps{})
+}
+
+func (h *StockLocationHandler) UpdateStockLocation(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	if !ctx.User.Permissions.SupplyChain.Admin {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	stockLocationID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid location ID", http.StatusBadRequest)
+		return
+	}
+
+	err = r.ParseForm()
+	if err != nil {
+		http.Error(w, "Error parsing form", http.StatusBadRequest)
+		return
+	}
+
+	var fd postStockLocationFormData
+	err = appurl.Unmarshal(r.Form, &fd)
+	if err != nil {
+		http.Error(w, "Error decoding form", http.StatusBadRequest)
+		return
+	}
+
+	fd.normalise()
+
+	validationErrors, err := h.stockLocationService.UpdateStockLocation(
+		r.Context(),
+		stockLocationID,
+		&model.StockLocationUpdate{
+			Description:  fd.Description,
+			LocationType: model.StockLocationType(fd.LocationType),
+			IsArchived:   fd.IsArchived,
+		},
+		ctx.User.UserID,
+	)
+	if err != nil {
+		h.renderStockLocationPage(w, r, stockLocationID, &stockview.StockLocationPageProps{
+			ErrorText: fmt.Sprintf("Error updating location: %v", err),
+		})
+		return
+	}
+
+	if len(validationErrors) > 0 {
+		h.renderStockLocationPage(w, r, stockLocationID, &stockview.StockLocationPageProps{
+			ErrorText: validationErrors.GetError("LocationType", "Type"),
+		})
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/stock/locations/%d", stockLocationID), http.StatusSeeOther)
+}
+
+type postStockBinFormData struct {
+	Bin        string
+	Capacity   *decimal.Decimal
+	IsArchived bool
+}
+
+func (fd *postStockBinFormData) normalise() {
+	fd.Bin = strings.ToUpper(strings.TrimSpace(fd.Bin))
+}
+
+func (h *StockLocationHandler) SaveStockBin(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	if !ctx.User.Permissions.SupplyChain.Admin {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	stockLocationID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid location ID", http.StatusBadRequest)
+		return
+	}
+
+	err = r.ParseForm()
+	if err != nil {
+		http.Error(w, "Error parsing form", http.StatusBadRequest)
+		return
+	}
+
+	var fd postStockBinFormData
+	err = appurl.Unmarshal(r.Form, &fd)
+	if err != nil {
+		http.Error(w, "Error decoding form", http.StatusBadRequest)
+		return
+	}
+
+	fd.normalise()
+
+	validationErrors, err := h.stockLocationService.SaveStockBin(
+		r.Context(),
+		&model.PostStockBin{
+			StockLocationID: stockLocationID,
+			Bin:             fd.Bin,
+			Capacity:        fd.Capacity,
+			IsArchived:      fd.IsArchived,
+		},
+		ctx.User.UserID,
+	)
+	if err != nil {
+		h.renderStockLocationPage(w, r, stockLocationID, &stockview.StockLocationPageProps{
+			Values:    r.Form,
+			ErrorText: fmt.Sprintf("Error saving bin: %v", err),
+		})
+		return
+	}
+
+	if len(validationErrors) > 0 {
+		h.renderStockLocationPage(w, r, stockLocationID, &stockview.StockLocationPageProps{
+			Values:           r.Form,
+			ValidationErrors: validationErrors,
+			IsSubmission:     true,
+		})
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/stock/locations/%d", stockLocationID), http.StatusSeeOther)
+}
+
+// GetStockLocations renders the active locations as search select options
+func (h *StockLocationHandler) GetStockLocations(w http.ResponseWriter, r *http.Request) {
+
+	searchText := strings.TrimSpace(r.URL.Query().Get("SearchText"))
+
+	locations, err := h.stockLocationService.SearchStockLocations(r.Context(), searchText)
+	if err != nil {
+		log.Println(err)
+	}
+
+	var searchSelectOptions []components.SearchSelectOption
+	for _, l := range locations {
+		text := l.Location
+		if l.Description != "" {
+			text += " - " + l.Description
+		}
+		searchSelectOptions = append(searchSelectOptions, components.SearchSelectOption{
+			Value: l.Location,
+			Text:  text,
+		})
+	}
+	_ = components.SearchSelectOptions(searchSelectOptions).Render(w)
+}
+
+// GetStockBins renders the active bins of a location as search select
+// options. The location is read from the form field named in the path, as
+// the search select sends the whole form.
+func (h *StockLocationHandler) GetStockBins(w http.ResponseWriter, r *http.Request) {
+
+	query := r.URL.Query()
+	location := query.Get(r.PathValue("locationField"))
+	searchText := strings.TrimSpace(query.Get("SearchText"))
+
+	searchSelectOptions := []components.SearchSelectOption{{
+		Value: "",
+		Text:  "No bin",
+	}}
+
+	if location != "" {
+		bins, err := h.stockLocationService.SearchStockBins(r.Context(), location, searchText)
+		if err != nil {
+			log.Println(err)
+		}
+
+		for _, b := range bins {
+			searchSelectOptions = append(searchSelectOptions, components.SearchSelectOption{
+				Value: b.Bin,
+				Text:  b.Bin,
+			})
+		}
+	}
+
+	_ = components.SearchSelectOptions(searchSelectOptions).Render(w)
+}
+
+func (h *StockLocationHandler) renderStockLocationsPage(
+	w http.ResponseWriter,
+	r *http.Request,
+	props *stockview.StockLocationsPageProps,
+) {
+	ctx := reqcontext.GetContext(r)
+
+	type urlVals struct {
+		SearchText   string
+		ShowArchived bool
+		Page         int
+		PageSize     int
+	}
+
+	var uv urlVals
+
+	err := appurl.Unmarshal(r.URL.Query(), &uv)
+	if err != nil {
+		http.Error(w, "Error decoding url values", http.StatusBadRequest)
+		return
+	}
+
+	uv.SearchText = strings.TrimSpace(uv.SearchText)
+
+	if uv.Page == 0 {
+		uv.Page = 1
+	}
+	if uv.PageSize == 0 {
+		uv.PageSize = 50
+	}
+
+	locations, count, err := h.stockLocationService.GetStockLocations(r.Context(), &model.GetStockLocationsQuery{
+		SearchText:   uv.SearchText,
+		ShowArchived: uv.ShowArchived,
+		Page:         uv.Page,
+		PageSize:     uv.PageSize,
+	})
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Error fetching locations", http.StatusInternalServerError)
+		return
+	}
+
+	if props.Values == nil {
+		props.Values = url.Values{}
+	}
+	if props.ValidationErrors == nil {
+		props.ValidationErrors = validate.ValidationErrors{}
+	}
+
+	props.Ctx = ctx
+	props.StockLocations = locations
+	props.StockLocationsCount = count
+	props.SearchText = uv.SearchText
+	props.ShowArchived = uv.ShowArchived
+	props.Page = uv.Page
+	props.PageSize = uv.PageSize
+
+	_ = stockview.StockLocationsPage(props).Render(w)
+}
+
+func (h *StockLocationHandler) renderStockLocationPage(
+	w http.ResponseWriter,
+	r *http.Request,
+	stockLocationID int,
+	props *stockview.StockLocationPageProps,
+) {
+	ctx := reqcontext.GetContext(r)
+
+	location, err := h.stockLocationService.GetStockLocation(r.Context(), stockLocationID)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Error fetching location", http.StatusInternalServerError)
+		return
+	}
+	if location == nil {
+		http.Error(w, "Location not found", http.StatusNotFound)
+		return
+	}
+
+	bins, err := h.stockLocationService.GetStockBins(r.Context(), stockLocationID)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Error fetching bins", http.StatusInternalServerError)
+		return
+	}
+
+	if props.Values == nil {
+		props.Values = url.Values{}
+	}
+	if props.ValidationErrors == nil {
+		props.ValidationErrors = validate.ValidationErrors{}
+	}
+
+	props.Ctx = ctx
+	props.StockLocation = *location
+	props.Bins = bins
+
+	_ = stockview.StockLocationPage(props).Render(w)
+}
@@ -0,0 +1,224 @@
+package handler
+
+import (
+	"app/internal/model"
+	"app/internal/service"
+	"app/internal/views/stockview"
+	"app/pkg/appurl"
+	"app/pkg/reqcontext"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type StockLotHandler struct {
+	stockLotService service.StockLotService
+}
+
+func NewStockLotHandler(
+	stockLotService service.StockLotService,
+) *StockLotHandler {
+	return &StockLotHandler{
+		stockLotService: stockLotService,
+	}
+}
+
+func (h *StockLotHandler) StockLotsPage(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	type urlVals struct {
+		StockCode          string
+		LotNumber          string
+		Status             string
+		ExpiringWithinDays int
+		Page               int
+		PageSize           int
+	}
+
+	var uv urlVals
+
+	err := appurl.Unmarshal(r.URL.Query(), &uv)
+	if err != nil {
+		http.Error(w, "Error decoding url values", http.StatusBadRequest)
+		return
+	}
+
+	uv.StockCode = strings.ToUpper(strings.TrimSpace(uv.StockCode))
+	uv.LotNumber = strings.ToUpper(strings.TrimSpace(uv.LotNumber))
+
+	if uv.Page == 0 {
+		uv.Page = 1
+	}
+	if uv.PageSize == 0 {
+		uv.PageSize = 50
+	}
+
+	stockLots, count, err := h.stockLotService.GetStockLots(r.Context(), &model.GetStockLotsQuery{
+		StockCode:          uv.StockCode,
+		LotNumber:          uv.LotNumber,
+		Status:             model.StockLotStatus(uv.Status),
+		ExpiringWithinDays: uv.ExpiringWithinDays,
+		Page:               uv.Page,
+		PageSize:           uv.PageSize,
+	})
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Error fetching lots", http.StatusInternalServerError)
+		return
+	}
+
+	_ = stockview.StockLotsPage(&stockview.StockLotsPageProps{
+		Ctx:                ctx,
+		StockLots:          stockLots,
+		StockLotsCount:     count,
+		StockCode:          uv.StockCode,
+		LotNumber:          uv.LotNumber,
+		Status:             uv.Status,
+		ExpiringWithinDays: uv.ExpiringWithinDays,
+		Page:               uv.Page,
+		PageSize:           uv.PageSize,
+	}).Render(w)
+}
+
+func (h *StockLotHandler) StockLotPage(w http.ResponseWriter, r *http.Request) {
+	stockLotID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid lot ID", http.StatusBadRequest)
+		return
+	}
+
+	h.renderStockLotPage(w, r, stockLotID, &stockview.StockLotPageProps{})
+}
+
+type postStockLotFormData struct {
+	SupplierLotNumber string
+	ManufactureDate   *time.Time
+	ExpiryDate        *time.Time
+	Status            string
+}
+
+func (fd *postStockLotFormData) normalise() {
+	fd.SupplierLotNumber = strings.ToUpper(strings.TrimSpace(fd.SupplierLotNumber))
+}
+
+func (h *StockLotHandler) UpdateStockLot(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	if !ctx.User.Permissions.SupplyChain.Admin {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	stockLotID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid lot ID", http.StatusBadRequest)
+		return
+	}
+
+	err = r.ParseForm()
+	if err != nil {
+		http.Error(w, "Error parsing form", http.StatusBadRequest)
+		return
+	}
+
+	var fd postStockLotFormData
+	err = appurl.Unmarshal(r.Form, &fd)
+	if err != nil {
+		http.Error(w, "Error decoding form", http.StatusBadRequest)
+		return
+	}
+
+	fd.normalise()
+
+	validationErrors, err := h.stockLotService.UpdateStockLot(
+		r.Context(),
+		stockLotID,
+		&model.UpdateStockLot{
+			SupplierLotNumber: fd.SupplierLotNumber,
+			ManufactureDate:   fd.ManufactureDate,
+			ExpiryDate:        fd.ExpiryDate,
+			Status:            model.StockLotStatus(fd.Status),
+		},
+		ctx.User.UserID,
+	)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Error updating lot", http.StatusInternalServerError)
+		return
+	}
+
+	if len(validationErrors) > 0 {
+		h.renderStockLotPage(w, r, stockLotID, &stockview.StockLotPageProps{
+			Values:           r.Form,
+			ValidationErrors: validationErrors,
+			IsSubmission:     true,
+		})
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/stock/lots/%d", stockLotID), http.StatusSeeOther)
+}
+
+// FEFOSuggestions renders the suggestions for the post transaction pages
+func (h *StockLotHandler) FEFOSuggestions(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	if !ctx.User.Permissions.SupplyChain.Admin {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	type urlVals struct {
+		StockItemID int
+	}
+
+	var uv urlVals
+
+	err := appurl.Unmarshal(r.URL.Query(), &uv)
+	if err != nil {
+		http.Error(w, "Error decoding url values", http.StatusBadRequest)
+		return
+	}
+
+	suggestions, err := h.stockLotService.GetFEFOSuggestions(r.Context(), uv.StockItemID)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Error fetching suggestions", http.StatusInternalServerError)
+		return
+	}
+
+	_ = stockview.FEFOSuggestions(suggestions).Render(w)
+}
+
+func (h *StockLotHandler) renderStockLotPage(
+	w http.ResponseWriter,
+	r *http.Request,
+	stockLotID int,
+	props *stockview.StockLotPageProps,
+) {
+	ctx := reqcontext.GetContext(r)
+
+	stockLot, err := h.stockLotService.GetStockLot(r.Context(), stockLotID)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Error fetching lot", http.StatusInternalServerError)
+		return
+	}
+	if stockLot == nil {
+		http.Error(w, "Lot not found", http.StatusNotFound)
+		return
+	}
+
+	props.Ctx = ctx
+	props.StockLot = *stockLot
+	props.CanEdit = ctx.User.Permissions.SupplyChain.Admin
+	if props.Values == nil {
+		props.Values = url.Values{}
+	}
+
+	_ = stockview.StockLotPage(props).Render(w)
+}
@@ -0,0 +1,170 @@
+package handler
+
+import (
+	"app/internal/model"
+	"app/internal/service"
+	"app/internal/views/stockview"
+	"app/pkg/appurl"
+	"app/pkg/reqcontext"
+	"app/pkg/validate"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+type NegativeStockPolicyHandler struct {
+	stockTransactionService service.StockTransactionService
+	stockItemService        service.StockItemService
+}
+
+func NewNegativeStockPolicyHandler(
+	stockTransactionService service.StockTransactionService,
+	stockItemService service.StockItemService,
+) *NegativeStockPolicyHandler {
+	return &NegativeStockPolicyHandler{
+		stockTransactionService: stockTransactionService,
+		stockItemService:        stockItemService,
+	}
+}
+
+func (h *NegativeStockPolicyHandler) NegativeStockPoliciesPage(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	if !ctx.User.Permissions.SupplyChain.Admin {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	h.renderNegativeStockPoliciesPage(w, r, &stockview.NegativeStockPoliciesPageProps{
+		Values: url.Values{"Action": {string(model.WarnNegativeStockAction)}},
+	})
+}
+
+func (h *NegativeStockPolicyHandler) SaveNegativeStockPolicy(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	if !ctx.User.Permissions.SupplyChain.Admin {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	err := r.ParseForm()
+	if err != nil {
+		http.Error(w, "Error parsing form", http.StatusBadRequest)
+		return
+	}
+
+	var fd postNegativeStockPolicyFormData
+	err = appurl.Unmarshal(r.Form, &fd)
+	if err != nil {
+		http.Error(w, "Error decoding form", http.StatusBadRequest)
+		return
+	}
+
+	fd.normalise()
+
+	input := &model.PostNegativeStockPolicy{
+		Action: model.NegativeStockAction(fd.Action),
+	}
+	if fd.StockItemID != 0 {
+		input.StockItemID = &fd.StockItemID
+	}
+	if fd.Location != "" {
+		input.Location = &fd.Location
+	}
+
+	validationErrors, err := h.stockTransactionService.SaveNegativeStockPolicy(r.Context(), input, ctx.User.UserID)
+	if err != nil {
+		h.renderNegativeStockPoliciesPage(w, r, &stockview.NegativeStockPoliciesPageProps{
+			Values:    r.Form,
+			ErrorText: fmt.Sprintf("Error saving policy: %v", err),
+		})
+		return
+	}
+
+	if len(validationErrors) > 0 {
+		h.renderNegativeStockPoliciesPage(w, r, &stockview.NegativeStockPoliciesPageProps{
+			Values:           r.Form,
+			ValidationErrors: validationErrors,
+			IsSubmission:     true,
+		})
+		return
+	}
+
+	http.Redirect(w, r, "/stock/negative-stock-policies", http.StatusSeeOther)
+}
+
+func (h *NegativeStockPolicyHandler) DeleteNegativeStockPolicy(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	if !ctx.User.Permissions.SupplyChain.Admin {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	negativeStockPolicyID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid negative stock policy ID", http.StatusBadRequest)
+		return
+	}
+
+	err = h.stockTransactionService.DeleteNegativeStockPolicy(r.Context(), negativeStockPolicyID)
+	if err != nil {
+		h.renderNegativeStockPoliciesPage(w, r, &stockview.NegativeStockPoliciesPageProps{
+			ErrorText: fmt.Sprintf("Error removing policy: %v", err),
+		})
+		return
+	}
+
+	http.Redirect(w, r, "/stock/negative-stock-policies", http.StatusSeeOther)
+}
+
+func (h *NegativeStockPolicyHandler) renderNegativeStockPoliciesPage(
+	w http.ResponseWriter,
+	r *http.Request,
+	props *stockview.NegativeStockPoliciesPageProps,
+) {
+	ctx := reqcontext.GetContext(r)
+
+	policies, err := h.stockTransactionService.GetNegativeStockPolicies(r.Context())
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Error fetching negative stock policies", http.StatusInternalServerError)
+		return
+	}
+
+	stockItems, _, err := h.stockItemService.GetStockItems(r.Context(), &model.GetStockItemsQuery{
+		Page: 1, PageSize: 10000,
+	}, ctx.User.UserID)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Error fetching stock items", http.StatusInternalServerError)
+		return
+	}
+
+	if props.Values == nil {
+		props.Values = url.Values{}
+	}
+	if props.ValidationErrors == nil {
+		props.ValidationErrors = validate.ValidationErrors{}
+	}
+
+	props.Ctx = ctx
+	props.Policies = policies
+	props.StockItems = stockItems
+
+	_ = stockview.NegativeStockPoliciesPage(props).Render(w)
+}
+
+type postNegativeStockPolicyFormData struct {
+	StockItemID int
+	Location    string
+	Action      string
+}
+
+func (fd *postNegativeStockPolicyFormData) normalise() {
+	fd.Location = strings.ToUpper(strings.TrimSpace(fd.Location))
+}
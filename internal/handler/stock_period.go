@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"app/internal/service"
+	"app/internal/views/stockview"
+	"app/pkg/appurl"
+	"app/pkg/reqcontext"
+	"app/pkg/validate"
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+type StockPeriodHandler struct {
+	stockPeriodService service.StockPeriodService
+}
+
+func NewStockPeriodHandler(stockPeriodService service.StockPeriodService) *StockPeriodHandler {
+	return &StockPeriodHandler{stockPeriodService: stockPeriodService}
+}
+
+func (h *StockPeriodHandler) StockPeriodsPage(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	if !ctx.User.Permissions.SupplyChain.Admin {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	h.renderStockPeriodsPage(w, r, &stockview.StockPeriodsPageProps{})
+}
+
+type postStockPeriodFormData struct {
+	// Period is the month, as given by a month input, e.g. 2026-01
+	Period string
+	Reason string
+}
+
+func (fd *postStockPeriodFormData) normalise() {
+	fd.Period = strings.TrimSpace(fd.Period)
+	fd.Reason = strings.TrimSpace(fd.Reason)
+}
+
+func (h *StockPeriodHandler) CloseStockPeriod(w http.ResponseWriter, r *http.Request) {
+	h.postStockPeriod(w, r, stockview.CloseStockPeriodForm, h.stockPeriodService.CloseStockPeriod)
+}
+
+// ReopenStockPeriod is the audited override to allow back-dated postings
+// into a closed period again
+func (h *StockPeriodHandler) ReopenStockPeriod(w http.ResponseWriter, r *http.Request) {
+	h.postStockPeriod(w, r, stockview.ReopenStockPeriodForm, h.stockPeriodService.ReopenStockPeriod)
+}
+
+// postStockPeriod handles the close and reopen forms, which differ only in
+// the change they make
+func (h *StockPeriodHandler) postStockPeriod(
+	w http.ResponseWriter,
+	r *http.Request,
+	form string,
+	setStockPeriod func(ctx context.Context, period time.Time, reason string, userID int) (validate.ValidationErrors, error),
+) {
+	ctx := reqcontext.GetContext(r)
+
+	if !ctx.User.Permissions.SupplyChain.Admin {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	err := r.ParseForm()
+	if err != nil {
+		http.Error(w, "Error parsing form", http.StatusBadRequest)
+		return
+	}
+
+	var fd postStockPeriodFormData
+	err = appurl.Unmarshal(r.Form, &fd)
+	if err != nil {
+		http.Error(w, "Error decoding form", http.StatusBadRequest)
+		return
+	}
+
+	fd.normalise()
+
+	props := &stockview.StockPeriodsPageProps{
+		SubmittedForm: form,
+		Values:        r.Form,
+	}
+
+	period, err := time.Parse("2006-01", fd.Period)
+	if err != nil {
+		props.ValidationErrors = validate.ValidationErrors{}
+		props.ValidationErrors.Add("Period", "must be a month")
+		h.renderStockPeriodsPage(w, r, props)
+		return
+	}
+
+	validationErrors, err := setStockPeriod(r.Context(), period, fd.Reason, ctx.User.UserID)
+	if err != nil {
+		props.ErrorText = fmt.Sprintf("Error updating period: %v", err)
+		h.renderStockPeriodsPage(w, r, props)
+		return
+	}
+
+	if len(validationErrors) > 0 {
+		props.ValidationErrors = validationErrors
+		h.renderStockPeriodsPage(w, r, props)
+		return
+	}
+
+	http.Redirect(w, r, "/stock/periods", http.StatusSeeOther)
+}
+
+func (h *StockPeriodHandler) renderStockPeriodsPage(
+	w http.ResponseWriter,
+	r *http.Request,
+	props *stockview.StockPeriodsPageProps,
+) {
+	ctx := reqcontext.GetContext(r)
+
+	periods, err := h.stockPeriodService.GetStockPeriods(r.Context())
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Error fetching periods", http.StatusInternalServerError)
+		return
+	}
+
+	changes, err := h.stockPeriodService.GetStockPeriodChanges(r.Context())
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Error fetching period changes", http.StatusInternalServerError)
+		return
+	}
+
+	if props.Values == nil {
+		props.Values = url.Values{}
+	}
+	if props.ValidationErrors == nil {
+		props.ValidationErrors = validate.ValidationErrors{}
+	}
+
+	props.Ctx = ctx
+	props.Periods = periods
+	props.Changes = changes
+
+	_ = stockview.StockPeriodsPage(props).Render(w)
+}
@@ -0,0 +1,205 @@
+package handler
+
+import (
+	"app/internal/model"
+	"app/internal/service"
+	"app/internal/views/stockview"
+	"app/pkg/appurl"
+	"app/pkg/reqcontext"
+	"app/pkg/validate"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+type StockReorderHandler struct {
+	stockReorderService service.StockReorderService
+	stockItemService    service.StockItemService
+	teamService         service.TeamService
+}
+
+func NewStockReorderHandler(
+	stockReorderService service.StockReorderService,
+	stockItemService service.StockItemService,
+	teamService service.TeamService,
+) *StockReorderHandler {
+	return &StockReorderHandler{
+		stockReorderService: stockReorderService,
+		stockItemService:    stockItemService,
+		teamService:         teamService,
+	}
+}
+
+func (h *StockReorderHandler) StockReplenishmentPage(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	replenishments, err := h.stockReorderService.GetStockReplenishments(r.Context())
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Error fetching replenishments", http.StatusInternalServerError)
+		return
+	}
+
+	_ = stockview.StockReplenishmentPage(&stockview.StockReplenishmentPageProps{
+		Ctx:            ctx,
+		Replenishments: replenishments,
+	}).Render(w)
+}
+
+func (h *StockReorderHandler) StockReorderPoliciesPage(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	if !ctx.User.Permissions.SupplyChain.Admin {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	h.renderStockReorderPoliciesPage(w, r, &stockview.StockReorderPoliciesPageProps{})
+}
+
+func (h *StockReorderHandler) SaveStockReorderPolicy(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	if !ctx.User.Permissions.SupplyChain.Admin {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	err := r.ParseForm()
+	if err != nil {
+		http.Error(w, "Error parsing form", http.StatusBadRequest)
+		return
+	}
+
+	var fd postStockReorderPolicyFormData
+	err = appurl.Unmarshal(r.Form, &fd)
+	if err != nil {
+		http.Error(w, "Error decoding form", http.StatusBadRequest)
+		return
+	}
+
+	fd.normalise()
+
+	input := &model.PostStockReorderPolicy{
+		StockItemID:  fd.StockItemID,
+		MinQty:       fd.MinQty,
+		ReorderPoint: fd.ReorderPoint,
+		MaxQty:       fd.MaxQty,
+	}
+	if fd.Location != "" {
+		input.Location = &fd.Location
+	}
+	if fd.NotifyTeamID != 0 {
+		input.NotifyTeamID = &fd.NotifyTeamID
+	}
+
+	validationErrors, err := h.stockReorderService.SaveStockReorderPolicy(r.Context(), input, ctx.User.UserID)
+	if err != nil {
+		h.renderStockReorderPoliciesPage(w, r, &stockview.StockReorderPoliciesPageProps{
+			Values:    r.Form,
+			ErrorText: fmt.Sprintf("Error saving reorder policy: %v", err),
+		})
+		return
+	}
+
+	if len(validationErrors) > 0 {
+		h.renderStockReorderPoliciesPage(w, r, &stockview.StockReorderPoliciesPageProps{
+			Values:           r.Form,
+			ValidationErrors: validationErrors,
+			IsSubmission:     true,
+		})
+		return
+	}
+
+	http.Redirect(w, r, "/stock/reorder-policies", http.StatusSeeOther)
+}
+
+func (h *StockReorderHandler) DeleteStockReorderPolicy(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	if !ctx.User.Permissions.SupplyChain.Admin {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	stockReorderPolicyID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid reorder policy ID", http.StatusBadRequest)
+		return
+	}
+
+	err = h.stockReorderService.DeleteStockReorderPolicy(r.Context(), stockReorderPolicyID)
+	if err != nil {
+		h.renderStockReorderPoliciesPage(w, r, &stockview.StockReorderPoliciesPageProps{
+			ErrorText: fmt.Sprintf("Error removing reorder policy: %v", err),
+		})
+		return
+	}
+
+	http.Redirect(w, r, "/stock/reorder-policies", http.StatusSeeOther)
+}
+
+func (h *StockReorderHandler) renderStockReorderPoliciesPage(
+	w http.ResponseWriter,
+	r *http.Request,
+	props *stockview.StockReorderPoliciesPageProps,
+) {
+	ctx := reqcontext.GetContext(r)
+
+	policies, err := h.stockReorderService.GetStockReorderPolicies(r.Context())
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Error fetching reorder policies", http.StatusInternalServerError)
+		return
+	}
+
+	stockItems, _, err := h.stockItemService.GetStockItems(r.Context(), &model.GetStockItemsQuery{
+		Page: 1, PageSize: 10000,
+	}, ctx.User.UserID)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Error fetching stock items", http.StatusInternalServerError)
+		return
+	}
+
+	teams, _, err := h.teamService.List(r.Context(), model.ListTeamsQuery{
+		Page: 1, PageSize: 1000,
+	})
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Error fetching teams", http.StatusInternalServerError)
+		return
+	}
+
+	if props.Values == nil {
+		props.Values = url.Values{}
+	}
+	if props.ValidationErrors == nil {
+		props.ValidationErrors = validate.ValidationErrors{}
+	}
+
+	props.Ctx = ctx
+	props.Policies = policies
+	props.StockItems = stockItems
+	props.Teams = teams
+
+	_ = stockview.StockReorderPoliciesPage(props).Render(w)
+}
+
+type postStockReorderPolicyFormData struct {
+	StockItemID  int
+	Location     string
+	MinQty       decimal.Decimal
+	ReorderPoint decimal.Decimal
+	MaxQty       decimal.Decimal
+	NotifyTeamID int
+}
+
+func (fd *postStockReorderPolicyFormData) normalise() {
+	fd.Location = strings.ToUpper(strings.TrimSpace(fd.Location))
+}
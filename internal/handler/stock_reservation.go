@@ -0,0 +1,213 @@
+package handler
+
+import (
+	"app/internal/model"
+	"app/internal/service"
+	"app/internal/views/stockview"
+	"app/pkg/appurl"
+	"app/pkg/reqcontext"
+	"app/pkg/validate"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+type StockReservationHandler struct {
+	stockReservationService service.StockReservationService
+	stockItemService        service.StockItemService
+}
+
+func NewStockReservationHandler(
+	stockReservationService service.StockReservationService,
+	stockItemService service.StockItemService,
+) *StockReservationHandler {
+	return &StockReservationHandler{
+		stockReservationService: stockReservationService,
+		stockItemService:        stockItemService,
+	}
+}
+
+func (h *StockReservationHandler) StockReservationsPage(w http.ResponseWriter, r *http.Request) {
+	h.renderStockReservationsPage(w, r, &stockview.StockReservationsPageProps{})
+}
+
+func (h *StockReservationHandler) AddStockReservation(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	if !ctx.User.Permissions.SupplyChain.Admin {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	err := r.ParseForm()
+	if err != nil {
+		http.Error(w, "Error parsing form", http.StatusBadRequest)
+		return
+	}
+
+	var fd addStockReservationFormData
+	err = appurl.Unmarshal(r.PostForm, &fd)
+	if err != nil {
+		http.Error(w, "Error decoding form", http.StatusBadRequest)
+		return
+	}
+
+	fd.normalise()
+
+	validationErrors, err := h.stockReservationService.CreateStockReservation(r.Context(), &model.NewStockReservation{
+		StockItemID:     fd.StockItemID,
+		Location:        fd.Location,
+		Bin:             fd.Bin,
+		LotNumber:       fd.LotNumber,
+		Qty:             fd.Qty,
+		Unit:            fd.Unit,
+		DemandReference: fd.DemandReference,
+		Note:            fd.Note,
+	}, ctx.User.UserID)
+	if err != nil {
+		h.renderStockReservationsPage(w, r, &stockview.StockReservationsPageProps{
+			Values:    r.PostForm,
+			ErrorText: fmt.Sprintf("Error adding reservation: %v", err),
+		})
+		return
+	}
+
+	if len(validationErrors) > 0 {
+		h.renderStockReservationsPage(w, r, &stockview.StockReservationsPageProps{
+			Values:           r.PostForm,
+			ValidationErrors: validationErrors,
+			IsSubmission:     true,
+		})
+		return
+	}
+
+	http.Redirect(w, r, "/stock/reservations", http.StatusSeeOther)
+}
+
+func (h *StockReservationHandler) ReleaseStockReservation(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	if !ctx.User.Permissions.SupplyChain.Admin {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	stockReservationID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid reservation ID", http.StatusBadRequest)
+		return
+	}
+
+	err = h.stockReservationService.ReleaseStockReservation(r.Context(), stockReservationID, ctx.User.UserID)
+	if err != nil {
+		h.renderStockReservationsPage(w, r, &stockview.StockReservationsPageProps{
+			ErrorText: fmt.Sprintf("Error releasing reservation: %v", err),
+		})
+		return
+	}
+
+	http.Redirect(w, r, "/stock/reservations", http.StatusSeeOther)
+}
+
+func (h *StockReservationHandler) renderStockReservationsPage(
+	w http.ResponseWriter,
+	r *http.Request,
+	props *stockview.StockReservationsPageProps,
+) {
+	ctx := reqcontext.GetContext(r)
+
+	type urlVals struct {
+		StockCode       string
+		DemandReference string
+		Status          string
+		Page            int
+		PageSize        int
+	}
+
+	var uv urlVals
+
+	err := appurl.Unmarshal(r.URL.Query(), &uv)
+	if err != nil {
+		http.Error(w, "Error decoding url values", http.StatusBadRequest)
+		return
+	}
+
+	uv.StockCode = strings.ToUpper(strings.TrimSpace(uv.StockCode))
+	uv.DemandReference = strings.ToUpper(strings.TrimSpace(uv.DemandReference))
+
+	if uv.Page == 0 {
+		uv.Page = 1
+	}
+	if uv.PageSize == 0 {
+		uv.PageSize = 50
+	}
+
+	reservations, count, err := h.stockReservationService.GetStockReservations(r.Context(), &model.GetStockReservationsQuery{
+		StockCode:       uv.StockCode,
+		DemandReference: uv.DemandReference,
+		Status:          model.StockReservationStatus(uv.Status),
+		Page:            uv.Page,
+		PageSize:        uv.PageSize,
+	})
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Error fetching reservations", http.StatusInternalServerError)
+		return
+	}
+
+	var stockItems []model.StockItem
+	if ctx.User.Permissions.SupplyChain.Admin {
+		stockItems, _, err = h.stockItemService.GetStockItems(r.Context(), &model.GetStockItemsQuery{
+			Page: 1, PageSize: 10000,
+		}, ctx.User.UserID)
+		if err != nil {
+			log.Println(err)
+			http.Error(w, "Error fetching stock items", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if props.Values == nil {
+		props.Values = url.Values{}
+	}
+	if props.ValidationErrors == nil {
+		props.ValidationErrors = validate.ValidationErrors{}
+	}
+
+	props.Ctx = ctx
+	props.StockReservations = reservations
+	props.StockReservationsCount = count
+	props.StockItems = stockItems
+	props.StockCode = uv.StockCode
+	props.DemandReference = uv.DemandReference
+	props.Status = uv.Status
+	props.Page = uv.Page
+	props.PageSize = uv.PageSize
+
+	_ = stockview.StockReservationsPage(props).Render(w)
+}
+
+type addStockReservationFormData struct {
+	StockItemID     int
+	Location        string
+	Bin             string
+	LotNumber       string
+	Qty             decimal.Decimal
+	Unit            string
+	DemandReference string
+	Note            string
+}
+
+func (fd *addStockReservationFormData) normalise() {
+	fd.Location = strings.ToUpper(strings.TrimSpace(fd.Location))
+	fd.Bin = strings.ToUpper(strings.TrimSpace(fd.Bin))
+	fd.LotNumber = strings.ToUpper(strings.TrimSpace(fd.LotNumber))
+	fd.Unit = strings.ToUpper(strings.TrimSpace(fd.Unit))
+	fd.DemandReference = strings.ToUpper(strings.TrimSpace(fd.DemandReference))
+	fd.Note = strings.TrimSpace(fd.Note)
+}
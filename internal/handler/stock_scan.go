@@ -0,0 +1,174 @@
+package handler
+
+import (
+	"app/internal/model"
+	"app/internal/service"
+	"app/internal/views/stockview"
+	"app/pkg/appurl"
+	"app/pkg/reqcontext"
+	"errors"
+	"log"
+	"net/http"
+	"slices"
+
+	"github.com/shopspring/decimal"
+)
+
+type StockScanHandler struct {
+	stockScanService service.StockScanService
+}
+
+func NewStockScanHandler(stockScanService service.StockScanService) *StockScanHandler {
+	return &StockScanHandler{stockScanService: stockScanService}
+}
+
+// stockScanVals are the state of a scan flow and the scan for its current
+// step. Scan is given more than once when the camera scanner appends a new
+// scan to a url that already has one, so the last is used.
+type stockScanVals struct {
+	Flow         model.StockScanFlow
+	StockCode    string
+	FromLocation string
+	FromBin      string
+	LotNumber    string
+	NoLot        bool
+	Qty          *decimal.Decimal
+	ToLocation   string
+	ToBin        string
+
+	Scan      []string
+	ScanNoLot bool
+}
+
+func (v *stockScanVals) state() model.StockScanState {
+	return model.StockScanState{
+		Flow:         v.Flow,
+		StockCode:    v.StockCode,
+		FromLocation: v.FromLocation,
+		FromBin:      v.FromBin,
+		LotNumber:    v.LotNumber,
+		NoLot:        v.NoLot,
+		Qty:          v.Qty,
+		ToLocation:   v.ToLocation,
+		ToBin:        v.ToBin,
+	}
+}
+
+func canPostStockScans(perms model.UserPermissions) bool {
+	return perms.SupplyChain.Admin || perms.SupplyChain.TeamMember
+}
+
+// StockScanPage shows the current step of a scan flow. A scan in the url is
+// validated and, if it is valid, the flow moves on to the next step.
+func (h *StockScanHandler) StockScanPage(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	if !canPostStockScans(ctx.User.Permissions) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	var uv stockScanVals
+
+	err := appurl.Unmarshal(r.URL.Query(), &uv)
+	if err != nil {
+		http.Error(w, "Error decoding url values", http.StatusBadRequest)
+		return
+	}
+
+	state := uv.state()
+	if !slices.Contains(model.StockScanFlows, state.Flow) {
+		h.renderStockScanPage(w, r, &stockview.StockScanPageProps{})
+		return
+	}
+
+	props := &stockview.StockScanPageProps{State: state}
+
+	if len(uv.Scan) > 0 || uv.ScanNoLot {
+		scan := ""
+		if len(uv.Scan) > 0 {
+			scan = uv.Scan[len(uv.Scan)-1]
+		}
+
+		err = h.stockScanService.ApplyScan(r.Context(), &state, scan, uv.ScanNoLot)
+		if err != nil {
+			if !errors.Is(err, service.ErrInvalidScan) {
+				log.Println(err)
+				http.Error(w, "Error checking scan", http.StatusInternalServerError)
+				return
+			}
+			props.ErrorText = err.Error()
+			props.Scan = scan
+			h.renderStockScanPage(w, r, props)
+			return
+		}
+
+		http.Redirect(w, r, "/stock/scan?"+state.Values().Encode(), http.StatusSeeOther)
+		return
+	}
+
+	h.renderStockScanPage(w, r, props)
+}
+
+// PostStockScan posts a scan flow once every step is done
+func (h *StockScanHandler) PostStockScan(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	if !canPostStockScans(ctx.User.Permissions) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	err := r.ParseForm()
+	if err != nil {
+		http.Error(w, "Error parsing form", http.StatusBadRequest)
+		return
+	}
+
+	var fd stockScanVals
+
+	err = appurl.Unmarshal(r.Form, &fd)
+	if err != nil {
+		http.Error(w, "Error decoding form", http.StatusBadRequest)
+		return
+	}
+
+	state := fd.state()
+	props := &stockview.StockScanPageProps{State: state}
+
+	err = h.stockScanService.PostStockScan(r.Context(), &state, ctx.User.UserID)
+	if err != nil {
+		props.ErrorText = err.Error()
+		h.renderStockScanPage(w, r, props)
+		return
+	}
+
+	props.SuccessText = string(state.Flow) + " posted successfully"
+	props.IsPosted = true
+	h.renderStockScanPage(w, r, props)
+}
+
+// renderStockScanPage loads what is in stock at the source of the flow, to
+// help choose the lot and quantity
+func (h *StockScanHandler) renderStockScanPage(
+	w http.ResponseWriter,
+	r *http.Request,
+	props *stockview.StockScanPageProps,
+) {
+	ctx := reqcontext.GetContext(r)
+
+	s := props.State
+	if !props.IsPosted && s.FromLocation != "" && s.Flow != model.ProductionStockScanFlow {
+		levels, err := h.stockScanService.GetStockLevelsAt(r.Context(), s.StockCode, s.FromLocation, s.FromBin)
+		if err != nil {
+			log.Println(err)
+			http.Error(w, "Error fetching stock levels", http.StatusInternalServerError)
+			return
+		}
+		props.SourceStockLevels = levels
+	}
+
+	props.Ctx = ctx
+
+	_ = stockview.StockScanPage(props).Render(w)
+}
@@ -0,0 +1,261 @@
+package handler
+
+import (
+	"app/internal/model"
+	"app/internal/service"
+	"app/internal/views/stockview"
+	"app/pkg/appurl"
+	"app/pkg/reqcontext"
+	"app/pkg/validate"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type StockScrapHandler struct {
+	stockScrapService service.StockScrapService
+}
+
+func NewStockScrapHandler(
+	stockScrapService service.StockScrapService,
+) *StockScrapHandler {
+	return &StockScrapHandler{
+		stockScrapService: stockScrapService,
+	}
+}
+
+func (h *StockScrapHandler) ScrapReasonsPage(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	if !ctx.User.Permissions.SupplyChain.Admin {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	h.renderScrapReasonsPage(w, r, &stockview.ScrapReasonsPageProps{})
+}
+
+type postScrapReasonFormData struct {
+	Code        string
+	Description string
+	IsArchived  bool
+}
+
+func (fd *postScrapReasonFormData) normalise() {
+	fd.Code = strings.ToUpper(strings.TrimSpace(fd.Code))
+	fd.Description = strings.TrimSpace(fd.Description)
+}
+
+func (h *StockScrapHandler) CreateScrapReason(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	if !ctx.User.Permissions.SupplyChain.Admin {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	err := r.ParseForm()
+	if err != nil {
+		http.Error(w, "Error parsing form", http.StatusBadRequest)
+		return
+	}
+
+	var fd postScrapReasonFormData
+	err = appurl.Unmarshal(r.Form, &fd)
+	if err != nil {
+		http.Error(w, "Error decoding form", http.StatusBadRequest)
+		return
+	}
+
+	fd.normalise()
+
+	validationErrors, err := h.stockScrapService.CreateScrapReason(
+		r.Context(),
+		&model.NewScrapReason{
+			Code:        fd.Code,
+			Description: fd.Description,
+		},
+		ctx.User.UserID,
+	)
+	if err != nil {
+		h.renderScrapReasonsPage(w, r, &stockview.ScrapReasonsPageProps{
+			Values:    r.Form,
+			ErrorText: fmt.Sprintf("Error adding scrap reason: %v", err),
+		})
+		return
+	}
+
+	if len(validationErrors) > 0 {
+		h.renderScrapReasonsPage(w, r, &stockview.ScrapReasonsPageProps{
+			Values:           r.Form,
+			ValidationErrors: validationErrors,
+			IsSubmission:     true,
+		})
+		return
+	}
+
+	http.Redirect(w, r, "/stock/scrap-reasons", http.StatusSeeOther)
+}
+
+func (h *StockScrapHandler) UpdateScrapReason(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	if !ctx.User.Permissions.SupplyChain.Admin {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	scrapReasonID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid scrap reason ID", http.StatusBadRequest)
+		return
+	}
+
+	err = r.ParseForm()
+	if err != nil {
+		http.Error(w, "Error parsing form", http.StatusBadRequest)
+		return
+	}
+
+	var fd postScrapReasonFormData
+	err = appurl.Unmarshal(r.Form, &fd)
+	if err != nil {
+		http.Error(w, "Error decoding form", http.StatusBadRequest)
+		return
+	}
+
+	fd.normalise()
+
+	err = h.stockScrapService.UpdateScrapReason(
+		r.Context(),
+		scrapReasonID,
+		&model.ScrapReasonUpdate{
+			Description: fd.Description,
+			IsArchived:  fd.IsArchived,
+		},
+		ctx.User.UserID,
+	)
+	if err != nil {
+		h.renderScrapReasonsPage(w, r, &stockview.ScrapReasonsPageProps{
+			ErrorText: fmt.Sprintf("Error updating scrap reason: %v", err),
+		})
+		return
+	}
+
+	http.Redirect(w, r, "/stock/scrap-reasons?ShowArchived=true", http.StatusSeeOther)
+}
+
+func (h *StockScrapHandler) WasteReportPage(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	perms := ctx.User.Permissions.SupplyChain
+	if !perms.Admin && !perms.TeamMember {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	type urlVals struct {
+		FromDate      *time.Time
+		ToDate        *time.Time
+		StockCode     string
+		Location      string
+		ScrapReasonID int
+		TrendPeriod   string
+		Page          int
+		PageSize      int
+	}
+
+	var uv urlVals
+
+	err := appurl.Unmarshal(r.URL.Query(), &uv)
+	if err != nil {
+		http.Error(w, "Error decoding url values", http.StatusBadRequest)
+		return
+	}
+
+	// the last 90 days by week unless asked otherwise
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	input := model.GetWasteReportInput{
+		FromDate:      today.AddDate(0, 0, -90),
+		ToDate:        today,
+		StockCode:     strings.ToUpper(strings.TrimSpace(uv.StockCode)),
+		Location:      strings.ToUpper(strings.TrimSpace(uv.Location)),
+		ScrapReasonID: uv.ScrapReasonID,
+		TrendPeriod:   model.WastePeriod(uv.TrendPeriod),
+		Page:          uv.Page,
+		PageSize:      uv.PageSize,
+	}
+	if uv.FromDate != nil {
+		input.FromDate = *uv.FromDate
+	}
+	if uv.ToDate != nil {
+		input.ToDate = *uv.ToDate
+	}
+	if !slices.Contains(model.WastePeriods, input.TrendPeriod) {
+		input.TrendPeriod = model.WeekWastePeriod
+	}
+	if input.Page == 0 {
+		input.Page = 1
+	}
+	if input.PageSize == 0 {
+		input.PageSize = 50
+	}
+
+	report, err := h.stockScrapService.GetWasteReport(r.Context(), &input)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Error fetching waste report", http.StatusInternalServerError)
+		return
+	}
+
+	scrapReasons, err := h.stockScrapService.GetScrapReasons(r.Context(), true)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Error fetching scrap reasons", http.StatusInternalServerError)
+		return
+	}
+
+	_ = stockview.WasteReportPage(&stockview.WasteReportPageProps{
+		Ctx:          ctx,
+		Report:       report,
+		Input:        input,
+		ScrapReasons: scrapReasons,
+	}).Render(w)
+}
+
+func (h *StockScrapHandler) renderScrapReasonsPage(
+	w http.ResponseWriter,
+	r *http.Request,
+	props *stockview.ScrapReasonsPageProps,
+) {
+	ctx := reqcontext.GetContext(r)
+
+	showArchived := r.URL.Query().Get("ShowArchived") == "true"
+
+	scrapReasons, err := h.stockScrapService.GetScrapReasons(r.Context(), showArchived)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Error fetching scrap reasons", http.StatusInternalServerError)
+		return
+	}
+
+	if props.Values == nil {
+		props.Values = url.Values{}
+	}
+	if props.ValidationErrors == nil {
+		props.ValidationErrors = validate.ValidationErrors{}
+	}
+
+	props.Ctx = ctx
+	props.ScrapReasons = scrapReasons
+	props.ShowArchived = showArchived
+
+	_ = stockview.ScrapReasonsPage(props).Render(w)
+}
@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"app/internal/model"
+	"app/internal/service"
+	"app/internal/views/stockview"
+	"app/pkg/appurl"
+	"app/pkg/reqcontext"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type StockSerialHandler struct {
+	stockSerialService service.StockSerialService
+}
+
+func NewStockSerialHandler(
+	stockSerialService service.StockSerialService,
+) *StockSerialHandler {
+	return &StockSerialHandler{
+		stockSerialService: stockSerialService,
+	}
+}
+
+func (h *StockSerialHandler) StockSerialsPage(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	type urlVals struct {
+		StockCode    string
+		SerialNumber string
+		Account      string
+		Location     string
+		Page         int
+		PageSize     int
+	}
+
+	var uv urlVals
+
+	err := appurl.Unmarshal(r.URL.Query(), &uv)
+	if err != nil {
+		http.Error(w, "Error decoding url values", http.StatusBadRequest)
+		return
+	}
+
+	uv.StockCode = strings.ToUpper(strings.TrimSpace(uv.StockCode))
+	uv.SerialNumber = strings.ToUpper(strings.TrimSpace(uv.SerialNumber))
+	uv.Location = strings.ToUpper(strings.TrimSpace(uv.Location))
+
+	if uv.Page == 0 {
+		uv.Page = 1
+	}
+	if uv.PageSize == 0 {
+		uv.PageSize = 50
+	}
+
+	stockSerials, count, err := h.stockSerialService.GetStockSerials(r.Context(), &model.GetStockSerialsQuery{
+		StockCode:    uv.StockCode,
+		SerialNumber: uv.SerialNumber,
+		Account:      model.StockAccount(uv.Account),
+		Location:     uv.Location,
+		Page:         uv.Page,
+		PageSize:     uv.PageSize,
+	})
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Error fetching serials", http.StatusInternalServerError)
+		return
+	}
+
+	_ = stockview.StockSerialsPage(&stockview.StockSerialsPageProps{
+		Ctx:               ctx,
+		StockSerials:      stockSerials,
+		StockSerialsCount: count,
+		StockCode:         uv.StockCode,
+		SerialNumber:      uv.SerialNumber,
+		Account:           uv.Account,
+		Location:          uv.Location,
+		Page:              uv.Page,
+		PageSize:          uv.PageSize,
+	}).Render(w)
+}
+
+func (h *StockSerialHandler) StockSerialPage(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	stockSerialID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid serial ID", http.StatusBadRequest)
+		return
+	}
+
+	stockSerial, err := h.stockSerialService.GetStockSerial(r.Context(), stockSerialID)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Error fetching serial", http.StatusInternalServerError)
+		return
+	}
+	if stockSerial == nil {
+		http.Error(w, "Serial not found", http.StatusNotFound)
+		return
+	}
+
+	movements, err := h.stockSerialService.GetStockSerialMovements(r.Context(), stockSerialID)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Error fetching serial movements", http.StatusInternalServerError)
+		return
+	}
+
+	_ = stockview.StockSerialPage(&stockview.StockSerialPageProps{
+		Ctx:         ctx,
+		StockSerial: *stockSerial,
+		Movements:   movements,
+	}).Render(w)
+}
@@ -7,9 +7,12 @@ import (
 	"app/pkg/appurl"
 	"app/pkg/nilsafe"
 	"app/pkg/reqcontext"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
@@ -18,16 +21,19 @@ import (
 
 type StockTransactionHandler struct {
 	stockItemService        service.StockItemService
+	stockReservationService service.StockReservationService
 	stockTransactionService service.StockTransactionService
 }
 
 func NewStockTransactionHandler(
 	stockTransactionService service.StockTransactionService,
 	stockItemService service.StockItemService,
+	stockReservationService service.StockReservationService,
 ) *StockTransactionHandler {
 	return &StockTransactionHandler{
 		stockTransactionService: stockTransactionService,
-		stockItemService:        stockItemService}
+		stockItemService:        stockItemService,
+		stockReservationService: stockReservationService}
 }
 
 type stockInputURLVals struct {
@@ -37,8 +43,10 @@ type stockInputURLVals struct {
 	Bin          string
 	LotNumber    string
 	LTETimestamp *time.Time
-	Page         int
-	PageSize     int
+	// StockTransactionID shows a single transaction and its reversal
+	StockTransactionID int
+	Page               int
+	PageSize           int
 }
 
 func (uv *stockInputURLVals) normalise() {
@@ -93,9 +101,20 @@ func (h *StockTransactionHandler) StockLevelsPage(w http.ResponseWriter, r *http
 		return
 	}
 
+	var availability *model.StockAvailability
+	if uv.StockCode != "" && uv.LTETimestamp == nil {
+		availability, err = h.stockReservationService.GetStockAvailability(r.Context(), uv.StockCode)
+		if err != nil {
+			log.Println(err)
+			http.Error(w, "Error fetching stock availability", http.StatusInternalServerError)
+			return
+		}
+	}
+
 	_ = stockview.StockLevelsPage(stockview.StockLevelsPageProps{
 		Ctx:          ctx,
 		StockLevels:  &stockLevels,
+		Availability: availability,
 		Account:      uv.Account,
 		StockCode:    uv.StockCode,
 		Location:     uv.Location,
@@ -115,17 +134,35 @@ func (h *StockTransactionHandler) StockDetailsPage(w http.ResponseWriter, r *htt
 
 	stockCode := r.PathValue("id")
 
-	_ = stockview.StockDetailPage(stockview.StockDetailPageProps{
-		Ctx:       ctx,
+	stockTransactions, err := h.stockTransactionService.GetStockTransactions(r.Context(), &model.GetTransactionsInput{
 		StockCode: stockCode,
+		Page:      1,
+		PageSize:  stockview.StockDetailTransactionsPageSize,
+	}, ctx.User.UserID)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Error fetching stock transactions", http.StatusInternalServerError)
+		return
+	}
+
+	lotNumbers, err := h.stockTransactionService.GetLotNumbers(r.Context(), stockCode)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Error fetching lot numbers", http.StatusInternalServerError)
+		return
+	}
+
+	_ = stockview.StockDetailPage(stockview.StockDetailPageProps{
+		Ctx:               ctx,
+		StockCode:         stockCode,
+		StockTransactions: stockTransactions,
+		LotNumbers:        lotNumbers,
 	}).
 		Render(w)
 
 }
 
 func (h *StockTransactionHandler) StockTransactionsPage(w http.ResponseWriter, r *http.Request) {
-	ctx := reqcontext.GetContext(r)
-
 	var uv stockInputURLVals
 
 	err := appurl.Unmarshal(r.URL.Query(), &uv)
@@ -136,15 +173,78 @@ func (h *StockTransactionHandler) StockTransactionsPage(w http.ResponseWriter, r
 
 	uv.normalise()
 
+	h.renderStockTransactionsPage(w, r, &uv, &stockview.StockTransactionsPageProps{})
+}
+
+func (h *StockTransactionHandler) ReverseStockTransaction(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	if !ctx.User.Permissions.SupplyChain.Admin {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	stockTransactionID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid stock transaction ID", http.StatusBadRequest)
+		return
+	}
+
+	err = r.ParseForm()
+	if err != nil {
+		http.Error(w, "Error parsing form", http.StatusBadRequest)
+		return
+	}
+
+	var fd reverseStockTransactionFormData
+	err = appurl.Unmarshal(r.Form, &fd)
+	if err != nil {
+		http.Error(w, "Error decoding form", http.StatusBadRequest)
+		return
+	}
+
+	err = h.stockTransactionService.ReverseStockTransaction(
+		r.Context(),
+		stockTransactionID,
+		fd.AcknowledgeNegativeStock,
+		ctx.User.UserID,
+	)
+	if err != nil {
+		uv := stockInputURLVals{StockTransactionID: stockTransactionID}
+		uv.normalise()
+
+		var negativeStockErr *service.NegativeStockError
+		h.renderStockTransactionsPage(w, r, &uv, &stockview.StockTransactionsPageProps{
+			ErrorText:                 fmt.Sprintf("Error reversing transaction: %v", err),
+			NegativeStockWarning:      errors.As(err, &negativeStockErr) && negativeStockErr.IsWarning(),
+			ReverseStockTransactionID: stockTransactionID,
+		})
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/stock/transactions?StockTransactionID=%d", stockTransactionID), http.StatusSeeOther)
+}
+
+// renderStockTransactionsPage loads the transactions matching the url values
+// and renders the page. Errors are taken from props.
+func (h *StockTransactionHandler) renderStockTransactionsPage(
+	w http.ResponseWriter,
+	r *http.Request,
+	uv *stockInputURLVals,
+	props *stockview.StockTransactionsPageProps,
+) {
+	ctx := reqcontext.GetContext(r)
+
 	stockTransactions, err := h.stockTransactionService.GetStockTransactions(r.Context(), &model.GetTransactionsInput{
-		Account:      model.StockAccount(uv.Account),
-		StockCode:    uv.StockCode,
-		Location:     uv.Location,
-		Bin:          uv.Bin,
-		LotNumber:    uv.LotNumber,
-		LTETimestamp: uv.LTETimestamp,
-		Page:         uv.Page,
-		PageSize:     uv.PageSize,
+		Account:            model.StockAccount(uv.Account),
+		StockCode:          uv.StockCode,
+		Location:           uv.Location,
+		Bin:                uv.Bin,
+		LotNumber:          uv.LotNumber,
+		LTETimestamp:       uv.LTETimestamp,
+		StockTransactionID: uv.StockTransactionID,
+		Page:               uv.Page,
+		PageSize:           uv.PageSize,
 	}, ctx.User.UserID)
 	if err != nil {
 		log.Println(err)
@@ -152,20 +252,20 @@ func (h *StockTransactionHandler) StockTransactionsPage(w http.ResponseWriter, r
 		return
 	}
 
-	_ = stockview.StockTransactionsPage(&stockview.StockTransactionsPageProps{
-		Ctx:               ctx,
-		StockTransactions: &stockTransactions,
-		Account:           uv.Account,
-		StockCode:         uv.StockCode,
-		Location:          uv.Location,
-		Bin:               uv.Bin,
-		LotNumber:         uv.LotNumber,
-		LTETimestamp:      uv.LTETimestamp,
-		Page:              uv.Page,
-		PageSize:          uv.PageSize,
-		Total:             len(stockTransactions),
-	}).Render(w)
-
+	props.Ctx = ctx
+	props.StockTransactions = &stockTransactions
+	props.Account = uv.Account
+	props.StockCode = uv.StockCode
+	props.Location = uv.Location
+	props.Bin = uv.Bin
+	props.LotNumber = uv.LotNumber
+	props.LTETimestamp = uv.LTETimestamp
+	props.StockTransactionID = uv.StockTransactionID
+	props.Page = uv.Page
+	props.PageSize = uv.PageSize
+	props.Total = len(stockTransactions)
+
+	_ = stockview.StockTransactionsPage(props).Render(w)
 }
 
 func (h *StockTransactionHandler) PostStockMovementPage(w http.ResponseWriter, r *http.Request) {
@@ -409,19 +509,26 @@ func (h *StockTransactionHandler) PostStockMovement(w http.ResponseWriter, r *ht
 		return
 	}
 
+	var qtyError string
+	var negativeStockWarning bool
 	renderWithError := func(errorText string) {
 		_ = stockview.PostStockMovementPage(
 			&stockview.PostStockMovementPageProps{
-				Ctx:          ctx,
-				StockItemID:  fd.StockItemID,
-				LotNumber:    fd.LotNumber,
-				Qty:          fd.Qty,
-				FromLocation: fd.FromLocation,
-				FromBin:      fd.FromBin,
-				ToLocation:   fd.ToLocation,
-				ToBin:        fd.ToBin,
-				ReturnTo:     fd.ReturnTo,
-				ErrorText:    errorText,
+				Ctx:                  ctx,
+				StockItemID:          fd.StockItemID,
+				LotNumber:            fd.LotNumber,
+				Qty:                  fd.Qty,
+				Unit:                 fd.Unit,
+				FromLocation:         fd.FromLocation,
+				FromBin:              fd.FromBin,
+				ToLocation:           fd.ToLocation,
+				ToBin:                fd.ToBin,
+				ReturnTo:             fd.ReturnTo,
+				ErrorText:            errorText,
+				QtyError:             qtyError,
+				NegativeStockWarning: negativeStockWarning,
+				TransactionNote:      fd.TransactionNote,
+				SerialNumbers:        fd.SerialNumbers,
 
 				StockItems: stockItems,
 			},
@@ -439,16 +546,27 @@ func (h *StockTransactionHandler) PostStockMovement(w http.ResponseWriter, r *ht
 		&model.PostManualStockMovementInput{
 			StockItemID:     fd.StockItemID,
 			Qty:             fd.Qty,
+			Unit:            fd.Unit,
 			FromLocation:    fd.FromLocation,
 			FromBin:         fd.FromBin,
 			LotNumber:       fd.LotNumber,
 			ToLocation:      fd.ToLocation,
 			ToBin:           fd.ToBin,
 			TransactionNote: fd.TransactionNote,
+			SerialNumbers:   splitSerialNumbers(fd.SerialNumbers),
+
+			AcknowledgeNegativeStock: fd.AcknowledgeNegativeStock,
 		},
 		ctx.User.UserID,
 	)
 	if err != nil {
+		var negativeStockErr *service.NegativeStockError
+		if errors.As(err, &negativeStockErr) {
+			qtyError = negativeStockErr.Error()
+			negativeStockWarning = negativeStockErr.IsWarning()
+			renderWithError("")
+			return
+		}
 		renderWithError(fmt.Sprintf("Error posting movement: %v", err))
 		return
 	}
@@ -458,10 +576,16 @@ func (h *StockTransactionHandler) PostStockMovement(w http.ResponseWriter, r *ht
 		http.Redirect(w, r, nilsafe.Str(fd.ReturnTo), http.StatusFound)
 	}
 
+	printLabelsURL := ""
+	if model.CanPrintStockLabels(ctx.User.Permissions) {
+		printLabelsURL = stockLabelsURL(fd.StockItemID, fd.LotNumber, fd.Qty, fd.Unit)
+	}
+
 	_ = stockview.PostStockMovementPage(
 		&stockview.PostStockMovementPageProps{
-			Ctx:         ctx,
-			SuccessText: "Successfully posted stock movement",
+			Ctx:            ctx,
+			SuccessText:    "Successfully posted stock movement",
+			PrintLabelsURL: printLabelsURL,
 		},
 	).Render(w)
 }
@@ -493,16 +617,36 @@ func (h *StockTransactionHandler) PostProduction(w http.ResponseWriter, r *http.
 
 	fd.normalise()
 
+	stockItems, _, err := h.stockItemService.GetStockItems(r.Context(), &model.GetStockItemsQuery{
+		Page: 1, PageSize: 10000,
+	}, ctx.User.UserID)
+	if err != nil {
+		log.Println("Failed to get stock items")
+		http.Error(w, "Error fetching stock items", http.StatusInternalServerError)
+		return
+	}
+
+	var qtyError string
+	var negativeStockWarning bool
 	renderWithError := func(errorText string) {
 		_ = stockview.PostProductionPage(
 			&stockview.PostGenericPageProps{
-				Ctx:         ctx,
-				StockItemID: fd.StockItemID,
-				Location:    fd.Location,
-				Bin:         fd.Bin,
-				LotNumber:   fd.LotNumber,
-				Qty:         fd.Qty,
-				ErrorText:   errorText,
+				Ctx:                  ctx,
+				StockItemID:          fd.StockItemID,
+				Location:             fd.Location,
+				Bin:                  fd.Bin,
+				LotNumber:            fd.LotNumber,
+				Qty:                  fd.Qty,
+				Unit:                 fd.Unit,
+				ErrorText:            errorText,
+				QtyError:             qtyError,
+				NegativeStockWarning: negativeStockWarning,
+				TransactionNote:      fd.TransactionNote,
+				SerialNumbers:        fd.SerialNumbers,
+				UnitCost:             fd.UnitCost,
+				BackflushLocation:    fd.BackflushLocation,
+				BackflushBin:         fd.BackflushBin,
+				StockItems:           stockItems,
 			},
 		).Render(w)
 	}
@@ -518,23 +662,44 @@ func (h *StockTransactionHandler) PostProduction(w http.ResponseWriter, r *http.
 		&model.PostManualGenericStockTransactionInput{
 			StockItemID:     fd.StockItemID,
 			Qty:             fd.Qty,
+			Unit:            fd.Unit,
 			Location:        fd.Location,
 			Bin:             fd.Bin,
 			LotNumber:       fd.LotNumber,
 			TransactionNote: fd.TransactionNote,
+			SerialNumbers:   splitSerialNumbers(fd.SerialNumbers),
+			UnitCost:        fd.UnitCost,
+
+			BackflushLocation: fd.BackflushLocation,
+			BackflushBin:      fd.BackflushBin,
+
+			AcknowledgeNegativeStock: fd.AcknowledgeNegativeStock,
 		},
 		ctx.User.UserID,
 	)
 
 	if err != nil {
+		var negativeStockErr *service.NegativeStockError
+		if errors.As(err, &negativeStockErr) {
+			qtyError = negativeStockErr.Error()
+			negativeStockWarning = negativeStockErr.IsWarning()
+			renderWithError("")
+			return
+		}
 		renderWithError(err.Error())
 		return
 	}
 
+	printLabelsURL := ""
+	if model.CanPrintStockLabels(ctx.User.Permissions) {
+		printLabelsURL = stockLabelsURL(fd.StockItemID, fd.LotNumber, fd.Qty, fd.Unit)
+	}
+
 	_ = stockview.PostProductionPage(
 		&stockview.PostGenericPageProps{
-			Ctx:         ctx,
-			SuccessText: "Production operation posted successfully",
+			Ctx:            ctx,
+			SuccessText:    "Production operation posted successfully",
+			PrintLabelsURL: printLabelsURL,
 		},
 	).Render(w)
 
@@ -567,16 +732,35 @@ func (h *StockTransactionHandler) PostProductionReversal(w http.ResponseWriter,
 
 	fd.normalise()
 
+	stockItems, _, err := h.stockItemService.GetStockItems(r.Context(), &model.GetStockItemsQuery{
+		Page: 1, PageSize: 10000,
+	}, ctx.User.UserID)
+	if err != nil {
+		log.Println("Failed to get stock items")
+		http.Error(w, "Error fetching stock items", http.StatusInternalServerError)
+		return
+	}
+
+	var qtyError string
+	var negativeStockWarning bool
 	renderWithError := func(errorText string) {
 		_ = stockview.PostProductionReversalPage(
 			&stockview.PostGenericPageProps{
-				Ctx:         ctx,
-				StockItemID: fd.StockItemID,
-				Location:    fd.Location,
-				Bin:         fd.Bin,
-				LotNumber:   fd.LotNumber,
-				Qty:         fd.Qty,
-				ErrorText:   errorText,
+				Ctx:                  ctx,
+				StockItemID:          fd.StockItemID,
+				Location:             fd.Location,
+				Bin:                  fd.Bin,
+				LotNumber:            fd.LotNumber,
+				Qty:                  fd.Qty,
+				Unit:                 fd.Unit,
+				ErrorText:            errorText,
+				QtyError:             qtyError,
+				NegativeStockWarning: negativeStockWarning,
+				TransactionNote:      fd.TransactionNote,
+				SerialNumbers:        fd.SerialNumbers,
+				BackflushLocation:    fd.BackflushLocation,
+				BackflushBin:         fd.BackflushBin,
+				StockItems:           stockItems,
 			},
 		).Render(w)
 	}
@@ -592,15 +776,29 @@ func (h *StockTransactionHandler) PostProductionReversal(w http.ResponseWriter,
 		&model.PostManualGenericStockTransactionInput{
 			StockItemID:     fd.StockItemID,
 			Qty:             fd.Qty,
+			Unit:            fd.Unit,
 			Location:        fd.Location,
 			Bin:             fd.Bin,
 			LotNumber:       fd.LotNumber,
 			TransactionNote: fd.TransactionNote,
+			SerialNumbers:   splitSerialNumbers(fd.SerialNumbers),
+
+			BackflushLocation: fd.BackflushLocation,
+			BackflushBin:      fd.BackflushBin,
+
+			AcknowledgeNegativeStock: fd.AcknowledgeNegativeStock,
 		},
 		ctx.User.UserID,
 	)
 
 	if err != nil {
+		var negativeStockErr *service.NegativeStockError
+		if errors.As(err, &negativeStockErr) {
+			qtyError = negativeStockErr.Error()
+			negativeStockWarning = negativeStockErr.IsWarning()
+			renderWithError("")
+			return
+		}
 		renderWithError(err.Error())
 		return
 	}
@@ -640,16 +838,34 @@ func (h *StockTransactionHandler) PostConsumption(w http.ResponseWriter, r *http
 
 	fd.normalise()
 
+	stockItems, _, err := h.stockItemService.GetStockItems(r.Context(), &model.GetStockItemsQuery{
+		Page: 1, PageSize: 10000,
+	}, ctx.User.UserID)
+	if err != nil {
+		log.Println("Failed to get stock items")
+		http.Error(w, "Error fetching stock items", http.StatusInternalServerError)
+		return
+	}
+
+	var qtyError string
+	var negativeStockWarning bool
 	renderWithError := func(errorText string) {
 		_ = stockview.PostConsumptionPage(
 			&stockview.PostGenericPageProps{
-				Ctx:         ctx,
-				StockItemID: fd.StockItemID,
-				Location:    fd.Location,
-				Bin:         fd.Bin,
-				LotNumber:   fd.LotNumber,
-				Qty:         fd.Qty,
-				ErrorText:   errorText,
+				Ctx:                  ctx,
+				StockItemID:          fd.StockItemID,
+				Location:             fd.Location,
+				Bin:                  fd.Bin,
+				LotNumber:            fd.LotNumber,
+				Qty:                  fd.Qty,
+				Unit:                 fd.Unit,
+				ErrorText:            errorText,
+				QtyError:             qtyError,
+				NegativeStockWarning: negativeStockWarning,
+				TransactionNote:      fd.TransactionNote,
+				SerialNumbers:        fd.SerialNumbers,
+				DemandReference:      fd.DemandReference,
+				StockItems:           stockItems,
 			},
 		).Render(w)
 	}
@@ -665,15 +881,33 @@ func (h *StockTransactionHandler) PostConsumption(w http.ResponseWriter, r *http
 		&model.PostManualGenericStockTransactionInput{
 			StockItemID:     fd.StockItemID,
 			Qty:             fd.Qty,
+			Unit:            fd.Unit,
 			Location:        fd.Location,
 			Bin:             fd.Bin,
 			LotNumber:       fd.LotNumber,
 			TransactionNote: fd.TransactionNote,
+			SerialNumbers:   splitSerialNumbers(fd.SerialNumbers),
+			DemandReference: fd.DemandReference,
+
+			AcknowledgeNegativeStock: fd.AcknowledgeNegativeStock,
 		},
 		ctx.User.UserID,
 	)
 
 	if err != nil {
+		var negativeStockErr *service.NegativeStockError
+		if errors.As(err, &negativeStockErr) {
+			qtyError = negativeStockErr.Error()
+			negativeStockWarning = negativeStockErr.IsWarning()
+			renderWithError("")
+			return
+		}
+		var reservedStockErr *service.ReservedStockError
+		if errors.As(err, &reservedStockErr) {
+			qtyError = reservedStockErr.Error()
+			renderWithError("")
+			return
+		}
 		renderWithError(err.Error())
 		return
 	}
@@ -713,16 +947,33 @@ func (h *StockTransactionHandler) PostConsumptionReversal(w http.ResponseWriter,
 
 	fd.normalise()
 
+	stockItems, _, err := h.stockItemService.GetStockItems(r.Context(), &model.GetStockItemsQuery{
+		Page: 1, PageSize: 10000,
+	}, ctx.User.UserID)
+	if err != nil {
+		log.Println("Failed to get stock items")
+		http.Error(w, "Error fetching stock items", http.StatusInternalServerError)
+		return
+	}
+
+	var qtyError string
+	var negativeStockWarning bool
 	renderWithError := func(errorText string) {
 		_ = stockview.PostConsumptionReversalPage(
 			&stockview.PostGenericPageProps{
-				Ctx:         ctx,
-				StockItemID: fd.StockItemID,
-				Location:    fd.Location,
-				Bin:         fd.Bin,
-				LotNumber:   fd.LotNumber,
-				Qty:         fd.Qty,
-				ErrorText:   errorText,
+				Ctx:                  ctx,
+				StockItemID:          fd.StockItemID,
+				Location:             fd.Location,
+				Bin:                  fd.Bin,
+				LotNumber:            fd.LotNumber,
+				Qty:                  fd.Qty,
+				Unit:                 fd.Unit,
+				ErrorText:            errorText,
+				QtyError:             qtyError,
+				NegativeStockWarning: negativeStockWarning,
+				TransactionNote:      fd.TransactionNote,
+				SerialNumbers:        fd.SerialNumbers,
+				StockItems:           stockItems,
 			},
 		).Render(w)
 	}
@@ -738,15 +989,26 @@ func (h *StockTransactionHandler) PostConsumptionReversal(w http.ResponseWriter,
 		&model.PostManualGenericStockTransactionInput{
 			StockItemID:     fd.StockItemID,
 			Qty:             fd.Qty,
+			Unit:            fd.Unit,
 			Location:        fd.Location,
 			Bin:             fd.Bin,
 			LotNumber:       fd.LotNumber,
 			TransactionNote: fd.TransactionNote,
+			SerialNumbers:   splitSerialNumbers(fd.SerialNumbers),
+
+			AcknowledgeNegativeStock: fd.AcknowledgeNegativeStock,
 		},
 		ctx.User.UserID,
 	)
 
 	if err != nil {
+		var negativeStockErr *service.NegativeStockError
+		if errors.As(err, &negativeStockErr) {
+			qtyError = negativeStockErr.Error()
+			negativeStockWarning = negativeStockErr.IsWarning()
+			renderWithError("")
+			return
+		}
 		renderWithError(err.Error())
 		return
 	}
@@ -787,16 +1049,34 @@ func (h *StockTransactionHandler) PostStockAdjustment(w http.ResponseWriter, r *
 
 	fd.normalise()
 
+	stockItems, _, err := h.stockItemService.GetStockItems(r.Context(), &model.GetStockItemsQuery{
+		Page: 1, PageSize: 10000,
+	}, ctx.User.UserID)
+	if err != nil {
+		log.Println("Failed to get stock items")
+		http.Error(w, "Error fetching stock items", http.StatusInternalServerError)
+		return
+	}
+
+	var qtyError string
+	var negativeStockWarning bool
 	renderWithError := func(errorText string) {
 		_ = stockview.PostStockAdjustPage(
 			&stockview.PostGenericPageProps{
-				Ctx:         ctx,
-				StockItemID: fd.StockItemID,
-				Location:    fd.Location,
-				Bin:         fd.Bin,
-				LotNumber:   fd.LotNumber,
-				Qty:         fd.Qty,
-				ErrorText:   errorText,
+				Ctx:                  ctx,
+				StockItemID:          fd.StockItemID,
+				Location:             fd.Location,
+				Bin:                  fd.Bin,
+				LotNumber:            fd.LotNumber,
+				Qty:                  fd.Qty,
+				Unit:                 fd.Unit,
+				ErrorText:            errorText,
+				QtyError:             qtyError,
+				NegativeStockWarning: negativeStockWarning,
+				TransactionNote:      fd.TransactionNote,
+				SerialNumbers:        fd.SerialNumbers,
+				UnitCost:             fd.UnitCost,
+				StockItems:           stockItems,
 			},
 		).Render(w)
 	}
@@ -812,15 +1092,27 @@ func (h *StockTransactionHandler) PostStockAdjustment(w http.ResponseWriter, r *
 		&model.PostManualGenericStockTransactionInput{
 			StockItemID:     fd.StockItemID,
 			Qty:             fd.Qty,
+			Unit:            fd.Unit,
 			Location:        fd.Location,
 			Bin:             fd.Bin,
 			LotNumber:       fd.LotNumber,
 			TransactionNote: fd.TransactionNote,
+			SerialNumbers:   splitSerialNumbers(fd.SerialNumbers),
+			UnitCost:        fd.UnitCost,
+
+			AcknowledgeNegativeStock: fd.AcknowledgeNegativeStock,
 		},
 		ctx.User.UserID,
 	)
 
 	if err != nil {
+		var negativeStockErr *service.NegativeStockError
+		if errors.As(err, &negativeStockErr) {
+			qtyError = negativeStockErr.Error()
+			negativeStockWarning = negativeStockErr.IsWarning()
+			renderWithError("")
+			return
+		}
 		renderWithError(err.Error())
 		return
 	}
@@ -833,14 +1125,453 @@ func (h *StockTransactionHandler) PostStockAdjustment(w http.ResponseWriter, r *
 	).Render(w)
 }
 
+func (h *StockTransactionHandler) PostQuarantinePage(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	perms := ctx.User.Permissions
+	// quarantine postings are approved by the QC approver posting them
+	hasPermission := perms.SupplyChain.Admin && perms.SupplyChain.QCApprover
+
+	if !hasPermission {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	h.renderPostQuarantinePage(w, r, &stockview.PostQuarantinePageProps{})
+}
+
+func (h *StockTransactionHandler) PostQuarantine(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	perms := ctx.User.Permissions
+	// quarantine postings are approved by the QC approver posting them
+	hasPermission := perms.SupplyChain.Admin && perms.SupplyChain.QCApprover
+
+	if !hasPermission {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+	err := r.ParseForm()
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Error parsing form", http.StatusBadRequest)
+		return
+	}
+
+	var fd postQuarantineFormData
+
+	err = appurl.Unmarshal(r.Form, &fd)
+	if err != nil {
+		http.Error(w, "Error decoding form", http.StatusBadRequest)
+		return
+	}
+
+	fd.normalise()
+
+	props := &stockview.PostQuarantinePageProps{
+		TransactionType: fd.TransactionType,
+		StockItemID:     fd.StockItemID,
+		Location:        fd.Location,
+		Bin:             fd.Bin,
+		LotNumber:       fd.LotNumber,
+		Qty:             fd.Qty,
+		Unit:            fd.Unit,
+		SerialNumbers:   fd.SerialNumbers,
+		Reason:          fd.Reason,
+		ScrapReasonID:   fd.ScrapReasonID,
+		TransactionNote: fd.TransactionNote,
+	}
+
+	errorText := fd.validate()
+	if errorText != "" {
+		props.ErrorText = errorText
+		h.renderPostQuarantinePage(w, r, props)
+		return
+	}
+
+	var scrapReasonID *int
+	if fd.ScrapReasonID != 0 {
+		scrapReasonID = &fd.ScrapReasonID
+	}
+
+	err = h.stockTransactionService.PostManualQuarantine(
+		r.Context(),
+		&model.PostManualQuarantineInput{
+			TransactionType: model.StockTransactionType(fd.TransactionType),
+			StockItemID:     fd.StockItemID,
+			Qty:             fd.Qty,
+			Unit:            fd.Unit,
+			Location:        fd.Location,
+			Bin:             fd.Bin,
+			LotNumber:       fd.LotNumber,
+			SerialNumbers:   splitSerialNumbers(fd.SerialNumbers),
+			Reason:          fd.Reason,
+			ScrapReasonID:   scrapReasonID,
+			TransactionNote: fd.TransactionNote,
+
+			AcknowledgeNegativeStock: fd.AcknowledgeNegativeStock,
+		},
+		ctx.User.UserID,
+	)
+
+	if err != nil {
+		var negativeStockErr *service.NegativeStockError
+		if errors.As(err, &negativeStockErr) {
+			props.QtyError = negativeStockErr.Error()
+			props.NegativeStockWarning = negativeStockErr.IsWarning()
+			h.renderPostQuarantinePage(w, r, props)
+			return
+		}
+		props.ErrorText = err.Error()
+		h.renderPostQuarantinePage(w, r, props)
+		return
+	}
+
+	h.renderPostQuarantinePage(w, r, &stockview.PostQuarantinePageProps{
+		SuccessText: fmt.Sprintf("%s posted successfully", fd.TransactionType),
+	})
+}
+
+// renderPostQuarantinePage loads the stock items and scrap reasons to choose
+// from. Form values and errors are taken from props.
+func (h *StockTransactionHandler) renderPostQuarantinePage(
+	w http.ResponseWriter,
+	r *http.Request,
+	props *stockview.PostQuarantinePageProps,
+) {
+	ctx := reqcontext.GetContext(r)
+
+	stockItems, _, err := h.stockItemService.GetStockItems(r.Context(), &model.GetStockItemsQuery{
+		Page: 1, PageSize: 10000,
+	}, ctx.User.UserID)
+	if err != nil {
+		log.Println("Failed to get stock items")
+		http.Error(w, "Error fetching stock items", http.StatusInternalServerError)
+		return
+	}
+
+	scrapReasons, err := h.stockTransactionService.GetScrapReasons(r.Context())
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Error fetching scrap reasons", http.StatusInternalServerError)
+		return
+	}
+
+	props.Ctx = ctx
+	props.StockItems = stockItems
+	props.ScrapReasons = scrapReasons
+
+	_ = stockview.PostQuarantinePage(props).Render(w)
+}
+
+func (h *StockTransactionHandler) PostScrapPage(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	perms := ctx.User.Permissions
+	hasPermission := perms.SupplyChain.Admin
+
+	if !hasPermission {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	h.renderPostScrapPage(w, r, &stockview.PostScrapPageProps{})
+}
+
+func (h *StockTransactionHandler) PostScrap(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	perms := ctx.User.Permissions
+	hasPermission := perms.SupplyChain.Admin
+
+	if !hasPermission {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+	err := r.ParseForm()
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Error parsing form", http.StatusBadRequest)
+		return
+	}
+
+	var fd postScrapFormData
+
+	err = appurl.Unmarshal(r.Form, &fd)
+	if err != nil {
+		http.Error(w, "Error decoding form", http.StatusBadRequest)
+		return
+	}
+
+	fd.normalise()
+
+	props := &stockview.PostScrapPageProps{
+		StockItemID:     fd.StockItemID,
+		Location:        fd.Location,
+		Bin:             fd.Bin,
+		LotNumber:       fd.LotNumber,
+		Qty:             fd.Qty,
+		Unit:            fd.Unit,
+		SerialNumbers:   fd.SerialNumbers,
+		ScrapReasonID:   fd.ScrapReasonID,
+		DemandReference: fd.DemandReference,
+		TransactionNote: fd.TransactionNote,
+	}
+
+	errorText := fd.validate()
+	if errorText != "" {
+		props.ErrorText = errorText
+		h.renderPostScrapPage(w, r, props)
+		return
+	}
+
+	err = h.stockTransactionService.PostManualScrap(
+		r.Context(),
+		&model.PostManualScrapInput{
+			StockItemID:     fd.StockItemID,
+			Qty:             fd.Qty,
+			Unit:            fd.Unit,
+			Location:        fd.Location,
+			Bin:             fd.Bin,
+			LotNumber:       fd.LotNumber,
+			SerialNumbers:   splitSerialNumbers(fd.SerialNumbers),
+			ScrapReasonID:   fd.ScrapReasonID,
+			TransactionNote: fd.TransactionNote,
+			DemandReference: fd.DemandReference,
+
+			AcknowledgeNegativeStock: fd.AcknowledgeNegativeStock,
+		},
+		ctx.User.UserID,
+	)
+
+	if err != nil {
+		var negativeStockErr *service.NegativeStockError
+		if errors.As(err, &negativeStockErr) {
+			props.QtyError = negativeStockErr.Error()
+			props.NegativeStockWarning = negativeStockErr.IsWarning()
+			h.renderPostScrapPage(w, r, props)
+			return
+		}
+		props.ErrorText = err.Error()
+		h.renderPostScrapPage(w, r, props)
+		return
+	}
+
+	h.renderPostScrapPage(w, r, &stockview.PostScrapPageProps{
+		SuccessText: "Scrap posted successfully",
+	})
+}
+
+// renderPostScrapPage loads the stock items and scrap reasons to choose from.
+// Form values and errors are taken from props.
+func (h *StockTransactionHandler) renderPostScrapPage(
+	w http.ResponseWriter,
+	r *http.Request,
+	props *stockview.PostScrapPageProps,
+) {
+	ctx := reqcontext.GetContext(r)
+
+	stockItems, _, err := h.stockItemService.GetStockItems(r.Context(), &model.GetStockItemsQuery{
+		Page: 1, PageSize: 10000,
+	}, ctx.User.UserID)
+	if err != nil {
+		log.Println("Failed to get stock items")
+		http.Error(w, "Error fetching stock items", http.StatusInternalServerError)
+		return
+	}
+
+	scrapReasons, err := h.stockTransactionService.GetScrapReasons(r.Context())
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Error fetching scrap reasons", http.StatusInternalServerError)
+		return
+	}
+
+	props.Ctx = ctx
+	props.StockItems = stockItems
+	props.ScrapReasons = scrapReasons
+
+	_ = stockview.PostScrapPage(props).Render(w)
+}
+
+// PostCustomPage lets users with supply chain permissions post the custom
+// transaction types their permissions allow
+func (h *StockTransactionHandler) PostCustomPage(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	perms := ctx.User.Permissions.SupplyChain
+	if !perms.Admin && !perms.TeamMember && !perms.QCApprover {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	type urlVals struct {
+		CustomStockTransactionTypeID int
+	}
+
+	var uv urlVals
+
+	err := appurl.Unmarshal(r.URL.Query(), &uv)
+	if err != nil {
+		http.Error(w, "Error decoding url values", http.StatusBadRequest)
+		return
+	}
+
+	h.renderPostCustomPage(w, r, &stockview.PostCustomPageProps{
+		CustomStockTransactionTypeID: uv.CustomStockTransactionTypeID,
+	})
+}
+
+func (h *StockTransactionHandler) PostCustom(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	perms := ctx.User.Permissions.SupplyChain
+	if !perms.Admin && !perms.TeamMember && !perms.QCApprover {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+	err := r.ParseForm()
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Error parsing form", http.StatusBadRequest)
+		return
+	}
+
+	var fd postCustomFormData
+
+	err = appurl.Unmarshal(r.Form, &fd)
+	if err != nil {
+		http.Error(w, "Error decoding form", http.StatusBadRequest)
+		return
+	}
+
+	fd.normalise()
+
+	props := &stockview.PostCustomPageProps{
+		CustomStockTransactionTypeID: fd.CustomStockTransactionTypeID,
+		StockItemID:                  fd.StockItemID,
+		FromLocation:                 fd.FromLocation,
+		FromBin:                      fd.FromBin,
+		FromLotNumber:                fd.FromLotNumber,
+		ToLocation:                   fd.ToLocation,
+		ToBin:                        fd.ToBin,
+		ToLotNumber:                  fd.ToLotNumber,
+		Qty:                          fd.Qty,
+		Unit:                         fd.Unit,
+		SerialNumbers:                fd.SerialNumbers,
+		UnitCost:                     fd.UnitCost,
+		DemandReference:              fd.DemandReference,
+		TransactionNote:              fd.TransactionNote,
+	}
+
+	errorText := fd.validate()
+	if errorText != "" {
+		props.ErrorText = errorText
+		h.renderPostCustomPage(w, r, props)
+		return
+	}
+
+	err = h.stockTransactionService.PostCustomStockTransaction(
+		r.Context(),
+		&model.PostCustomStockTransactionInput{
+			CustomStockTransactionTypeID: fd.CustomStockTransactionTypeID,
+			StockItemID:                  fd.StockItemID,
+			Qty:                          fd.Qty,
+			Unit:                         fd.Unit,
+			FromLocation:                 fd.FromLocation,
+			FromBin:                      fd.FromBin,
+			FromLotNumber:                fd.FromLotNumber,
+			ToLocation:                   fd.ToLocation,
+			ToBin:                        fd.ToBin,
+			ToLotNumber:                  fd.ToLotNumber,
+			SerialNumbers:                splitSerialNumbers(fd.SerialNumbers),
+			UnitCost:                     fd.UnitCost,
+			DemandReference:              fd.DemandReference,
+			TransactionNote:              fd.TransactionNote,
+
+			AcknowledgeNegativeStock: fd.AcknowledgeNegativeStock,
+		},
+		ctx.User.UserID,
+	)
+
+	if err != nil {
+		var negativeStockErr *service.NegativeStockError
+		if errors.As(err, &negativeStockErr) {
+			props.QtyError = negativeStockErr.Error()
+			props.NegativeStockWarning = negativeStockErr.IsWarning()
+			h.renderPostCustomPage(w, r, props)
+			return
+		}
+		props.ErrorText = err.Error()
+		h.renderPostCustomPage(w, r, props)
+		return
+	}
+
+	printLabelsURL := ""
+	if model.CanPrintStockLabels(ctx.User.Permissions) {
+		lotNumber := fd.FromLotNumber
+		if fd.ToLotNumber != "" {
+			lotNumber = fd.ToLotNumber
+		}
+		printLabelsURL = stockLabelsURL(fd.StockItemID, lotNumber, fd.Qty, fd.Unit)
+	}
+
+	h.renderPostCustomPage(w, r, &stockview.PostCustomPageProps{
+		CustomStockTransactionTypeID: fd.CustomStockTransactionTypeID,
+		SuccessText:                  "Transaction posted successfully",
+		PrintLabelsURL:               printLabelsURL,
+	})
+}
+
+// renderPostCustomPage loads the stock items and the custom transaction types
+// the user can post. Form values and errors are taken from props.
+func (h *StockTransactionHandler) renderPostCustomPage(
+	w http.ResponseWriter,
+	r *http.Request,
+	props *stockview.PostCustomPageProps,
+) {
+	ctx := reqcontext.GetContext(r)
+
+	stockItems, _, err := h.stockItemService.GetStockItems(r.Context(), &model.GetStockItemsQuery{
+		Page: 1, PageSize: 10000,
+	}, ctx.User.UserID)
+	if err != nil {
+		log.Println("Failed to get stock items")
+		http.Error(w, "Error fetching stock items", http.StatusInternalServerError)
+		return
+	}
+
+	transactionTypes, err := h.stockTransactionService.GetCustomStockTransactionTypes(
+		r.Context(), ctx.User.Permissions.SupplyChain,
+	)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Error fetching transaction types", http.StatusInternalServerError)
+		return
+	}
+
+	props.Ctx = ctx
+	props.StockItems = stockItems
+	props.TransactionTypes = transactionTypes
+
+	_ = stockview.PostCustomPage(props).Render(w)
+}
+
 type postGenericTransactionFormData struct {
-	StockItemID       int
-	Location          string
-	Bin               string
-	LotNumber         string
-	Qty               decimal.Decimal
-	TransactionNote   string
-	IsStockAdjustment bool
+	StockItemID              int
+	Location                 string
+	Bin                      string
+	LotNumber                string
+	Qty                      decimal.Decimal
+	Unit                     string
+	TransactionNote          string
+	SerialNumbers            string
+	DemandReference          string
+	UnitCost                 *decimal.Decimal
+	BackflushLocation        string
+	BackflushBin             string
+	IsStockAdjustment        bool
+	AcknowledgeNegativeStock bool
 }
 
 type postStockMovementFormData struct {
@@ -848,12 +1579,16 @@ type postStockMovementFormData struct {
 	Account         string
 	LotNumber       string
 	Qty             decimal.Decimal
+	Unit            string
 	FromLocation    string
 	FromBin         string
 	ToLocation      string
 	ToBin           string
 	TransactionNote string
+	SerialNumbers   string
 	ReturnTo        *string
+
+	AcknowledgeNegativeStock bool
 }
 
 func (fd *postStockMovementFormData) normalise() {
@@ -864,6 +1599,7 @@ func (fd *postStockMovementFormData) normalise() {
 	fd.ToLocation = strings.ToUpper(strings.TrimSpace(fd.ToLocation))
 	fd.ToBin = strings.ToUpper(strings.TrimSpace(fd.ToBin))
 	fd.LotNumber = strings.ToUpper(strings.TrimSpace(fd.LotNumber))
+	fd.Unit = strings.ToUpper(strings.TrimSpace(fd.Unit))
 
 	// trim
 	fd.TransactionNote = strings.TrimSpace(fd.TransactionNote)
@@ -895,6 +1631,10 @@ func (fd *postGenericTransactionFormData) normalise() {
 	fd.Location = strings.ToUpper(strings.TrimSpace(fd.Location))
 	fd.Bin = strings.ToUpper(strings.TrimSpace(fd.Bin))
 	fd.LotNumber = strings.ToUpper(strings.TrimSpace(fd.LotNumber))
+	fd.Unit = strings.ToUpper(strings.TrimSpace(fd.Unit))
+	fd.DemandReference = strings.ToUpper(strings.TrimSpace(fd.DemandReference))
+	fd.BackflushLocation = strings.ToUpper(strings.TrimSpace(fd.BackflushLocation))
+	fd.BackflushBin = strings.ToUpper(strings.TrimSpace(fd.BackflushBin))
 
 	// trim
 	fd.TransactionNote = strings.TrimSpace(fd.TransactionNote)
@@ -913,6 +1653,186 @@ func (fd *postGenericTransactionFormData) validate() string {
 	if fd.Location == "" {
 		return "Location cannot be empty"
 	}
+	if fd.UnitCost != nil && fd.UnitCost.IsNegative() {
+		return "Unit cost cannot be negative"
+	}
+
+	return ""
+}
+
+type postQuarantineFormData struct {
+	TransactionType          string
+	StockItemID              int
+	Location                 string
+	Bin                      string
+	LotNumber                string
+	Qty                      decimal.Decimal
+	Unit                     string
+	SerialNumbers            string
+	Reason                   string
+	ScrapReasonID            int
+	TransactionNote          string
+	AcknowledgeNegativeStock bool
+}
+
+func (fd *postQuarantineFormData) normalise() {
+
+	// trim and uppercase
+	fd.Location = strings.ToUpper(strings.TrimSpace(fd.Location))
+	fd.Bin = strings.ToUpper(strings.TrimSpace(fd.Bin))
+	fd.LotNumber = strings.ToUpper(strings.TrimSpace(fd.LotNumber))
+	fd.Unit = strings.ToUpper(strings.TrimSpace(fd.Unit))
+
+	// trim
+	fd.Reason = strings.TrimSpace(fd.Reason)
+	fd.TransactionNote = strings.TrimSpace(fd.TransactionNote)
+
+}
+
+func (fd *postQuarantineFormData) validate() string {
+
+	if !slices.Contains(model.QuarantineTransactionTypes, model.StockTransactionType(fd.TransactionType)) {
+		return "Action must be hold, release or reject"
+	}
+	if fd.Qty.LessThanOrEqual(decimal.Zero) {
+		return "Qty must be greater than 0"
+	}
+	if fd.StockItemID == 0 {
+		return "Stock code cannot be empty"
+	}
+	if fd.Location == "" {
+		return "Location cannot be empty"
+	}
+	if fd.Reason == "" {
+		return "Reason cannot be empty"
+	}
+	if fd.TransactionType == string(model.QuarantineRejectTransactionType) && fd.ScrapReasonID == 0 {
+		return "Scrap reason cannot be empty when rejecting"
+	}
+
+	return ""
+}
+
+type postScrapFormData struct {
+	StockItemID              int
+	Location                 string
+	Bin                      string
+	LotNumber                string
+	Qty                      decimal.Decimal
+	Unit                     string
+	SerialNumbers            string
+	ScrapReasonID            int
+	DemandReference          string
+	TransactionNote          string
+	AcknowledgeNegativeStock bool
+}
+
+func (fd *postScrapFormData) normalise() {
+
+	// trim and uppercase
+	fd.Location = strings.ToUpper(strings.TrimSpace(fd.Location))
+	fd.Bin = strings.ToUpper(strings.TrimSpace(fd.Bin))
+	fd.LotNumber = strings.ToUpper(strings.TrimSpace(fd.LotNumber))
+	fd.Unit = strings.ToUpper(strings.TrimSpace(fd.Unit))
+	fd.DemandReference = strings.ToUpper(strings.TrimSpace(fd.DemandReference))
+
+	// trim
+	fd.TransactionNote = strings.TrimSpace(fd.TransactionNote)
+
+}
+
+func (fd *postScrapFormData) validate() string {
+
+	if fd.Qty.LessThanOrEqual(decimal.Zero) {
+		return "Qty must be greater than 0"
+	}
+	if fd.StockItemID == 0 {
+		return "Stock code cannot be empty"
+	}
+	if fd.Location == "" {
+		return "Location cannot be empty"
+	}
+	if fd.ScrapReasonID == 0 {
+		return "Scrap reason cannot be empty"
+	}
+
+	return ""
+}
+
+type postCustomFormData struct {
+	CustomStockTransactionTypeID int
+	StockItemID                  int
+	FromLocation                 string
+	FromBin                      string
+	FromLotNumber                string
+	ToLocation                   string
+	ToBin                        string
+	ToLotNumber                  string
+	Qty                          decimal.Decimal
+	Unit                         string
+	SerialNumbers                string
+	UnitCost                     *decimal.Decimal
+	DemandReference              string
+	TransactionNote              string
+	AcknowledgeNegativeStock     bool
+}
+
+func (fd *postCustomFormData) normalise() {
+
+	// trim and uppercase
+	fd.FromLocation = strings.ToUpper(strings.TrimSpace(fd.FromLocation))
+	fd.FromBin = strings.ToUpper(strings.TrimSpace(fd.FromBin))
+	fd.FromLotNumber = strings.ToUpper(strings.TrimSpace(fd.FromLotNumber))
+	fd.ToLocation = strings.ToUpper(strings.TrimSpace(fd.ToLocation))
+	fd.ToBin = strings.ToUpper(strings.TrimSpace(fd.ToBin))
+	fd.ToLotNumber = strings.ToUpper(strings.TrimSpace(fd.ToLotNumber))
+	fd.Unit = strings.ToUpper(strings.TrimSpace(fd.Unit))
+	fd.DemandReference = strings.ToUpper(strings.TrimSpace(fd.DemandReference))
+
+	// trim
+	fd.TransactionNote = strings.TrimSpace(fd.TransactionNote)
+
+}
+
+func (fd *postCustomFormData) validate() string {
+
+	if fd.CustomStockTransactionTypeID == 0 {
+		return "Transaction type cannot be empty"
+	}
+	if fd.Qty.LessThanOrEqual(decimal.Zero) {
+		return "Qty must be greater than 0"
+	}
+	if fd.StockItemID == 0 {
+		return "Stock code cannot be empty"
+	}
+	if fd.FromLocation == "" {
+		return "From location cannot be empty"
+	}
+	if fd.UnitCost != nil && fd.UnitCost.IsNegative() {
+		return "Unit cost cannot be negative"
+	}
 
 	return ""
 }
+
+// splitSerialNumbers splits serial numbers entered separated by commas or
+// new lines
+func splitSerialNumbers(serialNumbers string) []string {
+	fields := strings.FieldsFunc(serialNumbers, func(r rune) bool {
+		return r == ',' || r == '\n' || r == '\r'
+	})
+
+	var split []string
+	for _, f := range fields {
+		f = strings.ToUpper(strings.TrimSpace(f))
+		if f != "" {
+			split = append(split, f)
+		}
+	}
+
+	return split
+}
+
+type reverseStockTransactionFormData struct {
+	AcknowledgeNegativeStock bool
+}
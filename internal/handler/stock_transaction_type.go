@@ -0,0 +1,262 @@
+package handler
+
+import (
+	"app/internal/model"
+	"app/internal/service"
+	"app/internal/views/stockview"
+	"app/pkg/appurl"
+	"app/pkg/reqcontext"
+	"app/pkg/validate"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+type StockTransactionTypeHandler struct {
+	stockTransactionTypeService service.StockTransactionTypeService
+}
+
+func NewStockTransactionTypeHandler(
+	stockTransactionTypeService service.StockTransactionTypeService,
+) *StockTransactionTypeHandler {
+	return &StockTransactionTypeHandler{
+		stockTransactionTypeService: stockTransactionTypeService,
+	}
+}
+
+func (h *StockTransactionTypeHandler) StockTransactionTypesPage(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	if !ctx.User.Permissions.SupplyChain.Admin {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	h.renderStockTransactionTypesPage(w, r, &stockview.StockTransactionTypesPageProps{})
+}
+
+type postStockTransactionTypeFormData struct {
+	Name                   string
+	Description            string
+	FromAccount            string
+	ToAccount              string
+	AllowDifferentLocation bool
+	AllowDifferentBin      bool
+	AllowDifferentLot      bool
+	RequiredFields         []string
+	RequiredPermission     string
+	IsArchived             bool
+}
+
+func (fd *postStockTransactionTypeFormData) normalise() {
+	fd.Name = strings.TrimSpace(fd.Name)
+	fd.Description = strings.TrimSpace(fd.Description)
+}
+
+func (fd *postStockTransactionTypeFormData) requiredFields() []model.CustomTransactionField {
+	fields := []model.CustomTransactionField{}
+	for _, f := range fd.RequiredFields {
+		fields = append(fields, model.CustomTransactionField(f))
+	}
+	return fields
+}
+
+func (h *StockTransactionTypeHandler) CreateStockTransactionType(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	if !ctx.User.Permissions.SupplyChain.Admin {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	err := r.ParseForm()
+	if err != nil {
+		http.Error(w, "Error parsing form", http.StatusBadRequest)
+		return
+	}
+
+	var fd postStockTransactionTypeFormData
+	err = appurl.Unmarshal(r.Form, &fd)
+	if err != nil {
+		http.Error(w, "Error decoding form", http.StatusBadRequest)
+		return
+	}
+
+	fd.normalise()
+
+	customStockTransactionTypeID, validationErrors, err := h.stockTransactionTypeService.CreateCustomStockTransactionType(
+		r.Context(),
+		&model.NewCustomStockTransactionType{
+			Name:                   fd.Name,
+			Description:            fd.Description,
+			FromAccount:            model.StockAccount(fd.FromAccount),
+			ToAccount:              model.StockAccount(fd.ToAccount),
+			AllowDifferentLocation: fd.AllowDifferentLocation,
+			AllowDifferentBin:      fd.AllowDifferentBin,
+			AllowDifferentLot:      fd.AllowDifferentLot,
+			RequiredFields:         fd.requiredFields(),
+			RequiredPermission:     model.CustomTransactionPermission(fd.RequiredPermission),
+		},
+		ctx.User.UserID,
+	)
+	if err != nil {
+		h.renderStockTransactionTypesPage(w, r, &stockview.StockTransactionTypesPageProps{
+			Values:    r.Form,
+			ErrorText: fmt.Sprintf("Error adding transaction type: %v", err),
+		})
+		return
+	}
+
+	if len(validationErrors) > 0 {
+		h.renderStockTransactionTypesPage(w, r, &stockview.StockTransactionTypesPageProps{
+			Values:           r.Form,
+			ValidationErrors: validationErrors,
+			IsSubmission:     true,
+		})
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/stock/transaction-types/%d", customStockTransactionTypeID), http.StatusSeeOther)
+}
+
+func (h *StockTransactionTypeHandler) StockTransactionTypePage(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	if !ctx.User.Permissions.SupplyChain.Admin {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	customStockTransactionTypeID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid transaction type ID", http.StatusBadRequest)
+		return
+	}
+
+	h.renderStockTransactionTypePage(w, r, customStockTransactionTypeID, &stockview.StockTransactionTypePageProps{})
+}
+
+func (h *StockTransactionTypeHandler) UpdateStockTransactionType(w http.ResponseWriter, r *http.Request) {
+	ctx := reqcontext.GetContext(r)
+
+	if !ctx.User.Permissions.SupplyChain.Admin {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	customStockTransactionTypeID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid transaction type ID", http.StatusBadRequest)
+		return
+	}
+
+	err = r.ParseForm()
+	if err != nil {
+		http.Error(w, "Error parsing form", http.StatusBadRequest)
+		return
+	}
+
+	var fd postStockTransactionTypeFormData
+	err = appurl.Unmarshal(r.Form, &fd)
+	if err != nil {
+		http.Error(w, "Error decoding form", http.StatusBadRequest)
+		return
+	}
+
+	fd.normalise()
+
+	validationErrors, err := h.stockTransactionTypeService.UpdateCustomStockTransactionType(
+		r.Context(),
+		customStockTransactionTypeID,
+		&model.CustomStockTransactionTypeUpdate{
+			Description:            fd.Description,
+			AllowDifferentLocation: fd.AllowDifferentLocation,
+			AllowDifferentBin:      fd.AllowDifferentBin,
+			AllowDifferentLot:      fd.AllowDifferentLot,
+			RequiredFields:         fd.requiredFields(),
+			RequiredPermission:     model.CustomTransactionPermission(fd.RequiredPermission),
+			IsArchived:             fd.IsArchived,
+		},
+		ctx.User.UserID,
+	)
+	if err != nil {
+		h.renderStockTransactionTypePage(w, r, customStockTransactionTypeID, &stockview.StockTransactionTypePageProps{
+			ErrorText: fmt.Sprintf("Error updating transaction type: %v", err),
+		})
+		return
+	}
+
+	if len(validationErrors) > 0 {
+		h.renderStockTransactionTypePage(w, r, customStockTransactionTypeID, &stockview.StockTransactionTypePageProps{
+			ValidationErrors: validationErrors,
+			IsSubmission:     true,
+		})
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/stock/transaction-types/%d", customStockTransactionTypeID), http.StatusSeeOther)
+}
+
+func (h *StockTransactionTypeHandler) renderStockTransactionTypesPage(
+	w http.ResponseWriter,
+	r *http.Request,
+	props *stockview.StockTransactionTypesPageProps,
+) {
+	ctx := reqcontext.GetContext(r)
+
+	showArchived := r.URL.Query().Get("ShowArchived") == "true"
+
+	transactionTypes, err := h.stockTransactionTypeService.GetCustomStockTransactionTypes(r.Context(), showArchived)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Error fetching transaction types", http.StatusInternalServerError)
+		return
+	}
+
+	if props.Values == nil {
+		props.Values = url.Values{}
+	}
+	if props.ValidationErrors == nil {
+		props.ValidationErrors = validate.ValidationErrors{}
+	}
+
+	props.Ctx = ctx
+	props.TransactionTypes = transactionTypes
+	props.ShowArchived = showArchived
+
+	_ = stockview.StockTransactionTypesPage(props).Render(w)
+}
+
+func (h *StockTransactionTypeHandler) renderStockTransactionTypePage(
+	w http.ResponseWriter,
+	r *http.Request,
+	customStockTransactionTypeID int,
+	props *stockview.StockTransactionTypePageProps,
+) {
+	ctx := reqcontext.GetContext(r)
+
+	transactionType, err := h.stockTransactionTypeService.GetCustomStockTransactionType(
+		r.Context(), customStockTransactionTypeID,
+	)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Error fetching transaction type", http.StatusInternalServerError)
+		return
+	}
+	if transactionType == nil {
+		http.Error(w, "Transaction type not found", http.StatusNotFound)
+		return
+	}
+
+	if props.ValidationErrors == nil {
+		props.ValidationErrors = validate.ValidationErrors{}
+	}
+
+	props.Ctx = ctx
+	props.TransactionType = *transactionType
+
+	_ = stockview.StockTransactionTypePage(props).Render(w)
+}
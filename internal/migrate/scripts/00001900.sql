@@ -0,0 +1,69 @@
+-- 00001900.sql: add stock documents to group multi-line stock postings
+
+CREATE TABLE stock_document (
+    stock_document_id INTEGER GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    document_type TEXT NOT NULL,
+    reference TEXT NOT NULL,
+    document_date TIMESTAMPTZ NOT NULL,
+    note TEXT NOT NULL DEFAULT '',
+    status TEXT NOT NULL DEFAULT 'Draft'
+        CHECK (status IN ('Draft', 'Posted', 'Cancelled')),
+    created_by INT NOT NULL REFERENCES app_user(user_id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    posted_by INT REFERENCES app_user(user_id),
+    posted_at TIMESTAMPTZ
+);
+
+CREATE INDEX stock_document_status_idx ON stock_document(status);
+
+
+CREATE TABLE stock_document_line (
+    stock_document_line_id INTEGER GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    stock_document_id INT NOT NULL REFERENCES stock_document(stock_document_id) ON DELETE CASCADE,
+    transaction_type TEXT NOT NULL,
+    stock_item_id INT NOT NULL REFERENCES stock_item(stock_item_id),
+    quantity NUMERIC NOT NULL CHECK (quantity > 0),
+    from_location TEXT NOT NULL,
+    from_bin TEXT NOT NULL,
+    from_lot_number TEXT NOT NULL,
+    to_location TEXT NOT NULL,
+    to_bin TEXT NOT NULL,
+    to_lot_number TEXT NOT NULL,
+    line_note TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX stock_document_line_stock_document_id_idx
+    ON stock_document_line(stock_document_id);
+
+
+ALTER TABLE stock_transaction
+    ADD COLUMN IF NOT EXISTS stock_document_id INT REFERENCES stock_document(stock_document_id);
+
+CREATE INDEX IF NOT EXISTS stock_transaction_stock_document_id_idx
+    ON stock_transaction(stock_document_id);
+
+
+CREATE OR REPLACE VIEW stock_document_view AS
+SELECT
+    sd.stock_document_id,
+    sd.document_type,
+    sd.reference,
+    sd.document_date,
+    sd.note,
+    sd.status,
+    (
+        SELECT COUNT(*)
+        FROM stock_document_line sdl
+        WHERE sdl.stock_document_id = sd.stock_document_id
+    )::INT AS line_count,
+    sd.created_by,
+    cu.username AS created_by_username,
+    sd.created_at,
+    sd.posted_by,
+    pu.username AS posted_by_username,
+    sd.posted_at
+FROM
+    stock_document sd
+JOIN app_user cu ON cu.user_id = sd.created_by
+LEFT JOIN app_user pu ON pu.user_id = sd.posted_by;
@@ -0,0 +1,19 @@
+-- 00002000.sql: add negative stock policies for the STOCK account
+
+CREATE TABLE stock_negative_policy (
+    stock_negative_policy_id INTEGER GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    stock_item_id INT REFERENCES stock_item(stock_item_id),
+    location TEXT,
+    action TEXT NOT NULL CHECK (action IN ('Allow', 'Warn', 'Reject')),
+    updated_by INT REFERENCES app_user(user_id),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- One policy per scope. A NULL stock item or location means "any", so the
+-- row with both NULL is the global policy.
+CREATE UNIQUE INDEX stock_negative_policy_scope_idx
+    ON stock_negative_policy ((COALESCE(stock_item_id, 0)), (COALESCE(location, '')));
+
+-- Keep today's behaviour until an admin decides otherwise
+INSERT INTO stock_negative_policy (stock_item_id, location, action)
+VALUES (NULL, NULL, 'Allow');
@@ -0,0 +1,40 @@
+-- 00002100.sql: add a maintained stock balance table for current stock levels
+
+-- One row per account, stock item, location, bin and lot holding the latest
+-- running total. The ledger remains the source of truth, this table is kept
+-- in step with it by every posting.
+CREATE TABLE stock_balance (
+    account TEXT NOT NULL,
+    stock_item_id INT NOT NULL REFERENCES stock_item(stock_item_id),
+    location TEXT NOT NULL,
+    bin TEXT NOT NULL,
+    lot_number TEXT NOT NULL,
+    quantity NUMERIC NOT NULL,
+    last_stock_transaction_id INT NOT NULL REFERENCES stock_transaction(stock_transaction_id),
+    last_timestamp TIMESTAMPTZ NOT NULL,
+
+    PRIMARY KEY (account, stock_item_id, location, bin, lot_number)
+);
+
+CREATE INDEX stock_balance_stock_item_id_idx ON stock_balance (stock_item_id);
+
+-- Populate from the latest ledger entry of each balance
+INSERT INTO stock_balance (
+    account, stock_item_id, location, bin, lot_number,
+    quantity, last_stock_transaction_id, last_timestamp
+)
+SELECT DISTINCT ON (e.account, t.stock_item_id, e.location, e.bin, e.lot_number)
+    e.account,
+    t.stock_item_id,
+    e.location,
+    e.bin,
+    e.lot_number,
+    e.running_total,
+    t.stock_transaction_id,
+    t.timestamp
+FROM
+    stock_transaction_entry e
+JOIN stock_transaction t ON t.stock_transaction_id = e.stock_transaction_id
+ORDER BY
+    e.account, t.stock_item_id, e.location, e.bin, e.lot_number,
+    t.timestamp DESC, e.stock_transaction_entry_id DESC;
@@ -0,0 +1,9 @@
+-- 00002200.sql: link reversal transactions to the transaction they reverse
+
+ALTER TABLE stock_transaction
+    ADD COLUMN reverses_stock_transaction_id INT REFERENCES stock_transaction(stock_transaction_id);
+
+-- A transaction can only be reversed once
+CREATE UNIQUE INDEX stock_transaction_reverses_stock_transaction_id_idx
+    ON stock_transaction (reverses_stock_transaction_id)
+    WHERE reverses_stock_transaction_id IS NOT NULL;
@@ -0,0 +1,78 @@
+-- 00002300.sql: add stock counts (stocktakes and cycle counts)
+
+CREATE TABLE stock_count (
+    stock_count_id INTEGER GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    reference TEXT NOT NULL,
+    note TEXT NOT NULL DEFAULT '',
+    -- An empty scope array means no restriction on that dimension
+    locations TEXT[] NOT NULL DEFAULT '{}',
+    bins TEXT[] NOT NULL DEFAULT '{}',
+    stock_item_ids INT[] NOT NULL DEFAULT '{}',
+    is_blind BOOLEAN NOT NULL DEFAULT FALSE,
+    snapshot_at TIMESTAMPTZ NOT NULL,
+    status TEXT NOT NULL DEFAULT 'Counting'
+        CHECK (status IN ('Counting', 'Approved', 'Cancelled')),
+    created_by INT NOT NULL REFERENCES app_user(user_id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    approved_by INT REFERENCES app_user(user_id),
+    approved_at TIMESTAMPTZ
+);
+
+CREATE INDEX stock_count_status_idx ON stock_count(status);
+
+
+CREATE TABLE stock_count_line (
+    stock_count_line_id INTEGER GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    stock_count_id INT NOT NULL REFERENCES stock_count(stock_count_id) ON DELETE CASCADE,
+    stock_item_id INT NOT NULL REFERENCES stock_item(stock_item_id),
+    location TEXT NOT NULL,
+    bin TEXT NOT NULL,
+    lot_number TEXT NOT NULL,
+    expected_quantity NUMERIC NOT NULL,
+    counted_quantity NUMERIC CHECK (counted_quantity >= 0),
+    counted_by INT REFERENCES app_user(user_id),
+    counted_at TIMESTAMPTZ,
+
+    UNIQUE (stock_count_id, stock_item_id, location, bin, lot_number)
+);
+
+
+ALTER TABLE stock_transaction
+    ADD COLUMN stock_count_id INT REFERENCES stock_count(stock_count_id);
+
+CREATE INDEX stock_transaction_stock_count_id_idx
+    ON stock_transaction(stock_count_id);
+
+
+CREATE OR REPLACE VIEW stock_count_view AS
+SELECT
+    sc.stock_count_id,
+    sc.reference,
+    sc.note,
+    sc.locations,
+    sc.bins,
+    sc.stock_item_ids,
+    sc.is_blind,
+    sc.snapshot_at,
+    sc.status,
+    (
+        SELECT COUNT(*)
+        FROM stock_count_line scl
+        WHERE scl.stock_count_id = sc.stock_count_id
+    )::INT AS line_count,
+    (
+        SELECT COUNT(*)
+        FROM stock_count_line scl
+        WHERE scl.stock_count_id = sc.stock_count_id
+          AND scl.counted_quantity IS NOT NULL
+    )::INT AS counted_line_count,
+    sc.created_by,
+    cu.username AS created_by_username,
+    sc.created_at,
+    sc.approved_by,
+    au.username AS approved_by_username,
+    sc.approved_at
+FROM
+    stock_count sc
+JOIN app_user cu ON cu.user_id = sc.created_by
+LEFT JOIN app_user au ON au.user_id = sc.approved_by;
@@ -0,0 +1,20 @@
+-- 00002400.sql: add units of measure to stock items
+
+-- Quantities in the ledger are always held in the base unit of the item
+ALTER TABLE stock_item
+    ADD COLUMN base_unit TEXT NOT NULL DEFAULT 'EA';
+
+ALTER TABLE stock_item_change
+    ADD COLUMN base_unit TEXT;
+
+-- Alternate units a quantity can be entered in. The conversion factor is the
+-- number of base units in one of the alternate unit, e.g. 50 for a box of 50
+CREATE TABLE stock_item_unit (
+    stock_item_unit_id INTEGER GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    stock_item_id INT NOT NULL REFERENCES stock_item(stock_item_id) ON DELETE CASCADE,
+    unit TEXT NOT NULL,
+    conversion_factor NUMERIC NOT NULL CHECK (conversion_factor > 0),
+    created_by INT NOT NULL REFERENCES app_user(user_id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (stock_item_id, unit)
+);
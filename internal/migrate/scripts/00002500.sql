@@ -0,0 +1,61 @@
+-- 00002500.sql: add lot genealogy links between consumption and production
+
+-- Records that a consumption fed a production. Links are made when a
+-- Production Batch document is posted, between every consumption and every
+-- production line on the document.
+CREATE TABLE stock_genealogy_link (
+    stock_genealogy_link_id INTEGER GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    consumption_stock_transaction_id INT NOT NULL REFERENCES stock_transaction(stock_transaction_id),
+    production_stock_transaction_id INT NOT NULL REFERENCES stock_transaction(stock_transaction_id),
+    stock_document_id INT REFERENCES stock_document(stock_document_id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (consumption_stock_transaction_id, production_stock_transaction_id)
+);
+
+CREATE INDEX stock_genealogy_link_production_idx
+    ON stock_genealogy_link(production_stock_transaction_id);
+
+
+-- Lot to lot links, ignoring transactions that have since been reversed.
+-- The consumed quantity is the total consumed of the lot across the linked
+-- consumptions.
+CREATE OR REPLACE VIEW stock_lot_link AS
+WITH linked AS (
+    SELECT DISTINCT
+        l.consumption_stock_transaction_id,
+        c.stock_item_id AS consumed_stock_item_id,
+        ce.lot_number AS consumed_lot_number,
+        ce.quantity AS consumed_quantity,
+        p.stock_item_id AS produced_stock_item_id,
+        pe.lot_number AS produced_lot_number
+    FROM
+        stock_genealogy_link l
+    JOIN stock_transaction c
+        ON c.stock_transaction_id = l.consumption_stock_transaction_id
+    JOIN stock_transaction_entry ce
+        ON ce.stock_transaction_id = c.stock_transaction_id
+        AND ce.account = 'CONSUMED'
+    JOIN stock_transaction p
+        ON p.stock_transaction_id = l.production_stock_transaction_id
+    JOIN stock_transaction_entry pe
+        ON pe.stock_transaction_id = p.stock_transaction_id
+        AND pe.account = 'STOCK'
+    WHERE
+        NOT EXISTS (
+            SELECT 1 FROM stock_transaction r
+            WHERE r.reverses_stock_transaction_id IN (c.stock_transaction_id, p.stock_transaction_id)
+        )
+)
+SELECT
+    consumed_stock_item_id,
+    consumed_lot_number,
+    produced_stock_item_id,
+    produced_lot_number,
+    SUM(consumed_quantity) AS consumed_quantity
+FROM
+    linked
+GROUP BY
+    consumed_stock_item_id,
+    consumed_lot_number,
+    produced_stock_item_id,
+    produced_lot_number;
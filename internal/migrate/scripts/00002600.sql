@@ -0,0 +1,40 @@
+-- 00002600.sql: add a lot master with expiry dates to stock items
+
+-- One row per lot of a stock item. Lots are created when first posted, their
+-- attributes are filled in afterwards. expiry_notified_at records when the
+-- supply chain team was told the lot is approaching expiry and is cleared if
+-- the expiry date changes.
+CREATE TABLE stock_lot (
+    stock_lot_id INTEGER GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    stock_item_id INT NOT NULL REFERENCES stock_item(stock_item_id) ON DELETE CASCADE,
+    lot_number TEXT NOT NULL,
+    manufacture_date DATE,
+    expiry_date DATE,
+    supplier_lot_number TEXT NOT NULL DEFAULT '',
+    status TEXT NOT NULL DEFAULT 'Available'
+        CHECK (status IN ('Available', 'On Hold', 'Rejected')),
+    expiry_notified_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_by INT REFERENCES app_user(user_id),
+    updated_at TIMESTAMPTZ,
+    UNIQUE (stock_item_id, lot_number)
+);
+
+CREATE INDEX stock_lot_expiry_date_idx
+    ON stock_lot (expiry_date)
+    WHERE expiry_date IS NOT NULL;
+
+-- Create the lots already posted to the ledger
+INSERT INTO stock_lot (stock_item_id, lot_number, created_at)
+SELECT
+    t.stock_item_id,
+    e.lot_number,
+    MIN(t.timestamp)
+FROM
+    stock_transaction_entry e
+JOIN stock_transaction t ON t.stock_transaction_id = e.stock_transaction_id
+WHERE
+    e.lot_number <> ''
+GROUP BY
+    t.stock_item_id,
+    e.lot_number;
@@ -0,0 +1,31 @@
+-- 00002700.sql: add serial number tracking for serialised stock items
+
+-- Every posting of a serialised stock item lists the serial numbers it moves,
+-- one per unit of its base unit
+ALTER TABLE stock_item
+    ADD COLUMN is_serialised BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE stock_item_change
+    ADD COLUMN is_serialised BOOLEAN;
+
+CREATE TABLE stock_serial (
+    stock_serial_id INTEGER GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    stock_item_id INT NOT NULL REFERENCES stock_item(stock_item_id) ON DELETE CASCADE,
+    serial_number TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (stock_item_id, serial_number)
+);
+
+-- The serials moved by each transaction. The movement history and current
+-- location of a serial are derived from the ledger entries of its transactions.
+CREATE TABLE stock_transaction_serial (
+    stock_transaction_id INT NOT NULL REFERENCES stock_transaction(stock_transaction_id),
+    stock_serial_id INT NOT NULL REFERENCES stock_serial(stock_serial_id),
+    PRIMARY KEY (stock_transaction_id, stock_serial_id)
+);
+
+CREATE INDEX stock_transaction_serial_stock_serial_id_idx
+    ON stock_transaction_serial(stock_serial_id);
+
+ALTER TABLE stock_document_line
+    ADD COLUMN serial_numbers TEXT[] NOT NULL DEFAULT '{}';
@@ -0,0 +1,30 @@
+-- 00002800.sql: add stock reservations against demand
+
+-- A reservation holds stock of an item for a demand such as a job or an
+-- order. An empty location, bin or lot number reserves across all of them.
+-- quantity is what is still reserved, it is drawn down by postings that
+-- consume stock for the demand.
+CREATE TABLE stock_reservation (
+    stock_reservation_id INTEGER GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    stock_item_id INT NOT NULL REFERENCES stock_item(stock_item_id) ON DELETE CASCADE,
+    location TEXT NOT NULL DEFAULT '',
+    bin TEXT NOT NULL DEFAULT '',
+    lot_number TEXT NOT NULL DEFAULT '',
+    reserved_quantity NUMERIC NOT NULL CHECK (reserved_quantity > 0),
+    quantity NUMERIC NOT NULL CHECK (quantity >= 0),
+    demand_reference TEXT NOT NULL,
+    note TEXT NOT NULL DEFAULT '',
+    status TEXT NOT NULL DEFAULT 'Open'
+        CHECK (status IN ('Open', 'Fulfilled', 'Released')),
+    created_by INT NOT NULL REFERENCES app_user(user_id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    closed_by INT REFERENCES app_user(user_id),
+    closed_at TIMESTAMPTZ
+);
+
+CREATE INDEX stock_reservation_open_idx
+    ON stock_reservation(stock_item_id, location, bin, lot_number)
+    WHERE status = 'Open';
+
+CREATE INDEX stock_reservation_demand_reference_idx
+    ON stock_reservation(demand_reference);
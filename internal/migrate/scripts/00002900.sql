@@ -0,0 +1,26 @@
+-- 00002900.sql: add reorder policies with min/max levels
+
+-- A reorder policy sets the min, reorder point and max of a stock item, in
+-- its base unit. A NULL location applies the policy to the item across all
+-- locations. Stock available (on hand less reserved) at or below the reorder
+-- point needs replenishing up to the max.
+CREATE TABLE stock_reorder_policy (
+    stock_reorder_policy_id INTEGER GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    stock_item_id INT NOT NULL REFERENCES stock_item(stock_item_id) ON DELETE CASCADE,
+    location TEXT,
+    min_quantity NUMERIC NOT NULL CHECK (min_quantity >= 0),
+    reorder_point NUMERIC NOT NULL,
+    max_quantity NUMERIC NOT NULL,
+    notify_team_id INT REFERENCES team(team_id) ON DELETE SET NULL,
+    -- whether available stock was at or below the reorder point when last
+    -- checked, so that the team is only notified when it crosses
+    is_below_reorder_point BOOLEAN NOT NULL DEFAULT FALSE,
+    updated_by INT REFERENCES app_user(user_id),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (reorder_point >= min_quantity),
+    CHECK (max_quantity > reorder_point)
+);
+
+-- One policy per stock item and location
+CREATE UNIQUE INDEX stock_reorder_policy_scope_idx
+    ON stock_reorder_policy (stock_item_id, (COALESCE(location, '')));
@@ -0,0 +1,60 @@
+-- 00003000.sql: add location and bin master data
+
+CREATE TABLE stock_location (
+    stock_location_id INTEGER GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    location TEXT NOT NULL UNIQUE CHECK (location <> ''),
+    description TEXT NOT NULL DEFAULT '',
+    location_type TEXT NOT NULL DEFAULT 'Storage'
+        CHECK (location_type IN ('Storage', 'Quarantine', 'Line-side')),
+    is_archived BOOLEAN NOT NULL DEFAULT FALSE,
+    created_by INT REFERENCES app_user(user_id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_by INT REFERENCES app_user(user_id),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- capacity is the most stock the bin holds, summed across stock items in
+-- their base units. A NULL capacity is unlimited.
+CREATE TABLE stock_bin (
+    stock_bin_id INTEGER GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    stock_location_id INT NOT NULL REFERENCES stock_location(stock_location_id) ON DELETE CASCADE,
+    bin TEXT NOT NULL CHECK (bin <> ''),
+    capacity NUMERIC CHECK (capacity > 0),
+    is_archived BOOLEAN NOT NULL DEFAULT FALSE,
+    created_by INT REFERENCES app_user(user_id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_by INT REFERENCES app_user(user_id),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (stock_location_id, bin)
+);
+
+-- Add the locations and bins already posted to or on draft documents so that
+-- existing stock stays valid
+WITH place AS (
+    SELECT location, bin FROM stock_transaction_entry
+    UNION
+    SELECT from_location, from_bin FROM stock_document_line
+    UNION
+    SELECT to_location, to_bin FROM stock_document_line
+)
+INSERT INTO stock_location (location)
+SELECT DISTINCT location FROM place WHERE location <> ''
+ON CONFLICT (location) DO NOTHING;
+
+WITH place AS (
+    SELECT location, bin FROM stock_transaction_entry
+    UNION
+    SELECT from_location, from_bin FROM stock_document_line
+    UNION
+    SELECT to_location, to_bin FROM stock_document_line
+)
+INSERT INTO stock_bin (stock_location_id, bin)
+SELECT DISTINCT
+    sl.stock_location_id,
+    p.bin
+FROM
+    place p
+JOIN stock_location sl ON sl.location = p.location
+WHERE
+    p.bin <> ''
+ON CONFLICT (stock_location_id, bin) DO NOTHING;
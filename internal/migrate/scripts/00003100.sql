@@ -0,0 +1,64 @@
+-- 00003100.sql: add stock costing and valuation
+
+ALTER TABLE stock_item
+    ADD COLUMN costing_method TEXT NOT NULL DEFAULT 'Weighted Average'
+        CHECK (costing_method IN ('Weighted Average', 'FIFO'));
+
+ALTER TABLE stock_item_change
+    ADD COLUMN costing_method TEXT;
+
+-- unit_cost is the cost of each base unit of stock the transaction moved.
+-- Transactions posted before costing have no cost and are valued at zero.
+ALTER TABLE stock_transaction
+    ADD COLUMN unit_cost NUMERIC CHECK (unit_cost >= 0);
+
+ALTER TABLE stock_document_line
+    ADD COLUMN unit_cost NUMERIC CHECK (unit_cost >= 0);
+
+-- The weighted average cost of each stock item across the STOCK account.
+-- quantity is what the average applies to, kept in step by every posting.
+CREATE TABLE stock_item_cost (
+    stock_item_id INT PRIMARY KEY REFERENCES stock_item(stock_item_id),
+    quantity NUMERIC NOT NULL DEFAULT 0,
+    average_cost NUMERIC NOT NULL DEFAULT 0 CHECK (average_cost >= 0),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- FIFO layers of each stock item, one per posting into the STOCK account.
+-- Stock taken out of the account uses up the oldest layers first.
+CREATE TABLE stock_cost_layer (
+    stock_cost_layer_id INTEGER GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    stock_item_id INT NOT NULL REFERENCES stock_item(stock_item_id),
+    received_at TIMESTAMPTZ NOT NULL,
+    quantity NUMERIC NOT NULL CHECK (quantity > 0),
+    remaining_quantity NUMERIC NOT NULL CHECK (remaining_quantity >= 0),
+    unit_cost NUMERIC NOT NULL CHECK (unit_cost >= 0)
+);
+
+CREATE INDEX stock_cost_layer_open_idx
+    ON stock_cost_layer (stock_item_id, received_at, stock_cost_layer_id)
+    WHERE remaining_quantity > 0;
+
+-- Stock already on hand has no known cost
+INSERT INTO stock_item_cost (stock_item_id, quantity)
+SELECT
+    stock_item_id,
+    SUM(quantity)
+FROM
+    stock_balance
+WHERE
+    account = 'STOCK'
+GROUP BY
+    stock_item_id;
+
+INSERT INTO stock_cost_layer (stock_item_id, received_at, quantity, remaining_quantity, unit_cost)
+SELECT
+    stock_item_id,
+    NOW(),
+    quantity,
+    quantity,
+    0
+FROM
+    stock_item_cost
+WHERE
+    quantity > 0;
@@ -0,0 +1,33 @@
+-- 00003200.sql: add bills of materials
+
+-- A version of the bill of materials of a stock item. The version in effect
+-- on a date is the one with the latest effective_from on or before it that
+-- has not yet reached its effective_to.
+CREATE TABLE stock_bom (
+    stock_bom_id INTEGER GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    stock_item_id INT NOT NULL REFERENCES stock_item(stock_item_id),
+    version TEXT NOT NULL CHECK (version <> ''),
+    effective_from DATE NOT NULL,
+    effective_to DATE CHECK (effective_to > effective_from),
+    note TEXT NOT NULL DEFAULT '',
+    created_by INT REFERENCES app_user(user_id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_by INT REFERENCES app_user(user_id),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (stock_item_id, version)
+);
+
+-- quantity_per is the quantity of the component, in its base unit, used for
+-- each base unit of the parent. scrap_factor is the fraction lost on top,
+-- so 0.05 uses 5% more.
+CREATE TABLE stock_bom_line (
+    stock_bom_line_id INTEGER GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    stock_bom_id INT NOT NULL REFERENCES stock_bom(stock_bom_id) ON DELETE CASCADE,
+    component_stock_item_id INT NOT NULL REFERENCES stock_item(stock_item_id),
+    quantity_per NUMERIC NOT NULL CHECK (quantity_per > 0),
+    scrap_factor NUMERIC NOT NULL DEFAULT 0 CHECK (scrap_factor >= 0),
+    UNIQUE (stock_bom_id, component_stock_item_id)
+);
+
+CREATE INDEX stock_bom_line_component_idx
+    ON stock_bom_line (component_stock_item_id);
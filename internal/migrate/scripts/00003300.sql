@@ -0,0 +1,59 @@
+-- 00003300.sql: add suppliers, purchase orders and goods receipt
+
+-- over_receipt_tolerance is the fraction of an ordered quantity that may be
+-- received on top of it, so 0.1 allows 110% to be received
+CREATE TABLE supplier (
+    supplier_id INTEGER GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    code TEXT NOT NULL UNIQUE CHECK (code <> ''),
+    name TEXT NOT NULL CHECK (name <> ''),
+    email TEXT NOT NULL DEFAULT '',
+    phone TEXT NOT NULL DEFAULT '',
+    over_receipt_tolerance NUMERIC NOT NULL DEFAULT 0 CHECK (over_receipt_tolerance >= 0),
+    is_archived BOOLEAN NOT NULL DEFAULT FALSE,
+    created_by INT REFERENCES app_user(user_id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_by INT REFERENCES app_user(user_id),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Lines are added while an order is a draft. Stock can be received against
+-- an order once it is open.
+CREATE TABLE purchase_order (
+    purchase_order_id INTEGER GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    supplier_id INT NOT NULL REFERENCES supplier(supplier_id),
+    reference TEXT NOT NULL UNIQUE CHECK (reference <> ''),
+    order_date DATE NOT NULL,
+    status TEXT NOT NULL DEFAULT 'Draft'
+        CHECK (status IN ('Draft', 'Open', 'Closed', 'Cancelled')),
+    note TEXT NOT NULL DEFAULT '',
+    created_by INT REFERENCES app_user(user_id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_by INT REFERENCES app_user(user_id),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX purchase_order_supplier_idx
+    ON purchase_order (supplier_id);
+
+-- quantity and received_quantity are in the base unit of the stock item.
+-- received_quantity is kept in step by goods receipts and their reversals.
+-- A line is Received once its quantity has been received and Closed when no
+-- more is expected.
+CREATE TABLE purchase_order_line (
+    purchase_order_line_id INTEGER GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    purchase_order_id INT NOT NULL REFERENCES purchase_order(purchase_order_id) ON DELETE CASCADE,
+    stock_item_id INT NOT NULL REFERENCES stock_item(stock_item_id),
+    quantity NUMERIC NOT NULL CHECK (quantity > 0),
+    received_quantity NUMERIC NOT NULL DEFAULT 0,
+    due_date DATE NOT NULL,
+    unit_price NUMERIC CHECK (unit_price >= 0),
+    status TEXT NOT NULL DEFAULT 'Open'
+        CHECK (status IN ('Open', 'Received', 'Closed'))
+);
+
+CREATE INDEX purchase_order_line_stock_item_idx
+    ON purchase_order_line (stock_item_id)
+    WHERE status = 'Open';
+
+ALTER TABLE stock_transaction
+    ADD COLUMN purchase_order_line_id INT REFERENCES purchase_order_line(purchase_order_line_id);
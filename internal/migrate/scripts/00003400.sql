@@ -0,0 +1,89 @@
+-- 00003400.sql: add customers, sales orders, pick lists and dispatch
+
+CREATE TABLE customer (
+    customer_id INTEGER GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    code TEXT NOT NULL UNIQUE CHECK (code <> ''),
+    name TEXT NOT NULL CHECK (name <> ''),
+    email TEXT NOT NULL DEFAULT '',
+    phone TEXT NOT NULL DEFAULT '',
+    delivery_address TEXT NOT NULL DEFAULT '',
+    is_archived BOOLEAN NOT NULL DEFAULT FALSE,
+    created_by INT REFERENCES app_user(user_id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_by INT REFERENCES app_user(user_id),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Lines are added while an order is a draft. Pick lists can be generated
+-- for an order once it is open. The reference of the order is the demand
+-- reference of its dispatches, so reservations made for it are drawn down as
+-- it is dispatched.
+CREATE TABLE sales_order (
+    sales_order_id INTEGER GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    customer_id INT NOT NULL REFERENCES customer(customer_id),
+    reference TEXT NOT NULL UNIQUE CHECK (reference <> ''),
+    customer_reference TEXT NOT NULL DEFAULT '',
+    order_date DATE NOT NULL,
+    status TEXT NOT NULL DEFAULT 'Draft'
+        CHECK (status IN ('Draft', 'Open', 'Closed', 'Cancelled')),
+    note TEXT NOT NULL DEFAULT '',
+    created_by INT REFERENCES app_user(user_id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_by INT REFERENCES app_user(user_id),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX sales_order_customer_idx
+    ON sales_order (customer_id);
+
+-- quantity and dispatched_quantity are in the base unit of the stock item.
+-- dispatched_quantity is kept in step by dispatches and their reversals.
+-- A line is Dispatched once its quantity has been dispatched and Closed when
+-- no more will be.
+CREATE TABLE sales_order_line (
+    sales_order_line_id INTEGER GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    sales_order_id INT NOT NULL REFERENCES sales_order(sales_order_id) ON DELETE CASCADE,
+    stock_item_id INT NOT NULL REFERENCES stock_item(stock_item_id),
+    quantity NUMERIC NOT NULL CHECK (quantity > 0),
+    dispatched_quantity NUMERIC NOT NULL DEFAULT 0,
+    due_date DATE NOT NULL,
+    unit_price NUMERIC CHECK (unit_price >= 0),
+    status TEXT NOT NULL DEFAULT 'Open'
+        CHECK (status IN ('Open', 'Dispatched', 'Closed'))
+);
+
+-- A pick list proposes where to pick the outstanding lines of an order from.
+-- Confirming the dispatch of an open pick list posts what was picked.
+CREATE TABLE pick_list (
+    pick_list_id INTEGER GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    sales_order_id INT NOT NULL REFERENCES sales_order(sales_order_id),
+    status TEXT NOT NULL DEFAULT 'Open'
+        CHECK (status IN ('Open', 'Dispatched', 'Cancelled')),
+    created_by INT REFERENCES app_user(user_id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    dispatched_by INT REFERENCES app_user(user_id),
+    dispatched_at TIMESTAMPTZ
+);
+
+CREATE INDEX pick_list_sales_order_idx
+    ON pick_list (sales_order_id);
+
+-- dispatched_quantity and serial_numbers are set when the dispatch is
+-- confirmed
+CREATE TABLE pick_list_line (
+    pick_list_line_id INTEGER GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    pick_list_id INT NOT NULL REFERENCES pick_list(pick_list_id) ON DELETE CASCADE,
+    sales_order_line_id INT NOT NULL REFERENCES sales_order_line(sales_order_line_id),
+    location TEXT NOT NULL,
+    bin TEXT NOT NULL DEFAULT '',
+    lot_number TEXT NOT NULL DEFAULT '',
+    quantity NUMERIC NOT NULL CHECK (quantity > 0),
+    dispatched_quantity NUMERIC CHECK (dispatched_quantity >= 0),
+    serial_numbers TEXT[] NOT NULL DEFAULT '{}'
+);
+
+CREATE INDEX pick_list_line_sales_order_line_idx
+    ON pick_list_line (sales_order_line_id);
+
+ALTER TABLE stock_transaction
+    ADD COLUMN sales_order_line_id INT REFERENCES sales_order_line(sales_order_line_id);
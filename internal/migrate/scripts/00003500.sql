@@ -0,0 +1,19 @@
+-- 00003500.sql: add stock imports
+
+-- An upload of a CSV file of stock postings. The file is kept for audit and
+-- its rows are validated again when the import is posted, so row_count and
+-- error_row_count are as of the upload.
+CREATE TABLE stock_import (
+    stock_import_id INTEGER GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    filename TEXT NOT NULL,
+    file_id UUID REFERENCES file(file_id),
+    status TEXT NOT NULL DEFAULT 'Pending'
+        CHECK (status IN ('Pending', 'Posted', 'Cancelled')),
+    row_count INT NOT NULL DEFAULT 0,
+    error_row_count INT NOT NULL DEFAULT 0,
+    posted_row_count INT,
+    created_by INT REFERENCES app_user(user_id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    posted_by INT REFERENCES app_user(user_id),
+    posted_at TIMESTAMPTZ
+);
@@ -0,0 +1,7 @@
+-- 00003600.sql: add quarantine reasons and approvals to stock transactions
+
+-- Stock placed on hold, released or rejected by quality control records why
+-- and who approved it
+ALTER TABLE stock_transaction
+    ADD COLUMN reason TEXT NOT NULL DEFAULT '',
+    ADD COLUMN approved_by INT REFERENCES app_user(user_id);
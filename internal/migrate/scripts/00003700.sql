@@ -0,0 +1,27 @@
+-- 00003700.sql: add scrap reasons
+
+-- The reasons stock can be scrapped for. Every posting into the SCRAP account
+-- records one so that waste can be reported by reason. Reasons in use are
+-- archived rather than deleted.
+CREATE TABLE scrap_reason (
+    scrap_reason_id INTEGER GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    code TEXT NOT NULL UNIQUE CHECK (code <> ''),
+    description TEXT NOT NULL DEFAULT '',
+    is_archived BOOLEAN NOT NULL DEFAULT FALSE,
+    created_by INT REFERENCES app_user(user_id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_by INT REFERENCES app_user(user_id),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO scrap_reason (code, description) VALUES
+    ('DAMAGED', 'Damaged in storage or handling'),
+    ('EXPIRED', 'Past its expiry date'),
+    ('QUALITY', 'Failed quality inspection'),
+    ('OBSOLETE', 'No longer used');
+
+ALTER TABLE stock_transaction
+    ADD COLUMN scrap_reason_id INT REFERENCES scrap_reason(scrap_reason_id);
+
+CREATE INDEX stock_transaction_scrap_reason_id_idx
+    ON stock_transaction (scrap_reason_id);
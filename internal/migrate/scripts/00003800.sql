@@ -0,0 +1,31 @@
+-- 00003800.sql: add stock transaction types defined by admins
+
+-- Transaction types defined in the database rather than in code. Each moves
+-- stock from one account to another and is posted through the custom
+-- transaction page. The accounts cannot be changed once defined as postings
+-- are reversed against them.
+CREATE TABLE custom_stock_transaction_type (
+    custom_stock_transaction_type_id INTEGER GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE CHECK (name <> ''),
+    description TEXT NOT NULL DEFAULT '',
+    from_account TEXT NOT NULL CHECK (from_account <> ''),
+    to_account TEXT NOT NULL CHECK (to_account <> ''),
+    allow_different_location BOOLEAN NOT NULL DEFAULT FALSE,
+    allow_different_bin BOOLEAN NOT NULL DEFAULT FALSE,
+    allow_different_lot BOOLEAN NOT NULL DEFAULT FALSE,
+    required_fields TEXT[] NOT NULL DEFAULT '{}',
+    required_permission TEXT NOT NULL,
+    is_archived BOOLEAN NOT NULL DEFAULT FALSE,
+    created_by INT REFERENCES app_user(user_id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_by INT REFERENCES app_user(user_id),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    -- posting from and to the same place of the same account is not allowed
+    CHECK (
+        from_account <> to_account
+        OR allow_different_location
+        OR allow_different_bin
+        OR allow_different_lot
+    )
+);
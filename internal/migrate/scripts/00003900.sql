@@ -0,0 +1,24 @@
+-- 00003900.sql: add accounting periods that close the ledger to postings
+
+-- A calendar month of the stock ledger. Once closed, no posting can be made
+-- with a timestamp in the month, so running totals of closed months cannot be
+-- rewritten by back-dating. A month with no row is open.
+CREATE TABLE stock_period (
+    period DATE PRIMARY KEY CHECK (period = date_trunc('month', period)::date),
+    is_closed BOOLEAN NOT NULL,
+    updated_by INT REFERENCES app_user(user_id),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Every close and reopen of a period, with the reason given. Reopening a
+-- period always needs a reason.
+CREATE TABLE stock_period_change (
+    stock_period_change_id INTEGER GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    period DATE NOT NULL REFERENCES stock_period(period),
+    is_closed BOOLEAN NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    change_by INT REFERENCES app_user(user_id),
+    changed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CHECK (is_closed OR reason <> '')
+);
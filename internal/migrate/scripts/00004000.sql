@@ -0,0 +1,44 @@
+-- 00004000.sql: keep the cost of stock on QC hold
+
+-- Stock on QC hold is now costed with STOCK, so holds no longer use up FIFO
+-- layers and releases no longer add them. Stock on hold when this runs has
+-- already used up its layers, so it is put back at the average cost.
+WITH held AS (
+    SELECT
+        stock_item_id,
+        SUM(quantity) AS quantity
+    FROM
+        stock_balance
+    WHERE
+        account = 'QUARANTINE'
+    GROUP BY
+        stock_item_id
+    HAVING
+        SUM(quantity) <> 0
+)
+UPDATE stock_item_cost c
+SET
+    quantity = c.quantity + held.quantity,
+    updated_at = NOW()
+FROM
+    held
+WHERE
+    c.stock_item_id = held.stock_item_id;
+
+INSERT INTO stock_cost_layer (stock_item_id, received_at, quantity, remaining_quantity, unit_cost)
+SELECT
+    b.stock_item_id,
+    NOW(),
+    SUM(b.quantity),
+    SUM(b.quantity),
+    c.average_cost
+FROM
+    stock_balance b
+JOIN stock_item_cost c ON c.stock_item_id = b.stock_item_id
+WHERE
+    b.account = 'QUARANTINE'
+GROUP BY
+    b.stock_item_id,
+    c.average_cost
+HAVING
+    SUM(b.quantity) > 0;
@@ -0,0 +1,150 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type Supplier struct {
+	SupplierID int
+	Code       string
+	Name       string
+	Email      string
+	Phone      string
+	// OverReceiptTolerance is the fraction of an ordered quantity that may be
+	// received on top of it
+	OverReceiptTolerance decimal.Decimal
+	IsArchived           bool
+	CreatedByUsername    *string
+	CreatedAt            time.Time
+	UpdatedByUsername    *string
+	UpdatedAt            time.Time
+}
+
+type NewSupplier struct {
+	Code                 string
+	Name                 string
+	Email                string
+	Phone                string
+	OverReceiptTolerance decimal.Decimal
+}
+
+type SupplierUpdate struct {
+	Name                 string
+	Email                string
+	Phone                string
+	OverReceiptTolerance decimal.Decimal
+	IsArchived           bool
+}
+
+type GetSuppliersQuery struct {
+	SearchText   string
+	ShowArchived bool
+	Page         int
+	PageSize     int
+}
+
+type PurchaseOrderStatus string
+
+const (
+	DraftPurchaseOrderStatus     PurchaseOrderStatus = "Draft"
+	OpenPurchaseOrderStatus      PurchaseOrderStatus = "Open"
+	ClosedPurchaseOrderStatus    PurchaseOrderStatus = "Closed"
+	CancelledPurchaseOrderStatus PurchaseOrderStatus = "Cancelled"
+)
+
+var PurchaseOrderStatuses = []PurchaseOrderStatus{
+	DraftPurchaseOrderStatus,
+	OpenPurchaseOrderStatus,
+	ClosedPurchaseOrderStatus,
+	CancelledPurchaseOrderStatus,
+}
+
+type PurchaseOrderLineStatus string
+
+const (
+	OpenPurchaseOrderLineStatus     PurchaseOrderLineStatus = "Open"
+	ReceivedPurchaseOrderLineStatus PurchaseOrderLineStatus = "Received"
+	ClosedPurchaseOrderLineStatus   PurchaseOrderLineStatus = "Closed"
+)
+
+type PurchaseOrder struct {
+	PurchaseOrderID      int
+	SupplierID           int
+	SupplierCode         string
+	SupplierName         string
+	OverReceiptTolerance decimal.Decimal
+	Reference            string
+	OrderDate            time.Time
+	Status               PurchaseOrderStatus
+	Note                 string
+	LineCount            int
+	CreatedByUsername    *string
+	CreatedAt            time.Time
+	UpdatedByUsername    *string
+	UpdatedAt            time.Time
+}
+
+type NewPurchaseOrder struct {
+	SupplierID int
+	Reference  string
+	OrderDate  time.Time
+	Note       string
+}
+
+// GetPurchaseOrdersQuery filters purchase orders. StockCode restricts them to
+// orders with a line for the stock item.
+type GetPurchaseOrdersQuery struct {
+	Status     PurchaseOrderStatus
+	SupplierID int
+	StockCode  string
+	Page       int
+	PageSize   int
+}
+
+// PurchaseOrderLine quantities are in the base unit of the stock item
+type PurchaseOrderLine struct {
+	PurchaseOrderLineID int
+	PurchaseOrderID     int
+	StockItemID         int
+	StockCode           string
+	Description         string
+	Unit                string
+	Qty                 decimal.Decimal
+	ReceivedQty         decimal.Decimal
+	DueDate             time.Time
+	UnitPrice           *decimal.Decimal
+	Status              PurchaseOrderLineStatus
+}
+
+// OutstandingQty is the quantity still to be received, never negative
+func (l PurchaseOrderLine) OutstandingQty() decimal.Decimal {
+	return decimal.Max(l.Qty.Sub(l.ReceivedQty), decimal.Zero)
+}
+
+// ReceivableQty is the most that can still be received given the over
+// receipt tolerance of the supplier
+func (l PurchaseOrderLine) ReceivableQty(tolerance decimal.Decimal) decimal.Decimal {
+	limit := l.Qty.Mul(decimal.NewFromInt(1).Add(tolerance))
+	return decimal.Max(limit.Sub(l.ReceivedQty), decimal.Zero)
+}
+
+type NewPurchaseOrderLine struct {
+	StockItemID int
+	Qty         decimal.Decimal
+	DueDate     time.Time
+	UnitPrice   *decimal.Decimal
+}
+
+// ReceivePurchaseOrderLineInput receives stock against a purchase order line
+// into a location and bin of the STOCK account
+type ReceivePurchaseOrderLineInput struct {
+	PurchaseOrderLineID int
+	Qty                 decimal.Decimal
+	Location            string
+	Bin                 string
+	LotNumber           string
+	SerialNumbers       []string
+	TransactionNote     string
+}
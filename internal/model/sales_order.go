@@ -0,0 +1,199 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type Customer struct {
+	CustomerID        int
+	Code              string
+	Name              string
+	Email             string
+	Phone             string
+	DeliveryAddress   string
+	IsArchived        bool
+	CreatedByUsername *string
+	CreatedAt         time.Time
+	UpdatedByUsername *string
+	UpdatedAt         time.Time
+}
+
+type NewCustomer struct {
+	Code            string
+	Name            string
+	Email           string
+	Phone           string
+	DeliveryAddress string
+}
+
+type CustomerUpdate struct {
+	Name            string
+	Email           string
+	Phone           string
+	DeliveryAddress string
+	IsArchived      bool
+}
+
+type GetCustomersQuery struct {
+	SearchText   string
+	ShowArchived bool
+	Page         int
+	PageSize     int
+}
+
+type SalesOrderStatus string
+
+const (
+	DraftSalesOrderStatus     SalesOrderStatus = "Draft"
+	OpenSalesOrderStatus      SalesOrderStatus = "Open"
+	ClosedSalesOrderStatus    SalesOrderStatus = "Closed"
+	CancelledSalesOrderStatus SalesOrderStatus = "Cancelled"
+)
+
+var SalesOrderStatuses = []SalesOrderStatus{
+	DraftSalesOrderStatus,
+	OpenSalesOrderStatus,
+	ClosedSalesOrderStatus,
+	CancelledSalesOrderStatus,
+}
+
+type SalesOrderLineStatus string
+
+const (
+	OpenSalesOrderLineStatus       SalesOrderLineStatus = "Open"
+	DispatchedSalesOrderLineStatus SalesOrderLineStatus = "Dispatched"
+	ClosedSalesOrderLineStatus     SalesOrderLineStatus = "Closed"
+)
+
+// SalesOrder Reference is also the demand reference of its dispatches
+type SalesOrder struct {
+	SalesOrderID      int
+	CustomerID        int
+	CustomerCode      string
+	CustomerName      string
+	DeliveryAddress   string
+	Reference         string
+	CustomerReference string
+	OrderDate         time.Time
+	Status            SalesOrderStatus
+	Note              string
+	LineCount         int
+	CreatedByUsername *string
+	CreatedAt         time.Time
+	UpdatedByUsername *string
+	UpdatedAt         time.Time
+}
+
+type NewSalesOrder struct {
+	CustomerID        int
+	Reference         string
+	CustomerReference string
+	OrderDate         time.Time
+	Note              string
+}
+
+// GetSalesOrdersQuery filters sales orders. StockCode restricts them to
+// orders with a line for the stock item.
+type GetSalesOrdersQuery struct {
+	Status     SalesOrderStatus
+	CustomerID int
+	StockCode  string
+	Page       int
+	PageSize   int
+}
+
+// SalesOrderLine quantities are in the base unit of the stock item.
+// PickingQty is what is on open pick lists, waiting to be dispatched.
+type SalesOrderLine struct {
+	SalesOrderLineID int
+	SalesOrderID     int
+	StockItemID      int
+	StockCode        string
+	Description      string
+	Unit             string
+	Qty              decimal.Decimal
+	DispatchedQty    decimal.Decimal
+	PickingQty       decimal.Decimal
+	DueDate          time.Time
+	UnitPrice        *decimal.Decimal
+	Status           SalesOrderLineStatus
+}
+
+// OutstandingQty is the quantity still to be dispatched, never negative
+func (l SalesOrderLine) OutstandingQty() decimal.Decimal {
+	return decimal.Max(l.Qty.Sub(l.DispatchedQty), decimal.Zero)
+}
+
+// UnpickedQty is the outstanding quantity that is not on an open pick list
+func (l SalesOrderLine) UnpickedQty() decimal.Decimal {
+	return decimal.Max(l.OutstandingQty().Sub(l.PickingQty), decimal.Zero)
+}
+
+type NewSalesOrderLine struct {
+	StockItemID int
+	Qty         decimal.Decimal
+	DueDate     time.Time
+	UnitPrice   *decimal.Decimal
+}
+
+type PickListStatus string
+
+const (
+	OpenPickListStatus       PickListStatus = "Open"
+	DispatchedPickListStatus PickListStatus = "Dispatched"
+	CancelledPickListStatus  PickListStatus = "Cancelled"
+)
+
+type PickList struct {
+	PickListID           int
+	SalesOrderID         int
+	SalesOrderReference  string
+	Status               PickListStatus
+	LineCount            int
+	CreatedByUsername    *string
+	CreatedAt            time.Time
+	DispatchedByUsername *string
+	DispatchedAt         *time.Time
+}
+
+// PickListLine is a proposed pick of a sales order line from a place in the
+// STOCK account. DispatchedQty is set once the dispatch is confirmed.
+type PickListLine struct {
+	PickListLineID   int
+	PickListID       int
+	SalesOrderLineID int
+	StockItemID      int
+	StockCode        string
+	Description      string
+	Unit             string
+	Location         string
+	Bin              string
+	LotNumber        string
+	Qty              decimal.Decimal
+	DispatchedQty    *decimal.Decimal
+	SerialNumbers    []string
+}
+
+type NewPickListLine struct {
+	SalesOrderLineID int
+	Location         string
+	Bin              string
+	LotNumber        string
+	Qty              decimal.Decimal
+}
+
+// DispatchPickListLineInput is what was picked of a pick list line, which may
+// be less than proposed. Nothing is posted for lines picked short to 0.
+type DispatchPickListLineInput struct {
+	PickListLineID int
+	Qty            decimal.Decimal
+	SerialNumbers  []string
+}
+
+type DispatchPickListInput struct {
+	Lines                    []DispatchPickListLineInput
+	TransactionNote          string
+	AcknowledgeNegativeStock bool
+}
@@ -0,0 +1,81 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// StockBOM is a version of the bill of materials of a stock item
+type StockBOM struct {
+	StockBOMID    int
+	StockItemID   int
+	StockCode     string
+	Version       string
+	EffectiveFrom time.Time
+	// EffectiveTo is nil if the version has no end date
+	EffectiveTo       *time.Time
+	Note              string
+	LineCount         int
+	CreatedByUsername *string
+	CreatedAt         time.Time
+}
+
+// IsEffective reports whether the version is in effect on the date
+func (b StockBOM) IsEffective(at time.Time) bool {
+	return !b.EffectiveFrom.After(at) &&
+		(b.EffectiveTo == nil || b.EffectiveTo.After(at))
+}
+
+type StockBOMLine struct {
+	StockBOMLineID       int
+	StockBOMID           int
+	ComponentStockItemID int
+	ComponentStockCode   string
+	ComponentDescription string
+	// QtyPer is the quantity of the component, in its base unit, used for
+	// each base unit of the parent
+	QtyPer decimal.Decimal
+	Unit   string
+	// ScrapFactor is the fraction of the component lost on top of QtyPer
+	ScrapFactor decimal.Decimal
+}
+
+// RequiredQty is the quantity of the component used to make qty of the
+// parent, including scrap
+func (l StockBOMLine) RequiredQty(qty decimal.Decimal) decimal.Decimal {
+	return qty.Mul(l.QtyPer).Mul(decimal.NewFromInt(1).Add(l.ScrapFactor))
+}
+
+type NewStockBOM struct {
+	Version       string
+	EffectiveFrom time.Time
+	EffectiveTo   *time.Time
+	Note          string
+}
+
+type StockBOMUpdate struct {
+	EffectiveFrom time.Time
+	EffectiveTo   *time.Time
+	Note          string
+}
+
+type NewStockBOMLine struct {
+	ComponentStockItemID int
+	QtyPer               decimal.Decimal
+	ScrapFactor          decimal.Decimal
+}
+
+// StockBOMWhereUsed is a bill of materials that a component appears in
+type StockBOMWhereUsed struct {
+	StockBOMID    int
+	StockItemID   int
+	StockCode     string
+	Description   string
+	Version       string
+	EffectiveFrom time.Time
+	EffectiveTo   *time.Time
+	QtyPer        decimal.Decimal
+	Unit          string
+	ScrapFactor   decimal.Decimal
+}
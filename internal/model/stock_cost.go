@@ -0,0 +1,70 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type StockCostingMethod string
+
+const (
+	WeightedAverageStockCostingMethod StockCostingMethod = "Weighted Average"
+	FIFOStockCostingMethod            StockCostingMethod = "FIFO"
+)
+
+var StockCostingMethods = []StockCostingMethod{
+	WeightedAverageStockCostingMethod,
+	FIFOStockCostingMethod,
+}
+
+// StockItemCost is the weighted average cost of a stock item across the
+// STOCK account and the quantity it applies to
+type StockItemCost struct {
+	StockItemID int
+	Qty         decimal.Decimal
+	AverageCost decimal.Decimal
+}
+
+// StockCostLayer is what is left of a posting into the STOCK account for FIFO
+// costing
+type StockCostLayer struct {
+	StockCostLayerID int
+	RemainingQty     decimal.Decimal
+	UnitCost         decimal.Decimal
+}
+
+type GetStockValuationInput struct {
+	StockCode    string
+	Location     string
+	LTETimestamp *time.Time
+	Page         int
+	PageSize     int
+}
+
+// StockValuation is the value of the stock of an item at a location, the sum
+// of its postings into and out of the STOCK and QUARANTINE accounts at their
+// unit costs
+type StockValuation struct {
+	StockCode     string
+	Description   string
+	CostingMethod StockCostingMethod
+	Location      string
+	Qty           decimal.Decimal
+	Unit          string
+	Value         decimal.Decimal
+}
+
+// UnitCost is the average cost of each unit valued
+func (v StockValuation) UnitCost() decimal.Decimal {
+	if v.Qty.IsZero() {
+		return decimal.Zero
+	}
+	return v.Value.Div(v.Qty)
+}
+
+// StockValuationSummary totals a stock valuation across every row matched
+type StockValuationSummary struct {
+	Count int
+	Value decimal.Decimal
+}
@@ -0,0 +1,103 @@
+package model
+
+import (
+	"app/pkg/appsort"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type StockCountStatus string
+
+const (
+	CountingStockCountStatus  StockCountStatus = "Counting"
+	ApprovedStockCountStatus  StockCountStatus = "Approved"
+	CancelledStockCountStatus StockCountStatus = "Cancelled"
+)
+
+var StockCountStatuses = []StockCountStatus{
+	CountingStockCountStatus,
+	ApprovedStockCountStatus,
+	CancelledStockCountStatus,
+}
+
+// StockCount is a stocktake or cycle count session. Expected quantities are
+// frozen at SnapshotAt and variances are posted as of that time. An empty
+// scope slice means the count is not restricted on that dimension.
+type StockCount struct {
+	StockCountID       int
+	Reference          string `sortable:"true"`
+	Note               string
+	Locations          []string
+	Bins               []string
+	StockItemIDs       []int
+	IsBlind            bool
+	SnapshotAt         time.Time        `sortable:"true"`
+	Status             StockCountStatus `sortable:"true"`
+	LineCount          int
+	CountedLineCount   int
+	CreatedBy          int
+	CreatedByUsername  string
+	CreatedAt          time.Time `sortable:"true"`
+	ApprovedBy         *int
+	ApprovedByUsername *string
+	ApprovedAt         *time.Time
+}
+
+type StockCountLine struct {
+	StockCountLineID  int
+	StockCountID      int
+	StockItemID       int
+	StockCode         string
+	Unit              string
+	Location          string
+	Bin               string
+	LotNumber         string
+	ExpectedQty       decimal.Decimal
+	CountedQty        *decimal.Decimal
+	CountedByUsername *string
+	CountedAt         *time.Time
+}
+
+// Variance is the counted less the expected quantity, or nil if the line
+// has not been counted
+func (l StockCountLine) Variance() *decimal.Decimal {
+	if l.CountedQty == nil {
+		return nil
+	}
+	variance := l.CountedQty.Sub(l.ExpectedQty)
+	return &variance
+}
+
+type NewStockCount struct {
+	Reference    string
+	Note         string
+	Locations    []string
+	Bins         []string
+	StockItemIDs []int
+	IsBlind      bool
+}
+
+// NewStockCountLine is either a line frozen from stock levels when the count
+// is created, which has no counted quantity yet, or a line for stock found
+// during the count that was not expected at the snapshot
+type NewStockCountLine struct {
+	StockItemID int
+	Location    string
+	Bin         string
+	LotNumber   string
+	ExpectedQty decimal.Decimal
+	CountedQty  *decimal.Decimal
+}
+
+type StockCountLineCount struct {
+	StockCountLineID int
+	CountedQty       decimal.Decimal
+}
+
+type GetStockCountsQuery struct {
+	Status   StockCountStatus
+	Sort     appsort.Sort
+	Page     int
+	PageSize int
+}
@@ -0,0 +1,109 @@
+package model
+
+import (
+	"app/pkg/appsort"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type StockDocumentType string
+
+const (
+	GoodsReceiptStockDocumentType StockDocumentType = "Goods Receipt"
+	TransferStockDocumentType     StockDocumentType = "Transfer"
+	DispatchStockDocumentType     StockDocumentType = "Dispatch"
+	GeneralStockDocumentType      StockDocumentType = "General"
+	// ProductionBatchStockDocumentType links its consumption lines to its
+	// production lines for lot genealogy when posted
+	ProductionBatchStockDocumentType StockDocumentType = "Production Batch"
+)
+
+var StockDocumentTypes = []StockDocumentType{
+	GoodsReceiptStockDocumentType,
+	TransferStockDocumentType,
+	DispatchStockDocumentType,
+	GeneralStockDocumentType,
+	ProductionBatchStockDocumentType,
+}
+
+type StockDocumentStatus string
+
+const (
+	DraftStockDocumentStatus     StockDocumentStatus = "Draft"
+	PostedStockDocumentStatus    StockDocumentStatus = "Posted"
+	CancelledStockDocumentStatus StockDocumentStatus = "Cancelled"
+)
+
+var StockDocumentStatuses = []StockDocumentStatus{
+	DraftStockDocumentStatus,
+	PostedStockDocumentStatus,
+	CancelledStockDocumentStatus,
+}
+
+type StockDocument struct {
+	StockDocumentID   int
+	DocumentType      StockDocumentType `sortable:"true"`
+	Reference         string            `sortable:"true"`
+	DocumentDate      time.Time         `sortable:"true"`
+	Note              string
+	Status            StockDocumentStatus `sortable:"true"`
+	LineCount         int
+	CreatedBy         int
+	CreatedByUsername string
+	CreatedAt         time.Time `sortable:"true"`
+	PostedBy          *int
+	PostedByUsername  *string
+	PostedAt          *time.Time
+}
+
+type StockDocumentLine struct {
+	StockDocumentLineID int
+	StockDocumentID     int
+	TransactionType     StockTransactionType
+	StockItemID         int
+	StockCode           string
+	Qty                 decimal.Decimal
+	Unit                string
+	FromLocation        string
+	FromBin             string
+	FromLotNumber       string
+	ToLocation          string
+	ToBin               string
+	ToLotNumber         string
+	SerialNumbers       []string
+	UnitCost            *decimal.Decimal
+	LineNote            string
+}
+
+type NewStockDocument struct {
+	DocumentType StockDocumentType
+	Reference    string
+	DocumentDate time.Time
+	Note         string
+}
+
+type NewStockDocumentLine struct {
+	TransactionType StockTransactionType
+	StockItemID     int
+	Qty             decimal.Decimal
+	// Unit is the unit Qty is entered in. Lines are stored in the base unit.
+	Unit          string
+	FromLocation  string
+	FromBin       string
+	FromLotNumber string
+	ToLocation    string
+	ToBin         string
+	ToLotNumber   string
+	SerialNumbers []string
+	// UnitCost is the cost of each base unit for lines posted into STOCK
+	UnitCost *decimal.Decimal
+	LineNote string
+}
+
+type GetStockDocumentsQuery struct {
+	Status   StockDocumentStatus
+	Sort     appsort.Sort
+	Page     int
+	PageSize int
+}
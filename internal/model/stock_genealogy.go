@@ -0,0 +1,39 @@
+package model
+
+import "github.com/shopspring/decimal"
+
+type LotTraceDirection string
+
+const (
+	// ForwardLotTraceDirection follows a lot into the lots it was consumed to
+	// produce, e.g. which finished lots contain a raw lot
+	ForwardLotTraceDirection LotTraceDirection = "Forward"
+	// BackwardLotTraceDirection follows a lot back to the lots consumed to
+	// produce it, e.g. which raw lots went into a finished lot
+	BackwardLotTraceDirection LotTraceDirection = "Backward"
+)
+
+// StockLotLink is a consumed lot feeding a produced lot. Depth is the number
+// of links from the lot being traced, starting at 1.
+type StockLotLink struct {
+	Depth             int
+	ConsumedStockCode string
+	ConsumedLotNumber string
+	ConsumedQty       decimal.Decimal
+	ConsumedUnit      string
+	ProducedStockCode string
+	ProducedLotNumber string
+}
+
+type LotTrace struct {
+	StockCode string
+	LotNumber string
+	Forward   []StockLotLink
+	Backward  []StockLotLink
+}
+
+// BackflushedLot is how much of a lot of a component was backflushed
+type BackflushedLot struct {
+	LotNumber string
+	Qty       decimal.Decimal
+}
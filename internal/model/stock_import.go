@@ -0,0 +1,87 @@
+package model
+
+import (
+	"time"
+)
+
+type StockImportStatus string
+
+const (
+	PendingStockImportStatus   StockImportStatus = "Pending"
+	PostedStockImportStatus    StockImportStatus = "Posted"
+	CancelledStockImportStatus StockImportStatus = "Cancelled"
+)
+
+var StockImportStatuses = []StockImportStatus{
+	PendingStockImportStatus,
+	PostedStockImportStatus,
+	CancelledStockImportStatus,
+}
+
+// StockImportColumns are the column headings of a stock import file, in the
+// order of the template. Headings are matched ignoring case and spaces, and
+// columns may be left out or in any order except for the required ones.
+var StockImportColumns = []string{
+	"Transaction Type",
+	"Stock Code",
+	"Qty",
+	"Unit",
+	"From Location",
+	"From Bin",
+	"From Lot Number",
+	"To Location",
+	"To Bin",
+	"To Lot Number",
+	"Serial Numbers",
+	"Unit Cost",
+	"Note",
+}
+
+var StockImportRequiredColumns = []string{
+	"Transaction Type",
+	"Stock Code",
+	"Qty",
+	"From Location",
+}
+
+type StockImport struct {
+	StockImportID     int
+	Filename          string
+	FileID            *string
+	Status            StockImportStatus
+	RowCount          int
+	ErrorRowCount     int
+	PostedRowCount    *int
+	CreatedByUsername *string
+	CreatedAt         time.Time
+	PostedByUsername  *string
+	PostedAt          *time.Time
+}
+
+type GetStockImportsQuery struct {
+	Status   StockImportStatus
+	Page     int
+	PageSize int
+}
+
+// StockImportRow is a row of a stock import file as read, with the problems
+// found validating it. RowNumber is the line of the file, counting the
+// heading row. Transaction is what the row posts, only set for valid rows.
+type StockImportRow struct {
+	RowNumber       int
+	TransactionType StockTransactionType
+	StockCode       string
+	Qty             string
+	Unit            string
+	FromLocation    string
+	FromBin         string
+	FromLotNumber   string
+	ToLocation      string
+	ToBin           string
+	ToLotNumber     string
+	SerialNumbers   []string
+	UnitCost        string
+	Note            string
+	Errors          []string
+	Transaction     *NewStockTransaction
+}
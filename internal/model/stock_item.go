@@ -3,12 +3,17 @@ package model
 import (
 	"app/pkg/appsort"
 	"time"
+
+	"github.com/shopspring/decimal"
 )
 
 type StockItem struct {
 	StockItemID     int
 	StockCode       string `sortable:"true"`
 	Description     string `sortable:"true"`
+	BaseUnit        string
+	IsSerialised    bool
+	CostingMethod   StockCostingMethod
 	GalleryID       int
 	CommentThreadID int
 	CreatedAt       time.Time `sortable:"true"`
@@ -18,30 +23,69 @@ type StockItemChange struct {
 	StockItemID      int
 	StockCode        *string
 	Description      *string
+	BaseUnit         *string
+	IsSerialised     *bool
+	CostingMethod    *string
 	ChangeByUsername string
 	ChangedAt        time.Time
 	IsCreation       bool
 }
 
 type PostStockItemChange struct {
-	StockItemID int
-	StockCode   *string
-	Description *string
-	ChangeBy    int
+	StockItemID   int
+	StockCode     *string
+	Description   *string
+	BaseUnit      *string
+	IsSerialised  *bool
+	CostingMethod *string
+	ChangeBy      int
 }
 
+// LabelGenerator is a request to print labels for a stock item on the printer
+// assigned to the named print requirement
 type LabelGenerator struct {
-	StockCode  string
-	LabelCount int
+	StockCode       string
+	LabelCount      int
+	LotNumber       string
+	Qty             *decimal.Decimal
+	Unit            string
+	LabelSize       string
+	Barcode         string
+	RequirementName string
+}
+
+// CanPrintStockLabels is whether the user can print stock item labels
+func CanPrintStockLabels(perms UserPermissions) bool {
+	return perms.Printing.Operator || perms.SupplyChain.Admin || perms.SupplyChain.TeamMember
 }
 
 type PostStockItem struct {
 	StockCode       string
 	Description     string
+	BaseUnit        string
+	IsSerialised    bool
+	CostingMethod   StockCostingMethod
 	GalleryID       int
 	CommentThreadID int // populated by service when creating a new stock item
 }
 
+// StockItemUnit is an alternate unit a quantity of the stock item can be
+// entered in. ConversionFactor is the number of base units in one unit.
+type StockItemUnit struct {
+	StockItemUnitID   int
+	StockItemID       int
+	Unit              string
+	ConversionFactor  decimal.Decimal
+	CreatedBy         int
+	CreatedByUsername string
+	CreatedAt         time.Time
+}
+
+type NewStockItemUnit struct {
+	Unit             string
+	ConversionFactor decimal.Decimal
+}
+
 type GetStockItemsQuery struct {
 	Sort     appsort.Sort
 	Page     int
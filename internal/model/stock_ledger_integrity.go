@@ -0,0 +1,72 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// StockLedgerEntryDivergence is a ledger entry whose stored running total does
+// not match the total recomputed from quantities
+type StockLedgerEntryDivergence struct {
+	StockTransactionEntryID int
+	StockTransactionID      int
+	StockCode               string
+	Account                 StockAccount
+	Location                string
+	Bin                     string
+	LotNumber               string
+	Timestamp               time.Time
+	Quantity                decimal.Decimal
+	RunningTotal            decimal.Decimal
+	ExpectedRunningTotal    decimal.Decimal
+}
+
+// UnbalancedStockTransaction is a transaction whose entries do not net to zero
+type UnbalancedStockTransaction struct {
+	StockTransactionID int
+	TransactionType    StockTransactionType
+	StockCode          string
+	Timestamp          time.Time
+	EntryCount         int
+	NetQuantity        decimal.Decimal
+}
+
+// StockBalanceDivergence is a balance table row that does not match the
+// latest running total in the ledger
+type StockBalanceDivergence struct {
+	StockCode        string
+	Account          StockAccount
+	Location         string
+	Bin              string
+	LotNumber        string
+	BalanceQuantity  decimal.Decimal
+	LedgerQuantity   decimal.Decimal
+	MissingInBalance bool
+	MissingInLedger  bool
+}
+
+type StockLedgerIntegrityReport struct {
+	CheckedAt time.Time
+
+	DivergentEntries      []StockLedgerEntryDivergence
+	DivergentEntriesCount int
+
+	UnbalancedTransactions      []UnbalancedStockTransaction
+	UnbalancedTransactionsCount int
+
+	DivergentBalances      []StockBalanceDivergence
+	DivergentBalancesCount int
+}
+
+func (r StockLedgerIntegrityReport) IsClean() bool {
+	return r.DivergentEntriesCount == 0 &&
+		r.UnbalancedTransactionsCount == 0 &&
+		r.DivergentBalancesCount == 0
+}
+
+type StockLedgerRebuildResult struct {
+	RebuiltAt           time.Time
+	UpdatedEntriesCount int
+	BalancesCount       int
+}
@@ -0,0 +1,88 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type StockLocationType string
+
+const (
+	StorageStockLocationType    StockLocationType = "Storage"
+	QuarantineStockLocationType StockLocationType = "Quarantine"
+	LineSideStockLocationType   StockLocationType = "Line-side"
+)
+
+var StockLocationTypes = []StockLocationType{
+	StorageStockLocationType,
+	QuarantineStockLocationType,
+	LineSideStockLocationType,
+}
+
+type StockLocation struct {
+	StockLocationID   int
+	Location          string
+	Description       string
+	LocationType      StockLocationType
+	IsArchived        bool
+	BinCount          int
+	CreatedByUsername *string
+	CreatedAt         time.Time
+	UpdatedByUsername *string
+	UpdatedAt         time.Time
+}
+
+type NewStockLocation struct {
+	Location     string
+	Description  string
+	LocationType StockLocationType
+}
+
+type StockLocationUpdate struct {
+	Description  string
+	LocationType StockLocationType
+	IsArchived   bool
+}
+
+type GetStockLocationsQuery struct {
+	SearchText   string
+	ShowArchived bool
+	Page         int
+	PageSize     int
+}
+
+// StockBin is a bin within a location. Capacity is nil when unlimited.
+// StockLevel is what the STOCK account holds in the bin, summed across stock
+// items in their base units like the capacity.
+type StockBin struct {
+	StockBinID      int
+	StockLocationID int
+	Location        string
+	Bin             string
+	Capacity        *decimal.Decimal
+	IsArchived      bool
+	StockLevel      decimal.Decimal
+	UpdatedAt       time.Time
+}
+
+func (b StockBin) IsOverCapacity() bool {
+	return b.Capacity != nil && b.StockLevel.GreaterThan(*b.Capacity)
+}
+
+// PostStockBin adds a bin to a location or updates it if it exists
+type PostStockBin struct {
+	StockLocationID int
+	Bin             string
+	Capacity        *decimal.Decimal
+	IsArchived      bool
+}
+
+// StockPlaceStatus is whether a location and bin a posting names are in the
+// master data and archived. The bin fields are only set for a bin.
+type StockPlaceStatus struct {
+	LocationExists   bool
+	LocationArchived bool
+	BinExists        bool
+	BinArchived      bool
+}
@@ -0,0 +1,88 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type StockLotStatus string
+
+const (
+	AvailableStockLotStatus StockLotStatus = "Available"
+	OnHoldStockLotStatus    StockLotStatus = "On Hold"
+	RejectedStockLotStatus  StockLotStatus = "Rejected"
+)
+
+var StockLotStatuses = []StockLotStatus{
+	AvailableStockLotStatus,
+	OnHoldStockLotStatus,
+	RejectedStockLotStatus,
+}
+
+// StockLotExpiryWarningDays is how far ahead of its expiry date a lot is
+// treated as approaching expiry
+const StockLotExpiryWarningDays = 30
+
+// StockLot is the master record of a lot of a stock item. StockQty is the
+// quantity of the lot currently in the STOCK account across all locations.
+type StockLot struct {
+	StockLotID        int
+	StockItemID       int
+	StockCode         string
+	LotNumber         string
+	ManufactureDate   *time.Time
+	ExpiryDate        *time.Time
+	SupplierLotNumber string
+	Status            StockLotStatus
+	StockQty          decimal.Decimal
+	Unit              string
+	CreatedAt         time.Time
+	UpdatedByUsername *string
+	UpdatedAt         *time.Time
+}
+
+// IsExpired reports whether the lot is past its expiry date on the given day
+func (l StockLot) IsExpired(on time.Time) bool {
+	return IsStockLotExpired(l.ExpiryDate, on)
+}
+
+// IsStockLotExpired reports whether a lot with the given expiry date is
+// expired on the given day. A lot can be used up to and including its expiry
+// date.
+func IsStockLotExpired(expiryDate *time.Time, on time.Time) bool {
+	if expiryDate == nil {
+		return false
+	}
+	day := time.Date(on.Year(), on.Month(), on.Day(), 0, 0, 0, 0, time.UTC)
+	return expiryDate.Before(day)
+}
+
+type UpdateStockLot struct {
+	ManufactureDate   *time.Time
+	ExpiryDate        *time.Time
+	SupplierLotNumber string
+	Status            StockLotStatus
+}
+
+type GetStockLotsQuery struct {
+	StockCode string
+	LotNumber string
+	Status    StockLotStatus
+	// ExpiringWithinDays restricts results to lots in stock that expire
+	// within the number of days, including those already expired
+	ExpiringWithinDays int
+	Page               int
+	PageSize           int
+}
+
+// FEFOSuggestion is available stock of a lot to pick first, first expired
+// first out
+type FEFOSuggestion struct {
+	Location   string
+	Bin        string
+	LotNumber  string
+	Qty        decimal.Decimal
+	Unit       string
+	ExpiryDate *time.Time
+}
@@ -0,0 +1,56 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type NegativeStockAction string
+
+const (
+	AllowNegativeStockAction  NegativeStockAction = "Allow"
+	WarnNegativeStockAction   NegativeStockAction = "Warn"
+	RejectNegativeStockAction NegativeStockAction = "Reject"
+)
+
+var NegativeStockActions = []NegativeStockAction{
+	AllowNegativeStockAction,
+	WarnNegativeStockAction,
+	RejectNegativeStockAction,
+}
+
+// NegativeStockPolicy decides what happens when a posting would leave the
+// STOCK account negative. StockItemID and Location are nil when the policy
+// applies to any stock item or location.
+type NegativeStockPolicy struct {
+	NegativeStockPolicyID int
+	StockItemID           *int
+	StockCode             *string
+	Location              *string
+	Action                NegativeStockAction
+	UpdatedByUsername     *string
+	UpdatedAt             time.Time
+}
+
+func (p NegativeStockPolicy) IsGlobal() bool {
+	return p.StockItemID == nil && p.Location == nil
+}
+
+type PostNegativeStockPolicy struct {
+	StockItemID *int
+	Location    *string
+	Action      NegativeStockAction
+}
+
+// NegativeStockBalance is the lowest STOCK running total at or after a
+// posting for one stock item, location, bin and lot
+type NegativeStockBalance struct {
+	StockItemID  int
+	StockCode    string
+	Location     string
+	Bin          string
+	LotNumber    string
+	RunningTotal decimal.Decimal
+	Timestamp    time.Time
+}
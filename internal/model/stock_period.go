@@ -0,0 +1,36 @@
+package model
+
+import "time"
+
+// StockPeriod is a calendar month of the stock ledger. Postings cannot be
+// made with a timestamp in a closed period. Months that have never been
+// closed have no StockPeriod and are open.
+type StockPeriod struct {
+	// Period is the first day of the month
+	Period            time.Time
+	IsClosed          bool
+	UpdatedByUsername *string
+	UpdatedAt         time.Time
+}
+
+// StockPeriodChange is a close or reopen of a period, kept as an audit trail
+type StockPeriodChange struct {
+	StockPeriodChangeID int
+	Period              time.Time
+	IsClosed            bool
+	Reason              string
+	ChangeByUsername    *string
+	ChangedAt           time.Time
+}
+
+// StockPeriodOf returns the period the time falls in. Periods are months in
+// UTC, as timestamps are stored in the ledger.
+func StockPeriodOf(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// FormatStockPeriod formats a period as its month, e.g. January 2026
+func FormatStockPeriod(period time.Time) string {
+	return period.Format("January 2006")
+}
@@ -0,0 +1,66 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// StockReorderPolicy sets the min, reorder point and max of a stock item in
+// its base unit. Location is nil when the policy covers all locations.
+// NotifyTeamID is nil when the supply chain team is notified.
+type StockReorderPolicy struct {
+	StockReorderPolicyID int
+	StockItemID          int
+	StockCode            string
+	Location             *string
+	MinQty               decimal.Decimal
+	ReorderPoint         decimal.Decimal
+	MaxQty               decimal.Decimal
+	Unit                 string
+	NotifyTeamID         *int
+	NotifyTeamName       *string
+	IsBelowReorderPoint  bool
+	UpdatedByUsername    *string
+	UpdatedAt            time.Time
+}
+
+type PostStockReorderPolicy struct {
+	StockItemID  int
+	Location     *string
+	MinQty       decimal.Decimal
+	ReorderPoint decimal.Decimal
+	MaxQty       decimal.Decimal
+	NotifyTeamID *int
+}
+
+// StockReplenishment is the stock available under a reorder policy
+type StockReplenishment struct {
+	StockReorderPolicyID int
+	StockItemID          int
+	StockCode            string
+	Description          string
+	Location             *string
+	OnHand               decimal.Decimal
+	Reserved             decimal.Decimal
+	OnOrder              decimal.Decimal
+	MinQty               decimal.Decimal
+	ReorderPoint         decimal.Decimal
+	MaxQty               decimal.Decimal
+	Unit                 string
+	NotifyTeamID         *int
+}
+
+func (r StockReplenishment) Available() decimal.Decimal {
+	return r.OnHand.Sub(r.Reserved)
+}
+
+func (r StockReplenishment) IsBelowMin() bool {
+	return r.Available().LessThan(r.MinQty)
+}
+
+// SuggestedQty is the quantity to order to bring available stock up to the
+// max, less what is already on order
+func (r StockReplenishment) SuggestedQty() decimal.Decimal {
+	return decimal.Max(r.MaxQty.Sub(r.Available()).Sub(r.OnOrder), decimal.Zero)
+}
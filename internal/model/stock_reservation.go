@@ -0,0 +1,98 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type StockReservationStatus string
+
+const (
+	OpenStockReservationStatus      StockReservationStatus = "Open"
+	FulfilledStockReservationStatus StockReservationStatus = "Fulfilled"
+	ReleasedStockReservationStatus  StockReservationStatus = "Released"
+)
+
+var StockReservationStatuses = []StockReservationStatus{
+	OpenStockReservationStatus,
+	FulfilledStockReservationStatus,
+	ReleasedStockReservationStatus,
+}
+
+// StockReservation holds stock for a demand. An empty Location, Bin or
+// LotNumber reserves across all of them. Qty is what is still reserved of
+// ReservedQty, both in the base unit.
+type StockReservation struct {
+	StockReservationID int
+	StockItemID        int
+	StockCode          string
+	Location           string
+	Bin                string
+	LotNumber          string
+	ReservedQty        decimal.Decimal
+	Qty                decimal.Decimal
+	Unit               string
+	DemandReference    string
+	Note               string
+	Status             StockReservationStatus
+	CreatedByUsername  string
+	CreatedAt          time.Time
+	ClosedByUsername   *string
+	ClosedAt           *time.Time
+}
+
+// Covers reports whether the reservation may draw from the place
+func (r StockReservation) Covers(location string, bin string, lotNumber string) bool {
+	return (r.Location == "" || r.Location == location) &&
+		(r.Bin == "" || r.Bin == bin) &&
+		(r.LotNumber == "" || r.LotNumber == lotNumber)
+}
+
+type NewStockReservation struct {
+	StockItemID int
+	Location    string
+	Bin         string
+	LotNumber   string
+	Qty         decimal.Decimal
+	// Unit is the unit Qty is entered in, empty for the base unit
+	Unit            string
+	DemandReference string
+	Note            string
+}
+
+type GetStockReservationsQuery struct {
+	StockCode       string
+	DemandReference string
+	Status          StockReservationStatus
+	Page            int
+	PageSize        int
+}
+
+// StockReservationShortfall is a reservation scope with more reserved than
+// is on hand in the STOCK account
+type StockReservationShortfall struct {
+	StockCode string
+	Location  string
+	Bin       string
+	LotNumber string
+	OnHand    decimal.Decimal
+	Reserved  decimal.Decimal
+	Unit      string
+}
+
+// StockAvailability is the stock of an item across all locations. OnOrder
+// is what is still to be received on open purchase orders.
+type StockAvailability struct {
+	StockCode string
+	OnHand    decimal.Decimal
+	Reserved  decimal.Decimal
+	OnOrder   decimal.Decimal
+	// Quarantined is on QC hold and not part of OnHand
+	Quarantined decimal.Decimal
+	Unit        string
+}
+
+func (a StockAvailability) Available() decimal.Decimal {
+	return a.OnHand.Sub(a.Reserved)
+}
@@ -0,0 +1,154 @@
+package model
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// StockScanFlow is a posting made on a phone by scanning labels one step at
+// a time
+type StockScanFlow string
+
+const (
+	MovementStockScanFlow    StockScanFlow = "Movement"
+	ConsumptionStockScanFlow StockScanFlow = "Consumption"
+	ProductionStockScanFlow  StockScanFlow = "Production"
+)
+
+var StockScanFlows = []StockScanFlow{
+	MovementStockScanFlow,
+	ConsumptionStockScanFlow,
+	ProductionStockScanFlow,
+}
+
+type StockScanStep string
+
+const (
+	ItemStockScanStep        StockScanStep = "Item"
+	SourceBinStockScanStep   StockScanStep = "Source Bin"
+	LotStockScanStep         StockScanStep = "Lot"
+	QtyStockScanStep         StockScanStep = "Qty"
+	DestinationStockScanStep StockScanStep = "Destination Bin"
+	ConfirmStockScanStep     StockScanStep = "Confirm"
+)
+
+// Steps returns the steps of the flow in order. Production has no source and
+// consumption no destination.
+func (f StockScanFlow) Steps() []StockScanStep {
+	switch f {
+	case MovementStockScanFlow:
+		return []StockScanStep{
+			ItemStockScanStep,
+			SourceBinStockScanStep,
+			LotStockScanStep,
+			QtyStockScanStep,
+			DestinationStockScanStep,
+			ConfirmStockScanStep,
+		}
+	case ConsumptionStockScanFlow:
+		return []StockScanStep{
+			ItemStockScanStep,
+			SourceBinStockScanStep,
+			LotStockScanStep,
+			QtyStockScanStep,
+			ConfirmStockScanStep,
+		}
+	case ProductionStockScanFlow:
+		return []StockScanStep{
+			ItemStockScanStep,
+			LotStockScanStep,
+			QtyStockScanStep,
+			DestinationStockScanStep,
+			ConfirmStockScanStep,
+		}
+	}
+	return nil
+}
+
+// StockScanState is the progress through a scan flow. It is carried from
+// step to step in the url, so it can be edited by hand and is checked again
+// before it is posted.
+type StockScanState struct {
+	Flow         StockScanFlow
+	StockCode    string
+	FromLocation string
+	FromBin      string
+	LotNumber    string
+	// NoLot is set once the lot step is done without a lot
+	NoLot      bool
+	Qty        *decimal.Decimal
+	ToLocation string
+	ToBin      string
+}
+
+// IsStepDone is whether the step has a value
+func (s *StockScanState) IsStepDone(step StockScanStep) bool {
+	switch step {
+	case ItemStockScanStep:
+		return s.StockCode != ""
+	case SourceBinStockScanStep:
+		return s.FromLocation != ""
+	case LotStockScanStep:
+		return s.LotNumber != "" || s.NoLot
+	case QtyStockScanStep:
+		return s.Qty != nil
+	case DestinationStockScanStep:
+		return s.ToLocation != ""
+	}
+	return false
+}
+
+// CurrentStep returns the first step of the flow without a value, which is
+// the confirm step once all the others are done
+func (s *StockScanState) CurrentStep() StockScanStep {
+	for _, step := range s.Flow.Steps() {
+		if !s.IsStepDone(step) {
+			return step
+		}
+	}
+	return ConfirmStockScanStep
+}
+
+// Values returns the state as url values to carry it to the next step
+func (s *StockScanState) Values() url.Values {
+	values := url.Values{}
+	set := func(key string, value string) {
+		if value != "" {
+			values.Set(key, value)
+		}
+	}
+
+	set("Flow", string(s.Flow))
+	set("StockCode", s.StockCode)
+	set("FromLocation", s.FromLocation)
+	set("FromBin", s.FromBin)
+	set("LotNumber", s.LotNumber)
+	if s.NoLot {
+		values.Set("NoLot", "true")
+	}
+	if s.Qty != nil {
+		values.Set("Qty", s.Qty.String())
+	}
+	set("ToLocation", s.ToLocation)
+	set("ToBin", s.ToBin)
+
+	return values
+}
+
+// ParseStockPlaceScan splits the scan of a bin label, LOCATION/BIN, into its
+// location and bin. A scan without a slash is a location without a bin.
+func ParseStockPlaceScan(scan string) (location string, bin string) {
+	scan = strings.ToUpper(strings.TrimSpace(scan))
+	location, bin, _ = strings.Cut(scan, "/")
+	return strings.TrimSpace(location), strings.TrimSpace(bin)
+}
+
+// StockPlaceScan is the inverse of ParseStockPlaceScan
+func StockPlaceScan(location string, bin string) string {
+	if bin == "" {
+		return location
+	}
+	return location + "/" + bin
+}
@@ -0,0 +1,107 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// ScrapReason is a reason stock can be scrapped for. Archived reasons stay
+// on the postings made with them but cannot be used for new ones.
+type ScrapReason struct {
+	ScrapReasonID     int
+	Code              string
+	Description       string
+	IsArchived        bool
+	CreatedByUsername *string
+	CreatedAt         time.Time
+	UpdatedByUsername *string
+	UpdatedAt         time.Time
+}
+
+type NewScrapReason struct {
+	Code        string
+	Description string
+}
+
+type ScrapReasonUpdate struct {
+	Description string
+	IsArchived  bool
+}
+
+// WastePeriod is the length of the periods waste trends are grouped by
+type WastePeriod string
+
+const (
+	DayWastePeriod   WastePeriod = "Day"
+	WeekWastePeriod  WastePeriod = "Week"
+	MonthWastePeriod WastePeriod = "Month"
+)
+
+var WastePeriods = []WastePeriod{
+	DayWastePeriod,
+	WeekWastePeriod,
+	MonthWastePeriod,
+}
+
+// GetWasteReportInput filters the waste report. Postings from the start of
+// FromDate up to the end of ToDate are included.
+type GetWasteReportInput struct {
+	FromDate      time.Time
+	ToDate        time.Time
+	StockCode     string
+	Location      string
+	ScrapReasonID int
+	TrendPeriod   WastePeriod
+	Page          int
+	PageSize      int
+}
+
+// WasteReportLine is what was scrapped of a stock item for a reason at a
+// location, net of reversals, valued at the unit costs it was posted at.
+// The reason is nil for postings made before scrap reasons were required.
+type WasteReportLine struct {
+	StockCode       string
+	Description     string
+	ScrapReasonCode *string
+	Location        string
+	Qty             decimal.Decimal
+	Unit            string
+	Value           decimal.Decimal
+	PostingCount    int
+}
+
+// WasteReportSummary totals every line matched by the report filters
+type WasteReportSummary struct {
+	Count        int
+	Value        decimal.Decimal
+	PostingCount int
+}
+
+// WasteTrendPeriod is the waste scrapped in a period. Quantities of
+// different stock items cannot be added, so Qty is only set when the report
+// is for a single stock code.
+type WasteTrendPeriod struct {
+	PeriodStart  time.Time
+	Qty          *decimal.Decimal
+	Value        decimal.Decimal
+	PostingCount int
+}
+
+// WasteOffender is a stock item among those with the most waste by value
+type WasteOffender struct {
+	StockCode    string
+	Description  string
+	Qty          decimal.Decimal
+	Unit         string
+	Value        decimal.Decimal
+	PostingCount int
+}
+
+// WasteReport is the waste matched by the report filters
+type WasteReport struct {
+	Lines        []WasteReportLine
+	Summary      WasteReportSummary
+	Trend        []WasteTrendPeriod
+	TopOffenders []WasteOffender
+}
@@ -0,0 +1,66 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+// StockSerialPosition is where a serial is. It is the entry that the latest
+// transaction moving the serial posted to.
+type StockSerialPosition struct {
+	Account   StockAccount
+	Location  string
+	Bin       string
+	LotNumber string
+	Timestamp time.Time
+}
+
+// IsAt reports whether the serial is at the account, location, bin and lot of
+// the other position, regardless of when it was posted there
+func (p StockSerialPosition) IsAt(other StockSerialPosition) bool {
+	return p.Account == other.Account &&
+		p.Location == other.Location &&
+		p.Bin == other.Bin &&
+		p.LotNumber == other.LotNumber
+}
+
+func (p StockSerialPosition) String() string {
+	place := p.Location
+	if p.Bin != "" {
+		place += "/" + p.Bin
+	}
+	if p.LotNumber != "" {
+		place += " lot " + p.LotNumber
+	}
+
+	return fmt.Sprintf("%s at %s", p.Account, place)
+}
+
+type StockSerial struct {
+	StockSerialID int
+	StockItemID   int
+	StockCode     string
+	SerialNumber  string
+	CreatedAt     time.Time
+	// Position is nil if the serial has not been posted
+	Position *StockSerialPosition
+}
+
+// StockSerialMovement is a transaction that moved a serial
+type StockSerialMovement struct {
+	StockTransactionID    int
+	TransactionType       StockTransactionType
+	TransactionByUsername string
+	Timestamp             time.Time
+	From                  StockSerialPosition
+	To                    StockSerialPosition
+}
+
+type GetStockSerialsQuery struct {
+	StockCode    string
+	SerialNumber string
+	Account      StockAccount
+	Location     string
+	Page         int
+	PageSize     int
+}
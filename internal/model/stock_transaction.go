@@ -1,6 +1,7 @@
 package model
 
 import (
+	"slices"
 	"time"
 
 	"github.com/shopspring/decimal"
@@ -13,6 +14,15 @@ const (
 	ProductionStockAccount StockAccount = "PRODUCTION"
 	ConsumedStockAccount   StockAccount = "CONSUMED"
 	AdjustStockAccount     StockAccount = "ADJUST"
+	// InboundStockAccount is where stock received from suppliers comes from
+	InboundStockAccount StockAccount = "INBOUND"
+	// DispatchedStockAccount is where stock dispatched to customers goes to
+	DispatchedStockAccount StockAccount = "DISPATCHED"
+	// QuarantineStockAccount holds stock on QC hold at the place it was held.
+	// It is not available to use until it is released back to STOCK.
+	QuarantineStockAccount StockAccount = "QUARANTINE"
+	// ScrapStockAccount is where stock that is written off goes to
+	ScrapStockAccount StockAccount = "SCRAP"
 )
 
 var StockAccounts = []StockAccount{
@@ -20,6 +30,10 @@ var StockAccounts = []StockAccount{
 	ProductionStockAccount,
 	ConsumedStockAccount,
 	AdjustStockAccount,
+	InboundStockAccount,
+	DispatchedStockAccount,
+	QuarantineStockAccount,
+	ScrapStockAccount,
 }
 
 type StockTransactionType string
@@ -32,12 +46,25 @@ const (
 	ConsumptionReversalTransactionType StockTransactionType = "Consumption Reversal"
 	StockAdjustUpTransactionType       StockTransactionType = "Stock Adjust Up"
 	StockAdjustDownTransactionType     StockTransactionType = "Stock Adjust Down"
+	GoodsReceiptTransactionType        StockTransactionType = "Goods Receipt"
+	DispatchTransactionType            StockTransactionType = "Dispatch"
+	QuarantineHoldTransactionType      StockTransactionType = "Quarantine Hold"
+	QuarantineReleaseTransactionType   StockTransactionType = "Quarantine Release"
+	QuarantineRejectTransactionType    StockTransactionType = "Quarantine Reject"
+	ScrapTransactionType               StockTransactionType = "Scrap"
 )
 
-var StockTransacationTypeMap = map[StockTransactionType]struct {
+// StockTransactionAccounts are the accounts a transaction type moves stock
+// from and to
+type StockTransactionAccounts struct {
 	From StockAccount
 	To   StockAccount
-}{
+}
+
+// StockTransacationTypeMap lists the built in transaction types. Transaction
+// types defined by admins are kept in the database, see
+// CustomStockTransactionType.
+var StockTransacationTypeMap = map[StockTransactionType]StockTransactionAccounts{
 	StockMovementTransactionType: {
 		From: StockStockAccount,
 		To:   StockStockAccount,
@@ -66,6 +93,52 @@ var StockTransacationTypeMap = map[StockTransactionType]struct {
 		From: StockStockAccount,
 		To:   AdjustStockAccount,
 	},
+	GoodsReceiptTransactionType: {
+		From: InboundStockAccount,
+		To:   StockStockAccount,
+	},
+	DispatchTransactionType: {
+		From: StockStockAccount,
+		To:   DispatchedStockAccount,
+	},
+	QuarantineHoldTransactionType: {
+		From: StockStockAccount,
+		To:   QuarantineStockAccount,
+	},
+	QuarantineReleaseTransactionType: {
+		From: QuarantineStockAccount,
+		To:   StockStockAccount,
+	},
+	QuarantineRejectTransactionType: {
+		From: QuarantineStockAccount,
+		To:   ScrapStockAccount,
+	},
+	ScrapTransactionType: {
+		From: StockStockAccount,
+		To:   ScrapStockAccount,
+	},
+}
+
+// ManualStockTransactionTypes are the transaction types that can be posted
+// directly, such as by an import or through the API. Goods receipts and
+// dispatches are posted against their orders instead, quarantine postings
+// need a QC approver and scrap needs a scrap reason.
+var ManualStockTransactionTypes = []StockTransactionType{
+	StockMovementTransactionType,
+	ProductionTransactionType,
+	ProductionReversalTransactionType,
+	ConsumptionTransactionType,
+	ConsumptionReversalTransactionType,
+	StockAdjustUpTransactionType,
+	StockAdjustDownTransactionType,
+}
+
+// QuarantineTransactionTypes are posted with a reason and the QC approver
+// who approved them. Stock is held, released and rejected at the same place.
+var QuarantineTransactionTypes = []StockTransactionType{
+	QuarantineHoldTransactionType,
+	QuarantineReleaseTransactionType,
+	QuarantineRejectTransactionType,
 }
 
 type StockTransactionEntry struct {
@@ -76,12 +149,23 @@ type StockTransactionEntry struct {
 	Location                string
 	Bin                     string
 	Quantity                decimal.Decimal
+	Unit                    string
 	LotNumber               string
 	RunningTotal            decimal.Decimal
 	TransactionBy           string
 	TransactionByUsername   string
 	Timestamp               time.Time
 	StockTransactionID      int
+	StockDocumentID         *int
+	// ReversesStockTransactionID is set on reversals, ReversedByStockTransactionID
+	// on transactions that have been reversed
+	ReversesStockTransactionID   *int
+	ReversedByStockTransactionID *int
+	// Reason and ApprovedByUsername are set on quarantine postings
+	Reason             string
+	ApprovedByUsername *string
+	// ScrapReasonCode is set on postings into the SCRAP account
+	ScrapReasonCode *string
 }
 
 type GetTransactionsInput struct {
@@ -91,6 +175,16 @@ type GetTransactionsInput struct {
 	Bin          string
 	LotNumber    string
 	LTETimestamp *time.Time
+	// StockDocumentID restricts results to transactions posted by a document
+	StockDocumentID int
+	// StockTransactionID restricts results to a transaction and its reversal
+	StockTransactionID int
+	// StockCountID restricts results to variances posted by a stock count
+	StockCountID int
+	// PurchaseOrderID restricts results to goods receipts against an order
+	PurchaseOrderID int
+	// SalesOrderID restricts results to dispatches against an order
+	SalesOrderID int
 	Page         int
 	PageSize     int
 }
@@ -100,6 +194,8 @@ type NewStockTransaction struct {
 	Timestamp       *time.Time
 	StockItemID     int
 	Qty             decimal.Decimal
+	// Unit is the unit Qty is entered in, empty for the base unit
+	Unit            string
 	FromLocation    string
 	FromBin         string
 	FromLotNumber   string
@@ -107,28 +203,149 @@ type NewStockTransaction struct {
 	ToBin           string
 	ToLotNumber     string
 	TransactionNote string
+	StockDocumentID *int
+	// ReversesStockTransactionID links a reversal to the transaction it reverses
+	ReversesStockTransactionID *int
+	// StockCountID links a variance posting to the stock count that approved it
+	StockCountID *int
+	// PurchaseOrderLineID links a goods receipt to the purchase order line it
+	// was received against, whose received quantity it updates
+	PurchaseOrderLineID *int
+	// SalesOrderLineID links a dispatch to the sales order line it was
+	// dispatched against, whose dispatched quantity it updates
+	SalesOrderLineID *int
+	// SerialNumbers lists the units moved of a serialised stock item, one per
+	// unit of the base unit
+	SerialNumbers []string
+	// UnitCost is the cost of each base unit posted into the STOCK account,
+	// defaulting to the current average cost. Costing sets it for every other
+	// posting.
+	UnitCost *decimal.Decimal
+	// DemandReference draws down the open reservations of the demand that
+	// cover the stock consumed
+	DemandReference string
+	// Reason says why stock was held, released or rejected, and is required
+	// for quarantine postings. ApprovedBy is set to the QC approver posting
+	// them when posted and need not be given.
+	Reason     string
+	ApprovedBy *int
+	// ScrapReasonID is why stock was scrapped, required for postings into
+	// the SCRAP account
+	ScrapReasonID *int
+	// AcknowledgeNegativeStock lets the posting through when the negative
+	// stock policy would only warn about it
+	AcknowledgeNegativeStock bool
+	// Accounts are set from the transaction type when posted and need not be
+	// given
+	Accounts StockTransactionAccounts
+	// StockTransactionID is set when the transaction is posted. It is left 0
+	// for a zero quantity, which is not posted.
+	StockTransactionID int
 }
 
 type PostStockTransactionsInput []NewStockTransaction
 
+// StockItemIDs returns the stock items posted, each once
+func (input PostStockTransactionsInput) StockItemIDs() []int {
+	stockItemIDs := []int{}
+	for _, t := range input {
+		if !slices.Contains(stockItemIDs, t.StockItemID) {
+			stockItemIDs = append(stockItemIDs, t.StockItemID)
+		}
+	}
+
+	return stockItemIDs
+}
+
+// StockTransactionRequest is a transaction given by stock code, as posted
+// through the API. Qty is in Unit, or the base unit if Unit is empty. The to
+// place is only used by stock movements, ToLotNumber defaulting to the from
+// lot number.
+type StockTransactionRequest struct {
+	TransactionType StockTransactionType
+	StockCode       string
+	Qty             decimal.Decimal
+	Unit            string
+	FromLocation    string
+	FromBin         string
+	FromLotNumber   string
+	ToLocation      string
+	ToBin           string
+	ToLotNumber     string
+	SerialNumbers   []string
+	UnitCost        *decimal.Decimal
+	TransactionNote string
+}
+
+type PostStockTransactionRequestsInput struct {
+	Transactions             []StockTransactionRequest
+	AcknowledgeNegativeStock bool
+}
+
 type PostManualGenericStockTransactionInput struct {
 	StockItemID     int
 	Qty             decimal.Decimal
+	Unit            string
 	Location        string
 	Bin             string
 	LotNumber       string
 	TransactionNote string
+	SerialNumbers   []string
+	DemandReference string
+	UnitCost        *decimal.Decimal
+	// BackflushLocation consumes the components of the bill of materials in
+	// effect from the location and BackflushBin along with a production. No
+	// components are consumed if it is empty.
+	BackflushLocation        string
+	BackflushBin             string
+	AcknowledgeNegativeStock bool
 }
 
 type PostManualStockMovementInput struct {
-	StockItemID     int
-	Qty             decimal.Decimal
-	FromLocation    string
-	FromBin         string
-	ToLocation      string
-	ToBin           string
-	LotNumber       string
-	TransactionNote string
+	StockItemID              int
+	Qty                      decimal.Decimal
+	Unit                     string
+	FromLocation             string
+	FromBin                  string
+	ToLocation               string
+	ToBin                    string
+	LotNumber                string
+	TransactionNote          string
+	SerialNumbers            []string
+	AcknowledgeNegativeStock bool
+}
+
+// PostManualQuarantineInput holds, releases or rejects stock at a place with
+// the approval of a QC approver. Rejecting stock to SCRAP needs a scrap
+// reason.
+type PostManualQuarantineInput struct {
+	TransactionType          StockTransactionType
+	StockItemID              int
+	Qty                      decimal.Decimal
+	Unit                     string
+	Location                 string
+	Bin                      string
+	LotNumber                string
+	SerialNumbers            []string
+	Reason                   string
+	ScrapReasonID            *int
+	TransactionNote          string
+	AcknowledgeNegativeStock bool
+}
+
+// PostManualScrapInput scraps stock from a place for a scrap reason
+type PostManualScrapInput struct {
+	StockItemID              int
+	Qty                      decimal.Decimal
+	Unit                     string
+	Location                 string
+	Bin                      string
+	LotNumber                string
+	SerialNumbers            []string
+	ScrapReasonID            int
+	TransactionNote          string
+	DemandReference          string
+	AcknowledgeNegativeStock bool
 }
 
 type GetStockLevelsInput struct {
@@ -149,5 +366,45 @@ type StockLevel struct {
 	Bin        string
 	LotNumber  string
 	StockLevel decimal.Decimal
+	Unit       string
 	Timestamp  time.Time
+	// ExpiryDate is the expiry date of the lot, if it has one
+	ExpiryDate *time.Time
+	// Reserved is what open reservations may draw from this place, including
+	// those that leave the location, bin or lot blank. Such a reservation
+	// counts against every place it covers, so Reserved cannot be summed
+	// across places. It is only set for current STOCK levels.
+	Reserved decimal.Decimal
+	// Quarantined is what is on QC hold at this exact place. It is not part
+	// of StockLevel and is only set for current STOCK levels.
+	Quarantined decimal.Decimal
+}
+
+func (sl StockLevel) Available() decimal.Decimal {
+	return sl.StockLevel.Sub(sl.Reserved)
+}
+
+// StockTransactionToReverse is the posting detail needed to mirror a
+// transaction. The from entry is the one posted against the From account of
+// the transaction type.
+type StockTransactionToReverse struct {
+	StockTransactionID           int
+	TransactionType              StockTransactionType
+	StockItemID                  int
+	UnitCost                     *decimal.Decimal
+	PurchaseOrderLineID          *int
+	SalesOrderLineID             *int
+	FromQuantity                 decimal.Decimal
+	FromLocation                 string
+	FromBin                      string
+	FromLotNumber                string
+	ToLocation                   string
+	ToBin                        string
+	ToLotNumber                  string
+	SerialNumbers                []string
+	Reason                       string
+	ApprovedBy                   *int
+	ScrapReasonID                *int
+	ReversesStockTransactionID   *int
+	ReversedByStockTransactionID *int
 }
@@ -0,0 +1,128 @@
+package model
+
+import (
+	"slices"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// CustomStockTransactionType is a transaction type defined by an admin. It is
+// posted like the built in types, from the given location, bin and lot of
+// the from account to the to account at the same place unless it allows the
+// place to differ.
+type CustomStockTransactionType struct {
+	CustomStockTransactionTypeID int
+	Name                         string
+	Description                  string
+	FromAccount                  StockAccount
+	ToAccount                    StockAccount
+	AllowDifferentLocation       bool
+	AllowDifferentBin            bool
+	AllowDifferentLot            bool
+	RequiredFields               []CustomTransactionField
+	RequiredPermission           CustomTransactionPermission
+	IsArchived                   bool
+	CreatedByUsername            *string
+	CreatedAt                    time.Time
+	UpdatedByUsername            *string
+	UpdatedAt                    time.Time
+}
+
+// Requires reports whether postings of the type must give the field
+func (t *CustomStockTransactionType) Requires(field CustomTransactionField) bool {
+	return slices.Contains(t.RequiredFields, field)
+}
+
+// CustomTransactionField is an optional field of a posting that a custom
+// transaction type can require
+type CustomTransactionField string
+
+const (
+	LotNumberCustomTransactionField       CustomTransactionField = "LotNumber"
+	SerialNumbersCustomTransactionField   CustomTransactionField = "SerialNumbers"
+	UnitCostCustomTransactionField        CustomTransactionField = "UnitCost"
+	DemandReferenceCustomTransactionField CustomTransactionField = "DemandReference"
+	TransactionNoteCustomTransactionField CustomTransactionField = "TransactionNote"
+)
+
+var CustomTransactionFields = []CustomTransactionField{
+	LotNumberCustomTransactionField,
+	SerialNumbersCustomTransactionField,
+	UnitCostCustomTransactionField,
+	DemandReferenceCustomTransactionField,
+	TransactionNoteCustomTransactionField,
+}
+
+// CustomTransactionPermission is the supply chain permission a user needs to
+// post a custom transaction type
+type CustomTransactionPermission string
+
+const (
+	AdminCustomTransactionPermission      CustomTransactionPermission = "Admin"
+	TeamMemberCustomTransactionPermission CustomTransactionPermission = "TeamMember"
+	QCApproverCustomTransactionPermission CustomTransactionPermission = "QCApprover"
+)
+
+var CustomTransactionPermissions = []CustomTransactionPermission{
+	AdminCustomTransactionPermission,
+	TeamMemberCustomTransactionPermission,
+	QCApproverCustomTransactionPermission,
+}
+
+// Allows reports whether the supply chain permissions include the permission
+func (p CustomTransactionPermission) Allows(perms SupplyChainPermissions) bool {
+	switch p {
+	case AdminCustomTransactionPermission:
+		return perms.Admin
+	case TeamMemberCustomTransactionPermission:
+		return perms.TeamMember
+	case QCApproverCustomTransactionPermission:
+		return perms.QCApprover
+	}
+	return false
+}
+
+type NewCustomStockTransactionType struct {
+	Name                   string
+	Description            string
+	FromAccount            StockAccount
+	ToAccount              StockAccount
+	AllowDifferentLocation bool
+	AllowDifferentBin      bool
+	AllowDifferentLot      bool
+	RequiredFields         []CustomTransactionField
+	RequiredPermission     CustomTransactionPermission
+}
+
+// CustomStockTransactionTypeUpdate changes everything but the name and
+// accounts, which postings of the type depend on
+type CustomStockTransactionTypeUpdate struct {
+	Description            string
+	AllowDifferentLocation bool
+	AllowDifferentBin      bool
+	AllowDifferentLot      bool
+	RequiredFields         []CustomTransactionField
+	RequiredPermission     CustomTransactionPermission
+	IsArchived             bool
+}
+
+// PostCustomStockTransactionInput posts a custom transaction type. The to
+// place is only used where the type allows it to differ from the from place.
+type PostCustomStockTransactionInput struct {
+	CustomStockTransactionTypeID int
+	StockItemID                  int
+	Qty                          decimal.Decimal
+	Unit                         string
+	FromLocation                 string
+	FromBin                      string
+	FromLotNumber                string
+	ToLocation                   string
+	ToBin                        string
+	ToLotNumber                  string
+	SerialNumbers                []string
+	UnitCost                     *decimal.Decimal
+	DemandReference              string
+	TransactionNote              string
+	AcknowledgeNegativeStock     bool
+}
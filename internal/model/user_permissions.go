@@ -16,6 +16,7 @@ type StockItemPermissions struct {
 type SupplyChainPermissions struct {
 	Admin      bool `description:"Administrative supply chain tasks"`
 	TeamMember bool `description:"General supply chain tasks"`
+	QCApprover bool `description:"Approve placing stock on QC hold, releasing it and rejecting it"`
 }
 
 type PrintingPermissions struct {
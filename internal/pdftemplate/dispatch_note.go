@@ -0,0 +1,150 @@
+package pdftemplate
+
+import (
+	"app/pkg/format"
+	"app/pkg/pdf"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+	g "maragu.dev/gomponents"
+	h "maragu.dev/gomponents/html"
+)
+
+type DispatchNoteLine struct {
+	StockCode     string
+	Description   string
+	LotNumber     string
+	Qty           decimal.Decimal
+	Unit          string
+	SerialNumbers []string
+}
+
+type DispatchNoteData struct {
+	PickListID          int
+	SalesOrderReference string
+	CustomerName        string
+	CustomerReference   string
+	DeliveryAddress     string
+	DispatchedAt        time.Time
+	// Lines are what was dispatched, lines picked short to nothing are left
+	// out
+	Lines []DispatchNoteLine
+}
+
+type DispatchNoteTemplate struct{}
+
+const dispatchNoteStyle = `
+body { font-family: sans-serif; font-size: 10pt; }
+h1 { font-size: 16pt; margin-bottom: 4pt; }
+dl { display: grid; grid-template-columns: max-content auto; gap: 2pt 12pt; }
+dt { font-weight: bold; }
+dd { margin: 0; white-space: pre-line; }
+table { width: 100%; border-collapse: collapse; margin-top: 12pt; }
+th, td { border-bottom: 1px solid #ccc; padding: 3pt 4pt; text-align: left; vertical-align: top; }
+th.num, td.num { text-align: right; }
+.signature { margin-top: 36pt; }
+`
+
+func (DispatchNoteTemplate) Generate(input DispatchNoteData) (pdf.PDFDefinition, error) {
+
+	var rows []g.Node
+	for _, l := range input.Lines {
+		rows = append(rows, h.Tr(
+			h.Td(g.Text(l.StockCode)),
+			h.Td(g.Text(l.Description)),
+			h.Td(g.Text(l.LotNumber)),
+			h.Td(g.Text(strings.Join(l.SerialNumbers, ", "))),
+			h.Td(h.Class("num"), g.Textf("%s %s", format.DecimalWithCommas(l.Qty.String()), l.Unit)),
+		))
+	}
+
+	customerReference := "\u2013"
+	if input.CustomerReference != "" {
+		customerReference = input.CustomerReference
+	}
+
+	deliveryAddress := "\u2013"
+	if input.DeliveryAddress != "" {
+		deliveryAddress = input.DeliveryAddress
+	}
+
+	html, err := gomponentToString(h.Div(
+		h.StyleEl(g.Raw(dispatchNoteStyle)),
+		h.H1(g.Text("Dispatch Note")),
+		h.Dl(
+			h.Dt(g.Text("Customer")), h.Dd(g.Text(input.CustomerName)),
+			h.Dt(g.Text("Delivery Address")), h.Dd(g.Text(deliveryAddress)),
+			h.Dt(g.Text("Customer Reference")), h.Dd(g.Text(customerReference)),
+			h.Dt(g.Text("Our Reference")), h.Dd(g.Textf("%s / %d", input.SalesOrderReference, input.PickListID)),
+			h.Dt(g.Text("Dispatched")), h.Dd(g.Text(input.DispatchedAt.Format("2006-01-02"))),
+		),
+		h.Table(
+			h.THead(h.Tr(
+				h.Th(g.Text("Stock Code")),
+				h.Th(g.Text("Description")),
+				h.Th(g.Text("Lot Number")),
+				h.Th(g.Text("Serial Numbers")),
+				h.Th(h.Class("num"), g.Text("Qty")),
+			)),
+			h.TBody(rows...),
+		),
+		h.P(h.Class("signature"), g.Text("Received in good condition by: ______________________  Date: __________")),
+	))
+	if err != nil {
+		return pdf.PDFDefinition{}, fmt.Errorf("error generating dispatch note html: %v", err)
+	}
+
+	title := DispatchNoteTemplate{}.GenerateTitle(input)
+
+	return pdf.PDFDefinition{Title: title, HTML: html}, nil
+}
+
+func (DispatchNoteTemplate) GenerateFromJSON(data []byte) (pdf.PDFDefinition, error) {
+	return GenerateTypedFromJSON(DispatchNoteTemplate{}.Generate, data)
+}
+
+// GenerateTitle derives a title for the dispatch note from the order reference.
+func (DispatchNoteTemplate) GenerateTitle(input DispatchNoteData) string {
+	base := strings.TrimSpace(input.SalesOrderReference)
+	if base == "" {
+		base = "Sales Order"
+	}
+	return fmt.Sprintf("%s-Dispatch-Note-%d-%s", base, input.PickListID, time.Now().Format("200601021504"))
+}
+
+var dispatchNoteExampleJSON = `
+{
+  "PickListID": 12,
+  "SalesOrderReference": "SO-1001",
+  "CustomerName": "Acme Ltd",
+  "CustomerReference": "PO-5521",
+  "DeliveryAddress": "1 High Street\nSpringfield\nAB1 2CD",
+  "DispatchedAt": "2024-01-08T14:30:00Z",
+  "Lines": [
+    {
+      "StockCode": "WIDGET-1",
+      "Description": "Widget",
+      "LotNumber": "",
+      "Qty": 2,
+      "Unit": "EA",
+      "SerialNumbers": ["SN-0001", "SN-0002"]
+    },
+    {
+      "StockCode": "WIDGET-2",
+      "Description": "Large widget",
+      "LotNumber": "L001",
+      "Qty": 5,
+      "Unit": "EA",
+      "SerialNumbers": []
+    }
+  ]
+}`
+
+var DispatchNoteTemplateDefinition = RegisteredTemplate{
+	Name:        "Dispatch Note",
+	Description: "What was dispatched to a customer against a pick list",
+	Generator:   DispatchNoteTemplate{},
+	ExampleJSON: dispatchNoteExampleJSON,
+}
@@ -42,7 +42,11 @@ type RegisteredTemplate struct {
 }
 
 var Registry = map[string]RegisteredTemplate{
-	InvoiceTemplateDefinition.Name: InvoiceTemplateDefinition,
+	DispatchNoteTemplateDefinition.Name:       DispatchNoteTemplateDefinition,
+	InvoiceTemplateDefinition.Name:            InvoiceTemplateDefinition,
+	PickListTemplateDefinition.Name:           PickListTemplateDefinition,
+	StockCountVarianceTemplateDefinition.Name: StockCountVarianceTemplateDefinition,
+	StockLabelTemplateDefinition.Name:         StockLabelTemplateDefinition,
 }
 
 // SortedTemplates returns a slice of RegisteredTemplate sorted by Name.
@@ -0,0 +1,151 @@
+package pdftemplate
+
+import (
+	"app/pkg/format"
+	"app/pkg/pdf"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+	g "maragu.dev/gomponents"
+	h "maragu.dev/gomponents/html"
+)
+
+type PickListLine struct {
+	StockCode   string
+	Description string
+	Location    string
+	Bin         string
+	LotNumber   string
+	Qty         decimal.Decimal
+	Unit        string
+}
+
+type PickListData struct {
+	PickListID          int
+	SalesOrderReference string
+	CustomerName        string
+	CustomerReference   string
+	CreatedBy           string
+	CreatedAt           time.Time
+	// Lines are in the order they should be picked, by location and bin
+	Lines []PickListLine
+}
+
+type PickListTemplate struct{}
+
+const pickListStyle = `
+body { font-family: sans-serif; font-size: 10pt; }
+h1 { font-size: 16pt; margin-bottom: 4pt; }
+dl { display: grid; grid-template-columns: max-content auto; gap: 2pt 12pt; }
+dt { font-weight: bold; }
+dd { margin: 0; }
+table { width: 100%; border-collapse: collapse; margin-top: 12pt; }
+th, td { border-bottom: 1px solid #ccc; padding: 6pt 4pt; text-align: left; }
+th.num, td.num { text-align: right; }
+td.picked { width: 60pt; border-bottom: 1px solid #000; }
+`
+
+func (PickListTemplate) Generate(input PickListData) (pdf.PDFDefinition, error) {
+
+	const dateTimeFormat = "2006-01-02 15:04"
+
+	var rows []g.Node
+	for _, l := range input.Lines {
+		rows = append(rows, h.Tr(
+			h.Td(g.Text(l.Location)),
+			h.Td(g.Text(l.Bin)),
+			h.Td(g.Text(l.StockCode)),
+			h.Td(g.Text(l.Description)),
+			h.Td(g.Text(l.LotNumber)),
+			h.Td(h.Class("num"), g.Textf("%s %s", format.DecimalWithCommas(l.Qty.String()), l.Unit)),
+			h.Td(h.Class("picked")),
+		))
+	}
+
+	customerReference := "\u2013"
+	if input.CustomerReference != "" {
+		customerReference = input.CustomerReference
+	}
+
+	html, err := gomponentToString(h.Div(
+		h.StyleEl(g.Raw(pickListStyle)),
+		h.H1(g.Textf("Pick List %d", input.PickListID)),
+		h.Dl(
+			h.Dt(g.Text("Sales Order")), h.Dd(g.Text(input.SalesOrderReference)),
+			h.Dt(g.Text("Customer")), h.Dd(g.Text(input.CustomerName)),
+			h.Dt(g.Text("Customer Reference")), h.Dd(g.Text(customerReference)),
+			h.Dt(g.Text("Created")), h.Dd(g.Textf("%s by %s", input.CreatedAt.Format(dateTimeFormat), input.CreatedBy)),
+		),
+		h.Table(
+			h.THead(h.Tr(
+				h.Th(g.Text("Location")),
+				h.Th(g.Text("Bin")),
+				h.Th(g.Text("Stock Code")),
+				h.Th(g.Text("Description")),
+				h.Th(g.Text("Lot Number")),
+				h.Th(h.Class("num"), g.Text("Qty")),
+				h.Th(g.Text("Picked")),
+			)),
+			h.TBody(rows...),
+		),
+	))
+	if err != nil {
+		return pdf.PDFDefinition{}, fmt.Errorf("error generating pick list html: %v", err)
+	}
+
+	title := PickListTemplate{}.GenerateTitle(input)
+
+	return pdf.PDFDefinition{Title: title, HTML: html}, nil
+}
+
+func (PickListTemplate) GenerateFromJSON(data []byte) (pdf.PDFDefinition, error) {
+	return GenerateTypedFromJSON(PickListTemplate{}.Generate, data)
+}
+
+// GenerateTitle derives a title for the pick list from the order reference.
+func (PickListTemplate) GenerateTitle(input PickListData) string {
+	base := strings.TrimSpace(input.SalesOrderReference)
+	if base == "" {
+		base = "Sales Order"
+	}
+	return fmt.Sprintf("%s-Pick-List-%d-%s", base, input.PickListID, time.Now().Format("200601021504"))
+}
+
+var pickListExampleJSON = `
+{
+  "PickListID": 12,
+  "SalesOrderReference": "SO-1001",
+  "CustomerName": "Acme Ltd",
+  "CustomerReference": "PO-5521",
+  "CreatedBy": "jsmith",
+  "CreatedAt": "2024-01-08T08:00:00Z",
+  "Lines": [
+    {
+      "StockCode": "WIDGET-1",
+      "Description": "Widget",
+      "Location": "STORES",
+      "Bin": "A1",
+      "LotNumber": "",
+      "Qty": 10,
+      "Unit": "EA"
+    },
+    {
+      "StockCode": "WIDGET-2",
+      "Description": "Large widget",
+      "Location": "STORES",
+      "Bin": "A2",
+      "LotNumber": "L001",
+      "Qty": 5,
+      "Unit": "EA"
+    }
+  ]
+}`
+
+var PickListTemplateDefinition = RegisteredTemplate{
+	Name:        "Pick List",
+	Description: "Where to pick the lines of a sales order from, in pick order",
+	Generator:   PickListTemplate{},
+	ExampleJSON: pickListExampleJSON,
+}
@@ -0,0 +1,168 @@
+package pdftemplate
+
+import (
+	"app/pkg/format"
+	"app/pkg/pdf"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+	g "maragu.dev/gomponents"
+	h "maragu.dev/gomponents/html"
+)
+
+type StockCountVarianceLine struct {
+	StockCode   string
+	Location    string
+	Bin         string
+	LotNumber   string
+	ExpectedQty decimal.Decimal
+	// CountedQty is nil for lines that have not been counted
+	CountedQty *decimal.Decimal
+}
+
+type StockCountVarianceData struct {
+	Reference  string
+	Note       string
+	Status     string
+	Scope      string
+	SnapshotAt time.Time
+	ApprovedBy string
+	ApprovedAt *time.Time
+	Lines      []StockCountVarianceLine
+}
+
+type StockCountVarianceTemplate struct{}
+
+const stockCountVarianceStyle = `
+body { font-family: sans-serif; font-size: 10pt; }
+h1 { font-size: 16pt; margin-bottom: 4pt; }
+dl { display: grid; grid-template-columns: max-content auto; gap: 2pt 12pt; }
+dt { font-weight: bold; }
+dd { margin: 0; }
+table { width: 100%; border-collapse: collapse; margin-top: 12pt; }
+th, td { border-bottom: 1px solid #ccc; padding: 3pt 4pt; text-align: left; }
+th.num, td.num { text-align: right; }
+tr.variance td { font-weight: bold; }
+`
+
+func (StockCountVarianceTemplate) Generate(input StockCountVarianceData) (pdf.PDFDefinition, error) {
+
+	const dateTimeFormat = "2006-01-02 15:04"
+
+	var rows []g.Node
+	varianceCount := 0
+	for _, l := range input.Lines {
+
+		counted := "\u2013"
+		variance := "\u2013"
+		hasVariance := false
+		if l.CountedQty != nil {
+			v := l.CountedQty.Sub(l.ExpectedQty)
+			counted = format.DecimalWithCommas(l.CountedQty.String())
+			variance = format.DecimalWithCommas(v.String())
+			hasVariance = !v.IsZero()
+		}
+		if hasVariance {
+			varianceCount++
+		}
+
+		rows = append(rows, h.Tr(
+			g.If(hasVariance, h.Class("variance")),
+			h.Td(g.Text(l.StockCode)),
+			h.Td(g.Text(l.Location)),
+			h.Td(g.Text(l.Bin)),
+			h.Td(g.Text(l.LotNumber)),
+			h.Td(h.Class("num"), g.Text(format.DecimalWithCommas(l.ExpectedQty.String()))),
+			h.Td(h.Class("num"), g.Text(counted)),
+			h.Td(h.Class("num"), g.Text(variance)),
+		))
+	}
+
+	approved := "\u2013"
+	if input.ApprovedAt != nil {
+		approved = fmt.Sprintf("%s by %s", input.ApprovedAt.Format(dateTimeFormat), input.ApprovedBy)
+	}
+
+	html, err := gomponentToString(h.Div(
+		h.StyleEl(g.Raw(stockCountVarianceStyle)),
+		h.H1(g.Textf("Stock Count Variance Report: %s", input.Reference)),
+		h.Dl(
+			h.Dt(g.Text("Status")), h.Dd(g.Text(input.Status)),
+			h.Dt(g.Text("Scope")), h.Dd(g.Text(input.Scope)),
+			h.Dt(g.Text("Snapshot")), h.Dd(g.Text(input.SnapshotAt.Format(dateTimeFormat))),
+			h.Dt(g.Text("Approved")), h.Dd(g.Text(approved)),
+			h.Dt(g.Text("Lines")), h.Dd(g.Textf("%d, %d with a variance", len(input.Lines), varianceCount)),
+			g.If(input.Note != "", g.Group([]g.Node{
+				h.Dt(g.Text("Note")), h.Dd(g.Text(input.Note)),
+			})),
+		),
+		h.Table(
+			h.THead(h.Tr(
+				h.Th(g.Text("Stock Code")),
+				h.Th(g.Text("Location")),
+				h.Th(g.Text("Bin")),
+				h.Th(g.Text("Lot Number")),
+				h.Th(h.Class("num"), g.Text("Expected")),
+				h.Th(h.Class("num"), g.Text("Counted")),
+				h.Th(h.Class("num"), g.Text("Variance")),
+			)),
+			h.TBody(rows...),
+		),
+	))
+	if err != nil {
+		return pdf.PDFDefinition{}, fmt.Errorf("error generating stock count variance html: %v", err)
+	}
+
+	title := StockCountVarianceTemplate{}.GenerateTitle(input)
+
+	return pdf.PDFDefinition{Title: title, HTML: html}, nil
+}
+
+func (StockCountVarianceTemplate) GenerateFromJSON(data []byte) (pdf.PDFDefinition, error) {
+	return GenerateTypedFromJSON(StockCountVarianceTemplate{}.Generate, data)
+}
+
+// GenerateTitle derives a title for the variance report from the count reference.
+func (StockCountVarianceTemplate) GenerateTitle(input StockCountVarianceData) string {
+	base := strings.TrimSpace(input.Reference)
+	if base == "" {
+		base = "Stock Count"
+	}
+	return fmt.Sprintf("%s-Variance-%s", base, time.Now().Format("200601021504"))
+}
+
+var stockCountVarianceExampleJSON = `
+{
+  "Reference": "CC-2024-01",
+  "Note": "Weekly cycle count",
+  "Status": "Counting",
+  "Scope": "Locations: STORES",
+  "SnapshotAt": "2024-01-08T08:00:00Z",
+  "Lines": [
+    {
+      "StockCode": "WIDGET-1",
+      "Location": "STORES",
+      "Bin": "A1",
+      "LotNumber": "",
+      "ExpectedQty": 10,
+      "CountedQty": 8
+    },
+    {
+      "StockCode": "WIDGET-2",
+      "Location": "STORES",
+      "Bin": "A2",
+      "LotNumber": "L001",
+      "ExpectedQty": 5,
+      "CountedQty": null
+    }
+  ]
+}`
+
+var StockCountVarianceTemplateDefinition = RegisteredTemplate{
+	Name:        "Stock Count Variance",
+	Description: "Expected, counted and variance quantities for a stock count",
+	Generator:   StockCountVarianceTemplate{},
+	ExampleJSON: stockCountVarianceExampleJSON,
+}
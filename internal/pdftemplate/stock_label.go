@@ -0,0 +1,267 @@
+package pdftemplate
+
+import (
+	"app/pkg/code128"
+	"app/pkg/format"
+	"app/pkg/pdf"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/skip2/go-qrcode"
+	g "maragu.dev/gomponents"
+	h "maragu.dev/gomponents/html"
+)
+
+// StockLabelSize is the size of a label in millimetres. Each label is printed
+// on its own page of that size.
+type StockLabelSize struct {
+	Name     string
+	WidthMM  int
+	HeightMM int
+}
+
+var StockLabelSizes = []StockLabelSize{
+	{Name: "100x50", WidthMM: 100, HeightMM: 50},
+	{Name: "100x150", WidthMM: 100, HeightMM: 150},
+	{Name: "62x29", WidthMM: 62, HeightMM: 29},
+	{Name: "50x25", WidthMM: 50, HeightMM: 25},
+}
+
+type StockLabelBarcode string
+
+const (
+	QRStockLabelBarcode      StockLabelBarcode = "QR"
+	Code128StockLabelBarcode StockLabelBarcode = "CODE128"
+)
+
+var StockLabelBarcodes = []StockLabelBarcode{
+	QRStockLabelBarcode,
+	Code128StockLabelBarcode,
+}
+
+type StockLabelData struct {
+	StockCode   string
+	Description string
+	LotNumber   string
+	Qty         *decimal.Decimal
+	Unit        string
+	// LabelSize is the name of one of StockLabelSizes, defaulting to the first
+	LabelSize string
+	// Barcode encodes the stock code, and the lot number as well when it is
+	// a Code128 barcode. Defaults to QR.
+	Barcode    StockLabelBarcode
+	LabelCount int
+}
+
+type StockLabelTemplate struct{}
+
+const stockLabelStyle = `
+@page { size: %dmm %dmm; margin: 0; }
+body { margin: 0; font-family: sans-serif; font-size: %.1fmm; }
+.label { box-sizing: border-box; width: %dmm; height: %dmm; padding: 2mm; overflow: hidden; page-break-after: always; display: flex; gap: 2mm; }
+.label:last-child { page-break-after: auto; }
+.label.code128 { flex-direction: column; }
+.details { flex: 1; min-width: 0; display: flex; flex-direction: column; gap: 1mm; }
+.stock-code { font-size: 1.6em; font-weight: bold; }
+.description { overflow: hidden; max-height: 3.6em; }
+.qrs { height: 100%%; display: flex; flex-direction: column; gap: 2mm; }
+.qr { flex: 1; min-height: 0; aspect-ratio: 1; }
+.code128 svg { display: block; width: 100%%; height: 2.5em; }
+.code128 .barcode-text { text-align: center; font-family: monospace; }
+`
+
+// StockLabelSizeByName returns the label size with the name, or the first
+// size if there is none
+func StockLabelSizeByName(name string) StockLabelSize {
+	for _, s := range StockLabelSizes {
+		if s.Name == name {
+			return s
+		}
+	}
+	return StockLabelSizes[0]
+}
+
+func (StockLabelTemplate) Generate(input StockLabelData) (pdf.PDFDefinition, error) {
+
+	if strings.TrimSpace(input.StockCode) == "" {
+		return pdf.PDFDefinition{}, fmt.Errorf("stock code is required")
+	}
+
+	size := StockLabelSizeByName(input.LabelSize)
+
+	labelCount := input.LabelCount
+	if labelCount < 1 {
+		labelCount = 1
+	}
+
+	barcode, err := stockLabelBarcode(input)
+	if err != nil {
+		return pdf.PDFDefinition{}, err
+	}
+
+	var details []g.Node
+	details = append(details,
+		h.Div(h.Class("stock-code"), g.Text(input.StockCode)),
+		h.Div(h.Class("description"), g.Text(input.Description)),
+	)
+	if input.LotNumber != "" {
+		details = append(details, h.Div(g.Textf("Lot: %s", input.LotNumber)))
+	}
+	if input.Qty != nil {
+		details = append(details, h.Div(g.Textf(
+			"Qty: %s %s", format.DecimalWithCommas(input.Qty.String()), input.Unit,
+		)))
+	}
+
+	// QR codes sit beside the details, Code128 barcodes beneath them
+	var label g.Node
+	if input.Barcode == Code128StockLabelBarcode {
+		label = h.Div(
+			h.Class("label code128"),
+			h.Div(h.Class("details"), g.Group(details)),
+			barcode,
+		)
+	} else {
+		label = h.Div(
+			h.Class("label"),
+			barcode,
+			h.Div(h.Class("details"), g.Group(details)),
+		)
+	}
+
+	labels := make([]g.Node, labelCount)
+	for i := range labels {
+		labels[i] = label
+	}
+
+	// labels are sized relative to their height so small labels stay legible
+	fontSize := float64(size.HeightMM) / 16
+	if fontSize > 5 {
+		fontSize = 5
+	}
+
+	html, err := gomponentToString(h.Div(
+		h.StyleEl(g.Raw(fmt.Sprintf(
+			stockLabelStyle,
+			size.WidthMM, size.HeightMM,
+			fontSize,
+			size.WidthMM, size.HeightMM,
+		))),
+		g.Group(labels),
+	))
+	if err != nil {
+		return pdf.PDFDefinition{}, fmt.Errorf("error generating stock label html: %v", err)
+	}
+
+	title := StockLabelTemplate{}.GenerateTitle(input)
+
+	return pdf.PDFDefinition{Title: title, HTML: html}, nil
+}
+
+// stockLabelBarcode renders a barcode of the stock code and another of the
+// lot number, so each can be scanned on its own, as QR code images stacked
+// one above the other or Code128 SVGs
+func stockLabelBarcode(input StockLabelData) (g.Node, error) {
+
+	texts := []string{input.StockCode}
+	if input.LotNumber != "" {
+		texts = append(texts, input.LotNumber)
+	}
+
+	if input.Barcode != Code128StockLabelBarcode {
+		var qrs []g.Node
+		for _, text := range texts {
+			png, err := qrcode.Encode(text, qrcode.Medium, 256)
+			if err != nil {
+				return nil, fmt.Errorf("error generating QR code: %v", err)
+			}
+			qrs = append(qrs, h.Img(
+				h.Class("qr"),
+				h.Src("data:image/png;base64,"+base64.StdEncoding.EncodeToString(png)),
+			))
+		}
+		return h.Div(h.Class("qrs"), g.Group(qrs)), nil
+	}
+
+	var barcodes []g.Node
+	for _, text := range texts {
+		svg, err := code128SVG(text)
+		if err != nil {
+			return nil, fmt.Errorf("error generating barcode: %v", err)
+		}
+		barcodes = append(barcodes, h.Div(
+			g.Raw(svg),
+			h.Div(h.Class("barcode-text"), g.Text(text)),
+		))
+	}
+
+	return g.Group(barcodes), nil
+}
+
+// code128SVG draws the bars of a Code128 barcode one unit wide each, with a
+// quiet zone of 10 either side, scaled to fill the width of the SVG
+func code128SVG(text string) (string, error) {
+	modules, err := code128.Encode(text)
+	if err != nil {
+		return "", err
+	}
+
+	const quietZone = 10
+
+	var sb strings.Builder
+	fmt.Fprintf(
+		&sb,
+		`<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d 1" preserveAspectRatio="none" shape-rendering="crispEdges">`,
+		len(modules)+2*quietZone,
+	)
+	for i := 0; i < len(modules); {
+		if !modules[i] {
+			i++
+			continue
+		}
+		width := 0
+		for i+width < len(modules) && modules[i+width] {
+			width++
+		}
+		fmt.Fprintf(&sb, `<rect x="%d" y="0" width="%d" height="1"/>`, quietZone+i, width)
+		i += width
+	}
+	sb.WriteString(`</svg>`)
+
+	return sb.String(), nil
+}
+
+func (StockLabelTemplate) GenerateFromJSON(data []byte) (pdf.PDFDefinition, error) {
+	return GenerateTypedFromJSON(StockLabelTemplate{}.Generate, data)
+}
+
+// GenerateTitle derives a title for the labels from the stock code.
+func (StockLabelTemplate) GenerateTitle(input StockLabelData) string {
+	base := strings.TrimSpace(input.StockCode)
+	if base == "" {
+		base = "Stock"
+	}
+	return fmt.Sprintf("%s-Labels-%s", base, time.Now().Format("200601021504"))
+}
+
+var stockLabelExampleJSON = `
+{
+  "StockCode": "WIDGET-1",
+  "Description": "Widget",
+  "LotNumber": "L001",
+  "Qty": 10,
+  "Unit": "EA",
+  "LabelSize": "100x50",
+  "Barcode": "CODE128",
+  "LabelCount": 2
+}`
+
+var StockLabelTemplateDefinition = RegisteredTemplate{
+	Name:        "Stock Label",
+	Description: "Labels for a stock item with its lot and quantity and a QR or Code128 barcode",
+	Generator:   StockLabelTemplate{},
+	ExampleJSON: stockLabelExampleJSON,
+}
@@ -0,0 +1,246 @@
+package repository
+
+import (
+	"app/internal/model"
+	"app/pkg/db"
+	"context"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type CustomerRepository struct{}
+
+func NewCustomerRepository() *CustomerRepository {
+	return &CustomerRepository{}
+}
+
+func (r *CustomerRepository) CreateCustomer(
+	ctx context.Context,
+	exec db.PGExecutor,
+	customer *model.NewCustomer,
+	userID int,
+) (int, error) {
+
+	query := `
+INSERT INTO customer (
+	code,
+	name,
+	email,
+	phone,
+	delivery_address,
+	created_by,
+	updated_by
+)
+VALUES ($1, $2, $3, $4, $5, $6, $6)
+RETURNING customer_id
+	`
+
+	var customerID int
+	err := exec.QueryRow(ctx, query,
+		customer.Code,
+		customer.Name,
+		customer.Email,
+		customer.Phone,
+		customer.DeliveryAddress,
+		userID,
+	).Scan(&customerID)
+	if err != nil {
+		return 0, err
+	}
+
+	return customerID, nil
+}
+
+func (r *CustomerRepository) UpdateCustomer(
+	ctx context.Context,
+	exec db.PGExecutor,
+	customerID int,
+	update *model.CustomerUpdate,
+	userID int,
+) error {
+
+	query := `
+UPDATE
+	customer
+SET
+	name = $2,
+	email = $3,
+	phone = $4,
+	delivery_address = $5,
+	is_archived = $6,
+	updated_by = $7,
+	updated_at = NOW()
+WHERE
+	customer_id = $1
+	`
+
+	_, err := exec.Exec(ctx, query,
+		customerID,
+		update.Name,
+		update.Email,
+		update.Phone,
+		update.DeliveryAddress,
+		update.IsArchived,
+		userID,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+var customerSelect = `
+SELECT
+	s.customer_id,
+	s.code,
+	s.name,
+	s.email,
+	s.phone,
+	s.delivery_address,
+	s.is_archived,
+	cu.username,
+	s.created_at,
+	uu.username,
+	s.updated_at
+FROM
+	customer s
+LEFT JOIN app_user cu ON cu.user_id = s.created_by
+LEFT JOIN app_user uu ON uu.user_id = s.updated_by
+`
+
+func scanCustomer(row pgx.Row) (model.Customer, error) {
+	var s model.Customer
+	err := row.Scan(
+		&s.CustomerID,
+		&s.Code,
+		&s.Name,
+		&s.Email,
+		&s.Phone,
+		&s.DeliveryAddress,
+		&s.IsArchived,
+		&s.CreatedByUsername,
+		&s.CreatedAt,
+		&s.UpdatedByUsername,
+		&s.UpdatedAt,
+	)
+	return s, err
+}
+
+func (r *CustomerRepository) GetCustomer(
+	ctx context.Context,
+	exec db.PGExecutor,
+	customerID int,
+) (*model.Customer, error) {
+
+	query := customerSelect + `
+WHERE
+	s.customer_id = $1
+	`
+
+	s, err := scanCustomer(exec.QueryRow(ctx, query, customerID))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &s, nil
+}
+
+func (r *CustomerRepository) GetCustomerByCode(
+	ctx context.Context,
+	exec db.PGExecutor,
+	code string,
+) (*model.Customer, error) {
+
+	query := customerSelect + `
+WHERE
+	s.code = $1
+	`
+
+	s, err := scanCustomer(exec.QueryRow(ctx, query, code))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &s, nil
+}
+
+// customersWhere filters customers by GetCustomersQuery, taking $1 and $2
+var customersWhere = `
+WHERE
+	($1 = '' OR s.code ILIKE '%' || $1 || '%' OR s.name ILIKE '%' || $1 || '%')
+	AND
+	($2 OR NOT s.is_archived)
+`
+
+func (r *CustomerRepository) GetCustomers(
+	ctx context.Context,
+	exec db.PGExecutor,
+	q *model.GetCustomersQuery,
+) ([]model.Customer, error) {
+
+	limit := q.PageSize
+	if limit == 0 {
+		limit = 50
+	}
+	offset := 0
+	if q.Page > 0 {
+		offset = (q.Page - 1) * limit
+	}
+
+	query := customerSelect + customersWhere + `
+ORDER BY
+	s.code
+LIMIT $3 OFFSET $4
+	`
+
+	rows, err := exec.Query(ctx, query, q.SearchText, q.ShowArchived, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	customers := []model.Customer{}
+	for rows.Next() {
+		s, err := scanCustomer(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		customers = append(customers, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return customers, nil
+}
+
+func (r *CustomerRepository) GetCustomersCount(
+	ctx context.Context,
+	exec db.PGExecutor,
+	q *model.GetCustomersQuery,
+) (int, error) {
+
+	query := `
+SELECT
+	COUNT(*)
+FROM
+	customer s
+` + customersWhere
+
+	var count int
+	err := exec.QueryRow(ctx, query, q.SearchText, q.ShowArchived).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
@@ -0,0 +1,494 @@
+package repository
+
+import (
+	"app/internal/model"
+	"app/pkg/db"
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/shopspring/decimal"
+)
+
+type PurchaseOrderRepository struct{}
+
+func NewPurchaseOrderRepository() *PurchaseOrderRepository {
+	return &PurchaseOrderRepository{}
+}
+
+func (r *PurchaseOrderRepository) CreatePurchaseOrder(
+	ctx context.Context,
+	exec db.PGExecutor,
+	purchaseOrder *model.NewPurchaseOrder,
+	userID int,
+) (int, error) {
+
+	query := `
+INSERT INTO purchase_order (
+	supplier_id,
+	reference,
+	order_date,
+	note,
+	created_by,
+	updated_by
+)
+VALUES ($1, $2, $3, $4, $5, $5)
+RETURNING purchase_order_id
+	`
+
+	var purchaseOrderID int
+	err := exec.QueryRow(ctx, query,
+		purchaseOrder.SupplierID,
+		purchaseOrder.Reference,
+		purchaseOrder.OrderDate,
+		purchaseOrder.Note,
+		userID,
+	).Scan(&purchaseOrderID)
+	if err != nil {
+		return 0, err
+	}
+
+	return purchaseOrderID, nil
+}
+
+var purchaseOrderSelect = `
+SELECT
+	po.purchase_order_id,
+	po.supplier_id,
+	s.code,
+	s.name,
+	s.over_receipt_tolerance,
+	po.reference,
+	po.order_date,
+	po.status,
+	po.note,
+	(SELECT COUNT(*) FROM purchase_order_line l WHERE l.purchase_order_id = po.purchase_order_id),
+	cu.username,
+	po.created_at,
+	uu.username,
+	po.updated_at
+FROM
+	purchase_order po
+JOIN supplier s ON s.supplier_id = po.supplier_id
+LEFT JOIN app_user cu ON cu.user_id = po.created_by
+LEFT JOIN app_user uu ON uu.user_id = po.updated_by
+`
+
+func scanPurchaseOrder(row pgx.Row) (model.PurchaseOrder, error) {
+	var po model.PurchaseOrder
+	err := row.Scan(
+		&po.PurchaseOrderID,
+		&po.SupplierID,
+		&po.SupplierCode,
+		&po.SupplierName,
+		&po.OverReceiptTolerance,
+		&po.Reference,
+		&po.OrderDate,
+		&po.Status,
+		&po.Note,
+		&po.LineCount,
+		&po.CreatedByUsername,
+		&po.CreatedAt,
+		&po.UpdatedByUsername,
+		&po.UpdatedAt,
+	)
+	return po, err
+}
+
+func (r *PurchaseOrderRepository) GetPurchaseOrder(
+	ctx context.Context,
+	exec db.PGExecutor,
+	purchaseOrderID int,
+) (*model.PurchaseOrder, error) {
+
+	query := purchaseOrderSelect + `
+WHERE
+	po.purchase_order_id = $1
+	`
+
+	po, err := scanPurchaseOrder(exec.QueryRow(ctx, query, purchaseOrderID))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &po, nil
+}
+
+func (r *PurchaseOrderRepository) GetPurchaseOrderByReference(
+	ctx context.Context,
+	exec db.PGExecutor,
+	reference string,
+) (*model.PurchaseOrder, error) {
+
+	query := purchaseOrderSelect + `
+WHERE
+	po.reference = $1
+	`
+
+	po, err := scanPurchaseOrder(exec.QueryRow(ctx, query, reference))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &po, nil
+}
+
+// purchaseOrdersWhere filters purchase orders by GetPurchaseOrdersQuery,
+// taking $1 to $3
+var purchaseOrdersWhere = `
+WHERE
+	($1 = '' OR po.status = $1)
+	AND
+	($2 = 0 OR po.supplier_id = $2)
+	AND
+	($3 = '' OR EXISTS (
+		SELECT 1
+		FROM purchase_order_line l
+		JOIN stock_item si ON si.stock_item_id = l.stock_item_id
+		WHERE l.purchase_order_id = po.purchase_order_id AND si.stock_code = $3
+	))
+`
+
+func (r *PurchaseOrderRepository) GetPurchaseOrders(
+	ctx context.Context,
+	exec db.PGExecutor,
+	q *model.GetPurchaseOrdersQuery,
+) ([]model.PurchaseOrder, error) {
+
+	limit := q.PageSize
+	if limit == 0 {
+		limit = 50
+	}
+	offset := 0
+	if q.Page > 0 {
+		offset = (q.Page - 1) * limit
+	}
+
+	query := purchaseOrderSelect + purchaseOrdersWhere + `
+ORDER BY
+	po.order_date DESC,
+	po.purchase_order_id DESC
+LIMIT $4 OFFSET $5
+	`
+
+	rows, err := exec.Query(ctx, query, q.Status, q.SupplierID, q.StockCode, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	purchaseOrders := []model.PurchaseOrder{}
+	for rows.Next() {
+		po, err := scanPurchaseOrder(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		purchaseOrders = append(purchaseOrders, po)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return purchaseOrders, nil
+}
+
+func (r *PurchaseOrderRepository) GetPurchaseOrdersCount(
+	ctx context.Context,
+	exec db.PGExecutor,
+	q *model.GetPurchaseOrdersQuery,
+) (int, error) {
+
+	query := `
+SELECT
+	COUNT(*)
+FROM
+	purchase_order po
+` + purchaseOrdersWhere
+
+	var count int
+	err := exec.QueryRow(ctx, query, q.Status, q.SupplierID, q.StockCode).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// LockPurchaseOrder takes a row lock on the order for the rest of the
+// transaction and returns its current status
+func (r *PurchaseOrderRepository) LockPurchaseOrder(
+	ctx context.Context,
+	exec pgx.Tx,
+	purchaseOrderID int,
+) (*model.PurchaseOrderStatus, error) {
+
+	query := `
+SELECT
+	status
+FROM
+	purchase_order
+WHERE
+	purchase_order_id = $1
+FOR UPDATE
+	`
+
+	var status model.PurchaseOrderStatus
+	err := exec.QueryRow(ctx, query, purchaseOrderID).Scan(&status)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &status, nil
+}
+
+func (r *PurchaseOrderRepository) UpdatePurchaseOrderStatus(
+	ctx context.Context,
+	exec db.PGExecutor,
+	purchaseOrderID int,
+	status model.PurchaseOrderStatus,
+	userID int,
+) error {
+
+	query := `
+UPDATE
+	purchase_order
+SET
+	status = $2,
+	updated_by = $3,
+	updated_at = NOW()
+WHERE
+	purchase_order_id = $1
+	`
+
+	_, err := exec.Exec(ctx, query, purchaseOrderID, status, userID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *PurchaseOrderRepository) AddPurchaseOrderLine(
+	ctx context.Context,
+	exec db.PGExecutor,
+	purchaseOrderID int,
+	line *model.NewPurchaseOrderLine,
+) (int, error) {
+
+	query := `
+INSERT INTO purchase_order_line (
+	purchase_order_id,
+	stock_item_id,
+	quantity,
+	due_date,
+	unit_price
+)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING purchase_order_line_id
+	`
+
+	var purchaseOrderLineID int
+	err := exec.QueryRow(ctx, query,
+		purchaseOrderID,
+		line.StockItemID,
+		line.Qty,
+		line.DueDate,
+		line.UnitPrice,
+	).Scan(&purchaseOrderLineID)
+	if err != nil {
+		return 0, err
+	}
+
+	return purchaseOrderLineID, nil
+}
+
+func (r *PurchaseOrderRepository) DeletePurchaseOrderLine(
+	ctx context.Context,
+	exec db.PGExecutor,
+	purchaseOrderID int,
+	purchaseOrderLineID int,
+) error {
+
+	query := `
+DELETE FROM
+	purchase_order_line
+WHERE
+	purchase_order_id = $1
+	AND purchase_order_line_id = $2
+	`
+
+	_, err := exec.Exec(ctx, query, purchaseOrderID, purchaseOrderLineID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+var purchaseOrderLineSelect = `
+SELECT
+	l.purchase_order_line_id,
+	l.purchase_order_id,
+	l.stock_item_id,
+	si.stock_code,
+	si.description,
+	si.base_unit,
+	l.quantity,
+	l.received_quantity,
+	l.due_date,
+	l.unit_price,
+	l.status
+FROM
+	purchase_order_line l
+JOIN stock_item si ON si.stock_item_id = l.stock_item_id
+`
+
+func scanPurchaseOrderLine(row pgx.Row) (model.PurchaseOrderLine, error) {
+	var l model.PurchaseOrderLine
+	err := row.Scan(
+		&l.PurchaseOrderLineID,
+		&l.PurchaseOrderID,
+		&l.StockItemID,
+		&l.StockCode,
+		&l.Description,
+		&l.Unit,
+		&l.Qty,
+		&l.ReceivedQty,
+		&l.DueDate,
+		&l.UnitPrice,
+		&l.Status,
+	)
+	return l, err
+}
+
+func (r *PurchaseOrderRepository) GetPurchaseOrderLine(
+	ctx context.Context,
+	exec db.PGExecutor,
+	purchaseOrderLineID int,
+) (*model.PurchaseOrderLine, error) {
+
+	query := purchaseOrderLineSelect + `
+WHERE
+	l.purchase_order_line_id = $1
+	`
+
+	l, err := scanPurchaseOrderLine(exec.QueryRow(ctx, query, purchaseOrderLineID))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &l, nil
+}
+
+// GetPurchaseOrderLines returns the lines of an order, soonest due first
+func (r *PurchaseOrderRepository) GetPurchaseOrderLines(
+	ctx context.Context,
+	exec db.PGExecutor,
+	purchaseOrderID int,
+) ([]model.PurchaseOrderLine, error) {
+
+	query := purchaseOrderLineSelect + `
+WHERE
+	l.purchase_order_id = $1
+ORDER BY
+	l.due_date,
+	si.stock_code,
+	l.purchase_order_line_id
+	`
+
+	rows, err := exec.Query(ctx, query, purchaseOrderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lines := []model.PurchaseOrderLine{}
+	for rows.Next() {
+		l, err := scanPurchaseOrderLine(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		lines = append(lines, l)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return lines, nil
+}
+
+// AddPurchaseOrderLineReceivedQty adds to the quantity received against a
+// line, negative for reversals. Open lines become Received once all of the
+// quantity has been received and Received lines open again if it no longer
+// is. Closed lines stay closed.
+func (r *PurchaseOrderRepository) AddPurchaseOrderLineReceivedQty(
+	ctx context.Context,
+	exec db.PGExecutor,
+	purchaseOrderLineID int,
+	qty decimal.Decimal,
+) error {
+
+	query := `
+UPDATE
+	purchase_order_line
+SET
+	received_quantity = received_quantity + $2,
+	status = CASE
+		WHEN status = 'Closed' THEN status
+		WHEN received_quantity + $2 >= quantity THEN 'Received'
+		ELSE 'Open'
+	END
+WHERE
+	purchase_order_line_id = $1
+	`
+
+	_, err := exec.Exec(ctx, query, purchaseOrderLineID, qty)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ClosePurchaseOrderLines closes the open lines of an order so that nothing
+// more is expected against them, or only the given line when
+// purchaseOrderLineID is not 0
+func (r *PurchaseOrderRepository) ClosePurchaseOrderLines(
+	ctx context.Context,
+	exec db.PGExecutor,
+	purchaseOrderID int,
+	purchaseOrderLineID int,
+) error {
+
+	query := `
+UPDATE
+	purchase_order_line
+SET
+	status = 'Closed'
+WHERE
+	purchase_order_id = $1
+	AND ($2 = 0 OR purchase_order_line_id = $2)
+	AND status = 'Open'
+	`
+
+	_, err := exec.Exec(ctx, query, purchaseOrderID, purchaseOrderLineID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
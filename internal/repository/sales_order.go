@@ -0,0 +1,859 @@
+package repository
+
+import (
+	"app/internal/model"
+	"app/pkg/db"
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/shopspring/decimal"
+)
+
+type SalesOrderRepository struct{}
+
+func NewSalesOrderRepository() *SalesOrderRepository {
+	return &SalesOrderRepository{}
+}
+
+func (r *SalesOrderRepository) CreateSalesOrder(
+	ctx context.Context,
+	exec db.PGExecutor,
+	salesOrder *model.NewSalesOrder,
+	userID int,
+) (int, error) {
+
+	query := `
+INSERT INTO sales_order (
+	customer_id,
+	reference,
+	customer_reference,
+	order_date,
+	note,
+	created_by,
+	updated_by
+)
+VALUES ($1, $2, $3, $4, $5, $6, $6)
+RETURNING sales_order_id
+	`
+
+	var salesOrderID int
+	err := exec.QueryRow(ctx, query,
+		salesOrder.CustomerID,
+		salesOrder.Reference,
+		salesOrder.CustomerReference,
+		salesOrder.OrderDate,
+		salesOrder.Note,
+		userID,
+	).Scan(&salesOrderID)
+	if err != nil {
+		return 0, err
+	}
+
+	return salesOrderID, nil
+}
+
+var salesOrderSelect = `
+SELECT
+	so.sales_order_id,
+	so.customer_id,
+	c.code,
+	c.name,
+	c.delivery_address,
+	so.reference,
+	so.customer_reference,
+	so.order_date,
+	so.status,
+	so.note,
+	(SELECT COUNT(*) FROM sales_order_line l WHERE l.sales_order_id = so.sales_order_id),
+	cu.username,
+	so.created_at,
+	uu.username,
+	so.updated_at
+FROM
+	sales_order so
+JOIN customer c ON c.customer_id = so.customer_id
+LEFT JOIN app_user cu ON cu.user_id = so.created_by
+LEFT JOIN app_user uu ON uu.user_id = so.updated_by
+`
+
+func scanSalesOrder(row pgx.Row) (model.SalesOrder, error) {
+	var so model.SalesOrder
+	err := row.Scan(
+		&so.SalesOrderID,
+		&so.CustomerID,
+		&so.CustomerCode,
+		&so.CustomerName,
+		&so.DeliveryAddress,
+		&so.Reference,
+		&so.CustomerReference,
+		&so.OrderDate,
+		&so.Status,
+		&so.Note,
+		&so.LineCount,
+		&so.CreatedByUsername,
+		&so.CreatedAt,
+		&so.UpdatedByUsername,
+		&so.UpdatedAt,
+	)
+	return so, err
+}
+
+func (r *SalesOrderRepository) GetSalesOrder(
+	ctx context.Context,
+	exec db.PGExecutor,
+	salesOrderID int,
+) (*model.SalesOrder, error) {
+
+	query := salesOrderSelect + `
+WHERE
+	so.sales_order_id = $1
+	`
+
+	so, err := scanSalesOrder(exec.QueryRow(ctx, query, salesOrderID))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &so, nil
+}
+
+func (r *SalesOrderRepository) GetSalesOrderByReference(
+	ctx context.Context,
+	exec db.PGExecutor,
+	reference string,
+) (*model.SalesOrder, error) {
+
+	query := salesOrderSelect + `
+WHERE
+	so.reference = $1
+	`
+
+	so, err := scanSalesOrder(exec.QueryRow(ctx, query, reference))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &so, nil
+}
+
+// salesOrdersWhere filters sales orders by GetSalesOrdersQuery,
+// taking $1 to $3
+var salesOrdersWhere = `
+WHERE
+	($1 = '' OR so.status = $1)
+	AND
+	($2 = 0 OR so.customer_id = $2)
+	AND
+	($3 = '' OR EXISTS (
+		SELECT 1
+		FROM sales_order_line l
+		JOIN stock_item si ON si.stock_item_id = l.stock_item_id
+		WHERE l.sales_order_id = so.sales_order_id AND si.stock_code = $3
+	))
+`
+
+func (r *SalesOrderRepository) GetSalesOrders(
+	ctx context.Context,
+	exec db.PGExecutor,
+	q *model.GetSalesOrdersQuery,
+) ([]model.SalesOrder, error) {
+
+	limit := q.PageSize
+	if limit == 0 {
+		limit = 50
+	}
+	offset := 0
+	if q.Page > 0 {
+		offset = (q.Page - 1) * limit
+	}
+
+	query := salesOrderSelect + salesOrdersWhere + `
+ORDER BY
+	so.order_date DESC,
+	so.sales_order_id DESC
+LIMIT $4 OFFSET $5
+	`
+
+	rows, err := exec.Query(ctx, query, q.Status, q.CustomerID, q.StockCode, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	salesOrders := []model.SalesOrder{}
+	for rows.Next() {
+		so, err := scanSalesOrder(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		salesOrders = append(salesOrders, so)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return salesOrders, nil
+}
+
+func (r *SalesOrderRepository) GetSalesOrdersCount(
+	ctx context.Context,
+	exec db.PGExecutor,
+	q *model.GetSalesOrdersQuery,
+) (int, error) {
+
+	query := `
+SELECT
+	COUNT(*)
+FROM
+	sales_order so
+` + salesOrdersWhere
+
+	var count int
+	err := exec.QueryRow(ctx, query, q.Status, q.CustomerID, q.StockCode).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// LockSalesOrder takes a row lock on the order for the rest of the
+// transaction and returns its current status
+func (r *SalesOrderRepository) LockSalesOrder(
+	ctx context.Context,
+	exec pgx.Tx,
+	salesOrderID int,
+) (*model.SalesOrderStatus, error) {
+
+	query := `
+SELECT
+	status
+FROM
+	sales_order
+WHERE
+	sales_order_id = $1
+FOR UPDATE
+	`
+
+	var status model.SalesOrderStatus
+	err := exec.QueryRow(ctx, query, salesOrderID).Scan(&status)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &status, nil
+}
+
+func (r *SalesOrderRepository) UpdateSalesOrderStatus(
+	ctx context.Context,
+	exec db.PGExecutor,
+	salesOrderID int,
+	status model.SalesOrderStatus,
+	userID int,
+) error {
+
+	query := `
+UPDATE
+	sales_order
+SET
+	status = $2,
+	updated_by = $3,
+	updated_at = NOW()
+WHERE
+	sales_order_id = $1
+	`
+
+	_, err := exec.Exec(ctx, query, salesOrderID, status, userID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *SalesOrderRepository) AddSalesOrderLine(
+	ctx context.Context,
+	exec db.PGExecutor,
+	salesOrderID int,
+	line *model.NewSalesOrderLine,
+) (int, error) {
+
+	query := `
+INSERT INTO sales_order_line (
+	sales_order_id,
+	stock_item_id,
+	quantity,
+	due_date,
+	unit_price
+)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING sales_order_line_id
+	`
+
+	var salesOrderLineID int
+	err := exec.QueryRow(ctx, query,
+		salesOrderID,
+		line.StockItemID,
+		line.Qty,
+		line.DueDate,
+		line.UnitPrice,
+	).Scan(&salesOrderLineID)
+	if err != nil {
+		return 0, err
+	}
+
+	return salesOrderLineID, nil
+}
+
+func (r *SalesOrderRepository) DeleteSalesOrderLine(
+	ctx context.Context,
+	exec db.PGExecutor,
+	salesOrderID int,
+	salesOrderLineID int,
+) error {
+
+	query := `
+DELETE FROM
+	sales_order_line
+WHERE
+	sales_order_id = $1
+	AND sales_order_line_id = $2
+	`
+
+	_, err := exec.Exec(ctx, query, salesOrderID, salesOrderLineID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+var salesOrderLineSelect = `
+SELECT
+	l.sales_order_line_id,
+	l.sales_order_id,
+	l.stock_item_id,
+	si.stock_code,
+	si.description,
+	si.base_unit,
+	l.quantity,
+	l.dispatched_quantity,
+	(
+		SELECT COALESCE(SUM(pll.quantity), 0)
+		FROM pick_list_line pll
+		JOIN pick_list pl ON pl.pick_list_id = pll.pick_list_id
+		WHERE pll.sales_order_line_id = l.sales_order_line_id AND pl.status = 'Open'
+	),
+	l.due_date,
+	l.unit_price,
+	l.status
+FROM
+	sales_order_line l
+JOIN stock_item si ON si.stock_item_id = l.stock_item_id
+`
+
+func scanSalesOrderLine(row pgx.Row) (model.SalesOrderLine, error) {
+	var l model.SalesOrderLine
+	err := row.Scan(
+		&l.SalesOrderLineID,
+		&l.SalesOrderID,
+		&l.StockItemID,
+		&l.StockCode,
+		&l.Description,
+		&l.Unit,
+		&l.Qty,
+		&l.DispatchedQty,
+		&l.PickingQty,
+		&l.DueDate,
+		&l.UnitPrice,
+		&l.Status,
+	)
+	return l, err
+}
+
+func (r *SalesOrderRepository) GetSalesOrderLine(
+	ctx context.Context,
+	exec db.PGExecutor,
+	salesOrderLineID int,
+) (*model.SalesOrderLine, error) {
+
+	query := salesOrderLineSelect + `
+WHERE
+	l.sales_order_line_id = $1
+	`
+
+	l, err := scanSalesOrderLine(exec.QueryRow(ctx, query, salesOrderLineID))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &l, nil
+}
+
+// GetSalesOrderLines returns the lines of an order, soonest due first
+func (r *SalesOrderRepository) GetSalesOrderLines(
+	ctx context.Context,
+	exec db.PGExecutor,
+	salesOrderID int,
+) ([]model.SalesOrderLine, error) {
+
+	query := salesOrderLineSelect + `
+WHERE
+	l.sales_order_id = $1
+ORDER BY
+	l.due_date,
+	si.stock_code,
+	l.sales_order_line_id
+	`
+
+	rows, err := exec.Query(ctx, query, salesOrderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lines := []model.SalesOrderLine{}
+	for rows.Next() {
+		l, err := scanSalesOrderLine(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		lines = append(lines, l)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return lines, nil
+}
+
+// AddSalesOrderLineDispatchedQty adds to the quantity dispatched against a
+// line, negative for reversals. Open lines become Dispatched once all of the
+// quantity has been dispatched and Dispatched lines open again if it no longer
+// is. Closed lines stay closed.
+func (r *SalesOrderRepository) AddSalesOrderLineDispatchedQty(
+	ctx context.Context,
+	exec db.PGExecutor,
+	salesOrderLineID int,
+	qty decimal.Decimal,
+) error {
+
+	query := `
+UPDATE
+	sales_order_line
+SET
+	dispatched_quantity = dispatched_quantity + $2,
+	status = CASE
+		WHEN status = 'Closed' THEN status
+		WHEN dispatched_quantity + $2 >= quantity THEN 'Dispatched'
+		ELSE 'Open'
+	END
+WHERE
+	sales_order_line_id = $1
+	`
+
+	_, err := exec.Exec(ctx, query, salesOrderLineID, qty)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// CloseSalesOrderLines closes the open lines of an order so that nothing
+// more is dispatched against them, or only the given line when
+// salesOrderLineID is not 0
+func (r *SalesOrderRepository) CloseSalesOrderLines(
+	ctx context.Context,
+	exec db.PGExecutor,
+	salesOrderID int,
+	salesOrderLineID int,
+) error {
+
+	query := `
+UPDATE
+	sales_order_line
+SET
+	status = 'Closed'
+WHERE
+	sales_order_id = $1
+	AND ($2 = 0 OR sales_order_line_id = $2)
+	AND status = 'Open'
+	`
+
+	_, err := exec.Exec(ctx, query, salesOrderID, salesOrderLineID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *SalesOrderRepository) CreatePickList(
+	ctx context.Context,
+	exec db.PGExecutor,
+	salesOrderID int,
+	userID int,
+) (int, error) {
+
+	query := `
+INSERT INTO pick_list (
+	sales_order_id,
+	created_by
+)
+VALUES ($1, $2)
+RETURNING pick_list_id
+	`
+
+	var pickListID int
+	err := exec.QueryRow(ctx, query, salesOrderID, userID).Scan(&pickListID)
+	if err != nil {
+		return 0, err
+	}
+
+	return pickListID, nil
+}
+
+func (r *SalesOrderRepository) AddPickListLine(
+	ctx context.Context,
+	exec db.PGExecutor,
+	pickListID int,
+	line *model.NewPickListLine,
+) (int, error) {
+
+	query := `
+INSERT INTO pick_list_line (
+	pick_list_id,
+	sales_order_line_id,
+	location,
+	bin,
+	lot_number,
+	quantity
+)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING pick_list_line_id
+	`
+
+	var pickListLineID int
+	err := exec.QueryRow(ctx, query,
+		pickListID,
+		line.SalesOrderLineID,
+		line.Location,
+		line.Bin,
+		line.LotNumber,
+		line.Qty,
+	).Scan(&pickListLineID)
+	if err != nil {
+		return 0, err
+	}
+
+	return pickListLineID, nil
+}
+
+var pickListSelect = `
+SELECT
+	pl.pick_list_id,
+	pl.sales_order_id,
+	so.reference,
+	pl.status,
+	(SELECT COUNT(*) FROM pick_list_line pll WHERE pll.pick_list_id = pl.pick_list_id),
+	cu.username,
+	pl.created_at,
+	du.username,
+	pl.dispatched_at
+FROM
+	pick_list pl
+JOIN sales_order so ON so.sales_order_id = pl.sales_order_id
+LEFT JOIN app_user cu ON cu.user_id = pl.created_by
+LEFT JOIN app_user du ON du.user_id = pl.dispatched_by
+`
+
+func scanPickList(row pgx.Row) (model.PickList, error) {
+	var pl model.PickList
+	err := row.Scan(
+		&pl.PickListID,
+		&pl.SalesOrderID,
+		&pl.SalesOrderReference,
+		&pl.Status,
+		&pl.LineCount,
+		&pl.CreatedByUsername,
+		&pl.CreatedAt,
+		&pl.DispatchedByUsername,
+		&pl.DispatchedAt,
+	)
+	return pl, err
+}
+
+func (r *SalesOrderRepository) GetPickList(
+	ctx context.Context,
+	exec db.PGExecutor,
+	pickListID int,
+) (*model.PickList, error) {
+
+	query := pickListSelect + `
+WHERE
+	pl.pick_list_id = $1
+	`
+
+	pl, err := scanPickList(exec.QueryRow(ctx, query, pickListID))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &pl, nil
+}
+
+// GetPickLists returns the pick lists of an order, newest first
+func (r *SalesOrderRepository) GetPickLists(
+	ctx context.Context,
+	exec db.PGExecutor,
+	salesOrderID int,
+) ([]model.PickList, error) {
+
+	query := pickListSelect + `
+WHERE
+	pl.sales_order_id = $1
+ORDER BY
+	pl.pick_list_id DESC
+	`
+
+	rows, err := exec.Query(ctx, query, salesOrderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	pickLists := []model.PickList{}
+	for rows.Next() {
+		pl, err := scanPickList(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		pickLists = append(pickLists, pl)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return pickLists, nil
+}
+
+// LockPickList takes a row lock on the pick list for the rest of the
+// transaction and returns its current status
+func (r *SalesOrderRepository) LockPickList(
+	ctx context.Context,
+	exec pgx.Tx,
+	pickListID int,
+) (*model.PickListStatus, error) {
+
+	query := `
+SELECT
+	status
+FROM
+	pick_list
+WHERE
+	pick_list_id = $1
+FOR UPDATE
+	`
+
+	var status model.PickListStatus
+	err := exec.QueryRow(ctx, query, pickListID).Scan(&status)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &status, nil
+}
+
+// UpdatePickListStatus sets the status of a pick list, recording who
+// dispatched it when it is Dispatched
+func (r *SalesOrderRepository) UpdatePickListStatus(
+	ctx context.Context,
+	exec db.PGExecutor,
+	pickListID int,
+	status model.PickListStatus,
+	userID int,
+) error {
+
+	query := `
+UPDATE
+	pick_list
+SET
+	status = $2,
+	dispatched_by = CASE WHEN $2 = 'Dispatched' THEN $3 END,
+	dispatched_at = CASE WHEN $2 = 'Dispatched' THEN NOW() END
+WHERE
+	pick_list_id = $1
+	`
+
+	_, err := exec.Exec(ctx, query, pickListID, status, userID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+var pickListLineSelect = `
+SELECT
+	pll.pick_list_line_id,
+	pll.pick_list_id,
+	pll.sales_order_line_id,
+	sol.stock_item_id,
+	si.stock_code,
+	si.description,
+	si.base_unit,
+	pll.location,
+	pll.bin,
+	pll.lot_number,
+	pll.quantity,
+	pll.dispatched_quantity,
+	pll.serial_numbers
+FROM
+	pick_list_line pll
+JOIN sales_order_line sol ON sol.sales_order_line_id = pll.sales_order_line_id
+JOIN stock_item si ON si.stock_item_id = sol.stock_item_id
+`
+
+func scanPickListLine(row pgx.Row) (model.PickListLine, error) {
+	var l model.PickListLine
+	err := row.Scan(
+		&l.PickListLineID,
+		&l.PickListID,
+		&l.SalesOrderLineID,
+		&l.StockItemID,
+		&l.StockCode,
+		&l.Description,
+		&l.Unit,
+		&l.Location,
+		&l.Bin,
+		&l.LotNumber,
+		&l.Qty,
+		&l.DispatchedQty,
+		&l.SerialNumbers,
+	)
+	return l, err
+}
+
+func (r *SalesOrderRepository) getPickListLines(
+	ctx context.Context,
+	exec db.PGExecutor,
+	where string,
+	args ...any,
+) ([]model.PickListLine, error) {
+
+	query := pickListLineSelect + where + `
+ORDER BY
+	pll.location,
+	pll.bin,
+	si.stock_code,
+	pll.pick_list_line_id
+	`
+
+	rows, err := exec.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lines := []model.PickListLine{}
+	for rows.Next() {
+		l, err := scanPickListLine(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		lines = append(lines, l)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return lines, nil
+}
+
+// GetPickListLines returns the lines of a pick list in the order they
+// should be picked, by location and bin
+func (r *SalesOrderRepository) GetPickListLines(
+	ctx context.Context,
+	exec db.PGExecutor,
+	pickListID int,
+) ([]model.PickListLine, error) {
+	return r.getPickListLines(ctx, exec, `
+WHERE
+	pll.pick_list_id = $1
+	`, pickListID)
+}
+
+// GetOpenPickListLines returns the lines of open pick lists for a stock
+// item, whatever order they are for. The stock they propose to pick is
+// spoken for until they are dispatched or cancelled.
+func (r *SalesOrderRepository) GetOpenPickListLines(
+	ctx context.Context,
+	exec db.PGExecutor,
+	stockItemID int,
+) ([]model.PickListLine, error) {
+	return r.getPickListLines(ctx, exec, `
+WHERE
+	sol.stock_item_id = $1
+	AND EXISTS (
+		SELECT 1
+		FROM pick_list pl
+		WHERE pl.pick_list_id = pll.pick_list_id AND pl.status = 'Open'
+	)
+	`, stockItemID)
+}
+
+// SetPickListLineDispatched records what was dispatched of a pick list line
+func (r *SalesOrderRepository) SetPickListLineDispatched(
+	ctx context.Context,
+	exec db.PGExecutor,
+	pickListLineID int,
+	qty decimal.Decimal,
+	serialNumbers []string,
+) error {
+
+	query := `
+UPDATE
+	pick_list_line
+SET
+	dispatched_quantity = $2,
+	serial_numbers = $3
+WHERE
+	pick_list_line_id = $1
+	`
+
+	if serialNumbers == nil {
+		serialNumbers = []string{}
+	}
+
+	_, err := exec.Exec(ctx, query, pickListLineID, qty, serialNumbers)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
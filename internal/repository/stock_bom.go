@@ -0,0 +1,422 @@
+package repository
+
+import (
+	"app/internal/model"
+	"app/pkg/db"
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type StockBOMRepository struct{}
+
+func NewStockBOMRepository() *StockBOMRepository {
+	return &StockBOMRepository{}
+}
+
+func (r *StockBOMRepository) CreateStockBOM(
+	ctx context.Context,
+	exec db.PGExecutor,
+	stockItemID int,
+	bom *model.NewStockBOM,
+	userID int,
+) (int, error) {
+
+	query := `
+INSERT INTO stock_bom (
+	stock_item_id,
+	version,
+	effective_from,
+	effective_to,
+	note,
+	created_by,
+	updated_by
+)
+VALUES ($1, $2, $3, $4, $5, $6, $6)
+RETURNING stock_bom_id
+	`
+
+	var stockBOMID int
+	err := exec.QueryRow(ctx, query,
+		stockItemID,
+		bom.Version,
+		bom.EffectiveFrom,
+		bom.EffectiveTo,
+		bom.Note,
+		userID,
+	).Scan(&stockBOMID)
+	if err != nil {
+		return 0, err
+	}
+
+	return stockBOMID, nil
+}
+
+func (r *StockBOMRepository) UpdateStockBOM(
+	ctx context.Context,
+	exec db.PGExecutor,
+	stockBOMID int,
+	update *model.StockBOMUpdate,
+	userID int,
+) error {
+
+	query := `
+UPDATE
+	stock_bom
+SET
+	effective_from = $2,
+	effective_to = $3,
+	note = $4,
+	updated_by = $5,
+	updated_at = NOW()
+WHERE
+	stock_bom_id = $1
+	`
+
+	_, err := exec.Exec(ctx, query,
+		stockBOMID,
+		update.EffectiveFrom,
+		update.EffectiveTo,
+		update.Note,
+		userID,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+var stockBOMSelect = `
+SELECT
+	b.stock_bom_id,
+	b.stock_item_id,
+	si.stock_code,
+	b.version,
+	b.effective_from,
+	b.effective_to,
+	b.note,
+	(SELECT COUNT(*) FROM stock_bom_line l WHERE l.stock_bom_id = b.stock_bom_id),
+	cu.username,
+	b.created_at
+FROM
+	stock_bom b
+JOIN stock_item si ON si.stock_item_id = b.stock_item_id
+LEFT JOIN app_user cu ON cu.user_id = b.created_by
+`
+
+func scanStockBOM(row pgx.Row) (model.StockBOM, error) {
+	var b model.StockBOM
+	err := row.Scan(
+		&b.StockBOMID,
+		&b.StockItemID,
+		&b.StockCode,
+		&b.Version,
+		&b.EffectiveFrom,
+		&b.EffectiveTo,
+		&b.Note,
+		&b.LineCount,
+		&b.CreatedByUsername,
+		&b.CreatedAt,
+	)
+	return b, err
+}
+
+func (r *StockBOMRepository) GetStockBOM(
+	ctx context.Context,
+	exec db.PGExecutor,
+	stockBOMID int,
+) (*model.StockBOM, error) {
+
+	query := stockBOMSelect + `
+WHERE
+	b.stock_bom_id = $1
+	`
+
+	b, err := scanStockBOM(exec.QueryRow(ctx, query, stockBOMID))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &b, nil
+}
+
+func (r *StockBOMRepository) GetStockBOMByVersion(
+	ctx context.Context,
+	exec db.PGExecutor,
+	stockItemID int,
+	version string,
+) (*model.StockBOM, error) {
+
+	query := stockBOMSelect + `
+WHERE
+	b.stock_item_id = $1
+	AND b.version = $2
+	`
+
+	b, err := scanStockBOM(exec.QueryRow(ctx, query, stockItemID, version))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &b, nil
+}
+
+// GetEffectiveStockBOM returns the version of the bill of materials of a
+// stock item in effect on a date, or nil if there is none. Where versions
+// overlap the one that took effect last is used.
+func (r *StockBOMRepository) GetEffectiveStockBOM(
+	ctx context.Context,
+	exec db.PGExecutor,
+	stockItemID int,
+	at time.Time,
+) (*model.StockBOM, error) {
+
+	query := stockBOMSelect + `
+WHERE
+	b.stock_item_id = $1
+	AND b.effective_from <= $2::DATE
+	AND (b.effective_to IS NULL OR b.effective_to > $2::DATE)
+ORDER BY
+	b.effective_from DESC,
+	b.stock_bom_id DESC
+LIMIT 1
+	`
+
+	b, err := scanStockBOM(exec.QueryRow(ctx, query, stockItemID, at))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &b, nil
+}
+
+// GetStockBOMs returns the versions of the bill of materials of a stock item,
+// latest effective first
+func (r *StockBOMRepository) GetStockBOMs(
+	ctx context.Context,
+	exec db.PGExecutor,
+	stockItemID int,
+) ([]model.StockBOM, error) {
+
+	query := stockBOMSelect + `
+WHERE
+	b.stock_item_id = $1
+ORDER BY
+	b.effective_from DESC,
+	b.stock_bom_id DESC
+	`
+
+	rows, err := exec.Query(ctx, query, stockItemID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	boms := []model.StockBOM{}
+	for rows.Next() {
+		b, err := scanStockBOM(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		boms = append(boms, b)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return boms, nil
+}
+
+func (r *StockBOMRepository) AddStockBOMLine(
+	ctx context.Context,
+	exec db.PGExecutor,
+	stockBOMID int,
+	line *model.NewStockBOMLine,
+) (int, error) {
+
+	query := `
+INSERT INTO stock_bom_line (
+	stock_bom_id,
+	component_stock_item_id,
+	quantity_per,
+	scrap_factor
+)
+VALUES ($1, $2, $3, $4)
+RETURNING stock_bom_line_id
+	`
+
+	var stockBOMLineID int
+	err := exec.QueryRow(ctx, query,
+		stockBOMID,
+		line.ComponentStockItemID,
+		line.QtyPer,
+		line.ScrapFactor,
+	).Scan(&stockBOMLineID)
+	if err != nil {
+		return 0, err
+	}
+
+	return stockBOMLineID, nil
+}
+
+func (r *StockBOMRepository) DeleteStockBOMLine(
+	ctx context.Context,
+	exec db.PGExecutor,
+	stockBOMID int,
+	stockBOMLineID int,
+) error {
+
+	query := `
+DELETE FROM
+	stock_bom_line
+WHERE
+	stock_bom_id = $1
+	AND stock_bom_line_id = $2
+	`
+
+	_, err := exec.Exec(ctx, query, stockBOMID, stockBOMLineID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *StockBOMRepository) GetStockBOMLines(
+	ctx context.Context,
+	exec db.PGExecutor,
+	stockBOMID int,
+) ([]model.StockBOMLine, error) {
+
+	query := `
+SELECT
+	l.stock_bom_line_id,
+	l.stock_bom_id,
+	l.component_stock_item_id,
+	si.stock_code,
+	si.description,
+	l.quantity_per,
+	si.base_unit,
+	l.scrap_factor
+FROM
+	stock_bom_line l
+JOIN stock_item si ON si.stock_item_id = l.component_stock_item_id
+WHERE
+	l.stock_bom_id = $1
+ORDER BY
+	si.stock_code
+	`
+
+	rows, err := exec.Query(ctx, query, stockBOMID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lines := []model.StockBOMLine{}
+	for rows.Next() {
+		var l model.StockBOMLine
+		err := rows.Scan(
+			&l.StockBOMLineID,
+			&l.StockBOMID,
+			&l.ComponentStockItemID,
+			&l.ComponentStockCode,
+			&l.ComponentDescription,
+			&l.QtyPer,
+			&l.Unit,
+			&l.ScrapFactor,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		lines = append(lines, l)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return lines, nil
+}
+
+// GetStockBOMWhereUsed returns every bill of materials a component appears
+// in, by parent stock code and latest effective first
+func (r *StockBOMRepository) GetStockBOMWhereUsed(
+	ctx context.Context,
+	exec db.PGExecutor,
+	componentStockItemID int,
+) ([]model.StockBOMWhereUsed, error) {
+
+	query := `
+SELECT
+	b.stock_bom_id,
+	b.stock_item_id,
+	si.stock_code,
+	si.description,
+	b.version,
+	b.effective_from,
+	b.effective_to,
+	l.quantity_per,
+	ci.base_unit,
+	l.scrap_factor
+FROM
+	stock_bom_line l
+JOIN stock_bom b ON b.stock_bom_id = l.stock_bom_id
+JOIN stock_item si ON si.stock_item_id = b.stock_item_id
+JOIN stock_item ci ON ci.stock_item_id = l.component_stock_item_id
+WHERE
+	l.component_stock_item_id = $1
+ORDER BY
+	si.stock_code,
+	b.effective_from DESC,
+	b.stock_bom_id DESC
+	`
+
+	rows, err := exec.Query(ctx, query, componentStockItemID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	whereUsed := []model.StockBOMWhereUsed{}
+	for rows.Next() {
+		var w model.StockBOMWhereUsed
+		err := rows.Scan(
+			&w.StockBOMID,
+			&w.StockItemID,
+			&w.StockCode,
+			&w.Description,
+			&w.Version,
+			&w.EffectiveFrom,
+			&w.EffectiveTo,
+			&w.QtyPer,
+			&w.Unit,
+			&w.ScrapFactor,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		whereUsed = append(whereUsed, w)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return whereUsed, nil
+}
@@ -0,0 +1,302 @@
+package repository
+
+import (
+	"app/internal/model"
+	"app/pkg/db"
+	"app/pkg/pgconv"
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/shopspring/decimal"
+)
+
+type StockCostRepository struct{}
+
+func NewStockCostRepository() *StockCostRepository {
+	return &StockCostRepository{}
+}
+
+// LockStockItemCost returns the weighted average cost of a stock item, locked
+// for the rest of the transaction. Stock items without a cost yet start with
+// nothing at zero cost.
+func (r *StockCostRepository) LockStockItemCost(
+	ctx context.Context,
+	tx pgx.Tx,
+	stockItemID int,
+) (model.StockItemCost, error) {
+
+	query := `
+INSERT INTO stock_item_cost (stock_item_id)
+VALUES ($1)
+ON CONFLICT (stock_item_id) DO NOTHING
+	`
+
+	cost := model.StockItemCost{StockItemID: stockItemID}
+
+	_, err := tx.Exec(ctx, query, stockItemID)
+	if err != nil {
+		return cost, err
+	}
+
+	query = `
+SELECT
+	quantity,
+	average_cost
+FROM
+	stock_item_cost
+WHERE
+	stock_item_id = $1
+FOR UPDATE
+	`
+
+	err = tx.QueryRow(ctx, query, stockItemID).Scan(&cost.Qty, &cost.AverageCost)
+	if err != nil {
+		return cost, err
+	}
+
+	return cost, nil
+}
+
+func (r *StockCostRepository) UpdateStockItemCost(
+	ctx context.Context,
+	tx pgx.Tx,
+	cost *model.StockItemCost,
+) error {
+
+	query := `
+UPDATE
+	stock_item_cost
+SET
+	quantity = $2,
+	average_cost = $3,
+	updated_at = NOW()
+WHERE
+	stock_item_id = $1
+	`
+
+	_, err := tx.Exec(ctx, query, cost.StockItemID, cost.Qty, cost.AverageCost)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetOpenStockCostLayers returns the layers of a stock item with stock left,
+// oldest first, locked for update
+func (r *StockCostRepository) GetOpenStockCostLayers(
+	ctx context.Context,
+	tx pgx.Tx,
+	stockItemID int,
+) ([]model.StockCostLayer, error) {
+
+	query := `
+SELECT
+	stock_cost_layer_id,
+	remaining_quantity,
+	unit_cost
+FROM
+	stock_cost_layer
+WHERE
+	stock_item_id = $1
+	AND remaining_quantity > 0
+ORDER BY
+	received_at,
+	stock_cost_layer_id
+FOR UPDATE
+	`
+
+	rows, err := tx.Query(ctx, query, stockItemID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	layers := []model.StockCostLayer{}
+	for rows.Next() {
+		var l model.StockCostLayer
+		err := rows.Scan(&l.StockCostLayerID, &l.RemainingQty, &l.UnitCost)
+		if err != nil {
+			return nil, err
+		}
+
+		layers = append(layers, l)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return layers, nil
+}
+
+func (r *StockCostRepository) CreateStockCostLayer(
+	ctx context.Context,
+	tx pgx.Tx,
+	stockItemID int,
+	receivedAt time.Time,
+	qty decimal.Decimal,
+	unitCost decimal.Decimal,
+) error {
+
+	query := `
+INSERT INTO stock_cost_layer (
+	stock_item_id,
+	received_at,
+	quantity,
+	remaining_quantity,
+	unit_cost
+)
+VALUES ($1, $2, $3, $3, $4)
+	`
+
+	_, err := tx.Exec(ctx, query, stockItemID, receivedAt, qty, unitCost)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *StockCostRepository) UpdateStockCostLayerRemaining(
+	ctx context.Context,
+	tx pgx.Tx,
+	stockCostLayerID int,
+	remainingQty decimal.Decimal,
+) error {
+
+	query := `
+UPDATE
+	stock_cost_layer
+SET
+	remaining_quantity = $2
+WHERE
+	stock_cost_layer_id = $1
+	`
+
+	_, err := tx.Exec(ctx, query, stockCostLayerID, remainingQty)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// stockValuationSelect values the STOCK and QUARANTINE accounts by stock item
+// and location from the ledger, as of $3 when it is set. It takes $1 to $3.
+var stockValuationSelect = `
+SELECT
+	si.stock_code,
+	si.description,
+	si.costing_method,
+	ste.location,
+	SUM(ste.quantity) AS quantity,
+	si.base_unit,
+	SUM(ste.quantity * COALESCE(st.unit_cost, 0)) AS value
+FROM
+	stock_transaction_entry ste
+JOIN stock_transaction st ON st.stock_transaction_id = ste.stock_transaction_id
+JOIN stock_item si ON si.stock_item_id = st.stock_item_id
+WHERE
+	ste.account IN ('STOCK', 'QUARANTINE')
+	AND
+	($1 = '' OR si.stock_code = $1)
+	AND
+	($2 = '' OR ste.location = $2)
+	AND
+	($3::timestamp IS NULL OR st.timestamp <= $3::timestamp)
+GROUP BY
+	si.stock_item_id,
+	ste.location
+HAVING
+	SUM(ste.quantity) <> 0
+	OR SUM(ste.quantity * COALESCE(st.unit_cost, 0)) <> 0
+`
+
+func (r *StockCostRepository) GetStockValuation(
+	ctx context.Context,
+	exec db.PGExecutor,
+	input *model.GetStockValuationInput,
+) ([]model.StockValuation, error) {
+
+	limit := input.PageSize
+	if limit == 0 {
+		limit = 50
+	}
+	offset := 0
+	if input.Page > 0 {
+		offset = (input.Page - 1) * limit
+	}
+
+	query := stockValuationSelect + `
+ORDER BY
+	si.stock_code,
+	ste.location
+LIMIT $4 OFFSET $5
+	`
+
+	rows, err := exec.Query(ctx, query,
+		input.StockCode,
+		input.Location,
+		pgconv.TimePtrToPGTimestamptz(input.LTETimestamp),
+		limit, offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	valuation := []model.StockValuation{}
+	for rows.Next() {
+		var v model.StockValuation
+		err := rows.Scan(
+			&v.StockCode,
+			&v.Description,
+			&v.CostingMethod,
+			&v.Location,
+			&v.Qty,
+			&v.Unit,
+			&v.Value,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		valuation = append(valuation, v)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return valuation, nil
+}
+
+func (r *StockCostRepository) GetStockValuationSummary(
+	ctx context.Context,
+	exec db.PGExecutor,
+	input *model.GetStockValuationInput,
+) (model.StockValuationSummary, error) {
+
+	query := `
+SELECT
+	COUNT(*),
+	COALESCE(SUM(value), 0)
+FROM (
+` + stockValuationSelect + `
+) valuation
+	`
+
+	var s model.StockValuationSummary
+	err := exec.QueryRow(ctx, query,
+		input.StockCode,
+		input.Location,
+		pgconv.TimePtrToPGTimestamptz(input.LTETimestamp),
+	).Scan(&s.Count, &s.Value)
+	if err != nil {
+		return s, err
+	}
+
+	return s, nil
+}
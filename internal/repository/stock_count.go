@@ -0,0 +1,452 @@
+package repository
+
+import (
+	"app/internal/model"
+	"app/pkg/db"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/shopspring/decimal"
+)
+
+type StockCountRepository struct{}
+
+func NewStockCountRepository() *StockCountRepository {
+	return &StockCountRepository{}
+}
+
+func (r *StockCountRepository) CreateStockCount(
+	ctx context.Context,
+	exec db.PGExecutor,
+	input *model.NewStockCount,
+	snapshotAt time.Time,
+	userID int,
+) (int, error) {
+
+	query := `
+INSERT INTO stock_count (
+	reference,
+	note,
+	locations,
+	bins,
+	stock_item_ids,
+	is_blind,
+	snapshot_at,
+	created_by
+)
+VALUES ($1, $2, COALESCE($3::TEXT[], '{}'), COALESCE($4::TEXT[], '{}'), COALESCE($5::INT[], '{}'), $6, $7, $8)
+RETURNING stock_count_id
+	`
+
+	// nil scope slices are sent as NULL and stored as empty arrays
+	var stockCountID int
+	err := exec.QueryRow(
+		ctx,
+		query,
+		input.Reference,
+		input.Note,
+		input.Locations,
+		input.Bins,
+		input.StockItemIDs,
+		input.IsBlind,
+		snapshotAt,
+		userID,
+	).Scan(&stockCountID)
+	if err != nil {
+		return 0, err
+	}
+
+	return stockCountID, nil
+}
+
+func (r *StockCountRepository) GetStockCount(
+	ctx context.Context,
+	exec db.PGExecutor,
+	stockCountID int,
+) (*model.StockCount, error) {
+
+	query := `
+SELECT
+	stock_count_id,
+	reference,
+	note,
+	locations,
+	bins,
+	stock_item_ids,
+	is_blind,
+	snapshot_at,
+	status,
+	line_count,
+	counted_line_count,
+	created_by,
+	created_by_username,
+	created_at,
+	approved_by,
+	approved_by_username,
+	approved_at
+FROM
+	stock_count_view
+WHERE
+	stock_count_id = $1
+	`
+
+	var sc model.StockCount
+	err := exec.QueryRow(ctx, query, stockCountID).Scan(
+		&sc.StockCountID,
+		&sc.Reference,
+		&sc.Note,
+		&sc.Locations,
+		&sc.Bins,
+		&sc.StockItemIDs,
+		&sc.IsBlind,
+		&sc.SnapshotAt,
+		&sc.Status,
+		&sc.LineCount,
+		&sc.CountedLineCount,
+		&sc.CreatedBy,
+		&sc.CreatedByUsername,
+		&sc.CreatedAt,
+		&sc.ApprovedBy,
+		&sc.ApprovedByUsername,
+		&sc.ApprovedAt,
+	)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &sc, nil
+}
+
+// LockStockCount takes a row lock on the count for the rest of the
+// transaction and returns its current status
+func (r *StockCountRepository) LockStockCount(
+	ctx context.Context,
+	exec pgx.Tx,
+	stockCountID int,
+) (*model.StockCountStatus, error) {
+
+	query := `
+SELECT
+	status
+FROM
+	stock_count
+WHERE
+	stock_count_id = $1
+FOR UPDATE
+	`
+
+	var status model.StockCountStatus
+	err := exec.QueryRow(ctx, query, stockCountID).Scan(&status)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &status, nil
+}
+
+func (r *StockCountRepository) GetStockCounts(
+	ctx context.Context,
+	exec db.PGExecutor,
+	q *model.GetStockCountsQuery,
+) ([]model.StockCount, error) {
+
+	limit := q.PageSize
+	if limit == 0 {
+		limit = 50
+	}
+	offset := 0
+	if q.Page > 0 {
+		offset = (q.Page - 1) * limit
+	}
+
+	orderByClause, _ := q.Sort.ToOrderByClause(model.StockCount{})
+	if orderByClause == "" {
+		orderByClause = "ORDER BY snapshot_at DESC, stock_count_id DESC"
+	}
+
+	query := fmt.Sprintf(`
+SELECT
+	stock_count_id,
+	reference,
+	note,
+	locations,
+	bins,
+	stock_item_ids,
+	is_blind,
+	snapshot_at,
+	status,
+	line_count,
+	counted_line_count,
+	created_by,
+	created_by_username,
+	created_at,
+	approved_by,
+	approved_by_username,
+	approved_at
+FROM
+	stock_count_view
+WHERE
+	($1 = '' OR status = $1)
+
+%s
+
+LIMIT $2 OFFSET $3
+	`,
+		orderByClause,
+	)
+
+	rows, err := exec.Query(ctx, query, q.Status, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stockCounts := []model.StockCount{}
+	for rows.Next() {
+		var sc model.StockCount
+		err := rows.Scan(
+			&sc.StockCountID,
+			&sc.Reference,
+			&sc.Note,
+			&sc.Locations,
+			&sc.Bins,
+			&sc.StockItemIDs,
+			&sc.IsBlind,
+			&sc.SnapshotAt,
+			&sc.Status,
+			&sc.LineCount,
+			&sc.CountedLineCount,
+			&sc.CreatedBy,
+			&sc.CreatedByUsername,
+			&sc.CreatedAt,
+			&sc.ApprovedBy,
+			&sc.ApprovedByUsername,
+			&sc.ApprovedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		stockCounts = append(stockCounts, sc)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return stockCounts, nil
+}
+
+func (r *StockCountRepository) GetStockCountsCount(
+	ctx context.Context,
+	exec db.PGExecutor,
+	q *model.GetStockCountsQuery,
+) (int, error) {
+
+	query := `
+SELECT
+	COUNT(*)
+FROM
+	stock_count
+WHERE
+	($1 = '' OR status = $1)
+	`
+
+	var count int
+	err := exec.QueryRow(ctx, query, q.Status).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (r *StockCountRepository) UpdateStockCountStatus(
+	ctx context.Context,
+	exec db.PGExecutor,
+	stockCountID int,
+	status model.StockCountStatus,
+	userID int,
+) error {
+
+	// approved_by and approved_at are only recorded when the count is approved
+	query := `
+UPDATE
+	stock_count
+SET
+	status = $2,
+	approved_by = CASE WHEN $2 = 'Approved' THEN $3::INT ELSE approved_by END,
+	approved_at = CASE WHEN $2 = 'Approved' THEN NOW() ELSE approved_at END
+WHERE
+	stock_count_id = $1
+	`
+
+	_, err := exec.Exec(ctx, query, stockCountID, status, userID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// AddStockCountLine adds a line to the count. When the line already exists
+// for the stock item, location, bin and lot the counted quantity is updated
+// instead, so that found stock entered twice does not fail.
+func (r *StockCountRepository) AddStockCountLine(
+	ctx context.Context,
+	exec db.PGExecutor,
+	stockCountID int,
+	line *model.NewStockCountLine,
+	userID int,
+) (int, error) {
+
+	query := `
+INSERT INTO stock_count_line (
+	stock_count_id,
+	stock_item_id,
+	location,
+	bin,
+	lot_number,
+	expected_quantity,
+	counted_quantity,
+	counted_by,
+	counted_at
+)
+VALUES (
+	$1, $2, $3, $4, $5, $6, $7,
+	CASE WHEN $7::NUMERIC IS NULL THEN NULL ELSE $8::INT END,
+	CASE WHEN $7::NUMERIC IS NULL THEN NULL ELSE NOW() END
+)
+ON CONFLICT (stock_count_id, stock_item_id, location, bin, lot_number) DO UPDATE
+SET
+	counted_quantity = COALESCE(EXCLUDED.counted_quantity, stock_count_line.counted_quantity),
+	counted_by = COALESCE(EXCLUDED.counted_by, stock_count_line.counted_by),
+	counted_at = COALESCE(EXCLUDED.counted_at, stock_count_line.counted_at)
+RETURNING stock_count_line_id
+	`
+
+	var stockCountLineID int
+	err := exec.QueryRow(
+		ctx,
+		query,
+		stockCountID,
+		line.StockItemID,
+		line.Location,
+		line.Bin,
+		line.LotNumber,
+		line.ExpectedQty,
+		line.CountedQty,
+		userID,
+	).Scan(&stockCountLineID)
+	if err != nil {
+		return 0, err
+	}
+
+	return stockCountLineID, nil
+}
+
+func (r *StockCountRepository) SetStockCountLineCount(
+	ctx context.Context,
+	exec db.PGExecutor,
+	stockCountID int,
+	stockCountLineID int,
+	countedQty decimal.Decimal,
+	userID int,
+) error {
+
+	query := `
+UPDATE
+	stock_count_line
+SET
+	counted_quantity = $3,
+	counted_by = $4,
+	counted_at = NOW()
+WHERE
+	stock_count_id = $1
+	AND stock_count_line_id = $2
+	AND counted_quantity IS DISTINCT FROM $3
+	`
+
+	_, err := exec.Exec(ctx, query, stockCountID, stockCountLineID, countedQty, userID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *StockCountRepository) GetStockCountLines(
+	ctx context.Context,
+	exec db.PGExecutor,
+	stockCountID int,
+) ([]model.StockCountLine, error) {
+
+	query := `
+SELECT
+	scl.stock_count_line_id,
+	scl.stock_count_id,
+	scl.stock_item_id,
+	si.stock_code,
+	si.base_unit,
+	scl.location,
+	scl.bin,
+	scl.lot_number,
+	scl.expected_quantity,
+	scl.counted_quantity,
+	u.username AS counted_by_username,
+	scl.counted_at
+FROM
+	stock_count_line scl
+JOIN stock_item si ON si.stock_item_id = scl.stock_item_id
+LEFT JOIN app_user u ON u.user_id = scl.counted_by
+WHERE
+	scl.stock_count_id = $1
+ORDER BY
+	scl.location,
+	scl.bin,
+	si.stock_code,
+	scl.lot_number
+	`
+
+	rows, err := exec.Query(ctx, query, stockCountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lines := []model.StockCountLine{}
+	for rows.Next() {
+		var l model.StockCountLine
+		err := rows.Scan(
+			&l.StockCountLineID,
+			&l.StockCountID,
+			&l.StockItemID,
+			&l.StockCode,
+			&l.Unit,
+			&l.Location,
+			&l.Bin,
+			&l.LotNumber,
+			&l.ExpectedQty,
+			&l.CountedQty,
+			&l.CountedByUsername,
+			&l.CountedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		lines = append(lines, l)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return lines, nil
+}
@@ -0,0 +1,419 @@
+package repository
+
+import (
+	"app/internal/model"
+	"app/pkg/db"
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type StockDocumentRepository struct{}
+
+func NewStockDocumentRepository() *StockDocumentRepository {
+	return &StockDocumentRepository{}
+}
+
+func (r *StockDocumentRepository) CreateStockDocument(
+	ctx context.Context,
+	exec db.PGExecutor,
+	input *model.NewStockDocument,
+	userID int,
+) (int, error) {
+
+	query := `
+INSERT INTO stock_document (
+	document_type,
+	reference,
+	document_date,
+	note,
+	created_by
+)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING stock_document_id
+	`
+
+	var stockDocumentID int
+	err := exec.QueryRow(
+		ctx,
+		query,
+		input.DocumentType,
+		input.Reference,
+		input.DocumentDate,
+		input.Note,
+		userID,
+	).Scan(&stockDocumentID)
+	if err != nil {
+		return 0, err
+	}
+
+	return stockDocumentID, nil
+}
+
+func (r *StockDocumentRepository) GetStockDocument(
+	ctx context.Context,
+	exec db.PGExecutor,
+	stockDocumentID int,
+) (*model.StockDocument, error) {
+
+	query := `
+SELECT
+	stock_document_id,
+	document_type,
+	reference,
+	document_date,
+	note,
+	status,
+	line_count,
+	created_by,
+	created_by_username,
+	created_at,
+	posted_by,
+	posted_by_username,
+	posted_at
+FROM
+	stock_document_view
+WHERE
+	stock_document_id = $1
+	`
+
+	var sd model.StockDocument
+	err := exec.QueryRow(ctx, query, stockDocumentID).Scan(
+		&sd.StockDocumentID,
+		&sd.DocumentType,
+		&sd.Reference,
+		&sd.DocumentDate,
+		&sd.Note,
+		&sd.Status,
+		&sd.LineCount,
+		&sd.CreatedBy,
+		&sd.CreatedByUsername,
+		&sd.CreatedAt,
+		&sd.PostedBy,
+		&sd.PostedByUsername,
+		&sd.PostedAt,
+	)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &sd, nil
+}
+
+// LockStockDocument takes a row lock on the document for the rest of the
+// transaction and returns its current status
+func (r *StockDocumentRepository) LockStockDocument(
+	ctx context.Context,
+	exec pgx.Tx,
+	stockDocumentID int,
+) (*model.StockDocumentStatus, error) {
+
+	query := `
+SELECT
+	status
+FROM
+	stock_document
+WHERE
+	stock_document_id = $1
+FOR UPDATE
+	`
+
+	var status model.StockDocumentStatus
+	err := exec.QueryRow(ctx, query, stockDocumentID).Scan(&status)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &status, nil
+}
+
+func (r *StockDocumentRepository) GetStockDocuments(
+	ctx context.Context,
+	exec db.PGExecutor,
+	q *model.GetStockDocumentsQuery,
+) ([]model.StockDocument, error) {
+
+	limit := q.PageSize
+	if limit == 0 {
+		limit = 50
+	}
+	offset := 0
+	if q.Page > 0 {
+		offset = (q.Page - 1) * limit
+	}
+
+	orderByClause, _ := q.Sort.ToOrderByClause(model.StockDocument{})
+	if orderByClause == "" {
+		orderByClause = "ORDER BY document_date DESC, stock_document_id DESC"
+	}
+
+	query := fmt.Sprintf(`
+SELECT
+	stock_document_id,
+	document_type,
+	reference,
+	document_date,
+	note,
+	status,
+	line_count,
+	created_by,
+	created_by_username,
+	created_at,
+	posted_by,
+	posted_by_username,
+	posted_at
+FROM
+	stock_document_view
+WHERE
+	($1 = '' OR status = $1)
+
+%s
+
+LIMIT $2 OFFSET $3
+	`,
+		orderByClause,
+	)
+
+	rows, err := exec.Query(ctx, query, q.Status, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stockDocuments := []model.StockDocument{}
+	for rows.Next() {
+		var sd model.StockDocument
+		err := rows.Scan(
+			&sd.StockDocumentID,
+			&sd.DocumentType,
+			&sd.Reference,
+			&sd.DocumentDate,
+			&sd.Note,
+			&sd.Status,
+			&sd.LineCount,
+			&sd.CreatedBy,
+			&sd.CreatedByUsername,
+			&sd.CreatedAt,
+			&sd.PostedBy,
+			&sd.PostedByUsername,
+			&sd.PostedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		stockDocuments = append(stockDocuments, sd)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return stockDocuments, nil
+}
+
+func (r *StockDocumentRepository) GetStockDocumentsCount(
+	ctx context.Context,
+	exec db.PGExecutor,
+	q *model.GetStockDocumentsQuery,
+) (int, error) {
+
+	query := `
+SELECT
+	COUNT(*)
+FROM
+	stock_document
+WHERE
+	($1 = '' OR status = $1)
+	`
+
+	var count int
+	err := exec.QueryRow(ctx, query, q.Status).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (r *StockDocumentRepository) UpdateStockDocumentStatus(
+	ctx context.Context,
+	exec db.PGExecutor,
+	stockDocumentID int,
+	status model.StockDocumentStatus,
+	userID int,
+) error {
+
+	// posted_by and posted_at are only recorded when the document is posted
+	query := `
+UPDATE
+	stock_document
+SET
+	status = $2,
+	posted_by = CASE WHEN $2 = 'Posted' THEN $3::INT ELSE posted_by END,
+	posted_at = CASE WHEN $2 = 'Posted' THEN NOW() ELSE posted_at END
+WHERE
+	stock_document_id = $1
+	`
+
+	_, err := exec.Exec(ctx, query, stockDocumentID, status, userID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *StockDocumentRepository) AddStockDocumentLine(
+	ctx context.Context,
+	exec db.PGExecutor,
+	stockDocumentID int,
+	line *model.NewStockDocumentLine,
+) (int, error) {
+
+	query := `
+INSERT INTO stock_document_line (
+	stock_document_id,
+	transaction_type,
+	stock_item_id,
+	quantity,
+	from_location,
+	from_bin,
+	from_lot_number,
+	to_location,
+	to_bin,
+	to_lot_number,
+	serial_numbers,
+	unit_cost,
+	line_note
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, COALESCE($11::TEXT[], '{}'), $12, $13)
+RETURNING stock_document_line_id
+	`
+
+	var stockDocumentLineID int
+	err := exec.QueryRow(
+		ctx,
+		query,
+		stockDocumentID,
+		line.TransactionType,
+		line.StockItemID,
+		line.Qty,
+		line.FromLocation,
+		line.FromBin,
+		line.FromLotNumber,
+		line.ToLocation,
+		line.ToBin,
+		line.ToLotNumber,
+		line.SerialNumbers,
+		line.UnitCost,
+		line.LineNote,
+	).Scan(&stockDocumentLineID)
+	if err != nil {
+		return 0, err
+	}
+
+	return stockDocumentLineID, nil
+}
+
+func (r *StockDocumentRepository) DeleteStockDocumentLine(
+	ctx context.Context,
+	exec db.PGExecutor,
+	stockDocumentID int,
+	stockDocumentLineID int,
+) error {
+
+	query := `
+DELETE FROM
+	stock_document_line
+WHERE
+	stock_document_id = $1
+	AND stock_document_line_id = $2
+	`
+
+	_, err := exec.Exec(ctx, query, stockDocumentID, stockDocumentLineID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *StockDocumentRepository) GetStockDocumentLines(
+	ctx context.Context,
+	exec db.PGExecutor,
+	stockDocumentID int,
+) ([]model.StockDocumentLine, error) {
+
+	query := `
+SELECT
+	sdl.stock_document_line_id,
+	sdl.stock_document_id,
+	sdl.transaction_type,
+	sdl.stock_item_id,
+	si.stock_code,
+	sdl.quantity,
+	si.base_unit,
+	sdl.from_location,
+	sdl.from_bin,
+	sdl.from_lot_number,
+	sdl.to_location,
+	sdl.to_bin,
+	sdl.to_lot_number,
+	sdl.serial_numbers,
+	sdl.unit_cost,
+	sdl.line_note
+FROM
+	stock_document_line sdl
+JOIN stock_item si ON si.stock_item_id = sdl.stock_item_id
+WHERE
+	sdl.stock_document_id = $1
+ORDER BY
+	sdl.stock_document_line_id
+	`
+
+	rows, err := exec.Query(ctx, query, stockDocumentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lines := []model.StockDocumentLine{}
+	for rows.Next() {
+		var l model.StockDocumentLine
+		err := rows.Scan(
+			&l.StockDocumentLineID,
+			&l.StockDocumentID,
+			&l.TransactionType,
+			&l.StockItemID,
+			&l.StockCode,
+			&l.Qty,
+			&l.Unit,
+			&l.FromLocation,
+			&l.FromBin,
+			&l.FromLotNumber,
+			&l.ToLocation,
+			&l.ToBin,
+			&l.ToLotNumber,
+			&l.SerialNumbers,
+			&l.UnitCost,
+			&l.LineNote,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		lines = append(lines, l)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return lines, nil
+}
@@ -0,0 +1,317 @@
+package repository
+
+import (
+	"app/internal/model"
+	"app/pkg/db"
+	"context"
+	"fmt"
+)
+
+type StockGenealogyRepository struct{}
+
+func NewStockGenealogyRepository() *StockGenealogyRepository {
+	return &StockGenealogyRepository{}
+}
+
+// LinkStockDocument links every consumption posted by the document to every
+// production posted by it
+func (r *StockGenealogyRepository) LinkStockDocument(
+	ctx context.Context,
+	exec db.PGExecutor,
+	stockDocumentID int,
+) error {
+
+	query := `
+INSERT INTO stock_genealogy_link (
+	consumption_stock_transaction_id,
+	production_stock_transaction_id,
+	stock_document_id
+)
+SELECT
+	c.stock_transaction_id,
+	p.stock_transaction_id,
+	$1
+FROM
+	stock_transaction c
+JOIN stock_transaction p
+	ON p.stock_document_id = c.stock_document_id
+	AND p.transaction_type = 'Production'
+WHERE
+	c.stock_document_id = $1
+	AND c.transaction_type = 'Consumption'
+ON CONFLICT DO NOTHING
+	`
+
+	_, err := exec.Exec(ctx, query, stockDocumentID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// LinkStockTransactions links each consumption to the production, as when
+// the components of a production are backflushed
+func (r *StockGenealogyRepository) LinkStockTransactions(
+	ctx context.Context,
+	exec db.PGExecutor,
+	productionStockTransactionID int,
+	consumptionStockTransactionIDs []int,
+) error {
+
+	query := `
+INSERT INTO stock_genealogy_link (
+	consumption_stock_transaction_id,
+	production_stock_transaction_id
+)
+SELECT
+	unnest($2::INT[]),
+	$1
+ON CONFLICT DO NOTHING
+	`
+
+	_, err := exec.Exec(ctx, query, productionStockTransactionID, consumptionStockTransactionIDs)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetBackflushStockTransactionIDs returns the consumptions backflushed with
+// the production that have not been reversed
+func (r *StockGenealogyRepository) GetBackflushStockTransactionIDs(
+	ctx context.Context,
+	exec db.PGExecutor,
+	productionStockTransactionID int,
+) ([]int, error) {
+
+	query := `
+SELECT
+	l.consumption_stock_transaction_id
+FROM
+	stock_genealogy_link l
+WHERE
+	l.production_stock_transaction_id = $1
+	AND l.stock_document_id IS NULL
+	AND NOT EXISTS (
+		SELECT 1
+		FROM stock_transaction r
+		WHERE r.reverses_stock_transaction_id = l.consumption_stock_transaction_id
+	)
+ORDER BY
+	l.consumption_stock_transaction_id
+	`
+
+	rows, err := exec.Query(ctx, query, productionStockTransactionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stockTransactionIDs := []int{}
+	for rows.Next() {
+		var stockTransactionID int
+		if err := rows.Scan(&stockTransactionID); err != nil {
+			return nil, err
+		}
+
+		stockTransactionIDs = append(stockTransactionIDs, stockTransactionID)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return stockTransactionIDs, nil
+}
+
+// GetBackflushedLots returns the lots of the component backflushed from the
+// location and bin by productions of the lot of the stock item that have not
+// been reversed, most recently backflushed first
+func (r *StockGenealogyRepository) GetBackflushedLots(
+	ctx context.Context,
+	exec db.PGExecutor,
+	stockItemID int,
+	lotNumber string,
+	componentStockItemID int,
+	location string,
+	bin string,
+) ([]model.BackflushedLot, error) {
+
+	query := `
+SELECT
+	ce.lot_number,
+	SUM(-ce.quantity) AS quantity
+FROM
+	stock_genealogy_link l
+JOIN stock_transaction p ON p.stock_transaction_id = l.production_stock_transaction_id
+JOIN stock_transaction_entry pe
+	ON pe.stock_transaction_id = p.stock_transaction_id
+	AND pe.account = 'STOCK'
+JOIN stock_transaction c ON c.stock_transaction_id = l.consumption_stock_transaction_id
+JOIN stock_transaction_entry ce
+	ON ce.stock_transaction_id = c.stock_transaction_id
+	AND ce.account = 'STOCK'
+WHERE
+	l.stock_document_id IS NULL
+	AND p.stock_item_id = $1
+	AND pe.lot_number = $2
+	AND c.stock_item_id = $3
+	AND ce.location = $4
+	AND ce.bin = $5
+	AND NOT EXISTS (
+		SELECT 1
+		FROM stock_transaction r
+		WHERE r.reverses_stock_transaction_id IN (p.stock_transaction_id, c.stock_transaction_id)
+	)
+GROUP BY
+	ce.lot_number
+ORDER BY
+	MAX(c.timestamp) DESC,
+	ce.lot_number
+	`
+
+	rows, err := exec.Query(ctx, query, stockItemID, lotNumber, componentStockItemID, location, bin)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lots := []model.BackflushedLot{}
+	for rows.Next() {
+		var l model.BackflushedLot
+		if err := rows.Scan(&l.LotNumber, &l.Qty); err != nil {
+			return nil, err
+		}
+
+		lots = append(lots, l)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return lots, nil
+}
+
+// maxLotTraceDepth stops a trace through lots that have been reworked into
+// themselves from running away
+const maxLotTraceDepth = 50
+
+// GetLotTrace returns every lot link reachable from the lot in the given
+// direction. Only lots with a lot number are followed further, as stock
+// without one cannot be told apart.
+func (r *StockGenealogyRepository) GetLotTrace(
+	ctx context.Context,
+	exec db.PGExecutor,
+	stockItemID int,
+	lotNumber string,
+	direction model.LotTraceDirection,
+) ([]model.StockLotLink, error) {
+
+	// the from side is the lot the trace starts at, the to side is where the
+	// link leads
+	fromSide, toSide := "consumed", "produced"
+	if direction == model.BackwardLotTraceDirection {
+		fromSide, toSide = "produced", "consumed"
+	}
+
+	query := fmt.Sprintf(`
+WITH RECURSIVE trace AS (
+	SELECT
+		1 AS depth,
+		l.consumed_stock_item_id,
+		l.consumed_lot_number,
+		l.consumed_quantity,
+		l.produced_stock_item_id,
+		l.produced_lot_number,
+		ARRAY[l.%[1]s_stock_item_id || ':' || l.%[1]s_lot_number] AS path
+	FROM
+		stock_lot_link l
+	WHERE
+		l.%[1]s_stock_item_id = $1
+		AND l.%[1]s_lot_number = $2
+
+	UNION ALL
+
+	SELECT
+		t.depth + 1,
+		l.consumed_stock_item_id,
+		l.consumed_lot_number,
+		l.consumed_quantity,
+		l.produced_stock_item_id,
+		l.produced_lot_number,
+		t.path || (l.%[1]s_stock_item_id || ':' || l.%[1]s_lot_number)
+	FROM
+		trace t
+	JOIN stock_lot_link l
+		ON l.%[1]s_stock_item_id = t.%[2]s_stock_item_id
+		AND l.%[1]s_lot_number = t.%[2]s_lot_number
+	WHERE
+		t.%[2]s_lot_number <> ''
+		AND NOT (t.%[2]s_stock_item_id || ':' || t.%[2]s_lot_number) = ANY(t.path)
+		AND t.depth < $3
+)
+
+SELECT
+	MIN(t.depth) AS depth,
+	csi.stock_code AS consumed_stock_code,
+	t.consumed_lot_number,
+	t.consumed_quantity,
+	csi.base_unit AS consumed_unit,
+	psi.stock_code AS produced_stock_code,
+	t.produced_lot_number
+FROM
+	trace t
+JOIN stock_item csi ON csi.stock_item_id = t.consumed_stock_item_id
+JOIN stock_item psi ON psi.stock_item_id = t.produced_stock_item_id
+GROUP BY
+	csi.stock_code,
+	t.consumed_lot_number,
+	t.consumed_quantity,
+	csi.base_unit,
+	psi.stock_code,
+	t.produced_lot_number
+ORDER BY
+	depth,
+	consumed_stock_code,
+	t.consumed_lot_number,
+	produced_stock_code,
+	t.produced_lot_number
+	`,
+		fromSide,
+		toSide,
+	)
+
+	rows, err := exec.Query(ctx, query, stockItemID, lotNumber, maxLotTraceDepth)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	links := []model.StockLotLink{}
+	for rows.Next() {
+		var l model.StockLotLink
+		err := rows.Scan(
+			&l.Depth,
+			&l.ConsumedStockCode,
+			&l.ConsumedLotNumber,
+			&l.ConsumedQty,
+			&l.ConsumedUnit,
+			&l.ProducedStockCode,
+			&l.ProducedLotNumber,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		links = append(links, l)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return links, nil
+}
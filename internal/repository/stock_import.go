@@ -0,0 +1,264 @@
+package repository
+
+import (
+	"app/internal/model"
+	"app/pkg/db"
+	"context"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type StockImportRepository struct{}
+
+func NewStockImportRepository() *StockImportRepository {
+	return &StockImportRepository{}
+}
+
+var stockImportSelect = `
+SELECT
+	si.stock_import_id,
+	si.filename,
+	si.file_id::TEXT,
+	si.status,
+	si.row_count,
+	si.error_row_count,
+	si.posted_row_count,
+	cu.username,
+	si.created_at,
+	pu.username,
+	si.posted_at
+FROM
+	stock_import si
+LEFT JOIN app_user cu ON cu.user_id = si.created_by
+LEFT JOIN app_user pu ON pu.user_id = si.posted_by
+`
+
+func scanStockImport(row pgx.Row) (model.StockImport, error) {
+	var si model.StockImport
+	err := row.Scan(
+		&si.StockImportID,
+		&si.Filename,
+		&si.FileID,
+		&si.Status,
+		&si.RowCount,
+		&si.ErrorRowCount,
+		&si.PostedRowCount,
+		&si.CreatedByUsername,
+		&si.CreatedAt,
+		&si.PostedByUsername,
+		&si.PostedAt,
+	)
+	return si, err
+}
+
+func (r *StockImportRepository) CreateStockImport(
+	ctx context.Context,
+	exec db.PGExecutor,
+	filename string,
+	rowCount int,
+	errorRowCount int,
+	userID int,
+) (int, error) {
+
+	query := `
+INSERT INTO stock_import (
+	filename,
+	row_count,
+	error_row_count,
+	created_by
+)
+VALUES ($1, $2, $3, $4)
+RETURNING stock_import_id
+	`
+
+	var stockImportID int
+	err := exec.QueryRow(ctx, query, filename, rowCount, errorRowCount, userID).Scan(&stockImportID)
+	if err != nil {
+		return 0, err
+	}
+
+	return stockImportID, nil
+}
+
+func (r *StockImportRepository) SetStockImportFile(
+	ctx context.Context,
+	exec db.PGExecutor,
+	stockImportID int,
+	fileID string,
+) error {
+
+	query := `
+UPDATE
+	stock_import
+SET
+	file_id = $2
+WHERE
+	stock_import_id = $1
+	`
+
+	_, err := exec.Exec(ctx, query, stockImportID, fileID)
+	return err
+}
+
+func (r *StockImportRepository) GetStockImport(
+	ctx context.Context,
+	exec db.PGExecutor,
+	stockImportID int,
+) (*model.StockImport, error) {
+
+	query := stockImportSelect + `
+WHERE
+	si.stock_import_id = $1
+	`
+
+	si, err := scanStockImport(exec.QueryRow(ctx, query, stockImportID))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &si, nil
+}
+
+// LockStockImport takes a row lock on the import for the rest of the
+// transaction and returns its current status
+func (r *StockImportRepository) LockStockImport(
+	ctx context.Context,
+	exec pgx.Tx,
+	stockImportID int,
+) (*model.StockImportStatus, error) {
+
+	query := `
+SELECT
+	status
+FROM
+	stock_import
+WHERE
+	stock_import_id = $1
+FOR UPDATE
+	`
+
+	var status model.StockImportStatus
+	err := exec.QueryRow(ctx, query, stockImportID).Scan(&status)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &status, nil
+}
+
+func (r *StockImportRepository) GetStockImports(
+	ctx context.Context,
+	exec db.PGExecutor,
+	q *model.GetStockImportsQuery,
+) ([]model.StockImport, error) {
+
+	limit := q.PageSize
+	if limit == 0 {
+		limit = 50
+	}
+	offset := 0
+	if q.Page > 0 {
+		offset = (q.Page - 1) * limit
+	}
+
+	query := stockImportSelect + `
+WHERE
+	($1 = '' OR si.status = $1)
+ORDER BY
+	si.stock_import_id DESC
+LIMIT $2 OFFSET $3
+	`
+
+	rows, err := exec.Query(ctx, query, q.Status, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stockImports := []model.StockImport{}
+	for rows.Next() {
+		si, err := scanStockImport(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		stockImports = append(stockImports, si)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return stockImports, nil
+}
+
+func (r *StockImportRepository) GetStockImportsCount(
+	ctx context.Context,
+	exec db.PGExecutor,
+	q *model.GetStockImportsQuery,
+) (int, error) {
+
+	query := `
+SELECT
+	COUNT(*)
+FROM
+	stock_import
+WHERE
+	($1 = '' OR status = $1)
+	`
+
+	var count int
+	err := exec.QueryRow(ctx, query, q.Status).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (r *StockImportRepository) SetStockImportPosted(
+	ctx context.Context,
+	exec db.PGExecutor,
+	stockImportID int,
+	postedRowCount int,
+	userID int,
+) error {
+
+	query := `
+UPDATE
+	stock_import
+SET
+	status = 'Posted',
+	posted_row_count = $2,
+	posted_by = $3,
+	posted_at = NOW()
+WHERE
+	stock_import_id = $1
+	`
+
+	_, err := exec.Exec(ctx, query, stockImportID, postedRowCount, userID)
+	return err
+}
+
+func (r *StockImportRepository) SetStockImportCancelled(
+	ctx context.Context,
+	exec db.PGExecutor,
+	stockImportID int,
+) error {
+
+	query := `
+UPDATE
+	stock_import
+SET
+	status = 'Cancelled'
+WHERE
+	stock_import_id = $1
+	`
+
+	_, err := exec.Exec(ctx, query, stockImportID)
+	return err
+}
@@ -26,10 +26,13 @@ func (r *StockItemRepository) CreateStockItem(
 INSERT INTO stock_item (
 	stock_code,
 	description,
+	base_unit,
+	is_serialised,
+	costing_method,
 	gallery_id,
 	comment_thread_id
 )
-VALUES ($1, $2, $3, $4)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
 RETURNING stock_item_id
 	`
 	var newStockItemID int
@@ -38,6 +41,9 @@ RETURNING stock_item_id
 		insertStmt,
 		stockItem.StockCode,
 		stockItem.Description,
+		stockItem.BaseUnit,
+		stockItem.IsSerialised,
+		stockItem.CostingMethod,
 		stockItem.GalleryID,
 		stockItem.CommentThreadID,
 	).Scan(&newStockItemID)
@@ -71,7 +77,10 @@ UPDATE
 
 SET
 	stock_code = $2,
-	description = $3
+	description = $3,
+	base_unit = $4,
+	is_serialised = $5,
+	costing_method = $6
 
 WHERE
 	stock_item_id = $1
@@ -84,6 +93,9 @@ WHERE
 		stockItemID,
 		input.StockCode,
 		input.Description,
+		input.BaseUnit,
+		input.IsSerialised,
+		input.CostingMethod,
 	)
 
 	if err != nil {
@@ -103,6 +115,9 @@ SELECT
 	stock_item_id,
 	stock_code,
 	description,
+	base_unit,
+	is_serialised,
+	costing_method,
 	gallery_id,
 	comment_thread_id,
 	created_at
@@ -117,6 +132,9 @@ WHERE
 		&stockItem.StockItemID,
 		&stockItem.StockCode,
 		&stockItem.Description,
+		&stockItem.BaseUnit,
+		&stockItem.IsSerialised,
+		&stockItem.CostingMethod,
 		&stockItem.GalleryID,
 		&stockItem.CommentThreadID,
 		&stockItem.CreatedAt,
@@ -141,6 +159,9 @@ SELECT
 	stock_item_id,
 	stock_code,
 	description,
+	base_unit,
+	is_serialised,
+	costing_method,
 	comment_thread_id,
 	created_at
 FROM
@@ -154,6 +175,9 @@ WHERE
 		&stockItem.StockItemID,
 		&stockItem.StockCode,
 		&stockItem.Description,
+		&stockItem.BaseUnit,
+		&stockItem.IsSerialised,
+		&stockItem.CostingMethod,
 		&stockItem.CommentThreadID,
 		&stockItem.CreatedAt,
 	)
@@ -186,6 +210,9 @@ SELECT
     stock_item_id,
     stock_code,
     description,
+    base_unit,
+    is_serialised,
+    costing_method,
 		comment_thread_id,
     created_at
 FROM
@@ -212,6 +239,9 @@ LIMIT $1 OFFSET $2
 			&stockItem.StockItemID,
 			&stockItem.StockCode,
 			&stockItem.Description,
+			&stockItem.BaseUnit,
+			&stockItem.IsSerialised,
+			&stockItem.CostingMethod,
 			&stockItem.CommentThreadID,
 			&stockItem.CreatedAt,
 		)
@@ -257,6 +287,9 @@ SELECT
     sic.stock_item_id,
     si.stock_code,
     sic.description,
+    sic.base_unit,
+    sic.is_serialised,
+    sic.costing_method,
     u.username AS changed_by_username,
     sic.changed_at,
     CASE
@@ -287,6 +320,9 @@ ORDER BY
 			&c.StockItemID,
 			&c.StockCode,
 			&c.Description,
+			&c.BaseUnit,
+			&c.IsSerialised,
+			&c.CostingMethod,
 			&c.ChangeByUsername,
 			&c.ChangedAt,
 			&c.IsCreation,
@@ -312,9 +348,12 @@ INSERT INTO stock_item_change (
 	stock_item_id,
 	stock_code,
 	description,
+	base_unit,
+	is_serialised,
+	costing_method,
 	change_by
 )
-VALUES ($1, $2, $3, $4)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
 	`
 	_, err := exec.Exec(
 		ctx,
@@ -323,6 +362,9 @@ VALUES ($1, $2, $3, $4)
 		stockItemChange.StockItemID,
 		stockItemChange.StockCode,
 		stockItemChange.Description,
+		stockItemChange.BaseUnit,
+		stockItemChange.IsSerialised,
+		stockItemChange.CostingMethod,
 		stockItemChange.ChangeBy,
 	)
 
@@ -333,6 +375,176 @@ VALUES ($1, $2, $3, $4)
 	return nil
 }
 
+// HasStockTransactions reports whether anything has been posted against the
+// stock item, after which its base unit and whether it is serialised can no
+// longer change
+func (r *StockItemRepository) HasStockTransactions(
+	ctx context.Context,
+	exec db.PGExecutor,
+	stockItemID int,
+) (bool, error) {
+
+	query := `
+SELECT EXISTS (
+	SELECT 1 FROM stock_transaction WHERE stock_item_id = $1
+)
+	`
+
+	var exists bool
+	err := exec.QueryRow(ctx, query, stockItemID).Scan(&exists)
+	if err != nil {
+		return false, err
+	}
+
+	return exists, nil
+}
+
+func (r *StockItemRepository) GetStockItemUnits(
+	ctx context.Context,
+	exec db.PGExecutor,
+	stockItemID int,
+) ([]model.StockItemUnit, error) {
+
+	query := `
+SELECT
+	siu.stock_item_unit_id,
+	siu.stock_item_id,
+	siu.unit,
+	siu.conversion_factor,
+	siu.created_by,
+	u.username AS created_by_username,
+	siu.created_at
+FROM
+	stock_item_unit siu
+LEFT JOIN app_user u ON u.user_id = siu.created_by
+WHERE
+	siu.stock_item_id = $1
+ORDER BY
+	siu.conversion_factor,
+	siu.unit
+	`
+
+	rows, err := exec.Query(ctx, query, stockItemID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	units := []model.StockItemUnit{}
+	for rows.Next() {
+		var siu model.StockItemUnit
+		err := rows.Scan(
+			&siu.StockItemUnitID,
+			&siu.StockItemID,
+			&siu.Unit,
+			&siu.ConversionFactor,
+			&siu.CreatedBy,
+			&siu.CreatedByUsername,
+			&siu.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		units = append(units, siu)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return units, nil
+}
+
+func (r *StockItemRepository) GetStockItemUnit(
+	ctx context.Context,
+	exec db.PGExecutor,
+	stockItemID int,
+	unit string,
+) (*model.StockItemUnit, error) {
+
+	query := `
+SELECT
+	stock_item_unit_id,
+	stock_item_id,
+	unit,
+	conversion_factor,
+	created_by,
+	created_at
+FROM
+	stock_item_unit
+WHERE
+	stock_item_id = $1
+	AND unit = $2
+	`
+
+	var siu model.StockItemUnit
+	err := exec.QueryRow(ctx, query, stockItemID, unit).Scan(
+		&siu.StockItemUnitID,
+		&siu.StockItemID,
+		&siu.Unit,
+		&siu.ConversionFactor,
+		&siu.CreatedBy,
+		&siu.CreatedAt,
+	)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &siu, nil
+}
+
+func (r *StockItemRepository) AddStockItemUnit(
+	ctx context.Context,
+	exec db.PGExecutor,
+	stockItemID int,
+	input *model.NewStockItemUnit,
+	userID int,
+) error {
+
+	query := `
+INSERT INTO stock_item_unit (
+	stock_item_id,
+	unit,
+	conversion_factor,
+	created_by
+)
+VALUES ($1, $2, $3, $4)
+	`
+
+	_, err := exec.Exec(ctx, query, stockItemID, input.Unit, input.ConversionFactor, userID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *StockItemRepository) DeleteStockItemUnit(
+	ctx context.Context,
+	exec db.PGExecutor,
+	stockItemID int,
+	stockItemUnitID int,
+) error {
+
+	query := `
+DELETE FROM
+	stock_item_unit
+WHERE
+	stock_item_id = $1
+	AND stock_item_unit_id = $2
+	`
+
+	_, err := exec.Exec(ctx, query, stockItemID, stockItemUnitID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
 func (r *StockItemRepository) GetStockCodes(
 	ctx context.Context,
 	db db.PGExecutor,
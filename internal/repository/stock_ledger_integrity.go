@@ -0,0 +1,350 @@
+package repository
+
+import (
+	"app/internal/model"
+	"app/pkg/db"
+	"context"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type StockLedgerIntegrityRepository struct{}
+
+func NewStockLedgerIntegrityRepository() *StockLedgerIntegrityRepository {
+	return &StockLedgerIntegrityRepository{}
+}
+
+// expectedRunningTotalsCTE recomputes every running total from quantities in
+// the same order postings apply them: by transaction timestamp, then by entry
+// ID for entries posted at the same time
+const expectedRunningTotalsCTE = `
+expected AS (
+	SELECT
+		e.stock_transaction_entry_id,
+		e.running_total,
+		SUM(e.quantity) OVER (
+			PARTITION BY e.account, t.stock_item_id, e.location, e.bin, e.lot_number
+			ORDER BY t.timestamp, e.stock_transaction_entry_id
+			ROWS BETWEEN UNBOUNDED PRECEDING AND CURRENT ROW
+		) AS expected_running_total
+	FROM
+		stock_transaction_entry e
+	JOIN stock_transaction t ON t.stock_transaction_id = e.stock_transaction_id
+)`
+
+// GetDivergentRunningTotals returns up to limit entries whose running total
+// differs from the recomputed total, along with the total number found
+func (r *StockLedgerIntegrityRepository) GetDivergentRunningTotals(
+	ctx context.Context,
+	exec db.PGExecutor,
+	limit int,
+) ([]model.StockLedgerEntryDivergence, int, error) {
+
+	query := `
+WITH ` + expectedRunningTotalsCTE + `
+
+SELECT
+	e.stock_transaction_entry_id,
+	t.stock_transaction_id,
+	si.stock_code,
+	e.account,
+	e.location,
+	e.bin,
+	e.lot_number,
+	t.timestamp,
+	e.quantity,
+	e.running_total,
+	x.expected_running_total,
+	COUNT(*) OVER () AS total_count
+FROM
+	expected x
+JOIN stock_transaction_entry e ON e.stock_transaction_entry_id = x.stock_transaction_entry_id
+JOIN stock_transaction t ON t.stock_transaction_id = e.stock_transaction_id
+JOIN stock_item si ON si.stock_item_id = t.stock_item_id
+WHERE
+	x.running_total <> x.expected_running_total
+ORDER BY
+	t.timestamp, e.stock_transaction_entry_id
+LIMIT $1
+	`
+
+	rows, err := exec.Query(ctx, query, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var count int
+	divergences := []model.StockLedgerEntryDivergence{}
+	for rows.Next() {
+		var d model.StockLedgerEntryDivergence
+		err := rows.Scan(
+			&d.StockTransactionEntryID,
+			&d.StockTransactionID,
+			&d.StockCode,
+			&d.Account,
+			&d.Location,
+			&d.Bin,
+			&d.LotNumber,
+			&d.Timestamp,
+			&d.Quantity,
+			&d.RunningTotal,
+			&d.ExpectedRunningTotal,
+			&count,
+		)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		divergences = append(divergences, d)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return divergences, count, nil
+}
+
+// GetUnbalancedStockTransactions returns up to limit transactions whose
+// entries do not net to zero, along with the total number found
+func (r *StockLedgerIntegrityRepository) GetUnbalancedStockTransactions(
+	ctx context.Context,
+	exec db.PGExecutor,
+	limit int,
+) ([]model.UnbalancedStockTransaction, int, error) {
+
+	query := `
+SELECT
+	t.stock_transaction_id,
+	t.transaction_type,
+	si.stock_code,
+	t.timestamp,
+	COUNT(e.stock_transaction_entry_id) AS entry_count,
+	COALESCE(SUM(e.quantity), 0) AS net_quantity,
+	COUNT(*) OVER () AS total_count
+FROM
+	stock_transaction t
+JOIN stock_item si ON si.stock_item_id = t.stock_item_id
+LEFT JOIN stock_transaction_entry e ON e.stock_transaction_id = t.stock_transaction_id
+GROUP BY
+	t.stock_transaction_id, si.stock_code
+HAVING
+	COALESCE(SUM(e.quantity), 0) <> 0
+	OR
+	COUNT(e.stock_transaction_entry_id) <> 2
+ORDER BY
+	t.timestamp, t.stock_transaction_id
+LIMIT $1
+	`
+
+	rows, err := exec.Query(ctx, query, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var count int
+	transactions := []model.UnbalancedStockTransaction{}
+	for rows.Next() {
+		var t model.UnbalancedStockTransaction
+		err := rows.Scan(
+			&t.StockTransactionID,
+			&t.TransactionType,
+			&t.StockCode,
+			&t.Timestamp,
+			&t.EntryCount,
+			&t.NetQuantity,
+			&count,
+		)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		transactions = append(transactions, t)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return transactions, count, nil
+}
+
+// GetDivergentStockBalances compares the balance table with the latest running
+// total of each balance in the ledger
+func (r *StockLedgerIntegrityRepository) GetDivergentStockBalances(
+	ctx context.Context,
+	exec db.PGExecutor,
+	limit int,
+) ([]model.StockBalanceDivergence, int, error) {
+
+	query := `
+WITH ledger AS (
+	SELECT DISTINCT ON (e.account, t.stock_item_id, e.location, e.bin, e.lot_number)
+		e.account,
+		t.stock_item_id,
+		e.location,
+		e.bin,
+		e.lot_number,
+		e.running_total
+	FROM
+		stock_transaction_entry e
+	JOIN stock_transaction t ON t.stock_transaction_id = e.stock_transaction_id
+	ORDER BY
+		e.account, t.stock_item_id, e.location, e.bin, e.lot_number,
+		t.timestamp DESC, e.stock_transaction_entry_id DESC
+)
+
+SELECT
+	si.stock_code,
+	COALESCE(l.account, b.account),
+	COALESCE(l.location, b.location),
+	COALESCE(l.bin, b.bin),
+	COALESCE(l.lot_number, b.lot_number),
+	COALESCE(b.quantity, 0),
+	COALESCE(l.running_total, 0),
+	b.account IS NULL AS missing_in_balance,
+	l.account IS NULL AS missing_in_ledger,
+	COUNT(*) OVER () AS total_count
+FROM
+	ledger l
+FULL OUTER JOIN stock_balance b
+	ON b.account = l.account
+	AND b.stock_item_id = l.stock_item_id
+	AND b.location = l.location
+	AND b.bin = l.bin
+	AND b.lot_number = l.lot_number
+JOIN stock_item si ON si.stock_item_id = COALESCE(l.stock_item_id, b.stock_item_id)
+WHERE
+	b.account IS NULL
+	OR
+	l.account IS NULL
+	OR
+	b.quantity <> l.running_total
+ORDER BY
+	si.stock_code, 2, 3, 4, 5
+LIMIT $1
+	`
+
+	rows, err := exec.Query(ctx, query, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var count int
+	divergences := []model.StockBalanceDivergence{}
+	for rows.Next() {
+		var d model.StockBalanceDivergence
+		err := rows.Scan(
+			&d.StockCode,
+			&d.Account,
+			&d.Location,
+			&d.Bin,
+			&d.LotNumber,
+			&d.BalanceQuantity,
+			&d.LedgerQuantity,
+			&d.MissingInBalance,
+			&d.MissingInLedger,
+			&count,
+		)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		divergences = append(divergences, d)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return divergences, count, nil
+}
+
+// LockStockLedger stops postings until the transaction ends so that a
+// rebuild sees a stable ledger
+func (r *StockLedgerIntegrityRepository) LockStockLedger(
+	ctx context.Context,
+	tx pgx.Tx,
+) error {
+
+	_, err := tx.Exec(ctx, `
+LOCK TABLE stock_transaction, stock_transaction_entry, stock_balance IN EXCLUSIVE MODE
+	`)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// RebuildRunningTotals rewrites every divergent running total from quantities
+// and returns the number of entries changed
+func (r *StockLedgerIntegrityRepository) RebuildRunningTotals(
+	ctx context.Context,
+	tx pgx.Tx,
+) (int, error) {
+
+	query := `
+WITH ` + expectedRunningTotalsCTE + `
+
+UPDATE stock_transaction_entry e
+SET running_total = x.expected_running_total
+FROM expected x
+WHERE
+	e.stock_transaction_entry_id = x.stock_transaction_entry_id
+	AND
+	x.running_total <> x.expected_running_total
+	`
+
+	tag, err := tx.Exec(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+
+	return int(tag.RowsAffected()), nil
+}
+
+// RebuildStockBalances replaces the balance table with the latest running
+// totals in the ledger and returns the number of balances written
+func (r *StockLedgerIntegrityRepository) RebuildStockBalances(
+	ctx context.Context,
+	tx pgx.Tx,
+) (int, error) {
+
+	_, err := tx.Exec(ctx, `DELETE FROM stock_balance`)
+	if err != nil {
+		return 0, err
+	}
+
+	query := `
+INSERT INTO stock_balance (
+	account, stock_item_id, location, bin, lot_number,
+	quantity, last_stock_transaction_id, last_timestamp
+)
+SELECT DISTINCT ON (e.account, t.stock_item_id, e.location, e.bin, e.lot_number)
+	e.account,
+	t.stock_item_id,
+	e.location,
+	e.bin,
+	e.lot_number,
+	e.running_total,
+	t.stock_transaction_id,
+	t.timestamp
+FROM
+	stock_transaction_entry e
+JOIN stock_transaction t ON t.stock_transaction_id = e.stock_transaction_id
+ORDER BY
+	e.account, t.stock_item_id, e.location, e.bin, e.lot_number,
+	t.timestamp DESC, e.stock_transaction_entry_id DESC
+	`
+
+	tag, err := tx.Exec(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+
+	return int(tag.RowsAffected()), nil
+}
@@ -0,0 +1,453 @@
+package repository
+
+import (
+	"app/internal/model"
+	"app/pkg/db"
+	"context"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type StockLocationRepository struct{}
+
+func NewStockLocationRepository() *StockLocationRepository {
+	return &StockLocationRepository{}
+}
+
+func (r *StockLocationRepository) CreateStockLocation(
+	ctx context.Context,
+	exec db.PGExecutor,
+	location *model.NewStockLocation,
+	userID int,
+) (int, error) {
+
+	query := `
+INSERT INTO stock_location (
+	location,
+	description,
+	location_type,
+	created_by,
+	updated_by
+)
+VALUES ($1, $2, $3, $4, $4)
+RETURNING stock_location_id
+	`
+
+	var stockLocationID int
+	err := exec.QueryRow(ctx, query,
+		location.Location,
+		location.Description,
+		location.LocationType,
+		userID,
+	).Scan(&stockLocationID)
+	if err != nil {
+		return 0, err
+	}
+
+	return stockLocationID, nil
+}
+
+func (r *StockLocationRepository) UpdateStockLocation(
+	ctx context.Context,
+	exec db.PGExecutor,
+	stockLocationID int,
+	update *model.StockLocationUpdate,
+	userID int,
+) error {
+
+	query := `
+UPDATE
+	stock_location
+SET
+	description = $2,
+	location_type = $3,
+	is_archived = $4,
+	updated_by = $5,
+	updated_at = NOW()
+WHERE
+	stock_location_id = $1
+	`
+
+	_, err := exec.Exec(ctx, query,
+		stockLocationID,
+		update.Description,
+		update.LocationType,
+		update.IsArchived,
+		userID,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+var stockLocationSelect = `
+SELECT
+	sl.stock_location_id,
+	sl.location,
+	sl.description,
+	sl.location_type,
+	sl.is_archived,
+	(SELECT COUNT(*) FROM stock_bin sb WHERE sb.stock_location_id = sl.stock_location_id),
+	cu.username,
+	sl.created_at,
+	uu.username,
+	sl.updated_at
+FROM
+	stock_location sl
+LEFT JOIN app_user cu ON cu.user_id = sl.created_by
+LEFT JOIN app_user uu ON uu.user_id = sl.updated_by
+`
+
+func scanStockLocation(row pgx.Row) (model.StockLocation, error) {
+	var sl model.StockLocation
+	err := row.Scan(
+		&sl.StockLocationID,
+		&sl.Location,
+		&sl.Description,
+		&sl.LocationType,
+		&sl.IsArchived,
+		&sl.BinCount,
+		&sl.CreatedByUsername,
+		&sl.CreatedAt,
+		&sl.UpdatedByUsername,
+		&sl.UpdatedAt,
+	)
+	return sl, err
+}
+
+func (r *StockLocationRepository) GetStockLocation(
+	ctx context.Context,
+	exec db.PGExecutor,
+	stockLocationID int,
+) (*model.StockLocation, error) {
+
+	query := stockLocationSelect + `
+WHERE
+	sl.stock_location_id = $1
+	`
+
+	sl, err := scanStockLocation(exec.QueryRow(ctx, query, stockLocationID))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &sl, nil
+}
+
+func (r *StockLocationRepository) GetStockLocationByName(
+	ctx context.Context,
+	exec db.PGExecutor,
+	location string,
+) (*model.StockLocation, error) {
+
+	query := stockLocationSelect + `
+WHERE
+	sl.location = $1
+	`
+
+	sl, err := scanStockLocation(exec.QueryRow(ctx, query, location))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &sl, nil
+}
+
+// stockLocationsWhere filters locations by GetStockLocationsQuery, taking $1
+// and $2
+var stockLocationsWhere = `
+WHERE
+	($1 = '' OR sl.location ILIKE '%' || $1 || '%' OR sl.description ILIKE '%' || $1 || '%')
+	AND
+	($2 OR NOT sl.is_archived)
+`
+
+func (r *StockLocationRepository) GetStockLocations(
+	ctx context.Context,
+	exec db.PGExecutor,
+	q *model.GetStockLocationsQuery,
+) ([]model.StockLocation, error) {
+
+	limit := q.PageSize
+	if limit == 0 {
+		limit = 50
+	}
+	offset := 0
+	if q.Page > 0 {
+		offset = (q.Page - 1) * limit
+	}
+
+	query := stockLocationSelect + stockLocationsWhere + `
+ORDER BY
+	sl.location
+LIMIT $3 OFFSET $4
+	`
+
+	rows, err := exec.Query(ctx, query, q.SearchText, q.ShowArchived, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	locations := []model.StockLocation{}
+	for rows.Next() {
+		sl, err := scanStockLocation(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		locations = append(locations, sl)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return locations, nil
+}
+
+func (r *StockLocationRepository) GetStockLocationsCount(
+	ctx context.Context,
+	exec db.PGExecutor,
+	q *model.GetStockLocationsQuery,
+) (int, error) {
+
+	query := `
+SELECT
+	COUNT(*)
+FROM
+	stock_location sl
+` + stockLocationsWhere
+
+	var count int
+	err := exec.QueryRow(ctx, query, q.SearchText, q.ShowArchived).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// UpsertStockBin adds a bin to a location, or updates its capacity and
+// whether it is archived if the location already has it
+func (r *StockLocationRepository) UpsertStockBin(
+	ctx context.Context,
+	exec db.PGExecutor,
+	bin *model.PostStockBin,
+	userID int,
+) error {
+
+	query := `
+INSERT INTO stock_bin (
+	stock_location_id,
+	bin,
+	capacity,
+	is_archived,
+	created_by,
+	updated_by
+)
+VALUES ($1, $2, $3, $4, $5, $5)
+ON CONFLICT (stock_location_id, bin)
+DO UPDATE SET
+	capacity = EXCLUDED.capacity,
+	is_archived = EXCLUDED.is_archived,
+	updated_by = EXCLUDED.updated_by,
+	updated_at = NOW()
+	`
+
+	_, err := exec.Exec(ctx, query,
+		bin.StockLocationID,
+		bin.Bin,
+		bin.Capacity,
+		bin.IsArchived,
+		userID,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+var stockBinSelect = `
+SELECT
+	sb.stock_bin_id,
+	sb.stock_location_id,
+	sl.location,
+	sb.bin,
+	sb.capacity,
+	sb.is_archived,
+	COALESCE((
+		SELECT SUM(b.quantity)
+		FROM stock_balance b
+		WHERE b.account = 'STOCK' AND b.location = sl.location AND b.bin = sb.bin
+	), 0),
+	sb.updated_at
+FROM
+	stock_bin sb
+JOIN stock_location sl ON sl.stock_location_id = sb.stock_location_id
+`
+
+func scanStockBin(row pgx.Row) (model.StockBin, error) {
+	var b model.StockBin
+	err := row.Scan(
+		&b.StockBinID,
+		&b.StockLocationID,
+		&b.Location,
+		&b.Bin,
+		&b.Capacity,
+		&b.IsArchived,
+		&b.StockLevel,
+		&b.UpdatedAt,
+	)
+	return b, err
+}
+
+func (r *StockLocationRepository) GetStockBins(
+	ctx context.Context,
+	exec db.PGExecutor,
+	stockLocationID int,
+) ([]model.StockBin, error) {
+
+	query := stockBinSelect + `
+WHERE
+	sb.stock_location_id = $1
+ORDER BY
+	sb.bin
+	`
+
+	rows, err := exec.Query(ctx, query, stockLocationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	bins := []model.StockBin{}
+	for rows.Next() {
+		b, err := scanStockBin(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		bins = append(bins, b)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return bins, nil
+}
+
+// GetStockBin returns a bin of a location by name, or nil if the location
+// does not have it
+func (r *StockLocationRepository) GetStockBin(
+	ctx context.Context,
+	exec db.PGExecutor,
+	location string,
+	bin string,
+) (*model.StockBin, error) {
+
+	query := stockBinSelect + `
+WHERE
+	sl.location = $1
+	AND sb.bin = $2
+	`
+
+	b, err := scanStockBin(exec.QueryRow(ctx, query, location, bin))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &b, nil
+}
+
+// SearchStockBins returns the active bins of a location matching the search
+// text
+func (r *StockLocationRepository) SearchStockBins(
+	ctx context.Context,
+	exec db.PGExecutor,
+	location string,
+	searchText string,
+	limit int,
+) ([]model.StockBin, error) {
+
+	query := stockBinSelect + `
+WHERE
+	sl.location = $1
+	AND ($2 = '' OR sb.bin ILIKE '%' || $2 || '%')
+	AND NOT sb.is_archived
+ORDER BY
+	sb.bin
+LIMIT $3
+	`
+
+	rows, err := exec.Query(ctx, query, location, searchText, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	bins := []model.StockBin{}
+	for rows.Next() {
+		b, err := scanStockBin(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		bins = append(bins, b)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return bins, nil
+}
+
+// GetStockPlaceStatus returns whether a location, and a bin in it if one is
+// given, are in the master data and archived
+func (r *StockLocationRepository) GetStockPlaceStatus(
+	ctx context.Context,
+	exec db.PGExecutor,
+	location string,
+	bin string,
+) (model.StockPlaceStatus, error) {
+
+	query := `
+SELECT
+	sl.stock_location_id IS NOT NULL,
+	COALESCE(sl.is_archived, FALSE),
+	sb.stock_bin_id IS NOT NULL,
+	COALESCE(sb.is_archived, FALSE)
+FROM
+	(SELECT 1) one
+LEFT JOIN stock_location sl ON sl.location = $1
+LEFT JOIN stock_bin sb ON sb.stock_location_id = sl.stock_location_id
+	AND sb.bin = $2
+	`
+
+	var s model.StockPlaceStatus
+	err := exec.QueryRow(ctx, query, location, bin).Scan(
+		&s.LocationExists,
+		&s.LocationArchived,
+		&s.BinExists,
+		&s.BinArchived,
+	)
+	if err != nil {
+		return s, err
+	}
+
+	return s, nil
+}
@@ -0,0 +1,470 @@
+package repository
+
+import (
+	"app/internal/model"
+	"app/pkg/db"
+	"context"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type StockLotRepository struct{}
+
+func NewStockLotRepository() *StockLotRepository {
+	return &StockLotRepository{}
+}
+
+// CreateStockLots creates any of the lots that do not yet exist for the stock
+// item. Lots without a lot number are ignored.
+func (r *StockLotRepository) CreateStockLots(
+	ctx context.Context,
+	exec db.PGExecutor,
+	stockItemID int,
+	lotNumbers []string,
+) error {
+
+	query := `
+INSERT INTO stock_lot (
+	stock_item_id,
+	lot_number
+)
+SELECT DISTINCT
+	$1::INT,
+	lot_number
+FROM
+	UNNEST(COALESCE($2::TEXT[], '{}')) AS lot_number
+WHERE
+	lot_number <> ''
+ON CONFLICT (stock_item_id, lot_number) DO NOTHING
+	`
+
+	_, err := exec.Exec(ctx, query, stockItemID, lotNumbers)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// stockLotSelect selects lots with the quantity of each currently in the
+// STOCK account
+var stockLotSelect = `
+SELECT
+	sl.stock_lot_id,
+	sl.stock_item_id,
+	si.stock_code,
+	sl.lot_number,
+	sl.manufacture_date,
+	sl.expiry_date,
+	sl.supplier_lot_number,
+	sl.status,
+	COALESCE(sb.quantity, 0),
+	si.base_unit,
+	sl.created_at,
+	u.username,
+	sl.updated_at
+FROM
+	stock_lot sl
+JOIN stock_item si ON si.stock_item_id = sl.stock_item_id
+LEFT JOIN LATERAL (
+	SELECT
+		SUM(b.quantity) AS quantity
+	FROM
+		stock_balance b
+	WHERE
+		b.account = 'STOCK'
+		AND b.stock_item_id = sl.stock_item_id
+		AND b.lot_number = sl.lot_number
+) sb ON TRUE
+LEFT JOIN app_user u ON u.user_id = sl.updated_by
+`
+
+func scanStockLot(row pgx.Row) (model.StockLot, error) {
+	var sl model.StockLot
+	err := row.Scan(
+		&sl.StockLotID,
+		&sl.StockItemID,
+		&sl.StockCode,
+		&sl.LotNumber,
+		&sl.ManufactureDate,
+		&sl.ExpiryDate,
+		&sl.SupplierLotNumber,
+		&sl.Status,
+		&sl.StockQty,
+		&sl.Unit,
+		&sl.CreatedAt,
+		&sl.UpdatedByUsername,
+		&sl.UpdatedAt,
+	)
+	return sl, err
+}
+
+func (r *StockLotRepository) GetStockLot(
+	ctx context.Context,
+	exec db.PGExecutor,
+	stockLotID int,
+) (*model.StockLot, error) {
+
+	query := stockLotSelect + `
+WHERE
+	sl.stock_lot_id = $1
+	`
+
+	sl, err := scanStockLot(exec.QueryRow(ctx, query, stockLotID))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &sl, nil
+}
+
+// stockLotsWhere filters lots by GetStockLotsQuery, taking $1 to $4
+var stockLotsWhere = `
+WHERE
+	($1 = '' OR si.stock_code = $1)
+	AND
+	($2 = '' OR sl.lot_number = $2)
+	AND
+	($3 = '' OR sl.status = $3)
+	AND
+	(
+		$4 = 0
+		OR (
+			sl.expiry_date <= CURRENT_DATE + $4::INT
+			AND COALESCE(sb.quantity, 0) > 0
+		)
+	)
+`
+
+// GetStockLots returns lots soonest to expire first, then most recently
+// created
+func (r *StockLotRepository) GetStockLots(
+	ctx context.Context,
+	exec db.PGExecutor,
+	q *model.GetStockLotsQuery,
+) ([]model.StockLot, error) {
+
+	limit := q.PageSize
+	if limit == 0 {
+		limit = 50
+	}
+	offset := 0
+	if q.Page > 0 {
+		offset = (q.Page - 1) * limit
+	}
+
+	query := stockLotSelect + stockLotsWhere + `
+ORDER BY
+	sl.expiry_date ASC NULLS LAST,
+	sl.created_at DESC,
+	sl.stock_lot_id DESC
+LIMIT $5 OFFSET $6
+	`
+
+	rows, err := exec.Query(ctx, query,
+		q.StockCode,
+		q.LotNumber,
+		q.Status,
+		q.ExpiringWithinDays,
+		limit, offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stockLots := []model.StockLot{}
+	for rows.Next() {
+		sl, err := scanStockLot(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		stockLots = append(stockLots, sl)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return stockLots, nil
+}
+
+func (r *StockLotRepository) GetStockLotsCount(
+	ctx context.Context,
+	exec db.PGExecutor,
+	q *model.GetStockLotsQuery,
+) (int, error) {
+
+	query := `
+SELECT
+	COUNT(*)
+FROM (
+` + stockLotSelect + stockLotsWhere + `
+) l
+	`
+
+	var count int
+	err := exec.QueryRow(ctx, query,
+		q.StockCode,
+		q.LotNumber,
+		q.Status,
+		q.ExpiringWithinDays,
+	).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// UpdateStockLot sets the attributes of a lot. Changing the expiry date
+// clears the expiry notification so that the new date is notified.
+func (r *StockLotRepository) UpdateStockLot(
+	ctx context.Context,
+	exec db.PGExecutor,
+	stockLotID int,
+	update *model.UpdateStockLot,
+	userID int,
+) error {
+
+	query := `
+UPDATE
+	stock_lot
+SET
+	manufacture_date = $2,
+	expiry_date = $3,
+	supplier_lot_number = $4,
+	status = $5,
+	expiry_notified_at = CASE
+		WHEN expiry_date IS NOT DISTINCT FROM $3::DATE THEN expiry_notified_at
+		ELSE NULL
+	END,
+	updated_by = $6,
+	updated_at = NOW()
+WHERE
+	stock_lot_id = $1
+	`
+
+	_, err := exec.Exec(ctx, query,
+		stockLotID,
+		update.ManufactureDate,
+		update.ExpiryDate,
+		update.SupplierLotNumber,
+		update.Status,
+		userID,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetFEFOSuggestions returns the available STOCK of the stock item by
+// location, bin and lot in the order it should be picked, soonest to expire
+// first. Lots that are expired or not available are left out, stock without
+// an expiry date comes last, oldest first.
+func (r *StockLotRepository) GetFEFOSuggestions(
+	ctx context.Context,
+	exec db.PGExecutor,
+	stockItemID int,
+	limit int,
+) ([]model.FEFOSuggestion, error) {
+
+	query := `
+SELECT
+	sb.location,
+	sb.bin,
+	sb.lot_number,
+	sb.quantity,
+	si.base_unit,
+	sl.expiry_date
+FROM
+	stock_balance sb
+JOIN stock_item si ON si.stock_item_id = sb.stock_item_id
+LEFT JOIN stock_lot sl
+	ON sl.stock_item_id = sb.stock_item_id
+	AND sl.lot_number = sb.lot_number
+WHERE
+	sb.account = 'STOCK'
+	AND sb.stock_item_id = $1
+	AND sb.quantity > 0
+	AND COALESCE(sl.status, 'Available') = 'Available'
+	AND (sl.expiry_date IS NULL OR sl.expiry_date >= CURRENT_DATE)
+ORDER BY
+	sl.expiry_date ASC NULLS LAST,
+	sb.last_timestamp ASC
+LIMIT $2
+	`
+
+	rows, err := exec.Query(ctx, query, stockItemID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	suggestions := []model.FEFOSuggestion{}
+	for rows.Next() {
+		var s model.FEFOSuggestion
+		err := rows.Scan(
+			&s.Location,
+			&s.Bin,
+			&s.LotNumber,
+			&s.Qty,
+			&s.Unit,
+			&s.ExpiryDate,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		suggestions = append(suggestions, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return suggestions, nil
+}
+
+// GetFEFOStockAtPlace returns the available stock of the stock item at the
+// location and bin by lot, soonest to expire first
+func (r *StockLotRepository) GetFEFOStockAtPlace(
+	ctx context.Context,
+	exec db.PGExecutor,
+	stockItemID int,
+	location string,
+	bin string,
+) ([]model.FEFOSuggestion, error) {
+
+	query := `
+SELECT
+	sb.location,
+	sb.bin,
+	sb.lot_number,
+	sb.quantity,
+	si.base_unit,
+	sl.expiry_date
+FROM
+	stock_balance sb
+JOIN stock_item si ON si.stock_item_id = sb.stock_item_id
+LEFT JOIN stock_lot sl
+	ON sl.stock_item_id = sb.stock_item_id
+	AND sl.lot_number = sb.lot_number
+WHERE
+	sb.account = 'STOCK'
+	AND sb.stock_item_id = $1
+	AND sb.location = $2
+	AND sb.bin = $3
+	AND sb.quantity > 0
+	AND COALESCE(sl.status, 'Available') = 'Available'
+	AND (sl.expiry_date IS NULL OR sl.expiry_date >= CURRENT_DATE)
+ORDER BY
+	sl.expiry_date ASC NULLS LAST,
+	sb.last_timestamp ASC,
+	sb.lot_number
+	`
+
+	rows, err := exec.Query(ctx, query, stockItemID, location, bin)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	suggestions := []model.FEFOSuggestion{}
+	for rows.Next() {
+		var s model.FEFOSuggestion
+		err := rows.Scan(
+			&s.Location,
+			&s.Bin,
+			&s.LotNumber,
+			&s.Qty,
+			&s.Unit,
+			&s.ExpiryDate,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		suggestions = append(suggestions, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return suggestions, nil
+}
+
+// GetStockLotsToNotifyExpiry returns lots in stock that expire within the
+// number of days and have not yet been notified, locking them so that only
+// one notifier picks each up
+func (r *StockLotRepository) GetStockLotsToNotifyExpiry(
+	ctx context.Context,
+	exec db.PGExecutor,
+	withinDays int,
+) ([]model.StockLot, error) {
+
+	query := stockLotSelect + `
+WHERE
+	sl.expiry_date <= CURRENT_DATE + $1::INT
+	AND sl.expiry_notified_at IS NULL
+	AND sl.status <> 'Rejected'
+	AND COALESCE(sb.quantity, 0) > 0
+ORDER BY
+	sl.expiry_date,
+	si.stock_code,
+	sl.lot_number
+FOR UPDATE OF sl SKIP LOCKED
+	`
+
+	rows, err := exec.Query(ctx, query, withinDays)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stockLots := []model.StockLot{}
+	for rows.Next() {
+		sl, err := scanStockLot(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		stockLots = append(stockLots, sl)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return stockLots, nil
+}
+
+func (r *StockLotRepository) SetStockLotsExpiryNotified(
+	ctx context.Context,
+	exec db.PGExecutor,
+	stockLotIDs []int,
+) error {
+
+	query := `
+UPDATE
+	stock_lot
+SET
+	expiry_notified_at = NOW()
+WHERE
+	stock_lot_id = ANY($1)
+	`
+
+	_, err := exec.Exec(ctx, query, stockLotIDs)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
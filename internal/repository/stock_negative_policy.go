@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"app/internal/model"
+	"app/pkg/db"
+	"context"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type NegativeStockPolicyRepository struct{}
+
+func NewNegativeStockPolicyRepository() *NegativeStockPolicyRepository {
+	return &NegativeStockPolicyRepository{}
+}
+
+func (r *NegativeStockPolicyRepository) GetNegativeStockPolicies(
+	ctx context.Context,
+	exec db.PGExecutor,
+) ([]model.NegativeStockPolicy, error) {
+
+	query := `
+SELECT
+	p.stock_negative_policy_id,
+	p.stock_item_id,
+	si.stock_code,
+	p.location,
+	p.action,
+	u.username,
+	p.updated_at
+FROM
+	stock_negative_policy p
+LEFT JOIN stock_item si ON si.stock_item_id = p.stock_item_id
+LEFT JOIN app_user u ON u.user_id = p.updated_by
+ORDER BY
+	(p.stock_item_id IS NOT NULL), (p.location IS NOT NULL), si.stock_code, p.location
+	`
+
+	rows, err := exec.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	policies := []model.NegativeStockPolicy{}
+	for rows.Next() {
+		var p model.NegativeStockPolicy
+		err := rows.Scan(
+			&p.NegativeStockPolicyID,
+			&p.StockItemID,
+			&p.StockCode,
+			&p.Location,
+			&p.Action,
+			&p.UpdatedByUsername,
+			&p.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		policies = append(policies, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return policies, nil
+}
+
+// GetEffectiveNegativeStockAction returns the action of the most specific
+// policy matching the stock item and location. Precedence is stock item and
+// location, then stock item, then location, then the global policy.
+func (r *NegativeStockPolicyRepository) GetEffectiveNegativeStockAction(
+	ctx context.Context,
+	exec db.PGExecutor,
+	stockItemID int,
+	location string,
+) (model.NegativeStockAction, error) {
+
+	query := `
+SELECT
+	action
+FROM
+	stock_negative_policy
+WHERE
+	(stock_item_id IS NULL OR stock_item_id = $1)
+	AND
+	(location IS NULL OR location = $2)
+ORDER BY
+	(stock_item_id IS NOT NULL) DESC,
+	(location IS NOT NULL) DESC
+LIMIT 1
+	`
+
+	var action model.NegativeStockAction
+	err := exec.QueryRow(ctx, query, stockItemID, location).Scan(&action)
+	if err == pgx.ErrNoRows {
+		return model.AllowNegativeStockAction, nil
+	} else if err != nil {
+		return "", err
+	}
+
+	return action, nil
+}
+
+func (r *NegativeStockPolicyRepository) UpsertNegativeStockPolicy(
+	ctx context.Context,
+	exec db.PGExecutor,
+	input *model.PostNegativeStockPolicy,
+	userID int,
+) error {
+
+	query := `
+INSERT INTO stock_negative_policy (
+	stock_item_id,
+	location,
+	action,
+	updated_by
+)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT ((COALESCE(stock_item_id, 0)), (COALESCE(location, '')))
+DO UPDATE SET
+	action = EXCLUDED.action,
+	updated_by = EXCLUDED.updated_by,
+	updated_at = NOW()
+	`
+
+	_, err := exec.Exec(ctx, query, input.StockItemID, input.Location, input.Action, userID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DeleteNegativeStockPolicy deletes a scoped policy. The global policy cannot
+// be deleted, only changed.
+func (r *NegativeStockPolicyRepository) DeleteNegativeStockPolicy(
+	ctx context.Context,
+	exec db.PGExecutor,
+	negativeStockPolicyID int,
+) error {
+
+	query := `
+DELETE FROM
+	stock_negative_policy
+WHERE
+	stock_negative_policy_id = $1
+	AND NOT (stock_item_id IS NULL AND location IS NULL)
+	`
+
+	_, err := exec.Exec(ctx, query, negativeStockPolicyID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,237 @@
+package repository
+
+import (
+	"app/internal/model"
+	"app/pkg/db"
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type StockPeriodRepository struct{}
+
+func NewStockPeriodRepository() *StockPeriodRepository {
+	return &StockPeriodRepository{}
+}
+
+// SetStockPeriodClosed closes or reopens a period and records the change
+func (r *StockPeriodRepository) SetStockPeriodClosed(
+	ctx context.Context,
+	exec db.PGExecutor,
+	period time.Time,
+	isClosed bool,
+	reason string,
+	userID int,
+) error {
+
+	query := `
+INSERT INTO stock_period (
+	period,
+	is_closed,
+	updated_by
+)
+VALUES ($1, $2, $3)
+ON CONFLICT (period) DO UPDATE SET
+	is_closed = EXCLUDED.is_closed,
+	updated_by = EXCLUDED.updated_by,
+	updated_at = NOW()
+	`
+
+	_, err := exec.Exec(ctx, query, period, isClosed, userID)
+	if err != nil {
+		return err
+	}
+
+	query = `
+INSERT INTO stock_period_change (
+	period,
+	is_closed,
+	reason,
+	change_by
+)
+VALUES ($1, $2, $3, $4)
+	`
+
+	_, err = exec.Exec(ctx, query, period, isClosed, reason, userID)
+	return err
+}
+
+var stockPeriodSelect = `
+SELECT
+	sp.period,
+	sp.is_closed,
+	u.username,
+	sp.updated_at
+FROM
+	stock_period sp
+LEFT JOIN app_user u ON u.user_id = sp.updated_by
+`
+
+func scanStockPeriod(row pgx.Row) (model.StockPeriod, error) {
+	var p model.StockPeriod
+	err := row.Scan(
+		&p.Period,
+		&p.IsClosed,
+		&p.UpdatedByUsername,
+		&p.UpdatedAt,
+	)
+	return p, err
+}
+
+func (r *StockPeriodRepository) GetStockPeriod(
+	ctx context.Context,
+	exec db.PGExecutor,
+	period time.Time,
+) (*model.StockPeriod, error) {
+
+	query := stockPeriodSelect + `
+WHERE
+	sp.period = $1
+	`
+
+	p, err := scanStockPeriod(exec.QueryRow(ctx, query, period))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &p, nil
+}
+
+// GetStockPeriods returns the periods that have been closed, even if since
+// reopened, latest first
+func (r *StockPeriodRepository) GetStockPeriods(
+	ctx context.Context,
+	exec db.PGExecutor,
+) ([]model.StockPeriod, error) {
+
+	query := stockPeriodSelect + `
+ORDER BY
+	sp.period DESC
+	`
+
+	rows, err := exec.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	periods := []model.StockPeriod{}
+	for rows.Next() {
+		p, err := scanStockPeriod(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		periods = append(periods, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return periods, nil
+}
+
+// LockStockPeriods takes a lock on the periods until the end of the
+// transaction. Back-dated postings take a shared lock and closing or
+// reopening a period an exclusive one, so no period can be closed while a
+// back-dated posting that would change it is in progress.
+func (r *StockPeriodRepository) LockStockPeriods(
+	ctx context.Context,
+	exec db.PGExecutor,
+	shared bool,
+) error {
+
+	lock := "pg_advisory_xact_lock"
+	if shared {
+		lock = "pg_advisory_xact_lock_shared"
+	}
+
+	query := `SELECT ` + lock + `(hashtext('stock_period'), 0)`
+
+	_, err := exec.Exec(ctx, query)
+	return err
+}
+
+// GetLatestClosedStockPeriod returns the latest closed period, or nil if
+// none is closed. Lock the periods first, see LockStockPeriods.
+func (r *StockPeriodRepository) GetLatestClosedStockPeriod(
+	ctx context.Context,
+	exec db.PGExecutor,
+) (*time.Time, error) {
+
+	query := `
+SELECT
+	MAX(period)
+FROM
+	stock_period
+WHERE
+	is_closed
+	`
+
+	var period *time.Time
+	err := exec.QueryRow(ctx, query).Scan(&period)
+	if err != nil {
+		return nil, err
+	}
+
+	return period, nil
+}
+
+// GetStockPeriodChanges returns every close and reopen of a period, latest
+// first
+func (r *StockPeriodRepository) GetStockPeriodChanges(
+	ctx context.Context,
+	exec db.PGExecutor,
+) ([]model.StockPeriodChange, error) {
+
+	query := `
+SELECT
+	spc.stock_period_change_id,
+	spc.period,
+	spc.is_closed,
+	spc.reason,
+	u.username,
+	spc.changed_at
+FROM
+	stock_period_change spc
+LEFT JOIN app_user u ON u.user_id = spc.change_by
+ORDER BY
+	spc.changed_at DESC,
+	spc.stock_period_change_id DESC
+	`
+
+	rows, err := exec.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	changes := []model.StockPeriodChange{}
+	for rows.Next() {
+		var c model.StockPeriodChange
+		err := rows.Scan(
+			&c.StockPeriodChangeID,
+			&c.Period,
+			&c.IsClosed,
+			&c.Reason,
+			&c.ChangeByUsername,
+			&c.ChangedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		changes = append(changes, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return changes, nil
+}
@@ -0,0 +1,350 @@
+package repository
+
+import (
+	"app/internal/model"
+	"app/pkg/db"
+	"context"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type StockReorderRepository struct{}
+
+func NewStockReorderRepository() *StockReorderRepository {
+	return &StockReorderRepository{}
+}
+
+func (r *StockReorderRepository) GetStockReorderPolicies(
+	ctx context.Context,
+	exec db.PGExecutor,
+) ([]model.StockReorderPolicy, error) {
+
+	query := `
+SELECT
+	p.stock_reorder_policy_id,
+	p.stock_item_id,
+	si.stock_code,
+	p.location,
+	p.min_quantity,
+	p.reorder_point,
+	p.max_quantity,
+	si.base_unit,
+	p.notify_team_id,
+	t.team_name,
+	p.is_below_reorder_point,
+	u.username,
+	p.updated_at
+FROM
+	stock_reorder_policy p
+JOIN stock_item si ON si.stock_item_id = p.stock_item_id
+LEFT JOIN team t ON t.team_id = p.notify_team_id
+LEFT JOIN app_user u ON u.user_id = p.updated_by
+ORDER BY
+	si.stock_code, (p.location IS NOT NULL), p.location
+	`
+
+	rows, err := exec.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	policies := []model.StockReorderPolicy{}
+	for rows.Next() {
+		var p model.StockReorderPolicy
+		err := rows.Scan(
+			&p.StockReorderPolicyID,
+			&p.StockItemID,
+			&p.StockCode,
+			&p.Location,
+			&p.MinQty,
+			&p.ReorderPoint,
+			&p.MaxQty,
+			&p.Unit,
+			&p.NotifyTeamID,
+			&p.NotifyTeamName,
+			&p.IsBelowReorderPoint,
+			&p.UpdatedByUsername,
+			&p.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		policies = append(policies, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return policies, nil
+}
+
+func (r *StockReorderRepository) UpsertStockReorderPolicy(
+	ctx context.Context,
+	exec db.PGExecutor,
+	input *model.PostStockReorderPolicy,
+	userID int,
+) error {
+
+	query := `
+INSERT INTO stock_reorder_policy (
+	stock_item_id,
+	location,
+	min_quantity,
+	reorder_point,
+	max_quantity,
+	notify_team_id,
+	updated_by
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (stock_item_id, (COALESCE(location, '')))
+DO UPDATE SET
+	min_quantity = EXCLUDED.min_quantity,
+	reorder_point = EXCLUDED.reorder_point,
+	max_quantity = EXCLUDED.max_quantity,
+	notify_team_id = EXCLUDED.notify_team_id,
+	updated_by = EXCLUDED.updated_by,
+	updated_at = NOW()
+	`
+
+	_, err := exec.Exec(ctx, query,
+		input.StockItemID,
+		input.Location,
+		input.MinQty,
+		input.ReorderPoint,
+		input.MaxQty,
+		input.NotifyTeamID,
+		userID,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *StockReorderRepository) DeleteStockReorderPolicy(
+	ctx context.Context,
+	exec db.PGExecutor,
+	stockReorderPolicyID int,
+) error {
+
+	query := `
+DELETE FROM
+	stock_reorder_policy
+WHERE
+	stock_reorder_policy_id = $1
+	`
+
+	_, err := exec.Exec(ctx, query, stockReorderPolicyID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// stockReplenishmentLevel works out the stock available under each reorder
+// policy of the stock items in $1, or of all stock items when $1 is NULL.
+// Purchase orders are not for a location so all that is on order counts
+// towards every policy of the stock item, as do reservations without a
+// location.
+var stockReplenishmentLevel = `
+SELECT
+	p.stock_reorder_policy_id,
+	COALESCE((
+		SELECT SUM(b.quantity)
+		FROM stock_balance b
+		WHERE b.account = 'STOCK'
+			AND b.stock_item_id = p.stock_item_id
+			AND (p.location IS NULL OR b.location = p.location)
+	), 0) AS on_hand,
+	COALESCE((
+		SELECT SUM(r.quantity)
+		FROM stock_reservation r
+		WHERE r.status = 'Open'
+			AND r.stock_item_id = p.stock_item_id
+			AND (p.location IS NULL OR r.location = '' OR r.location = p.location)
+	), 0) AS reserved,
+	COALESCE((
+		SELECT SUM(GREATEST(pol.quantity - pol.received_quantity, 0))
+		FROM purchase_order_line pol
+		JOIN purchase_order po ON po.purchase_order_id = pol.purchase_order_id
+		WHERE po.status = 'Open'
+			AND pol.status = 'Open'
+			AND pol.stock_item_id = p.stock_item_id
+	), 0) AS on_order
+FROM
+	stock_reorder_policy p
+WHERE
+	$1::INT[] IS NULL OR p.stock_item_id = ANY($1)
+`
+
+func scanStockReplenishment(row pgx.Row) (model.StockReplenishment, error) {
+	var sr model.StockReplenishment
+	err := row.Scan(
+		&sr.StockReorderPolicyID,
+		&sr.StockItemID,
+		&sr.StockCode,
+		&sr.Description,
+		&sr.Location,
+		&sr.OnHand,
+		&sr.Reserved,
+		&sr.OnOrder,
+		&sr.MinQty,
+		&sr.ReorderPoint,
+		&sr.MaxQty,
+		&sr.Unit,
+		&sr.NotifyTeamID,
+	)
+	return sr, err
+}
+
+// GetStockReplenishments returns the reorder policies with stock available at
+// or below the reorder point, furthest below first
+func (r *StockReorderRepository) GetStockReplenishments(
+	ctx context.Context,
+	exec db.PGExecutor,
+) ([]model.StockReplenishment, error) {
+
+	query := `
+WITH level AS (
+` + stockReplenishmentLevel + `
+)
+
+SELECT
+	p.stock_reorder_policy_id,
+	p.stock_item_id,
+	si.stock_code,
+	si.description,
+	p.location,
+	l.on_hand,
+	l.reserved,
+	l.on_order,
+	p.min_quantity,
+	p.reorder_point,
+	p.max_quantity,
+	si.base_unit,
+	p.notify_team_id
+FROM
+	stock_reorder_policy p
+JOIN level l ON l.stock_reorder_policy_id = p.stock_reorder_policy_id
+JOIN stock_item si ON si.stock_item_id = p.stock_item_id
+WHERE
+	l.on_hand - l.reserved <= p.reorder_point
+ORDER BY
+	(l.on_hand - l.reserved - p.reorder_point) / NULLIF(p.max_quantity - p.reorder_point, 0),
+	si.stock_code,
+	p.location
+	`
+
+	rows, err := exec.Query(ctx, query, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	replenishments := []model.StockReplenishment{}
+	for rows.Next() {
+		sr, err := scanStockReplenishment(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		replenishments = append(replenishments, sr)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return replenishments, nil
+}
+
+// UpdateStockReorderStates records whether stock available under each reorder
+// policy of the stock items is at or below the reorder point. All policies
+// are updated when stockItemIDs is nil. The policies that have just crossed
+// below the reorder point are returned.
+func (r *StockReorderRepository) UpdateStockReorderStates(
+	ctx context.Context,
+	tx pgx.Tx,
+	stockItemIDs []int,
+) ([]model.StockReplenishment, error) {
+
+	query := `
+WITH level AS (
+` + stockReplenishmentLevel + `
+),
+
+updated AS (
+	UPDATE
+		stock_reorder_policy p
+	SET
+		is_below_reorder_point = l.on_hand - l.reserved <= p.reorder_point
+	FROM
+		level l
+	WHERE
+		l.stock_reorder_policy_id = p.stock_reorder_policy_id
+		AND p.is_below_reorder_point <> (l.on_hand - l.reserved <= p.reorder_point)
+	RETURNING
+		p.stock_reorder_policy_id,
+		p.stock_item_id,
+		p.location,
+		l.on_hand,
+		l.reserved,
+		l.on_order,
+		p.min_quantity,
+		p.reorder_point,
+		p.max_quantity,
+		p.notify_team_id,
+		p.is_below_reorder_point
+)
+
+SELECT
+	u.stock_reorder_policy_id,
+	u.stock_item_id,
+	si.stock_code,
+	si.description,
+	u.location,
+	u.on_hand,
+	u.reserved,
+	u.on_order,
+	u.min_quantity,
+	u.reorder_point,
+	u.max_quantity,
+	si.base_unit,
+	u.notify_team_id
+FROM
+	updated u
+JOIN stock_item si ON si.stock_item_id = u.stock_item_id
+WHERE
+	u.is_below_reorder_point
+ORDER BY
+	si.stock_code,
+	u.location
+	`
+
+	rows, err := tx.Query(ctx, query, stockItemIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	crossed := []model.StockReplenishment{}
+	for rows.Next() {
+		sr, err := scanStockReplenishment(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		crossed = append(crossed, sr)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return crossed, nil
+}
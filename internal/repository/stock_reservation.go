@@ -0,0 +1,512 @@
+package repository
+
+import (
+	"app/internal/model"
+	"app/pkg/db"
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/shopspring/decimal"
+)
+
+type StockReservationRepository struct{}
+
+func NewStockReservationRepository() *StockReservationRepository {
+	return &StockReservationRepository{}
+}
+
+func (r *StockReservationRepository) CreateStockReservation(
+	ctx context.Context,
+	exec db.PGExecutor,
+	reservation *model.NewStockReservation,
+	userID int,
+) (int, error) {
+
+	query := `
+INSERT INTO stock_reservation (
+	stock_item_id,
+	location,
+	bin,
+	lot_number,
+	reserved_quantity,
+	quantity,
+	demand_reference,
+	note,
+	created_by
+)
+VALUES ($1, $2, $3, $4, $5, $5, $6, $7, $8)
+RETURNING stock_reservation_id
+	`
+
+	var stockReservationID int
+	err := exec.QueryRow(ctx, query,
+		reservation.StockItemID,
+		reservation.Location,
+		reservation.Bin,
+		reservation.LotNumber,
+		reservation.Qty,
+		reservation.DemandReference,
+		reservation.Note,
+		userID,
+	).Scan(&stockReservationID)
+	if err != nil {
+		return 0, err
+	}
+
+	return stockReservationID, nil
+}
+
+var stockReservationSelect = `
+SELECT
+	sr.stock_reservation_id,
+	sr.stock_item_id,
+	si.stock_code,
+	sr.location,
+	sr.bin,
+	sr.lot_number,
+	sr.reserved_quantity,
+	sr.quantity,
+	si.base_unit,
+	sr.demand_reference,
+	sr.note,
+	sr.status,
+	cu.username,
+	sr.created_at,
+	clu.username,
+	sr.closed_at
+FROM
+	stock_reservation sr
+JOIN stock_item si ON si.stock_item_id = sr.stock_item_id
+JOIN app_user cu ON cu.user_id = sr.created_by
+LEFT JOIN app_user clu ON clu.user_id = sr.closed_by
+`
+
+func scanStockReservation(row pgx.Row) (model.StockReservation, error) {
+	var sr model.StockReservation
+	err := row.Scan(
+		&sr.StockReservationID,
+		&sr.StockItemID,
+		&sr.StockCode,
+		&sr.Location,
+		&sr.Bin,
+		&sr.LotNumber,
+		&sr.ReservedQty,
+		&sr.Qty,
+		&sr.Unit,
+		&sr.DemandReference,
+		&sr.Note,
+		&sr.Status,
+		&sr.CreatedByUsername,
+		&sr.CreatedAt,
+		&sr.ClosedByUsername,
+		&sr.ClosedAt,
+	)
+	return sr, err
+}
+
+func (r *StockReservationRepository) GetStockReservation(
+	ctx context.Context,
+	exec db.PGExecutor,
+	stockReservationID int,
+) (*model.StockReservation, error) {
+
+	query := stockReservationSelect + `
+WHERE
+	sr.stock_reservation_id = $1
+	`
+
+	sr, err := scanStockReservation(exec.QueryRow(ctx, query, stockReservationID))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &sr, nil
+}
+
+// stockReservationsWhere filters reservations by GetStockReservationsQuery,
+// taking $1 to $3
+var stockReservationsWhere = `
+WHERE
+	($1 = '' OR si.stock_code = $1)
+	AND
+	($2 = '' OR sr.demand_reference = $2)
+	AND
+	($3 = '' OR sr.status = $3)
+`
+
+// GetStockReservations returns reservations most recently created first
+func (r *StockReservationRepository) GetStockReservations(
+	ctx context.Context,
+	exec db.PGExecutor,
+	q *model.GetStockReservationsQuery,
+) ([]model.StockReservation, error) {
+
+	limit := q.PageSize
+	if limit == 0 {
+		limit = 50
+	}
+	offset := 0
+	if q.Page > 0 {
+		offset = (q.Page - 1) * limit
+	}
+
+	query := stockReservationSelect + stockReservationsWhere + `
+ORDER BY
+	sr.created_at DESC,
+	sr.stock_reservation_id DESC
+LIMIT $4 OFFSET $5
+	`
+
+	rows, err := exec.Query(ctx, query,
+		q.StockCode,
+		q.DemandReference,
+		q.Status,
+		limit, offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reservations := []model.StockReservation{}
+	for rows.Next() {
+		sr, err := scanStockReservation(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		reservations = append(reservations, sr)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return reservations, nil
+}
+
+func (r *StockReservationRepository) GetStockReservationsCount(
+	ctx context.Context,
+	exec db.PGExecutor,
+	q *model.GetStockReservationsQuery,
+) (int, error) {
+
+	query := `
+SELECT
+	COUNT(*)
+FROM
+	stock_reservation sr
+JOIN stock_item si ON si.stock_item_id = sr.stock_item_id
+` + stockReservationsWhere
+
+	var count int
+	err := exec.QueryRow(ctx, query,
+		q.StockCode,
+		q.DemandReference,
+		q.Status,
+	).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// LockStockReservation locks a reservation for update and returns its
+// status, or nil if it does not exist
+func (r *StockReservationRepository) LockStockReservation(
+	ctx context.Context,
+	tx pgx.Tx,
+	stockReservationID int,
+) (*model.StockReservationStatus, error) {
+
+	query := `
+SELECT
+	status
+FROM
+	stock_reservation
+WHERE
+	stock_reservation_id = $1
+FOR UPDATE
+	`
+
+	var status model.StockReservationStatus
+	err := tx.QueryRow(ctx, query, stockReservationID).Scan(&status)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &status, nil
+}
+
+// UpdateStockReservationQuantity sets what is still reserved. Reservations
+// are fulfilled once nothing is left reserved.
+func (r *StockReservationRepository) UpdateStockReservationQuantity(
+	ctx context.Context,
+	exec db.PGExecutor,
+	stockReservationID int,
+	qty decimal.Decimal,
+	userID int,
+) error {
+
+	query := `
+UPDATE
+	stock_reservation
+SET
+	quantity = $2,
+	status = CASE WHEN $2::NUMERIC = 0 THEN 'Fulfilled' ELSE status END,
+	closed_by = CASE WHEN $2::NUMERIC = 0 THEN $3 ELSE closed_by END,
+	closed_at = CASE WHEN $2::NUMERIC = 0 THEN NOW() ELSE closed_at END
+WHERE
+	stock_reservation_id = $1
+	`
+
+	_, err := exec.Exec(ctx, query, stockReservationID, qty, userID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *StockReservationRepository) ReleaseStockReservation(
+	ctx context.Context,
+	exec db.PGExecutor,
+	stockReservationID int,
+	userID int,
+) error {
+
+	query := `
+UPDATE
+	stock_reservation
+SET
+	status = 'Released',
+	closed_by = $2,
+	closed_at = NOW()
+WHERE
+	stock_reservation_id = $1
+	`
+
+	_, err := exec.Exec(ctx, query, stockReservationID, userID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetOpenStockReservationsForDemand returns the open reservations of a demand
+// that cover a place, oldest first, locked for update
+func (r *StockReservationRepository) GetOpenStockReservationsForDemand(
+	ctx context.Context,
+	tx pgx.Tx,
+	stockItemID int,
+	location string,
+	bin string,
+	lotNumber string,
+	demandReference string,
+) ([]model.StockReservation, error) {
+
+	query := `
+SELECT
+	stock_reservation_id,
+	quantity
+FROM
+	stock_reservation
+WHERE
+	stock_item_id = $1
+	AND status = 'Open'
+	AND (location = '' OR location = $2)
+	AND (bin = '' OR bin = $3)
+	AND (lot_number = '' OR lot_number = $4)
+	AND demand_reference = $5
+ORDER BY
+	created_at,
+	stock_reservation_id
+FOR UPDATE
+	`
+
+	rows, err := tx.Query(ctx, query, stockItemID, location, bin, lotNumber, demandReference)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reservations := []model.StockReservation{}
+	for rows.Next() {
+		var sr model.StockReservation
+		err := rows.Scan(&sr.StockReservationID, &sr.Qty)
+		if err != nil {
+			return nil, err
+		}
+
+		reservations = append(reservations, sr)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return reservations, nil
+}
+
+// GetStockReservationShortfall checks every reservation scope that covers a
+// place, from the whole item down to the lot in a bin, and returns the first
+// with more reserved than is on hand. Nil is returned if there is none.
+func (r *StockReservationRepository) GetStockReservationShortfall(
+	ctx context.Context,
+	exec db.PGExecutor,
+	stockItemID int,
+	location string,
+	bin string,
+	lotNumber string,
+) (*model.StockReservationShortfall, error) {
+
+	query := `
+WITH scope AS (
+	SELECT DISTINCT
+		location,
+		bin,
+		lot_number
+	FROM
+		stock_reservation
+	WHERE
+		stock_item_id = $1
+		AND status = 'Open'
+		AND (location = '' OR location = $2)
+		AND (bin = '' OR bin = $3)
+		AND (lot_number = '' OR lot_number = $4)
+),
+
+scope_total AS (
+	SELECT
+		s.location,
+		s.bin,
+		s.lot_number,
+		COALESCE((
+			SELECT SUM(b.quantity)
+			FROM stock_balance b
+			WHERE b.account = 'STOCK'
+				AND b.stock_item_id = $1
+				AND (s.location = '' OR b.location = s.location)
+				AND (s.bin = '' OR b.bin = s.bin)
+				AND (s.lot_number = '' OR b.lot_number = s.lot_number)
+		), 0) AS on_hand,
+		(
+			SELECT SUM(r.quantity)
+			FROM stock_reservation r
+			WHERE r.stock_item_id = $1
+				AND r.status = 'Open'
+				AND (s.location = '' OR r.location = s.location)
+				AND (s.bin = '' OR r.bin = s.bin)
+				AND (s.lot_number = '' OR r.lot_number = s.lot_number)
+		) AS reserved
+	FROM
+		scope s
+)
+
+SELECT
+	si.stock_code,
+	st.location,
+	st.bin,
+	st.lot_number,
+	st.on_hand,
+	st.reserved,
+	si.base_unit
+FROM
+	scope_total st
+JOIN stock_item si ON si.stock_item_id = $1
+WHERE
+	st.reserved > st.on_hand
+ORDER BY
+	st.location,
+	st.bin,
+	st.lot_number
+LIMIT 1
+	`
+
+	var s model.StockReservationShortfall
+	err := exec.QueryRow(ctx, query, stockItemID, location, bin, lotNumber).Scan(
+		&s.StockCode,
+		&s.Location,
+		&s.Bin,
+		&s.LotNumber,
+		&s.OnHand,
+		&s.Reserved,
+		&s.Unit,
+	)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &s, nil
+}
+
+// GetStockAvailability returns the stock of an item in the STOCK account and
+// how much of it is reserved, or nil if the stock code does not exist
+func (r *StockReservationRepository) GetStockAvailability(
+	ctx context.Context,
+	exec db.PGExecutor,
+	stockCode string,
+) (*model.StockAvailability, error) {
+
+	query := `
+SELECT
+	si.stock_code,
+	COALESCE((
+		SELECT SUM(b.quantity)
+		FROM stock_balance b
+		WHERE b.account = 'STOCK' AND b.stock_item_id = si.stock_item_id
+	), 0),
+	COALESCE((
+		SELECT SUM(r.quantity)
+		FROM stock_reservation r
+		WHERE r.status = 'Open' AND r.stock_item_id = si.stock_item_id
+	), 0),
+	COALESCE((
+		SELECT SUM(GREATEST(pol.quantity - pol.received_quantity, 0))
+		FROM purchase_order_line pol
+		JOIN purchase_order po ON po.purchase_order_id = pol.purchase_order_id
+		WHERE po.status = 'Open'
+			AND pol.status = 'Open'
+			AND pol.stock_item_id = si.stock_item_id
+	), 0),
+	COALESCE((
+		SELECT SUM(b.quantity)
+		FROM stock_balance b
+		WHERE b.account = 'QUARANTINE' AND b.stock_item_id = si.stock_item_id
+	), 0),
+	si.base_unit
+FROM
+	stock_item si
+WHERE
+	si.stock_code = $1
+	`
+
+	var a model.StockAvailability
+	err := exec.QueryRow(ctx, query, stockCode).Scan(
+		&a.StockCode,
+		&a.OnHand,
+		&a.Reserved,
+		&a.OnOrder,
+		&a.Quarantined,
+		&a.Unit,
+	)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &a, nil
+}
@@ -0,0 +1,451 @@
+package repository
+
+import (
+	"app/internal/model"
+	"app/pkg/db"
+	"context"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type StockScrapRepository struct{}
+
+func NewStockScrapRepository() *StockScrapRepository {
+	return &StockScrapRepository{}
+}
+
+func (r *StockScrapRepository) CreateScrapReason(
+	ctx context.Context,
+	exec db.PGExecutor,
+	reason *model.NewScrapReason,
+	userID int,
+) (int, error) {
+
+	query := `
+INSERT INTO scrap_reason (
+	code,
+	description,
+	created_by,
+	updated_by
+)
+VALUES ($1, $2, $3, $3)
+RETURNING scrap_reason_id
+	`
+
+	var scrapReasonID int
+	err := exec.QueryRow(ctx, query,
+		reason.Code,
+		reason.Description,
+		userID,
+	).Scan(&scrapReasonID)
+	if err != nil {
+		return 0, err
+	}
+
+	return scrapReasonID, nil
+}
+
+func (r *StockScrapRepository) UpdateScrapReason(
+	ctx context.Context,
+	exec db.PGExecutor,
+	scrapReasonID int,
+	update *model.ScrapReasonUpdate,
+	userID int,
+) error {
+
+	query := `
+UPDATE
+	scrap_reason
+SET
+	description = $2,
+	is_archived = $3,
+	updated_by = $4,
+	updated_at = NOW()
+WHERE
+	scrap_reason_id = $1
+	`
+
+	_, err := exec.Exec(ctx, query,
+		scrapReasonID,
+		update.Description,
+		update.IsArchived,
+		userID,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+var scrapReasonSelect = `
+SELECT
+	sr.scrap_reason_id,
+	sr.code,
+	sr.description,
+	sr.is_archived,
+	cu.username,
+	sr.created_at,
+	uu.username,
+	sr.updated_at
+FROM
+	scrap_reason sr
+LEFT JOIN app_user cu ON cu.user_id = sr.created_by
+LEFT JOIN app_user uu ON uu.user_id = sr.updated_by
+`
+
+func scanScrapReason(row pgx.Row) (model.ScrapReason, error) {
+	var sr model.ScrapReason
+	err := row.Scan(
+		&sr.ScrapReasonID,
+		&sr.Code,
+		&sr.Description,
+		&sr.IsArchived,
+		&sr.CreatedByUsername,
+		&sr.CreatedAt,
+		&sr.UpdatedByUsername,
+		&sr.UpdatedAt,
+	)
+	return sr, err
+}
+
+func (r *StockScrapRepository) GetScrapReason(
+	ctx context.Context,
+	exec db.PGExecutor,
+	scrapReasonID int,
+) (*model.ScrapReason, error) {
+
+	query := scrapReasonSelect + `
+WHERE
+	sr.scrap_reason_id = $1
+	`
+
+	sr, err := scanScrapReason(exec.QueryRow(ctx, query, scrapReasonID))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &sr, nil
+}
+
+func (r *StockScrapRepository) GetScrapReasonByCode(
+	ctx context.Context,
+	exec db.PGExecutor,
+	code string,
+) (*model.ScrapReason, error) {
+
+	query := scrapReasonSelect + `
+WHERE
+	sr.code = $1
+	`
+
+	sr, err := scanScrapReason(exec.QueryRow(ctx, query, code))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &sr, nil
+}
+
+// GetScrapReasons returns the scrap reasons ordered by code, including the
+// archived ones if asked
+func (r *StockScrapRepository) GetScrapReasons(
+	ctx context.Context,
+	exec db.PGExecutor,
+	showArchived bool,
+) ([]model.ScrapReason, error) {
+
+	query := scrapReasonSelect + `
+WHERE
+	$1 OR NOT sr.is_archived
+ORDER BY
+	sr.code
+	`
+
+	rows, err := exec.Query(ctx, query, showArchived)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reasons := []model.ScrapReason{}
+	for rows.Next() {
+		sr, err := scanScrapReason(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		reasons = append(reasons, sr)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return reasons, nil
+}
+
+// wasteEntriesWhere matches the SCRAP account entries of the waste report,
+// taking $1 to $5
+var wasteEntriesWhere = `
+WHERE
+	ste.account = 'SCRAP'
+	AND
+	st.timestamp >= $1::date
+	AND
+	st.timestamp < $2::date + 1
+	AND
+	($3 = '' OR si.stock_code = $3)
+	AND
+	($4 = '' OR ste.location = $4)
+	AND
+	($5 = 0 OR st.scrap_reason_id = $5)
+`
+
+var wasteEntriesFrom = `
+FROM
+	stock_transaction_entry ste
+JOIN stock_transaction st ON st.stock_transaction_id = ste.stock_transaction_id
+JOIN stock_item si ON si.stock_item_id = st.stock_item_id
+LEFT JOIN scrap_reason sr ON sr.scrap_reason_id = st.scrap_reason_id
+`
+
+// wasteLinesSelect groups waste by stock item, reason and location, leaving
+// out lines that reversals have cancelled. It takes $1 to $5.
+var wasteLinesSelect = `
+SELECT
+	si.stock_code,
+	si.description,
+	sr.code,
+	ste.location,
+	SUM(ste.quantity) AS quantity,
+	si.base_unit,
+	SUM(ste.quantity * COALESCE(st.unit_cost, 0)) AS value,
+	COUNT(*) FILTER (WHERE st.reverses_stock_transaction_id IS NULL)
+		- COUNT(*) FILTER (WHERE st.reverses_stock_transaction_id IS NOT NULL) AS posting_count
+` + wasteEntriesFrom + wasteEntriesWhere + `
+GROUP BY
+	si.stock_item_id,
+	sr.scrap_reason_id,
+	ste.location
+HAVING
+	SUM(ste.quantity) <> 0
+	OR SUM(ste.quantity * COALESCE(st.unit_cost, 0)) <> 0
+`
+
+func wasteReportArgs(input *model.GetWasteReportInput) []any {
+	return []any{
+		input.FromDate,
+		input.ToDate,
+		input.StockCode,
+		input.Location,
+		input.ScrapReasonID,
+	}
+}
+
+func (r *StockScrapRepository) GetWasteReportLines(
+	ctx context.Context,
+	exec db.PGExecutor,
+	input *model.GetWasteReportInput,
+) ([]model.WasteReportLine, error) {
+
+	limit := input.PageSize
+	if limit == 0 {
+		limit = 50
+	}
+	offset := 0
+	if input.Page > 0 {
+		offset = (input.Page - 1) * limit
+	}
+
+	query := wasteLinesSelect + `
+ORDER BY
+	value DESC,
+	si.stock_code,
+	sr.code,
+	ste.location
+LIMIT $6 OFFSET $7
+	`
+
+	rows, err := exec.Query(ctx, query, append(wasteReportArgs(input), limit, offset)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lines := []model.WasteReportLine{}
+	for rows.Next() {
+		var l model.WasteReportLine
+		err := rows.Scan(
+			&l.StockCode,
+			&l.Description,
+			&l.ScrapReasonCode,
+			&l.Location,
+			&l.Qty,
+			&l.Unit,
+			&l.Value,
+			&l.PostingCount,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		lines = append(lines, l)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return lines, nil
+}
+
+func (r *StockScrapRepository) GetWasteReportSummary(
+	ctx context.Context,
+	exec db.PGExecutor,
+	input *model.GetWasteReportInput,
+) (model.WasteReportSummary, error) {
+
+	query := `
+SELECT
+	COUNT(*),
+	COALESCE(SUM(value), 0),
+	COALESCE(SUM(posting_count), 0)
+FROM (
+` + wasteLinesSelect + `
+) lines
+	`
+
+	var s model.WasteReportSummary
+	err := exec.QueryRow(ctx, query, wasteReportArgs(input)...).Scan(
+		&s.Count,
+		&s.Value,
+		&s.PostingCount,
+	)
+	if err != nil {
+		return s, err
+	}
+
+	return s, nil
+}
+
+// GetWasteTrend returns the waste in each period of the report that had any,
+// oldest first
+func (r *StockScrapRepository) GetWasteTrend(
+	ctx context.Context,
+	exec db.PGExecutor,
+	input *model.GetWasteReportInput,
+) ([]model.WasteTrendPeriod, error) {
+
+	query := `
+SELECT
+	date_trunc($6, st.timestamp) AS period_start,
+	CASE WHEN $3 = '' THEN NULL ELSE SUM(ste.quantity) END,
+	SUM(ste.quantity * COALESCE(st.unit_cost, 0)),
+	COUNT(*) FILTER (WHERE st.reverses_stock_transaction_id IS NULL)
+		- COUNT(*) FILTER (WHERE st.reverses_stock_transaction_id IS NOT NULL)
+` + wasteEntriesFrom + wasteEntriesWhere + `
+GROUP BY
+	period_start
+ORDER BY
+	period_start
+	`
+
+	rows, err := exec.Query(ctx, query,
+		append(wasteReportArgs(input), strings.ToLower(string(input.TrendPeriod)))...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	trend := []model.WasteTrendPeriod{}
+	for rows.Next() {
+		var p model.WasteTrendPeriod
+		err := rows.Scan(
+			&p.PeriodStart,
+			&p.Qty,
+			&p.Value,
+			&p.PostingCount,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		trend = append(trend, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return trend, nil
+}
+
+// GetWasteTopOffenders returns the stock items with the most waste by value,
+// most first
+func (r *StockScrapRepository) GetWasteTopOffenders(
+	ctx context.Context,
+	exec db.PGExecutor,
+	input *model.GetWasteReportInput,
+	limit int,
+) ([]model.WasteOffender, error) {
+
+	query := `
+SELECT
+	si.stock_code,
+	si.description,
+	SUM(ste.quantity) AS quantity,
+	si.base_unit,
+	SUM(ste.quantity * COALESCE(st.unit_cost, 0)) AS value,
+	COUNT(*) FILTER (WHERE st.reverses_stock_transaction_id IS NULL)
+		- COUNT(*) FILTER (WHERE st.reverses_stock_transaction_id IS NOT NULL)
+` + wasteEntriesFrom + wasteEntriesWhere + `
+GROUP BY
+	si.stock_item_id
+HAVING
+	SUM(ste.quantity) > 0
+ORDER BY
+	value DESC,
+	quantity DESC,
+	si.stock_code
+LIMIT $6
+	`
+
+	rows, err := exec.Query(ctx, query, append(wasteReportArgs(input), limit)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	offenders := []model.WasteOffender{}
+	for rows.Next() {
+		var o model.WasteOffender
+		err := rows.Scan(
+			&o.StockCode,
+			&o.Description,
+			&o.Qty,
+			&o.Unit,
+			&o.Value,
+			&o.PostingCount,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		offenders = append(offenders, o)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return offenders, nil
+}
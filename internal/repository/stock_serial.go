@@ -0,0 +1,386 @@
+package repository
+
+import (
+	"app/internal/model"
+	"app/pkg/db"
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type StockSerialRepository struct{}
+
+func NewStockSerialRepository() *StockSerialRepository {
+	return &StockSerialRepository{}
+}
+
+// CreateStockSerials creates any of the serials that do not yet exist for the
+// stock item
+func (r *StockSerialRepository) CreateStockSerials(
+	ctx context.Context,
+	exec db.PGExecutor,
+	stockItemID int,
+	serialNumbers []string,
+) error {
+
+	query := `
+INSERT INTO stock_serial (
+	stock_item_id,
+	serial_number
+)
+SELECT DISTINCT
+	$1::INT,
+	serial_number
+FROM
+	UNNEST(COALESCE($2::TEXT[], '{}')) AS serial_number
+ON CONFLICT (stock_item_id, serial_number) DO NOTHING
+	`
+
+	_, err := exec.Exec(ctx, query, stockItemID, serialNumbers)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetStockSerialPositions returns where each of the serials of the stock item
+// is, keyed by serial number. Serials that have not been posted are left
+// out. The serials are locked for the rest of the transaction so that
+// concurrent postings cannot move the same serial twice.
+func (r *StockSerialRepository) GetStockSerialPositions(
+	ctx context.Context,
+	tx pgx.Tx,
+	stockItemID int,
+	serialNumbers []string,
+) (map[string]model.StockSerialPosition, error) {
+
+	query := `
+WITH locked_serial AS (
+	SELECT
+		stock_serial_id,
+		serial_number
+	FROM
+		stock_serial
+	WHERE
+		stock_item_id = $1
+		AND serial_number = ANY(COALESCE($2::TEXT[], '{}'))
+	ORDER BY
+		stock_serial_id
+	FOR UPDATE
+)
+
+SELECT DISTINCT ON (ls.serial_number)
+	ls.serial_number,
+	ste.account,
+	ste.location,
+	ste.bin,
+	ste.lot_number,
+	st.timestamp
+FROM
+	locked_serial ls
+JOIN stock_transaction_serial sts ON sts.stock_serial_id = ls.stock_serial_id
+JOIN stock_transaction st ON st.stock_transaction_id = sts.stock_transaction_id
+JOIN stock_transaction_entry ste ON ste.stock_transaction_id = st.stock_transaction_id
+	AND ste.quantity > 0
+ORDER BY
+	ls.serial_number,
+	st.timestamp DESC,
+	st.stock_transaction_id DESC
+	`
+
+	rows, err := tx.Query(ctx, query, stockItemID, serialNumbers)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	positions := map[string]model.StockSerialPosition{}
+	for rows.Next() {
+		var serialNumber string
+		var p model.StockSerialPosition
+		err := rows.Scan(
+			&serialNumber,
+			&p.Account,
+			&p.Location,
+			&p.Bin,
+			&p.LotNumber,
+			&p.Timestamp,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		positions[serialNumber] = p
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return positions, nil
+}
+
+// stockSerialSelect selects serials with where each of them currently is
+var stockSerialSelect = `
+SELECT
+	ss.stock_serial_id,
+	ss.stock_item_id,
+	si.stock_code,
+	ss.serial_number,
+	ss.created_at,
+	pos.account,
+	pos.location,
+	pos.bin,
+	pos.lot_number,
+	pos.timestamp
+FROM
+	stock_serial ss
+JOIN stock_item si ON si.stock_item_id = ss.stock_item_id
+LEFT JOIN LATERAL (
+	SELECT
+		ste.account,
+		ste.location,
+		ste.bin,
+		ste.lot_number,
+		st.timestamp
+	FROM
+		stock_transaction_serial sts
+	JOIN stock_transaction st ON st.stock_transaction_id = sts.stock_transaction_id
+	JOIN stock_transaction_entry ste ON ste.stock_transaction_id = st.stock_transaction_id
+		AND ste.quantity > 0
+	WHERE
+		sts.stock_serial_id = ss.stock_serial_id
+	ORDER BY
+		st.timestamp DESC,
+		st.stock_transaction_id DESC
+	LIMIT 1
+) pos ON TRUE
+`
+
+func scanStockSerial(row pgx.Row) (model.StockSerial, error) {
+	var s model.StockSerial
+	var account *model.StockAccount
+	var location, bin, lotNumber *string
+	var timestamp *time.Time
+	err := row.Scan(
+		&s.StockSerialID,
+		&s.StockItemID,
+		&s.StockCode,
+		&s.SerialNumber,
+		&s.CreatedAt,
+		&account,
+		&location,
+		&bin,
+		&lotNumber,
+		&timestamp,
+	)
+	if err != nil {
+		return s, err
+	}
+
+	if account != nil {
+		s.Position = &model.StockSerialPosition{
+			Account:   *account,
+			Location:  *location,
+			Bin:       *bin,
+			LotNumber: *lotNumber,
+			Timestamp: *timestamp,
+		}
+	}
+
+	return s, nil
+}
+
+func (r *StockSerialRepository) GetStockSerial(
+	ctx context.Context,
+	exec db.PGExecutor,
+	stockSerialID int,
+) (*model.StockSerial, error) {
+
+	query := stockSerialSelect + `
+WHERE
+	ss.stock_serial_id = $1
+	`
+
+	s, err := scanStockSerial(exec.QueryRow(ctx, query, stockSerialID))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &s, nil
+}
+
+// stockSerialsWhere filters serials by GetStockSerialsQuery, taking $1 to $4
+var stockSerialsWhere = `
+WHERE
+	($1 = '' OR si.stock_code = $1)
+	AND
+	($2 = '' OR ss.serial_number = $2)
+	AND
+	($3 = '' OR pos.account = $3)
+	AND
+	($4 = '' OR pos.location = $4)
+`
+
+// GetStockSerials returns serials most recently moved first
+func (r *StockSerialRepository) GetStockSerials(
+	ctx context.Context,
+	exec db.PGExecutor,
+	q *model.GetStockSerialsQuery,
+) ([]model.StockSerial, error) {
+
+	limit := q.PageSize
+	if limit == 0 {
+		limit = 50
+	}
+	offset := 0
+	if q.Page > 0 {
+		offset = (q.Page - 1) * limit
+	}
+
+	query := stockSerialSelect + stockSerialsWhere + `
+ORDER BY
+	pos.timestamp DESC NULLS LAST,
+	si.stock_code,
+	ss.serial_number
+LIMIT $5 OFFSET $6
+	`
+
+	rows, err := exec.Query(ctx, query,
+		q.StockCode,
+		q.SerialNumber,
+		q.Account,
+		q.Location,
+		limit, offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stockSerials := []model.StockSerial{}
+	for rows.Next() {
+		s, err := scanStockSerial(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		stockSerials = append(stockSerials, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return stockSerials, nil
+}
+
+func (r *StockSerialRepository) GetStockSerialsCount(
+	ctx context.Context,
+	exec db.PGExecutor,
+	q *model.GetStockSerialsQuery,
+) (int, error) {
+
+	query := `
+SELECT
+	COUNT(*)
+FROM (
+` + stockSerialSelect + stockSerialsWhere + `
+) serials
+	`
+
+	var count int
+	err := exec.QueryRow(ctx, query,
+		q.StockCode,
+		q.SerialNumber,
+		q.Account,
+		q.Location,
+	).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// GetStockSerialMovements returns the transactions that moved a serial, most
+// recent first. Each moved the serial from its negative entry to its positive
+// entry.
+func (r *StockSerialRepository) GetStockSerialMovements(
+	ctx context.Context,
+	exec db.PGExecutor,
+	stockSerialID int,
+) ([]model.StockSerialMovement, error) {
+
+	query := `
+SELECT
+	st.stock_transaction_id,
+	st.transaction_type,
+	u.username,
+	st.timestamp,
+	src.account,
+	src.location,
+	src.bin,
+	src.lot_number,
+	dst.account,
+	dst.location,
+	dst.bin,
+	dst.lot_number
+FROM
+	stock_transaction_serial sts
+JOIN stock_transaction st ON st.stock_transaction_id = sts.stock_transaction_id
+JOIN stock_transaction_entry src ON src.stock_transaction_id = st.stock_transaction_id
+	AND src.quantity < 0
+JOIN stock_transaction_entry dst ON dst.stock_transaction_id = st.stock_transaction_id
+	AND dst.quantity > 0
+LEFT JOIN app_user u ON u.user_id = st.transaction_by
+WHERE
+	sts.stock_serial_id = $1
+ORDER BY
+	st.timestamp DESC,
+	st.stock_transaction_id DESC
+	`
+
+	rows, err := exec.Query(ctx, query, stockSerialID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	movements := []model.StockSerialMovement{}
+	for rows.Next() {
+		var m model.StockSerialMovement
+		err := rows.Scan(
+			&m.StockTransactionID,
+			&m.TransactionType,
+			&m.TransactionByUsername,
+			&m.Timestamp,
+			&m.From.Account,
+			&m.From.Location,
+			&m.From.Bin,
+			&m.From.LotNumber,
+			&m.To.Account,
+			&m.To.Location,
+			&m.To.Bin,
+			&m.To.LotNumber,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		m.From.Timestamp = m.Timestamp
+		m.To.Timestamp = m.Timestamp
+		movements = append(movements, m)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return movements, nil
+}
@@ -7,6 +7,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/shopspring/decimal"
@@ -18,23 +19,32 @@ func NewStockTransactionRepository() *StockTransactionRepository {
 	return &StockTransactionRepository{}
 }
 
+// GetStockLevels returns current stock levels from the balance table. When
+// LTETimestamp is set, levels as of that time are calculated from the ledger
+// instead.
 func (r *StockTransactionRepository) GetStockLevels(
 	ctx context.Context,
 	exec db.PGExecutor,
 	input *model.GetStockLevelsInput,
 ) ([]model.StockLevel, error) {
 
+	if input.LTETimestamp == nil {
+		return r.getCurrentStockLevels(ctx, exec, input)
+	}
+
 	query := `
 WITH RankedStock AS (
 	SELECT
 		ste.stock_transaction_id,
 		si.stock_code,
+		si.base_unit,
 		ste.account,
 		ste.location,
 		ste.bin,
 		ste.lot_number,
 		ste.running_total AS stock_level,
 		st.timestamp,
+		sl.expiry_date,
 		ROW_NUMBER() OVER (
 			PARTITION BY ste.account, si.stock_code, ste.location, ste.bin, ste.lot_number
 			ORDER BY st.timestamp DESC, ste.stock_transaction_id DESC
@@ -45,6 +55,9 @@ WITH RankedStock AS (
 			stock_transaction st ON ste.stock_transaction_id = st.stock_transaction_id
 		JOIN stock_item si
 			ON st.stock_item_id = si.stock_item_id
+		LEFT JOIN stock_lot sl
+			ON sl.stock_item_id = st.stock_item_id
+			AND sl.lot_number = ste.lot_number
 	WHERE
 		($1 = '' OR ste.account = $1)
 		AND
@@ -66,15 +79,23 @@ SELECT
 	bin,
 	lot_number,
 	stock_level,
-	timestamp
+	base_unit,
+	timestamp,
+	expiry_date
 FROM
 	RankedStock
 WHERE
 	rn = 1
 	AND
 	stock_level <> 0
+-- the place breaks ties so that pages neither repeat nor skip levels
 ORDER BY
-	stock_transaction_id DESC
+	stock_transaction_id DESC,
+	stock_code,
+	account,
+	location,
+	bin,
+	lot_number
 LIMIT $7 OFFSET $8;
     `
 
@@ -114,7 +135,138 @@ LIMIT $7 OFFSET $8;
 			&sl.Bin,
 			&sl.LotNumber,
 			&sl.StockLevel,
+			&sl.Unit,
+			&sl.Timestamp,
+			&sl.ExpiryDate,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		results = append(results, sl)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+func (r *StockTransactionRepository) getCurrentStockLevels(
+	ctx context.Context,
+	exec db.PGExecutor,
+	input *model.GetStockLevelsInput,
+) ([]model.StockLevel, error) {
+
+	query := `
+SELECT
+	sb.account,
+	si.stock_code,
+	sb.location,
+	sb.bin,
+	sb.lot_number,
+	sb.quantity,
+	si.base_unit,
+	sb.last_timestamp,
+	sl.expiry_date,
+	COALESCE(sr.reserved, 0),
+	COALESCE(sq.quarantined, 0)
+FROM
+	stock_balance sb
+JOIN stock_item si ON si.stock_item_id = sb.stock_item_id
+LEFT JOIN stock_lot sl
+	ON sl.stock_item_id = sb.stock_item_id
+	AND sl.lot_number = sb.lot_number
+LEFT JOIN LATERAL (
+	SELECT
+		SUM(r.quantity) AS reserved
+	FROM
+		stock_reservation r
+	WHERE
+		sb.account = 'STOCK'
+		AND r.status = 'Open'
+		AND r.stock_item_id = sb.stock_item_id
+		AND (r.location = '' OR r.location = sb.location)
+		AND (r.bin = '' OR r.bin = sb.bin)
+		AND (r.lot_number = '' OR r.lot_number = sb.lot_number)
+) sr ON TRUE
+-- Stock is held and released at the place it was in, so a place with stock
+-- on hold always has a STOCK balance, though it may be zero
+LEFT JOIN LATERAL (
+	SELECT
+		q.quantity AS quarantined
+	FROM
+		stock_balance q
+	WHERE
+		sb.account = 'STOCK'
+		AND q.account = 'QUARANTINE'
+		AND q.stock_item_id = sb.stock_item_id
+		AND q.location = sb.location
+		AND q.bin = sb.bin
+		AND q.lot_number = sb.lot_number
+) sq ON TRUE
+WHERE
+	($1 = '' OR sb.account = $1)
+	AND
+	($2 = '' OR si.stock_code = $2)
+	AND
+	($3 = '' OR sb.location = $3)
+	AND
+	($4 = '' OR sb.bin = $4)
+	AND
+	($5 = '' OR sb.lot_number = $5)
+	AND
+	(sb.quantity <> 0 OR COALESCE(sq.quarantined, 0) <> 0)
+-- the place breaks ties so that pages neither repeat nor skip levels
+ORDER BY
+	sb.last_stock_transaction_id DESC,
+	sb.stock_item_id,
+	sb.account,
+	sb.location,
+	sb.bin,
+	sb.lot_number
+LIMIT $6 OFFSET $7;
+	`
+
+	limit := 1000
+	if input.PageSize > 0 {
+		limit = input.PageSize
+	}
+	offset := 0
+	if input.Page > 0 {
+		offset = (input.Page - 1) * input.PageSize
+	}
+
+	rows, err := exec.Query(ctx, query,
+		input.Account,
+		input.StockCode,
+		input.Location,
+		input.Bin,
+		input.LotNumber,
+
+		limit, offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []model.StockLevel
+	for rows.Next() {
+		var sl model.StockLevel
+		err := rows.Scan(
+			&sl.Account,
+			&sl.StockCode,
+			&sl.Location,
+			&sl.Bin,
+			&sl.LotNumber,
+			&sl.StockLevel,
+			&sl.Unit,
 			&sl.Timestamp,
+			&sl.ExpiryDate,
+			&sl.Reserved,
+			&sl.Quarantined,
 		)
 		if err != nil {
 			return nil, err
@@ -154,13 +306,25 @@ $11   → to_account
 $12   → to_location
 $13   → to_bin
 $14   → to_lot_number
+$15   → stock_document_id
+$16   → reverses_stock_transaction_id
+$17   → stock_count_id
+$18   → serial_numbers
+$19   → unit_cost
+$20   → purchase_order_line_id
+$21   → sales_order_line_id
+$22   → reason
+$23   → approved_by
+$24   → scrap_reason_id
 */
 
 WITH inserted_tx AS (
     INSERT INTO stock_transaction (
-        transaction_type, stock_item_id, transaction_note, transaction_by, timestamp
+        transaction_type, stock_item_id, transaction_note, transaction_by, timestamp, stock_document_id,
+        reverses_stock_transaction_id, stock_count_id, unit_cost, purchase_order_line_id,
+        sales_order_line_id, reason, approved_by, scrap_reason_id
     )
-    VALUES ($1, $2, $4, $5, COALESCE($6, NOW()))
+    VALUES ($1, $2, $4, $5, COALESCE($6, NOW()), $15, $16, $17, $19, $20, $21, $22, $23, $24)
     RETURNING stock_transaction_id, timestamp
 ),
 
@@ -234,17 +398,68 @@ updated_future_to AS (
       AND e.lot_number = $14
       AND t.timestamp > inserted_tx.timestamp
     RETURNING e.stock_transaction_entry_id
+),
+
+-- Keep the balance table in step with the ledger. A back-dated posting
+-- changes the quantity but not the latest transaction of the balance.
+upserted_from_balance AS (
+    INSERT INTO stock_balance AS b (
+        account, stock_item_id, location, bin, lot_number,
+        quantity, last_stock_transaction_id, last_timestamp
+    )
+    SELECT $7, $2, $8, $9, $10, -1 * $3, inserted_tx.stock_transaction_id, inserted_tx.timestamp
+    FROM inserted_tx
+    ON CONFLICT (account, stock_item_id, location, bin, lot_number) DO UPDATE SET
+        quantity = b.quantity + EXCLUDED.quantity,
+        last_stock_transaction_id = CASE
+            WHEN EXCLUDED.last_timestamp >= b.last_timestamp THEN EXCLUDED.last_stock_transaction_id
+            ELSE b.last_stock_transaction_id
+        END,
+        last_timestamp = GREATEST(b.last_timestamp, EXCLUDED.last_timestamp)
+    RETURNING b.account
+),
+
+upserted_to_balance AS (
+    INSERT INTO stock_balance AS b (
+        account, stock_item_id, location, bin, lot_number,
+        quantity, last_stock_transaction_id, last_timestamp
+    )
+    SELECT $11, $2, $12, $13, $14, $3, inserted_tx.stock_transaction_id, inserted_tx.timestamp
+    FROM inserted_tx
+    ON CONFLICT (account, stock_item_id, location, bin, lot_number) DO UPDATE SET
+        quantity = b.quantity + EXCLUDED.quantity,
+        last_stock_transaction_id = CASE
+            WHEN EXCLUDED.last_timestamp >= b.last_timestamp THEN EXCLUDED.last_stock_transaction_id
+            ELSE b.last_stock_transaction_id
+        END,
+        last_timestamp = GREATEST(b.last_timestamp, EXCLUDED.last_timestamp)
+    RETURNING b.account
+),
+
+-- The serials of serialised stock items are created before posting
+inserted_serials AS (
+    INSERT INTO stock_transaction_serial (stock_transaction_id, stock_serial_id)
+    SELECT inserted_tx.stock_transaction_id, ss.stock_serial_id
+    FROM inserted_tx
+    JOIN stock_serial ss ON ss.stock_item_id = $2
+        AND ss.serial_number = ANY(COALESCE($18::TEXT[], '{}'))
+    RETURNING stock_serial_id
 )
 
 SELECT
+    (SELECT stock_transaction_id FROM inserted_tx) AS stock_transaction_id,
+    (SELECT count(*) FROM upserted_from_balance) AS upserted_from_count,
+    (SELECT count(*) FROM upserted_to_balance) AS upserted_to_count,
     (SELECT count(*) FROM inserted_from_entry) AS inserted_from_count,
     (SELECT count(*) FROM inserted_to_entry) AS inserted_to_count,
     (SELECT count(*) FROM updated_future_from) AS updated_from_count,
-    (SELECT count(*) FROM updated_future_to) AS updated_to_count;
+    (SELECT count(*) FROM updated_future_to) AS updated_to_count,
+    (SELECT count(*) FROM inserted_serials) AS inserted_serials_count;
 	`
 
-	for _, t := range *transactions {
-		accounts, _ := model.StockTransacationTypeMap[t.TransactionType]
+	for i := range *transactions {
+		t := &(*transactions)[i]
+		accounts := t.Accounts
 
 		// IMPORTANT: posting from and to the same Account, Location, Bin and
 		// LotNumber is not allowed as it is not compatible with how running totals
@@ -261,7 +476,9 @@ SELECT
 			continue
 		}
 
+		var upsertedFromCount, upsertedToCount int
 		var insertedFromCount, insertedToCount, updatedFromCount, updatedToCount int
+		var insertedSerialsCount int
 		err := exec.QueryRow(ctx, query,
 			t.TransactionType,
 			t.StockItemID,
@@ -277,11 +494,32 @@ SELECT
 			t.ToLocation,
 			t.ToBin,
 			t.ToLotNumber,
-		).Scan(&insertedFromCount, &insertedToCount, &updatedFromCount, &updatedToCount)
+			t.StockDocumentID,
+			t.ReversesStockTransactionID,
+			t.StockCountID,
+			t.SerialNumbers,
+			t.UnitCost,
+			t.PurchaseOrderLineID,
+			t.SalesOrderLineID,
+			t.Reason,
+			t.ApprovedBy,
+			t.ScrapReasonID,
+		).Scan(
+			&t.StockTransactionID,
+			&upsertedFromCount, &upsertedToCount,
+			&insertedFromCount, &insertedToCount, &updatedFromCount, &updatedToCount,
+			&insertedSerialsCount,
+		)
 		if err != nil {
 			log.Println(err)
 			return fmt.Errorf("failed to create stock transaction: %v", err)
 		}
+		if insertedSerialsCount != len(t.SerialNumbers) {
+			return fmt.Errorf(
+				"failed to link serial numbers: %d of %d serials exist",
+				insertedSerialsCount, len(t.SerialNumbers),
+			)
+		}
 
 	}
 
@@ -309,6 +547,20 @@ WITH matched_tx_ids AS (
 		($5 = '' OR ste.lot_number = $5)
 		AND
 		($6::timestamp IS NULL OR st.timestamp <= $6::timestamp)
+		AND
+		($9 = 0 OR st.stock_document_id = $9)
+		AND
+		($10 = 0 OR st.stock_transaction_id = $10 OR st.reverses_stock_transaction_id = $10)
+		AND
+		($11 = 0 OR st.stock_count_id = $11)
+		AND
+		($12 = 0 OR st.purchase_order_line_id IN (
+			SELECT purchase_order_line_id FROM purchase_order_line WHERE purchase_order_id = $12
+		))
+		AND
+		($13 = 0 OR st.sales_order_line_id IN (
+			SELECT sales_order_line_id FROM sales_order_line WHERE sales_order_id = $13
+		))
 )
 
 SELECT
@@ -319,16 +571,26 @@ SELECT
 	ste.location,
 	ste.bin,
 	ste.quantity,
+	si.base_unit,
 	ste.lot_number,
 	ste.running_total,
 	st.transaction_by,
 	u.username AS transaction_by_username,
 	st.timestamp,
-	ste.stock_transaction_id
+	ste.stock_transaction_id,
+	st.stock_document_id,
+	st.reverses_stock_transaction_id,
+	rev.stock_transaction_id AS reversed_by_stock_transaction_id,
+	st.reason,
+	au.username AS approved_by_username,
+	sr.code AS scrap_reason_code
 FROM stock_transaction_entry ste
 JOIN stock_transaction st ON st.stock_transaction_id = ste.stock_transaction_id
 JOIN stock_item si ON st.stock_item_id = si.stock_item_id
 LEFT JOIN app_user u ON u.user_id = st.transaction_by
+LEFT JOIN app_user au ON au.user_id = st.approved_by
+LEFT JOIN scrap_reason sr ON sr.scrap_reason_id = st.scrap_reason_id
+LEFT JOIN stock_transaction rev ON rev.reverses_stock_transaction_id = st.stock_transaction_id
 JOIN matched_tx_ids m ON m.stock_transaction_id = ste.stock_transaction_id
 ORDER BY st.timestamp DESC, ste.stock_transaction_entry_id DESC
 LIMIT $7 OFFSET $8
@@ -357,7 +619,13 @@ LIMIT $7 OFFSET $8
 		lotNumber,
 		lteTimestamp,
 		limit,
-		offset)
+		offset,
+		input.StockDocumentID,
+		input.StockTransactionID,
+		input.StockCountID,
+		input.PurchaseOrderID,
+		input.SalesOrderID,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -375,12 +643,19 @@ LIMIT $7 OFFSET $8
 			&st.Location,
 			&st.Bin,
 			&st.Quantity,
+			&st.Unit,
 			&st.LotNumber,
 			&st.RunningTotal,
 			&st.TransactionBy,
 			&st.TransactionByUsername,
 			&st.Timestamp,
 			&st.StockTransactionID,
+			&st.StockDocumentID,
+			&st.ReversesStockTransactionID,
+			&st.ReversedByStockTransactionID,
+			&st.Reason,
+			&st.ApprovedByUsername,
+			&st.ScrapReasonCode,
 		)
 
 		if err != nil {
@@ -398,3 +673,286 @@ LIMIT $7 OFFSET $8
 	return transactions, nil
 
 }
+
+// GetLotNumbers returns the lot numbers posted for a stock code, most
+// recently posted first
+func (r *StockTransactionRepository) GetLotNumbers(
+	ctx context.Context,
+	exec db.PGExecutor,
+	stockCode string,
+) ([]string, error) {
+
+	query := `
+SELECT
+	ste.lot_number
+FROM
+	stock_transaction_entry ste
+JOIN stock_transaction st ON st.stock_transaction_id = ste.stock_transaction_id
+JOIN stock_item si ON si.stock_item_id = st.stock_item_id
+WHERE
+	si.stock_code = $1
+	AND ste.lot_number <> ''
+GROUP BY
+	ste.lot_number
+ORDER BY
+	MAX(st.timestamp) DESC,
+	ste.lot_number
+LIMIT 200
+	`
+
+	rows, err := exec.Query(ctx, query, stockCode)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lotNumbers := []string{}
+	for rows.Next() {
+		var lotNumber string
+		if err := rows.Scan(&lotNumber); err != nil {
+			return nil, err
+		}
+
+		lotNumbers = append(lotNumbers, lotNumber)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return lotNumbers, nil
+}
+
+// GetLowestStockBalance returns the lowest negative STOCK running total for
+// the stock item, location, bin and lot at or after the given timestamp, or
+// nil if none of those running totals are negative. A nil timestamp means
+// the start of the current database transaction, matching how postings
+// without a timestamp are recorded.
+func (r *StockTransactionRepository) GetLowestStockBalance(
+	ctx context.Context,
+	exec db.PGExecutor,
+	stockItemID int,
+	location string,
+	bin string,
+	lotNumber string,
+	timestamp *time.Time,
+) (*model.NegativeStockBalance, error) {
+
+	query := `
+SELECT
+	t.stock_item_id,
+	si.stock_code,
+	e.location,
+	e.bin,
+	e.lot_number,
+	e.running_total,
+	t.timestamp
+FROM
+	stock_transaction_entry e
+JOIN stock_transaction t ON t.stock_transaction_id = e.stock_transaction_id
+JOIN stock_item si ON si.stock_item_id = t.stock_item_id
+WHERE
+	e.account = 'STOCK'
+	AND t.stock_item_id = $1
+	AND e.location = $2
+	AND e.bin = $3
+	AND e.lot_number = $4
+	AND t.timestamp >= COALESCE($5, NOW())
+	AND e.running_total < 0
+ORDER BY
+	e.running_total ASC, t.timestamp ASC
+LIMIT 1
+	`
+
+	var b model.NegativeStockBalance
+	err := exec.QueryRow(ctx, query, stockItemID, location, bin, lotNumber, timestamp).Scan(
+		&b.StockItemID,
+		&b.StockCode,
+		&b.Location,
+		&b.Bin,
+		&b.LotNumber,
+		&b.RunningTotal,
+		&b.Timestamp,
+	)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &b, nil
+}
+
+// LockStockBalance locks and returns the current balance of an account at a
+// place, which is zero if nothing has been posted there
+func (r *StockTransactionRepository) LockStockBalance(
+	ctx context.Context,
+	tx pgx.Tx,
+	account model.StockAccount,
+	stockItemID int,
+	location string,
+	bin string,
+	lotNumber string,
+) (decimal.Decimal, error) {
+
+	query := `
+SELECT
+	quantity
+FROM
+	stock_balance
+WHERE
+	account = $1
+	AND stock_item_id = $2
+	AND location = $3
+	AND bin = $4
+	AND lot_number = $5
+FOR UPDATE
+	`
+
+	var quantity decimal.Decimal
+	err := tx.QueryRow(ctx, query, account, stockItemID, location, bin, lotNumber).Scan(&quantity)
+	if err == pgx.ErrNoRows {
+		return decimal.Zero, nil
+	} else if err != nil {
+		return decimal.Zero, err
+	}
+
+	return quantity, nil
+}
+
+// GetStockTransactionToReverse locks a transaction and returns what is needed
+// to post its mirror, or nil if it does not exist
+func (r *StockTransactionRepository) GetStockTransactionToReverse(
+	ctx context.Context,
+	tx pgx.Tx,
+	stockTransactionID int,
+) (*model.StockTransactionToReverse, error) {
+
+	query := `
+SELECT
+	st.stock_transaction_id,
+	st.transaction_type,
+	st.stock_item_id,
+	st.unit_cost,
+	st.purchase_order_line_id,
+	st.sales_order_line_id,
+	st.reason,
+	st.approved_by,
+	st.scrap_reason_id,
+	st.reverses_stock_transaction_id,
+	(
+		SELECT ctt.from_account
+		FROM custom_stock_transaction_type ctt
+		WHERE ctt.name = st.transaction_type
+	),
+	(
+		SELECT rev.stock_transaction_id
+		FROM stock_transaction rev
+		WHERE rev.reverses_stock_transaction_id = st.stock_transaction_id
+	),
+	ARRAY(
+		SELECT ss.serial_number
+		FROM stock_transaction_serial sts
+		JOIN stock_serial ss ON ss.stock_serial_id = sts.stock_serial_id
+		WHERE sts.stock_transaction_id = st.stock_transaction_id
+		ORDER BY ss.serial_number
+	)
+FROM
+	stock_transaction st
+WHERE
+	st.stock_transaction_id = $1
+FOR UPDATE
+	`
+
+	var t model.StockTransactionToReverse
+	var customFromAccount *model.StockAccount
+	err := tx.QueryRow(ctx, query, stockTransactionID).Scan(
+		&t.StockTransactionID,
+		&t.TransactionType,
+		&t.StockItemID,
+		&t.UnitCost,
+		&t.PurchaseOrderLineID,
+		&t.SalesOrderLineID,
+		&t.Reason,
+		&t.ApprovedBy,
+		&t.ScrapReasonID,
+		&t.ReversesStockTransactionID,
+		&customFromAccount,
+		&t.ReversedByStockTransactionID,
+		&t.SerialNumbers,
+	)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	entriesQuery := `
+SELECT
+	account,
+	location,
+	bin,
+	lot_number,
+	quantity
+FROM
+	stock_transaction_entry
+WHERE
+	stock_transaction_id = $1
+ORDER BY
+	quantity, stock_transaction_entry_id
+	`
+
+	rows, err := tx.Query(ctx, entriesQuery, stockTransactionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	type entry struct {
+		account   model.StockAccount
+		location  string
+		bin       string
+		lotNumber string
+		quantity  decimal.Decimal
+	}
+
+	var entries []entry
+	for rows.Next() {
+		var e entry
+		err := rows.Scan(&e.account, &e.location, &e.bin, &e.lotNumber, &e.quantity)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(entries) != 2 {
+		return nil, fmt.Errorf("transaction %d has %d entries, expected 2", stockTransactionID, len(entries))
+	}
+
+	// Entries are ordered by quantity so the outgoing entry comes first when
+	// both are posted against the same account, as with stock movements.
+	// Custom transaction types take their accounts from the database.
+	fromAccount := model.StockTransacationTypeMap[t.TransactionType].From
+	if customFromAccount != nil {
+		fromAccount = *customFromAccount
+	}
+	from, to := entries[0], entries[1]
+	if from.account != fromAccount {
+		from, to = to, from
+	}
+
+	t.FromQuantity = from.quantity
+	t.FromLocation = from.location
+	t.FromBin = from.bin
+	t.FromLotNumber = from.lotNumber
+	t.ToLocation = to.location
+	t.ToBin = to.bin
+	t.ToLotNumber = to.lotNumber
+
+	return &t, nil
+}
@@ -0,0 +1,242 @@
+package repository
+
+import (
+	"app/internal/model"
+	"app/pkg/db"
+	"context"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type StockTransactionTypeRepository struct{}
+
+func NewStockTransactionTypeRepository() *StockTransactionTypeRepository {
+	return &StockTransactionTypeRepository{}
+}
+
+func customTransactionFieldsToStrings(fields []model.CustomTransactionField) []string {
+	strs := []string{}
+	for _, f := range fields {
+		strs = append(strs, string(f))
+	}
+	return strs
+}
+
+func (r *StockTransactionTypeRepository) CreateCustomStockTransactionType(
+	ctx context.Context,
+	exec db.PGExecutor,
+	transactionType *model.NewCustomStockTransactionType,
+	userID int,
+) (int, error) {
+
+	query := `
+INSERT INTO custom_stock_transaction_type (
+	name,
+	description,
+	from_account,
+	to_account,
+	allow_different_location,
+	allow_different_bin,
+	allow_different_lot,
+	required_fields,
+	required_permission,
+	created_by,
+	updated_by
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $10)
+RETURNING custom_stock_transaction_type_id
+	`
+
+	var customStockTransactionTypeID int
+	err := exec.QueryRow(ctx, query,
+		transactionType.Name,
+		transactionType.Description,
+		transactionType.FromAccount,
+		transactionType.ToAccount,
+		transactionType.AllowDifferentLocation,
+		transactionType.AllowDifferentBin,
+		transactionType.AllowDifferentLot,
+		customTransactionFieldsToStrings(transactionType.RequiredFields),
+		transactionType.RequiredPermission,
+		userID,
+	).Scan(&customStockTransactionTypeID)
+	if err != nil {
+		return 0, err
+	}
+
+	return customStockTransactionTypeID, nil
+}
+
+func (r *StockTransactionTypeRepository) UpdateCustomStockTransactionType(
+	ctx context.Context,
+	exec db.PGExecutor,
+	customStockTransactionTypeID int,
+	update *model.CustomStockTransactionTypeUpdate,
+	userID int,
+) error {
+
+	query := `
+UPDATE
+	custom_stock_transaction_type
+SET
+	description = $2,
+	allow_different_location = $3,
+	allow_different_bin = $4,
+	allow_different_lot = $5,
+	required_fields = $6,
+	required_permission = $7,
+	is_archived = $8,
+	updated_by = $9,
+	updated_at = NOW()
+WHERE
+	custom_stock_transaction_type_id = $1
+	`
+
+	_, err := exec.Exec(ctx, query,
+		customStockTransactionTypeID,
+		update.Description,
+		update.AllowDifferentLocation,
+		update.AllowDifferentBin,
+		update.AllowDifferentLot,
+		customTransactionFieldsToStrings(update.RequiredFields),
+		update.RequiredPermission,
+		update.IsArchived,
+		userID,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+var customStockTransactionTypeSelect = `
+SELECT
+	ctt.custom_stock_transaction_type_id,
+	ctt.name,
+	ctt.description,
+	ctt.from_account,
+	ctt.to_account,
+	ctt.allow_different_location,
+	ctt.allow_different_bin,
+	ctt.allow_different_lot,
+	ctt.required_fields,
+	ctt.required_permission,
+	ctt.is_archived,
+	cu.username,
+	ctt.created_at,
+	uu.username,
+	ctt.updated_at
+FROM
+	custom_stock_transaction_type ctt
+LEFT JOIN app_user cu ON cu.user_id = ctt.created_by
+LEFT JOIN app_user uu ON uu.user_id = ctt.updated_by
+`
+
+func scanCustomStockTransactionType(row pgx.Row) (model.CustomStockTransactionType, error) {
+	var t model.CustomStockTransactionType
+	var requiredFields []string
+	err := row.Scan(
+		&t.CustomStockTransactionTypeID,
+		&t.Name,
+		&t.Description,
+		&t.FromAccount,
+		&t.ToAccount,
+		&t.AllowDifferentLocation,
+		&t.AllowDifferentBin,
+		&t.AllowDifferentLot,
+		&requiredFields,
+		&t.RequiredPermission,
+		&t.IsArchived,
+		&t.CreatedByUsername,
+		&t.CreatedAt,
+		&t.UpdatedByUsername,
+		&t.UpdatedAt,
+	)
+	for _, f := range requiredFields {
+		t.RequiredFields = append(t.RequiredFields, model.CustomTransactionField(f))
+	}
+	return t, err
+}
+
+func (r *StockTransactionTypeRepository) GetCustomStockTransactionType(
+	ctx context.Context,
+	exec db.PGExecutor,
+	customStockTransactionTypeID int,
+) (*model.CustomStockTransactionType, error) {
+
+	query := customStockTransactionTypeSelect + `
+WHERE
+	ctt.custom_stock_transaction_type_id = $1
+	`
+
+	t, err := scanCustomStockTransactionType(exec.QueryRow(ctx, query, customStockTransactionTypeID))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &t, nil
+}
+
+func (r *StockTransactionTypeRepository) GetCustomStockTransactionTypeByName(
+	ctx context.Context,
+	exec db.PGExecutor,
+	name string,
+) (*model.CustomStockTransactionType, error) {
+
+	query := customStockTransactionTypeSelect + `
+WHERE
+	ctt.name = $1
+	`
+
+	t, err := scanCustomStockTransactionType(exec.QueryRow(ctx, query, name))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &t, nil
+}
+
+// GetCustomStockTransactionTypes returns the custom transaction types ordered
+// by name, including the archived ones if asked
+func (r *StockTransactionTypeRepository) GetCustomStockTransactionTypes(
+	ctx context.Context,
+	exec db.PGExecutor,
+	showArchived bool,
+) ([]model.CustomStockTransactionType, error) {
+
+	query := customStockTransactionTypeSelect + `
+WHERE
+	$1 OR NOT ctt.is_archived
+ORDER BY
+	ctt.name
+	`
+
+	rows, err := exec.Query(ctx, query, showArchived)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	transactionTypes := []model.CustomStockTransactionType{}
+	for rows.Next() {
+		t, err := scanCustomStockTransactionType(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		transactionTypes = append(transactionTypes, t)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return transactionTypes, nil
+}
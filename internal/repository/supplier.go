@@ -0,0 +1,246 @@
+package repository
+
+import (
+	"app/internal/model"
+	"app/pkg/db"
+	"context"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type SupplierRepository struct{}
+
+func NewSupplierRepository() *SupplierRepository {
+	return &SupplierRepository{}
+}
+
+func (r *SupplierRepository) CreateSupplier(
+	ctx context.Context,
+	exec db.PGExecutor,
+	supplier *model.NewSupplier,
+	userID int,
+) (int, error) {
+
+	query := `
+INSERT INTO supplier (
+	code,
+	name,
+	email,
+	phone,
+	over_receipt_tolerance,
+	created_by,
+	updated_by
+)
+VALUES ($1, $2, $3, $4, $5, $6, $6)
+RETURNING supplier_id
+	`
+
+	var supplierID int
+	err := exec.QueryRow(ctx, query,
+		supplier.Code,
+		supplier.Name,
+		supplier.Email,
+		supplier.Phone,
+		supplier.OverReceiptTolerance,
+		userID,
+	).Scan(&supplierID)
+	if err != nil {
+		return 0, err
+	}
+
+	return supplierID, nil
+}
+
+func (r *SupplierRepository) UpdateSupplier(
+	ctx context.Context,
+	exec db.PGExecutor,
+	supplierID int,
+	update *model.SupplierUpdate,
+	userID int,
+) error {
+
+	query := `
+UPDATE
+	supplier
+SET
+	name = $2,
+	email = $3,
+	phone = $4,
+	over_receipt_tolerance = $5,
+	is_archived = $6,
+	updated_by = $7,
+	updated_at = NOW()
+WHERE
+	supplier_id = $1
+	`
+
+	_, err := exec.Exec(ctx, query,
+		supplierID,
+		update.Name,
+		update.Email,
+		update.Phone,
+		update.OverReceiptTolerance,
+		update.IsArchived,
+		userID,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+var supplierSelect = `
+SELECT
+	s.supplier_id,
+	s.code,
+	s.name,
+	s.email,
+	s.phone,
+	s.over_receipt_tolerance,
+	s.is_archived,
+	cu.username,
+	s.created_at,
+	uu.username,
+	s.updated_at
+FROM
+	supplier s
+LEFT JOIN app_user cu ON cu.user_id = s.created_by
+LEFT JOIN app_user uu ON uu.user_id = s.updated_by
+`
+
+func scanSupplier(row pgx.Row) (model.Supplier, error) {
+	var s model.Supplier
+	err := row.Scan(
+		&s.SupplierID,
+		&s.Code,
+		&s.Name,
+		&s.Email,
+		&s.Phone,
+		&s.OverReceiptTolerance,
+		&s.IsArchived,
+		&s.CreatedByUsername,
+		&s.CreatedAt,
+		&s.UpdatedByUsername,
+		&s.UpdatedAt,
+	)
+	return s, err
+}
+
+func (r *SupplierRepository) GetSupplier(
+	ctx context.Context,
+	exec db.PGExecutor,
+	supplierID int,
+) (*model.Supplier, error) {
+
+	query := supplierSelect + `
+WHERE
+	s.supplier_id = $1
+	`
+
+	s, err := scanSupplier(exec.QueryRow(ctx, query, supplierID))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &s, nil
+}
+
+func (r *SupplierRepository) GetSupplierByCode(
+	ctx context.Context,
+	exec db.PGExecutor,
+	code string,
+) (*model.Supplier, error) {
+
+	query := supplierSelect + `
+WHERE
+	s.code = $1
+	`
+
+	s, err := scanSupplier(exec.QueryRow(ctx, query, code))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &s, nil
+}
+
+// suppliersWhere filters suppliers by GetSuppliersQuery, taking $1 and $2
+var suppliersWhere = `
+WHERE
+	($1 = '' OR s.code ILIKE '%' || $1 || '%' OR s.name ILIKE '%' || $1 || '%')
+	AND
+	($2 OR NOT s.is_archived)
+`
+
+func (r *SupplierRepository) GetSuppliers(
+	ctx context.Context,
+	exec db.PGExecutor,
+	q *model.GetSuppliersQuery,
+) ([]model.Supplier, error) {
+
+	limit := q.PageSize
+	if limit == 0 {
+		limit = 50
+	}
+	offset := 0
+	if q.Page > 0 {
+		offset = (q.Page - 1) * limit
+	}
+
+	query := supplierSelect + suppliersWhere + `
+ORDER BY
+	s.code
+LIMIT $3 OFFSET $4
+	`
+
+	rows, err := exec.Query(ctx, query, q.SearchText, q.ShowArchived, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	suppliers := []model.Supplier{}
+	for rows.Next() {
+		s, err := scanSupplier(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		suppliers = append(suppliers, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return suppliers, nil
+}
+
+func (r *SupplierRepository) GetSuppliersCount(
+	ctx context.Context,
+	exec db.PGExecutor,
+	q *model.GetSuppliersQuery,
+) (int, error) {
+
+	query := `
+SELECT
+	COUNT(*)
+FROM
+	supplier s
+` + suppliersWhere
+
+	var count int
+	err := exec.QueryRow(ctx, query, q.SearchText, q.ShowArchived).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
@@ -394,6 +394,49 @@ WHERE
 	return result, nil
 }
 
+// ListSupplyChainUserIDs returns the users, other than API users, with
+// either supply chain permission
+func (r *UserRepository) ListSupplyChainUserIDs(
+	ctx context.Context,
+	exec db.PGExecutor,
+) ([]int, error) {
+	query := `
+SELECT
+	user_id
+FROM
+	app_user
+WHERE
+	NOT is_api_user
+	AND (
+		COALESCE((permissions->'SupplyChain'->>'Admin')::BOOLEAN, FALSE)
+		OR COALESCE((permissions->'SupplyChain'->>'TeamMember')::BOOLEAN, FALSE)
+	)
+ORDER BY
+	user_id
+`
+
+	rows, err := exec.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var userIDs []int
+	for rows.Next() {
+		var userID int
+		if err := rows.Scan(&userID); err != nil {
+			return nil, err
+		}
+		userIDs = append(userIDs, userID)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return userIDs, nil
+}
+
 func (r *UserRepository) SearchMentionUsers(
 	ctx context.Context,
 	exec db.PGExecutor,
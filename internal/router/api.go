@@ -0,0 +1,25 @@
+package router
+
+import (
+	"app/internal/handler"
+	"app/internal/service"
+	"net/http"
+)
+
+// addAPIRoutes adds the versioned JSON API. API users authenticate with the
+// Authorization header.
+func addAPIRoutes(
+	mux *http.ServeMux,
+	stockItemService service.StockItemService,
+	stockTransactionService service.StockTransactionService,
+) {
+	stockAPIHandler := handler.NewStockAPIHandler(stockItemService, stockTransactionService)
+
+	mux.HandleFunc("GET /api/v1/stock-items", stockAPIHandler.GetStockItems)
+	mux.HandleFunc("GET /api/v1/stock-levels", stockAPIHandler.GetStockLevels)
+	mux.HandleFunc("GET /api/v1/stock-transactions", stockAPIHandler.GetStockTransactions)
+	mux.HandleFunc("POST /api/v1/stock-transactions", stockAPIHandler.PostStockTransactions)
+
+	// unknown endpoints get a JSON error rather than the HTML not found page
+	mux.HandleFunc("/api/v1/", handler.APINotFound)
+}
@@ -0,0 +1,38 @@
+package router
+
+import (
+	"app/internal/handler"
+	"app/internal/service"
+	"net/http"
+)
+
+func addPurchaseOrderRoutes(
+	mux *http.ServeMux,
+	purchaseOrderService service.PurchaseOrderService,
+	stockItemService service.StockItemService,
+) {
+	purchaseOrderHandler := handler.NewPurchaseOrderHandler(purchaseOrderService, stockItemService)
+
+	mux.HandleFunc("GET /stock/suppliers", purchaseOrderHandler.SuppliersPage)
+	mux.HandleFunc("POST /stock/suppliers", purchaseOrderHandler.CreateSupplier)
+	mux.HandleFunc("GET /stock/suppliers/{id}", purchaseOrderHandler.SupplierPage)
+	mux.HandleFunc("POST /stock/suppliers/{id}", purchaseOrderHandler.UpdateSupplier)
+
+	mux.HandleFunc("GET /stock/purchase-orders", purchaseOrderHandler.PurchaseOrdersPage)
+
+	mux.HandleFunc("GET /stock/purchase-orders/add", purchaseOrderHandler.AddPurchaseOrderPage)
+	mux.HandleFunc("POST /stock/purchase-orders/add", purchaseOrderHandler.AddPurchaseOrder)
+
+	mux.HandleFunc("GET /stock/purchase-orders/{id}", purchaseOrderHandler.PurchaseOrderPage)
+
+	mux.HandleFunc("POST /stock/purchase-orders/{id}/lines", purchaseOrderHandler.AddPurchaseOrderLine)
+	mux.HandleFunc("POST /stock/purchase-orders/{id}/lines/{lineID}/delete", purchaseOrderHandler.DeletePurchaseOrderLine)
+	mux.HandleFunc("POST /stock/purchase-orders/{id}/lines/{lineID}/close", purchaseOrderHandler.ClosePurchaseOrderLine)
+
+	mux.HandleFunc("POST /stock/purchase-orders/{id}/issue", purchaseOrderHandler.IssuePurchaseOrder)
+	mux.HandleFunc("POST /stock/purchase-orders/{id}/close", purchaseOrderHandler.ClosePurchaseOrder)
+	mux.HandleFunc("POST /stock/purchase-orders/{id}/cancel", purchaseOrderHandler.CancelPurchaseOrder)
+
+	mux.HandleFunc("GET /stock/purchase-orders/{id}/receive", purchaseOrderHandler.ReceivePurchaseOrderPage)
+	mux.HandleFunc("POST /stock/purchase-orders/{id}/receive", purchaseOrderHandler.ReceivePurchaseOrderLine)
+}
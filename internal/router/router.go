@@ -13,22 +13,40 @@ import (
 )
 
 type Services struct {
-	AndonService            service.AndonService
-	AndonIssueService       service.AndonIssueService
-	AuthService             service.AuthService
-	CommentService          service.CommentService
-	FileService             service.FileService
-	GalleryService          service.GalleryService
-	NotificationService     service.NotificationService
-	PDFService              service.PDFService
-	PrintNodeService        service.PrintNodeService
-	ResourceService         service.ResourceService
-	SearchService           service.SearchService
-	ServicesService         service.ServicesService
-	StockTransactionService service.StockTransactionService
-	StockItemService        service.StockItemService
-	TeamService             service.TeamService
-	UserService             service.UserService
+	AndonService                service.AndonService
+	AndonIssueService           service.AndonIssueService
+	AuthService                 service.AuthService
+	CommentService              service.CommentService
+	FileService                 service.FileService
+	GalleryService              service.GalleryService
+	NotificationService         service.NotificationService
+	PDFService                  service.PDFService
+	PrintNodeService            service.PrintNodeService
+	PurchaseOrderService        service.PurchaseOrderService
+	ResourceService             service.ResourceService
+	SalesOrderService           service.SalesOrderService
+	SearchService               service.SearchService
+	ServicesService             service.ServicesService
+	StockBOMService             service.StockBOMService
+	StockCostService            service.StockCostService
+	StockCountService           service.StockCountService
+	StockDocumentService        service.StockDocumentService
+	StockGenealogyService       service.StockGenealogyService
+	StockImportService          service.StockImportService
+	StockLedgerIntegrityService service.StockLedgerIntegrityService
+	StockLocationService        service.StockLocationService
+	StockLotService             service.StockLotService
+	StockPeriodService          service.StockPeriodService
+	StockReorderService         service.StockReorderService
+	StockReservationService     service.StockReservationService
+	StockScanService            service.StockScanService
+	StockScrapService           service.StockScrapService
+	StockSerialService          service.StockSerialService
+	StockTransactionService     service.StockTransactionService
+	StockTransactionTypeService service.StockTransactionTypeService
+	StockItemService            service.StockItemService
+	TeamService                 service.TeamService
+	UserService                 service.UserService
 }
 
 func NewRouter(services *Services, appHMAC apphmac.AppHMAC) http.Handler {
@@ -55,6 +73,7 @@ func NewRouter(services *Services, appHMAC apphmac.AppHMAC) http.Handler {
 
 	// add routes
 	addAIRoutes(mux)
+	addAPIRoutes(mux, services.StockItemService, services.StockTransactionService)
 	addAuthRoutes(mux, services.AuthService, services.NotificationService)
 	addAndonRoutes(
 		mux,
@@ -93,8 +112,28 @@ func NewRouter(services *Services, appHMAC apphmac.AppHMAC) http.Handler {
 		services.TeamService,
 		appHMAC,
 	)
-	addStockItemRoutes(mux, services.StockItemService, services.CommentService, services.GalleryService, appHMAC)
-	addStockTransactionRoutes(mux, services.StockItemService, services.StockTransactionService)
+	addStockItemRoutes(mux, services.StockItemService, services.StockBOMService, services.CommentService, services.GalleryService, appHMAC)
+	addStockBOMRoutes(mux, services.StockBOMService, services.StockItemService)
+	addStockLabelRoutes(mux, services.StockItemService, services.PDFService)
+	addStockTransactionRoutes(mux, services.StockItemService, services.StockReservationService, services.StockTransactionService)
+	addStockDocumentRoutes(mux, services.StockDocumentService, services.StockItemService)
+	addStockImportRoutes(mux, services.StockImportService)
+	addStockCostRoutes(mux, services.StockCostService)
+	addStockCountRoutes(mux, services.StockCountService, services.StockItemService, services.PDFService)
+	addStockGenealogyRoutes(mux, services.StockGenealogyService)
+	addStockLotRoutes(mux, services.StockLotService)
+	addStockPeriodRoutes(mux, services.StockPeriodService)
+	addStockLocationRoutes(mux, services.StockLocationService)
+	addStockSerialRoutes(mux, services.StockSerialService)
+	addStockReservationRoutes(mux, services.StockReservationService, services.StockItemService)
+	addStockScrapRoutes(mux, services.StockScrapService)
+	addStockTransactionTypeRoutes(mux, services.StockTransactionTypeService)
+	addStockScanRoutes(mux, services.StockScanService)
+	addStockReorderRoutes(mux, services.StockReorderService, services.StockItemService, services.TeamService)
+	addNegativeStockPolicyRoutes(mux, services.StockTransactionService, services.StockItemService)
+	addStockLedgerIntegrityRoutes(mux, services.StockLedgerIntegrityService)
+	addPurchaseOrderRoutes(mux, services.PurchaseOrderService, services.StockItemService)
+	addSalesOrderRoutes(mux, services.SalesOrderService, services.StockItemService, services.PDFService)
 	addTeamRoutes(mux, services.TeamService, services.UserService)
 	addUserRoutes(mux, services.UserService)
 
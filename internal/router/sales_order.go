@@ -0,0 +1,44 @@
+package router
+
+import (
+	"app/internal/handler"
+	"app/internal/service"
+	"net/http"
+)
+
+func addSalesOrderRoutes(
+	mux *http.ServeMux,
+	salesOrderService service.SalesOrderService,
+	stockItemService service.StockItemService,
+	pdfService service.PDFService,
+) {
+	salesOrderHandler := handler.NewSalesOrderHandler(salesOrderService, stockItemService, pdfService)
+
+	mux.HandleFunc("GET /stock/customers", salesOrderHandler.CustomersPage)
+	mux.HandleFunc("POST /stock/customers", salesOrderHandler.CreateCustomer)
+	mux.HandleFunc("GET /stock/customers/{id}", salesOrderHandler.CustomerPage)
+	mux.HandleFunc("POST /stock/customers/{id}", salesOrderHandler.UpdateCustomer)
+
+	mux.HandleFunc("GET /stock/sales-orders", salesOrderHandler.SalesOrdersPage)
+
+	mux.HandleFunc("GET /stock/sales-orders/add", salesOrderHandler.AddSalesOrderPage)
+	mux.HandleFunc("POST /stock/sales-orders/add", salesOrderHandler.AddSalesOrder)
+
+	mux.HandleFunc("GET /stock/sales-orders/{id}", salesOrderHandler.SalesOrderPage)
+
+	mux.HandleFunc("POST /stock/sales-orders/{id}/lines", salesOrderHandler.AddSalesOrderLine)
+	mux.HandleFunc("POST /stock/sales-orders/{id}/lines/{lineID}/delete", salesOrderHandler.DeleteSalesOrderLine)
+	mux.HandleFunc("POST /stock/sales-orders/{id}/lines/{lineID}/close", salesOrderHandler.CloseSalesOrderLine)
+
+	mux.HandleFunc("POST /stock/sales-orders/{id}/issue", salesOrderHandler.IssueSalesOrder)
+	mux.HandleFunc("POST /stock/sales-orders/{id}/close", salesOrderHandler.CloseSalesOrder)
+	mux.HandleFunc("POST /stock/sales-orders/{id}/cancel", salesOrderHandler.CancelSalesOrder)
+
+	mux.HandleFunc("POST /stock/sales-orders/{id}/pick-lists", salesOrderHandler.GeneratePickList)
+
+	mux.HandleFunc("GET /stock/pick-lists/{id}", salesOrderHandler.PickListPage)
+	mux.HandleFunc("GET /stock/pick-lists/{id}/print", salesOrderHandler.PickListPDF)
+	mux.HandleFunc("GET /stock/pick-lists/{id}/dispatch-note", salesOrderHandler.DispatchNotePDF)
+	mux.HandleFunc("POST /stock/pick-lists/{id}/dispatch", salesOrderHandler.DispatchPickList)
+	mux.HandleFunc("POST /stock/pick-lists/{id}/cancel", salesOrderHandler.CancelPickList)
+}
@@ -0,0 +1,24 @@
+package router
+
+import (
+	"app/internal/handler"
+	"app/internal/service"
+	"net/http"
+)
+
+func addStockBOMRoutes(
+	mux *http.ServeMux,
+	stockBOMService service.StockBOMService,
+	stockItemService service.StockItemService,
+) {
+	stockBOMHandler := handler.NewStockBOMHandler(stockBOMService, stockItemService)
+
+	mux.HandleFunc("GET /stock-items/{id}/boms/add", stockBOMHandler.AddStockBOMPage)
+	mux.HandleFunc("POST /stock-items/{id}/boms/add", stockBOMHandler.AddStockBOM)
+
+	mux.HandleFunc("GET /stock-items/{id}/boms/{bomID}", stockBOMHandler.StockBOMPage)
+	mux.HandleFunc("POST /stock-items/{id}/boms/{bomID}", stockBOMHandler.UpdateStockBOM)
+
+	mux.HandleFunc("POST /stock-items/{id}/boms/{bomID}/lines", stockBOMHandler.AddStockBOMLine)
+	mux.HandleFunc("POST /stock-items/{id}/boms/{bomID}/lines/{lineID}/delete", stockBOMHandler.DeleteStockBOMLine)
+}
@@ -0,0 +1,16 @@
+package router
+
+import (
+	"app/internal/handler"
+	"app/internal/service"
+	"net/http"
+)
+
+func addStockCostRoutes(
+	mux *http.ServeMux,
+	stockCostService service.StockCostService,
+) {
+	stockCostHandler := handler.NewStockCostHandler(stockCostService)
+
+	mux.HandleFunc("GET /stock/valuation", stockCostHandler.StockValuationPage)
+}
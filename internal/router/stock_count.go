@@ -0,0 +1,30 @@
+package router
+
+import (
+	"app/internal/handler"
+	"app/internal/service"
+	"net/http"
+)
+
+func addStockCountRoutes(
+	mux *http.ServeMux,
+	stockCountService service.StockCountService,
+	stockItemService service.StockItemService,
+	pdfService service.PDFService,
+) {
+	stockCountHandler := handler.NewStockCountHandler(stockCountService, stockItemService, pdfService)
+
+	mux.HandleFunc("GET /stock/counts", stockCountHandler.StockCountsPage)
+
+	mux.HandleFunc("GET /stock/counts/add", stockCountHandler.AddStockCountPage)
+	mux.HandleFunc("POST /stock/counts/add", stockCountHandler.AddStockCount)
+
+	mux.HandleFunc("GET /stock/counts/{id}", stockCountHandler.StockCountPage)
+	mux.HandleFunc("GET /stock/counts/{id}/report", stockCountHandler.StockCountVarianceReport)
+
+	mux.HandleFunc("POST /stock/counts/{id}/counts", stockCountHandler.SaveStockCountCounts)
+	mux.HandleFunc("POST /stock/counts/{id}/lines", stockCountHandler.AddStockCountLine)
+
+	mux.HandleFunc("POST /stock/counts/{id}/approve", stockCountHandler.ApproveStockCount)
+	mux.HandleFunc("POST /stock/counts/{id}/cancel", stockCountHandler.CancelStockCount)
+}
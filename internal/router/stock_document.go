@@ -0,0 +1,28 @@
+package router
+
+import (
+	"app/internal/handler"
+	"app/internal/service"
+	"net/http"
+)
+
+func addStockDocumentRoutes(
+	mux *http.ServeMux,
+	stockDocumentService service.StockDocumentService,
+	stockItemService service.StockItemService,
+) {
+	stockDocumentHandler := handler.NewStockDocumentHandler(stockDocumentService, stockItemService)
+
+	mux.HandleFunc("GET /stock/documents", stockDocumentHandler.StockDocumentsPage)
+
+	mux.HandleFunc("GET /stock/documents/add", stockDocumentHandler.AddStockDocumentPage)
+	mux.HandleFunc("POST /stock/documents/add", stockDocumentHandler.AddStockDocument)
+
+	mux.HandleFunc("GET /stock/documents/{id}", stockDocumentHandler.StockDocumentPage)
+
+	mux.HandleFunc("POST /stock/documents/{id}/lines", stockDocumentHandler.AddStockDocumentLine)
+	mux.HandleFunc("POST /stock/documents/{id}/lines/{lineID}/delete", stockDocumentHandler.DeleteStockDocumentLine)
+
+	mux.HandleFunc("POST /stock/documents/{id}/post", stockDocumentHandler.PostStockDocument)
+	mux.HandleFunc("POST /stock/documents/{id}/cancel", stockDocumentHandler.CancelStockDocument)
+}
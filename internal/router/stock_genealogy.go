@@ -0,0 +1,16 @@
+package router
+
+import (
+	"app/internal/handler"
+	"app/internal/service"
+	"net/http"
+)
+
+func addStockGenealogyRoutes(
+	mux *http.ServeMux,
+	stockGenealogyService service.StockGenealogyService,
+) {
+	stockGenealogyHandler := handler.NewStockGenealogyHandler(stockGenealogyService)
+
+	mux.HandleFunc("GET /stock/lot-trace", stockGenealogyHandler.LotTracePage)
+}
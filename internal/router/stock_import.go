@@ -0,0 +1,23 @@
+package router
+
+import (
+	"app/internal/handler"
+	"app/internal/service"
+	"net/http"
+)
+
+func addStockImportRoutes(
+	mux *http.ServeMux,
+	stockImportService service.StockImportService,
+) {
+	stockImportHandler := handler.NewStockImportHandler(stockImportService)
+
+	mux.HandleFunc("GET /stock/imports", stockImportHandler.StockImportsPage)
+	mux.HandleFunc("POST /stock/imports", stockImportHandler.UploadStockImport)
+	mux.HandleFunc("GET /stock/imports/template", stockImportHandler.DownloadStockImportTemplate)
+
+	mux.HandleFunc("GET /stock/imports/{id}", stockImportHandler.StockImportPage)
+
+	mux.HandleFunc("POST /stock/imports/{id}/post", stockImportHandler.PostStockImport)
+	mux.HandleFunc("POST /stock/imports/{id}/cancel", stockImportHandler.CancelStockImport)
+}
@@ -10,11 +10,12 @@ import (
 func addStockItemRoutes(
 	mux *http.ServeMux,
 	stockItemService service.StockItemService,
+	stockBOMService service.StockBOMService,
 	commentService service.CommentService,
 	galleryService service.GalleryService,
 	appHMAC apphmac.AppHMAC,
 ) {
-	stockItemHandler := handler.NewStockItemHandler(stockItemService, commentService, galleryService, appHMAC)
+	stockItemHandler := handler.NewStockItemHandler(stockItemService, stockBOMService, commentService, galleryService, appHMAC)
 
 	mux.HandleFunc("GET /stock-items", stockItemHandler.StockItemsPage)
 
@@ -26,5 +27,8 @@ func addStockItemRoutes(
 	mux.HandleFunc("GET /stock-items/{id}/edit", stockItemHandler.EditStockItemPage)
 	mux.HandleFunc("POST /stock-items/{id}/edit", stockItemHandler.EditStockItem)
 
+	mux.HandleFunc("POST /stock-items/{id}/units", stockItemHandler.AddStockItemUnit)
+	mux.HandleFunc("POST /stock-items/{id}/units/{unitID}/delete", stockItemHandler.DeleteStockItemUnit)
+
 	mux.HandleFunc("GET /get-stock-codes", stockItemHandler.GetStockCodes)
 }
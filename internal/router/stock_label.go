@@ -0,0 +1,18 @@
+package router
+
+import (
+	"app/internal/handler"
+	"app/internal/service"
+	"net/http"
+)
+
+func addStockLabelRoutes(
+	mux *http.ServeMux,
+	stockItemService service.StockItemService,
+	pdfService service.PDFService,
+) {
+	stockLabelHandler := handler.NewStockLabelHandler(stockItemService, pdfService)
+
+	mux.HandleFunc("GET /stock-items/{id}/labels", stockLabelHandler.StockLabelsPage)
+	mux.HandleFunc("POST /stock-items/{id}/labels", stockLabelHandler.PrintStockLabels)
+}
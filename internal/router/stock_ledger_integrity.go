@@ -0,0 +1,17 @@
+package router
+
+import (
+	"app/internal/handler"
+	"app/internal/service"
+	"net/http"
+)
+
+func addStockLedgerIntegrityRoutes(
+	mux *http.ServeMux,
+	stockLedgerIntegrityService service.StockLedgerIntegrityService,
+) {
+	stockLedgerIntegrityHandler := handler.NewStockLedgerIntegrityHandler(stockLedgerIntegrityService)
+
+	mux.HandleFunc("GET /stock/ledger-integrity", stockLedgerIntegrityHandler.StockLedgerIntegrityPage)
+	mux.HandleFunc("POST /stock/ledger-integrity/rebuild", stockLedgerIntegrityHandler.RebuildStockLedger)
+}
@@ -0,0 +1,23 @@
+package router
+
+import (
+	"app/internal/handler"
+	"app/internal/service"
+	"net/http"
+)
+
+func addStockLocationRoutes(
+	mux *http.ServeMux,
+	stockLocationService service.StockLocationService,
+) {
+	stockLocationHandler := handler.NewStockLocationHandler(stockLocationService)
+
+	mux.HandleFunc("GET /stock/locations", stockLocationHandler.StockLocationsPage)
+	mux.HandleFunc("POST /stock/locations", stockLocationHandler.CreateStockLocation)
+	mux.HandleFunc("GET /stock/locations/{id}", stockLocationHandler.StockLocationPage)
+	mux.HandleFunc("POST /stock/locations/{id}", stockLocationHandler.UpdateStockLocation)
+	mux.HandleFunc("POST /stock/locations/{id}/bins", stockLocationHandler.SaveStockBin)
+
+	mux.HandleFunc("GET /stock/get-locations", stockLocationHandler.GetStockLocations)
+	mux.HandleFunc("GET /stock/get-bins/{locationField}", stockLocationHandler.GetStockBins)
+}
@@ -0,0 +1,20 @@
+package router
+
+import (
+	"app/internal/handler"
+	"app/internal/service"
+	"net/http"
+)
+
+func addStockLotRoutes(
+	mux *http.ServeMux,
+	stockLotService service.StockLotService,
+) {
+	stockLotHandler := handler.NewStockLotHandler(stockLotService)
+
+	mux.HandleFunc("GET /stock/lots", stockLotHandler.StockLotsPage)
+	mux.HandleFunc("GET /stock/lots/{id}", stockLotHandler.StockLotPage)
+	mux.HandleFunc("POST /stock/lots/{id}", stockLotHandler.UpdateStockLot)
+
+	mux.HandleFunc("GET /stock/fefo-suggestions", stockLotHandler.FEFOSuggestions)
+}
@@ -0,0 +1,19 @@
+package router
+
+import (
+	"app/internal/handler"
+	"app/internal/service"
+	"net/http"
+)
+
+func addNegativeStockPolicyRoutes(
+	mux *http.ServeMux,
+	stockTransactionService service.StockTransactionService,
+	stockItemService service.StockItemService,
+) {
+	negativeStockPolicyHandler := handler.NewNegativeStockPolicyHandler(stockTransactionService, stockItemService)
+
+	mux.HandleFunc("GET /stock/negative-stock-policies", negativeStockPolicyHandler.NegativeStockPoliciesPage)
+	mux.HandleFunc("POST /stock/negative-stock-policies", negativeStockPolicyHandler.SaveNegativeStockPolicy)
+	mux.HandleFunc("POST /stock/negative-stock-policies/{id}/delete", negativeStockPolicyHandler.DeleteNegativeStockPolicy)
+}
@@ -0,0 +1,18 @@
+package router
+
+import (
+	"app/internal/handler"
+	"app/internal/service"
+	"net/http"
+)
+
+func addStockPeriodRoutes(
+	mux *http.ServeMux,
+	stockPeriodService service.StockPeriodService,
+) {
+	stockPeriodHandler := handler.NewStockPeriodHandler(stockPeriodService)
+
+	mux.HandleFunc("GET /stock/periods", stockPeriodHandler.StockPeriodsPage)
+	mux.HandleFunc("POST /stock/periods/close", stockPeriodHandler.CloseStockPeriod)
+	mux.HandleFunc("POST /stock/periods/reopen", stockPeriodHandler.ReopenStockPeriod)
+}
@@ -0,0 +1,22 @@
+package router
+
+import (
+	"app/internal/handler"
+	"app/internal/service"
+	"net/http"
+)
+
+func addStockReorderRoutes(
+	mux *http.ServeMux,
+	stockReorderService service.StockReorderService,
+	stockItemService service.StockItemService,
+	teamService service.TeamService,
+) {
+	stockReorderHandler := handler.NewStockReorderHandler(stockReorderService, stockItemService, teamService)
+
+	mux.HandleFunc("GET /stock/replenishment", stockReorderHandler.StockReplenishmentPage)
+
+	mux.HandleFunc("GET /stock/reorder-policies", stockReorderHandler.StockReorderPoliciesPage)
+	mux.HandleFunc("POST /stock/reorder-policies", stockReorderHandler.SaveStockReorderPolicy)
+	mux.HandleFunc("POST /stock/reorder-policies/{id}/delete", stockReorderHandler.DeleteStockReorderPolicy)
+}
@@ -0,0 +1,19 @@
+package router
+
+import (
+	"app/internal/handler"
+	"app/internal/service"
+	"net/http"
+)
+
+func addStockReservationRoutes(
+	mux *http.ServeMux,
+	stockReservationService service.StockReservationService,
+	stockItemService service.StockItemService,
+) {
+	stockReservationHandler := handler.NewStockReservationHandler(stockReservationService, stockItemService)
+
+	mux.HandleFunc("GET /stock/reservations", stockReservationHandler.StockReservationsPage)
+	mux.HandleFunc("POST /stock/reservations", stockReservationHandler.AddStockReservation)
+	mux.HandleFunc("POST /stock/reservations/{id}/release", stockReservationHandler.ReleaseStockReservation)
+}
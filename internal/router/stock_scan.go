@@ -0,0 +1,17 @@
+package router
+
+import (
+	"app/internal/handler"
+	"app/internal/service"
+	"net/http"
+)
+
+func addStockScanRoutes(
+	mux *http.ServeMux,
+	stockScanService service.StockScanService,
+) {
+	stockScanHandler := handler.NewStockScanHandler(stockScanService)
+
+	mux.HandleFunc("GET /stock/scan", stockScanHandler.StockScanPage)
+	mux.HandleFunc("POST /stock/scan", stockScanHandler.PostStockScan)
+}
@@ -0,0 +1,20 @@
+package router
+
+import (
+	"app/internal/handler"
+	"app/internal/service"
+	"net/http"
+)
+
+func addStockScrapRoutes(
+	mux *http.ServeMux,
+	stockScrapService service.StockScrapService,
+) {
+	stockScrapHandler := handler.NewStockScrapHandler(stockScrapService)
+
+	mux.HandleFunc("GET /stock/scrap-reasons", stockScrapHandler.ScrapReasonsPage)
+	mux.HandleFunc("POST /stock/scrap-reasons", stockScrapHandler.CreateScrapReason)
+	mux.HandleFunc("POST /stock/scrap-reasons/{id}", stockScrapHandler.UpdateScrapReason)
+
+	mux.HandleFunc("GET /stock/waste", stockScrapHandler.WasteReportPage)
+}
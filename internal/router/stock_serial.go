@@ -0,0 +1,17 @@
+package router
+
+import (
+	"app/internal/handler"
+	"app/internal/service"
+	"net/http"
+)
+
+func addStockSerialRoutes(
+	mux *http.ServeMux,
+	stockSerialService service.StockSerialService,
+) {
+	stockSerialHandler := handler.NewStockSerialHandler(stockSerialService)
+
+	mux.HandleFunc("GET /stock/serials", stockSerialHandler.StockSerialsPage)
+	mux.HandleFunc("GET /stock/serials/{id}", stockSerialHandler.StockSerialPage)
+}
@@ -9,15 +9,17 @@ import (
 func addStockTransactionRoutes(
 	mux *http.ServeMux,
 	stockItemService service.StockItemService,
+	stockReservationService service.StockReservationService,
 	stockTransactionService service.StockTransactionService,
 ) {
-	stockTransactionHandler := handler.NewStockTransactionHandler(stockTransactionService, stockItemService)
+	stockTransactionHandler := handler.NewStockTransactionHandler(stockTransactionService, stockItemService, stockReservationService)
 
 	// Stock Home page
 	mux.HandleFunc("GET /stock", stockTransactionHandler.StockLevelsPage)
 
 	// Stock transactions page
 	mux.HandleFunc("GET /stock/transactions", stockTransactionHandler.StockTransactionsPage)
+	mux.HandleFunc("POST /stock/transactions/{id}/reverse", stockTransactionHandler.ReverseStockTransaction)
 
 	// Stock details page
 	mux.HandleFunc("GET /stock/{id}", stockTransactionHandler.StockDetailsPage)
@@ -51,4 +53,12 @@ func addStockTransactionRoutes(
 	mux.HandleFunc("GET /stock/post-transaction/stock-adjustment", stockTransactionHandler.PostStockAdjustmentPage)
 	mux.HandleFunc("POST /stock/post-transaction/stock-adjustment", stockTransactionHandler.PostStockAdjustment)
 
+	// Quarantine
+	mux.HandleFunc("GET /stock/post-transaction/quarantine", stockTransactionHandler.PostQuarantinePage)
+	mux.HandleFunc("POST /stock/post-transaction/quarantine", stockTransactionHandler.PostQuarantine)
+	mux.HandleFunc("GET /stock/post-transaction/scrap", stockTransactionHandler.PostScrapPage)
+	mux.HandleFunc("POST /stock/post-transaction/scrap", stockTransactionHandler.PostScrap)
+	mux.HandleFunc("GET /stock/post-transaction/custom", stockTransactionHandler.PostCustomPage)
+	mux.HandleFunc("POST /stock/post-transaction/custom", stockTransactionHandler.PostCustom)
+
 }
@@ -0,0 +1,19 @@
+package router
+
+import (
+	"app/internal/handler"
+	"app/internal/service"
+	"net/http"
+)
+
+func addStockTransactionTypeRoutes(
+	mux *http.ServeMux,
+	stockTransactionTypeService service.StockTransactionTypeService,
+) {
+	stockTransactionTypeHandler := handler.NewStockTransactionTypeHandler(stockTransactionTypeService)
+
+	mux.HandleFunc("GET /stock/transaction-types", stockTransactionTypeHandler.StockTransactionTypesPage)
+	mux.HandleFunc("POST /stock/transaction-types", stockTransactionTypeHandler.CreateStockTransactionType)
+	mux.HandleFunc("GET /stock/transaction-types/{id}", stockTransactionTypeHandler.StockTransactionTypePage)
+	mux.HandleFunc("POST /stock/transaction-types/{id}", stockTransactionTypeHandler.UpdateStockTransactionType)
+}
@@ -5,6 +5,7 @@ import (
 	"app/internal/repository"
 	"context"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/ncw/swift/v2"
 )
@@ -81,3 +82,21 @@ func (s *FileService) CompleteFileUpload(
 
 	return nil
 }
+
+// SaveFileContent uploads file content from the server as part of the
+// caller's transaction, for files that are not uploaded by the browser
+func (s *FileService) SaveFileContent(
+	ctx context.Context,
+	tx pgx.Tx,
+	file *model.File,
+	content []byte,
+) (*model.File, error) {
+	return s.fileRepository.SaveFileContent(ctx, tx, s.swiftConn, file, content)
+}
+
+func (s *FileService) GetFileContent(
+	ctx context.Context,
+	fileID string,
+) ([]byte, error) {
+	return s.fileRepository.GetFileContent(ctx, s.swiftConn, fileID)
+}
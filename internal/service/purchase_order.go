@@ -0,0 +1,690 @@
+package service
+
+import (
+	"app/internal/model"
+	"app/internal/repository"
+	"app/pkg/validate"
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/shopspring/decimal"
+)
+
+type PurchaseOrderService struct {
+	db                         *pgxpool.Pool
+	purchaseOrderRepository    *repository.PurchaseOrderRepository
+	stockItemRepository        *repository.StockItemRepository
+	stockTransactionRepository *repository.StockTransactionRepository
+	supplierRepository         *repository.SupplierRepository
+	stockTransactionService    *StockTransactionService
+}
+
+func NewPurchaseOrderService(
+	db *pgxpool.Pool,
+	purchaseOrderRepository *repository.PurchaseOrderRepository,
+	stockItemRepository *repository.StockItemRepository,
+	stockTransactionRepository *repository.StockTransactionRepository,
+	supplierRepository *repository.SupplierRepository,
+	stockTransactionService *StockTransactionService,
+) *PurchaseOrderService {
+	return &PurchaseOrderService{
+		db:                         db,
+		purchaseOrderRepository:    purchaseOrderRepository,
+		stockItemRepository:        stockItemRepository,
+		stockTransactionRepository: stockTransactionRepository,
+		supplierRepository:         supplierRepository,
+		stockTransactionService:    stockTransactionService,
+	}
+}
+
+func (s *PurchaseOrderService) CreateSupplier(
+	ctx context.Context,
+	input *model.NewSupplier,
+	userID int,
+) (int, validate.ValidationErrors, error) {
+
+	var validationErrors validate.ValidationErrors = make(map[string][]string)
+
+	if input.Code == "" {
+		validationErrors.Add("Code", "is required")
+	}
+	validateSupplierDetails(validationErrors, input.Name, input.Email, input.OverReceiptTolerance)
+	if len(validationErrors) > 0 {
+		return 0, validationErrors, nil
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	existing, err := s.supplierRepository.GetSupplierByCode(ctx, tx, input.Code)
+	if err != nil {
+		return 0, nil, err
+	}
+	if existing != nil {
+		validationErrors.Add("Code", "already exists")
+		return 0, validationErrors, nil
+	}
+
+	supplierID, err := s.supplierRepository.CreateSupplier(ctx, tx, input, userID)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, nil, fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return supplierID, nil, nil
+}
+
+func (s *PurchaseOrderService) UpdateSupplier(
+	ctx context.Context,
+	supplierID int,
+	update *model.SupplierUpdate,
+	userID int,
+) (validate.ValidationErrors, error) {
+
+	var validationErrors validate.ValidationErrors = make(map[string][]string)
+
+	validateSupplierDetails(validationErrors, update.Name, update.Email, update.OverReceiptTolerance)
+	if len(validationErrors) > 0 {
+		return validationErrors, nil
+	}
+
+	err := s.supplierRepository.UpdateSupplier(ctx, s.db, supplierID, update, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+func (s *PurchaseOrderService) GetSupplier(
+	ctx context.Context,
+	supplierID int,
+) (*model.Supplier, error) {
+
+	supplier, err := s.supplierRepository.GetSupplier(ctx, s.db, supplierID)
+	if err != nil {
+		return nil, err
+	}
+
+	return supplier, nil
+}
+
+func (s *PurchaseOrderService) GetSuppliers(
+	ctx context.Context,
+	q *model.GetSuppliersQuery,
+) ([]model.Supplier, int, error) {
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return []model.Supplier{}, 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	suppliers, err := s.supplierRepository.GetSuppliers(ctx, tx, q)
+	if err != nil {
+		return []model.Supplier{}, 0, err
+	}
+
+	count, err := s.supplierRepository.GetSuppliersCount(ctx, tx, q)
+	if err != nil {
+		return []model.Supplier{}, 0, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return []model.Supplier{}, 0, err
+	}
+
+	return suppliers, count, nil
+}
+
+func (s *PurchaseOrderService) CreatePurchaseOrder(
+	ctx context.Context,
+	input *model.NewPurchaseOrder,
+	userID int,
+) (int, validate.ValidationErrors, error) {
+
+	var validationErrors validate.ValidationErrors = make(map[string][]string)
+
+	if input.SupplierID == 0 {
+		validationErrors.Add("SupplierID", "is required")
+	}
+	if input.Reference == "" {
+		validationErrors.Add("Reference", "is required")
+	}
+	if input.OrderDate.IsZero() {
+		validationErrors.Add("OrderDate", "is required")
+	}
+	if len(validationErrors) > 0 {
+		return 0, validationErrors, nil
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	supplier, err := s.supplierRepository.GetSupplier(ctx, tx, input.SupplierID)
+	if err != nil {
+		return 0, nil, err
+	}
+	if supplier == nil {
+		validationErrors.Add("SupplierID", "does not exist")
+	} else if supplier.IsArchived {
+		validationErrors.Add("SupplierID", "is archived")
+	}
+
+	existing, err := s.purchaseOrderRepository.GetPurchaseOrderByReference(ctx, tx, input.Reference)
+	if err != nil {
+		return 0, nil, err
+	}
+	if existing != nil {
+		validationErrors.Add("Reference", "already exists")
+	}
+
+	if len(validationErrors) > 0 {
+		return 0, validationErrors, nil
+	}
+
+	purchaseOrderID, err := s.purchaseOrderRepository.CreatePurchaseOrder(ctx, tx, input, userID)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, nil, fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return purchaseOrderID, nil, nil
+}
+
+func (s *PurchaseOrderService) GetPurchaseOrder(
+	ctx context.Context,
+	purchaseOrderID int,
+) (*model.PurchaseOrder, error) {
+
+	purchaseOrder, err := s.purchaseOrderRepository.GetPurchaseOrder(ctx, s.db, purchaseOrderID)
+	if err != nil {
+		return nil, err
+	}
+
+	return purchaseOrder, nil
+}
+
+func (s *PurchaseOrderService) GetPurchaseOrders(
+	ctx context.Context,
+	q *model.GetPurchaseOrdersQuery,
+) ([]model.PurchaseOrder, int, error) {
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return []model.PurchaseOrder{}, 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	purchaseOrders, err := s.purchaseOrderRepository.GetPurchaseOrders(ctx, tx, q)
+	if err != nil {
+		return []model.PurchaseOrder{}, 0, err
+	}
+
+	count, err := s.purchaseOrderRepository.GetPurchaseOrdersCount(ctx, tx, q)
+	if err != nil {
+		return []model.PurchaseOrder{}, 0, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return []model.PurchaseOrder{}, 0, err
+	}
+
+	return purchaseOrders, count, nil
+}
+
+func (s *PurchaseOrderService) GetPurchaseOrderLines(
+	ctx context.Context,
+	purchaseOrderID int,
+) ([]model.PurchaseOrderLine, error) {
+
+	lines, err := s.purchaseOrderRepository.GetPurchaseOrderLines(ctx, s.db, purchaseOrderID)
+	if err != nil {
+		return nil, err
+	}
+
+	return lines, nil
+}
+
+// GetPurchaseOrderReceipts returns the ledger entries of the goods receipts
+// against an order and their reversals
+func (s *PurchaseOrderService) GetPurchaseOrderReceipts(
+	ctx context.Context,
+	purchaseOrderID int,
+) ([]model.StockTransactionEntry, error) {
+
+	entries, err := s.stockTransactionRepository.GetStockTransactions(ctx, s.db, &model.GetTransactionsInput{
+		Account:         model.StockStockAccount,
+		PurchaseOrderID: purchaseOrderID,
+		Page:            1,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// AddPurchaseOrderLine adds a line to a draft or open order. Lines can be
+// added once an order is open to cover extra items agreed with the supplier.
+func (s *PurchaseOrderService) AddPurchaseOrderLine(
+	ctx context.Context,
+	purchaseOrderID int,
+	line *model.NewPurchaseOrderLine,
+) (validate.ValidationErrors, error) {
+
+	var validationErrors validate.ValidationErrors = make(map[string][]string)
+
+	if line.StockItemID == 0 {
+		validationErrors.Add("StockItemID", "is required")
+	}
+	if line.Qty.LessThanOrEqual(decimal.Zero) {
+		validationErrors.Add("Qty", "must be greater than 0")
+	}
+	if line.DueDate.IsZero() {
+		validationErrors.Add("DueDate", "is required")
+	}
+	if line.UnitPrice != nil && line.UnitPrice.IsNegative() {
+		validationErrors.Add("UnitPrice", "cannot be negative")
+	}
+	if len(validationErrors) > 0 {
+		return validationErrors, nil
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	status, err := s.purchaseOrderRepository.LockPurchaseOrder(ctx, tx, purchaseOrderID)
+	if err != nil {
+		return nil, err
+	}
+	if status == nil {
+		return nil, fmt.Errorf("purchase order does not exist")
+	}
+	if *status != model.DraftPurchaseOrderStatus && *status != model.OpenPurchaseOrderStatus {
+		return nil, fmt.Errorf("lines cannot be added to a purchase order that is %s", *status)
+	}
+
+	stockItem, err := s.stockItemRepository.GetStockItem(ctx, tx, line.StockItemID)
+	if err != nil {
+		return nil, err
+	}
+	if stockItem == nil {
+		validationErrors.Add("StockItemID", "does not exist")
+		return validationErrors, nil
+	}
+
+	_, err = s.purchaseOrderRepository.AddPurchaseOrderLine(ctx, tx, purchaseOrderID, line)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return nil, nil
+}
+
+// DeletePurchaseOrderLine removes a line that nothing has been received
+// against from a draft or open order
+func (s *PurchaseOrderService) DeletePurchaseOrderLine(
+	ctx context.Context,
+	purchaseOrderID int,
+	purchaseOrderLineID int,
+) error {
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	status, err := s.purchaseOrderRepository.LockPurchaseOrder(ctx, tx, purchaseOrderID)
+	if err != nil {
+		return err
+	}
+	if status == nil {
+		return fmt.Errorf("purchase order does not exist")
+	}
+	if *status != model.DraftPurchaseOrderStatus && *status != model.OpenPurchaseOrderStatus {
+		return fmt.Errorf("lines cannot be removed from a purchase order that is %s", *status)
+	}
+
+	line, err := s.purchaseOrderRepository.GetPurchaseOrderLine(ctx, tx, purchaseOrderLineID)
+	if err != nil {
+		return err
+	}
+	if line == nil || line.PurchaseOrderID != purchaseOrderID {
+		return fmt.Errorf("purchase order line does not exist")
+	}
+	if !line.ReceivedQty.IsZero() {
+		return fmt.Errorf("stock has been received against the line, close it instead")
+	}
+
+	err = s.purchaseOrderRepository.DeletePurchaseOrderLine(ctx, tx, purchaseOrderID, purchaseOrderLineID)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return nil
+}
+
+// IssuePurchaseOrder opens a draft order so that stock can be received
+// against it and its lines count as on order
+func (s *PurchaseOrderService) IssuePurchaseOrder(
+	ctx context.Context,
+	purchaseOrderID int,
+	userID int,
+) error {
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	status, err := s.purchaseOrderRepository.LockPurchaseOrder(ctx, tx, purchaseOrderID)
+	if err != nil {
+		return err
+	}
+	if status == nil {
+		return fmt.Errorf("purchase order does not exist")
+	}
+	if *status != model.DraftPurchaseOrderStatus {
+		return fmt.Errorf("only draft purchase orders can be issued, this order is %s", *status)
+	}
+
+	lines, err := s.purchaseOrderRepository.GetPurchaseOrderLines(ctx, tx, purchaseOrderID)
+	if err != nil {
+		return err
+	}
+	if len(lines) == 0 {
+		return fmt.Errorf("purchase order has no lines to issue")
+	}
+
+	err = s.purchaseOrderRepository.UpdatePurchaseOrderStatus(
+		ctx, tx, purchaseOrderID, model.OpenPurchaseOrderStatus, userID,
+	)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return nil
+}
+
+// ClosePurchaseOrder closes an open order and its open lines once nothing
+// more is expected against it, whether or not all of it was received
+func (s *PurchaseOrderService) ClosePurchaseOrder(
+	ctx context.Context,
+	purchaseOrderID int,
+	userID int,
+) error {
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	status, err := s.purchaseOrderRepository.LockPurchaseOrder(ctx, tx, purchaseOrderID)
+	if err != nil {
+		return err
+	}
+	if status == nil {
+		return fmt.Errorf("purchase order does not exist")
+	}
+	if *status != model.OpenPurchaseOrderStatus {
+		return fmt.Errorf("only open purchase orders can be closed, this order is %s", *status)
+	}
+
+	err = s.purchaseOrderRepository.ClosePurchaseOrderLines(ctx, tx, purchaseOrderID, 0)
+	if err != nil {
+		return err
+	}
+
+	err = s.purchaseOrderRepository.UpdatePurchaseOrderStatus(
+		ctx, tx, purchaseOrderID, model.ClosedPurchaseOrderStatus, userID,
+	)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return nil
+}
+
+// CancelPurchaseOrder cancels a draft order, or an open order that nothing
+// has been received against
+func (s *PurchaseOrderService) CancelPurchaseOrder(
+	ctx context.Context,
+	purchaseOrderID int,
+	userID int,
+) error {
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	status, err := s.purchaseOrderRepository.LockPurchaseOrder(ctx, tx, purchaseOrderID)
+	if err != nil {
+		return err
+	}
+	if status == nil {
+		return fmt.Errorf("purchase order does not exist")
+	}
+	if *status != model.DraftPurchaseOrderStatus && *status != model.OpenPurchaseOrderStatus {
+		return fmt.Errorf("only draft or open purchase orders can be cancelled, this order is %s", *status)
+	}
+
+	lines, err := s.purchaseOrderRepository.GetPurchaseOrderLines(ctx, tx, purchaseOrderID)
+	if err != nil {
+		return err
+	}
+	for _, l := range lines {
+		if !l.ReceivedQty.IsZero() {
+			return fmt.Errorf("stock has been received against the order, close it instead")
+		}
+	}
+
+	err = s.purchaseOrderRepository.UpdatePurchaseOrderStatus(
+		ctx, tx, purchaseOrderID, model.CancelledPurchaseOrderStatus, userID,
+	)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return nil
+}
+
+// ClosePurchaseOrderLine closes an open line of an open order, short of its
+// quantity, so that no more is expected against it
+func (s *PurchaseOrderService) ClosePurchaseOrderLine(
+	ctx context.Context,
+	purchaseOrderID int,
+	purchaseOrderLineID int,
+) error {
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	status, err := s.purchaseOrderRepository.LockPurchaseOrder(ctx, tx, purchaseOrderID)
+	if err != nil {
+		return err
+	}
+	if status == nil {
+		return fmt.Errorf("purchase order does not exist")
+	}
+	if *status != model.OpenPurchaseOrderStatus {
+		return fmt.Errorf("lines can only be closed on an open purchase order, this order is %s", *status)
+	}
+
+	err = s.purchaseOrderRepository.ClosePurchaseOrderLines(ctx, tx, purchaseOrderID, purchaseOrderLineID)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return nil
+}
+
+// ReceivePurchaseOrderLine posts a goods receipt against a line of an open
+// order, from the INBOUND account into STOCK at the unit price of the line.
+// Receipts may go over the ordered quantity by the over receipt tolerance of
+// the supplier. The received quantity of the line is updated as the receipt
+// is posted, see PostStockTransactions.
+func (s *PurchaseOrderService) ReceivePurchaseOrderLine(
+	ctx context.Context,
+	purchaseOrderID int,
+	input *model.ReceivePurchaseOrderLineInput,
+	userID int,
+) (validate.ValidationErrors, error) {
+
+	var validationErrors validate.ValidationErrors = make(map[string][]string)
+
+	if input.PurchaseOrderLineID == 0 {
+		validationErrors.Add("PurchaseOrderLineID", "is required")
+	}
+	if input.Qty.LessThanOrEqual(decimal.Zero) {
+		validationErrors.Add("Qty", "must be greater than 0")
+	}
+	if input.Location == "" {
+		validationErrors.Add("Location", "is required")
+	}
+	if len(validationErrors) > 0 {
+		return validationErrors, nil
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	status, err := s.purchaseOrderRepository.LockPurchaseOrder(ctx, tx, purchaseOrderID)
+	if err != nil {
+		return nil, err
+	}
+	if status == nil {
+		return nil, fmt.Errorf("purchase order does not exist")
+	}
+	if *status != model.OpenPurchaseOrderStatus {
+		return nil, fmt.Errorf("stock can only be received against an open purchase order, this order is %s", *status)
+	}
+
+	purchaseOrder, err := s.purchaseOrderRepository.GetPurchaseOrder(ctx, tx, purchaseOrderID)
+	if err != nil {
+		return nil, err
+	}
+
+	line, err := s.purchaseOrderRepository.GetPurchaseOrderLine(ctx, tx, input.PurchaseOrderLineID)
+	if err != nil {
+		return nil, err
+	}
+	if line == nil || line.PurchaseOrderID != purchaseOrderID {
+		validationErrors.Add("PurchaseOrderLineID", "is not on the purchase order")
+		return validationErrors, nil
+	}
+	if line.Status == model.ClosedPurchaseOrderLineStatus {
+		validationErrors.Add("PurchaseOrderLineID", "is closed")
+		return validationErrors, nil
+	}
+
+	receivable := line.ReceivableQty(purchaseOrder.OverReceiptTolerance)
+	if input.Qty.GreaterThan(receivable) {
+		validationErrors.Add("Qty", fmt.Sprintf(
+			"is more than the supplier's over receipt tolerance allows, at most %s %s can be received",
+			receivable.String(), line.Unit,
+		))
+		return validationErrors, nil
+	}
+
+	transactionNote := fmt.Sprintf("Purchase order %s", purchaseOrder.Reference)
+	if input.TransactionNote != "" {
+		transactionNote = fmt.Sprintf("%s: %s", transactionNote, input.TransactionNote)
+	}
+
+	err = s.stockTransactionService.PostStockTransactions(ctx, tx, &model.PostStockTransactionsInput{{
+		TransactionType:     model.GoodsReceiptTransactionType,
+		StockItemID:         line.StockItemID,
+		Qty:                 input.Qty,
+		FromLocation:        input.Location,
+		FromBin:             input.Bin,
+		FromLotNumber:       input.LotNumber,
+		ToLocation:          input.Location,
+		ToBin:               input.Bin,
+		ToLotNumber:         input.LotNumber,
+		TransactionNote:     transactionNote,
+		SerialNumbers:       input.SerialNumbers,
+		UnitCost:            line.UnitPrice,
+		PurchaseOrderLineID: &line.PurchaseOrderLineID,
+		Timestamp:           nil,
+	}}, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	s.stockTransactionService.NotifyStockReorder(ctx, line.StockItemID)
+
+	return nil, nil
+}
+
+func validateSupplierDetails(
+	ve validate.ValidationErrors,
+	name string,
+	email string,
+	overReceiptTolerance decimal.Decimal,
+) {
+	if name == "" {
+		ve.Add("Name", "is required")
+	}
+	if email != "" {
+		validate.Email(&ve, "Email", email)
+	}
+	if overReceiptTolerance.IsNegative() {
+		ve.Add("OverReceiptTolerance", "cannot be negative")
+	}
+}
@@ -0,0 +1,1088 @@
+package service
+
+import (
+	"app/internal/model"
+	"app/internal/repository"
+	"app/pkg/validate"
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/shopspring/decimal"
+)
+
+type SalesOrderService struct {
+	db                         *pgxpool.Pool
+	customerRepository         *repository.CustomerRepository
+	salesOrderRepository       *repository.SalesOrderRepository
+	stockItemRepository        *repository.StockItemRepository
+	stockLocationRepository    *repository.StockLocationRepository
+	stockLotRepository         *repository.StockLotRepository
+	stockReservationRepository *repository.StockReservationRepository
+	stockTransactionRepository *repository.StockTransactionRepository
+	stockTransactionService    *StockTransactionService
+}
+
+func NewSalesOrderService(
+	db *pgxpool.Pool,
+	customerRepository *repository.CustomerRepository,
+	salesOrderRepository *repository.SalesOrderRepository,
+	stockItemRepository *repository.StockItemRepository,
+	stockLocationRepository *repository.StockLocationRepository,
+	stockLotRepository *repository.StockLotRepository,
+	stockReservationRepository *repository.StockReservationRepository,
+	stockTransactionRepository *repository.StockTransactionRepository,
+	stockTransactionService *StockTransactionService,
+) *SalesOrderService {
+	return &SalesOrderService{
+		db:                         db,
+		customerRepository:         customerRepository,
+		salesOrderRepository:       salesOrderRepository,
+		stockItemRepository:        stockItemRepository,
+		stockLocationRepository:    stockLocationRepository,
+		stockLotRepository:         stockLotRepository,
+		stockReservationRepository: stockReservationRepository,
+		stockTransactionRepository: stockTransactionRepository,
+		stockTransactionService:    stockTransactionService,
+	}
+}
+
+func (s *SalesOrderService) CreateCustomer(
+	ctx context.Context,
+	input *model.NewCustomer,
+	userID int,
+) (int, validate.ValidationErrors, error) {
+
+	var validationErrors validate.ValidationErrors = make(map[string][]string)
+
+	if input.Code == "" {
+		validationErrors.Add("Code", "is required")
+	}
+	validateCustomerDetails(validationErrors, input.Name, input.Email)
+	if len(validationErrors) > 0 {
+		return 0, validationErrors, nil
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	existing, err := s.customerRepository.GetCustomerByCode(ctx, tx, input.Code)
+	if err != nil {
+		return 0, nil, err
+	}
+	if existing != nil {
+		validationErrors.Add("Code", "already exists")
+		return 0, validationErrors, nil
+	}
+
+	customerID, err := s.customerRepository.CreateCustomer(ctx, tx, input, userID)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, nil, fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return customerID, nil, nil
+}
+
+func (s *SalesOrderService) UpdateCustomer(
+	ctx context.Context,
+	customerID int,
+	update *model.CustomerUpdate,
+	userID int,
+) (validate.ValidationErrors, error) {
+
+	var validationErrors validate.ValidationErrors = make(map[string][]string)
+
+	validateCustomerDetails(validationErrors, update.Name, update.Email)
+	if len(validationErrors) > 0 {
+		return validationErrors, nil
+	}
+
+	err := s.customerRepository.UpdateCustomer(ctx, s.db, customerID, update, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+func (s *SalesOrderService) GetCustomer(
+	ctx context.Context,
+	customerID int,
+) (*model.Customer, error) {
+
+	customer, err := s.customerRepository.GetCustomer(ctx, s.db, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	return customer, nil
+}
+
+func (s *SalesOrderService) GetCustomers(
+	ctx context.Context,
+	q *model.GetCustomersQuery,
+) ([]model.Customer, int, error) {
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return []model.Customer{}, 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	customers, err := s.customerRepository.GetCustomers(ctx, tx, q)
+	if err != nil {
+		return []model.Customer{}, 0, err
+	}
+
+	count, err := s.customerRepository.GetCustomersCount(ctx, tx, q)
+	if err != nil {
+		return []model.Customer{}, 0, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return []model.Customer{}, 0, err
+	}
+
+	return customers, count, nil
+}
+
+func (s *SalesOrderService) CreateSalesOrder(
+	ctx context.Context,
+	input *model.NewSalesOrder,
+	userID int,
+) (int, validate.ValidationErrors, error) {
+
+	var validationErrors validate.ValidationErrors = make(map[string][]string)
+
+	if input.CustomerID == 0 {
+		validationErrors.Add("CustomerID", "is required")
+	}
+	if input.Reference == "" {
+		validationErrors.Add("Reference", "is required")
+	}
+	if input.OrderDate.IsZero() {
+		validationErrors.Add("OrderDate", "is required")
+	}
+	if len(validationErrors) > 0 {
+		return 0, validationErrors, nil
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	customer, err := s.customerRepository.GetCustomer(ctx, tx, input.CustomerID)
+	if err != nil {
+		return 0, nil, err
+	}
+	if customer == nil {
+		validationErrors.Add("CustomerID", "does not exist")
+	} else if customer.IsArchived {
+		validationErrors.Add("CustomerID", "is archived")
+	}
+
+	existing, err := s.salesOrderRepository.GetSalesOrderByReference(ctx, tx, input.Reference)
+	if err != nil {
+		return 0, nil, err
+	}
+	if existing != nil {
+		validationErrors.Add("Reference", "already exists")
+	}
+
+	if len(validationErrors) > 0 {
+		return 0, validationErrors, nil
+	}
+
+	salesOrderID, err := s.salesOrderRepository.CreateSalesOrder(ctx, tx, input, userID)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, nil, fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return salesOrderID, nil, nil
+}
+
+func (s *SalesOrderService) GetSalesOrder(
+	ctx context.Context,
+	salesOrderID int,
+) (*model.SalesOrder, error) {
+
+	salesOrder, err := s.salesOrderRepository.GetSalesOrder(ctx, s.db, salesOrderID)
+	if err != nil {
+		return nil, err
+	}
+
+	return salesOrder, nil
+}
+
+func (s *SalesOrderService) GetSalesOrders(
+	ctx context.Context,
+	q *model.GetSalesOrdersQuery,
+) ([]model.SalesOrder, int, error) {
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return []model.SalesOrder{}, 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	salesOrders, err := s.salesOrderRepository.GetSalesOrders(ctx, tx, q)
+	if err != nil {
+		return []model.SalesOrder{}, 0, err
+	}
+
+	count, err := s.salesOrderRepository.GetSalesOrdersCount(ctx, tx, q)
+	if err != nil {
+		return []model.SalesOrder{}, 0, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return []model.SalesOrder{}, 0, err
+	}
+
+	return salesOrders, count, nil
+}
+
+func (s *SalesOrderService) GetSalesOrderLines(
+	ctx context.Context,
+	salesOrderID int,
+) ([]model.SalesOrderLine, error) {
+
+	lines, err := s.salesOrderRepository.GetSalesOrderLines(ctx, s.db, salesOrderID)
+	if err != nil {
+		return nil, err
+	}
+
+	return lines, nil
+}
+
+// GetSalesOrderDispatches returns the ledger entries of the dispatches
+// against an order and their reversals
+func (s *SalesOrderService) GetSalesOrderDispatches(
+	ctx context.Context,
+	salesOrderID int,
+) ([]model.StockTransactionEntry, error) {
+
+	entries, err := s.stockTransactionRepository.GetStockTransactions(ctx, s.db, &model.GetTransactionsInput{
+		Account:      model.StockStockAccount,
+		SalesOrderID: salesOrderID,
+		Page:         1,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// AddSalesOrderLine adds a line to a draft or open order
+func (s *SalesOrderService) AddSalesOrderLine(
+	ctx context.Context,
+	salesOrderID int,
+	line *model.NewSalesOrderLine,
+) (validate.ValidationErrors, error) {
+
+	var validationErrors validate.ValidationErrors = make(map[string][]string)
+
+	if line.StockItemID == 0 {
+		validationErrors.Add("StockItemID", "is required")
+	}
+	if line.Qty.LessThanOrEqual(decimal.Zero) {
+		validationErrors.Add("Qty", "must be greater than 0")
+	}
+	if line.DueDate.IsZero() {
+		validationErrors.Add("DueDate", "is required")
+	}
+	if line.UnitPrice != nil && line.UnitPrice.IsNegative() {
+		validationErrors.Add("UnitPrice", "cannot be negative")
+	}
+	if len(validationErrors) > 0 {
+		return validationErrors, nil
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	status, err := s.salesOrderRepository.LockSalesOrder(ctx, tx, salesOrderID)
+	if err != nil {
+		return nil, err
+	}
+	if status == nil {
+		return nil, fmt.Errorf("sales order does not exist")
+	}
+	if *status != model.DraftSalesOrderStatus && *status != model.OpenSalesOrderStatus {
+		return nil, fmt.Errorf("lines cannot be added to a sales order that is %s", *status)
+	}
+
+	stockItem, err := s.stockItemRepository.GetStockItem(ctx, tx, line.StockItemID)
+	if err != nil {
+		return nil, err
+	}
+	if stockItem == nil {
+		validationErrors.Add("StockItemID", "does not exist")
+		return validationErrors, nil
+	}
+
+	_, err = s.salesOrderRepository.AddSalesOrderLine(ctx, tx, salesOrderID, line)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return nil, nil
+}
+
+// DeleteSalesOrderLine removes a line that is not on any pick list from a
+// draft or open order
+func (s *SalesOrderService) DeleteSalesOrderLine(
+	ctx context.Context,
+	salesOrderID int,
+	salesOrderLineID int,
+) error {
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	status, err := s.salesOrderRepository.LockSalesOrder(ctx, tx, salesOrderID)
+	if err != nil {
+		return err
+	}
+	if status == nil {
+		return fmt.Errorf("sales order does not exist")
+	}
+	if *status != model.DraftSalesOrderStatus && *status != model.OpenSalesOrderStatus {
+		return fmt.Errorf("lines cannot be removed from a sales order that is %s", *status)
+	}
+
+	line, err := s.salesOrderRepository.GetSalesOrderLine(ctx, tx, salesOrderLineID)
+	if err != nil {
+		return err
+	}
+	if line == nil || line.SalesOrderID != salesOrderID {
+		return fmt.Errorf("sales order line does not exist")
+	}
+	if !line.DispatchedQty.IsZero() {
+		return fmt.Errorf("stock has been dispatched against the line, close it instead")
+	}
+
+	pickLists, err := s.salesOrderRepository.GetPickLists(ctx, tx, salesOrderID)
+	if err != nil {
+		return err
+	}
+	for _, pl := range pickLists {
+		lines, err := s.salesOrderRepository.GetPickListLines(ctx, tx, pl.PickListID)
+		if err != nil {
+			return err
+		}
+		for _, l := range lines {
+			if l.SalesOrderLineID == salesOrderLineID {
+				return fmt.Errorf("the line is on pick list %d, close it instead", pl.PickListID)
+			}
+		}
+	}
+
+	err = s.salesOrderRepository.DeleteSalesOrderLine(ctx, tx, salesOrderID, salesOrderLineID)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return nil
+}
+
+// IssueSalesOrder opens a draft order so that pick lists can be generated
+// for it
+func (s *SalesOrderService) IssueSalesOrder(
+	ctx context.Context,
+	salesOrderID int,
+	userID int,
+) error {
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	status, err := s.salesOrderRepository.LockSalesOrder(ctx, tx, salesOrderID)
+	if err != nil {
+		return err
+	}
+	if status == nil {
+		return fmt.Errorf("sales order does not exist")
+	}
+	if *status != model.DraftSalesOrderStatus {
+		return fmt.Errorf("only draft sales orders can be issued, this order is %s", *status)
+	}
+
+	lines, err := s.salesOrderRepository.GetSalesOrderLines(ctx, tx, salesOrderID)
+	if err != nil {
+		return err
+	}
+	if len(lines) == 0 {
+		return fmt.Errorf("sales order has no lines to issue")
+	}
+
+	err = s.salesOrderRepository.UpdateSalesOrderStatus(
+		ctx, tx, salesOrderID, model.OpenSalesOrderStatus, userID,
+	)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return nil
+}
+
+// CloseSalesOrder closes an open order and its open lines once nothing more
+// will be dispatched against it. Open pick lists must be dispatched or
+// cancelled first.
+func (s *SalesOrderService) CloseSalesOrder(
+	ctx context.Context,
+	salesOrderID int,
+	userID int,
+) error {
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	status, err := s.salesOrderRepository.LockSalesOrder(ctx, tx, salesOrderID)
+	if err != nil {
+		return err
+	}
+	if status == nil {
+		return fmt.Errorf("sales order does not exist")
+	}
+	if *status != model.OpenSalesOrderStatus {
+		return fmt.Errorf("only open sales orders can be closed, this order is %s", *status)
+	}
+
+	err = s.checkNoOpenPickLists(ctx, tx, salesOrderID)
+	if err != nil {
+		return err
+	}
+
+	err = s.salesOrderRepository.CloseSalesOrderLines(ctx, tx, salesOrderID, 0)
+	if err != nil {
+		return err
+	}
+
+	err = s.salesOrderRepository.UpdateSalesOrderStatus(
+		ctx, tx, salesOrderID, model.ClosedSalesOrderStatus, userID,
+	)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return nil
+}
+
+// CancelSalesOrder cancels a draft order, or an open order that nothing has
+// been dispatched against and that has no open pick lists
+func (s *SalesOrderService) CancelSalesOrder(
+	ctx context.Context,
+	salesOrderID int,
+	userID int,
+) error {
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	status, err := s.salesOrderRepository.LockSalesOrder(ctx, tx, salesOrderID)
+	if err != nil {
+		return err
+	}
+	if status == nil {
+		return fmt.Errorf("sales order does not exist")
+	}
+	if *status != model.DraftSalesOrderStatus && *status != model.OpenSalesOrderStatus {
+		return fmt.Errorf("only draft or open sales orders can be cancelled, this order is %s", *status)
+	}
+
+	lines, err := s.salesOrderRepository.GetSalesOrderLines(ctx, tx, salesOrderID)
+	if err != nil {
+		return err
+	}
+	for _, l := range lines {
+		if !l.DispatchedQty.IsZero() {
+			return fmt.Errorf("stock has been dispatched against the order, close it instead")
+		}
+	}
+
+	err = s.checkNoOpenPickLists(ctx, tx, salesOrderID)
+	if err != nil {
+		return err
+	}
+
+	err = s.salesOrderRepository.UpdateSalesOrderStatus(
+		ctx, tx, salesOrderID, model.CancelledSalesOrderStatus, userID,
+	)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return nil
+}
+
+// CloseSalesOrderLine closes an open line of an open order, short of its
+// quantity, so that no more is dispatched against it
+func (s *SalesOrderService) CloseSalesOrderLine(
+	ctx context.Context,
+	salesOrderID int,
+	salesOrderLineID int,
+) error {
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	status, err := s.salesOrderRepository.LockSalesOrder(ctx, tx, salesOrderID)
+	if err != nil {
+		return err
+	}
+	if status == nil {
+		return fmt.Errorf("sales order does not exist")
+	}
+	if *status != model.OpenSalesOrderStatus {
+		return fmt.Errorf("lines can only be closed on an open sales order, this order is %s", *status)
+	}
+
+	line, err := s.salesOrderRepository.GetSalesOrderLine(ctx, tx, salesOrderLineID)
+	if err != nil {
+		return err
+	}
+	if line == nil || line.SalesOrderID != salesOrderID {
+		return fmt.Errorf("sales order line does not exist")
+	}
+	if !line.PickingQty.IsZero() {
+		return fmt.Errorf("the line is on an open pick list, dispatch or cancel it first")
+	}
+
+	err = s.salesOrderRepository.CloseSalesOrderLines(ctx, tx, salesOrderID, salesOrderLineID)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return nil
+}
+
+func (s *SalesOrderService) checkNoOpenPickLists(
+	ctx context.Context,
+	tx pgx.Tx,
+	salesOrderID int,
+) error {
+
+	pickLists, err := s.salesOrderRepository.GetPickLists(ctx, tx, salesOrderID)
+	if err != nil {
+		return err
+	}
+	for _, pl := range pickLists {
+		if pl.Status == model.OpenPickListStatus {
+			return fmt.Errorf("pick list %d is open, dispatch or cancel it first", pl.PickListID)
+		}
+	}
+
+	return nil
+}
+
+func (s *SalesOrderService) GetPickList(
+	ctx context.Context,
+	pickListID int,
+) (*model.PickList, error) {
+
+	pickList, err := s.salesOrderRepository.GetPickList(ctx, s.db, pickListID)
+	if err != nil {
+		return nil, err
+	}
+
+	return pickList, nil
+}
+
+func (s *SalesOrderService) GetPickLists(
+	ctx context.Context,
+	salesOrderID int,
+) ([]model.PickList, error) {
+
+	pickLists, err := s.salesOrderRepository.GetPickLists(ctx, s.db, salesOrderID)
+	if err != nil {
+		return nil, err
+	}
+
+	return pickLists, nil
+}
+
+func (s *SalesOrderService) GetPickListLines(
+	ctx context.Context,
+	pickListID int,
+) ([]model.PickListLine, error) {
+
+	lines, err := s.salesOrderRepository.GetPickListLines(ctx, s.db, pickListID)
+	if err != nil {
+		return nil, err
+	}
+
+	return lines, nil
+}
+
+// pickablePlace is stock at a place in the STOCK account that a pick list
+// can propose to pick from
+type pickablePlace struct {
+	location   string
+	bin        string
+	lotNumber  string
+	qty        decimal.Decimal
+	expiryDate *time.Time
+	timestamp  time.Time
+}
+
+// getPickablePlaces returns where a stock item can be picked from for an
+// order, first expired first out and then oldest first. Only storage
+// locations and available, unexpired lots are picked from. What other open
+// reservations hold and what open pick lists already propose to pick is not
+// pickable, but stock reserved for the order itself is.
+func (s *SalesOrderService) getPickablePlaces(
+	ctx context.Context,
+	tx pgx.Tx,
+	stockItem *model.StockItem,
+	demandReference string,
+) ([]pickablePlace, error) {
+
+	levels, err := s.stockTransactionRepository.GetStockLevels(ctx, tx, &model.GetStockLevelsInput{
+		Account:   model.StockStockAccount,
+		StockCode: stockItem.StockCode,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	reservations, err := s.stockReservationRepository.GetStockReservations(ctx, tx, &model.GetStockReservationsQuery{
+		StockCode:       stockItem.StockCode,
+		DemandReference: demandReference,
+		Status:          model.OpenStockReservationStatus,
+		PageSize:        1000,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	openPicks, err := s.salesOrderRepository.GetOpenPickListLines(ctx, tx, stockItem.StockItemID)
+	if err != nil {
+		return nil, err
+	}
+
+	isStorage := map[string]bool{}
+	today := time.Now()
+
+	places := []pickablePlace{}
+	for _, sl := range levels {
+		storage, ok := isStorage[sl.Location]
+		if !ok {
+			location, err := s.stockLocationRepository.GetStockLocationByName(ctx, tx, sl.Location)
+			if err != nil {
+				return nil, err
+			}
+			storage = location != nil && !location.IsArchived &&
+				location.LocationType == model.StorageStockLocationType
+			isStorage[sl.Location] = storage
+		}
+		if !storage {
+			continue
+		}
+
+		if sl.LotNumber != "" {
+			if model.IsStockLotExpired(sl.ExpiryDate, today) {
+				continue
+			}
+			lots, err := s.stockLotRepository.GetStockLots(ctx, tx, &model.GetStockLotsQuery{
+				StockCode: stockItem.StockCode,
+				LotNumber: sl.LotNumber,
+			})
+			if err != nil {
+				return nil, err
+			}
+			if len(lots) > 0 && lots[0].Status != model.AvailableStockLotStatus {
+				continue
+			}
+		}
+
+		qty := sl.Available()
+		for _, r := range reservations {
+			if r.Covers(sl.Location, sl.Bin, sl.LotNumber) {
+				qty = qty.Add(r.Qty)
+			}
+		}
+		for _, l := range openPicks {
+			if l.Location == sl.Location && l.Bin == sl.Bin && l.LotNumber == sl.LotNumber {
+				qty = qty.Sub(l.Qty)
+			}
+		}
+		if !qty.IsPositive() {
+			continue
+		}
+
+		places = append(places, pickablePlace{
+			location:   sl.Location,
+			bin:        sl.Bin,
+			lotNumber:  sl.LotNumber,
+			qty:        qty,
+			expiryDate: sl.ExpiryDate,
+			timestamp:  sl.Timestamp,
+		})
+	}
+
+	sort.SliceStable(places, func(i, j int) bool {
+		a, b := places[i], places[j]
+		switch {
+		case a.expiryDate != nil && b.expiryDate == nil:
+			return true
+		case a.expiryDate == nil && b.expiryDate != nil:
+			return false
+		case a.expiryDate != nil && !a.expiryDate.Equal(*b.expiryDate):
+			return a.expiryDate.Before(*b.expiryDate)
+		}
+		return a.timestamp.Before(b.timestamp)
+	})
+
+	return places, nil
+}
+
+// GeneratePickList creates a pick list for what is still to be picked of the
+// open lines of an open order, proposing where to pick each line from the
+// current stock levels. Lines are picked short when there is not enough
+// stock, and an error is returned if nothing at all can be picked.
+func (s *SalesOrderService) GeneratePickList(
+	ctx context.Context,
+	salesOrderID int,
+	userID int,
+) (int, error) {
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	status, err := s.salesOrderRepository.LockSalesOrder(ctx, tx, salesOrderID)
+	if err != nil {
+		return 0, err
+	}
+	if status == nil {
+		return 0, fmt.Errorf("sales order does not exist")
+	}
+	if *status != model.OpenSalesOrderStatus {
+		return 0, fmt.Errorf("pick lists can only be generated for an open sales order, this order is %s", *status)
+	}
+
+	salesOrder, err := s.salesOrderRepository.GetSalesOrder(ctx, tx, salesOrderID)
+	if err != nil {
+		return 0, err
+	}
+
+	lines, err := s.salesOrderRepository.GetSalesOrderLines(ctx, tx, salesOrderID)
+	if err != nil {
+		return 0, err
+	}
+
+	// Places are shared by the lines of a stock item, so that two lines for
+	// the same item are not proposed the same stock
+	placesByStockItem := map[int][]pickablePlace{}
+
+	pickLines := []model.NewPickListLine{}
+	for _, line := range lines {
+		toPick := line.UnpickedQty()
+		if line.Status != model.OpenSalesOrderLineStatus || !toPick.IsPositive() {
+			continue
+		}
+
+		places, ok := placesByStockItem[line.StockItemID]
+		if !ok {
+			stockItem, err := s.stockItemRepository.GetStockItem(ctx, tx, line.StockItemID)
+			if err != nil {
+				return 0, err
+			}
+			places, err = s.getPickablePlaces(ctx, tx, stockItem, salesOrder.Reference)
+			if err != nil {
+				return 0, err
+			}
+		}
+
+		for i := range places {
+			if !toPick.IsPositive() {
+				break
+			}
+			qty := decimal.Min(toPick, places[i].qty)
+			if !qty.IsPositive() {
+				continue
+			}
+
+			pickLines = append(pickLines, model.NewPickListLine{
+				SalesOrderLineID: line.SalesOrderLineID,
+				Location:         places[i].location,
+				Bin:              places[i].bin,
+				LotNumber:        places[i].lotNumber,
+				Qty:              qty,
+			})
+			places[i].qty = places[i].qty.Sub(qty)
+			toPick = toPick.Sub(qty)
+		}
+
+		placesByStockItem[line.StockItemID] = places
+	}
+
+	if len(pickLines) == 0 {
+		return 0, fmt.Errorf("no stock available to pick for the outstanding lines of the order")
+	}
+
+	pickListID, err := s.salesOrderRepository.CreatePickList(ctx, tx, salesOrderID, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	for i := range pickLines {
+		_, err = s.salesOrderRepository.AddPickListLine(ctx, tx, pickListID, &pickLines[i])
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return pickListID, nil
+}
+
+// CancelPickList cancels an open pick list, freeing the stock it proposed to
+// pick
+func (s *SalesOrderService) CancelPickList(
+	ctx context.Context,
+	pickListID int,
+	userID int,
+) error {
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	status, err := s.salesOrderRepository.LockPickList(ctx, tx, pickListID)
+	if err != nil {
+		return err
+	}
+	if status == nil {
+		return fmt.Errorf("pick list does not exist")
+	}
+	if *status != model.OpenPickListStatus {
+		return fmt.Errorf("only open pick lists can be cancelled, this pick list is %s", *status)
+	}
+
+	err = s.salesOrderRepository.UpdatePickListStatus(
+		ctx, tx, pickListID, model.CancelledPickListStatus, userID,
+	)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return nil
+}
+
+// DispatchPickList confirms what was picked of an open pick list and posts
+// it from STOCK to the DISPATCHED account, against the demand reference of
+// the order so that stock reserved for it is drawn down. The dispatched
+// quantity of each sales order line is updated as the dispatch is posted, see
+// PostStockTransactions.
+func (s *SalesOrderService) DispatchPickList(
+	ctx context.Context,
+	pickListID int,
+	input *model.DispatchPickListInput,
+	userID int,
+) error {
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	pickListStatus, err := s.salesOrderRepository.LockPickList(ctx, tx, pickListID)
+	if err != nil {
+		return err
+	}
+	if pickListStatus == nil {
+		return fmt.Errorf("pick list does not exist")
+	}
+	if *pickListStatus != model.OpenPickListStatus {
+		return fmt.Errorf("only open pick lists can be dispatched, this pick list is %s", *pickListStatus)
+	}
+
+	pickList, err := s.salesOrderRepository.GetPickList(ctx, tx, pickListID)
+	if err != nil {
+		return err
+	}
+
+	status, err := s.salesOrderRepository.LockSalesOrder(ctx, tx, pickList.SalesOrderID)
+	if err != nil {
+		return err
+	}
+	if *status != model.OpenSalesOrderStatus {
+		return fmt.Errorf("stock can only be dispatched against an open sales order, this order is %s", *status)
+	}
+
+	salesOrder, err := s.salesOrderRepository.GetSalesOrder(ctx, tx, pickList.SalesOrderID)
+	if err != nil {
+		return err
+	}
+
+	lines, err := s.salesOrderRepository.GetPickListLines(ctx, tx, pickListID)
+	if err != nil {
+		return err
+	}
+
+	picked := map[int]model.DispatchPickListLineInput{}
+	for _, l := range input.Lines {
+		picked[l.PickListLineID] = l
+	}
+
+	transactionNote := fmt.Sprintf("Sales order %s, pick list %d", salesOrder.Reference, pickListID)
+	if input.TransactionNote != "" {
+		transactionNote = fmt.Sprintf("%s: %s", transactionNote, input.TransactionNote)
+	}
+
+	postings := model.PostStockTransactionsInput{}
+	for _, line := range lines {
+		p := picked[line.PickListLineID]
+
+		if p.Qty.IsNegative() {
+			return fmt.Errorf("the quantity dispatched of %s cannot be negative", line.StockCode)
+		}
+		if p.Qty.GreaterThan(line.Qty) {
+			return fmt.Errorf(
+				"more of %s cannot be dispatched than the %s %s on the pick list",
+				line.StockCode, line.Qty.String(), line.Unit,
+			)
+		}
+		if p.Qty.IsZero() {
+			continue
+		}
+
+		postings = append(postings, model.NewStockTransaction{
+			TransactionType:          model.DispatchTransactionType,
+			StockItemID:              line.StockItemID,
+			Qty:                      p.Qty,
+			FromLocation:             line.Location,
+			FromBin:                  line.Bin,
+			FromLotNumber:            line.LotNumber,
+			ToLocation:               line.Location,
+			ToBin:                    line.Bin,
+			ToLotNumber:              line.LotNumber,
+			TransactionNote:          transactionNote,
+			SerialNumbers:            p.SerialNumbers,
+			SalesOrderLineID:         &line.SalesOrderLineID,
+			DemandReference:          salesOrder.Reference,
+			Timestamp:                nil,
+			AcknowledgeNegativeStock: input.AcknowledgeNegativeStock,
+		})
+	}
+
+	if len(postings) == 0 {
+		return fmt.Errorf("nothing has been picked, cancel the pick list instead")
+	}
+
+	err = s.stockTransactionService.PostStockTransactions(ctx, tx, &postings, userID)
+	if err != nil {
+		return err
+	}
+
+	for _, line := range lines {
+		p := picked[line.PickListLineID]
+		err = s.salesOrderRepository.SetPickListLineDispatched(
+			ctx, tx, line.PickListLineID, p.Qty, p.SerialNumbers,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = s.salesOrderRepository.UpdatePickListStatus(
+		ctx, tx, pickListID, model.DispatchedPickListStatus, userID,
+	)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	s.stockTransactionService.NotifyStockReorder(ctx, postings.StockItemIDs()...)
+
+	return nil
+}
+
+func validateCustomerDetails(
+	ve validate.ValidationErrors,
+	name string,
+	email string,
+) {
+	if name == "" {
+		ve.Add("Name", "is required")
+	}
+	if email != "" {
+		validate.Email(&ve, "Email", email)
+	}
+}
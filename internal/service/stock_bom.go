@@ -0,0 +1,250 @@
+package service
+
+import (
+	"app/internal/model"
+	"app/internal/repository"
+	"app/pkg/validate"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/shopspring/decimal"
+)
+
+type StockBOMService struct {
+	db                  *pgxpool.Pool
+	stockBOMRepository  *repository.StockBOMRepository
+	stockItemRepository *repository.StockItemRepository
+}
+
+func NewStockBOMService(
+	db *pgxpool.Pool,
+	stockBOMRepository *repository.StockBOMRepository,
+	stockItemRepository *repository.StockItemRepository,
+) *StockBOMService {
+	return &StockBOMService{
+		db:                  db,
+		stockBOMRepository:  stockBOMRepository,
+		stockItemRepository: stockItemRepository,
+	}
+}
+
+func (s *StockBOMService) CreateStockBOM(
+	ctx context.Context,
+	stockItemID int,
+	input *model.NewStockBOM,
+	userID int,
+) (int, validate.ValidationErrors, error) {
+
+	var validationErrors validate.ValidationErrors = make(map[string][]string)
+
+	if input.Version == "" {
+		validationErrors.Add("Version", "is required")
+	}
+	validateStockBOMDates(validationErrors, input.EffectiveFrom, input.EffectiveTo)
+	if len(validationErrors) > 0 {
+		return 0, validationErrors, nil
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	existing, err := s.stockBOMRepository.GetStockBOMByVersion(ctx, tx, stockItemID, input.Version)
+	if err != nil {
+		return 0, nil, err
+	}
+	if existing != nil {
+		validationErrors.Add("Version", "already exists for the stock item")
+		return 0, validationErrors, nil
+	}
+
+	stockBOMID, err := s.stockBOMRepository.CreateStockBOM(ctx, tx, stockItemID, input, userID)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, nil, fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return stockBOMID, nil, nil
+}
+
+// UpdateStockBOM changes when a version is in effect. Versions are not
+// deleted, end them instead so that the history of what was used remains.
+func (s *StockBOMService) UpdateStockBOM(
+	ctx context.Context,
+	stockBOMID int,
+	update *model.StockBOMUpdate,
+	userID int,
+) (validate.ValidationErrors, error) {
+
+	var validationErrors validate.ValidationErrors = make(map[string][]string)
+
+	validateStockBOMDates(validationErrors, update.EffectiveFrom, update.EffectiveTo)
+	if len(validationErrors) > 0 {
+		return validationErrors, nil
+	}
+
+	err := s.stockBOMRepository.UpdateStockBOM(ctx, s.db, stockBOMID, update, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+func (s *StockBOMService) GetStockBOM(
+	ctx context.Context,
+	stockBOMID int,
+) (*model.StockBOM, error) {
+
+	bom, err := s.stockBOMRepository.GetStockBOM(ctx, s.db, stockBOMID)
+	if err != nil {
+		return nil, err
+	}
+
+	return bom, nil
+}
+
+func (s *StockBOMService) GetStockBOMs(
+	ctx context.Context,
+	stockItemID int,
+) ([]model.StockBOM, error) {
+
+	boms, err := s.stockBOMRepository.GetStockBOMs(ctx, s.db, stockItemID)
+	if err != nil {
+		return nil, err
+	}
+
+	return boms, nil
+}
+
+func (s *StockBOMService) GetStockBOMLines(
+	ctx context.Context,
+	stockBOMID int,
+) ([]model.StockBOMLine, error) {
+
+	lines, err := s.stockBOMRepository.GetStockBOMLines(ctx, s.db, stockBOMID)
+	if err != nil {
+		return nil, err
+	}
+
+	return lines, nil
+}
+
+// GetStockBOMWhereUsed returns every bill of materials a stock item is a
+// component of
+func (s *StockBOMService) GetStockBOMWhereUsed(
+	ctx context.Context,
+	componentStockItemID int,
+) ([]model.StockBOMWhereUsed, error) {
+
+	whereUsed, err := s.stockBOMRepository.GetStockBOMWhereUsed(ctx, s.db, componentStockItemID)
+	if err != nil {
+		return nil, err
+	}
+
+	return whereUsed, nil
+}
+
+func (s *StockBOMService) AddStockBOMLine(
+	ctx context.Context,
+	stockBOMID int,
+	line *model.NewStockBOMLine,
+) (validate.ValidationErrors, error) {
+
+	var validationErrors validate.ValidationErrors = make(map[string][]string)
+
+	if line.ComponentStockItemID == 0 {
+		validationErrors.Add("ComponentStockItemID", "is required")
+	}
+	if line.QtyPer.LessThanOrEqual(decimal.Zero) {
+		validationErrors.Add("QtyPer", "must be greater than 0")
+	}
+	if line.ScrapFactor.IsNegative() {
+		validationErrors.Add("ScrapFactor", "cannot be negative")
+	}
+	if len(validationErrors) > 0 {
+		return validationErrors, nil
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	bom, err := s.stockBOMRepository.GetStockBOM(ctx, tx, stockBOMID)
+	if err != nil {
+		return nil, err
+	}
+	if bom == nil {
+		return nil, fmt.Errorf("bill of materials does not exist")
+	}
+
+	component, err := s.stockItemRepository.GetStockItem(ctx, tx, line.ComponentStockItemID)
+	if err != nil {
+		return nil, err
+	}
+	if component == nil {
+		validationErrors.Add("ComponentStockItemID", "does not exist")
+		return validationErrors, nil
+	}
+	if component.StockItemID == bom.StockItemID {
+		validationErrors.Add("ComponentStockItemID", "cannot be the stock item itself")
+		return validationErrors, nil
+	}
+
+	lines, err := s.stockBOMRepository.GetStockBOMLines(ctx, tx, stockBOMID)
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range lines {
+		if l.ComponentStockItemID == line.ComponentStockItemID {
+			validationErrors.Add("ComponentStockItemID", "is already on the bill of materials")
+			return validationErrors, nil
+		}
+	}
+
+	_, err = s.stockBOMRepository.AddStockBOMLine(ctx, tx, stockBOMID, line)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return nil, nil
+}
+
+func (s *StockBOMService) DeleteStockBOMLine(
+	ctx context.Context,
+	stockBOMID int,
+	stockBOMLineID int,
+) error {
+
+	err := s.stockBOMRepository.DeleteStockBOMLine(ctx, s.db, stockBOMID, stockBOMLineID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func validateStockBOMDates(
+	ve validate.ValidationErrors,
+	effectiveFrom time.Time,
+	effectiveTo *time.Time,
+) {
+	if effectiveFrom.IsZero() {
+		ve.Add("EffectiveFrom", "is required")
+	} else if effectiveTo != nil && !effectiveTo.After(effectiveFrom) {
+		ve.Add("EffectiveTo", "must be after the effective from date")
+	}
+}
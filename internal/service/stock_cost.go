@@ -0,0 +1,54 @@
+package service
+
+import (
+	"app/internal/model"
+	"app/internal/repository"
+	"context"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type StockCostService struct {
+	db                  *pgxpool.Pool
+	stockCostRepository *repository.StockCostRepository
+}
+
+func NewStockCostService(
+	db *pgxpool.Pool,
+	stockCostRepository *repository.StockCostRepository,
+) *StockCostService {
+	return &StockCostService{
+		db:                  db,
+		stockCostRepository: stockCostRepository,
+	}
+}
+
+// GetStockValuation returns a page of the stock valuation by stock item and
+// location, with the count and total value of every row matched
+func (s *StockCostService) GetStockValuation(
+	ctx context.Context,
+	input *model.GetStockValuationInput,
+) ([]model.StockValuation, model.StockValuationSummary, error) {
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return []model.StockValuation{}, model.StockValuationSummary{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	valuation, err := s.stockCostRepository.GetStockValuation(ctx, tx, input)
+	if err != nil {
+		return []model.StockValuation{}, model.StockValuationSummary{}, err
+	}
+
+	summary, err := s.stockCostRepository.GetStockValuationSummary(ctx, tx, input)
+	if err != nil {
+		return []model.StockValuation{}, model.StockValuationSummary{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return []model.StockValuation{}, model.StockValuationSummary{}, err
+	}
+
+	return valuation, summary, nil
+}
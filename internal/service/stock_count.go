@@ -0,0 +1,553 @@
+package service
+
+import (
+	"app/internal/model"
+	"app/internal/repository"
+	"app/pkg/validate"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/shopspring/decimal"
+)
+
+// stockCountSnapshotPageSize is the number of stock levels read per query
+// when freezing expected quantities
+const stockCountSnapshotPageSize = 1000
+
+type StockCountService struct {
+	db                         *pgxpool.Pool
+	stockCountRepository       *repository.StockCountRepository
+	stockItemRepository        *repository.StockItemRepository
+	stockTransactionRepository *repository.StockTransactionRepository
+	stockTransactionService    *StockTransactionService
+}
+
+func NewStockCountService(
+	db *pgxpool.Pool,
+	stockCountRepository *repository.StockCountRepository,
+	stockItemRepository *repository.StockItemRepository,
+	stockTransactionRepository *repository.StockTransactionRepository,
+	stockTransactionService *StockTransactionService,
+) *StockCountService {
+	return &StockCountService{
+		db:                         db,
+		stockCountRepository:       stockCountRepository,
+		stockItemRepository:        stockItemRepository,
+		stockTransactionRepository: stockTransactionRepository,
+		stockTransactionService:    stockTransactionService,
+	}
+}
+
+// CreateStockCount creates a count and freezes the STOCK levels in its scope
+// as the expected quantities. The snapshot is taken as of now and variances
+// are later posted as of the same time, so postings made while the count is
+// in progress are not counted twice.
+func (s *StockCountService) CreateStockCount(
+	ctx context.Context,
+	input *model.NewStockCount,
+	userID int,
+) (int, validate.ValidationErrors, error) {
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	validationErrors := s.validateNewStockCount(input)
+
+	// stock items in scope by stock code, as stock levels are keyed by code
+	stockItemIDs := map[string]int{}
+	for _, stockItemID := range input.StockItemIDs {
+		stockItem, err := s.stockItemRepository.GetStockItem(ctx, tx, stockItemID)
+		if err != nil {
+			return 0, nil, err
+		}
+		if stockItem == nil {
+			validationErrors.Add("StockItemIDs", "contains a stock item that does not exist")
+			break
+		}
+		if stockItem.IsSerialised {
+			validationErrors.Add("StockItemIDs", fmt.Sprintf("contains %s, which is serialised and cannot be counted", stockItem.StockCode))
+			break
+		}
+		stockItemIDs[stockItem.StockCode] = stockItem.StockItemID
+	}
+
+	if len(validationErrors) > 0 {
+		return 0, validationErrors, nil
+	}
+
+	snapshotAt := time.Now()
+
+	stockCountID, err := s.stockCountRepository.CreateStockCount(ctx, tx, input, snapshotAt, userID)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	levels, err := s.getStockLevelsInScope(ctx, tx, input, snapshotAt)
+	if err != nil {
+		return 0, nil, fmt.Errorf("error reading stock levels: %v", err)
+	}
+
+	serialised := map[string]bool{}
+	for _, l := range levels {
+		if len(input.StockItemIDs) > 0 {
+			if _, ok := stockItemIDs[l.StockCode]; !ok {
+				continue
+			}
+		}
+
+		// a count does not record serial numbers, so the variances of
+		// serialised stock items could not be posted and they are left out
+		if serialised[l.StockCode] {
+			continue
+		}
+
+		stockItemID, ok := stockItemIDs[l.StockCode]
+		if !ok {
+			stockItem, err := s.stockItemRepository.GetStockItemByStockCode(ctx, tx, l.StockCode)
+			if err != nil {
+				return 0, nil, err
+			}
+			if stockItem == nil {
+				return 0, nil, fmt.Errorf("stock item %s does not exist", l.StockCode)
+			}
+			if stockItem.IsSerialised {
+				serialised[l.StockCode] = true
+				continue
+			}
+			stockItemID = stockItem.StockItemID
+			stockItemIDs[l.StockCode] = stockItemID
+		}
+
+		_, err = s.stockCountRepository.AddStockCountLine(ctx, tx, stockCountID, &model.NewStockCountLine{
+			StockItemID: stockItemID,
+			Location:    l.Location,
+			Bin:         l.Bin,
+			LotNumber:   l.LotNumber,
+			ExpectedQty: l.StockLevel,
+		}, userID)
+		if err != nil {
+			return 0, nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, nil, fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return stockCountID, nil, nil
+}
+
+// getStockLevelsInScope reads non-zero STOCK levels as of the snapshot for
+// every location in scope, or all locations when none are given, keeping
+// only the bins in scope
+func (s *StockCountService) getStockLevelsInScope(
+	ctx context.Context,
+	tx pgx.Tx,
+	input *model.NewStockCount,
+	snapshotAt time.Time,
+) ([]model.StockLevel, error) {
+
+	locations := input.Locations
+	if len(locations) == 0 {
+		locations = []string{""}
+	}
+
+	levels := []model.StockLevel{}
+	for _, location := range locations {
+		for page := 1; ; page++ {
+			pageLevels, err := s.stockTransactionRepository.GetStockLevels(ctx, tx, &model.GetStockLevelsInput{
+				Account:      model.StockStockAccount,
+				Location:     location,
+				LTETimestamp: &snapshotAt,
+				Page:         page,
+				PageSize:     stockCountSnapshotPageSize,
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			for _, l := range pageLevels {
+				if len(input.Bins) > 0 && !slices.Contains(input.Bins, l.Bin) {
+					continue
+				}
+				levels = append(levels, l)
+			}
+
+			if len(pageLevels) < stockCountSnapshotPageSize {
+				break
+			}
+		}
+	}
+
+	return levels, nil
+}
+
+func (s *StockCountService) GetStockCounts(
+	ctx context.Context,
+	q *model.GetStockCountsQuery,
+) ([]model.StockCount, int, error) {
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return []model.StockCount{}, 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	stockCounts, err := s.stockCountRepository.GetStockCounts(ctx, tx, q)
+	if err != nil {
+		return []model.StockCount{}, 0, err
+	}
+
+	count, err := s.stockCountRepository.GetStockCountsCount(ctx, tx, q)
+	if err != nil {
+		return []model.StockCount{}, 0, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return []model.StockCount{}, 0, err
+	}
+
+	return stockCounts, count, nil
+}
+
+func (s *StockCountService) GetStockCount(
+	ctx context.Context,
+	stockCountID int,
+) (*model.StockCount, error) {
+
+	stockCount, err := s.stockCountRepository.GetStockCount(ctx, s.db, stockCountID)
+	if err != nil {
+		return nil, err
+	}
+
+	return stockCount, nil
+}
+
+func (s *StockCountService) GetStockCountLines(
+	ctx context.Context,
+	stockCountID int,
+) ([]model.StockCountLine, error) {
+
+	lines, err := s.stockCountRepository.GetStockCountLines(ctx, s.db, stockCountID)
+	if err != nil {
+		return nil, err
+	}
+
+	return lines, nil
+}
+
+// GetStockCountEntries returns the ledger entries, across all accounts, that
+// were posted when the count was approved
+func (s *StockCountService) GetStockCountEntries(
+	ctx context.Context,
+	stockCountID int,
+) ([]model.StockTransactionEntry, error) {
+
+	entries, err := s.stockTransactionRepository.GetStockTransactions(ctx, s.db, &model.GetTransactionsInput{
+		StockCountID: stockCountID,
+		Page:         1,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// SaveStockCountCounts records counted quantities against existing lines.
+// Lines that are not included keep their current count.
+func (s *StockCountService) SaveStockCountCounts(
+	ctx context.Context,
+	stockCountID int,
+	counts []model.StockCountLineCount,
+	userID int,
+) error {
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	status, err := s.stockCountRepository.LockStockCount(ctx, tx, stockCountID)
+	if err != nil {
+		return err
+	}
+	if status == nil {
+		return fmt.Errorf("stock count does not exist")
+	}
+	if *status != model.CountingStockCountStatus {
+		return fmt.Errorf("counts can only be entered while counting")
+	}
+
+	for _, c := range counts {
+		if c.CountedQty.IsNegative() {
+			return fmt.Errorf("counted quantities cannot be negative")
+		}
+
+		err = s.stockCountRepository.SetStockCountLineCount(
+			ctx, tx, stockCountID, c.StockCountLineID, c.CountedQty, userID,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return nil
+}
+
+// AddStockCountLine records stock found during the count that was not
+// expected at the snapshot, so its expected quantity is zero
+func (s *StockCountService) AddStockCountLine(
+	ctx context.Context,
+	stockCountID int,
+	line *model.NewStockCountLine,
+	userID int,
+) (validate.ValidationErrors, error) {
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	status, err := s.stockCountRepository.LockStockCount(ctx, tx, stockCountID)
+	if err != nil {
+		return nil, err
+	}
+	if status == nil {
+		return nil, fmt.Errorf("stock count does not exist")
+	}
+	if *status != model.CountingStockCountStatus {
+		return nil, fmt.Errorf("lines can only be added while counting")
+	}
+
+	stockCount, err := s.stockCountRepository.GetStockCount(ctx, tx, stockCountID)
+	if err != nil {
+		return nil, err
+	}
+
+	line.ExpectedQty = decimal.Zero
+
+	validationErrors := s.validateNewStockCountLine(stockCount, line)
+
+	if line.StockItemID != 0 {
+		stockItem, err := s.stockItemRepository.GetStockItem(ctx, tx, line.StockItemID)
+		if err != nil {
+			return nil, err
+		}
+		if stockItem == nil {
+			validationErrors.Add("StockItemID", "does not exist")
+		} else if stockItem.IsSerialised {
+			validationErrors.Add("StockItemID", "is serialised and cannot be counted")
+		}
+	}
+
+	if len(validationErrors) > 0 {
+		return validationErrors, nil
+	}
+
+	_, err = s.stockCountRepository.AddStockCountLine(ctx, tx, stockCountID, line, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return nil, nil
+}
+
+// ApproveStockCount posts every non-zero variance as a stock adjustment as
+// of the snapshot time, all in one database transaction. Every line must
+// have been counted first.
+func (s *StockCountService) ApproveStockCount(
+	ctx context.Context,
+	stockCountID int,
+	acknowledgeNegativeStock bool,
+	userID int,
+) error {
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	status, err := s.stockCountRepository.LockStockCount(ctx, tx, stockCountID)
+	if err != nil {
+		return err
+	}
+	if status == nil {
+		return fmt.Errorf("stock count does not exist")
+	}
+	if *status != model.CountingStockCountStatus {
+		return fmt.Errorf("only counts in progress can be approved, this count is %s", *status)
+	}
+
+	stockCount, err := s.stockCountRepository.GetStockCount(ctx, tx, stockCountID)
+	if err != nil {
+		return err
+	}
+
+	lines, err := s.stockCountRepository.GetStockCountLines(ctx, tx, stockCountID)
+	if err != nil {
+		return err
+	}
+
+	uncounted := 0
+	for _, l := range lines {
+		if l.CountedQty == nil {
+			uncounted++
+		}
+	}
+	if uncounted > 0 {
+		return fmt.Errorf("%d of %d lines have not been counted", uncounted, len(lines))
+	}
+
+	transactionNote := fmt.Sprintf("Stock count %s", stockCount.Reference)
+
+	transactions := model.PostStockTransactionsInput{}
+	for _, l := range lines {
+		variance := l.Variance()
+		if variance.IsZero() {
+			continue
+		}
+
+		transactionType := model.StockAdjustUpTransactionType
+		if variance.IsNegative() {
+			transactionType = model.StockAdjustDownTransactionType
+		}
+
+		transactions = append(transactions, model.NewStockTransaction{
+			TransactionType: transactionType,
+			Timestamp:       &stockCount.SnapshotAt,
+			StockItemID:     l.StockItemID,
+			Qty:             variance.Abs(),
+			FromLocation:    l.Location,
+			FromBin:         l.Bin,
+			FromLotNumber:   l.LotNumber,
+			ToLocation:      l.Location,
+			ToBin:           l.Bin,
+			ToLotNumber:     l.LotNumber,
+			TransactionNote: transactionNote,
+			StockCountID:    &stockCountID,
+
+			AcknowledgeNegativeStock: acknowledgeNegativeStock,
+		})
+	}
+
+	err = s.stockTransactionService.PostStockTransactions(ctx, tx, &transactions, userID)
+	if err != nil {
+		return err
+	}
+
+	err = s.stockCountRepository.UpdateStockCountStatus(
+		ctx, tx, stockCountID, model.ApprovedStockCountStatus, userID,
+	)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	s.stockTransactionService.NotifyStockReorder(ctx, transactions.StockItemIDs()...)
+
+	return nil
+}
+
+func (s *StockCountService) CancelStockCount(
+	ctx context.Context,
+	stockCountID int,
+	userID int,
+) error {
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	status, err := s.stockCountRepository.LockStockCount(ctx, tx, stockCountID)
+	if err != nil {
+		return err
+	}
+	if status == nil {
+		return fmt.Errorf("stock count does not exist")
+	}
+	if *status != model.CountingStockCountStatus {
+		return fmt.Errorf("only counts in progress can be cancelled")
+	}
+
+	err = s.stockCountRepository.UpdateStockCountStatus(
+		ctx, tx, stockCountID, model.CancelledStockCountStatus, userID,
+	)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return nil
+}
+
+func (s *StockCountService) validateNewStockCount(
+	input *model.NewStockCount,
+) validate.ValidationErrors {
+
+	var ve validate.ValidationErrors = make(map[string][]string)
+
+	if input.Reference == "" {
+		ve.Add("Reference", "is required")
+	}
+
+	return ve
+}
+
+func (s *StockCountService) validateNewStockCountLine(
+	stockCount *model.StockCount,
+	line *model.NewStockCountLine,
+) validate.ValidationErrors {
+
+	var ve validate.ValidationErrors = make(map[string][]string)
+
+	if line.StockItemID == 0 {
+		ve.Add("StockItemID", "is required")
+	} else if len(stockCount.StockItemIDs) > 0 && !slices.Contains(stockCount.StockItemIDs, line.StockItemID) {
+		ve.Add("StockItemID", "is not in the scope of this count")
+	}
+
+	if line.Location == "" {
+		ve.Add("Location", "is required")
+	} else if len(stockCount.Locations) > 0 && !slices.Contains(stockCount.Locations, line.Location) {
+		ve.Add("Location", "is not in the scope of this count")
+	}
+
+	if len(stockCount.Bins) > 0 && !slices.Contains(stockCount.Bins, line.Bin) {
+		ve.Add("Bin", "is not in the scope of this count")
+	}
+
+	if line.CountedQty == nil {
+		ve.Add("CountedQty", "is required")
+	} else if line.CountedQty.IsNegative() {
+		ve.Add("CountedQty", "cannot be negative")
+	}
+
+	return ve
+}
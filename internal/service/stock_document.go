@@ -0,0 +1,454 @@
+package service
+
+import (
+	"app/internal/model"
+	"app/internal/repository"
+	"app/pkg/validate"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/shopspring/decimal"
+)
+
+type StockDocumentService struct {
+	db                         *pgxpool.Pool
+	stockDocumentRepository    *repository.StockDocumentRepository
+	stockGenealogyRepository   *repository.StockGenealogyRepository
+	stockItemRepository        *repository.StockItemRepository
+	stockTransactionRepository *repository.StockTransactionRepository
+	stockTransactionService    *StockTransactionService
+}
+
+func NewStockDocumentService(
+	db *pgxpool.Pool,
+	stockDocumentRepository *repository.StockDocumentRepository,
+	stockGenealogyRepository *repository.StockGenealogyRepository,
+	stockItemRepository *repository.StockItemRepository,
+	stockTransactionRepository *repository.StockTransactionRepository,
+	stockTransactionService *StockTransactionService,
+) *StockDocumentService {
+	return &StockDocumentService{
+		db:                         db,
+		stockDocumentRepository:    stockDocumentRepository,
+		stockGenealogyRepository:   stockGenealogyRepository,
+		stockItemRepository:        stockItemRepository,
+		stockTransactionRepository: stockTransactionRepository,
+		stockTransactionService:    stockTransactionService,
+	}
+}
+
+func (s *StockDocumentService) CreateStockDocument(
+	ctx context.Context,
+	input *model.NewStockDocument,
+	userID int,
+) (int, validate.ValidationErrors, error) {
+
+	validationErrors := s.validateNewStockDocument(input)
+	if len(validationErrors) > 0 {
+		return 0, validationErrors, nil
+	}
+
+	stockDocumentID, err := s.stockDocumentRepository.CreateStockDocument(ctx, s.db, input, userID)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return stockDocumentID, nil, nil
+}
+
+func (s *StockDocumentService) GetStockDocuments(
+	ctx context.Context,
+	q *model.GetStockDocumentsQuery,
+) ([]model.StockDocument, int, error) {
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return []model.StockDocument{}, 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	stockDocuments, err := s.stockDocumentRepository.GetStockDocuments(ctx, tx, q)
+	if err != nil {
+		return []model.StockDocument{}, 0, err
+	}
+
+	count, err := s.stockDocumentRepository.GetStockDocumentsCount(ctx, tx, q)
+	if err != nil {
+		return []model.StockDocument{}, 0, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return []model.StockDocument{}, 0, err
+	}
+
+	return stockDocuments, count, nil
+}
+
+func (s *StockDocumentService) GetStockDocument(
+	ctx context.Context,
+	stockDocumentID int,
+) (*model.StockDocument, error) {
+
+	stockDocument, err := s.stockDocumentRepository.GetStockDocument(ctx, s.db, stockDocumentID)
+	if err != nil {
+		return nil, err
+	}
+
+	return stockDocument, nil
+}
+
+func (s *StockDocumentService) GetStockDocumentLines(
+	ctx context.Context,
+	stockDocumentID int,
+) ([]model.StockDocumentLine, error) {
+
+	lines, err := s.stockDocumentRepository.GetStockDocumentLines(ctx, s.db, stockDocumentID)
+	if err != nil {
+		return nil, err
+	}
+
+	return lines, nil
+}
+
+// GetStockDocumentEntries returns the ledger entries, across all accounts,
+// that were posted by the document
+func (s *StockDocumentService) GetStockDocumentEntries(
+	ctx context.Context,
+	stockDocumentID int,
+) ([]model.StockTransactionEntry, error) {
+
+	entries, err := s.stockTransactionRepository.GetStockTransactions(ctx, s.db, &model.GetTransactionsInput{
+		StockDocumentID: stockDocumentID,
+		Page:            1,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+func (s *StockDocumentService) AddStockDocumentLine(
+	ctx context.Context,
+	stockDocumentID int,
+	line *model.NewStockDocumentLine,
+) (validate.ValidationErrors, error) {
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	status, err := s.stockDocumentRepository.LockStockDocument(ctx, tx, stockDocumentID)
+	if err != nil {
+		return nil, err
+	}
+	if status == nil {
+		return nil, fmt.Errorf("stock document does not exist")
+	}
+	if *status != model.DraftStockDocumentStatus {
+		return nil, fmt.Errorf("lines can only be added to a draft document")
+	}
+
+	// Only stock movements post between two different places, every other
+	// transaction type moves stock between accounts at the same place
+	if line.TransactionType != model.StockMovementTransactionType {
+		line.ToLocation = line.FromLocation
+		line.ToBin = line.FromBin
+		line.ToLotNumber = line.FromLotNumber
+	} else if line.ToLotNumber == "" {
+		line.ToLotNumber = line.FromLotNumber
+	}
+
+	validationErrors := s.validateNewStockDocumentLine(line)
+
+	var stockItem *model.StockItem
+	if line.StockItemID != 0 {
+		stockItem, err = s.stockItemRepository.GetStockItem(ctx, tx, line.StockItemID)
+		if err != nil {
+			return nil, err
+		}
+		if stockItem == nil {
+			validationErrors.Add("StockItemID", "does not exist")
+		}
+	}
+
+	if len(validationErrors) > 0 {
+		return validationErrors, nil
+	}
+
+	// lines are stored in the base unit so that posting does not depend on
+	// alternate units that may since have changed
+	qty, err := s.stockTransactionService.ConvertToBaseUnit(ctx, tx, line.StockItemID, line.Unit, line.Qty)
+	if errors.Is(err, ErrUnknownStockItemUnit) {
+		validationErrors.Add("Unit", "is not defined for the stock item")
+		return validationErrors, nil
+	} else if err != nil {
+		return nil, err
+	}
+	line.Qty = qty
+	line.Unit = ""
+
+	// where the serials are is checked when the document is posted, as
+	// earlier lines may move them
+	if stockItem.IsSerialised {
+		if !line.Qty.Equal(decimal.NewFromInt(int64(len(line.SerialNumbers)))) {
+			validationErrors.Add("SerialNumbers", fmt.Sprintf(
+				"must list one serial number per %s, %d given for a quantity of %s",
+				stockItem.BaseUnit, len(line.SerialNumbers), line.Qty.String(),
+			))
+			return validationErrors, nil
+		}
+	} else if len(line.SerialNumbers) > 0 {
+		validationErrors.Add("SerialNumbers", "can only be given for serialised stock items")
+		return validationErrors, nil
+	}
+
+	_, err = s.stockDocumentRepository.AddStockDocumentLine(ctx, tx, stockDocumentID, line)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return nil, nil
+}
+
+func (s *StockDocumentService) DeleteStockDocumentLine(
+	ctx context.Context,
+	stockDocumentID int,
+	stockDocumentLineID int,
+) error {
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	status, err := s.stockDocumentRepository.LockStockDocument(ctx, tx, stockDocumentID)
+	if err != nil {
+		return err
+	}
+	if status == nil {
+		return fmt.Errorf("stock document does not exist")
+	}
+	if *status != model.DraftStockDocumentStatus {
+		return fmt.Errorf("lines can only be removed from a draft document")
+	}
+
+	err = s.stockDocumentRepository.DeleteStockDocumentLine(ctx, tx, stockDocumentID, stockDocumentLineID)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return nil
+}
+
+// PostStockDocument posts every line of a draft document to the stock ledger
+// in a single database transaction. Either all lines are posted or none are.
+func (s *StockDocumentService) PostStockDocument(
+	ctx context.Context,
+	stockDocumentID int,
+	acknowledgeNegativeStock bool,
+	userID int,
+) error {
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	status, err := s.stockDocumentRepository.LockStockDocument(ctx, tx, stockDocumentID)
+	if err != nil {
+		return err
+	}
+	if status == nil {
+		return fmt.Errorf("stock document does not exist")
+	}
+	if *status != model.DraftStockDocumentStatus {
+		return fmt.Errorf("only draft documents can be posted, this document is %s", *status)
+	}
+
+	stockDocument, err := s.stockDocumentRepository.GetStockDocument(ctx, tx, stockDocumentID)
+	if err != nil {
+		return err
+	}
+
+	lines, err := s.stockDocumentRepository.GetStockDocumentLines(ctx, tx, stockDocumentID)
+	if err != nil {
+		return err
+	}
+	if len(lines) == 0 {
+		return fmt.Errorf("document has no lines to post")
+	}
+
+	documentNote := fmt.Sprintf("%s %s", stockDocument.DocumentType, stockDocument.Reference)
+
+	transactions := model.PostStockTransactionsInput{}
+	for _, l := range lines {
+		transactionNote := documentNote
+		if l.LineNote != "" {
+			transactionNote = fmt.Sprintf("%s: %s", documentNote, l.LineNote)
+		}
+
+		transactions = append(transactions, model.NewStockTransaction{
+			TransactionType: l.TransactionType,
+			Timestamp:       &stockDocument.DocumentDate,
+			StockItemID:     l.StockItemID,
+			Qty:             l.Qty,
+			FromLocation:    l.FromLocation,
+			FromBin:         l.FromBin,
+			FromLotNumber:   l.FromLotNumber,
+			ToLocation:      l.ToLocation,
+			ToBin:           l.ToBin,
+			ToLotNumber:     l.ToLotNumber,
+			SerialNumbers:   l.SerialNumbers,
+			UnitCost:        l.UnitCost,
+			TransactionNote: transactionNote,
+			// stock reserved against the document reference, such as the order
+			// a dispatch is for, is drawn down by the lines consuming it
+			DemandReference: stockDocument.Reference,
+			StockDocumentID: &stockDocumentID,
+
+			AcknowledgeNegativeStock: acknowledgeNegativeStock,
+		})
+	}
+
+	err = s.stockTransactionService.PostStockTransactions(ctx, tx, &transactions, userID)
+	if err != nil {
+		return err
+	}
+
+	if stockDocument.DocumentType == model.ProductionBatchStockDocumentType {
+		err = s.stockGenealogyRepository.LinkStockDocument(ctx, tx, stockDocumentID)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = s.stockDocumentRepository.UpdateStockDocumentStatus(
+		ctx, tx, stockDocumentID, model.PostedStockDocumentStatus, userID,
+	)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	s.stockTransactionService.NotifyStockReorder(ctx, transactions.StockItemIDs()...)
+
+	return nil
+}
+
+func (s *StockDocumentService) CancelStockDocument(
+	ctx context.Context,
+	stockDocumentID int,
+	userID int,
+) error {
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	status, err := s.stockDocumentRepository.LockStockDocument(ctx, tx, stockDocumentID)
+	if err != nil {
+		return err
+	}
+	if status == nil {
+		return fmt.Errorf("stock document does not exist")
+	}
+	if *status != model.DraftStockDocumentStatus {
+		return fmt.Errorf("only draft documents can be cancelled")
+	}
+
+	err = s.stockDocumentRepository.UpdateStockDocumentStatus(
+		ctx, tx, stockDocumentID, model.CancelledStockDocumentStatus, userID,
+	)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return nil
+}
+
+func (s *StockDocumentService) validateNewStockDocument(
+	input *model.NewStockDocument,
+) validate.ValidationErrors {
+
+	var ve validate.ValidationErrors = make(map[string][]string)
+
+	if !slices.Contains(model.StockDocumentTypes, input.DocumentType) {
+		ve.Add("DocumentType", "is not a valid document type")
+	}
+
+	if input.Reference == "" {
+		ve.Add("Reference", "is required")
+	}
+
+	if input.DocumentDate.IsZero() {
+		ve.Add("DocumentDate", "is required")
+	}
+
+	return ve
+}
+
+func (s *StockDocumentService) validateNewStockDocumentLine(
+	line *model.NewStockDocumentLine,
+) validate.ValidationErrors {
+
+	var ve validate.ValidationErrors = make(map[string][]string)
+
+	// other built in types have their own workflows, such as goods receipt
+	// against a purchase order, and cannot be posted on a document
+	if !slices.Contains(model.ManualStockTransactionTypes, line.TransactionType) {
+		ve.Add("TransactionType", "is not a transaction type that can be posted on a document")
+	}
+
+	if line.StockItemID == 0 {
+		ve.Add("StockItemID", "is required")
+	}
+
+	if line.Qty.LessThanOrEqual(decimal.Zero) {
+		ve.Add("Qty", "must be greater than 0")
+	}
+
+	if line.FromLocation == "" {
+		ve.Add("FromLocation", "is required")
+	}
+
+	if line.UnitCost != nil && line.UnitCost.IsNegative() {
+		ve.Add("UnitCost", "cannot be negative")
+	}
+
+	if line.TransactionType == model.StockMovementTransactionType {
+		if line.ToLocation == "" {
+			ve.Add("ToLocation", "is required")
+		} else if line.FromLocation == line.ToLocation &&
+			line.FromBin == line.ToBin &&
+			line.FromLotNumber == line.ToLotNumber {
+			ve.Add("ToLocation", "must differ from the from location and bin")
+		}
+	}
+
+	return ve
+}
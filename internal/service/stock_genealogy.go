@@ -0,0 +1,76 @@
+package service
+
+import (
+	"app/internal/model"
+	"app/internal/repository"
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type StockGenealogyService struct {
+	db                       *pgxpool.Pool
+	stockGenealogyRepository *repository.StockGenealogyRepository
+	stockItemRepository      *repository.StockItemRepository
+}
+
+func NewStockGenealogyService(
+	db *pgxpool.Pool,
+	stockGenealogyRepository *repository.StockGenealogyRepository,
+	stockItemRepository *repository.StockItemRepository,
+) *StockGenealogyService {
+	return &StockGenealogyService{
+		db:                       db,
+		stockGenealogyRepository: stockGenealogyRepository,
+		stockItemRepository:      stockItemRepository,
+	}
+}
+
+// GetLotTrace returns the lots a lot went into and the lots that went into
+// it, or nil if the stock code does not exist
+func (s *StockGenealogyService) GetLotTrace(
+	ctx context.Context,
+	stockCode string,
+	lotNumber string,
+) (*model.LotTrace, error) {
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	stockItem, err := s.stockItemRepository.GetStockItemByStockCode(ctx, tx, stockCode)
+	if err != nil {
+		return nil, err
+	}
+	if stockItem == nil {
+		return nil, nil
+	}
+
+	forward, err := s.stockGenealogyRepository.GetLotTrace(
+		ctx, tx, stockItem.StockItemID, lotNumber, model.ForwardLotTraceDirection,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	backward, err := s.stockGenealogyRepository.GetLotTrace(
+		ctx, tx, stockItem.StockItemID, lotNumber, model.BackwardLotTraceDirection,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return &model.LotTrace{
+		StockCode: stockItem.StockCode,
+		LotNumber: lotNumber,
+		Forward:   forward,
+		Backward:  backward,
+	}, nil
+}
@@ -0,0 +1,561 @@
+package service
+
+import (
+	"app/internal/model"
+	"app/internal/repository"
+	"app/pkg/db"
+	"app/pkg/validate"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/shopspring/decimal"
+)
+
+type StockImportService struct {
+	db                      *pgxpool.Pool
+	fileService             *FileService
+	stockImportRepository   *repository.StockImportRepository
+	stockItemRepository     *repository.StockItemRepository
+	stockTransactionService *StockTransactionService
+}
+
+func NewStockImportService(
+	db *pgxpool.Pool,
+	fileService *FileService,
+	stockImportRepository *repository.StockImportRepository,
+	stockItemRepository *repository.StockItemRepository,
+	stockTransactionService *StockTransactionService,
+) *StockImportService {
+	return &StockImportService{
+		db:                      db,
+		fileService:             fileService,
+		stockImportRepository:   stockImportRepository,
+		stockItemRepository:     stockItemRepository,
+		stockTransactionService: stockTransactionService,
+	}
+}
+
+// stockImportFileEntity is the file entity of uploaded stock import files
+const stockImportFileEntity = "StockImport"
+
+// CreateStockImport reads and validates an uploaded CSV file and keeps it for
+// the import to be previewed and posted. Only problems with the file as a
+// whole are returned as validation errors, problems with rows are shown when
+// the import is previewed.
+func (s *StockImportService) CreateStockImport(
+	ctx context.Context,
+	filename string,
+	content []byte,
+	userID int,
+) (int, validate.ValidationErrors, error) {
+
+	var validationErrors validate.ValidationErrors = make(map[string][]string)
+
+	rows, err := readStockImportFile(content)
+	if err != nil {
+		validationErrors.Add("File", err.Error())
+		return 0, validationErrors, nil
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	err = s.validateStockImportRows(ctx, tx, rows)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	errorRowCount := 0
+	for _, row := range rows {
+		if len(row.Errors) > 0 {
+			errorRowCount++
+		}
+	}
+
+	stockImportID, err := s.stockImportRepository.CreateStockImport(
+		ctx, tx, filename, len(rows), errorRowCount, userID,
+	)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	file, err := s.fileService.SaveFileContent(ctx, tx, &model.File{
+		Filename:    filename,
+		ContentType: "text/csv",
+		SizeBytes:   len(content),
+		Entity:      stockImportFileEntity,
+		EntityID:    stockImportID,
+		UserID:      userID,
+	}, content)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	err = s.stockImportRepository.SetStockImportFile(ctx, tx, stockImportID, file.FileID)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, nil, fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return stockImportID, nil, nil
+}
+
+func (s *StockImportService) GetStockImports(
+	ctx context.Context,
+	q *model.GetStockImportsQuery,
+) ([]model.StockImport, int, error) {
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return []model.StockImport{}, 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	stockImports, err := s.stockImportRepository.GetStockImports(ctx, tx, q)
+	if err != nil {
+		return []model.StockImport{}, 0, err
+	}
+
+	count, err := s.stockImportRepository.GetStockImportsCount(ctx, tx, q)
+	if err != nil {
+		return []model.StockImport{}, 0, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return []model.StockImport{}, 0, err
+	}
+
+	return stockImports, count, nil
+}
+
+func (s *StockImportService) GetStockImport(
+	ctx context.Context,
+	stockImportID int,
+) (*model.StockImport, error) {
+
+	stockImport, err := s.stockImportRepository.GetStockImport(ctx, s.db, stockImportID)
+	if err != nil {
+		return nil, err
+	}
+
+	return stockImport, nil
+}
+
+// GetStockImportRows reads the rows of the kept file of an import. The rows
+// of a pending import are validated against the stock items as they are now,
+// the rows of posted and cancelled imports are returned as read.
+func (s *StockImportService) GetStockImportRows(
+	ctx context.Context,
+	stockImport *model.StockImport,
+) ([]model.StockImportRow, error) {
+
+	rows, err := s.getStockImportFileRows(ctx, stockImport)
+	if err != nil {
+		return nil, err
+	}
+
+	if stockImport.Status != model.PendingStockImportStatus {
+		return rows, nil
+	}
+
+	err = s.validateStockImportRows(ctx, s.db, rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return rows, nil
+}
+
+// PostStockImport validates the rows of a pending import again and posts
+// every valid row in a single database transaction. Rows with errors are
+// skipped. Either all valid rows are posted or none are.
+func (s *StockImportService) PostStockImport(
+	ctx context.Context,
+	stockImportID int,
+	acknowledgeNegativeStock bool,
+	userID int,
+) error {
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	status, err := s.stockImportRepository.LockStockImport(ctx, tx, stockImportID)
+	if err != nil {
+		return err
+	}
+	if status == nil {
+		return fmt.Errorf("stock import does not exist")
+	}
+	if *status != model.PendingStockImportStatus {
+		return fmt.Errorf("only pending imports can be posted, this import is %s", *status)
+	}
+
+	stockImport, err := s.stockImportRepository.GetStockImport(ctx, tx, stockImportID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := s.getStockImportFileRows(ctx, stockImport)
+	if err != nil {
+		return err
+	}
+
+	err = s.validateStockImportRows(ctx, tx, rows)
+	if err != nil {
+		return err
+	}
+
+	importNote := fmt.Sprintf("Stock import %d", stockImportID)
+
+	transactions := model.PostStockTransactionsInput{}
+	for _, row := range rows {
+		if row.Transaction == nil {
+			continue
+		}
+
+		t := *row.Transaction
+		t.TransactionNote = fmt.Sprintf("%s row %d", importNote, row.RowNumber)
+		if row.Note != "" {
+			t.TransactionNote = fmt.Sprintf("%s: %s", t.TransactionNote, row.Note)
+		}
+		t.AcknowledgeNegativeStock = acknowledgeNegativeStock
+
+		transactions = append(transactions, t)
+	}
+	if len(transactions) == 0 {
+		return fmt.Errorf("import has no valid rows to post")
+	}
+
+	err = s.stockTransactionService.PostStockTransactions(ctx, tx, &transactions, userID)
+	if err != nil {
+		return err
+	}
+
+	err = s.stockImportRepository.SetStockImportPosted(ctx, tx, stockImportID, len(transactions), userID)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	s.stockTransactionService.NotifyStockReorder(ctx, transactions.StockItemIDs()...)
+
+	return nil
+}
+
+func (s *StockImportService) CancelStockImport(
+	ctx context.Context,
+	stockImportID int,
+) error {
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	status, err := s.stockImportRepository.LockStockImport(ctx, tx, stockImportID)
+	if err != nil {
+		return err
+	}
+	if status == nil {
+		return fmt.Errorf("stock import does not exist")
+	}
+	if *status != model.PendingStockImportStatus {
+		return fmt.Errorf("only pending imports can be cancelled")
+	}
+
+	err = s.stockImportRepository.SetStockImportCancelled(ctx, tx, stockImportID)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return nil
+}
+
+func (s *StockImportService) getStockImportFileRows(
+	ctx context.Context,
+	stockImport *model.StockImport,
+) ([]model.StockImportRow, error) {
+
+	if stockImport == nil {
+		return nil, fmt.Errorf("stock import does not exist")
+	}
+	if stockImport.FileID == nil {
+		return nil, fmt.Errorf("stock import has no file")
+	}
+
+	content, err := s.fileService.GetFileContent(ctx, *stockImport.FileID)
+	if err != nil {
+		return nil, err
+	}
+
+	return readStockImportFile(content)
+}
+
+// validateStockImportRows records the problems with each row and sets the
+// transaction of the rows without any. Only database errors are returned.
+func (s *StockImportService) validateStockImportRows(
+	ctx context.Context,
+	exec db.PGExecutor,
+	rows []model.StockImportRow,
+) error {
+
+	stockItems := map[string]*model.StockItem{}
+
+	for i := range rows {
+		row := &rows[i]
+
+		if !slices.Contains(model.ManualStockTransactionTypes, row.TransactionType) {
+			row.Errors = append(row.Errors, fmt.Sprintf(
+				"Transaction Type %q is not a type that can be imported", row.TransactionType,
+			))
+		}
+
+		var stockItem *model.StockItem
+		if row.StockCode == "" {
+			row.Errors = append(row.Errors, "Stock Code is required")
+		} else {
+			var ok bool
+			stockItem, ok = stockItems[row.StockCode]
+			if !ok {
+				var err error
+				stockItem, err = s.stockItemRepository.GetStockItemByStockCode(ctx, exec, row.StockCode)
+				if err != nil {
+					return err
+				}
+				stockItems[row.StockCode] = stockItem
+			}
+			if stockItem == nil {
+				row.Errors = append(row.Errors, fmt.Sprintf("Stock Code %s does not exist", row.StockCode))
+			}
+		}
+
+		qty, err := decimal.NewFromString(row.Qty)
+		if err != nil {
+			row.Errors = append(row.Errors, fmt.Sprintf("Qty %q is not a number", row.Qty))
+		} else if qty.LessThanOrEqual(decimal.Zero) {
+			row.Errors = append(row.Errors, "Qty must be greater than 0")
+		}
+
+		if row.FromLocation == "" {
+			row.Errors = append(row.Errors, "From Location is required")
+		}
+
+		// Only stock movements post between two different places, every
+		// other transaction type moves stock between accounts at the same
+		// place
+		toLocation, toBin, toLotNumber := row.FromLocation, row.FromBin, row.FromLotNumber
+		if row.TransactionType == model.StockMovementTransactionType {
+			toLocation, toBin = row.ToLocation, row.ToBin
+			if row.ToLotNumber != "" {
+				toLotNumber = row.ToLotNumber
+			}
+
+			if toLocation == "" {
+				row.Errors = append(row.Errors, "To Location is required for a stock movement")
+			} else if row.FromLocation == toLocation &&
+				row.FromBin == toBin &&
+				row.FromLotNumber == toLotNumber {
+				row.Errors = append(row.Errors, "To Location must differ from the from location and bin")
+			}
+		}
+
+		var unitCost *decimal.Decimal
+		if row.UnitCost != "" {
+			cost, err := decimal.NewFromString(row.UnitCost)
+			if err != nil {
+				row.Errors = append(row.Errors, fmt.Sprintf("Unit Cost %q is not a number", row.UnitCost))
+			} else if cost.IsNegative() {
+				row.Errors = append(row.Errors, "Unit Cost cannot be negative")
+			} else {
+				unitCost = &cost
+			}
+		}
+
+		if stockItem == nil || len(row.Errors) > 0 {
+			continue
+		}
+
+		baseQty, err := s.stockTransactionService.ConvertToBaseUnit(ctx, exec, stockItem.StockItemID, row.Unit, qty)
+		if errors.Is(err, ErrUnknownStockItemUnit) {
+			row.Errors = append(row.Errors, fmt.Sprintf("Unit %s is not defined for the stock item", row.Unit))
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		if stockItem.IsSerialised {
+			if !baseQty.Equal(decimal.NewFromInt(int64(len(row.SerialNumbers)))) {
+				row.Errors = append(row.Errors, fmt.Sprintf(
+					"Serial Numbers must list one serial number per %s, %d given for a quantity of %s",
+					stockItem.BaseUnit, len(row.SerialNumbers), baseQty.String(),
+				))
+				continue
+			}
+		} else if len(row.SerialNumbers) > 0 {
+			row.Errors = append(row.Errors, "Serial Numbers can only be given for serialised stock items")
+			continue
+		}
+
+		// units are converted here rather than when posting so that the
+		// conversion is validated with the rest of the row
+		row.Transaction = &model.NewStockTransaction{
+			TransactionType: row.TransactionType,
+			StockItemID:     stockItem.StockItemID,
+			Qty:             baseQty,
+			FromLocation:    row.FromLocation,
+			FromBin:         row.FromBin,
+			FromLotNumber:   row.FromLotNumber,
+			ToLocation:      toLocation,
+			ToBin:           toBin,
+			ToLotNumber:     toLotNumber,
+			SerialNumbers:   row.SerialNumbers,
+			UnitCost:        unitCost,
+		}
+	}
+
+	return nil
+}
+
+// readStockImportFile reads the rows of a stock import CSV file. Errors are
+// only returned for problems with the file as a whole, such as missing
+// columns.
+func readStockImportFile(content []byte) ([]model.StockImportRow, error) {
+
+	// spreadsheet programs often save CSV files with a byte order mark
+	content = bytes.TrimPrefix(content, []byte("\ufeff"))
+
+	reader := csv.NewReader(bytes.NewReader(content))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	headings, err := reader.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("file is empty")
+	} else if err != nil {
+		return nil, fmt.Errorf("file is not a valid CSV file: %v", err)
+	}
+
+	normaliseHeading := func(heading string) string {
+		return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(heading), " ", ""))
+	}
+
+	columnIndexes := map[string]int{}
+	for i, heading := range headings {
+		for _, column := range model.StockImportColumns {
+			if normaliseHeading(heading) == normaliseHeading(column) {
+				columnIndexes[column] = i
+			}
+		}
+	}
+
+	missingColumns := []string{}
+	for _, column := range model.StockImportRequiredColumns {
+		if _, ok := columnIndexes[column]; !ok {
+			missingColumns = append(missingColumns, column)
+		}
+	}
+	if len(missingColumns) > 0 {
+		return nil, fmt.Errorf("file is missing the %s column(s)", strings.Join(missingColumns, ", "))
+	}
+
+	rows := []model.StockImportRow{}
+	for rowNumber := 2; ; rowNumber++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("file is not a valid CSV file: %v", err)
+		}
+
+		value := func(column string) string {
+			i, ok := columnIndexes[column]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+
+		// skip blank lines, which spreadsheet programs leave as rows of
+		// empty fields
+		if strings.TrimSpace(strings.Join(record, "")) == "" {
+			continue
+		}
+
+		rows = append(rows, model.StockImportRow{
+			RowNumber:       rowNumber,
+			TransactionType: stockImportTransactionType(value("Transaction Type")),
+			StockCode:       strings.ToUpper(value("Stock Code")),
+			Qty:             value("Qty"),
+			Unit:            strings.ToUpper(value("Unit")),
+			FromLocation:    strings.ToUpper(value("From Location")),
+			FromBin:         strings.ToUpper(value("From Bin")),
+			FromLotNumber:   strings.ToUpper(value("From Lot Number")),
+			ToLocation:      strings.ToUpper(value("To Location")),
+			ToBin:           strings.ToUpper(value("To Bin")),
+			ToLotNumber:     strings.ToUpper(value("To Lot Number")),
+			SerialNumbers:   splitStockImportSerialNumbers(value("Serial Numbers")),
+			UnitCost:        value("Unit Cost"),
+			Note:            value("Note"),
+		})
+	}
+
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("file has no rows")
+	}
+
+	return rows, nil
+}
+
+// stockImportTransactionType matches a transaction type ignoring case, so
+// that the file can be typed by hand
+func stockImportTransactionType(value string) model.StockTransactionType {
+	for _, t := range model.ManualStockTransactionTypes {
+		if strings.EqualFold(string(t), value) {
+			return t
+		}
+	}
+
+	return model.StockTransactionType(value)
+}
+
+// splitStockImportSerialNumbers splits serial numbers listed in a single
+// field, separated by semicolons, commas or new lines
+func splitStockImportSerialNumbers(serialNumbers string) []string {
+	fields := strings.FieldsFunc(serialNumbers, func(r rune) bool {
+		return r == ';' || r == ',' || r == '\n' || r == '\r'
+	})
+
+	var split []string
+	for _, f := range fields {
+		f = strings.ToUpper(strings.TrimSpace(f))
+		if f != "" {
+			split = append(split, f)
+		}
+	}
+
+	return split
+}
@@ -6,6 +6,7 @@ import (
 	"app/pkg/validate"
 	"context"
 	"fmt"
+	"slices"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/ncw/swift/v2"
@@ -155,11 +156,15 @@ func (s *StockItemService) CreateStockItem(
 		return validate.ValidationErrors{}, err
 	}
 
+	costingMethod := string(input.CostingMethod)
 	err = s.stockItemRepository.AddStockItemChange(ctx, tx, model.PostStockItemChange{
-		StockItemID: newStockItemID,
-		StockCode:   &input.StockCode,
-		Description: &input.Description,
-		ChangeBy:    userID,
+		StockItemID:   newStockItemID,
+		StockCode:     &input.StockCode,
+		Description:   &input.Description,
+		BaseUnit:      &input.BaseUnit,
+		IsSerialised:  &input.IsSerialised,
+		CostingMethod: &costingMethod,
+		ChangeBy:      userID,
 	})
 	if err != nil {
 		fmt.Println(err)
@@ -203,15 +208,53 @@ func (s *StockItemService) UpdateStockItem(
 		}
 	}
 
+	// serial numbers are only tracked from the first posting, so the flag
+	// cannot change once stock has been posted without serials
+	if input.IsSerialised != stockItem.IsSerialised {
+		hasTransactions, err := s.stockItemRepository.HasStockTransactions(ctx, tx, stockItemID)
+		if err != nil {
+			return validate.ValidationErrors{}, err
+		}
+		if hasTransactions {
+			validationErrors.Add("IsSerialised", "cannot change once stock has been posted")
+			return validationErrors, nil
+		}
+	}
+
+	// ledger quantities are held in the base unit, so changing it once
+	// anything is posted would silently change every stock level
+	if input.BaseUnit != stockItem.BaseUnit {
+		hasTransactions, err := s.stockItemRepository.HasStockTransactions(ctx, tx, stockItemID)
+		if err != nil {
+			return validate.ValidationErrors{}, err
+		}
+		if hasTransactions {
+			validationErrors.Add("BaseUnit", "cannot change once stock has been posted")
+			return validationErrors, nil
+		}
+
+		unit, err := s.stockItemRepository.GetStockItemUnit(ctx, tx, stockItemID, input.BaseUnit)
+		if err != nil {
+			return validate.ValidationErrors{}, err
+		}
+		if unit != nil {
+			validationErrors.Add("BaseUnit", "is already an alternate unit")
+			return validationErrors, nil
+		}
+	}
+
 	err = s.stockItemRepository.UpdateStockItem(ctx, tx, stockItemID, input)
 	if err != nil {
 		return validate.ValidationErrors{}, err
 	}
 
 	change := model.PostStockItemChange{
-		StockCode:   nil,
-		Description: nil,
-		ChangeBy:    userID,
+		StockCode:     nil,
+		Description:   nil,
+		BaseUnit:      nil,
+		IsSerialised:  nil,
+		CostingMethod: nil,
+		ChangeBy:      userID,
 	}
 
 	if stockItem.StockCode != input.StockCode {
@@ -222,8 +265,22 @@ func (s *StockItemService) UpdateStockItem(
 		change.Description = &input.Description
 	}
 
+	if stockItem.BaseUnit != input.BaseUnit {
+		change.BaseUnit = &input.BaseUnit
+	}
+
+	if stockItem.IsSerialised != input.IsSerialised {
+		change.IsSerialised = &input.IsSerialised
+	}
+
+	if stockItem.CostingMethod != input.CostingMethod {
+		costingMethod := string(input.CostingMethod)
+		change.CostingMethod = &costingMethod
+	}
+
 	// Only insert if at least one field changed
-	if change.Description != nil || change.StockCode != nil {
+	if change.Description != nil || change.StockCode != nil || change.BaseUnit != nil ||
+		change.IsSerialised != nil || change.CostingMethod != nil {
 		change.StockItemID = stockItemID
 		err = s.stockItemRepository.AddStockItemChange(ctx, tx, change)
 		if err != nil {
@@ -254,6 +311,14 @@ func (s *StockItemService) validateNewStockItem(
 		ve.Add("Description", "is required")
 	}
 
+	if stockItem.BaseUnit == "" {
+		ve.Add("BaseUnit", "is required")
+	}
+
+	if !slices.Contains(model.StockCostingMethods, stockItem.CostingMethod) {
+		ve.Add("CostingMethod", "is not a valid costing method")
+	}
+
 	return ve, nil
 }
 
@@ -271,9 +336,108 @@ func (s *StockItemService) validateUpdateStockItem(
 		ve.Add("Description", "should not be empty")
 	}
 
+	if stockItem.BaseUnit == "" {
+		ve.Add("BaseUnit", "should not be empty")
+	}
+
+	// the costing method can change at any time, it applies to postings from
+	// then on
+	if !slices.Contains(model.StockCostingMethods, stockItem.CostingMethod) {
+		ve.Add("CostingMethod", "is not a valid costing method")
+	}
+
 	return ve, nil
 }
 
+func (s *StockItemService) GetStockItemUnits(
+	ctx context.Context,
+	stockItemID int,
+) ([]model.StockItemUnit, error) {
+
+	units, err := s.stockItemRepository.GetStockItemUnits(ctx, s.db, stockItemID)
+	if err != nil {
+		return nil, err
+	}
+
+	return units, nil
+}
+
+// AddStockItemUnit defines an alternate unit for the stock item. Postings
+// entered in it are converted to the base unit, so existing stock is not
+// affected.
+func (s *StockItemService) AddStockItemUnit(
+	ctx context.Context,
+	stockItemID int,
+	input *model.NewStockItemUnit,
+	userID int,
+) (validate.ValidationErrors, error) {
+
+	var ve validate.ValidationErrors = make(map[string][]string)
+
+	if input.Unit == "" {
+		ve.Add("Unit", "is required")
+	}
+	if !input.ConversionFactor.IsPositive() {
+		ve.Add("ConversionFactor", "must be greater than 0")
+	}
+	if len(ve) > 0 {
+		return ve, nil
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	stockItem, err := s.stockItemRepository.GetStockItem(ctx, tx, stockItemID)
+	if err != nil {
+		return nil, err
+	}
+	if stockItem == nil {
+		return nil, fmt.Errorf("stock item does not exist")
+	}
+
+	if input.Unit == stockItem.BaseUnit {
+		ve.Add("Unit", "is the base unit")
+		return ve, nil
+	}
+
+	existing, err := s.stockItemRepository.GetStockItemUnit(ctx, tx, stockItemID, input.Unit)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		ve.Add("Unit", "already exists")
+		return ve, nil
+	}
+
+	err = s.stockItemRepository.AddStockItemUnit(ctx, tx, stockItemID, input, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return ve, nil
+}
+
+func (s *StockItemService) DeleteStockItemUnit(
+	ctx context.Context,
+	stockItemID int,
+	stockItemUnitID int,
+) error {
+
+	err := s.stockItemRepository.DeleteStockItemUnit(ctx, s.db, stockItemID, stockItemUnitID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
 func (s *StockItemService) GetStockCodes(ctx context.Context, searchText string, selectedValues []int) ([]model.StockItem, error) {
 
 	tx, err := s.db.Begin(ctx)
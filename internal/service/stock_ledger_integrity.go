@@ -0,0 +1,111 @@
+package service
+
+import (
+	"app/internal/model"
+	"app/internal/repository"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// stockLedgerIntegrityReportLimit caps the rows listed per check. Counts are
+// always reported in full.
+const stockLedgerIntegrityReportLimit = 1000
+
+type StockLedgerIntegrityService struct {
+	db                             *pgxpool.Pool
+	stockLedgerIntegrityRepository *repository.StockLedgerIntegrityRepository
+}
+
+func NewStockLedgerIntegrityService(
+	db *pgxpool.Pool,
+	stockLedgerIntegrityRepository *repository.StockLedgerIntegrityRepository,
+) *StockLedgerIntegrityService {
+	return &StockLedgerIntegrityService{
+		db:                             db,
+		stockLedgerIntegrityRepository: stockLedgerIntegrityRepository,
+	}
+}
+
+// CheckStockLedger recomputes running totals from quantities and reports
+// every divergent entry, every transaction that does not net to zero and
+// every stock balance that does not match the ledger
+func (s *StockLedgerIntegrityService) CheckStockLedger(
+	ctx context.Context,
+) (*model.StockLedgerIntegrityReport, error) {
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	report := &model.StockLedgerIntegrityReport{
+		CheckedAt: time.Now(),
+	}
+
+	report.DivergentEntries, report.DivergentEntriesCount, err =
+		s.stockLedgerIntegrityRepository.GetDivergentRunningTotals(ctx, tx, stockLedgerIntegrityReportLimit)
+	if err != nil {
+		return nil, fmt.Errorf("error checking running totals: %v", err)
+	}
+
+	report.UnbalancedTransactions, report.UnbalancedTransactionsCount, err =
+		s.stockLedgerIntegrityRepository.GetUnbalancedStockTransactions(ctx, tx, stockLedgerIntegrityReportLimit)
+	if err != nil {
+		return nil, fmt.Errorf("error checking transaction balances: %v", err)
+	}
+
+	report.DivergentBalances, report.DivergentBalancesCount, err =
+		s.stockLedgerIntegrityRepository.GetDivergentStockBalances(ctx, tx, stockLedgerIntegrityReportLimit)
+	if err != nil {
+		return nil, fmt.Errorf("error checking stock balances: %v", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return report, nil
+}
+
+// RebuildStockLedger rewrites running totals from quantities and then the
+// stock balance table from the running totals, all in a single transaction.
+// Postings wait until the rebuild has finished.
+func (s *StockLedgerIntegrityService) RebuildStockLedger(
+	ctx context.Context,
+) (*model.StockLedgerRebuildResult, error) {
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	err = s.stockLedgerIntegrityRepository.LockStockLedger(ctx, tx)
+	if err != nil {
+		return nil, fmt.Errorf("error locking stock ledger: %v", err)
+	}
+
+	updatedEntriesCount, err := s.stockLedgerIntegrityRepository.RebuildRunningTotals(ctx, tx)
+	if err != nil {
+		return nil, fmt.Errorf("error rebuilding running totals: %v", err)
+	}
+
+	balancesCount, err := s.stockLedgerIntegrityRepository.RebuildStockBalances(ctx, tx)
+	if err != nil {
+		return nil, fmt.Errorf("error rebuilding stock balances: %v", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return &model.StockLedgerRebuildResult{
+		RebuiltAt:           time.Now(),
+		UpdatedEntriesCount: updatedEntriesCount,
+		BalancesCount:       balancesCount,
+	}, nil
+}
@@ -0,0 +1,217 @@
+package service
+
+import (
+	"app/internal/model"
+	"app/internal/repository"
+	"app/pkg/validate"
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/shopspring/decimal"
+)
+
+// stockPlaceSearchLimit is the number of locations or bins offered when
+// searching for one to post to
+const stockPlaceSearchLimit = 50
+
+type StockLocationService struct {
+	db                      *pgxpool.Pool
+	stockLocationRepository *repository.StockLocationRepository
+}
+
+func NewStockLocationService(
+	db *pgxpool.Pool,
+	stockLocationRepository *repository.StockLocationRepository,
+) *StockLocationService {
+	return &StockLocationService{
+		db:                      db,
+		stockLocationRepository: stockLocationRepository,
+	}
+}
+
+func (s *StockLocationService) CreateStockLocation(
+	ctx context.Context,
+	input *model.NewStockLocation,
+	userID int,
+) (int, validate.ValidationErrors, error) {
+
+	var validationErrors validate.ValidationErrors = make(map[string][]string)
+
+	if input.Location == "" {
+		validationErrors.Add("Location", "is required")
+	}
+	if !slices.Contains(model.StockLocationTypes, input.LocationType) {
+		validationErrors.Add("LocationType", "is not a valid location type")
+	}
+	if len(validationErrors) > 0 {
+		return 0, validationErrors, nil
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	existing, err := s.stockLocationRepository.GetStockLocationByName(ctx, tx, input.Location)
+	if err != nil {
+		return 0, nil, err
+	}
+	if existing != nil {
+		validationErrors.Add("Location", "already exists")
+		return 0, validationErrors, nil
+	}
+
+	stockLocationID, err := s.stockLocationRepository.CreateStockLocation(ctx, tx, input, userID)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, nil, fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return stockLocationID, nil, nil
+}
+
+func (s *StockLocationService) UpdateStockLocation(
+	ctx context.Context,
+	stockLocationID int,
+	update *model.StockLocationUpdate,
+	userID int,
+) (validate.ValidationErrors, error) {
+
+	var validationErrors validate.ValidationErrors = make(map[string][]string)
+
+	if !slices.Contains(model.StockLocationTypes, update.LocationType) {
+		validationErrors.Add("LocationType", "is not a valid location type")
+		return validationErrors, nil
+	}
+
+	err := s.stockLocationRepository.UpdateStockLocation(ctx, s.db, stockLocationID, update, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+func (s *StockLocationService) GetStockLocations(
+	ctx context.Context,
+	q *model.GetStockLocationsQuery,
+) ([]model.StockLocation, int, error) {
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return []model.StockLocation{}, 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	locations, err := s.stockLocationRepository.GetStockLocations(ctx, tx, q)
+	if err != nil {
+		return []model.StockLocation{}, 0, err
+	}
+
+	count, err := s.stockLocationRepository.GetStockLocationsCount(ctx, tx, q)
+	if err != nil {
+		return []model.StockLocation{}, 0, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return []model.StockLocation{}, 0, err
+	}
+
+	return locations, count, nil
+}
+
+func (s *StockLocationService) GetStockLocation(
+	ctx context.Context,
+	stockLocationID int,
+) (*model.StockLocation, error) {
+
+	location, err := s.stockLocationRepository.GetStockLocation(ctx, s.db, stockLocationID)
+	if err != nil {
+		return nil, err
+	}
+
+	return location, nil
+}
+
+func (s *StockLocationService) GetStockBins(
+	ctx context.Context,
+	stockLocationID int,
+) ([]model.StockBin, error) {
+
+	bins, err := s.stockLocationRepository.GetStockBins(ctx, s.db, stockLocationID)
+	if err != nil {
+		return nil, err
+	}
+
+	return bins, nil
+}
+
+// SaveStockBin adds a bin to a location or updates the bin if the location
+// already has it
+func (s *StockLocationService) SaveStockBin(
+	ctx context.Context,
+	input *model.PostStockBin,
+	userID int,
+) (validate.ValidationErrors, error) {
+
+	var validationErrors validate.ValidationErrors = make(map[string][]string)
+
+	if input.Bin == "" {
+		validationErrors.Add("Bin", "is required")
+	}
+	if input.Capacity != nil && input.Capacity.LessThanOrEqual(decimal.Zero) {
+		validationErrors.Add("Capacity", "must be greater than 0")
+	}
+	if len(validationErrors) > 0 {
+		return validationErrors, nil
+	}
+
+	err := s.stockLocationRepository.UpsertStockBin(ctx, s.db, input, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+// SearchStockLocations returns the active locations matching the search text
+func (s *StockLocationService) SearchStockLocations(
+	ctx context.Context,
+	searchText string,
+) ([]model.StockLocation, error) {
+
+	locations, err := s.stockLocationRepository.GetStockLocations(ctx, s.db, &model.GetStockLocationsQuery{
+		SearchText: searchText,
+		Page:       1,
+		PageSize:   stockPlaceSearchLimit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return locations, nil
+}
+
+// SearchStockBins returns the active bins of a location matching the search
+// text
+func (s *StockLocationService) SearchStockBins(
+	ctx context.Context,
+	location string,
+	searchText string,
+) ([]model.StockBin, error) {
+
+	bins, err := s.stockLocationRepository.SearchStockBins(
+		ctx, s.db, location, searchText, stockPlaceSearchLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return bins, nil
+}
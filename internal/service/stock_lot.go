@@ -0,0 +1,228 @@
+package service
+
+import (
+	"app/internal/model"
+	"app/internal/repository"
+	"app/pkg/validate"
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// fefoSuggestionLimit is the number of FEFO suggestions shown when posting
+const fefoSuggestionLimit = 10
+
+// expiryNotificationLotLimit is the number of lots named in an expiry
+// notification, the rest are counted
+const expiryNotificationLotLimit = 3
+
+type StockLotService struct {
+	db                  *pgxpool.Pool
+	stockLotRepository  *repository.StockLotRepository
+	userRepository      *repository.UserRepository
+	notificationService *NotificationService
+}
+
+func NewStockLotService(
+	db *pgxpool.Pool,
+	stockLotRepository *repository.StockLotRepository,
+	userRepository *repository.UserRepository,
+	notificationService *NotificationService,
+) *StockLotService {
+	return &StockLotService{
+		db:                  db,
+		stockLotRepository:  stockLotRepository,
+		userRepository:      userRepository,
+		notificationService: notificationService,
+	}
+}
+
+func (s *StockLotService) GetStockLots(
+	ctx context.Context,
+	q *model.GetStockLotsQuery,
+) ([]model.StockLot, int, error) {
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return []model.StockLot{}, 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	stockLots, err := s.stockLotRepository.GetStockLots(ctx, tx, q)
+	if err != nil {
+		return []model.StockLot{}, 0, err
+	}
+
+	count, err := s.stockLotRepository.GetStockLotsCount(ctx, tx, q)
+	if err != nil {
+		return []model.StockLot{}, 0, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return []model.StockLot{}, 0, err
+	}
+
+	return stockLots, count, nil
+}
+
+func (s *StockLotService) GetStockLot(
+	ctx context.Context,
+	stockLotID int,
+) (*model.StockLot, error) {
+
+	stockLot, err := s.stockLotRepository.GetStockLot(ctx, s.db, stockLotID)
+	if err != nil {
+		return nil, err
+	}
+
+	return stockLot, nil
+}
+
+func (s *StockLotService) UpdateStockLot(
+	ctx context.Context,
+	stockLotID int,
+	update *model.UpdateStockLot,
+	userID int,
+) (validate.ValidationErrors, error) {
+
+	var ve validate.ValidationErrors = make(map[string][]string)
+
+	if !slices.Contains(model.StockLotStatuses, update.Status) {
+		ve.Add("Status", "is not a valid status")
+	}
+
+	if update.ManufactureDate != nil && update.ExpiryDate != nil &&
+		update.ExpiryDate.Before(*update.ManufactureDate) {
+		ve.Add("ExpiryDate", "must not be before the manufacture date")
+	}
+
+	if len(ve) > 0 {
+		return ve, nil
+	}
+
+	err := s.stockLotRepository.UpdateStockLot(ctx, s.db, stockLotID, update, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+// GetFEFOSuggestions returns where to pick the stock item from, soonest to
+// expire first
+func (s *StockLotService) GetFEFOSuggestions(
+	ctx context.Context,
+	stockItemID int,
+) ([]model.FEFOSuggestion, error) {
+
+	suggestions, err := s.stockLotRepository.GetFEFOSuggestions(
+		ctx, s.db, stockItemID, fefoSuggestionLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return suggestions, nil
+}
+
+// NotifyExpiringStockLots notifies the supply chain team of lots in stock
+// that are approaching expiry. Each lot is only notified once per expiry
+// date, so it is safe to run repeatedly.
+func (s *StockLotService) NotifyExpiringStockLots(ctx context.Context) error {
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	stockLots, err := s.stockLotRepository.GetStockLotsToNotifyExpiry(
+		ctx, tx, model.StockLotExpiryWarningDays,
+	)
+	if err != nil {
+		return err
+	}
+	if len(stockLots) == 0 {
+		return nil
+	}
+
+	userIDs, err := s.userRepository.ListSupplyChainUserIDs(ctx, tx)
+	if err != nil {
+		return err
+	}
+
+	stockLotIDs := make([]int, len(stockLots))
+	for i, sl := range stockLots {
+		stockLotIDs[i] = sl.StockLotID
+	}
+
+	err = s.stockLotRepository.SetStockLotsExpiryNotified(ctx, tx, stockLotIDs)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	title := "Lots approaching expiry"
+	summary := expiringStockLotsSummary(stockLots)
+
+	query := url.Values{}
+	query.Set("ExpiringWithinDays", fmt.Sprintf("%d", model.StockLotExpiryWarningDays))
+	targetURL := "/stock/lots?" + query.Encode()
+
+	for _, recipientID := range userIDs {
+		notificationID, err := s.notificationService.CreateNotification(ctx, model.NewNotification{
+			UserID:     recipientID,
+			Category:   "stock",
+			Title:      title,
+			Summary:    summary,
+			URL:        targetURL,
+			Reason:     "Lot expiry",
+			ReasonType: model.NotificationReasonWarning,
+		})
+		if err != nil {
+			log.Println("error creating lot expiry notification:", err)
+		}
+
+		payload := model.PushNotificationPayload{
+			Title:          title,
+			Body:           summary,
+			URL:            targetURL,
+			NotificationID: notificationID,
+		}
+		if notificationID > 0 {
+			query := url.Values{}
+			query.Set("Redirect", targetURL)
+			payload.URL = fmt.Sprintf("/notifications/%d?%s", notificationID, query.Encode())
+		}
+
+		if err := s.notificationService.SendPushNotification(ctx, recipientID, payload, ""); err != nil {
+			log.Println("error sending lot expiry push notification:", err)
+		}
+	}
+
+	return nil
+}
+
+func expiringStockLotsSummary(stockLots []model.StockLot) string {
+	parts := make([]string, 0, expiryNotificationLotLimit+1)
+	for i, sl := range stockLots {
+		if i == expiryNotificationLotLimit {
+			parts = append(parts, fmt.Sprintf("and %d more", len(stockLots)-i))
+			break
+		}
+		parts = append(parts, fmt.Sprintf(
+			"%s lot %s expires %s",
+			sl.StockCode, sl.LotNumber, sl.ExpiryDate.Format("2006-01-02"),
+		))
+	}
+
+	return strings.Join(parts, ", ")
+}
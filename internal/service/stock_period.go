@@ -0,0 +1,145 @@
+package service
+
+import (
+	"app/internal/model"
+	"app/internal/repository"
+	"app/pkg/validate"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type StockPeriodService struct {
+	db                    *pgxpool.Pool
+	stockPeriodRepository *repository.StockPeriodRepository
+}
+
+func NewStockPeriodService(
+	db *pgxpool.Pool,
+	stockPeriodRepository *repository.StockPeriodRepository,
+) *StockPeriodService {
+	return &StockPeriodService{
+		db:                    db,
+		stockPeriodRepository: stockPeriodRepository,
+	}
+}
+
+func (s *StockPeriodService) GetStockPeriods(ctx context.Context) ([]model.StockPeriod, error) {
+	return s.stockPeriodRepository.GetStockPeriods(ctx, s.db)
+}
+
+func (s *StockPeriodService) GetStockPeriodChanges(ctx context.Context) ([]model.StockPeriodChange, error) {
+	return s.stockPeriodRepository.GetStockPeriodChanges(ctx, s.db)
+}
+
+// CloseStockPeriod closes a period to postings, which also closes every month
+// before it, as a posting back-dated into an earlier month would change its
+// running totals. Only months that have ended can be closed, as postings are
+// made in the current month, and reopened periods before it must be closed
+// first.
+func (s *StockPeriodService) CloseStockPeriod(
+	ctx context.Context,
+	period time.Time,
+	reason string,
+	userID int,
+) (validate.ValidationErrors, error) {
+	return s.setStockPeriodClosed(ctx, period, true, reason, userID)
+}
+
+// ReopenStockPeriod reopens a closed period to allow back-dated postings to
+// it. Only the latest closed period can be reopened, so periods are reopened
+// latest first. The reason is required and kept with the change.
+func (s *StockPeriodService) ReopenStockPeriod(
+	ctx context.Context,
+	period time.Time,
+	reason string,
+	userID int,
+) (validate.ValidationErrors, error) {
+	return s.setStockPeriodClosed(ctx, period, false, reason, userID)
+}
+
+func (s *StockPeriodService) setStockPeriodClosed(
+	ctx context.Context,
+	period time.Time,
+	isClosed bool,
+	reason string,
+	userID int,
+) (validate.ValidationErrors, error) {
+
+	var validationErrors validate.ValidationErrors = make(map[string][]string)
+
+	period = model.StockPeriodOf(period)
+
+	if isClosed && !period.Before(model.StockPeriodOf(time.Now())) {
+		validationErrors.Add("Period", "must be a month that has ended")
+	}
+	if !isClosed && reason == "" {
+		validationErrors.Add("Reason", "is required to reopen a period")
+	}
+
+	if len(validationErrors) > 0 {
+		return validationErrors, nil
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	err = s.stockPeriodRepository.LockStockPeriods(ctx, tx, false)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.stockPeriodRepository.GetStockPeriod(ctx, tx, period)
+	if err != nil {
+		return nil, err
+	}
+
+	isClosedNow := existing != nil && existing.IsClosed
+	if isClosed && isClosedNow {
+		validationErrors.Add("Period", "is already closed")
+		return validationErrors, nil
+	}
+	if !isClosed && !isClosedNow {
+		validationErrors.Add("Period", "is not closed")
+		return validationErrors, nil
+	}
+
+	// closed periods run without gaps up to the latest, so that the periods
+	// shown open are the ones that can be posted to
+	periods, err := s.stockPeriodRepository.GetStockPeriods(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range periods {
+		if isClosed && !p.IsClosed && p.Period.Before(period) {
+			validationErrors.Add("Period", fmt.Sprintf(
+				"cannot be closed while %s is reopened, close it first",
+				model.FormatStockPeriod(p.Period),
+			))
+			return validationErrors, nil
+		}
+		if !isClosed && p.IsClosed && p.Period.After(period) {
+			validationErrors.Add("Period", fmt.Sprintf(
+				"cannot be reopened while %s is closed, reopen it first",
+				model.FormatStockPeriod(p.Period),
+			))
+			return validationErrors, nil
+		}
+	}
+
+	err = s.stockPeriodRepository.SetStockPeriodClosed(ctx, tx, period, isClosed, reason, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,260 @@
+package service
+
+import (
+	"app/internal/model"
+	"app/internal/repository"
+	"app/pkg/validate"
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/shopspring/decimal"
+)
+
+// reorderNotificationItemLimit is the number of stock items named in a
+// reorder notification, the rest are counted
+const reorderNotificationItemLimit = 3
+
+type StockReorderService struct {
+	db                     *pgxpool.Pool
+	stockItemRepository    *repository.StockItemRepository
+	stockReorderRepository *repository.StockReorderRepository
+	teamRepository         *repository.TeamRepository
+	userRepository         *repository.UserRepository
+	notificationService    *NotificationService
+}
+
+func NewStockReorderService(
+	db *pgxpool.Pool,
+	stockItemRepository *repository.StockItemRepository,
+	stockReorderRepository *repository.StockReorderRepository,
+	teamRepository *repository.TeamRepository,
+	userRepository *repository.UserRepository,
+	notificationService *NotificationService,
+) *StockReorderService {
+	return &StockReorderService{
+		db:                     db,
+		stockItemRepository:    stockItemRepository,
+		stockReorderRepository: stockReorderRepository,
+		teamRepository:         teamRepository,
+		userRepository:         userRepository,
+		notificationService:    notificationService,
+	}
+}
+
+func (s *StockReorderService) GetStockReorderPolicies(
+	ctx context.Context,
+) ([]model.StockReorderPolicy, error) {
+
+	policies, err := s.stockReorderRepository.GetStockReorderPolicies(ctx, s.db)
+	if err != nil {
+		return nil, err
+	}
+
+	return policies, nil
+}
+
+// SaveStockReorderPolicy creates the policy for the stock item and location,
+// or updates it if there is one. Stock is checked against the saved policy
+// straight away.
+func (s *StockReorderService) SaveStockReorderPolicy(
+	ctx context.Context,
+	input *model.PostStockReorderPolicy,
+	userID int,
+) (validate.ValidationErrors, error) {
+
+	validationErrors := validateStockReorderPolicy(input)
+	if len(validationErrors) > 0 {
+		return validationErrors, nil
+	}
+
+	stockItem, err := s.stockItemRepository.GetStockItem(ctx, s.db, input.StockItemID)
+	if err != nil {
+		return nil, err
+	}
+	if stockItem == nil {
+		validationErrors.Add("StockItemID", "does not exist")
+		return validationErrors, nil
+	}
+
+	err = s.stockReorderRepository.UpsertStockReorderPolicy(ctx, s.db, input, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.CheckStockReorder(ctx, []int{input.StockItemID})
+	if err != nil {
+		log.Println("error checking stock reorder:", err)
+	}
+
+	return nil, nil
+}
+
+func (s *StockReorderService) DeleteStockReorderPolicy(
+	ctx context.Context,
+	stockReorderPolicyID int,
+) error {
+
+	err := s.stockReorderRepository.DeleteStockReorderPolicy(ctx, s.db, stockReorderPolicyID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *StockReorderService) GetStockReplenishments(
+	ctx context.Context,
+) ([]model.StockReplenishment, error) {
+
+	replenishments, err := s.stockReorderRepository.GetStockReplenishments(ctx, s.db)
+	if err != nil {
+		return nil, err
+	}
+
+	return replenishments, nil
+}
+
+// CheckStockReorder checks the stock of the stock items, or of all stock
+// items if stockItemIDs is nil, against their reorder policies and notifies
+// the team of each policy whose available stock has crossed below the
+// reorder point. Policies are only notified again once stock has recovered
+// above the reorder point, so it is safe to run repeatedly.
+func (s *StockReorderService) CheckStockReorder(
+	ctx context.Context,
+	stockItemIDs []int,
+) error {
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	crossed, err := s.stockReorderRepository.UpdateStockReorderStates(ctx, tx, stockItemIDs)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	// group by the team to notify, 0 being the supply chain team
+	byTeam := map[int][]model.StockReplenishment{}
+	teamIDs := []int{}
+	for _, sr := range crossed {
+		teamID := 0
+		if sr.NotifyTeamID != nil {
+			teamID = *sr.NotifyTeamID
+		}
+		if _, ok := byTeam[teamID]; !ok {
+			teamIDs = append(teamIDs, teamID)
+		}
+		byTeam[teamID] = append(byTeam[teamID], sr)
+	}
+
+	for _, teamID := range teamIDs {
+		var userIDs []int
+		if teamID == 0 {
+			userIDs, err = s.userRepository.ListSupplyChainUserIDs(ctx, s.db)
+		} else {
+			userIDs, err = s.teamRepository.ListTeamUserIDs(ctx, s.db, teamID)
+		}
+		if err != nil {
+			return err
+		}
+
+		s.notifyStockReorder(ctx, userIDs, byTeam[teamID])
+	}
+
+	return nil
+}
+
+func (s *StockReorderService) notifyStockReorder(
+	ctx context.Context,
+	userIDs []int,
+	replenishments []model.StockReplenishment,
+) {
+	title := "Stock needs replenishing"
+	summary := stockReorderSummary(replenishments)
+	targetURL := "/stock/replenishment"
+
+	for _, recipientID := range userIDs {
+		notificationID, err := s.notificationService.CreateNotification(ctx, model.NewNotification{
+			UserID:     recipientID,
+			Category:   "stock",
+			Title:      title,
+			Summary:    summary,
+			URL:        targetURL,
+			Reason:     "Reorder point",
+			ReasonType: model.NotificationReasonWarning,
+		})
+		if err != nil {
+			log.Println("error creating reorder notification:", err)
+		}
+
+		payload := model.PushNotificationPayload{
+			Title:          title,
+			Body:           summary,
+			URL:            targetURL,
+			NotificationID: notificationID,
+		}
+		if notificationID > 0 {
+			query := url.Values{}
+			query.Set("Redirect", targetURL)
+			payload.URL = fmt.Sprintf("/notifications/%d?%s", notificationID, query.Encode())
+		}
+
+		if err := s.notificationService.SendPushNotification(ctx, recipientID, payload, ""); err != nil {
+			log.Println("error sending reorder push notification:", err)
+		}
+	}
+}
+
+func stockReorderSummary(replenishments []model.StockReplenishment) string {
+	parts := make([]string, 0, reorderNotificationItemLimit+1)
+	for i, sr := range replenishments {
+		if i == reorderNotificationItemLimit {
+			parts = append(parts, fmt.Sprintf("and %d more", len(replenishments)-i))
+			break
+		}
+
+		place := ""
+		if sr.Location != nil {
+			place = " at " + *sr.Location
+		}
+		parts = append(parts, fmt.Sprintf(
+			"%s%s has %s %s available",
+			sr.StockCode, place, sr.Available().String(), sr.Unit,
+		))
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+func validateStockReorderPolicy(input *model.PostStockReorderPolicy) validate.ValidationErrors {
+
+	var ve validate.ValidationErrors = make(map[string][]string)
+
+	if input.StockItemID == 0 {
+		ve.Add("StockItemID", "is required")
+	}
+
+	if input.MinQty.LessThan(decimal.Zero) {
+		ve.Add("MinQty", "must not be negative")
+	}
+
+	if input.ReorderPoint.LessThan(input.MinQty) {
+		ve.Add("ReorderPoint", "must not be less than the min")
+	}
+
+	if input.MaxQty.LessThanOrEqual(input.ReorderPoint) {
+		ve.Add("MaxQty", "must be greater than the reorder point")
+	}
+
+	return ve
+}
@@ -0,0 +1,227 @@
+package service
+
+import (
+	"app/internal/model"
+	"app/internal/repository"
+	"app/pkg/validate"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/shopspring/decimal"
+)
+
+type StockReservationService struct {
+	db                         *pgxpool.Pool
+	stockItemRepository        *repository.StockItemRepository
+	stockReservationRepository *repository.StockReservationRepository
+	stockTransactionService    *StockTransactionService
+}
+
+func NewStockReservationService(
+	db *pgxpool.Pool,
+	stockItemRepository *repository.StockItemRepository,
+	stockReservationRepository *repository.StockReservationRepository,
+	stockTransactionService *StockTransactionService,
+) *StockReservationService {
+	return &StockReservationService{
+		db:                         db,
+		stockItemRepository:        stockItemRepository,
+		stockReservationRepository: stockReservationRepository,
+		stockTransactionService:    stockTransactionService,
+	}
+}
+
+// CreateStockReservation reserves stock for a demand. Only stock that is on
+// hand and not already reserved can be reserved.
+func (s *StockReservationService) CreateStockReservation(
+	ctx context.Context,
+	input *model.NewStockReservation,
+	userID int,
+) (validate.ValidationErrors, error) {
+
+	validationErrors := s.validateNewStockReservation(input)
+	if len(validationErrors) > 0 {
+		return validationErrors, nil
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	stockItem, err := s.stockItemRepository.GetStockItem(ctx, tx, input.StockItemID)
+	if err != nil {
+		return nil, err
+	}
+	if stockItem == nil {
+		validationErrors.Add("StockItemID", "does not exist")
+		return validationErrors, nil
+	}
+
+	// reservations are held in the base unit, like the ledger
+	qty, err := s.stockTransactionService.ConvertToBaseUnit(ctx, tx, input.StockItemID, input.Unit, input.Qty)
+	if errors.Is(err, ErrUnknownStockItemUnit) {
+		validationErrors.Add("Unit", "is not defined for the stock item")
+		return validationErrors, nil
+	} else if err != nil {
+		return nil, err
+	}
+	input.Qty = qty
+	input.Unit = ""
+
+	_, err = s.stockReservationRepository.CreateStockReservation(ctx, tx, input, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	shortfall, err := s.stockReservationRepository.GetStockReservationShortfall(
+		ctx, tx, input.StockItemID, input.Location, input.Bin, input.LotNumber,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if shortfall != nil {
+		validationErrors.Add("Qty", fmt.Sprintf(
+			"exceeds the stock available, %s %s on hand with %s %s reserved",
+			shortfall.OnHand.String(), shortfall.Unit,
+			shortfall.Reserved.Sub(input.Qty).String(), shortfall.Unit,
+		))
+		return validationErrors, nil
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	// reserving stock takes it from what is available to reorder against
+	s.stockTransactionService.NotifyStockReorder(ctx, input.StockItemID)
+
+	return nil, nil
+}
+
+// ReleaseStockReservation frees what is left of an open reservation
+func (s *StockReservationService) ReleaseStockReservation(
+	ctx context.Context,
+	stockReservationID int,
+	userID int,
+) error {
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	status, err := s.stockReservationRepository.LockStockReservation(ctx, tx, stockReservationID)
+	if err != nil {
+		return err
+	}
+	if status == nil {
+		return fmt.Errorf("stock reservation does not exist")
+	}
+	if *status != model.OpenStockReservationStatus {
+		return fmt.Errorf("only open reservations can be released, this reservation is %s", *status)
+	}
+
+	reservation, err := s.stockReservationRepository.GetStockReservation(ctx, tx, stockReservationID)
+	if err != nil {
+		return err
+	}
+
+	err = s.stockReservationRepository.ReleaseStockReservation(ctx, tx, stockReservationID, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	s.stockTransactionService.NotifyStockReorder(ctx, reservation.StockItemID)
+
+	return nil
+}
+
+func (s *StockReservationService) GetStockReservations(
+	ctx context.Context,
+	q *model.GetStockReservationsQuery,
+) ([]model.StockReservation, int, error) {
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return []model.StockReservation{}, 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	reservations, err := s.stockReservationRepository.GetStockReservations(ctx, tx, q)
+	if err != nil {
+		return []model.StockReservation{}, 0, err
+	}
+
+	count, err := s.stockReservationRepository.GetStockReservationsCount(ctx, tx, q)
+	if err != nil {
+		return []model.StockReservation{}, 0, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return []model.StockReservation{}, 0, err
+	}
+
+	return reservations, count, nil
+}
+
+func (s *StockReservationService) GetStockReservation(
+	ctx context.Context,
+	stockReservationID int,
+) (*model.StockReservation, error) {
+
+	reservation, err := s.stockReservationRepository.GetStockReservation(ctx, s.db, stockReservationID)
+	if err != nil {
+		return nil, err
+	}
+
+	return reservation, nil
+}
+
+// GetStockAvailability returns what is on hand, reserved and available of a
+// stock code across all locations
+func (s *StockReservationService) GetStockAvailability(
+	ctx context.Context,
+	stockCode string,
+) (*model.StockAvailability, error) {
+
+	availability, err := s.stockReservationRepository.GetStockAvailability(ctx, s.db, stockCode)
+	if err != nil {
+		return nil, err
+	}
+
+	return availability, nil
+}
+
+func (s *StockReservationService) validateNewStockReservation(
+	input *model.NewStockReservation,
+) validate.ValidationErrors {
+
+	var ve validate.ValidationErrors = make(map[string][]string)
+
+	if input.StockItemID == 0 {
+		ve.Add("StockItemID", "is required")
+	}
+
+	if input.Qty.LessThanOrEqual(decimal.Zero) {
+		ve.Add("Qty", "must be greater than 0")
+	}
+
+	if input.Bin != "" && input.Location == "" {
+		ve.Add("Location", "is required when a bin is given")
+	}
+
+	if input.DemandReference == "" {
+		ve.Add("DemandReference", "is required")
+	}
+
+	return ve
+}
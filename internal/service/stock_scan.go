@@ -0,0 +1,388 @@
+package service
+
+import (
+	"app/internal/model"
+	"app/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/shopspring/decimal"
+)
+
+// ErrInvalidScan is returned when a scan does not match the master data or
+// the stock ledger. The message says what was wrong with it.
+var ErrInvalidScan = errors.New("invalid scan")
+
+type StockScanService struct {
+	db                         *pgxpool.Pool
+	stockItemRepository        *repository.StockItemRepository
+	stockLocationRepository    *repository.StockLocationRepository
+	stockTransactionRepository *repository.StockTransactionRepository
+	stockTransactionService    *StockTransactionService
+}
+
+func NewStockScanService(
+	db *pgxpool.Pool,
+	stockItemRepository *repository.StockItemRepository,
+	stockLocationRepository *repository.StockLocationRepository,
+	stockTransactionRepository *repository.StockTransactionRepository,
+	stockTransactionService *StockTransactionService,
+) *StockScanService {
+	return &StockScanService{
+		db:                         db,
+		stockItemRepository:        stockItemRepository,
+		stockLocationRepository:    stockLocationRepository,
+		stockTransactionRepository: stockTransactionRepository,
+		stockTransactionService:    stockTransactionService,
+	}
+}
+
+// ApplyScan validates what was scanned or entered for the current step of the
+// flow and records it in the state, moving the flow on to the next step. An
+// invalid scan returns ErrInvalidScan and leaves the state as it was. noLot
+// completes the lot step without a lot.
+func (s *StockScanService) ApplyScan(
+	ctx context.Context,
+	state *model.StockScanState,
+	scan string,
+	noLot bool,
+) error {
+
+	scan = strings.ToUpper(strings.TrimSpace(scan))
+
+	switch state.CurrentStep() {
+	case model.ItemStockScanStep:
+		return s.applyItemScan(ctx, state, scan)
+	case model.SourceBinStockScanStep:
+		return s.applySourceBinScan(ctx, state, scan)
+	case model.LotStockScanStep:
+		return s.applyLotScan(ctx, state, scan, noLot)
+	case model.QtyStockScanStep:
+		return s.applyQty(ctx, state, scan)
+	case model.DestinationStockScanStep:
+		return s.applyDestinationBinScan(ctx, state, scan)
+	}
+
+	return fmt.Errorf("%w: nothing left to scan, confirm the posting", ErrInvalidScan)
+}
+
+func (s *StockScanService) applyItemScan(
+	ctx context.Context,
+	state *model.StockScanState,
+	scan string,
+) error {
+
+	if scan == "" {
+		return fmt.Errorf("%w: scan the item label", ErrInvalidScan)
+	}
+
+	stockItem, err := s.stockItemRepository.GetStockItemByStockCode(ctx, s.db, scan)
+	if err != nil {
+		return err
+	}
+	if stockItem == nil {
+		return fmt.Errorf("%w: stock code %s does not exist", ErrInvalidScan, scan)
+	}
+	if stockItem.IsSerialised {
+		return fmt.Errorf(
+			"%w: %s is serialised, post it from Post Transaction to give its serial numbers",
+			ErrInvalidScan, scan,
+		)
+	}
+
+	state.StockCode = stockItem.StockCode
+	return nil
+}
+
+func (s *StockScanService) applySourceBinScan(
+	ctx context.Context,
+	state *model.StockScanState,
+	scan string,
+) error {
+
+	location, bin, err := s.checkStockPlaceScan(ctx, scan, false)
+	if err != nil {
+		return err
+	}
+
+	levels, err := s.GetStockLevelsAt(ctx, state.StockCode, location, bin)
+	if err != nil {
+		return err
+	}
+	if len(levels) == 0 {
+		return fmt.Errorf(
+			"%w: there is no %s in %s",
+			ErrInvalidScan, state.StockCode, model.StockPlaceScan(location, bin),
+		)
+	}
+
+	state.FromLocation, state.FromBin = location, bin
+	return nil
+}
+
+func (s *StockScanService) applyLotScan(
+	ctx context.Context,
+	state *model.StockScanState,
+	scan string,
+	noLot bool,
+) error {
+
+	if noLot {
+		scan = ""
+	} else if scan == "" {
+		return fmt.Errorf("%w: scan the lot label or choose no lot", ErrInvalidScan)
+	}
+
+	// production makes new lots, other flows take stock of a lot already
+	// at the source
+	if state.Flow != model.ProductionStockScanFlow {
+		level, _, err := s.stockLevelAt(ctx, state, scan)
+		if err != nil {
+			return err
+		}
+		if !level.IsPositive() {
+			lot := "without a lot"
+			if scan != "" {
+				lot = "of lot " + scan
+			}
+			return fmt.Errorf(
+				"%w: there is no %s %s in %s",
+				ErrInvalidScan, state.StockCode, lot,
+				model.StockPlaceScan(state.FromLocation, state.FromBin),
+			)
+		}
+	}
+
+	state.LotNumber, state.NoLot = scan, noLot
+	return nil
+}
+
+func (s *StockScanService) applyQty(
+	ctx context.Context,
+	state *model.StockScanState,
+	scan string,
+) error {
+
+	qty, err := decimal.NewFromString(scan)
+	if err != nil || !qty.IsPositive() {
+		return fmt.Errorf("%w: enter a quantity greater than 0", ErrInvalidScan)
+	}
+
+	if state.Flow != model.ProductionStockScanFlow {
+		level, unit, err := s.stockLevelAt(ctx, state, state.LotNumber)
+		if err != nil {
+			return err
+		}
+		if qty.GreaterThan(level) {
+			return fmt.Errorf(
+				"%w: there is only %s %s of %s in %s",
+				ErrInvalidScan, level, unit, state.StockCode,
+				model.StockPlaceScan(state.FromLocation, state.FromBin),
+			)
+		}
+	}
+
+	state.Qty = &qty
+	return nil
+}
+
+func (s *StockScanService) applyDestinationBinScan(
+	ctx context.Context,
+	state *model.StockScanState,
+	scan string,
+) error {
+
+	location, bin, err := s.checkStockPlaceScan(ctx, scan, true)
+	if err != nil {
+		return err
+	}
+
+	if location == state.FromLocation && bin == state.FromBin {
+		return fmt.Errorf(
+			"%w: the stock is already in %s, scan where it is going",
+			ErrInvalidScan, model.StockPlaceScan(location, bin),
+		)
+	}
+
+	state.ToLocation, state.ToBin = location, bin
+	return nil
+}
+
+// checkStockPlaceScan checks the location and bin of a bin label are in the
+// master data. Stock can only be put into places that are not archived.
+func (s *StockScanService) checkStockPlaceScan(
+	ctx context.Context,
+	scan string,
+	isDestination bool,
+) (string, string, error) {
+
+	location, bin := model.ParseStockPlaceScan(scan)
+	if location == "" {
+		return "", "", fmt.Errorf("%w: scan the bin label", ErrInvalidScan)
+	}
+
+	status, err := s.stockLocationRepository.GetStockPlaceStatus(ctx, s.db, location, bin)
+	if err != nil {
+		return "", "", err
+	}
+
+	if !status.LocationExists {
+		return "", "", fmt.Errorf("%w: location %s does not exist", ErrInvalidScan, location)
+	}
+	if bin != "" && !status.BinExists {
+		return "", "", fmt.Errorf("%w: location %s has no bin %s", ErrInvalidScan, location, bin)
+	}
+	if isDestination && (status.LocationArchived || status.BinArchived) {
+		return "", "", fmt.Errorf("%w: %s is archived", ErrInvalidScan, model.StockPlaceScan(location, bin))
+	}
+
+	return location, bin, nil
+}
+
+// GetStockLevelsAt returns the STOCK levels of each lot of the stock code at
+// exactly the location and bin
+func (s *StockScanService) GetStockLevelsAt(
+	ctx context.Context,
+	stockCode string,
+	location string,
+	bin string,
+) ([]model.StockLevel, error) {
+
+	levels, err := s.stockTransactionRepository.GetStockLevels(ctx, s.db, &model.GetStockLevelsInput{
+		Account:   model.StockStockAccount,
+		StockCode: stockCode,
+		Location:  location,
+		Bin:       bin,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// an empty bin matches every bin
+	atPlace := []model.StockLevel{}
+	for _, l := range levels {
+		if l.Bin == bin && l.StockLevel.IsPositive() {
+			atPlace = append(atPlace, l)
+		}
+	}
+
+	return atPlace, nil
+}
+
+// stockLevelAt returns the STOCK level of the lot at the source of the flow
+// and the unit it is in
+func (s *StockScanService) stockLevelAt(
+	ctx context.Context,
+	state *model.StockScanState,
+	lotNumber string,
+) (decimal.Decimal, string, error) {
+
+	levels, err := s.GetStockLevelsAt(ctx, state.StockCode, state.FromLocation, state.FromBin)
+	if err != nil {
+		return decimal.Zero, "", err
+	}
+
+	for _, l := range levels {
+		if l.LotNumber == lotNumber {
+			return l.StockLevel, l.Unit, nil
+		}
+	}
+
+	return decimal.Zero, "", nil
+}
+
+// checkStockScanState checks every step of a completed scan flow again, as
+// the state comes back from the client, by scanning each value in turn into
+// a new state. It returns the new state.
+func (s *StockScanService) checkStockScanState(
+	ctx context.Context,
+	state *model.StockScanState,
+) (*model.StockScanState, error) {
+
+	if state.CurrentStep() != model.ConfirmStockScanStep {
+		return nil, fmt.Errorf("%w: the %s step is not done", ErrInvalidScan, state.CurrentStep())
+	}
+
+	checked := &model.StockScanState{Flow: state.Flow}
+	for _, step := range state.Flow.Steps() {
+		var err error
+		switch step {
+		case model.ItemStockScanStep:
+			err = s.ApplyScan(ctx, checked, state.StockCode, false)
+		case model.SourceBinStockScanStep:
+			err = s.ApplyScan(ctx, checked, model.StockPlaceScan(state.FromLocation, state.FromBin), false)
+		case model.LotStockScanStep:
+			err = s.ApplyScan(ctx, checked, state.LotNumber, state.NoLot)
+		case model.QtyStockScanStep:
+			err = s.ApplyScan(ctx, checked, state.Qty.String(), false)
+		case model.DestinationStockScanStep:
+			err = s.ApplyScan(ctx, checked, model.StockPlaceScan(state.ToLocation, state.ToBin), false)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return checked, nil
+}
+
+// PostStockScan posts a completed scan flow. Every step is checked again
+// before posting, and the posting is then checked as any other.
+func (s *StockScanService) PostStockScan(
+	ctx context.Context,
+	state *model.StockScanState,
+	userID int,
+) error {
+
+	state, err := s.checkStockScanState(ctx, state)
+	if err != nil {
+		return err
+	}
+
+	stockItem, err := s.stockItemRepository.GetStockItemByStockCode(ctx, s.db, state.StockCode)
+	if err != nil {
+		return err
+	}
+	if stockItem == nil {
+		return fmt.Errorf("%w: stock code %s does not exist", ErrInvalidScan, state.StockCode)
+	}
+
+	const transactionNote = "Posted by scanning"
+
+	switch state.Flow {
+	case model.MovementStockScanFlow:
+		return s.stockTransactionService.PostManualStockMovement(ctx, &model.PostManualStockMovementInput{
+			StockItemID:     stockItem.StockItemID,
+			Qty:             *state.Qty,
+			FromLocation:    state.FromLocation,
+			FromBin:         state.FromBin,
+			ToLocation:      state.ToLocation,
+			ToBin:           state.ToBin,
+			LotNumber:       state.LotNumber,
+			TransactionNote: transactionNote,
+		}, userID)
+	case model.ConsumptionStockScanFlow:
+		return s.stockTransactionService.PostManualConsumption(ctx, &model.PostManualGenericStockTransactionInput{
+			StockItemID:     stockItem.StockItemID,
+			Qty:             *state.Qty,
+			Location:        state.FromLocation,
+			Bin:             state.FromBin,
+			LotNumber:       state.LotNumber,
+			TransactionNote: transactionNote,
+		}, userID)
+	case model.ProductionStockScanFlow:
+		return s.stockTransactionService.PostManualProduction(ctx, &model.PostManualGenericStockTransactionInput{
+			StockItemID:     stockItem.StockItemID,
+			Qty:             *state.Qty,
+			Location:        state.ToLocation,
+			Bin:             state.ToBin,
+			LotNumber:       state.LotNumber,
+			TransactionNote: transactionNote,
+		}, userID)
+	}
+
+	return fmt.Errorf("%w: %s", ErrUnknownTransactionType, state.Flow)
+}
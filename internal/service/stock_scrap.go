@@ -0,0 +1,145 @@
+package service
+
+import (
+	"app/internal/model"
+	"app/internal/repository"
+	"app/pkg/validate"
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// wasteTopOffendersCount is how many stock items the waste report lists as
+// top offenders
+const wasteTopOffendersCount = 10
+
+type StockScrapService struct {
+	db                   *pgxpool.Pool
+	stockScrapRepository *repository.StockScrapRepository
+}
+
+func NewStockScrapService(
+	db *pgxpool.Pool,
+	stockScrapRepository *repository.StockScrapRepository,
+) *StockScrapService {
+	return &StockScrapService{
+		db:                   db,
+		stockScrapRepository: stockScrapRepository,
+	}
+}
+
+func (s *StockScrapService) CreateScrapReason(
+	ctx context.Context,
+	input *model.NewScrapReason,
+	userID int,
+) (validate.ValidationErrors, error) {
+
+	var validationErrors validate.ValidationErrors = make(map[string][]string)
+
+	if input.Code == "" {
+		validationErrors.Add("Code", "is required")
+		return validationErrors, nil
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	existing, err := s.stockScrapRepository.GetScrapReasonByCode(ctx, tx, input.Code)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		validationErrors.Add("Code", "already exists")
+		return validationErrors, nil
+	}
+
+	_, err = s.stockScrapRepository.CreateScrapReason(ctx, tx, input, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return nil, nil
+}
+
+func (s *StockScrapService) UpdateScrapReason(
+	ctx context.Context,
+	scrapReasonID int,
+	update *model.ScrapReasonUpdate,
+	userID int,
+) error {
+
+	existing, err := s.stockScrapRepository.GetScrapReason(ctx, s.db, scrapReasonID)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return fmt.Errorf("scrap reason %d does not exist", scrapReasonID)
+	}
+
+	return s.stockScrapRepository.UpdateScrapReason(ctx, s.db, scrapReasonID, update, userID)
+}
+
+func (s *StockScrapService) GetScrapReasons(
+	ctx context.Context,
+	showArchived bool,
+) ([]model.ScrapReason, error) {
+
+	reasons, err := s.stockScrapRepository.GetScrapReasons(ctx, s.db, showArchived)
+	if err != nil {
+		return []model.ScrapReason{}, err
+	}
+
+	return reasons, nil
+}
+
+// GetWasteReport returns a page of waste by stock item, reason and location
+// along with the totals, trend and top offenders of everything matched
+func (s *StockScrapService) GetWasteReport(
+	ctx context.Context,
+	input *model.GetWasteReportInput,
+) (model.WasteReport, error) {
+
+	var report model.WasteReport
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return report, err
+	}
+	defer tx.Rollback(ctx)
+
+	report.Lines, err = s.stockScrapRepository.GetWasteReportLines(ctx, tx, input)
+	if err != nil {
+		return report, err
+	}
+
+	report.Summary, err = s.stockScrapRepository.GetWasteReportSummary(ctx, tx, input)
+	if err != nil {
+		return report, err
+	}
+
+	report.Trend, err = s.stockScrapRepository.GetWasteTrend(ctx, tx, input)
+	if err != nil {
+		return report, err
+	}
+
+	report.TopOffenders, err = s.stockScrapRepository.GetWasteTopOffenders(
+		ctx, tx, input, wasteTopOffendersCount,
+	)
+	if err != nil {
+		return report, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return report, err
+	}
+
+	return report, nil
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"app/internal/model"
+	"app/internal/repository"
+	"context"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type StockSerialService struct {
+	db                    *pgxpool.Pool
+	stockSerialRepository *repository.StockSerialRepository
+}
+
+func NewStockSerialService(
+	db *pgxpool.Pool,
+	stockSerialRepository *repository.StockSerialRepository,
+) *StockSerialService {
+	return &StockSerialService{
+		db:                    db,
+		stockSerialRepository: stockSerialRepository,
+	}
+}
+
+func (s *StockSerialService) GetStockSerials(
+	ctx context.Context,
+	q *model.GetStockSerialsQuery,
+) ([]model.StockSerial, int, error) {
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return []model.StockSerial{}, 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	stockSerials, err := s.stockSerialRepository.GetStockSerials(ctx, tx, q)
+	if err != nil {
+		return []model.StockSerial{}, 0, err
+	}
+
+	count, err := s.stockSerialRepository.GetStockSerialsCount(ctx, tx, q)
+	if err != nil {
+		return []model.StockSerial{}, 0, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return []model.StockSerial{}, 0, err
+	}
+
+	return stockSerials, count, nil
+}
+
+func (s *StockSerialService) GetStockSerial(
+	ctx context.Context,
+	stockSerialID int,
+) (*model.StockSerial, error) {
+
+	stockSerial, err := s.stockSerialRepository.GetStockSerial(ctx, s.db, stockSerialID)
+	if err != nil {
+		return nil, err
+	}
+
+	return stockSerial, nil
+}
+
+// GetStockSerialMovements returns the movement history of a serial, most
+// recent first
+func (s *StockSerialService) GetStockSerialMovements(
+	ctx context.Context,
+	stockSerialID int,
+) ([]model.StockSerialMovement, error) {
+
+	movements, err := s.stockSerialRepository.GetStockSerialMovements(ctx, s.db, stockSerialID)
+	if err != nil {
+		return nil, err
+	}
+
+	return movements, nil
+}
@@ -3,31 +3,1426 @@ package service
 import (
 	"app/internal/model"
 	"app/internal/repository"
+	"app/pkg/db"
+	"app/pkg/validate"
+	"cmp"
 	"context"
+	"errors"
 	"fmt"
+	"log"
+	"slices"
+	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/shopspring/decimal"
 )
 
 type StockTransactionService struct {
-	db                         *pgxpool.Pool
-	stockTransactionRepository *repository.StockTransactionRepository
+	db                             *pgxpool.Pool
+	negativeStockPolicyRepository  *repository.NegativeStockPolicyRepository
+	purchaseOrderRepository        *repository.PurchaseOrderRepository
+	salesOrderRepository           *repository.SalesOrderRepository
+	stockBOMRepository             *repository.StockBOMRepository
+	stockCostRepository            *repository.StockCostRepository
+	stockGenealogyRepository       *repository.StockGenealogyRepository
+	stockItemRepository            *repository.StockItemRepository
+	stockLocationRepository        *repository.StockLocationRepository
+	stockLotRepository             *repository.StockLotRepository
+	stockPeriodRepository          *repository.StockPeriodRepository
+	stockReservationRepository     *repository.StockReservationRepository
+	stockScrapRepository           *repository.StockScrapRepository
+	stockSerialRepository          *repository.StockSerialRepository
+	stockTransactionRepository     *repository.StockTransactionRepository
+	stockTransactionTypeRepository *repository.StockTransactionTypeRepository
+	userRepository                 *repository.UserRepository
+	stockReorderService            *StockReorderService
 }
 
 func NewStockTransactionService(
 	db *pgxpool.Pool,
+	negativeStockPolicyRepository *repository.NegativeStockPolicyRepository,
+	purchaseOrderRepository *repository.PurchaseOrderRepository,
+	salesOrderRepository *repository.SalesOrderRepository,
+	stockBOMRepository *repository.StockBOMRepository,
+	stockCostRepository *repository.StockCostRepository,
+	stockGenealogyRepository *repository.StockGenealogyRepository,
+	stockItemRepository *repository.StockItemRepository,
+	stockLocationRepository *repository.StockLocationRepository,
+	stockLotRepository *repository.StockLotRepository,
+	stockPeriodRepository *repository.StockPeriodRepository,
+	stockReservationRepository *repository.StockReservationRepository,
+	stockScrapRepository *repository.StockScrapRepository,
+	stockSerialRepository *repository.StockSerialRepository,
 	stockTransactionRepository *repository.StockTransactionRepository,
+	stockTransactionTypeRepository *repository.StockTransactionTypeRepository,
+	userRepository *repository.UserRepository,
+	stockReorderService *StockReorderService,
 ) *StockTransactionService {
 	return &StockTransactionService{
-		db:                         db,
-		stockTransactionRepository: stockTransactionRepository,
+		db:                             db,
+		negativeStockPolicyRepository:  negativeStockPolicyRepository,
+		purchaseOrderRepository:        purchaseOrderRepository,
+		salesOrderRepository:           salesOrderRepository,
+		stockBOMRepository:             stockBOMRepository,
+		stockCostRepository:            stockCostRepository,
+		stockGenealogyRepository:       stockGenealogyRepository,
+		stockItemRepository:            stockItemRepository,
+		stockLocationRepository:        stockLocationRepository,
+		stockLotRepository:             stockLotRepository,
+		stockPeriodRepository:          stockPeriodRepository,
+		stockReservationRepository:     stockReservationRepository,
+		stockScrapRepository:           stockScrapRepository,
+		stockSerialRepository:          stockSerialRepository,
+		stockTransactionRepository:     stockTransactionRepository,
+		stockTransactionTypeRepository: stockTransactionTypeRepository,
+		userRepository:                 userRepository,
+		stockReorderService:            stockReorderService,
 	}
 }
 
+// ErrUnknownTransactionType is returned when a posting is of a transaction
+// type that is neither built in nor defined in the database, or is archived
+var ErrUnknownTransactionType = errors.New("unknown transaction type")
+
+// ErrUnknownStockItemUnit is returned when a quantity is entered in a unit
+// that is neither the base unit nor an alternate unit of the stock item
+var ErrUnknownStockItemUnit = errors.New("unit is not defined for the stock item")
+
+// ErrInvalidSerialNumbers is returned when the serial numbers of a posting do
+// not account for its quantity or are not where the posting moves them from
+var ErrInvalidSerialNumbers = errors.New("invalid serial numbers")
+
+// ErrInvalidStockPlace is returned when a posting names a location or bin
+// that is not in the master data, or puts stock into an archived one
+var ErrInvalidStockPlace = errors.New("invalid location or bin")
+
+// ErrStockBinCapacity is returned when a posting would fill a bin beyond its
+// capacity
+var ErrStockBinCapacity = errors.New("bin capacity exceeded")
+
+// ErrNoEffectiveStockBOM is returned when production is backflushed for a
+// stock item with no bill of materials in effect
+var ErrNoEffectiveStockBOM = errors.New("no bill of materials is in effect")
+
+// ErrInvalidUnitCost is returned when a posting is given a negative unit cost
+var ErrInvalidUnitCost = errors.New("unit cost cannot be negative")
+
+// ErrQuarantineApproval is returned when a quarantine posting has no reason
+// or is not approved by a QC approver
+var ErrQuarantineApproval = errors.New("quarantine posting not approved")
+
+// ErrScrapReasonRequired is returned when a posting into the SCRAP account
+// has no scrap reason, or one that is archived
+var ErrScrapReasonRequired = errors.New("scrap reason required")
+
+// ErrInsufficientQuarantinedStock is returned when a posting would release or
+// reject more stock than is on hold at a place
+var ErrInsufficientQuarantinedStock = errors.New("not enough stock on hold")
+
+// ErrStockPeriodClosed is returned when a posting is back-dated into a
+// period that has been closed
+var ErrStockPeriodClosed = errors.New("stock period is closed")
+
+// NegativeStockError is returned when a posting would leave the STOCK account
+// negative and the negative stock policy for it is to warn or reject
+type NegativeStockError struct {
+	Action  model.NegativeStockAction
+	Balance model.NegativeStockBalance
+}
+
+func (e *NegativeStockError) Error() string {
+	place := e.Balance.Location
+	if e.Balance.Bin != "" {
+		place += "/" + e.Balance.Bin
+	}
+	if e.Balance.LotNumber != "" {
+		place += " lot " + e.Balance.LotNumber
+	}
+
+	return fmt.Sprintf(
+		"posting would leave %s at %s with a negative stock level of %s at %s",
+		e.Balance.StockCode,
+		place,
+		e.Balance.RunningTotal.String(),
+		e.Balance.Timestamp.Format("2006-01-02 15:04"),
+	)
+}
+
+// IsWarning reports whether the posting may go ahead once acknowledged
+func (e *NegativeStockError) IsWarning() bool {
+	return e.Action == model.WarnNegativeStockAction
+}
+
+// ReservedStockError is returned when a posting would take stock that is
+// reserved for a demand other than the one it was posted for
+type ReservedStockError struct {
+	Shortfall model.StockReservationShortfall
+}
+
+func (e *ReservedStockError) Error() string {
+	place := "all locations"
+	if e.Shortfall.Location != "" {
+		place = e.Shortfall.Location
+	}
+	if e.Shortfall.Bin != "" {
+		place += "/" + e.Shortfall.Bin
+	}
+	if e.Shortfall.LotNumber != "" {
+		place += " lot " + e.Shortfall.LotNumber
+	}
+
+	return fmt.Sprintf(
+		"posting would leave %s of %s on hand at %s with %s reserved",
+		e.Shortfall.OnHand.String(),
+		e.Shortfall.StockCode,
+		place,
+		e.Shortfall.Reserved.String(),
+	)
+}
+
+// stockBalanceKey is a place in the STOCK account of a stock item
+type stockBalanceKey struct {
+	location  string
+	bin       string
+	lotNumber string
+}
+
+// reducedStockBalances returns the places in the STOCK account that a
+// posting takes stock from
+func reducedStockBalances(t model.NewStockTransaction) []stockBalanceKey {
+	accounts := t.Accounts
+
+	var reduced []stockBalanceKey
+	if accounts.From == model.StockStockAccount && t.Qty.IsPositive() {
+		reduced = append(reduced, stockBalanceKey{t.FromLocation, t.FromBin, t.FromLotNumber})
+	}
+	if accounts.To == model.StockStockAccount && t.Qty.IsNegative() {
+		reduced = append(reduced, stockBalanceKey{t.ToLocation, t.ToBin, t.ToLotNumber})
+	}
+
+	return reduced
+}
+
+// stockQtyIn returns how much a posting adds to the STOCK account of the stock
+// item as a whole, negative when it takes stock out. Movements within the
+// account add nothing.
+func stockQtyIn(t model.NewStockTransaction) decimal.Decimal {
+	accounts := t.Accounts
+
+	switch {
+	case accounts.To == model.StockStockAccount && accounts.From != model.StockStockAccount:
+		return t.Qty
+	case accounts.From == model.StockStockAccount && accounts.To != model.StockStockAccount:
+		return t.Qty.Neg()
+	}
+
+	return decimal.Zero
+}
+
+// isCostedStockAccount is whether stock in the account is owned and valued.
+// Stock on QC hold keeps its cost, so holds and releases are costed as
+// movements within STOCK.
+func isCostedStockAccount(account model.StockAccount) bool {
+	return account == model.StockStockAccount || account == model.QuarantineStockAccount
+}
+
+// costedQtyIn returns how much a posting adds to the costed accounts of the
+// stock item as a whole, negative when it takes stock out
+func costedQtyIn(t model.NewStockTransaction) decimal.Decimal {
+	accounts := t.Accounts
+
+	switch {
+	case isCostedStockAccount(accounts.To) && !isCostedStockAccount(accounts.From):
+		return t.Qty
+	case isCostedStockAccount(accounts.From) && !isCostedStockAccount(accounts.To):
+		return t.Qty.Neg()
+	}
+
+	return decimal.Zero
+}
+
+// PostStockTransactions posts transactions to the ledger as part of the
+// caller's database transaction. All postings should go through this method
+// so that the same ledger rules apply regardless of where they come from.
+// Each rule is checked or applied by its own helper, e.g. checkStockPeriods,
+// checkStockPlaces, checkSerialNumbers, checkQuarantine, applyStockCosts,
+// applyStockReservations and checkNegativeStock.
+func (s *StockTransactionService) PostStockTransactions(
+	ctx context.Context,
+	tx pgx.Tx,
+	input *model.PostStockTransactionsInput,
+	userID int,
+) error {
+	err := s.checkStockPeriods(ctx, tx, input)
+	if err != nil {
+		return err
+	}
+
+	err = s.setStockTransactionAccounts(ctx, tx, input)
+	if err != nil {
+		return err
+	}
+
+	for i := range *input {
+		t := &(*input)[i]
+		if t.Unit == "" {
+			continue
+		}
+
+		qty, err := s.ConvertToBaseUnit(ctx, tx, t.StockItemID, t.Unit, t.Qty)
+		if err != nil {
+			return err
+		}
+		t.Qty = qty
+		t.Unit = ""
+	}
+
+	err = s.lockReducedStockBalances(ctx, tx, input)
+	if err != nil {
+		return err
+	}
+
+	err = s.checkStockPlaces(ctx, tx, input)
+	if err != nil {
+		return err
+	}
+
+	err = s.checkSerialNumbers(ctx, tx, input)
+	if err != nil {
+		return err
+	}
+
+	err = s.checkQuarantine(ctx, tx, input, userID)
+	if err != nil {
+		return err
+	}
+
+	err = s.checkScrapReasons(ctx, tx, input)
+	if err != nil {
+		return err
+	}
+
+	for _, t := range *input {
+		err = s.stockSerialRepository.CreateStockSerials(ctx, tx, t.StockItemID, t.SerialNumbers)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = s.applyStockCosts(ctx, tx, input)
+	if err != nil {
+		return err
+	}
+
+	err = s.stockTransactionRepository.PostStockTransactions(ctx, tx, input, userID)
+	if err != nil {
+		return err
+	}
+
+	for _, t := range *input {
+		err = s.stockLotRepository.CreateStockLots(
+			ctx, tx, t.StockItemID, []string{t.FromLotNumber, t.ToLotNumber},
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, t := range *input {
+		if t.PurchaseOrderLineID == nil {
+			continue
+		}
+		err = s.purchaseOrderRepository.AddPurchaseOrderLineReceivedQty(
+			ctx, tx, *t.PurchaseOrderLineID, stockQtyIn(t),
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, t := range *input {
+		if t.SalesOrderLineID == nil {
+			continue
+		}
+		err = s.salesOrderRepository.AddSalesOrderLineDispatchedQty(
+			ctx, tx, *t.SalesOrderLineID, stockQtyIn(t).Neg(),
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = s.applyStockReservations(ctx, tx, input, userID)
+	if err != nil {
+		return err
+	}
+
+	err = s.checkStockBinCapacity(ctx, tx, input)
+	if err != nil {
+		return err
+	}
+
+	err = s.checkNegativeStock(ctx, tx, input)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// setStockTransactionAccounts sets the accounts of each posting from its
+// transaction type, built in or defined in the database. Archived custom
+// types can still be reversed.
+func (s *StockTransactionService) setStockTransactionAccounts(
+	ctx context.Context,
+	tx pgx.Tx,
+	input *model.PostStockTransactionsInput,
+) error {
+
+	for i := range *input {
+		t := &(*input)[i]
+
+		if accounts, ok := model.StockTransacationTypeMap[t.TransactionType]; ok {
+			t.Accounts = accounts
+			continue
+		}
+
+		customType, err := s.stockTransactionTypeRepository.GetCustomStockTransactionTypeByName(
+			ctx, tx, string(t.TransactionType),
+		)
+		if err != nil {
+			return err
+		}
+		if customType == nil || (customType.IsArchived && t.ReversesStockTransactionID == nil) {
+			return fmt.Errorf("%w: %s", ErrUnknownTransactionType, t.TransactionType)
+		}
+
+		t.Accounts = model.StockTransactionAccounts{
+			From: customType.FromAccount,
+			To:   customType.ToAccount,
+		}
+	}
+
+	return nil
+}
+
+// NotifyStockReorder checks the stock items against their reorder policies.
+// It is called once postings are committed, so errors are only logged.
+func (s *StockTransactionService) NotifyStockReorder(
+	ctx context.Context,
+	stockItemIDs ...int,
+) {
+	if err := s.stockReorderService.CheckStockReorder(ctx, stockItemIDs); err != nil {
+		log.Println("error checking stock reorder:", err)
+	}
+}
+
+// ConvertToBaseUnit converts a quantity entered in the given unit to the base
+// unit of the stock item. An empty unit is taken to be the base unit.
+func (s *StockTransactionService) ConvertToBaseUnit(
+	ctx context.Context,
+	exec db.PGExecutor,
+	stockItemID int,
+	unit string,
+	qty decimal.Decimal,
+) (decimal.Decimal, error) {
+
+	if unit == "" {
+		return qty, nil
+	}
+
+	stockItem, err := s.stockItemRepository.GetStockItem(ctx, exec, stockItemID)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	if stockItem == nil {
+		return decimal.Zero, fmt.Errorf("stock item does not exist")
+	}
+	if unit == stockItem.BaseUnit {
+		return qty, nil
+	}
+
+	stockItemUnit, err := s.stockItemRepository.GetStockItemUnit(ctx, exec, stockItemID, unit)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	if stockItemUnit == nil {
+		return decimal.Zero, fmt.Errorf("%w: %s is not a unit of %s", ErrUnknownStockItemUnit, unit, stockItem.StockCode)
+	}
+
+	return qty.Mul(stockItemUnit.ConversionFactor), nil
+}
+
+// checkStockPlaces checks that the locations and bins of each posting are in
+// the master data. Archived locations and bins can still have stock taken out
+// of the STOCK account so that they can be emptied, but nothing else can be
+// posted to them.
+func (s *StockTransactionService) checkStockPlaces(
+	ctx context.Context,
+	tx pgx.Tx,
+	input *model.PostStockTransactionsInput,
+) error {
+
+	for _, t := range *input {
+		reduced := reducedStockBalances(t)
+
+		places := [][2]string{{t.FromLocation, t.FromBin}, {t.ToLocation, t.ToBin}}
+		for i, p := range places {
+			location, bin := p[0], p[1]
+			if i == 1 && places[0] == places[1] {
+				break
+			}
+
+			status, err := s.stockLocationRepository.GetStockPlaceStatus(ctx, tx, location, bin)
+			if err != nil {
+				return err
+			}
+
+			if !status.LocationExists {
+				return fmt.Errorf("%w: location %s does not exist", ErrInvalidStockPlace, location)
+			}
+			if bin != "" && !status.BinExists {
+				return fmt.Errorf("%w: location %s has no bin %s", ErrInvalidStockPlace, location, bin)
+			}
+
+			takesStockOut := slices.ContainsFunc(reduced, func(k stockBalanceKey) bool {
+				return k.location == location && k.bin == bin
+			})
+			if takesStockOut {
+				continue
+			}
+			if status.LocationArchived {
+				return fmt.Errorf("%w: location %s is archived", ErrInvalidStockPlace, location)
+			}
+			if status.BinArchived {
+				return fmt.Errorf("%w: bin %s/%s is archived", ErrInvalidStockPlace, location, bin)
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkQuarantine checks that postings into and out of the QUARANTINE account
+// have a reason, whatever their transaction type, and are posted by a user
+// with QC approver permission, who is recorded as approving them. Reversals
+// carry the reason of the posting they reverse and are approved by the user
+// reversing it. No posting may take more out of the QUARANTINE account at a
+// place than is on hold there.
+func (s *StockTransactionService) checkQuarantine(
+	ctx context.Context,
+	tx pgx.Tx,
+	input *model.PostStockTransactionsInput,
+	userID int,
+) error {
+
+	type heldChange struct {
+		key stockBalanceKey
+		qty decimal.Decimal
+	}
+
+	var isApprover *bool
+	held := map[int]map[stockBalanceKey]decimal.Decimal{}
+
+	for i := range *input {
+		t := &(*input)[i]
+		accounts := t.Accounts
+
+		if accounts.From == model.QuarantineStockAccount || accounts.To == model.QuarantineStockAccount {
+			if t.Reason == "" {
+				return fmt.Errorf("%w: a reason is required", ErrQuarantineApproval)
+			}
+
+			if isApprover == nil {
+				user, err := s.userRepository.GetUserByID(ctx, tx, userID)
+				if err != nil {
+					return err
+				}
+				approver := user != nil && user.Permissions.SupplyChain.QCApprover
+				isApprover = &approver
+			}
+			if !*isApprover {
+				return fmt.Errorf("%w: %s must be posted by a QC approver", ErrQuarantineApproval, t.TransactionType)
+			}
+			t.ApprovedBy = &userID
+		}
+
+		var changes []heldChange
+		if accounts.From == model.QuarantineStockAccount {
+			changes = append(changes, heldChange{
+				key: stockBalanceKey{t.FromLocation, t.FromBin, t.FromLotNumber},
+				qty: t.Qty.Neg(),
+			})
+		}
+		if accounts.To == model.QuarantineStockAccount {
+			changes = append(changes, heldChange{
+				key: stockBalanceKey{t.ToLocation, t.ToBin, t.ToLotNumber},
+				qty: t.Qty,
+			})
+		}
+
+		for _, c := range changes {
+			if held[t.StockItemID] == nil {
+				held[t.StockItemID] = map[stockBalanceKey]decimal.Decimal{}
+			}
+
+			balance, ok := held[t.StockItemID][c.key]
+			if !ok {
+				var err error
+				balance, err = s.stockTransactionRepository.LockStockBalance(
+					ctx, tx, model.QuarantineStockAccount,
+					t.StockItemID, c.key.location, c.key.bin, c.key.lotNumber,
+				)
+				if err != nil {
+					return err
+				}
+			}
+
+			balance = balance.Add(c.qty)
+			if balance.IsNegative() {
+				return fmt.Errorf(
+					"%w: posting would take %s more than is on hold at %s",
+					ErrInsufficientQuarantinedStock, balance.Neg().String(), stockPlace(c.key),
+				)
+			}
+			held[t.StockItemID][c.key] = balance
+		}
+	}
+
+	return nil
+}
+
+// checkStockPeriods checks that no posting is back-dated before the end of
+// the latest closed period. A back-dated posting changes the running totals
+// of every later entry, so it cannot go into an open month that comes before
+// a closed one either. Postings without a timestamp are made now, and the
+// current period cannot be closed.
+func (s *StockTransactionService) checkStockPeriods(
+	ctx context.Context,
+	tx pgx.Tx,
+	input *model.PostStockTransactionsInput,
+) error {
+
+	var earliest *time.Time
+	for _, t := range *input {
+		if t.Timestamp != nil && (earliest == nil || t.Timestamp.Before(*earliest)) {
+			earliest = t.Timestamp
+		}
+	}
+
+	if earliest == nil {
+		return nil
+	}
+
+	err := s.stockPeriodRepository.LockStockPeriods(ctx, tx, true)
+	if err != nil {
+		return err
+	}
+
+	latestClosed, err := s.stockPeriodRepository.GetLatestClosedStockPeriod(ctx, tx)
+	if err != nil {
+		return err
+	}
+	if latestClosed != nil && earliest.Before(latestClosed.AddDate(0, 1, 0)) {
+		return fmt.Errorf(
+			"%w: postings cannot be dated before the end of %s, the latest closed period",
+			ErrStockPeriodClosed, model.FormatStockPeriod(*latestClosed),
+		)
+	}
+
+	return nil
+}
+
+// checkScrapReasons checks that postings into and out of the SCRAP account
+// have a scrap reason. Reversals carry the reason of the posting they
+// reverse, which may since have been archived.
+func (s *StockTransactionService) checkScrapReasons(
+	ctx context.Context,
+	tx pgx.Tx,
+	input *model.PostStockTransactionsInput,
+) error {
+
+	active := map[int]bool{}
+	for _, t := range *input {
+		accounts := t.Accounts
+		if accounts.From != model.ScrapStockAccount && accounts.To != model.ScrapStockAccount {
+			continue
+		}
+
+		if t.ScrapReasonID == nil {
+			return fmt.Errorf("%w: %s needs a scrap reason", ErrScrapReasonRequired, t.TransactionType)
+		}
+		if t.ReversesStockTransactionID != nil {
+			continue
+		}
+
+		isActive, ok := active[*t.ScrapReasonID]
+		if !ok {
+			reason, err := s.stockScrapRepository.GetScrapReason(ctx, tx, *t.ScrapReasonID)
+			if err != nil {
+				return err
+			}
+			isActive = reason != nil && !reason.IsArchived
+			active[*t.ScrapReasonID] = isActive
+		}
+		if !isActive {
+			return fmt.Errorf("%w: the scrap reason is archived or does not exist", ErrScrapReasonRequired)
+		}
+	}
+
+	return nil
+}
+
+// stockPlace describes a place for messages
+func stockPlace(k stockBalanceKey) string {
+	place := k.location
+	if k.bin != "" {
+		place += "/" + k.bin
+	}
+	if k.lotNumber != "" {
+		place += " lot " + k.lotNumber
+	}
+
+	return place
+}
+
+// checkStockBinCapacity checks that the bins each posting puts stock into the
+// STOCK account of still hold no more than their capacity
+func (s *StockTransactionService) checkStockBinCapacity(
+	ctx context.Context,
+	tx pgx.Tx,
+	input *model.PostStockTransactionsInput,
+) error {
+
+	checked := map[[2]string]bool{}
+	for _, t := range *input {
+		accounts := t.Accounts
+
+		var location, bin string
+		if accounts.To == model.StockStockAccount && t.Qty.IsPositive() {
+			location, bin = t.ToLocation, t.ToBin
+		} else if accounts.From == model.StockStockAccount && t.Qty.IsNegative() {
+			location, bin = t.FromLocation, t.FromBin
+		}
+		if bin == "" || checked[[2]string{location, bin}] {
+			continue
+		}
+		checked[[2]string{location, bin}] = true
+
+		stockBin, err := s.stockLocationRepository.GetStockBin(ctx, tx, location, bin)
+		if err != nil {
+			return err
+		}
+		if stockBin != nil && stockBin.IsOverCapacity() {
+			return fmt.Errorf(
+				"%w: bin %s/%s would hold %s with a capacity of %s",
+				ErrStockBinCapacity, location, bin,
+				stockBin.StockLevel.String(), stockBin.Capacity.String(),
+			)
+		}
+	}
+
+	return nil
+}
+
+// applyStockCosts sets the unit cost of each posting and keeps the weighted
+// average cost and FIFO layers of the stock items in step, whichever costing
+// method they use so that the method can be changed at any time. Stock posted
+// into the costed accounts, see isCostedStockAccount, is costed at the unit
+// cost given, or the current average cost if there is none, and adds a FIFO
+// layer. Stock taken out is costed by the costing method of the stock item.
+// Movements within and between the costed accounts, such as QC holds and
+// releases, are costed at the current cost so that the value moves with the
+// stock and the FIFO layers are left as they are.
+func (s *StockTransactionService) applyStockCosts(
+	ctx context.Context,
+	tx pgx.Tx,
+	input *model.PostStockTransactionsInput,
+) error {
+
+	costingMethods := map[int]model.StockCostingMethod{}
+	for i := range *input {
+		t := &(*input)[i]
+		if t.UnitCost != nil && t.UnitCost.IsNegative() {
+			return ErrInvalidUnitCost
+		}
+		if t.Qty.IsZero() {
+			continue
+		}
+
+		costingMethod, ok := costingMethods[t.StockItemID]
+		if !ok {
+			stockItem, err := s.stockItemRepository.GetStockItem(ctx, tx, t.StockItemID)
+			if err != nil {
+				return err
+			}
+			if stockItem == nil {
+				return fmt.Errorf("stock item %d does not exist", t.StockItemID)
+			}
+			costingMethod = stockItem.CostingMethod
+			costingMethods[t.StockItemID] = costingMethod
+		}
+
+		cost, err := s.stockCostRepository.LockStockItemCost(ctx, tx, t.StockItemID)
+		if err != nil {
+			return err
+		}
+
+		layers, err := s.stockCostRepository.GetOpenStockCostLayers(ctx, tx, t.StockItemID)
+		if err != nil {
+			return err
+		}
+
+		unitCost := cost.AverageCost
+		qtyIn := costedQtyIn(*t)
+
+		switch {
+		case qtyIn.IsPositive():
+			if t.UnitCost != nil {
+				unitCost = *t.UnitCost
+			}
+
+			// stock held below zero has no cost to average with
+			held := decimal.Max(cost.Qty, decimal.Zero)
+			cost.AverageCost = held.Mul(cost.AverageCost).
+				Add(qtyIn.Mul(unitCost)).
+				Div(held.Add(qtyIn))
+
+			receivedAt := time.Now()
+			if t.Timestamp != nil {
+				receivedAt = *t.Timestamp
+			}
+			err = s.stockCostRepository.CreateStockCostLayer(
+				ctx, tx, t.StockItemID, receivedAt, qtyIn, unitCost,
+			)
+			if err != nil {
+				return err
+			}
+
+		case qtyIn.IsNegative():
+			fifoCost, err := s.useStockCostLayers(ctx, tx, layers, qtyIn.Neg(), cost.AverageCost)
+			if err != nil {
+				return err
+			}
+			if costingMethod == model.FIFOStockCostingMethod {
+				unitCost = fifoCost
+			}
+
+		default:
+			if costingMethod == model.FIFOStockCostingMethod {
+				unitCost = stockCostLayersAverage(layers, cost.AverageCost)
+			}
+		}
+
+		cost.Qty = cost.Qty.Add(qtyIn)
+		err = s.stockCostRepository.UpdateStockItemCost(ctx, tx, &cost)
+		if err != nil {
+			return err
+		}
+
+		t.UnitCost = &unitCost
+	}
+
+	return nil
+}
+
+// useStockCostLayers takes qty out of the FIFO layers, oldest first, and
+// returns the cost of each unit taken. Anything beyond what the layers hold
+// is costed at the last layer used, or the fallback cost if there are none.
+func (s *StockTransactionService) useStockCostLayers(
+	ctx context.Context,
+	tx pgx.Tx,
+	layers []model.StockCostLayer,
+	qty decimal.Decimal,
+	fallbackCost decimal.Decimal,
+) (decimal.Decimal, error) {
+
+	value := decimal.Zero
+	remaining := qty
+	lastCost := fallbackCost
+	for _, l := range layers {
+		if !remaining.IsPositive() {
+			break
+		}
+
+		used := decimal.Min(remaining, l.RemainingQty)
+		err := s.stockCostRepository.UpdateStockCostLayerRemaining(
+			ctx, tx, l.StockCostLayerID, l.RemainingQty.Sub(used),
+		)
+		if err != nil {
+			return decimal.Zero, err
+		}
+
+		value = value.Add(used.Mul(l.UnitCost))
+		remaining = remaining.Sub(used)
+		lastCost = l.UnitCost
+	}
+
+	value = value.Add(remaining.Mul(lastCost))
+
+	return value.Div(qty), nil
+}
+
+// stockCostLayersAverage is the average cost of what the FIFO layers hold,
+// or the fallback cost if they hold nothing
+func stockCostLayersAverage(layers []model.StockCostLayer, fallbackCost decimal.Decimal) decimal.Decimal {
+	qty := decimal.Zero
+	value := decimal.Zero
+	for _, l := range layers {
+		qty = qty.Add(l.RemainingQty)
+		value = value.Add(l.RemainingQty.Mul(l.UnitCost))
+	}
+
+	if qty.IsZero() {
+		return fallbackCost
+	}
+
+	return value.Div(qty)
+}
+
+// checkSerialNumbers checks that each posting of a serialised stock item lists
+// one serial per unit moved and that every serial is where the posting moves
+// it from. Serials that have not been posted before can only come from outside
+// the STOCK account. Postings are checked in order, so one posting can move a
+// serial that an earlier posting in the same input put there.
+func (s *StockTransactionService) checkSerialNumbers(
+	ctx context.Context,
+	tx pgx.Tx,
+	input *model.PostStockTransactionsInput,
+) error {
+
+	type serialKey struct {
+		stockItemID  int
+		serialNumber string
+	}
+
+	stockItems := map[int]*model.StockItem{}
+	// positions holds nil for serials that have not been posted
+	positions := map[serialKey]*model.StockSerialPosition{}
+
+	for _, t := range *input {
+		stockItem, ok := stockItems[t.StockItemID]
+		if !ok {
+			var err error
+			stockItem, err = s.stockItemRepository.GetStockItem(ctx, tx, t.StockItemID)
+			if err != nil {
+				return err
+			}
+			if stockItem == nil {
+				return fmt.Errorf("stock item does not exist")
+			}
+			stockItems[t.StockItemID] = stockItem
+		}
+
+		if !stockItem.IsSerialised {
+			if len(t.SerialNumbers) > 0 {
+				return fmt.Errorf(
+					"%w: %s is not serialised", ErrInvalidSerialNumbers, stockItem.StockCode,
+				)
+			}
+			continue
+		}
+
+		if !t.Qty.Abs().Equal(decimal.NewFromInt(int64(len(t.SerialNumbers)))) {
+			return fmt.Errorf(
+				"%w: %s is serialised, %d serial numbers were given for a quantity of %s %s",
+				ErrInvalidSerialNumbers,
+				stockItem.StockCode,
+				len(t.SerialNumbers),
+				t.Qty.Abs().String(),
+				stockItem.BaseUnit,
+			)
+		}
+
+		seen := map[string]bool{}
+		var unknown []string
+		for _, serialNumber := range t.SerialNumbers {
+			if serialNumber == "" {
+				return fmt.Errorf("%w: serial numbers cannot be empty", ErrInvalidSerialNumbers)
+			}
+			if seen[serialNumber] {
+				return fmt.Errorf(
+					"%w: serial %s of %s is listed more than once",
+					ErrInvalidSerialNumbers, serialNumber, stockItem.StockCode,
+				)
+			}
+			seen[serialNumber] = true
+
+			if _, ok := positions[serialKey{t.StockItemID, serialNumber}]; !ok {
+				unknown = append(unknown, serialNumber)
+			}
+		}
+
+		if len(unknown) > 0 {
+			posted, err := s.stockSerialRepository.GetStockSerialPositions(ctx, tx, t.StockItemID, unknown)
+			if err != nil {
+				return err
+			}
+			for _, serialNumber := range unknown {
+				var position *model.StockSerialPosition
+				if p, ok := posted[serialNumber]; ok {
+					position = &p
+				}
+				positions[serialKey{t.StockItemID, serialNumber}] = position
+			}
+		}
+
+		// the posting moves serials from its negative entry to its positive
+		// entry, which is the To side when the quantity is negative
+		accounts := t.Accounts
+		source := model.StockSerialPosition{
+			Account:   accounts.From,
+			Location:  t.FromLocation,
+			Bin:       t.FromBin,
+			LotNumber: t.FromLotNumber,
+		}
+		destination := model.StockSerialPosition{
+			Account:   accounts.To,
+			Location:  t.ToLocation,
+			Bin:       t.ToBin,
+			LotNumber: t.ToLotNumber,
+		}
+		if t.Qty.IsNegative() {
+			source, destination = destination, source
+		}
+		if t.Timestamp != nil {
+			destination.Timestamp = *t.Timestamp
+		}
+
+		for _, serialNumber := range t.SerialNumbers {
+			key := serialKey{t.StockItemID, serialNumber}
+			current := positions[key]
+
+			if current == nil && source.Account == model.StockStockAccount {
+				return fmt.Errorf(
+					"%w: serial %s of %s is not in stock",
+					ErrInvalidSerialNumbers, serialNumber, stockItem.StockCode,
+				)
+			}
+			if current != nil && !current.IsAt(source) {
+				return fmt.Errorf(
+					"%w: serial %s of %s is in %s, not %s",
+					ErrInvalidSerialNumbers, serialNumber, stockItem.StockCode, current, source,
+				)
+			}
+			// the current position is taken from the latest posting, so a
+			// serial cannot be posted back before it last moved
+			if current != nil && t.Timestamp != nil && t.Timestamp.Before(current.Timestamp) {
+				return fmt.Errorf(
+					"%w: serial %s of %s cannot be posted before it last moved on %s",
+					ErrInvalidSerialNumbers, serialNumber, stockItem.StockCode,
+					current.Timestamp.Format("2006-01-02 15:04"),
+				)
+			}
+
+			position := destination
+			positions[key] = &position
+		}
+	}
+
+	return nil
+}
+
+// applyStockReservations draws down the reservations of the demand that each
+// posting consumes stock for, then checks that every reservation covering the
+// places the postings took stock from is still on hand. Movements within the
+// STOCK account do not fulfil a demand, so they can only take stock that is
+// not reserved.
+func (s *StockTransactionService) applyStockReservations(
+	ctx context.Context,
+	tx pgx.Tx,
+	input *model.PostStockTransactionsInput,
+	userID int,
+) error {
+
+	for _, t := range *input {
+		accounts := t.Accounts
+		if t.DemandReference == "" || accounts.From == accounts.To {
+			continue
+		}
+
+		for _, k := range reducedStockBalances(t) {
+			reservations, err := s.stockReservationRepository.GetOpenStockReservationsForDemand(
+				ctx, tx, t.StockItemID, k.location, k.bin, k.lotNumber, t.DemandReference,
+			)
+			if err != nil {
+				return err
+			}
+
+			remaining := t.Qty.Abs()
+			for _, r := range reservations {
+				if !remaining.IsPositive() {
+					break
+				}
+
+				drawn := decimal.Min(remaining, r.Qty)
+				err = s.stockReservationRepository.UpdateStockReservationQuantity(
+					ctx, tx, r.StockReservationID, r.Qty.Sub(drawn), userID,
+				)
+				if err != nil {
+					return err
+				}
+				remaining = remaining.Sub(drawn)
+			}
+		}
+	}
+
+	for _, t := range *input {
+		for _, k := range reducedStockBalances(t) {
+			shortfall, err := s.stockReservationRepository.GetStockReservationShortfall(
+				ctx, tx, t.StockItemID, k.location, k.bin, k.lotNumber,
+			)
+			if err != nil {
+				return err
+			}
+			if shortfall != nil {
+				return &ReservedStockError{Shortfall: *shortfall}
+			}
+		}
+	}
+
+	return nil
+}
+
+// lockReducedStockBalances locks the STOCK balances that the postings take
+// stock from until the end of the transaction, so that concurrent postings
+// from the same place are checked against each other's results by
+// checkNegativeStock. Balances are locked in order so that postings cannot
+// deadlock.
+func (s *StockTransactionService) lockReducedStockBalances(
+	ctx context.Context,
+	tx pgx.Tx,
+	input *model.PostStockTransactionsInput,
+) error {
+
+	type lockKey struct {
+		stockItemID int
+		place       stockBalanceKey
+	}
+
+	var keys []lockKey
+	for _, t := range *input {
+		for _, k := range reducedStockBalances(t) {
+			key := lockKey{t.StockItemID, k}
+			if !slices.Contains(keys, key) {
+				keys = append(keys, key)
+			}
+		}
+	}
+
+	slices.SortFunc(keys, func(a, b lockKey) int {
+		return cmp.Or(
+			cmp.Compare(a.stockItemID, b.stockItemID),
+			cmp.Compare(a.place.location, b.place.location),
+			cmp.Compare(a.place.bin, b.place.bin),
+			cmp.Compare(a.place.lotNumber, b.place.lotNumber),
+		)
+	})
+
+	for _, k := range keys {
+		_, err := s.stockTransactionRepository.LockStockBalance(
+			ctx, tx, model.StockStockAccount, k.stockItemID, k.place.location, k.place.bin, k.place.lotNumber,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkNegativeStock applies the negative stock policy to every STOCK balance
+// that the postings reduced. It runs after the postings so that running
+// totals already reflect them, including later totals rewritten by
+// back-dated postings. The balances were locked before posting, see
+// lockReducedStockBalances, so concurrent postings cannot both pass.
+func (s *StockTransactionService) checkNegativeStock(
+	ctx context.Context,
+	tx pgx.Tx,
+	input *model.PostStockTransactionsInput,
+) error {
+
+	for _, t := range *input {
+		for _, k := range reducedStockBalances(t) {
+			action, err := s.negativeStockPolicyRepository.GetEffectiveNegativeStockAction(
+				ctx, tx, t.StockItemID, k.location,
+			)
+			if err != nil {
+				return err
+			}
+
+			if action == model.AllowNegativeStockAction ||
+				(action == model.WarnNegativeStockAction && t.AcknowledgeNegativeStock) {
+				continue
+			}
+
+			balance, err := s.stockTransactionRepository.GetLowestStockBalance(
+				ctx, tx, t.StockItemID, k.location, k.bin, k.lotNumber, t.Timestamp,
+			)
+			if err != nil {
+				return err
+			}
+
+			if balance != nil {
+				return &NegativeStockError{
+					Action:  action,
+					Balance: *balance,
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
 func (s *StockTransactionService) PostManualStockMovement(
 	ctx context.Context,
-	input *model.PostManualStockMovementInput,
+	input *model.PostManualStockMovementInput,
+	userID int,
+) error {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	err = s.PostStockTransactions(ctx, tx, &model.PostStockTransactionsInput{{
+		TransactionType: "Stock Movement",
+		StockItemID:     input.StockItemID,
+		Qty:             input.Qty,
+		FromLocation:    input.FromLocation,
+		FromBin:         input.FromBin,
+		FromLotNumber:   input.LotNumber,
+		ToLocation:      input.ToLocation,
+		ToBin:           input.ToBin,
+		ToLotNumber:     input.LotNumber,
+		Unit:            input.Unit,
+		TransactionNote: input.TransactionNote,
+		SerialNumbers:   input.SerialNumbers,
+		Timestamp:       nil,
+
+		AcknowledgeNegativeStock: input.AcknowledgeNegativeStock,
+	}}, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	s.NotifyStockReorder(ctx, input.StockItemID)
+
+	return nil
+}
+
+// PostManualProduction posts production into STOCK, backflushing the
+// components of its bill of materials in the same transaction when a
+// backflush location is given
+func (s *StockTransactionService) PostManualProduction(
+	ctx context.Context,
+	input *model.PostManualGenericStockTransactionInput,
+	userID int,
+) error {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	transactions := model.PostStockTransactionsInput{{
+		TransactionType: "Production",
+		StockItemID:     input.StockItemID,
+		Qty:             input.Qty,
+		FromLocation:    input.Location,
+		FromBin:         input.Bin,
+		FromLotNumber:   input.LotNumber,
+		ToLocation:      input.Location,
+		ToBin:           input.Bin,
+		ToLotNumber:     input.LotNumber,
+		Unit:            input.Unit,
+		TransactionNote: input.TransactionNote,
+		SerialNumbers:   input.SerialNumbers,
+		DemandReference: input.DemandReference,
+		UnitCost:        input.UnitCost,
+		Timestamp:       nil,
+
+		AcknowledgeNegativeStock: input.AcknowledgeNegativeStock,
+	}}
+
+	if input.BackflushLocation != "" {
+		consumption, err := s.backflushTransactions(ctx, tx, input, false)
+		if err != nil {
+			return err
+		}
+		transactions = append(transactions, consumption...)
+	}
+
+	err = s.PostStockTransactions(ctx, tx, &transactions, userID)
+	if err != nil {
+		return err
+	}
+
+	// the backflushed components are traced into the lot produced, and are
+	// reversed along with the production
+	if len(transactions) > 1 && transactions[0].StockTransactionID != 0 {
+		consumptionIDs := []int{}
+		for _, t := range transactions[1:] {
+			if t.StockTransactionID != 0 {
+				consumptionIDs = append(consumptionIDs, t.StockTransactionID)
+			}
+		}
+		err = s.stockGenealogyRepository.LinkStockTransactions(
+			ctx, tx, transactions[0].StockTransactionID, consumptionIDs,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	s.NotifyStockReorder(ctx, transactions.StockItemIDs()...)
+
+	return nil
+}
+
+// backflushTransactions returns the consumption of the components of the
+// bill of materials in effect for a manual production, taken from the lots at
+// the backflush location and bin soonest to expire first. When reversing, the
+// components are returned to the lots they were backflushed from by
+// productions of the lot, most recent first.
+func (s *StockTransactionService) backflushTransactions(
+	ctx context.Context,
+	tx pgx.Tx,
+	input *model.PostManualGenericStockTransactionInput,
+	reverse bool,
+) (model.PostStockTransactionsInput, error) {
+
+	bom, err := s.stockBOMRepository.GetEffectiveStockBOM(ctx, tx, input.StockItemID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if bom == nil {
+		return nil, ErrNoEffectiveStockBOM
+	}
+
+	qty, err := s.ConvertToBaseUnit(ctx, tx, input.StockItemID, input.Unit, input.Qty)
+	if err != nil {
+		return nil, err
+	}
+
+	lines, err := s.stockBOMRepository.GetStockBOMLines(ctx, tx, bom.StockBOMID)
+	if err != nil {
+		return nil, err
+	}
+
+	transactionType := model.ConsumptionTransactionType
+	transactionNote := fmt.Sprintf("Backflush for production of %s (BOM %s)", bom.StockCode, bom.Version)
+	if reverse {
+		transactionType = model.ConsumptionReversalTransactionType
+		transactionNote = fmt.Sprintf("Reversal of backflush for production of %s (BOM %s)", bom.StockCode, bom.Version)
+	}
+	if input.TransactionNote != "" {
+		transactionNote = fmt.Sprintf("%s: %s", transactionNote, input.TransactionNote)
+	}
+
+	consumption := model.PostStockTransactionsInput{}
+	for _, l := range lines {
+		component, err := s.stockItemRepository.GetStockItem(ctx, tx, l.ComponentStockItemID)
+		if err != nil {
+			return nil, err
+		}
+		if component == nil {
+			return nil, fmt.Errorf("component stock item %d does not exist", l.ComponentStockItemID)
+		}
+		if component.IsSerialised {
+			return nil, fmt.Errorf(
+				"%s is serialised and cannot be backflushed, post its consumption separately",
+				component.StockCode,
+			)
+		}
+
+		lots := []model.BackflushedLot{}
+		if reverse {
+			lots, err = s.stockGenealogyRepository.GetBackflushedLots(
+				ctx, tx, input.StockItemID, input.LotNumber,
+				l.ComponentStockItemID, input.BackflushLocation, input.BackflushBin,
+			)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			stock, err := s.stockLotRepository.GetFEFOStockAtPlace(
+				ctx, tx, l.ComponentStockItemID, input.BackflushLocation, input.BackflushBin,
+			)
+			if err != nil {
+				return nil, err
+			}
+			for _, st := range stock {
+				lots = append(lots, model.BackflushedLot{LotNumber: st.LotNumber, Qty: st.Qty})
+			}
+		}
+
+		for _, lot := range allocateBackflush(l.RequiredQty(qty), lots) {
+			consumption = append(consumption, model.NewStockTransaction{
+				TransactionType: transactionType,
+				StockItemID:     l.ComponentStockItemID,
+				Qty:             lot.Qty,
+				FromLocation:    input.BackflushLocation,
+				FromBin:         input.BackflushBin,
+				FromLotNumber:   lot.LotNumber,
+				ToLocation:      input.BackflushLocation,
+				ToBin:           input.BackflushBin,
+				ToLotNumber:     lot.LotNumber,
+				TransactionNote: transactionNote,
+				DemandReference: input.DemandReference,
+				Timestamp:       nil,
+
+				AcknowledgeNegativeStock: input.AcknowledgeNegativeStock,
+			})
+		}
+	}
+
+	return consumption, nil
+}
+
+// allocateBackflush splits qty across the lots in order, taking no more than
+// each lot holds. Whatever the lots cannot cover is taken from the last lot,
+// or from stock without a lot number if there are none, leaving the negative
+// stock policy to decide whether it can go negative.
+func allocateBackflush(qty decimal.Decimal, lots []model.BackflushedLot) []model.BackflushedLot {
+	if len(lots) == 0 {
+		return []model.BackflushedLot{{LotNumber: "", Qty: qty}}
+	}
+
+	allocated := []model.BackflushedLot{}
+	for i, lot := range lots {
+		if !qty.IsPositive() {
+			break
+		}
+
+		lotQty := decimal.Min(qty, lot.Qty)
+		if i == len(lots)-1 {
+			lotQty = qty
+		}
+
+		allocated = append(allocated, model.BackflushedLot{LotNumber: lot.LotNumber, Qty: lotQty})
+		qty = qty.Sub(lotQty)
+	}
+
+	return allocated
+}
+
+// PostManualProductionReversal posts a production reversal out of STOCK,
+// returning the backflushed components in the same transaction when a
+// backflush location is given
+func (s *StockTransactionService) PostManualProductionReversal(
+	ctx context.Context,
+	input *model.PostManualGenericStockTransactionInput,
 	userID int,
 ) error {
 	tx, err := s.db.Begin(ctx)
@@ -36,19 +1431,34 @@ func (s *StockTransactionService) PostManualStockMovement(
 	}
 	defer tx.Rollback(ctx)
 
-	err = s.stockTransactionRepository.PostStockTransactions(ctx, tx, &model.PostStockTransactionsInput{{
-		TransactionType: "Stock Movement",
+	transactions := model.PostStockTransactionsInput{{
+		TransactionType: "Production Reversal",
 		StockItemID:     input.StockItemID,
 		Qty:             input.Qty,
-		FromLocation:    input.FromLocation,
-		FromBin:         input.FromBin,
+		FromLocation:    input.Location,
+		FromBin:         input.Bin,
 		FromLotNumber:   input.LotNumber,
-		ToLocation:      input.ToLocation,
-		ToBin:           input.ToBin,
+		ToLocation:      input.Location,
+		ToBin:           input.Bin,
 		ToLotNumber:     input.LotNumber,
+		Unit:            input.Unit,
 		TransactionNote: input.TransactionNote,
+		SerialNumbers:   input.SerialNumbers,
+		DemandReference: input.DemandReference,
 		Timestamp:       nil,
-	}}, userID)
+
+		AcknowledgeNegativeStock: input.AcknowledgeNegativeStock,
+	}}
+
+	if input.BackflushLocation != "" {
+		consumption, err := s.backflushTransactions(ctx, tx, input, true)
+		if err != nil {
+			return err
+		}
+		transactions = append(transactions, consumption...)
+	}
+
+	err = s.PostStockTransactions(ctx, tx, &transactions, userID)
 	if err != nil {
 		return err
 	}
@@ -57,10 +1467,12 @@ func (s *StockTransactionService) PostManualStockMovement(
 		return fmt.Errorf("error committing transaction: %v", err)
 	}
 
+	s.NotifyStockReorder(ctx, transactions.StockItemIDs()...)
+
 	return nil
 }
 
-func (s *StockTransactionService) PostManualProduction(
+func (s *StockTransactionService) PostManualConsumption(
 	ctx context.Context,
 	input *model.PostManualGenericStockTransactionInput,
 	userID int,
@@ -71,8 +1483,8 @@ func (s *StockTransactionService) PostManualProduction(
 	}
 	defer tx.Rollback(ctx)
 
-	err = s.stockTransactionRepository.PostStockTransactions(ctx, tx, &model.PostStockTransactionsInput{{
-		TransactionType: "Production",
+	err = s.PostStockTransactions(ctx, tx, &model.PostStockTransactionsInput{{
+		TransactionType: "Consumption",
 		StockItemID:     input.StockItemID,
 		Qty:             input.Qty,
 		FromLocation:    input.Location,
@@ -81,8 +1493,13 @@ func (s *StockTransactionService) PostManualProduction(
 		ToLocation:      input.Location,
 		ToBin:           input.Bin,
 		ToLotNumber:     input.LotNumber,
+		Unit:            input.Unit,
 		TransactionNote: input.TransactionNote,
+		SerialNumbers:   input.SerialNumbers,
+		DemandReference: input.DemandReference,
 		Timestamp:       nil,
+
+		AcknowledgeNegativeStock: input.AcknowledgeNegativeStock,
 	}}, userID)
 	if err != nil {
 		return err
@@ -92,10 +1509,12 @@ func (s *StockTransactionService) PostManualProduction(
 		return fmt.Errorf("error committing transaction: %v", err)
 	}
 
+	s.NotifyStockReorder(ctx, input.StockItemID)
+
 	return nil
 }
 
-func (s *StockTransactionService) PostManualProductionReversal(
+func (s *StockTransactionService) PostManualConsumptionReversal(
 	ctx context.Context,
 	input *model.PostManualGenericStockTransactionInput,
 	userID int,
@@ -106,8 +1525,8 @@ func (s *StockTransactionService) PostManualProductionReversal(
 	}
 	defer tx.Rollback(ctx)
 
-	err = s.stockTransactionRepository.PostStockTransactions(ctx, tx, &model.PostStockTransactionsInput{{
-		TransactionType: "Production Reversal",
+	err = s.PostStockTransactions(ctx, tx, &model.PostStockTransactionsInput{{
+		TransactionType: "Consumption Reversal",
 		StockItemID:     input.StockItemID,
 		Qty:             input.Qty,
 		FromLocation:    input.Location,
@@ -116,8 +1535,13 @@ func (s *StockTransactionService) PostManualProductionReversal(
 		ToLocation:      input.Location,
 		ToBin:           input.Bin,
 		ToLotNumber:     input.LotNumber,
+		Unit:            input.Unit,
 		TransactionNote: input.TransactionNote,
+		SerialNumbers:   input.SerialNumbers,
+		DemandReference: input.DemandReference,
 		Timestamp:       nil,
+
+		AcknowledgeNegativeStock: input.AcknowledgeNegativeStock,
 	}}, userID)
 	if err != nil {
 		return err
@@ -127,10 +1551,12 @@ func (s *StockTransactionService) PostManualProductionReversal(
 		return fmt.Errorf("error committing transaction: %v", err)
 	}
 
+	s.NotifyStockReorder(ctx, input.StockItemID)
+
 	return nil
 }
 
-func (s *StockTransactionService) PostManualConsumption(
+func (s *StockTransactionService) PostManualStockAdjustment(
 	ctx context.Context,
 	input *model.PostManualGenericStockTransactionInput,
 	userID int,
@@ -141,18 +1567,36 @@ func (s *StockTransactionService) PostManualConsumption(
 	}
 	defer tx.Rollback(ctx)
 
-	err = s.stockTransactionRepository.PostStockTransactions(ctx, tx, &model.PostStockTransactionsInput{{
-		TransactionType: "Consumption",
+	var transactionType model.StockTransactionType
+
+	if input.Qty.GreaterThan(decimal.Zero) {
+		transactionType = "Stock Adjust Up"
+	} else {
+		transactionType = "Stock Adjust Down"
+	}
+
+	// the transaction type carries the direction, so the quantity must be
+	// positive or an adjustment down would increase STOCK
+	qty := input.Qty.Abs()
+
+	err = s.PostStockTransactions(ctx, tx, &model.PostStockTransactionsInput{{
+		TransactionType: transactionType,
 		StockItemID:     input.StockItemID,
-		Qty:             input.Qty,
+		Qty:             qty,
 		FromLocation:    input.Location,
 		FromBin:         input.Bin,
 		FromLotNumber:   input.LotNumber,
 		ToLocation:      input.Location,
 		ToBin:           input.Bin,
 		ToLotNumber:     input.LotNumber,
+		Unit:            input.Unit,
 		TransactionNote: input.TransactionNote,
+		SerialNumbers:   input.SerialNumbers,
+		DemandReference: input.DemandReference,
+		UnitCost:        input.UnitCost,
 		Timestamp:       nil,
+
+		AcknowledgeNegativeStock: input.AcknowledgeNegativeStock,
 	}}, userID)
 	if err != nil {
 		return err
@@ -162,22 +1606,31 @@ func (s *StockTransactionService) PostManualConsumption(
 		return fmt.Errorf("error committing transaction: %v", err)
 	}
 
+	s.NotifyStockReorder(ctx, input.StockItemID)
+
 	return nil
 }
 
-func (s *StockTransactionService) PostManualConsumptionReversal(
+// PostManualQuarantine places stock on QC hold, releases it back to STOCK or
+// rejects it to SCRAP, all at the same place. Released stock comes back at
+// the current average cost.
+func (s *StockTransactionService) PostManualQuarantine(
 	ctx context.Context,
-	input *model.PostManualGenericStockTransactionInput,
+	input *model.PostManualQuarantineInput,
 	userID int,
 ) error {
+	if !slices.Contains(model.QuarantineTransactionTypes, input.TransactionType) {
+		return fmt.Errorf("%s is not a quarantine transaction type", input.TransactionType)
+	}
+
 	tx, err := s.db.Begin(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction")
 	}
 	defer tx.Rollback(ctx)
 
-	err = s.stockTransactionRepository.PostStockTransactions(ctx, tx, &model.PostStockTransactionsInput{{
-		TransactionType: "Consumption Reversal",
+	err = s.PostStockTransactions(ctx, tx, &model.PostStockTransactionsInput{{
+		TransactionType: input.TransactionType,
 		StockItemID:     input.StockItemID,
 		Qty:             input.Qty,
 		FromLocation:    input.Location,
@@ -186,8 +1639,14 @@ func (s *StockTransactionService) PostManualConsumptionReversal(
 		ToLocation:      input.Location,
 		ToBin:           input.Bin,
 		ToLotNumber:     input.LotNumber,
+		Unit:            input.Unit,
 		TransactionNote: input.TransactionNote,
+		SerialNumbers:   input.SerialNumbers,
+		Reason:          input.Reason,
+		ScrapReasonID:   input.ScrapReasonID,
 		Timestamp:       nil,
+
+		AcknowledgeNegativeStock: input.AcknowledgeNegativeStock,
 	}}, userID)
 	if err != nil {
 		return err
@@ -197,12 +1656,15 @@ func (s *StockTransactionService) PostManualConsumptionReversal(
 		return fmt.Errorf("error committing transaction: %v", err)
 	}
 
+	s.NotifyStockReorder(ctx, input.StockItemID)
+
 	return nil
 }
 
-func (s *StockTransactionService) PostManualStockAdjustment(
+// PostManualScrap scraps stock from a place for a scrap reason
+func (s *StockTransactionService) PostManualScrap(
 	ctx context.Context,
-	input *model.PostManualGenericStockTransactionInput,
+	input *model.PostManualScrapInput,
 	userID int,
 ) error {
 	tx, err := s.db.Begin(ctx)
@@ -211,16 +1673,8 @@ func (s *StockTransactionService) PostManualStockAdjustment(
 	}
 	defer tx.Rollback(ctx)
 
-	var transactionType model.StockTransactionType
-
-	if input.Qty.GreaterThan(decimal.Zero) {
-		transactionType = "Stock Adjust Up"
-	} else {
-		transactionType = "Stock Adjust Down"
-	}
-
-	err = s.stockTransactionRepository.PostStockTransactions(ctx, tx, &model.PostStockTransactionsInput{{
-		TransactionType: transactionType,
+	err = s.PostStockTransactions(ctx, tx, &model.PostStockTransactionsInput{{
+		TransactionType: model.ScrapTransactionType,
 		StockItemID:     input.StockItemID,
 		Qty:             input.Qty,
 		FromLocation:    input.Location,
@@ -229,8 +1683,105 @@ func (s *StockTransactionService) PostManualStockAdjustment(
 		ToLocation:      input.Location,
 		ToBin:           input.Bin,
 		ToLotNumber:     input.LotNumber,
+		Unit:            input.Unit,
+		TransactionNote: input.TransactionNote,
+		SerialNumbers:   input.SerialNumbers,
+		DemandReference: input.DemandReference,
+		ScrapReasonID:   &input.ScrapReasonID,
+		Timestamp:       nil,
+
+		AcknowledgeNegativeStock: input.AcknowledgeNegativeStock,
+	}}, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	s.NotifyStockReorder(ctx, input.StockItemID)
+
+	return nil
+}
+
+// PostCustomStockTransaction posts a transaction type defined in the
+// database. The user must have the permission the type requires and give
+// the fields it requires. The to place is the from place unless the type
+// allows it to differ.
+func (s *StockTransactionService) PostCustomStockTransaction(
+	ctx context.Context,
+	input *model.PostCustomStockTransactionInput,
+	userID int,
+) error {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	customType, err := s.stockTransactionTypeRepository.GetCustomStockTransactionType(
+		ctx, tx, input.CustomStockTransactionTypeID,
+	)
+	if err != nil {
+		return err
+	}
+	if customType == nil || customType.IsArchived {
+		return fmt.Errorf("%w: %d", ErrUnknownTransactionType, input.CustomStockTransactionTypeID)
+	}
+
+	user, err := s.userRepository.GetUserByID(ctx, tx, userID)
+	if err != nil {
+		return err
+	}
+	if user == nil || !customType.RequiredPermission.Allows(user.Permissions.SupplyChain) {
+		return fmt.Errorf("posting %s needs the supply chain %s permission",
+			customType.Name, customType.RequiredPermission)
+	}
+
+	given := map[model.CustomTransactionField]bool{
+		model.LotNumberCustomTransactionField:       input.FromLotNumber != "",
+		model.SerialNumbersCustomTransactionField:   len(input.SerialNumbers) > 0,
+		model.UnitCostCustomTransactionField:        input.UnitCost != nil,
+		model.DemandReferenceCustomTransactionField: input.DemandReference != "",
+		model.TransactionNoteCustomTransactionField: input.TransactionNote != "",
+	}
+	for _, f := range customType.RequiredFields {
+		if !given[f] {
+			return fmt.Errorf("%s is required for %s", f, customType.Name)
+		}
+	}
+
+	// bins belong to a location, so moving to another location moves to the
+	// bin given for it
+	toLocation, toBin, toLotNumber := input.FromLocation, input.FromBin, input.FromLotNumber
+	if customType.AllowDifferentLocation && input.ToLocation != "" {
+		toLocation, toBin = input.ToLocation, input.ToBin
+	} else if customType.AllowDifferentBin {
+		toBin = input.ToBin
+	}
+	if customType.AllowDifferentLot && input.ToLotNumber != "" {
+		toLotNumber = input.ToLotNumber
+	}
+
+	err = s.PostStockTransactions(ctx, tx, &model.PostStockTransactionsInput{{
+		TransactionType: model.StockTransactionType(customType.Name),
+		StockItemID:     input.StockItemID,
+		Qty:             input.Qty,
+		Unit:            input.Unit,
+		FromLocation:    input.FromLocation,
+		FromBin:         input.FromBin,
+		FromLotNumber:   input.FromLotNumber,
+		ToLocation:      toLocation,
+		ToBin:           toBin,
+		ToLotNumber:     toLotNumber,
+		SerialNumbers:   input.SerialNumbers,
+		UnitCost:        input.UnitCost,
+		DemandReference: input.DemandReference,
 		TransactionNote: input.TransactionNote,
 		Timestamp:       nil,
+
+		AcknowledgeNegativeStock: input.AcknowledgeNegativeStock,
 	}}, userID)
 	if err != nil {
 		return err
@@ -240,9 +1791,267 @@ func (s *StockTransactionService) PostManualStockAdjustment(
 		return fmt.Errorf("error committing transaction: %v", err)
 	}
 
+	s.NotifyStockReorder(ctx, input.StockItemID)
+
+	return nil
+}
+
+// GetCustomStockTransactionTypes returns the custom transaction types that can
+// be posted with the supply chain permissions
+func (s *StockTransactionService) GetCustomStockTransactionTypes(
+	ctx context.Context,
+	perms model.SupplyChainPermissions,
+) ([]model.CustomStockTransactionType, error) {
+
+	transactionTypes, err := s.stockTransactionTypeRepository.GetCustomStockTransactionTypes(ctx, s.db, false)
+	if err != nil {
+		return nil, err
+	}
+
+	postable := []model.CustomStockTransactionType{}
+	for _, t := range transactionTypes {
+		if t.RequiredPermission.Allows(perms) {
+			postable = append(postable, t)
+		}
+	}
+
+	return postable, nil
+}
+
+// GetScrapReasons returns the scrap reasons that can be posted with
+func (s *StockTransactionService) GetScrapReasons(
+	ctx context.Context,
+) ([]model.ScrapReason, error) {
+
+	reasons, err := s.stockScrapRepository.GetScrapReasons(ctx, s.db, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return reasons, nil
+}
+
+// PostStockTransactionRequests posts transactions given by stock code, as
+// they come from the API, in a single database transaction. Either all are
+// posted or none are. Validation errors are keyed by the position of the
+// transaction, such as Transactions[0].StockCode.
+func (s *StockTransactionService) PostStockTransactionRequests(
+	ctx context.Context,
+	input *model.PostStockTransactionRequestsInput,
+	userID int,
+) (validate.ValidationErrors, error) {
+
+	var validationErrors validate.ValidationErrors = make(map[string][]string)
+
+	if len(input.Transactions) == 0 {
+		validationErrors.Add("Transactions", "must list at least one transaction")
+		return validationErrors, nil
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	transactions := model.PostStockTransactionsInput{}
+	for i, t := range input.Transactions {
+		key := func(field string) string {
+			return fmt.Sprintf("Transactions[%d].%s", i, field)
+		}
+
+		if !slices.Contains(model.ManualStockTransactionTypes, t.TransactionType) {
+			validationErrors.Add(key("TransactionType"), "is not a type that can be posted directly")
+		}
+
+		if t.Qty.LessThanOrEqual(decimal.Zero) {
+			validationErrors.Add(key("Qty"), "must be greater than 0")
+		}
+
+		if t.FromLocation == "" {
+			validationErrors.Add(key("FromLocation"), "is required")
+		}
+
+		if t.UnitCost != nil && t.UnitCost.IsNegative() {
+			validationErrors.Add(key("UnitCost"), "cannot be negative")
+		}
+
+		// Only stock movements post between two different places, every
+		// other transaction type moves stock between accounts at the same
+		// place
+		toLocation, toBin, toLotNumber := t.FromLocation, t.FromBin, t.FromLotNumber
+		if t.TransactionType == model.StockMovementTransactionType {
+			toLocation, toBin = t.ToLocation, t.ToBin
+			if t.ToLotNumber != "" {
+				toLotNumber = t.ToLotNumber
+			}
+
+			if toLocation == "" {
+				validationErrors.Add(key("ToLocation"), "is required")
+			} else if t.FromLocation == toLocation &&
+				t.FromBin == toBin &&
+				t.FromLotNumber == toLotNumber {
+				validationErrors.Add(key("ToLocation"), "must differ from the from location and bin")
+			}
+		}
+
+		stockItem, err := s.stockItemRepository.GetStockItemByStockCode(ctx, tx, t.StockCode)
+		if err != nil {
+			return nil, err
+		}
+		if stockItem == nil {
+			validationErrors.Add(key("StockCode"), "does not exist")
+			continue
+		}
+
+		_, err = s.ConvertToBaseUnit(ctx, tx, stockItem.StockItemID, t.Unit, t.Qty)
+		if errors.Is(err, ErrUnknownStockItemUnit) {
+			validationErrors.Add(key("Unit"), "is not defined for the stock item")
+		} else if err != nil {
+			return nil, err
+		}
+
+		transactions = append(transactions, model.NewStockTransaction{
+			TransactionType: t.TransactionType,
+			StockItemID:     stockItem.StockItemID,
+			Qty:             t.Qty,
+			Unit:            t.Unit,
+			FromLocation:    t.FromLocation,
+			FromBin:         t.FromBin,
+			FromLotNumber:   t.FromLotNumber,
+			ToLocation:      toLocation,
+			ToBin:           toBin,
+			ToLotNumber:     toLotNumber,
+			SerialNumbers:   t.SerialNumbers,
+			UnitCost:        t.UnitCost,
+			TransactionNote: t.TransactionNote,
+
+			AcknowledgeNegativeStock: input.AcknowledgeNegativeStock,
+		})
+	}
+
+	if len(validationErrors) > 0 {
+		return validationErrors, nil
+	}
+
+	err = s.PostStockTransactions(ctx, tx, &transactions, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	s.NotifyStockReorder(ctx, transactions.StockItemIDs()...)
+
+	return nil, nil
+}
+
+// ReverseStockTransaction posts the mirror of a transaction now, linked to
+// the original. A transaction can only be reversed once and reversals cannot
+// themselves be reversed, post the original again instead.
+func (s *StockTransactionService) ReverseStockTransaction(
+	ctx context.Context,
+	stockTransactionID int,
+	acknowledgeNegativeStock bool,
+	userID int,
+) error {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	original, err := s.stockTransactionRepository.GetStockTransactionToReverse(ctx, tx, stockTransactionID)
+	if err != nil {
+		return err
+	}
+	if original == nil {
+		return fmt.Errorf("stock transaction %d does not exist", stockTransactionID)
+	}
+	if original.ReversedByStockTransactionID != nil {
+		return fmt.Errorf(
+			"stock transaction %d has already been reversed by transaction %d",
+			stockTransactionID, *original.ReversedByStockTransactionID,
+		)
+	}
+	if original.ReversesStockTransactionID != nil {
+		return fmt.Errorf(
+			"stock transaction %d is a reversal of transaction %d and cannot be reversed",
+			stockTransactionID, *original.ReversesStockTransactionID,
+		)
+	}
+
+	reversals := model.PostStockTransactionsInput{
+		reversalOf(original, stockTransactionID, acknowledgeNegativeStock),
+	}
+
+	// the components backflushed with a production go back with it
+	if original.TransactionType == model.ProductionTransactionType {
+		consumptionIDs, err := s.stockGenealogyRepository.GetBackflushStockTransactionIDs(ctx, tx, stockTransactionID)
+		if err != nil {
+			return err
+		}
+		for _, consumptionID := range consumptionIDs {
+			consumption, err := s.stockTransactionRepository.GetStockTransactionToReverse(ctx, tx, consumptionID)
+			if err != nil {
+				return err
+			}
+			if consumption == nil {
+				return fmt.Errorf("stock transaction %d does not exist", consumptionID)
+			}
+			reversals = append(reversals, reversalOf(consumption, consumptionID, acknowledgeNegativeStock))
+		}
+	}
+
+	err = s.PostStockTransactions(ctx, tx, &reversals, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	s.NotifyStockReorder(ctx, reversals.StockItemIDs()...)
+
 	return nil
 }
 
+// reversalOf returns the transaction reversing the original. Posting the
+// quantity of the original from entry gives each entry the negated quantity
+// of its original, whichever direction it went. Stock coming back into STOCK
+// comes back at the cost it went out at.
+func reversalOf(
+	original *model.StockTransactionToReverse,
+	stockTransactionID int,
+	acknowledgeNegativeStock bool,
+) model.NewStockTransaction {
+	return model.NewStockTransaction{
+		TransactionType:            original.TransactionType,
+		StockItemID:                original.StockItemID,
+		Qty:                        original.FromQuantity,
+		FromLocation:               original.FromLocation,
+		FromBin:                    original.FromBin,
+		FromLotNumber:              original.FromLotNumber,
+		ToLocation:                 original.ToLocation,
+		ToBin:                      original.ToBin,
+		ToLotNumber:                original.ToLotNumber,
+		TransactionNote:            fmt.Sprintf("Reversal of transaction %d", stockTransactionID),
+		SerialNumbers:              original.SerialNumbers,
+		UnitCost:                   original.UnitCost,
+		PurchaseOrderLineID:        original.PurchaseOrderLineID,
+		SalesOrderLineID:           original.SalesOrderLineID,
+		Reason:                     original.Reason,
+		ScrapReasonID:              original.ScrapReasonID,
+		Timestamp:                  nil,
+		ReversesStockTransactionID: &stockTransactionID,
+
+		AcknowledgeNegativeStock: acknowledgeNegativeStock,
+	}
+}
+
 func (s *StockTransactionService) GetStockTransactions(
 	ctx context.Context,
 	input *model.GetTransactionsInput,
@@ -257,6 +2066,21 @@ func (s *StockTransactionService) GetStockTransactions(
 	return transactions, nil
 }
 
+// GetLotNumbers returns the lot numbers posted for a stock code, most
+// recently posted first
+func (s *StockTransactionService) GetLotNumbers(
+	ctx context.Context,
+	stockCode string,
+) ([]string, error) {
+
+	lotNumbers, err := s.stockTransactionRepository.GetLotNumbers(ctx, s.db, stockCode)
+	if err != nil {
+		return nil, err
+	}
+
+	return lotNumbers, nil
+}
+
 func (s *StockTransactionService) GetStockLevels(
 	ctx context.Context,
 	input *model.GetStockLevelsInput,
@@ -270,3 +2094,54 @@ func (s *StockTransactionService) GetStockLevels(
 
 	return levels, nil
 }
+
+func (s *StockTransactionService) GetNegativeStockPolicies(
+	ctx context.Context,
+) ([]model.NegativeStockPolicy, error) {
+
+	policies, err := s.negativeStockPolicyRepository.GetNegativeStockPolicies(ctx, s.db)
+	if err != nil {
+		return nil, err
+	}
+
+	return policies, nil
+}
+
+// SaveNegativeStockPolicy creates the policy for the given scope or replaces
+// the action of the existing one
+func (s *StockTransactionService) SaveNegativeStockPolicy(
+	ctx context.Context,
+	input *model.PostNegativeStockPolicy,
+	userID int,
+) (validate.ValidationErrors, error) {
+
+	var ve validate.ValidationErrors = make(map[string][]string)
+
+	if !slices.Contains(model.NegativeStockActions, input.Action) {
+		ve.Add("Action", "is not a valid action")
+	}
+
+	if len(ve) > 0 {
+		return ve, nil
+	}
+
+	err := s.negativeStockPolicyRepository.UpsertNegativeStockPolicy(ctx, s.db, input, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+func (s *StockTransactionService) DeleteNegativeStockPolicy(
+	ctx context.Context,
+	negativeStockPolicyID int,
+) error {
+
+	err := s.negativeStockPolicyRepository.DeleteNegativeStockPolicy(ctx, s.db, negativeStockPolicyID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
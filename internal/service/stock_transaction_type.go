@@ -0,0 +1,206 @@
+package service
+
+import (
+	"app/internal/model"
+	"app/internal/repository"
+	"app/pkg/validate"
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type StockTransactionTypeService struct {
+	db                             *pgxpool.Pool
+	stockTransactionTypeRepository *repository.StockTransactionTypeRepository
+}
+
+func NewStockTransactionTypeService(
+	db *pgxpool.Pool,
+	stockTransactionTypeRepository *repository.StockTransactionTypeRepository,
+) *StockTransactionTypeService {
+	return &StockTransactionTypeService{
+		db:                             db,
+		stockTransactionTypeRepository: stockTransactionTypeRepository,
+	}
+}
+
+// validateCustomStockTransactionTypeRules checks what can be changed after a
+// custom transaction type is created. A type that posts from and to the same
+// account must allow the place to differ, as the ledger cannot post from and
+// to the same place of an account.
+func validateCustomStockTransactionTypeRules(
+	validationErrors validate.ValidationErrors,
+	fromAccount model.StockAccount,
+	toAccount model.StockAccount,
+	allowDifferentLocation bool,
+	allowDifferentBin bool,
+	allowDifferentLot bool,
+	requiredFields []model.CustomTransactionField,
+	requiredPermission model.CustomTransactionPermission,
+) {
+
+	if fromAccount == toAccount && !allowDifferentLocation && !allowDifferentBin && !allowDifferentLot {
+		validationErrors.Add("ToAccount", "must differ from the from account unless the location, bin or lot may differ")
+	}
+
+	for _, f := range requiredFields {
+		if !slices.Contains(model.CustomTransactionFields, f) {
+			validationErrors.Add("RequiredFields", fmt.Sprintf("%s is not a field that can be required", f))
+		}
+	}
+
+	if !slices.Contains(model.CustomTransactionPermissions, requiredPermission) {
+		validationErrors.Add("RequiredPermission", "is not a supply chain permission")
+	}
+}
+
+func (s *StockTransactionTypeService) CreateCustomStockTransactionType(
+	ctx context.Context,
+	input *model.NewCustomStockTransactionType,
+	userID int,
+) (int, validate.ValidationErrors, error) {
+
+	var validationErrors validate.ValidationErrors = make(map[string][]string)
+
+	if input.Name == "" {
+		validationErrors.Add("Name", "is required")
+	} else if _, ok := model.StockTransacationTypeMap[model.StockTransactionType(input.Name)]; ok {
+		validationErrors.Add("Name", "is a built in transaction type")
+	}
+
+	if !slices.Contains(model.StockAccounts, input.FromAccount) {
+		validationErrors.Add("FromAccount", "is not a stock account")
+	}
+	if !slices.Contains(model.StockAccounts, input.ToAccount) {
+		validationErrors.Add("ToAccount", "is not a stock account")
+	}
+
+	// holding and releasing stock needs a reason and QC approval, which only
+	// the quarantine transaction types take
+	if input.FromAccount == model.QuarantineStockAccount {
+		validationErrors.Add("FromAccount", "cannot be QUARANTINE, use the quarantine transaction types")
+	}
+	if input.ToAccount == model.QuarantineStockAccount {
+		validationErrors.Add("ToAccount", "cannot be QUARANTINE, use the quarantine transaction types")
+	}
+
+	validateCustomStockTransactionTypeRules(
+		validationErrors,
+		input.FromAccount,
+		input.ToAccount,
+		input.AllowDifferentLocation,
+		input.AllowDifferentBin,
+		input.AllowDifferentLot,
+		input.RequiredFields,
+		input.RequiredPermission,
+	)
+
+	if len(validationErrors) > 0 {
+		return 0, validationErrors, nil
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	existing, err := s.stockTransactionTypeRepository.GetCustomStockTransactionTypeByName(ctx, tx, input.Name)
+	if err != nil {
+		return 0, nil, err
+	}
+	if existing != nil {
+		validationErrors.Add("Name", "already exists")
+		return 0, validationErrors, nil
+	}
+
+	customStockTransactionTypeID, err := s.stockTransactionTypeRepository.CreateCustomStockTransactionType(
+		ctx, tx, input, userID,
+	)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, nil, fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return customStockTransactionTypeID, nil, nil
+}
+
+func (s *StockTransactionTypeService) UpdateCustomStockTransactionType(
+	ctx context.Context,
+	customStockTransactionTypeID int,
+	update *model.CustomStockTransactionTypeUpdate,
+	userID int,
+) (validate.ValidationErrors, error) {
+
+	var validationErrors validate.ValidationErrors = make(map[string][]string)
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	existing, err := s.stockTransactionTypeRepository.GetCustomStockTransactionType(
+		ctx, tx, customStockTransactionTypeID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if existing == nil {
+		return nil, fmt.Errorf("transaction type %d does not exist", customStockTransactionTypeID)
+	}
+
+	validateCustomStockTransactionTypeRules(
+		validationErrors,
+		existing.FromAccount,
+		existing.ToAccount,
+		update.AllowDifferentLocation,
+		update.AllowDifferentBin,
+		update.AllowDifferentLot,
+		update.RequiredFields,
+		update.RequiredPermission,
+	)
+
+	if len(validationErrors) > 0 {
+		return validationErrors, nil
+	}
+
+	err = s.stockTransactionTypeRepository.UpdateCustomStockTransactionType(
+		ctx, tx, customStockTransactionTypeID, update, userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return nil, nil
+}
+
+func (s *StockTransactionTypeService) GetCustomStockTransactionType(
+	ctx context.Context,
+	customStockTransactionTypeID int,
+) (*model.CustomStockTransactionType, error) {
+
+	return s.stockTransactionTypeRepository.GetCustomStockTransactionType(ctx, s.db, customStockTransactionTypeID)
+}
+
+func (s *StockTransactionTypeService) GetCustomStockTransactionTypes(
+	ctx context.Context,
+	showArchived bool,
+) ([]model.CustomStockTransactionType, error) {
+
+	transactionTypes, err := s.stockTransactionTypeRepository.GetCustomStockTransactionTypes(ctx, s.db, showArchived)
+	if err != nil {
+		return []model.CustomStockTransactionType{}, err
+	}
+
+	return transactionTypes, nil
+}
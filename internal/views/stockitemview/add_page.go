@@ -3,6 +3,7 @@ package stockitemview
 import (
 	"app/internal/components"
 	"app/internal/layout"
+	"app/internal/model"
 	"app/pkg/reqcontext"
 	"app/pkg/validate"
 	"net/url"
@@ -83,6 +84,34 @@ func addStockItemForm(p *addStockItemFormProps) g.Node {
 		descriptionHelperType = components.InputHelperTypeError
 	}
 
+	baseUnitLabel := "Base Unit"
+	baseUnitKey := "BaseUnit"
+	baseUnitValue := p.values.Get(baseUnitKey)
+	if !p.isSubmission && baseUnitValue == "" {
+		baseUnitValue = "EA"
+	}
+	baseUnitError := ""
+	if p.isSubmission {
+		baseUnitError = p.validationErrors.GetError(baseUnitKey, baseUnitLabel)
+	}
+
+	isSerialisedLabel := "Serialised"
+	isSerialisedKey := "IsSerialised"
+	isSerialisedValue := p.values.Get(isSerialisedKey) == "true"
+	isSerialisedError := ""
+	if p.isSubmission {
+		isSerialisedError = p.validationErrors.GetError(isSerialisedKey, isSerialisedLabel)
+	}
+
+	costingMethodValue := string(model.WeightedAverageStockCostingMethod)
+	if p.values.Get("CostingMethod") != "" {
+		costingMethodValue = p.values.Get("CostingMethod")
+	}
+	costingMethodError := ""
+	if p.isSubmission {
+		costingMethodError = p.validationErrors.GetError("CostingMethod", "Costing Method")
+	}
+
 	return components.Form(
 		h.ID("sku-generate-form"),
 		h.Method("POST"),
@@ -124,6 +153,44 @@ func addStockItemForm(p *addStockItemFormProps) g.Node {
 			})),
 		),
 
+		h.Div(
+			h.Label(h.For(baseUnitKey), g.Text(baseUnitLabel)),
+			h.Input(
+				h.Name(baseUnitKey),
+				h.ID(baseUnitKey),
+				h.Placeholder("Enter base unit, e.g. EA or KG"),
+				h.Type("text"),
+				h.Value(baseUnitValue),
+				h.AutoComplete("off"),
+			),
+			g.If(baseUnitError != "", components.InputHelper(&components.InputHelperProps{
+				Label: baseUnitError,
+				Type:  components.InputHelperTypeError,
+			})),
+		),
+
+		h.Div(
+			h.Label(
+				g.Text(isSerialisedLabel),
+
+				h.Input(
+					h.Type("checkbox"),
+					h.Name(isSerialisedKey),
+					g.If(isSerialisedValue, h.Checked()),
+					h.Value("true"),
+				),
+			),
+			components.InputHelper(&components.InputHelperProps{
+				Label: "Every posting must list the serial number of each unit",
+			}),
+			g.If(isSerialisedError != "", components.InputHelper(&components.InputHelperProps{
+				Label: isSerialisedError,
+				Type:  components.InputHelperTypeError,
+			})),
+		),
+
+		costingMethodField(costingMethodValue, costingMethodError),
+
 		components.Button(
 			&components.ButtonProps{},
 			h.Type("submit"),
@@ -4,6 +4,7 @@ import (
 	"app/internal/components"
 	"app/internal/layout"
 	"app/internal/model"
+	"app/pkg/format"
 	"app/pkg/nilsafe"
 	"app/pkg/reqcontext"
 	"app/pkg/validate"
@@ -20,6 +21,12 @@ type EditStockItemPageProps struct {
 	Values           url.Values
 	ValidationErrors validate.ValidationErrors
 	IsSubmission     bool
+
+	// Add unit form state
+	StockItemUnits       []model.StockItemUnit
+	UnitValues           url.Values
+	UnitValidationErrors validate.ValidationErrors
+	IsUnitSubmission     bool
 }
 
 func EditStockItemPage(p *EditStockItemPageProps) g.Node {
@@ -31,6 +38,14 @@ func EditStockItemPage(p *EditStockItemPageProps) g.Node {
 			validationErrors: p.ValidationErrors,
 			isSubmission:     p.IsSubmission,
 		}),
+
+		stockItemUnitsSection(&stockItemUnitsSectionProps{
+			stockItem:        p.StockItem,
+			units:            p.StockItemUnits,
+			values:           p.UnitValues,
+			validationErrors: p.UnitValidationErrors,
+			isSubmission:     p.IsUnitSubmission,
+		}),
 	})
 
 	return layout.Page(layout.PageProps{
@@ -103,6 +118,37 @@ func editUserForm(p *editUserFormProps) g.Node {
 		descriptionHelperType = components.InputHelperTypeError
 	}
 
+	baseUnitLabel := "Base Unit"
+	baseUnitKey := "BaseUnit"
+	baseUnitValue := p.stockItem.BaseUnit
+	if p.values.Get(baseUnitKey) != "" {
+		baseUnitValue = p.values.Get(baseUnitKey)
+	}
+	baseUnitError := ""
+	if p.isSubmission {
+		baseUnitError = p.validationErrors.GetError(baseUnitKey, baseUnitLabel)
+	}
+
+	isSerialisedLabel := "Serialised"
+	isSerialisedKey := "IsSerialised"
+	isSerialisedValue := p.stockItem.IsSerialised
+	if p.isSubmission {
+		isSerialisedValue = p.values.Get(isSerialisedKey) == "true"
+	}
+	isSerialisedError := ""
+	if p.isSubmission {
+		isSerialisedError = p.validationErrors.GetError(isSerialisedKey, isSerialisedLabel)
+	}
+
+	costingMethodValue := string(p.stockItem.CostingMethod)
+	if p.values.Get("CostingMethod") != "" {
+		costingMethodValue = p.values.Get("CostingMethod")
+	}
+	costingMethodError := ""
+	if p.isSubmission {
+		costingMethodError = p.validationErrors.GetError("CostingMethod", "Costing Method")
+	}
+
 	return components.Form(
 		h.ID("sku-generate-form"),
 		h.Method("POST"),
@@ -136,6 +182,44 @@ func editUserForm(p *editUserFormProps) g.Node {
 			},
 		}),
 
+		h.Div(
+			h.Label(h.For(baseUnitKey), g.Text(baseUnitLabel)),
+			h.Input(
+				h.Name(baseUnitKey),
+				h.ID(baseUnitKey),
+				h.Placeholder("Enter base unit, e.g. EA or KG"),
+				h.Type("text"),
+				h.Value(baseUnitValue),
+				h.AutoComplete("off"),
+			),
+			g.If(baseUnitError != "", components.InputHelper(&components.InputHelperProps{
+				Label: baseUnitError,
+				Type:  components.InputHelperTypeError,
+			})),
+		),
+
+		h.Div(
+			h.Label(
+				g.Text(isSerialisedLabel),
+
+				h.Input(
+					h.Type("checkbox"),
+					h.Name(isSerialisedKey),
+					g.If(isSerialisedValue, h.Checked()),
+					h.Value("true"),
+				),
+			),
+			components.InputHelper(&components.InputHelperProps{
+				Label: "Every posting must list the serial number of each unit",
+			}),
+			g.If(isSerialisedError != "", components.InputHelper(&components.InputHelperProps{
+				Label: isSerialisedError,
+				Type:  components.InputHelperTypeError,
+			})),
+		),
+
+		costingMethodField(costingMethodValue, costingMethodError),
+
 		components.Button(
 			&components.ButtonProps{},
 			h.Type("submit"),
@@ -144,3 +228,152 @@ func editUserForm(p *editUserFormProps) g.Node {
 	)
 
 }
+
+type stockItemUnitsSectionProps struct {
+	stockItem        model.StockItem
+	units            []model.StockItemUnit
+	values           url.Values
+	validationErrors validate.ValidationErrors
+	isSubmission     bool
+}
+
+// stockItemUnitsSection lists the alternate units of the stock item with a
+// form to add another. Quantities are always held in the base unit, so units
+// can be added and removed without affecting existing stock.
+func stockItemUnitsSection(p *stockItemUnitsSectionProps) g.Node {
+
+	fieldError := func(key, label string) g.Node {
+		if !p.isSubmission {
+			return nil
+		}
+		errorText := p.validationErrors.GetError(key, label)
+		if errorText == "" {
+			return nil
+		}
+		return components.InputHelper(&components.InputHelperProps{
+			Label: errorText,
+			Type:  components.InputHelperTypeError,
+		})
+	}
+
+	columns := components.TableColumns{
+		{TitleContents: g.Text("Unit")},
+		{TitleContents: g.Text("Conversion")},
+		{TitleContents: g.Text("")},
+	}
+
+	var rows components.TableRows
+	for _, u := range p.units {
+		rows = append(rows, components.TableRow{
+			Cells: []components.TableCell{
+				{Contents: g.Text(u.Unit)},
+				{Contents: g.Textf(
+					"1 %s = %s %s",
+					u.Unit,
+					format.DecimalWithCommas(u.ConversionFactor.String()),
+					p.stockItem.BaseUnit,
+				)},
+				{Contents: h.Form(
+					h.Method("POST"),
+					h.Action(fmt.Sprintf(
+						"/stock-items/%d/units/%d/delete",
+						p.stockItem.StockItemID,
+						u.StockItemUnitID,
+					)),
+					h.Button(
+						h.Class("button secondary small"),
+						h.Type("submit"),
+						g.Text("Remove"),
+					),
+				)},
+			},
+		})
+	}
+
+	return h.Div(
+		h.Class("stock-item-units"),
+
+		h.H3(g.Text("Alternate Units")),
+
+		g.If(
+			len(p.units) == 0,
+			h.P(g.Text("No alternate units, quantities are entered in the base unit.")),
+		),
+		g.If(
+			len(p.units) > 0,
+			components.Table(&components.TableProps{
+				Columns: columns,
+				Rows:    rows,
+			}),
+		),
+
+		h.Form(
+			h.Method("POST"),
+			h.Class("form"),
+			h.Action(fmt.Sprintf("/stock-items/%d/units", p.stockItem.StockItemID)),
+
+			h.Div(
+				h.Label(
+					g.Text("Unit"),
+					h.Input(
+						h.Type("text"),
+						h.Name("Unit"),
+						h.Value(p.values.Get("Unit")),
+						h.Placeholder("Enter unit, e.g. BOX"),
+						h.AutoComplete("off"),
+					),
+				),
+				fieldError("Unit", "Unit"),
+			),
+
+			h.Div(
+				h.Label(
+					g.Text(fmt.Sprintf("%s per Unit", p.stockItem.BaseUnit)),
+					h.Input(
+						h.Type("number"),
+						h.Min("0"),
+						h.Step("any"),
+						h.Name("ConversionFactor"),
+						h.Value(p.values.Get("ConversionFactor")),
+						h.Placeholder("Enter conversion factor, e.g. 50"),
+						h.AutoComplete("off"),
+					),
+				),
+				fieldError("ConversionFactor", "Conversion Factor"),
+			),
+
+			components.Button(
+				&components.ButtonProps{},
+				h.Type("submit"),
+				g.Text("Add Unit"),
+			),
+		),
+	)
+}
+
+// costingMethodField picks how stock taken out of the STOCK account of the
+// item is valued
+func costingMethodField(value string, errorText string) g.Node {
+	return h.Div(
+		h.Label(
+			g.Text("Costing Method"),
+			h.Select(
+				h.Name("CostingMethod"),
+				g.Group(g.Map(model.StockCostingMethods, func(m model.StockCostingMethod) g.Node {
+					return h.Option(
+						h.Value(string(m)),
+						g.Text(string(m)),
+						g.If(value == string(m), h.Selected()),
+					)
+				})),
+			),
+		),
+		components.InputHelper(&components.InputHelperProps{
+			Label: "Applies to postings from when it is changed",
+		}),
+		g.If(errorText != "", components.InputHelper(&components.InputHelperProps{
+			Label: errorText,
+			Type:  components.InputHelperTypeError,
+		})),
+	)
+}
@@ -0,0 +1,431 @@
+package stockitemview
+
+import (
+	"app/internal/components"
+	"app/internal/layout"
+	"app/internal/model"
+	"app/pkg/format"
+	"app/pkg/nilsafe"
+	"app/pkg/reqcontext"
+	"app/pkg/validate"
+	"fmt"
+	"net/url"
+	"time"
+
+	g "maragu.dev/gomponents"
+	h "maragu.dev/gomponents/html"
+)
+
+type StockBOMPageProps struct {
+	Ctx        reqcontext.ReqContext
+	StockItem  model.StockItem
+	StockBOM   model.StockBOM
+	Lines      []model.StockBOMLine
+	StockItems []model.StockItem
+	ErrorText  string
+
+	// Edit version form state
+	Values           url.Values
+	ValidationErrors validate.ValidationErrors
+	IsSubmission     bool
+
+	// Add line form state
+	LineValues           url.Values
+	LineValidationErrors validate.ValidationErrors
+	IsLineSubmission     bool
+}
+
+func StockBOMPage(p *StockBOMPageProps) g.Node {
+
+	canUserEdit := p.Ctx.User.Permissions.Stock.Admin
+	bom := p.StockBOM
+
+	content := g.Group([]g.Node{
+		h.Div(
+			h.Class("stock-bom"),
+
+			h.H3(g.Textf("%s BOM %s", p.StockItem.StockCode, bom.Version)),
+
+			h.P(
+				g.Textf("Effective %s. Created by %s on ",
+					stockBOMEffectiveText(bom.EffectiveFrom, bom.EffectiveTo),
+					nilsafe.Str(bom.CreatedByUsername),
+				),
+				h.Span(h.Class("local-datetime"), g.Text(bom.CreatedAt.Format(time.RFC3339))),
+			),
+
+			g.If(bom.Note != "", h.P(g.Text(bom.Note))),
+
+			g.If(
+				p.ErrorText != "",
+				h.Div(
+					h.Class("error-msg"),
+					g.Text(p.ErrorText),
+				),
+			),
+
+			h.H3(g.Text("Components")),
+
+			stockBOMLinesTable(&bom, p.Lines, canUserEdit),
+
+			g.Iff(canUserEdit, func() g.Node {
+				return g.Group([]g.Node{
+					addStockBOMLineForm(&addStockBOMLineFormProps{
+						stockBOM:         bom,
+						stockItems:       p.StockItems,
+						values:           p.LineValues,
+						validationErrors: p.LineValidationErrors,
+						isSubmission:     p.IsLineSubmission,
+					}),
+
+					h.H3(g.Text("Edit Version")),
+
+					stockBOMForm(&stockBOMFormProps{
+						action:           fmt.Sprintf("/stock-items/%d/boms/%d", bom.StockItemID, bom.StockBOMID),
+						submitText:       "Save Version",
+						values:           p.Values,
+						validationErrors: p.ValidationErrors,
+						isSubmission:     p.IsSubmission,
+					}),
+				})
+			}),
+		),
+	})
+
+	return layout.Page(layout.PageProps{
+		Ctx:   p.Ctx,
+		Title: fmt.Sprintf("%s BOM %s", p.StockItem.StockCode, bom.Version),
+		Breadcrumbs: []layout.Breadcrumb{
+			layout.HomeBreadcrumb,
+			{
+				IconIdentifier: "package-variant-closed",
+				Title:          "Stock Items",
+				URL:            "/stock-items",
+			},
+			{
+				Title: p.StockItem.StockCode,
+				URL:   fmt.Sprintf("/stock-items/%d", p.StockItem.StockItemID),
+			},
+			{
+				Title: fmt.Sprintf("BOM %s", bom.Version),
+			},
+		},
+		Content: content,
+		AppendHead: []g.Node{
+			components.InlineStyle("/internal/views/stockitemview/stock_bom_page.css"),
+		},
+	})
+}
+
+type AddStockBOMPageProps struct {
+	Ctx              reqcontext.ReqContext
+	StockItem        model.StockItem
+	Values           url.Values
+	ValidationErrors validate.ValidationErrors
+	IsSubmission     bool
+}
+
+func AddStockBOMPage(p *AddStockBOMPageProps) g.Node {
+
+	content := h.Div(
+		h.Class("stock-bom"),
+
+		h.P(g.Text(`Each version of a bill of materials lists the components
+			used to make one base unit of the stock item. The version in
+			effect on the day of production is used when backflushing.`)),
+
+		stockBOMForm(&stockBOMFormProps{
+			action:           fmt.Sprintf("/stock-items/%d/boms/add", p.StockItem.StockItemID),
+			showVersion:      true,
+			submitText:       "Add Version",
+			values:           p.Values,
+			validationErrors: p.ValidationErrors,
+			isSubmission:     p.IsSubmission,
+		}),
+	)
+
+	return layout.Page(layout.PageProps{
+		Ctx:   p.Ctx,
+		Title: fmt.Sprintf("Add %s BOM Version", p.StockItem.StockCode),
+		Breadcrumbs: []layout.Breadcrumb{
+			layout.HomeBreadcrumb,
+			{
+				IconIdentifier: "package-variant-closed",
+				Title:          "Stock Items",
+				URL:            "/stock-items",
+			},
+			{
+				Title: p.StockItem.StockCode,
+				URL:   fmt.Sprintf("/stock-items/%d", p.StockItem.StockItemID),
+			},
+			{
+				IconIdentifier: "plus",
+				Title:          "Add BOM Version",
+			},
+		},
+		Content: content,
+		AppendHead: []g.Node{
+			components.InlineStyle("/internal/views/stockitemview/stock_bom_page.css"),
+		},
+	})
+}
+
+// stockBOMEffectiveText describes the dates a version is in effect
+func stockBOMEffectiveText(effectiveFrom time.Time, effectiveTo *time.Time) string {
+	if effectiveTo == nil {
+		return fmt.Sprintf("from %s", effectiveFrom.Format("2006-01-02"))
+	}
+	return fmt.Sprintf(
+		"from %s until %s",
+		effectiveFrom.Format("2006-01-02"),
+		effectiveTo.Format("2006-01-02"),
+	)
+}
+
+// stockBOMFieldError renders the validation error of a BOM form field once
+// the form has been submitted
+func stockBOMFieldError(
+	validationErrors validate.ValidationErrors,
+	isSubmission bool,
+	key string,
+	label string,
+) g.Node {
+	if !isSubmission {
+		return nil
+	}
+	errorText := validationErrors.GetError(key, label)
+	if errorText == "" {
+		return nil
+	}
+	return components.InputHelper(&components.InputHelperProps{
+		Label: errorText,
+		Type:  components.InputHelperTypeError,
+	})
+}
+
+type stockBOMFormProps struct {
+	action           string
+	showVersion      bool
+	submitText       string
+	values           url.Values
+	validationErrors validate.ValidationErrors
+	isSubmission     bool
+}
+
+func stockBOMForm(p *stockBOMFormProps) g.Node {
+
+	fieldError := func(key, label string) g.Node {
+		return stockBOMFieldError(p.validationErrors, p.isSubmission, key, label)
+	}
+
+	return h.Form(
+		h.Method("POST"),
+		h.Class("form"),
+		h.Action(p.action),
+
+		g.If(
+			p.showVersion,
+			h.Div(
+				h.Label(
+					g.Text("Version"),
+					h.Input(
+						h.Type("text"),
+						h.Name("Version"),
+						h.Value(p.values.Get("Version")),
+						h.Placeholder("Enter version, e.g. A"),
+						h.AutoComplete("off"),
+					),
+				),
+				fieldError("Version", "Version"),
+			),
+		),
+
+		h.Div(
+			h.Label(
+				g.Text("Effective From"),
+				h.Input(
+					h.Type("date"),
+					h.Name("EffectiveFrom"),
+					h.Value(p.values.Get("EffectiveFrom")),
+				),
+			),
+			fieldError("EffectiveFrom", "Effective From"),
+		),
+
+		h.Div(
+			h.Label(
+				g.Text("Effective To (optional, the first day it is no longer used)"),
+				h.Input(
+					h.Type("date"),
+					h.Name("EffectiveTo"),
+					h.Value(p.values.Get("EffectiveTo")),
+				),
+			),
+			fieldError("EffectiveTo", "Effective To"),
+		),
+
+		h.Div(
+			h.Label(
+				g.Text("Note (optional)"),
+				h.Textarea(
+					h.Name("Note"),
+					h.Placeholder("Enter note"),
+					h.AutoComplete("off"),
+					g.Text(p.values.Get("Note")),
+				),
+			),
+		),
+
+		components.Button(
+			&components.ButtonProps{
+				ButtonType: "Primary",
+			},
+			h.Type("submit"),
+			g.Text(p.submitText),
+		),
+	)
+}
+
+func stockBOMLinesTable(bom *model.StockBOM, lines []model.StockBOMLine, canRemove bool) g.Node {
+
+	if len(lines) == 0 {
+		return h.P(g.Text("No components, add them below."))
+	}
+
+	columns := components.TableColumns{
+		{TitleContents: g.Text("Component")},
+		{TitleContents: g.Text("Description")},
+		{TitleContents: g.Text("Qty Per")},
+		{TitleContents: g.Text("Scrap Factor")},
+	}
+	if canRemove {
+		columns = append(columns, components.TableColumn{TitleContents: g.Text("")})
+	}
+
+	var rows components.TableRows
+	for _, l := range lines {
+
+		cells := []components.TableCell{
+			{Contents: components.StockItemAnchor(l.ComponentStockCode)},
+			{Contents: g.Text(l.ComponentDescription)},
+			{Contents: g.Textf("%s %s", format.DecimalWithCommas(l.QtyPer.String()), l.Unit)},
+			{Contents: g.Text(l.ScrapFactor.String())},
+		}
+
+		if canRemove {
+			cells = append(cells, components.TableCell{
+				Contents: h.Form(
+					h.Method("POST"),
+					h.Action(fmt.Sprintf(
+						"/stock-items/%d/boms/%d/lines/%d/delete",
+						bom.StockItemID,
+						bom.StockBOMID,
+						l.StockBOMLineID,
+					)),
+					h.Button(
+						h.Class("button secondary small"),
+						h.Type("submit"),
+						g.Text("Remove"),
+					),
+				),
+			})
+		}
+
+		rows = append(rows, components.TableRow{
+			Cells: cells,
+		})
+	}
+
+	return components.Table(&components.TableProps{
+		Columns: columns,
+		Rows:    rows,
+	})
+}
+
+type addStockBOMLineFormProps struct {
+	stockBOM         model.StockBOM
+	stockItems       []model.StockItem
+	values           url.Values
+	validationErrors validate.ValidationErrors
+	isSubmission     bool
+}
+
+func addStockBOMLineForm(p *addStockBOMLineFormProps) g.Node {
+
+	fieldError := func(key, label string) g.Node {
+		return stockBOMFieldError(p.validationErrors, p.isSubmission, key, label)
+	}
+
+	selected := p.values.Get("ComponentStockItemID")
+	options := make([]components.SearchSelectOption, len(p.stockItems))
+	for i, si := range p.stockItems {
+		value := fmt.Sprintf("%d", si.StockItemID)
+		options[i] = components.SearchSelectOption{
+			Text:     si.StockCode,
+			Value:    value,
+			Selected: value == selected,
+		}
+	}
+
+	return h.Form(
+		h.Method("POST"),
+		h.Class("form"),
+		h.Action(fmt.Sprintf(
+			"/stock-items/%d/boms/%d/lines", p.stockBOM.StockItemID, p.stockBOM.StockBOMID,
+		)),
+
+		h.Div(
+			h.Label(
+				g.Text("Component"),
+				components.SearchSelect(&components.SearchSelectProps{
+					Name:                 "ComponentStockItemID",
+					Placeholder:          "Select Stock Code",
+					Mode:                 "single",
+					Options:              options,
+					Selected:             selected,
+					OptionsEndpoint:      "/get-stock-codes",
+					SearchQueryParamName: "SearchText",
+				}),
+			),
+			fieldError("ComponentStockItemID", "Component"),
+		),
+
+		h.Div(
+			h.Label(
+				g.Text("Qty Per (component base units per base unit made)"),
+				h.Input(
+					h.Type("number"),
+					h.Min("0"),
+					h.Step("any"),
+					h.Name("QtyPer"),
+					h.Value(p.values.Get("QtyPer")),
+					h.Placeholder("Enter quantity per"),
+					h.AutoComplete("off"),
+				),
+			),
+			fieldError("QtyPer", "Qty Per"),
+		),
+
+		h.Div(
+			h.Label(
+				g.Text("Scrap Factor (optional, e.g. 0.05 for 5% extra)"),
+				h.Input(
+					h.Type("number"),
+					h.Min("0"),
+					h.Step("any"),
+					h.Name("ScrapFactor"),
+					h.Value(p.values.Get("ScrapFactor")),
+					h.Placeholder("0"),
+					h.AutoComplete("off"),
+				),
+			),
+			fieldError("ScrapFactor", "Scrap Factor"),
+		),
+
+		components.Button(
+			&components.ButtonProps{},
+			h.Type("submit"),
+			g.Text("Add Component"),
+		),
+	)
+}
@@ -5,8 +5,11 @@ import (
 	"app/internal/layout"
 	"app/internal/model"
 	"app/pkg/appsort"
+	"app/pkg/format"
 	"app/pkg/reqcontext"
 	"fmt"
+	"strings"
+	"time"
 
 	g "maragu.dev/gomponents"
 	h "maragu.dev/gomponents/html"
@@ -16,6 +19,9 @@ type StockItemPageProps struct {
 	Id                      int
 	Ctx                     reqcontext.ReqContext
 	StockItem               model.StockItem
+	StockItemUnits          []model.StockItemUnit
+	StockBOMs               []model.StockBOM
+	WhereUsed               []model.StockBOMWhereUsed
 	QRCode                  string
 	GalleryImageURLs        []string
 	GalleryURL              string
@@ -38,13 +44,17 @@ func StockItemPage(p *StockItemPageProps) g.Node {
 
 			h.H3(g.Text(p.StockItem.StockCode)),
 
-			stockItemActions(p.StockItem.StockItemID, canUserEdit),
+			stockItemActions(
+				p.StockItem.StockItemID,
+				canUserEdit,
+				model.CanPrintStockLabels(p.Ctx.User.Permissions),
+			),
 		),
 
 		h.Div(
 			h.Class("two-column-flex"),
 
-			stockItemProperties(p.StockItem),
+			stockItemProperties(p.StockItem, p.StockItemUnits),
 
 			h.Div(
 				h.Class("gallery-container"),
@@ -64,6 +74,14 @@ func StockItemPage(p *StockItemPageProps) g.Node {
 			),
 		),
 
+		h.Div(
+			h.Class("two-column-flex"),
+
+			stockItemBOMs(p.StockItem.StockItemID, p.StockBOMs, canUserEdit),
+
+			stockItemWhereUsed(p.WhereUsed),
+		),
+
 		h.Div(
 			h.Class("two-column-flex"),
 
@@ -97,9 +115,19 @@ func StockItemPage(p *StockItemPageProps) g.Node {
 	})
 }
 
-func stockItemActions(stockItemID int, userCanEdit bool) g.Node {
+func stockItemActions(stockItemID int, userCanEdit bool, userCanPrintLabels bool) g.Node {
 	return h.Div(
 		h.Class("actions"),
+		g.If(userCanPrintLabels,
+			h.A(
+				h.Class("button secondary"),
+				h.Href(fmt.Sprintf("/stock-items/%d/labels", stockItemID)),
+				h.Title("Print labels"),
+				components.Icon(&components.IconProps{
+					Identifier: "printer",
+				}),
+			),
+		),
 		g.If(userCanEdit,
 			h.A(
 				h.Class("button primary"),
@@ -112,7 +140,27 @@ func stockItemActions(stockItemID int, userCanEdit bool) g.Node {
 	)
 }
 
-func stockItemProperties(si model.StockItem) g.Node {
+func stockItemProperties(si model.StockItem, units []model.StockItemUnit) g.Node {
+
+	alternateUnits := make([]string, len(units))
+	for i, u := range units {
+		alternateUnits[i] = fmt.Sprintf(
+			"%s = %s %s",
+			u.Unit,
+			format.DecimalWithCommas(u.ConversionFactor.String()),
+			si.BaseUnit,
+		)
+	}
+	alternateUnitsValue := strings.Join(alternateUnits, ", ")
+	if alternateUnitsValue == "" {
+		alternateUnitsValue = "\u2013"
+	}
+
+	isSerialisedValue := "No"
+	if si.IsSerialised {
+		isSerialisedValue = "Yes"
+	}
+
 	return h.Div(
 		h.Class("properties"),
 
@@ -122,6 +170,10 @@ func stockItemProperties(si model.StockItem) g.Node {
 		}{
 			{"Stock Code", si.StockCode},
 			{"Description", si.Description},
+			{"Base Unit", si.BaseUnit},
+			{"Alternate Units", alternateUnitsValue},
+			{"Serialised", isSerialisedValue},
+			{"Costing Method", string(si.CostingMethod)},
 		}, func(i struct {
 			label string
 			value string
@@ -135,9 +187,117 @@ func stockItemProperties(si model.StockItem) g.Node {
 	)
 }
 
+// stockItemBOMs lists the versions of the bill of materials of the stock item,
+// marking the one in effect today
+func stockItemBOMs(stockItemID int, boms []model.StockBOM, userCanEdit bool) g.Node {
+
+	now := time.Now()
+
+	columns := components.TableColumns{
+		{TitleContents: g.Text("Version")},
+		{TitleContents: g.Text("Effective")},
+		{TitleContents: g.Text("Components")},
+	}
+
+	var rows components.TableRows
+	for _, b := range boms {
+		bomHref := fmt.Sprintf("/stock-items/%d/boms/%d", stockItemID, b.StockBOMID)
+
+		rows = append(rows, components.TableRow{
+			Cells: []components.TableCell{
+				{Contents: g.Group([]g.Node{
+					h.A(h.Href(bomHref), g.Text(b.Version)),
+					g.If(b.IsEffective(now), g.Group([]g.Node{
+						g.Text(" "),
+						components.Badge(&components.BadgeProps{
+							Type: components.BadgeSuccess,
+							Size: components.BadgeSm,
+						}, g.Text("In effect")),
+					})),
+				})},
+				{Contents: g.Text(stockBOMEffectiveText(b.EffectiveFrom, b.EffectiveTo))},
+				{Contents: g.Textf("%d", b.LineCount)},
+			},
+			HREF: bomHref,
+		})
+	}
+
+	return h.Div(
+		h.H3(g.Text("Bills of Materials")),
+
+		g.If(
+			len(boms) == 0,
+			h.P(g.Text("No bill of materials.")),
+		),
+		g.If(
+			len(boms) > 0,
+			components.Table(&components.TableProps{
+				Columns: columns,
+				Rows:    rows,
+			}),
+		),
+
+		g.If(userCanEdit,
+			h.A(
+				h.Class("button primary"),
+				h.Href(fmt.Sprintf("/stock-items/%d/boms/add", stockItemID)),
+				g.Text("Add BOM Version"),
+			),
+		),
+	)
+}
+
+// stockItemWhereUsed lists every bill of materials the stock item is a
+// component of
+func stockItemWhereUsed(whereUsed []model.StockBOMWhereUsed) g.Node {
+
+	columns := components.TableColumns{
+		{TitleContents: g.Text("Stock Code")},
+		{TitleContents: g.Text("Version")},
+		{TitleContents: g.Text("Effective")},
+		{TitleContents: g.Text("Qty Per")},
+		{TitleContents: g.Text("Scrap Factor")},
+	}
+
+	var rows components.TableRows
+	for _, w := range whereUsed {
+		bomHref := fmt.Sprintf("/stock-items/%d/boms/%d", w.StockItemID, w.StockBOMID)
+
+		rows = append(rows, components.TableRow{
+			Cells: []components.TableCell{
+				{Contents: g.Text(w.StockCode)},
+				{Contents: h.A(h.Href(bomHref), g.Text(w.Version))},
+				{Contents: g.Text(stockBOMEffectiveText(w.EffectiveFrom, w.EffectiveTo))},
+				{Contents: g.Textf("%s %s", format.DecimalWithCommas(w.QtyPer.String()), w.Unit)},
+				{Contents: g.Text(w.ScrapFactor.String())},
+			},
+			HREF: bomHref,
+		})
+	}
+
+	return h.Div(
+		h.H3(g.Text("Where Used")),
+
+		g.If(
+			len(whereUsed) == 0,
+			h.P(g.Text("Not a component of any bill of materials.")),
+		),
+		g.If(
+			len(whereUsed) > 0,
+			components.Table(&components.TableProps{
+				Columns: columns,
+				Rows:    rows,
+			}),
+		),
+	)
+}
+
 var changelogFieldDefs = []components.ChangelogProperty{
 	{FieldKey: "StockCode", Label: g.Text("Stock Code")},
 	{FieldKey: "Description", Label: g.Text("Description")},
+	{FieldKey: "BaseUnit", Label: g.Text("Base Unit")},
+	{FieldKey: "IsSerialised", Label: g.Text("Serialised")},
+	{FieldKey: "CostingMethod", Label: g.Text("Costing Method")},
 }
 
 func stockItemChangeLog(changes []model.StockItemChange) g.Node {
@@ -149,8 +309,11 @@ func stockItemChangeLog(changes []model.StockItemChange) g.Node {
 			ChangeByUsername: change.ChangeByUsername,
 			IsCreation:       change.IsCreation,
 			Changes: map[string]any{
-				"StockCode":   change.StockCode,
-				"Description": change.Description,
+				"StockCode":     change.StockCode,
+				"Description":   change.Description,
+				"BaseUnit":      change.BaseUnit,
+				"IsSerialised":  change.IsSerialised,
+				"CostingMethod": change.CostingMethod,
 			},
 		}
 		changelogEntries = append(changelogEntries, entry)
@@ -0,0 +1,204 @@
+package stockitemview
+
+import (
+	"app/internal/components"
+	"app/internal/layout"
+	"app/internal/model"
+	"app/internal/pdftemplate"
+	"app/pkg/reqcontext"
+	"fmt"
+
+	g "maragu.dev/gomponents"
+	h "maragu.dev/gomponents/html"
+)
+
+type StockLabelsPageProps struct {
+	Ctx               reqcontext.ReqContext
+	StockItem         model.StockItem
+	PrintRequirements []model.PrintRequirement
+	SuccessText       string
+	ErrorText         string
+
+	// Form state
+	Values model.LabelGenerator
+}
+
+func StockLabelsPage(p *StockLabelsPageProps) g.Node {
+
+	v := p.Values
+
+	content := h.Div(
+		h.Class("stock-labels"),
+
+		h.H3(g.Textf("%s Labels", p.StockItem.StockCode)),
+
+		h.P(g.Textf(`Labels show the stock code and description, %s, with
+			the lot and quantity when given. Each label has a barcode of the
+			stock code and one of the lot, so both can be scanned. They are
+			sent to the printer assigned to the print requirement chosen.`, p.StockItem.Description)),
+
+		g.If(
+			len(p.PrintRequirements) == 0,
+			h.P(g.Text(`NOTE: there are no print requirements. A printing admin
+				needs to assign a printer to one before labels can be printed.`)),
+		),
+
+		h.Form(
+			h.Method("POST"),
+			h.Class("form"),
+			h.Action(fmt.Sprintf("/stock-items/%d/labels", p.StockItem.StockItemID)),
+
+			h.Div(
+				h.Label(
+					g.Text("Lot Number (optional)"),
+					h.Input(
+						h.Type("text"),
+						h.Name("LotNumber"),
+						h.Value(v.LotNumber),
+						h.Placeholder("Enter lot number"),
+						h.AutoComplete("off"),
+					),
+				),
+			),
+
+			h.Div(
+				h.Label(
+					g.Text("Qty (optional)"),
+					h.Input(
+						h.Type("number"),
+						h.Min("0"),
+						h.Step("any"),
+						h.Name("Qty"),
+						g.If(v.Qty != nil, h.Value(v.Qty.String())),
+						h.Placeholder("Enter quantity on each label"),
+						h.AutoComplete("off"),
+					),
+				),
+			),
+
+			h.Div(
+				h.Label(
+					g.Textf("Unit (optional, defaults to %s)", p.StockItem.BaseUnit),
+					h.Input(
+						h.Type("text"),
+						h.Name("Unit"),
+						h.Value(v.Unit),
+						h.Placeholder("Enter unit"),
+						h.AutoComplete("off"),
+					),
+				),
+			),
+
+			h.Div(
+				h.Label(
+					g.Text("Number of Labels"),
+					h.Input(
+						h.Type("number"),
+						h.Min("1"),
+						h.Max("500"),
+						h.Name("LabelCount"),
+						h.Value(fmt.Sprintf("%d", v.LabelCount)),
+					),
+				),
+			),
+
+			h.Div(
+				h.Label(
+					g.Text("Label Size (mm)"),
+					h.Select(
+						h.Name("LabelSize"),
+						g.Group(g.Map(pdftemplate.StockLabelSizes, func(s pdftemplate.StockLabelSize) g.Node {
+							return h.Option(
+								h.Value(s.Name),
+								g.Text(s.Name),
+								g.If(v.LabelSize == s.Name, h.Selected()),
+							)
+						})),
+					),
+				),
+			),
+
+			h.Div(
+				h.Label(
+					g.Text("Barcode"),
+					h.Select(
+						h.Name("Barcode"),
+						g.Group(g.Map(pdftemplate.StockLabelBarcodes, func(b pdftemplate.StockLabelBarcode) g.Node {
+							return h.Option(
+								h.Value(string(b)),
+								g.Text(string(b)),
+								g.If(v.Barcode == string(b), h.Selected()),
+							)
+						})),
+					),
+				),
+			),
+
+			h.Div(
+				h.Label(
+					g.Text("Print Requirement"),
+					h.Select(
+						h.Name("RequirementName"),
+						h.Option(h.Value(""), g.Text("Select print requirement")),
+						g.Group(g.Map(p.PrintRequirements, func(pr model.PrintRequirement) g.Node {
+							return h.Option(
+								h.Value(pr.RequirementName),
+								g.Textf("%s (%s)", pr.RequirementName, pr.PrinterName),
+								g.If(v.RequirementName == pr.RequirementName, h.Selected()),
+							)
+						})),
+					),
+				),
+			),
+
+			components.Button(
+				&components.ButtonProps{
+					ButtonType: "Primary",
+				},
+				h.Type("submit"),
+				g.Text("Print Labels"),
+			),
+		),
+
+		g.If(
+			p.ErrorText != "",
+			h.Div(
+				h.Class("error-msg"),
+				g.Text(p.ErrorText),
+			),
+		),
+
+		g.If(
+			p.SuccessText != "",
+			h.Div(
+				h.Class("success-msg"),
+				g.Text(p.SuccessText),
+			),
+		),
+	)
+
+	return layout.Page(layout.PageProps{
+		Ctx:   p.Ctx,
+		Title: fmt.Sprintf("%s Labels", p.StockItem.StockCode),
+		Breadcrumbs: []layout.Breadcrumb{
+			layout.HomeBreadcrumb,
+			{
+				IconIdentifier: "package-variant-closed",
+				Title:          "Stock Items",
+				URL:            "/stock-items",
+			},
+			{
+				Title: p.StockItem.StockCode,
+				URL:   fmt.Sprintf("/stock-items/%d", p.StockItem.StockItemID),
+			},
+			{
+				IconIdentifier: "printer",
+				Title:          "Labels",
+			},
+		},
+		Content: content,
+		AppendHead: []g.Node{
+			components.InlineStyle("/internal/views/stockitemview/stock_labels_page.css"),
+		},
+	})
+}
@@ -0,0 +1,145 @@
+package stockview
+
+import (
+	"app/internal/components"
+	"app/internal/layout"
+	"app/internal/model"
+	"app/pkg/reqcontext"
+	"app/pkg/validate"
+	"net/url"
+	"strconv"
+
+	g "maragu.dev/gomponents"
+	h "maragu.dev/gomponents/html"
+)
+
+type AddPurchaseOrderPageProps struct {
+	Ctx              reqcontext.ReqContext
+	Suppliers        []model.Supplier
+	Values           url.Values
+	ValidationErrors validate.ValidationErrors
+	IsSubmission     bool
+}
+
+func AddPurchaseOrderPage(p *AddPurchaseOrderPageProps) g.Node {
+
+	content := g.Group([]g.Node{
+		h.Div(
+			h.Class("add-stock-document-page"),
+			addPurchaseOrderForm(p),
+		),
+	})
+
+	return layout.Page(layout.PageProps{
+		Ctx:     p.Ctx,
+		Title:   "Add Purchase Order",
+		Header:  &layout.PageHeaderProps{},
+		Content: content,
+		Breadcrumbs: []layout.Breadcrumb{
+			layout.HomeBreadcrumb,
+			{
+				IconIdentifier: "package-variant-closed",
+				Title:          "Stock",
+				URLPart:        "stock",
+			},
+			{
+				Title:   "Purchase Orders",
+				URLPart: "purchase-orders",
+			},
+			{
+				IconIdentifier: "plus",
+				Title:          "Add",
+			},
+		},
+		AppendHead: []g.Node{
+			components.InlineStyle("/internal/views/stockview/stock_document_page.css"),
+		},
+	})
+}
+
+func addPurchaseOrderForm(p *AddPurchaseOrderPageProps) g.Node {
+
+	fieldError := func(key, label string) g.Node {
+		if !p.IsSubmission {
+			return nil
+		}
+		errorText := p.ValidationErrors.GetError(key, label)
+		if errorText == "" {
+			return nil
+		}
+		return components.InputHelper(&components.InputHelperProps{
+			Label: errorText,
+			Type:  components.InputHelperTypeError,
+		})
+	}
+
+	supplierValue := p.Values.Get("SupplierID")
+
+	return h.Form(
+		h.Method("POST"),
+		h.Class("form"),
+
+		h.Div(
+			h.Label(
+				g.Text("Supplier"),
+				h.Select(
+					h.Name("SupplierID"),
+					h.Class("select"),
+					h.Option(h.Value(""), g.Text("Select supplier")),
+					g.Group(g.Map(p.Suppliers, func(s model.Supplier) g.Node {
+						value := strconv.Itoa(s.SupplierID)
+						return h.Option(
+							h.Value(value),
+							g.Textf("%s \u2013 %s", s.Code, s.Name),
+							g.If(supplierValue == value, h.Selected()),
+						)
+					})),
+				),
+			),
+			fieldError("SupplierID", "Supplier"),
+		),
+
+		h.Div(
+			h.Label(
+				g.Text("Reference"),
+				h.Input(
+					h.Name("Reference"),
+					h.Placeholder("Enter purchase order number"),
+					h.Value(p.Values.Get("Reference")),
+					h.AutoComplete("off"),
+				),
+			),
+			fieldError("Reference", "Reference"),
+		),
+
+		h.Div(
+			h.Label(
+				g.Text("Order Date"),
+				h.Input(
+					h.Type("date"),
+					h.Name("OrderDate"),
+					h.Value(p.Values.Get("OrderDate")),
+					h.AutoComplete("off"),
+				),
+			),
+			fieldError("OrderDate", "Order Date"),
+		),
+
+		h.Div(
+			h.Label(
+				g.Text("Note (optional)"),
+				h.Textarea(
+					h.Name("Note"),
+					h.Placeholder("Enter note"),
+					g.Text(p.Values.Get("Note")),
+				),
+			),
+		),
+
+		h.Button(
+			h.Class("button primary"),
+			h.Type("submit"),
+			g.Text("Create Purchase Order"),
+		),
+	)
+}
@@ -0,0 +1,157 @@
+package stockview
+
+import (
+	"app/internal/components"
+	"app/internal/layout"
+	"app/internal/model"
+	"app/pkg/reqcontext"
+	"app/pkg/validate"
+	"net/url"
+	"strconv"
+
+	g "maragu.dev/gomponents"
+	h "maragu.dev/gomponents/html"
+)
+
+type AddSalesOrderPageProps struct {
+	Ctx              reqcontext.ReqContext
+	Customers        []model.Customer
+	Values           url.Values
+	ValidationErrors validate.ValidationErrors
+	IsSubmission     bool
+}
+
+func AddSalesOrderPage(p *AddSalesOrderPageProps) g.Node {
+
+	content := g.Group([]g.Node{
+		h.Div(
+			h.Class("add-stock-document-page"),
+			addSalesOrderForm(p),
+		),
+	})
+
+	return layout.Page(layout.PageProps{
+		Ctx:     p.Ctx,
+		Title:   "Add Sales Order",
+		Header:  &layout.PageHeaderProps{},
+		Content: content,
+		Breadcrumbs: []layout.Breadcrumb{
+			layout.HomeBreadcrumb,
+			{
+				IconIdentifier: "package-variant-closed",
+				Title:          "Stock",
+				URLPart:        "stock",
+			},
+			{
+				Title:   "Sales Orders",
+				URLPart: "sales-orders",
+			},
+			{
+				IconIdentifier: "plus",
+				Title:          "Add",
+			},
+		},
+		AppendHead: []g.Node{
+			components.InlineStyle("/internal/views/stockview/stock_document_page.css"),
+		},
+	})
+}
+
+func addSalesOrderForm(p *AddSalesOrderPageProps) g.Node {
+
+	fieldError := func(key, label string) g.Node {
+		if !p.IsSubmission {
+			return nil
+		}
+		errorText := p.ValidationErrors.GetError(key, label)
+		if errorText == "" {
+			return nil
+		}
+		return components.InputHelper(&components.InputHelperProps{
+			Label: errorText,
+			Type:  components.InputHelperTypeError,
+		})
+	}
+
+	customerValue := p.Values.Get("CustomerID")
+
+	return h.Form(
+		h.Method("POST"),
+		h.Class("form"),
+
+		h.Div(
+			h.Label(
+				g.Text("Customer"),
+				h.Select(
+					h.Name("CustomerID"),
+					h.Class("select"),
+					h.Option(h.Value(""), g.Text("Select customer")),
+					g.Group(g.Map(p.Customers, func(cu model.Customer) g.Node {
+						value := strconv.Itoa(cu.CustomerID)
+						return h.Option(
+							h.Value(value),
+							g.Textf("%s \u2013 %s", cu.Code, cu.Name),
+							g.If(customerValue == value, h.Selected()),
+						)
+					})),
+				),
+			),
+			fieldError("CustomerID", "Customer"),
+		),
+
+		h.Div(
+			h.Label(
+				g.Text("Reference"),
+				h.Input(
+					h.Name("Reference"),
+					h.Placeholder("Enter sales order number"),
+					h.Value(p.Values.Get("Reference")),
+					h.AutoComplete("off"),
+				),
+			),
+			fieldError("Reference", "Reference"),
+		),
+
+		h.Div(
+			h.Label(
+				g.Text("Customer Reference (optional)"),
+				h.Input(
+					h.Name("CustomerReference"),
+					h.Placeholder("Enter the customer's order number"),
+					h.Value(p.Values.Get("CustomerReference")),
+					h.AutoComplete("off"),
+				),
+			),
+		),
+
+		h.Div(
+			h.Label(
+				g.Text("Order Date"),
+				h.Input(
+					h.Type("date"),
+					h.Name("OrderDate"),
+					h.Value(p.Values.Get("OrderDate")),
+					h.AutoComplete("off"),
+				),
+			),
+			fieldError("OrderDate", "Order Date"),
+		),
+
+		h.Div(
+			h.Label(
+				g.Text("Note (optional)"),
+				h.Textarea(
+					h.Name("Note"),
+					h.Placeholder("Enter note"),
+					g.Text(p.Values.Get("Note")),
+				),
+			),
+		),
+
+		h.Button(
+			h.Class("button primary"),
+			h.Type("submit"),
+			g.Text("Create Sales Order"),
+		),
+	)
+}
@@ -0,0 +1,170 @@
+package stockview
+
+import (
+	"app/internal/components"
+	"app/internal/layout"
+	"app/internal/model"
+	"app/pkg/reqcontext"
+	"app/pkg/validate"
+	"net/url"
+	"slices"
+
+	g "maragu.dev/gomponents"
+	h "maragu.dev/gomponents/html"
+)
+
+type AddStockCountPageProps struct {
+	Ctx              reqcontext.ReqContext
+	Values           url.Values
+	StockItems       []model.StockItem
+	ValidationErrors validate.ValidationErrors
+	IsSubmission     bool
+}
+
+func AddStockCountPage(p *AddStockCountPageProps) g.Node {
+
+	content := g.Group([]g.Node{
+		h.Div(
+			h.Class("add-stock-count-page"),
+			addStockCountForm(&addStockCountFormProps{
+				values:           p.Values,
+				stockItems:       p.StockItems,
+				validationErrors: p.ValidationErrors,
+				isSubmission:     p.IsSubmission,
+			}),
+		),
+	})
+
+	return layout.Page(layout.PageProps{
+		Ctx:     p.Ctx,
+		Title:   "Add Stock Count",
+		Header:  &layout.PageHeaderProps{},
+		Content: content,
+		Breadcrumbs: []layout.Breadcrumb{
+			layout.HomeBreadcrumb,
+			{
+				IconIdentifier: "package-variant-closed",
+				Title:          "Stock",
+				URLPart:        "stock",
+			},
+			{
+				Title:   "Counts",
+				URLPart: "counts",
+			},
+			{
+				IconIdentifier: "plus",
+				Title:          "Add",
+			},
+		},
+		AppendHead: []g.Node{
+			components.InlineStyle("/internal/views/stockview/stock_count_page.css"),
+		},
+	})
+}
+
+type addStockCountFormProps struct {
+	values           url.Values
+	stockItems       []model.StockItem
+	validationErrors validate.ValidationErrors
+	isSubmission     bool
+}
+
+func addStockCountForm(p *addStockCountFormProps) g.Node {
+
+	fieldError := func(key, label string) g.Node {
+		if !p.isSubmission {
+			return nil
+		}
+		errorText := p.validationErrors.GetError(key, label)
+		if errorText == "" {
+			return nil
+		}
+		return components.InputHelper(&components.InputHelperProps{
+			Label: errorText,
+			Type:  components.InputHelperTypeError,
+		})
+	}
+
+	textInput := func(key, label, placeholder string) g.Node {
+		return h.Div(
+			h.Label(
+				g.Text(label),
+				h.Input(
+					h.Type("text"),
+					h.Name(key),
+					h.Value(p.values.Get(key)),
+					h.Placeholder(placeholder),
+					h.AutoComplete("off"),
+				),
+			),
+			fieldError(key, label),
+		)
+	}
+
+	selectedStockItemIDs := p.values["StockItemIDs"]
+	stockItemOptions := make([]components.SearchSelectOption, len(p.stockItems))
+	for i, option := range MapStockItemsToOptions(p.stockItems, "") {
+		option.Selected = slices.Contains(selectedStockItemIDs, option.Value)
+		stockItemOptions[i] = option
+	}
+
+	return h.Form(
+		h.Method("POST"),
+		h.Class("form"),
+
+		textInput("Reference", "Reference", "Enter reference, e.g. CC-2024-01"),
+		textInput("Locations", "Locations (optional, comma separated)", "Leave empty to count all locations"),
+		textInput("Bins", "Bins (optional, comma separated)", "Leave empty to count all bins"),
+
+		h.Div(
+			h.Label(
+				g.Text("Stock Codes (optional)"),
+				components.SearchSelect(&components.SearchSelectProps{
+					Name:        "StockItemIDs",
+					Placeholder: "Leave empty to count all stock codes",
+					Mode:        "multi",
+					Options:     stockItemOptions,
+				}),
+			),
+			fieldError("StockItemIDs", "Stock Codes"),
+		),
+
+		h.Div(
+			h.Label(
+				h.Class("checkbox"),
+				h.Input(
+					h.Type("checkbox"),
+					h.Name("IsBlind"),
+					h.Value("true"),
+					g.If(p.values.Get("IsBlind") == "true", h.Checked()),
+				),
+				g.Text("Blind count (hide expected quantities from counters)"),
+			),
+		),
+
+		h.Div(
+			h.Label(
+				g.Text("Note (optional)"),
+				h.Textarea(
+					h.Name("Note"),
+					h.Placeholder("Enter note"),
+					g.Text(p.values.Get("Note")),
+				),
+			),
+		),
+
+		h.P(
+			h.Class("add-stock-count-info"),
+			g.Text(`Expected quantities are frozen from current stock levels when
+				the count is created. Variances are posted as of that time when the
+				count is approved. Serialised stock items are not counted, as their
+				variances must be adjusted with serial numbers.`),
+		),
+
+		h.Button(
+			h.Class("button primary"),
+			h.Type("submit"),
+			g.Text("Create Count"),
+		),
+	)
+}
@@ -0,0 +1,181 @@
+package stockview
+
+import (
+	"app/internal/components"
+	"app/internal/layout"
+	"app/internal/model"
+	"app/pkg/reqcontext"
+	"app/pkg/validate"
+	"net/url"
+
+	g "maragu.dev/gomponents"
+	h "maragu.dev/gomponents/html"
+)
+
+type AddStockDocumentPageProps struct {
+	Ctx              reqcontext.ReqContext
+	Values           url.Values
+	ValidationErrors validate.ValidationErrors
+	IsSubmission     bool
+}
+
+func AddStockDocumentPage(p *AddStockDocumentPageProps) g.Node {
+
+	content := g.Group([]g.Node{
+		h.Div(
+			h.Class("add-stock-document-page"),
+			addStockDocumentForm(&addStockDocumentFormProps{
+				values:           p.Values,
+				validationErrors: p.ValidationErrors,
+				isSubmission:     p.IsSubmission,
+			}),
+		),
+	})
+
+	return layout.Page(layout.PageProps{
+		Ctx:     p.Ctx,
+		Title:   "Add Stock Document",
+		Header:  &layout.PageHeaderProps{},
+		Content: content,
+		Breadcrumbs: []layout.Breadcrumb{
+			layout.HomeBreadcrumb,
+			{
+				IconIdentifier: "package-variant-closed",
+				Title:          "Stock",
+				URLPart:        "stock",
+			},
+			{
+				Title:   "Documents",
+				URLPart: "documents",
+			},
+			{
+				IconIdentifier: "plus",
+				Title:          "Add",
+			},
+		},
+		AppendHead: []g.Node{
+			components.InlineStyle("/internal/views/stockview/stock_document_page.css"),
+		},
+	})
+}
+
+type addStockDocumentFormProps struct {
+	values           url.Values
+	validationErrors validate.ValidationErrors
+	isSubmission     bool
+}
+
+func addStockDocumentForm(p *addStockDocumentFormProps) g.Node {
+
+	documentTypeLabel := "Document Type"
+	documentTypeKey := "DocumentType"
+	documentTypeValue := p.values.Get(documentTypeKey)
+	documentTypeError := ""
+	if p.isSubmission {
+		documentTypeError = p.validationErrors.GetError(documentTypeKey, documentTypeLabel)
+	}
+
+	referenceLabel := "Reference"
+	referenceKey := "Reference"
+	referenceValue := p.values.Get(referenceKey)
+	referenceError := ""
+	if p.isSubmission || referenceValue != "" {
+		referenceError = p.validationErrors.GetError(referenceKey, referenceLabel)
+	}
+
+	documentDateLabel := "Document Date"
+	documentDateKey := "DocumentDate"
+	documentDateValue := p.values.Get(documentDateKey)
+	documentDateError := ""
+	if p.isSubmission || documentDateValue != "" {
+		documentDateError = p.validationErrors.GetError(documentDateKey, documentDateLabel)
+	}
+
+	noteLabel := "Note (optional)"
+	noteKey := "Note"
+	noteValue := p.values.Get(noteKey)
+
+	return h.Form(
+		h.Method("POST"),
+		h.Class("form"),
+
+		h.Div(
+			h.Label(
+				g.Text(documentTypeLabel),
+				h.Select(
+					h.Name(documentTypeKey),
+					h.Class("select"),
+					g.Group(g.Map(model.StockDocumentTypes, func(dt model.StockDocumentType) g.Node {
+						return h.Option(
+							h.Value(string(dt)),
+							g.Text(string(dt)),
+							g.If(documentTypeValue == string(dt), h.Selected()),
+						)
+					})),
+				),
+			),
+			g.If(
+				documentTypeError != "",
+				components.InputHelper(&components.InputHelperProps{
+					Label: documentTypeError,
+					Type:  components.InputHelperTypeError,
+				}),
+			),
+		),
+
+		h.Div(
+			h.Label(
+				g.Text(referenceLabel),
+				h.Input(
+					h.Name(referenceKey),
+					h.Placeholder("Enter reference, e.g. delivery note number"),
+					h.Value(referenceValue),
+					h.AutoComplete("off"),
+				),
+			),
+			g.If(
+				referenceError != "",
+				components.InputHelper(&components.InputHelperProps{
+					Label: referenceError,
+					Type:  components.InputHelperTypeError,
+				}),
+			),
+		),
+
+		h.Div(
+			h.Label(
+				g.Text(documentDateLabel),
+				h.Input(
+					h.Type("datetime-local"),
+					h.Name(documentDateKey),
+					h.Value(documentDateValue),
+					h.AutoComplete("off"),
+				),
+			),
+			g.If(
+				documentDateError != "",
+				components.InputHelper(&components.InputHelperProps{
+					Label: documentDateError,
+					Type:  components.InputHelperTypeError,
+				}),
+			),
+		),
+
+		h.Div(
+			h.Label(
+				g.Text(noteLabel),
+				h.Textarea(
+					h.Name(noteKey),
+					h.Placeholder("Enter note"),
+					g.Text(noteValue),
+				),
+			),
+		),
+
+		h.Button(
+			h.Class("button primary"),
+			h.Type("submit"),
+			g.Text("Create Document"),
+		),
+	)
+}
@@ -0,0 +1,140 @@
+package stockview
+
+import (
+	"app/internal/components"
+	"app/internal/layout"
+	"app/internal/model"
+	"app/pkg/nilsafe"
+	"app/pkg/reqcontext"
+	"app/pkg/validate"
+	"fmt"
+	"net/url"
+	"time"
+
+	g "maragu.dev/gomponents"
+	h "maragu.dev/gomponents/html"
+)
+
+type CustomerPageProps struct {
+	Ctx       reqcontext.ReqContext
+	Customer  model.Customer
+	ErrorText string
+
+	// Edit customer form state
+	Values           url.Values
+	ValidationErrors validate.ValidationErrors
+	IsSubmission     bool
+}
+
+func CustomerPage(p *CustomerPageProps) g.Node {
+
+	s := p.Customer
+
+	type attribute struct {
+		label string
+		value g.Node
+	}
+
+	dashIfEmpty := func(v string) string {
+		if v == "" {
+			return "\u2013"
+		}
+		return v
+	}
+
+	createdBy := g.Group([]g.Node{
+		g.Textf("%s on ", nilsafe.Str(s.CreatedByUsername)),
+		h.Span(h.Class("local-datetime"), g.Text(s.CreatedAt.Format(time.RFC3339))),
+	})
+	updatedBy := g.Group([]g.Node{
+		g.Textf("%s on ", nilsafe.Str(s.UpdatedByUsername)),
+		h.Span(h.Class("local-datetime"), g.Text(s.UpdatedAt.Format(time.RFC3339))),
+	})
+
+	salesOrdersParams := url.Values{}
+	salesOrdersParams.Set("CustomerID", fmt.Sprintf("%d", s.CustomerID))
+
+	attributes := []attribute{
+		{label: "Code", value: g.Text(s.Code)},
+		{label: "Name", value: g.Text(s.Name)},
+		{label: "Email", value: g.Text(dashIfEmpty(s.Email))},
+		{label: "Phone", value: g.Text(dashIfEmpty(s.Phone))},
+		{label: "Delivery Address", value: g.Text(dashIfEmpty(s.DeliveryAddress))},
+		{label: "Status", value: archivedBadge(s.IsArchived)},
+		{label: "Created By", value: createdBy},
+		{label: "Updated By", value: updatedBy},
+	}
+
+	content := g.Group([]g.Node{
+		h.Nav(
+			h.Class("stock-nav"),
+			h.A(h.Href("/stock/customers"), g.Text("Customers")),
+			h.A(
+				h.Href("/stock/sales-orders?"+salesOrdersParams.Encode()),
+				g.Text("Sales orders"),
+			),
+			g.If(
+				!s.IsArchived,
+				h.A(
+					h.Href("/stock/sales-orders/add?"+salesOrdersParams.Encode()),
+					g.Text("New sales order"),
+				),
+			),
+		),
+
+		h.Ul(
+			h.Class("attributes-list"),
+
+			g.Group(g.Map(attributes, func(a attribute) g.Node {
+				return h.Li(
+					components.Icon(&components.IconProps{
+						Identifier: "arrow-right-thin",
+					}),
+					h.Strong(g.Textf("%s: ", a.label)),
+					h.Span(a.value),
+				)
+			})),
+		),
+
+		g.If(
+			p.ErrorText != "",
+			h.Div(
+				h.Class("error-msg"),
+				g.Text(p.ErrorText),
+			),
+		),
+
+		h.H3(g.Text("Edit Customer")),
+
+		customerForm(&customerFormProps{
+			action:           fmt.Sprintf("/stock/customers/%d", s.CustomerID),
+			values:           p.Values,
+			validationErrors: p.ValidationErrors,
+			isSubmission:     p.IsSubmission,
+		}),
+	})
+
+	return layout.Page(layout.PageProps{
+		Ctx:     p.Ctx,
+		Title:   fmt.Sprintf("Customer %s", s.Code),
+		Content: content,
+		Breadcrumbs: []layout.Breadcrumb{
+			layout.HomeBreadcrumb,
+			{
+				IconIdentifier: "package-variant-closed",
+				Title:          "Stock",
+				URLPart:        "stock",
+			},
+			{
+				Title:   "Customers",
+				URLPart: "customers",
+			},
+			{
+				Title: s.Code,
+			},
+		},
+		AppendHead: []g.Node{
+			components.InlineStyle("/internal/views/stockview/sales_order_page.css"),
+		},
+	})
+}
@@ -0,0 +1,287 @@
+package stockview
+
+import (
+	"app/internal/components"
+	"app/internal/layout"
+	"app/internal/model"
+	"app/pkg/reqcontext"
+	"app/pkg/validate"
+	"fmt"
+	"net/url"
+
+	g "maragu.dev/gomponents"
+	c "maragu.dev/gomponents/components"
+	h "maragu.dev/gomponents/html"
+)
+
+type CustomersPageProps struct {
+	Ctx            reqcontext.ReqContext
+	Customers      []model.Customer
+	CustomersCount int
+	SearchText     string
+	ShowArchived   bool
+	Page           int
+	PageSize       int
+	ErrorText      string
+
+	// Add customer form state
+	Values           url.Values
+	ValidationErrors validate.ValidationErrors
+	IsSubmission     bool
+}
+
+func CustomersPage(p *CustomersPageProps) g.Node {
+
+	content := g.Group([]g.Node{
+		h.FormEl(
+			h.Method("GET"),
+
+			h.Nav(
+				h.Class("stock-nav"),
+				h.A(h.Href("/stock"), g.Text("Stock levels")),
+				h.A(h.Href("/stock/sales-orders"), g.Text("Sales orders")),
+			),
+
+			h.H3(g.Text("Customers")),
+
+			h.Div(
+				h.Class("stock-levels-filters"),
+
+				h.Label(
+					h.Class("filter"),
+					g.Text("Search"),
+					h.Input(
+						h.Class("lg"),
+						h.Name("SearchText"),
+						h.Value(p.SearchText),
+						h.AutoComplete("off"),
+						h.Placeholder("Code or name"),
+					),
+				),
+
+				h.Label(
+					h.Class("checkbox"),
+					h.Input(
+						h.Type("checkbox"),
+						h.Name("ShowArchived"),
+						h.Value("true"),
+						g.If(p.ShowArchived, h.Checked()),
+					),
+					g.Text("Show archived"),
+				),
+
+				h.Div(
+					h.Class("go-button-wrapper"),
+					components.Button(&components.ButtonProps{
+						ButtonType: components.ButtonPrimary,
+						Classes:    c.Classes{"go-button": true},
+						Size:       components.ButtonLg,
+					},
+						h.Type("button"),
+						g.Attr("onclick", "submitTableForm(this.form)"),
+						g.Text("GO"),
+					),
+				),
+			),
+
+			components.Divider(),
+
+			customersTable(&customersTableProps{
+				customers:      p.Customers,
+				customersCount: p.CustomersCount,
+				page:           p.Page,
+				pageSize:       p.PageSize,
+			}),
+		),
+
+		g.If(
+			p.ErrorText != "",
+			h.Div(
+				h.Class("error-msg"),
+				g.Text(p.ErrorText),
+			),
+		),
+
+		h.H3(g.Text("Add Customer")),
+
+		customerForm(&customerFormProps{
+			action:           "/stock/customers",
+			isNew:            true,
+			values:           p.Values,
+			validationErrors: p.ValidationErrors,
+			isSubmission:     p.IsSubmission,
+		}),
+	})
+
+	return layout.Page(layout.PageProps{
+		Title:   "Customers",
+		Content: content,
+		Ctx:     p.Ctx,
+		Breadcrumbs: []layout.Breadcrumb{
+			layout.HomeBreadcrumb,
+			{
+				IconIdentifier: "package-variant-closed",
+				Title:          "Stock",
+				URLPart:        "stock",
+			},
+			{
+				Title: "Customers",
+			},
+		},
+		AppendHead: []g.Node{
+			components.InlineStyle("/internal/views/stockview/stock_table.css"),
+			components.InlineStyle("/internal/views/stockview/sales_order_page.css"),
+		},
+	})
+}
+
+type customersTableProps struct {
+	customers      []model.Customer
+	customersCount int
+	page           int
+	pageSize       int
+}
+
+func customersTable(p *customersTableProps) g.Node {
+
+	columns := components.TableColumns{
+		{TitleContents: g.Text("Code")},
+		{TitleContents: g.Text("Name")},
+		{TitleContents: g.Text("Email")},
+		{TitleContents: g.Text("Phone")},
+		{TitleContents: g.Text("Status")},
+	}
+
+	dashIfEmpty := func(s string) string {
+		if s == "" {
+			return "\u2013"
+		}
+		return s
+	}
+
+	var rows components.TableRows
+	for _, s := range p.customers {
+
+		customerHref := fmt.Sprintf("/stock/customers/%d", s.CustomerID)
+
+		rows = append(rows, components.TableRow{
+			Cells: []components.TableCell{
+				{Contents: h.A(h.Href(customerHref), g.Text(s.Code))},
+				{Contents: g.Text(s.Name)},
+				{Contents: g.Text(dashIfEmpty(s.Email))},
+				{Contents: g.Text(dashIfEmpty(s.Phone))},
+				{Contents: archivedBadge(s.IsArchived)},
+			},
+			HREF: customerHref,
+		})
+	}
+
+	return components.Table(&components.TableProps{
+		Classes: c.Classes{"stock-table": true},
+		Columns: columns,
+		Rows:    rows,
+		Pagination: &components.TablePaginationProps{
+			TotalRecords:        p.customersCount,
+			PageSize:            p.pageSize,
+			CurrentPage:         p.page,
+			CurrentPageQueryKey: "Page",
+			PageSizeQueryKey:    "PageSize",
+		},
+	})
+}
+
+type customerFormProps struct {
+	action           string
+	isNew            bool
+	values           url.Values
+	validationErrors validate.ValidationErrors
+	isSubmission     bool
+}
+
+func customerForm(p *customerFormProps) g.Node {
+
+	fieldError := func(key, label string) g.Node {
+		if !p.isSubmission {
+			return nil
+		}
+		errorText := p.validationErrors.GetError(key, label)
+		if errorText == "" {
+			return nil
+		}
+		return components.InputHelper(&components.InputHelperProps{
+			Label: errorText,
+			Type:  components.InputHelperTypeError,
+		})
+	}
+
+	textInput := func(key, label, placeholder string) g.Node {
+		return h.Div(
+			h.Label(
+				g.Text(label),
+				h.Input(
+					h.Type("text"),
+					h.Name(key),
+					h.Value(p.values.Get(key)),
+					h.Placeholder(placeholder),
+					h.AutoComplete("off"),
+				),
+			),
+			fieldError(key, label),
+		)
+	}
+
+	submitText := "Save Customer"
+	if p.isNew {
+		submitText = "Add Customer"
+	}
+
+	return h.Form(
+		h.Method("POST"),
+		h.Class("form sales-order-form"),
+		h.Action(p.action),
+
+		g.If(p.isNew, textInput("Code", "Code", "Enter customer code")),
+		textInput("Name", "Name", "Enter customer name"),
+		textInput("Email", "Email (optional)", "Enter email address"),
+		textInput("Phone", "Phone (optional)", "Enter phone number"),
+
+		h.Div(
+			h.Label(
+				g.Text("Delivery Address (optional)"),
+				h.Textarea(
+					h.Name("DeliveryAddress"),
+					h.Placeholder("Enter delivery address, as it should appear on dispatch notes"),
+					h.AutoComplete("off"),
+					g.Text(p.values.Get("DeliveryAddress")),
+				),
+			),
+			fieldError("DeliveryAddress", "Delivery Address"),
+		),
+
+		g.If(
+			!p.isNew,
+			h.Label(
+				h.Class("checkbox"),
+				h.Input(
+					h.Type("checkbox"),
+					h.Name("IsArchived"),
+					h.Value("true"),
+					g.If(p.values.Get("IsArchived") == "true", h.Checked()),
+				),
+				g.Text("Archived"),
+			),
+		),
+
+		h.P(
+			h.Class("sales-order-info"),
+			g.Text(`Archived customers cannot be used on new sales orders.
+				Orders already taken from them can still be dispatched.`),
+		),
+
+		h.Button(
+			h.Class("button primary"),
+			h.Type("submit"),
+			g.Text(submitText),
+		),
+	)
+}
@@ -0,0 +1,84 @@
+package stockview
+
+import (
+	"app/internal/components"
+	"app/internal/model"
+
+	g "maragu.dev/gomponents"
+	h "maragu.dev/gomponents/html"
+)
+
+// fefoSuggestionsRow shows where to pick the selected stock item from, soonest
+// to expire first. Using a suggestion fills in the named location and bin
+// inputs and the lot number of the form.
+func fefoSuggestionsRow(locationField, binField string) g.Node {
+	return h.Div(
+		h.Class("form-row fefo-suggestions"),
+		h.Data("location-field", locationField),
+		h.Data("bin-field", binField),
+
+		h.Div(
+			h.Class("fefo-suggestions-list"),
+		),
+
+		components.InlineScript("/internal/views/stockview/fefo_suggestions.js"),
+	)
+}
+
+// FEFOSuggestions is the list of suggestions fetched when a stock item is
+// selected
+func FEFOSuggestions(suggestions []model.FEFOSuggestion) g.Node {
+
+	if len(suggestions) == 0 {
+		return h.P(
+			h.Class("transaction-info"),
+			g.Text("No available stock to suggest for this stock code."),
+		)
+	}
+
+	return g.Group([]g.Node{
+		h.P(
+			h.Class("transaction-info"),
+			g.Text("Suggested picks, first expired first out:"),
+		),
+		h.Table(
+			h.Class("fefo-suggestions-table"),
+			h.THead(
+				h.Tr(
+					h.Th(g.Text("Location")),
+					h.Th(g.Text("Bin")),
+					h.Th(g.Text("Lot")),
+					h.Th(g.Text("Expiry")),
+					h.Th(h.Class("text-right"), g.Text("Qty")),
+					h.Th(),
+				),
+			),
+			h.TBody(
+				g.Group(g.Map(suggestions, func(s model.FEFOSuggestion) g.Node {
+					lotNumber := s.LotNumber
+					if lotNumber == "" {
+						lotNumber = "\u2013"
+					}
+
+					return h.Tr(
+						h.Td(g.Text(s.Location)),
+						h.Td(g.Text(s.Bin)),
+						h.Td(g.Text(lotNumber)),
+						h.Td(lotExpiry(s.ExpiryDate)),
+						h.Td(h.Class("text-right"), g.Text(quantityWithUnit(s.Qty, s.Unit))),
+						h.Td(
+							h.Button(
+								h.Class("button small fefo-use-button"),
+								h.Type("button"),
+								h.Data("location", s.Location),
+								h.Data("bin", s.Bin),
+								h.Data("lot-number", s.LotNumber),
+								g.Text("Use"),
+							),
+						),
+					)
+				})),
+			),
+		),
+	})
+}
@@ -0,0 +1,60 @@
+(function () {
+  const container = document.currentScript.closest(".fefo-suggestions");
+  if (!container) return;
+
+  const form = container.closest("form");
+  const stockItemSelect = form.querySelector(
+    '.search-select[data-name="StockItemID"]',
+  );
+  const list = container.querySelector(".fefo-suggestions-list");
+  if (!stockItemSelect || !list) return;
+
+  async function loadSuggestions() {
+    const input = stockItemSelect.querySelector('input[name="StockItemID"]');
+    const stockItemID = input ? input.value : "";
+    if (!stockItemID) {
+      list.innerHTML = "";
+      return;
+    }
+
+    try {
+      const query = new URLSearchParams({ StockItemID: stockItemID });
+      const res = await fetch(`/stock/fefo-suggestions?${query}`);
+      if (!res.ok) {
+        throw new Error(`FEFO suggestions request failed: ${res.status}`);
+      }
+      list.innerHTML = await res.text();
+    } catch (err) {
+      list.innerHTML = "";
+      console.error(err);
+    }
+  }
+
+  // Location and bin fields may be search selects, which keep their value in
+  // a hidden input and show it in the select's span
+  function setField(name, value) {
+    const select = form.querySelector(`.search-select[data-name="${name}"]`);
+    if (!select) {
+      form.elements[name].value = value;
+      return;
+    }
+
+    const input = select.querySelector(`input[name="${name}"]`);
+    if (input) input.value = value;
+    select.querySelector(".select-input span").textContent =
+      value || (name === container.dataset.binField ? "No bin" : "");
+  }
+
+  stockItemSelect.addEventListener("change", loadSuggestions);
+
+  list.addEventListener("click", (e) => {
+    const button = e.target.closest(".fefo-use-button");
+    if (!button) return;
+
+    setField(container.dataset.locationField, button.dataset.location);
+    setField(container.dataset.binField, button.dataset.bin);
+    setField("LotNumber", button.dataset.lotNumber);
+  });
+
+  loadSuggestions();
+})();
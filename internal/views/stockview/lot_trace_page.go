@@ -0,0 +1,244 @@
+package stockview
+
+import (
+	"app/internal/components"
+	"app/internal/layout"
+	"app/internal/model"
+	"app/pkg/reqcontext"
+	"net/url"
+
+	g "maragu.dev/gomponents"
+	h "maragu.dev/gomponents/html"
+)
+
+type LotTracePageProps struct {
+	Ctx       reqcontext.ReqContext
+	StockCode string
+	LotNumber string
+	Trace     *model.LotTrace
+	ErrorText string
+}
+
+func LotTracePage(p *LotTracePageProps) g.Node {
+
+	content := g.Group([]g.Node{
+		h.Nav(
+			h.Class("stock-nav"),
+			h.A(h.Href("/stock"), g.Text("Stock levels")),
+			h.A(h.Href("/stock/transactions"), g.Text("See all transactions")),
+			h.A(h.Href("/stock/documents"), g.Text("Documents")),
+			h.A(h.Href("/stock/lots"), g.Text("Lots")),
+			g.Iff(p.Trace != nil, func() g.Node {
+				return h.A(h.Href(StockLotsURL(p.Trace.StockCode, p.Trace.LotNumber)), g.Text("Lot details"))
+			}),
+		),
+
+		h.H3(g.Text("Lot Trace")),
+
+		h.P(
+			h.Class("lot-trace-info"),
+			g.Text(`Consumption is linked to the production it fed when a
+				Production Batch document is posted. Where used follows a lot into
+				everything produced from it, where from follows a lot back to
+				everything consumed to produce it. Reversed transactions are not
+				followed.`),
+		),
+
+		h.Form(
+			h.Method("GET"),
+			h.Class("lot-trace-form"),
+
+			h.Label(
+				g.Text("Stock Code"),
+				h.Input(
+					h.Type("text"),
+					h.Name("StockCode"),
+					h.Value(p.StockCode),
+					h.Placeholder("Enter stock code"),
+					h.AutoComplete("off"),
+				),
+			),
+
+			h.Label(
+				g.Text("Lot Number"),
+				h.Input(
+					h.Type("text"),
+					h.Name("LotNumber"),
+					h.Value(p.LotNumber),
+					h.Placeholder("Enter lot number"),
+					h.AutoComplete("off"),
+				),
+			),
+
+			h.Button(
+				h.Class("button primary"),
+				h.Type("submit"),
+				g.Text("Trace"),
+			),
+		),
+
+		g.If(
+			p.ErrorText != "",
+			h.Div(
+				h.Class("error-msg"),
+				g.Text(p.ErrorText),
+			),
+		),
+
+		g.Iff(p.Trace != nil, func() g.Node {
+			return lotTrace(p.Trace)
+		}),
+	})
+
+	return layout.Page(layout.PageProps{
+		Title:   "Lot Trace",
+		Content: content,
+		Ctx:     p.Ctx,
+		Breadcrumbs: []layout.Breadcrumb{
+			layout.HomeBreadcrumb,
+			{
+				IconIdentifier: "package-variant-closed",
+				Title:          "Stock",
+				URLPart:        "stock",
+			},
+			{
+				Title: "Lot Trace",
+			},
+		},
+		AppendHead: []g.Node{
+			components.InlineStyle("/internal/views/stockview/lot_trace_page.css"),
+		},
+	})
+}
+
+// LotTraceURL is the trace page for a lot
+func LotTraceURL(stockCode, lotNumber string) string {
+	params := url.Values{}
+	params.Add("StockCode", stockCode)
+	params.Add("LotNumber", lotNumber)
+	return "/stock/lot-trace?" + params.Encode()
+}
+
+// lotTraceAnchor links a lot number to its trace page, or shows a dash when
+// there is no lot
+func lotTraceAnchor(stockCode, lotNumber string) g.Node {
+	if lotNumber == "" {
+		return g.Text("\u2013")
+	}
+	return h.A(h.Href(LotTraceURL(stockCode, lotNumber)), g.Text(lotNumber))
+}
+
+type lotKey struct {
+	stockCode string
+	lotNumber string
+}
+
+func lotTrace(t *model.LotTrace) g.Node {
+
+	root := lotKey{t.StockCode, t.LotNumber}
+
+	// children of a lot in each direction, in the order returned
+	forward := map[lotKey][]model.StockLotLink{}
+	for _, l := range t.Forward {
+		k := lotKey{l.ConsumedStockCode, l.ConsumedLotNumber}
+		forward[k] = append(forward[k], l)
+	}
+	backward := map[lotKey][]model.StockLotLink{}
+	for _, l := range t.Backward {
+		k := lotKey{l.ProducedStockCode, l.ProducedLotNumber}
+		backward[k] = append(backward[k], l)
+	}
+
+	return g.Group([]g.Node{
+		h.H3(g.Textf("Where Used: %s lot %s", t.StockCode, t.LotNumber)),
+		g.If(
+			len(t.Forward) == 0,
+			h.P(g.Text("Not consumed into any linked production.")),
+		),
+		g.If(
+			len(t.Forward) > 0,
+			lotTraceTree(root, forward, model.ForwardLotTraceDirection, map[lotKey]bool{root: true}),
+		),
+
+		h.H3(g.Textf("Where From: %s lot %s", t.StockCode, t.LotNumber)),
+		g.If(
+			len(t.Backward) == 0,
+			h.P(g.Text("Not produced from any linked consumption.")),
+		),
+		g.If(
+			len(t.Backward) > 0,
+			lotTraceTree(root, backward, model.BackwardLotTraceDirection, map[lotKey]bool{root: true}),
+		),
+	})
+}
+
+// lotTraceTree renders the lots linked to parent as a nested list. Lots
+// already on the path are shown but not expanded again so that reworked lots
+// do not recurse forever.
+func lotTraceTree(
+	parent lotKey,
+	children map[lotKey][]model.StockLotLink,
+	direction model.LotTraceDirection,
+	onPath map[lotKey]bool,
+) g.Node {
+
+	links := children[parent]
+	if len(links) == 0 {
+		return nil
+	}
+
+	return h.Ul(
+		h.Class("lot-trace-tree"),
+		g.Group(g.Map(links, func(l model.StockLotLink) g.Node {
+
+			child := lotKey{l.ProducedStockCode, l.ProducedLotNumber}
+			if direction == model.BackwardLotTraceDirection {
+				child = lotKey{l.ConsumedStockCode, l.ConsumedLotNumber}
+			}
+
+			var subtree g.Node
+			if child.lotNumber != "" && !onPath[child] {
+				onPath[child] = true
+				subtree = lotTraceTree(child, children, direction, onPath)
+				delete(onPath, child)
+			}
+
+			return h.Li(
+				lotTraceNode(child),
+				h.Span(
+					h.Class("lot-trace-qty"),
+					g.Textf("%s consumed", quantityWithUnit(l.ConsumedQty, l.ConsumedUnit)),
+				),
+				subtree,
+			)
+		})),
+	)
+}
+
+func lotTraceNode(k lotKey) g.Node {
+
+	if k.lotNumber == "" {
+		return h.Span(
+			h.Class("lot-trace-node"),
+			components.StockItemAnchor(k.stockCode),
+			g.Text(" (no lot)"),
+		)
+	}
+
+	trxParams := url.Values{}
+	trxParams.Add("StockCode", k.stockCode)
+	trxParams.Add("LotNumber", k.lotNumber)
+
+	return h.Span(
+		h.Class("lot-trace-node"),
+		components.StockItemAnchor(k.stockCode),
+		g.Text(" lot "),
+		h.A(h.Href(LotTraceURL(k.stockCode, k.lotNumber)), g.Text(k.lotNumber)),
+		g.Text(" "),
+		h.A(
+			h.Class("lot-trace-transactions"),
+			h.Href("/stock/transactions?"+trxParams.Encode()),
+			g.Text("Transactions"),
+		),
+	)
+}
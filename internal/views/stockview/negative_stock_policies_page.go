@@ -0,0 +1,250 @@
+package stockview
+
+import (
+	"app/internal/components"
+	"app/internal/layout"
+	"app/internal/model"
+	"app/pkg/nilsafe"
+	"app/pkg/reqcontext"
+	"app/pkg/validate"
+	"fmt"
+	"net/url"
+	"time"
+
+	g "maragu.dev/gomponents"
+	c "maragu.dev/gomponents/components"
+	h "maragu.dev/gomponents/html"
+)
+
+type NegativeStockPoliciesPageProps struct {
+	Ctx        reqcontext.ReqContext
+	Policies   []model.NegativeStockPolicy
+	StockItems []model.StockItem
+	ErrorText  string
+
+	// Save policy form state
+	Values           url.Values
+	ValidationErrors validate.ValidationErrors
+	IsSubmission     bool
+}
+
+func NegativeStockPoliciesPage(p *NegativeStockPoliciesPageProps) g.Node {
+
+	content := g.Group([]g.Node{
+		h.Nav(
+			h.Class("stock-nav"),
+			h.A(h.Href("/stock"), g.Text("Stock levels")),
+			h.A(h.Href("/stock/transactions"), g.Text("See all transactions")),
+			h.A(h.Href("/stock/documents"), g.Text("Documents")),
+		),
+
+		h.H3(g.Text("Negative Stock Policies")),
+
+		h.P(
+			h.Class("negative-stock-policies-info"),
+			g.Text(`A policy decides what happens when a posting would leave the
+				STOCK account below zero. Allow posts as normal, Warn asks the
+				user to acknowledge before posting and Reject refuses the
+				posting. The most specific policy applies: stock code and
+				location, then stock code, then location, then the default.`),
+		),
+
+		g.If(
+			p.ErrorText != "",
+			h.Div(
+				h.Class("error-msg"),
+				g.Text(p.ErrorText),
+			),
+		),
+
+		negativeStockPoliciesTable(p.Policies),
+
+		h.H3(g.Text("Add or Update Policy")),
+
+		saveNegativeStockPolicyForm(&saveNegativeStockPolicyFormProps{
+			stockItems:       p.StockItems,
+			values:           p.Values,
+			validationErrors: p.ValidationErrors,
+			isSubmission:     p.IsSubmission,
+		}),
+	})
+
+	return layout.Page(layout.PageProps{
+		Title:   "Negative Stock Policies",
+		Content: content,
+		Ctx:     p.Ctx,
+		Breadcrumbs: []layout.Breadcrumb{
+			layout.HomeBreadcrumb,
+			{
+				IconIdentifier: "package-variant-closed",
+				Title:          "Stock",
+				URLPart:        "stock",
+			},
+			{
+				Title: "Negative Stock Policies",
+			},
+		},
+		AppendHead: []g.Node{
+			components.InlineStyle("/internal/views/stockview/stock_table.css"),
+			components.InlineStyle("/internal/views/stockview/negative_stock_policies_page.css"),
+		},
+	})
+}
+
+func negativeStockPoliciesTable(policies []model.NegativeStockPolicy) g.Node {
+
+	columns := components.TableColumns{
+		{TitleContents: g.Text("Stock Code")},
+		{TitleContents: g.Text("Location")},
+		{TitleContents: g.Text("Action")},
+		{TitleContents: g.Text("Updated By")},
+		{TitleContents: g.Text("Updated")},
+		{TitleContents: g.Text("")},
+	}
+
+	var rows components.TableRows
+	for _, np := range policies {
+
+		stockCode := g.Text("All")
+		if np.StockCode != nil {
+			stockCode = components.StockItemAnchor(*np.StockCode)
+		}
+
+		location := "All"
+		if np.Location != nil {
+			location = *np.Location
+		}
+
+		updatedBy := "\u2013"
+		if np.UpdatedByUsername != nil {
+			updatedBy = nilsafe.Str(np.UpdatedByUsername)
+		}
+
+		var deleteButton g.Node
+		if !np.IsGlobal() {
+			deleteButton = h.Form(
+				h.Method("POST"),
+				h.Action(fmt.Sprintf("/stock/negative-stock-policies/%d/delete", np.NegativeStockPolicyID)),
+				h.Button(
+					h.Class("button secondary small"),
+					h.Type("submit"),
+					g.Text("Remove"),
+				),
+			)
+		}
+
+		rows = append(rows, components.TableRow{
+			Cells: []components.TableCell{
+				{Contents: stockCode},
+				{Contents: g.Text(location)},
+				{Contents: negativeStockActionBadge(np.Action)},
+				{Contents: g.Text(updatedBy)},
+				{Contents: h.Span(h.Class("local-datetime"), g.Text(np.UpdatedAt.Format(time.RFC3339)))},
+				{Contents: deleteButton},
+			},
+		})
+	}
+
+	return components.Table(&components.TableProps{
+		Classes: c.Classes{"stock-table": true},
+		Columns: columns,
+		Rows:    rows,
+	})
+}
+
+func negativeStockActionBadge(action model.NegativeStockAction) g.Node {
+	badgeType := components.BadgeSuccess
+	switch action {
+	case model.WarnNegativeStockAction:
+		badgeType = components.BadgeWarning
+	case model.RejectNegativeStockAction:
+		badgeType = components.BadgeDanger
+	}
+
+	return components.Badge(&components.BadgeProps{
+		Type: badgeType,
+		Size: components.BadgeSm,
+	}, g.Text(string(action)))
+}
+
+type saveNegativeStockPolicyFormProps struct {
+	stockItems       []model.StockItem
+	values           url.Values
+	validationErrors validate.ValidationErrors
+	isSubmission     bool
+}
+
+func saveNegativeStockPolicyForm(p *saveNegativeStockPolicyFormProps) g.Node {
+
+	actionValue := p.values.Get("Action")
+	actionError := ""
+	if p.isSubmission {
+		actionError = p.validationErrors.GetError("Action", "Action")
+	}
+
+	selectedStockItem := p.values.Get("StockItemID")
+
+	return h.Form(
+		h.Method("POST"),
+		h.Class("form negative-stock-policy-form"),
+		h.Action("/stock/negative-stock-policies"),
+
+		h.Div(
+			h.Label(
+				g.Text("Stock Code (leave blank for all)"),
+				components.SearchSelect(&components.SearchSelectProps{
+					Name:                 "StockItemID",
+					Placeholder:          "Select Stock Code",
+					Mode:                 "single",
+					Options:              MapStockItemsToOptions(p.stockItems, selectedStockItem),
+					Selected:             selectedStockItem,
+					OptionsEndpoint:      "/get-stock-codes",
+					SearchQueryParamName: "SearchText",
+				}),
+			),
+		),
+
+		h.Div(
+			h.Label(
+				g.Text("Location (leave blank for all)"),
+				h.Input(
+					h.Type("text"),
+					h.Name("Location"),
+					h.Value(p.values.Get("Location")),
+					h.Placeholder("Enter location"),
+					h.AutoComplete("off"),
+				),
+			),
+		),
+
+		h.Div(
+			h.Label(
+				g.Text("Action"),
+				h.Select(
+					h.Name("Action"),
+					h.Class("select"),
+					g.Group(g.Map(model.NegativeStockActions, func(a model.NegativeStockAction) g.Node {
+						return h.Option(
+							h.Value(string(a)),
+							g.Text(string(a)),
+							g.If(actionValue == string(a), h.Selected()),
+						)
+					})),
+				),
+			),
+			g.If(
+				actionError != "",
+				components.InputHelper(&components.InputHelperProps{
+					Label: actionError,
+					Type:  components.InputHelperTypeError,
+				}),
+			),
+		),
+
+		h.Button(
+			h.Class("button primary"),
+			h.Type("submit"),
+			g.Text("Save Policy"),
+		),
+	)
+}
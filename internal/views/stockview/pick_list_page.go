@@ -0,0 +1,360 @@
+package stockview
+
+import (
+	"app/internal/components"
+	"app/internal/layout"
+	"app/internal/model"
+	"app/pkg/nilsafe"
+	"app/pkg/reqcontext"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	g "maragu.dev/gomponents"
+	c "maragu.dev/gomponents/components"
+	h "maragu.dev/gomponents/html"
+)
+
+type PickListPageProps struct {
+	Ctx        reqcontext.ReqContext
+	PickList   model.PickList
+	SalesOrder model.SalesOrder
+	Lines      []model.PickListLine
+	CanEdit    bool
+	ErrorText  string
+
+	NegativeStockWarning bool
+
+	// Dispatch form state, with a Qty and SerialNumbers value for each line
+	// in line order
+	Values url.Values
+}
+
+func PickListPage(p *PickListPageProps) g.Node {
+
+	pl := p.PickList
+	so := p.SalesOrder
+	isOpen := pl.Status == model.OpenPickListStatus
+
+	type attribute struct {
+		label string
+		value g.Node
+	}
+
+	createdBy := g.Group([]g.Node{
+		g.Textf("%s on ", nilsafe.Str(pl.CreatedByUsername)),
+		h.Span(h.Class("local-datetime"), g.Text(pl.CreatedAt.Format(time.RFC3339))),
+	})
+
+	dispatchedBy := g.Text("\u2013")
+	if pl.DispatchedAt != nil {
+		dispatchedBy = g.Group([]g.Node{
+			g.Textf("%s on ", nilsafe.Str(pl.DispatchedByUsername)),
+			h.Span(h.Class("local-datetime"), g.Text(pl.DispatchedAt.Format(time.RFC3339))),
+		})
+	}
+
+	attributes := []attribute{
+		{
+			label: "Sales Order",
+			value: h.A(
+				h.Href(fmt.Sprintf("/stock/sales-orders/%d", so.SalesOrderID)),
+				g.Text(so.Reference),
+			),
+		},
+		{label: "Customer", value: g.Textf("%s \u2013 %s", so.CustomerCode, so.CustomerName)},
+		{label: "Created By", value: createdBy},
+		{label: "Dispatched By", value: dispatchedBy},
+	}
+
+	content := g.Group([]g.Node{
+		h.Ul(
+			h.Class("attributes-list"),
+
+			g.Group(g.Map(attributes, func(a attribute) g.Node {
+				return h.Li(
+					components.Icon(&components.IconProps{
+						Identifier: "arrow-right-thin",
+					}),
+					h.Strong(g.Textf("%s: ", a.label)),
+					h.Span(a.value),
+				)
+			})),
+		),
+
+		g.If(
+			p.ErrorText != "",
+			h.Div(
+				h.Class("error-msg"),
+				g.Text(p.ErrorText),
+			),
+		),
+
+		h.H3(g.Text("Lines")),
+
+		g.If(
+			isOpen && p.CanEdit,
+			dispatchPickListForm(p),
+		),
+
+		g.If(
+			!isOpen || !p.CanEdit,
+			pickListLinesTable(p.Lines),
+		),
+	})
+
+	return layout.Page(layout.PageProps{
+		Ctx:   p.Ctx,
+		Title: fmt.Sprintf("Pick List %d - %s", pl.PickListID, so.Reference),
+		Header: &layout.PageHeaderProps{
+			Title: h.Div(
+				h.Class("stock-document-page-title"),
+				h.H1(g.Textf("Pick List %d", pl.PickListID)),
+				pickListStatusBadge(pl.Status),
+			),
+			Actions: pickListActions(&pickListActionsProps{
+				pickListID:         pl.PickListID,
+				canPrint:           isOpen,
+				canPrintDispatched: pl.Status == model.DispatchedPickListStatus,
+				canCancel:          isOpen && p.CanEdit,
+			}),
+		},
+		Content: content,
+		Breadcrumbs: []layout.Breadcrumb{
+			layout.HomeBreadcrumb,
+			{
+				IconIdentifier: "package-variant-closed",
+				Title:          "Stock",
+				URLPart:        "stock",
+			},
+			{
+				Title:   "Sales Orders",
+				URLPart: "sales-orders",
+			},
+			{
+				Title:   so.Reference,
+				URLPart: strconv.Itoa(so.SalesOrderID),
+			},
+			{
+				Title: fmt.Sprintf("Pick List %d", pl.PickListID),
+			},
+		},
+		AppendHead: []g.Node{
+			components.InlineStyle("/internal/views/stockview/stock_table.css"),
+			components.InlineStyle("/internal/views/stockview/stock_document_page.css"),
+			components.InlineStyle("/internal/views/stockview/sales_order_page.css"),
+			components.InlineScript("/internal/views/stockview/sales_order_page.js"),
+		},
+	})
+}
+
+type pickListActionsProps struct {
+	pickListID         int
+	canPrint           bool
+	canPrintDispatched bool
+	canCancel          bool
+}
+
+func pickListActions(p *pickListActionsProps) []g.Node {
+	actions := []g.Node{}
+
+	if p.canPrint {
+		actions = append(actions, h.A(
+			h.Class("button secondary"),
+			h.Href(fmt.Sprintf("/stock/pick-lists/%d/print", p.pickListID)),
+			h.Target("_blank"),
+			g.Text("Print Pick List"),
+		))
+	}
+
+	if p.canPrintDispatched {
+		actions = append(actions, h.A(
+			h.Class("button secondary"),
+			h.Href(fmt.Sprintf("/stock/pick-lists/%d/dispatch-note", p.pickListID)),
+			h.Target("_blank"),
+			g.Text("Dispatch Note"),
+		))
+	}
+
+	if p.canCancel {
+		actions = append(actions, h.Form(
+			h.Method("POST"),
+			h.Class("sales-order-action-form"),
+			h.Action(fmt.Sprintf("/stock/pick-lists/%d/cancel", p.pickListID)),
+			g.Attr("data-confirm", "Cancel this pick list? The stock it proposes will be free to pick again."),
+			h.Button(
+				h.Class("button secondary"),
+				h.Type("submit"),
+				g.Text("Cancel Pick List"),
+			),
+		))
+	}
+
+	return actions
+}
+
+func pickListLinesTable(lines []model.PickListLine) g.Node {
+
+	columns := components.TableColumns{
+		{TitleContents: g.Text("Location")},
+		{TitleContents: g.Text("Bin")},
+		{TitleContents: g.Text("Stock Code")},
+		{TitleContents: g.Text("Description")},
+		{TitleContents: g.Text("Lot Number")},
+		{TitleContents: g.Text("Qty"), Classes: c.Classes{"text-right": true}},
+		{TitleContents: g.Text("Dispatched"), Classes: c.Classes{"text-right": true}},
+		{TitleContents: g.Text("Serial Numbers")},
+	}
+
+	dashIfEmpty := func(v string) string {
+		if v == "" {
+			return "\u2013"
+		}
+		return v
+	}
+
+	var rows components.TableRows
+	for _, l := range lines {
+
+		dispatched := "\u2013"
+		if l.DispatchedQty != nil {
+			dispatched = quantityWithUnit(*l.DispatchedQty, l.Unit)
+		}
+
+		rows = append(rows, components.TableRow{
+			Cells: []components.TableCell{
+				{Contents: g.Text(l.Location)},
+				{Contents: g.Text(dashIfEmpty(l.Bin))},
+				{Contents: components.StockItemAnchor(l.StockCode)},
+				{Contents: g.Text(l.Description)},
+				{Contents: g.Text(dashIfEmpty(l.LotNumber))},
+				{Contents: g.Text(quantityWithUnit(l.Qty, l.Unit)), Classes: c.Classes{"text-right": true}},
+				{Contents: g.Text(dispatched), Classes: c.Classes{"text-right": true}},
+				{Contents: g.Text(dashIfEmpty(strings.Join(l.SerialNumbers, ", ")))},
+			},
+		})
+	}
+
+	return components.Table(&components.TableProps{
+		Classes: c.Classes{"stock-table": true},
+		Columns: columns,
+		Rows:    rows,
+	})
+}
+
+// dispatchPickListForm lists the lines to confirm what was picked of each.
+// Each line posts its PickListLineID, Qty and SerialNumbers so that the
+// values line up by position.
+func dispatchPickListForm(p *PickListPageProps) g.Node {
+
+	valueAt := func(key string, i int) string {
+		values := p.Values[key]
+		if i < len(values) {
+			return values[i]
+		}
+		return ""
+	}
+
+	columns := components.TableColumns{
+		{TitleContents: g.Text("Location")},
+		{TitleContents: g.Text("Bin")},
+		{TitleContents: g.Text("Stock Code")},
+		{TitleContents: g.Text("Description")},
+		{TitleContents: g.Text("Lot Number")},
+		{TitleContents: g.Text("To Pick"), Classes: c.Classes{"text-right": true}},
+		{TitleContents: g.Text("Picked")},
+		{TitleContents: g.Text("Serial Numbers (serialised items only)")},
+	}
+
+	var rows components.TableRows
+	for i, l := range p.Lines {
+		rows = append(rows, components.TableRow{
+			Cells: []components.TableCell{
+				{Contents: g.Text(l.Location)},
+				{Contents: g.Text(l.Bin)},
+				{Contents: components.StockItemAnchor(l.StockCode)},
+				{Contents: g.Text(l.Description)},
+				{Contents: g.Text(l.LotNumber)},
+				{Contents: g.Text(quantityWithUnit(l.Qty, l.Unit)), Classes: c.Classes{"text-right": true}},
+				{Contents: g.Group([]g.Node{
+					h.Input(
+						h.Type("hidden"),
+						h.Name("PickListLineID"),
+						h.Value(strconv.Itoa(l.PickListLineID)),
+					),
+					h.Input(
+						h.Type("number"),
+						h.Min("0"),
+						h.Step("any"),
+						h.Name("Qty"),
+						h.Value(valueAt("Qty", i)),
+						h.AutoComplete("off"),
+					),
+				})},
+				{Contents: h.Textarea(
+					h.Name("SerialNumbers"),
+					h.Placeholder("Separated by commas or new lines"),
+					h.AutoComplete("off"),
+					g.Text(valueAt("SerialNumbers", i)),
+				)},
+			},
+		})
+	}
+
+	return h.Form(
+		h.Method("POST"),
+		h.Class("form sales-order-action-form"),
+		h.Action(fmt.Sprintf("/stock/pick-lists/%d/dispatch", p.PickList.PickListID)),
+		g.Attr("data-confirm", "Confirm the dispatch of the picked quantities? Stock will be posted out to the customer."),
+
+		h.Div(
+			h.Class("pick-list-lines"),
+			components.Table(&components.TableProps{
+				Classes: c.Classes{"stock-table": true},
+				Columns: columns,
+				Rows:    rows,
+			}),
+		),
+
+		h.P(
+			h.Class("sales-order-info"),
+			g.Text(`Enter what was picked of each line. Lines picked short are dispatched
+				short and the rest stays outstanding on the order, for a later pick list.`),
+		),
+
+		h.Div(
+			h.Class("sales-order-form"),
+			h.Label(
+				g.Text("Note (optional)"),
+				h.Input(
+					h.Type("text"),
+					h.Name("TransactionNote"),
+					h.Value(p.Values.Get("TransactionNote")),
+					h.Placeholder("Enter note, e.g. carrier and consignment number"),
+					h.AutoComplete("off"),
+				),
+			),
+		),
+
+		g.If(
+			p.NegativeStockWarning,
+			h.Label(
+				h.Class("checkbox"),
+				h.Input(
+					h.Type("checkbox"),
+					h.Name("AcknowledgeNegativeStock"),
+					h.Value("true"),
+				),
+				g.Text("Dispatch even though it leaves negative stock"),
+			),
+		),
+
+		h.Button(
+			h.Class("button primary"),
+			h.Type("submit"),
+			g.Text("Confirm Dispatch"),
+		),
+	)
+}
@@ -25,6 +25,8 @@ type PostConsumptionPageProps struct {
 func PostConsumptionPage(p *PostGenericPageProps) g.Node {
 	p.StockCodePlaceholder = "Enter stock code for consumption"
 	p.QtyPlaceholder = "Enter quantity to consume"
+	p.ShowFEFOSuggestions = true
+	p.ShowDemandReference = true
 
 	content := g.Group([]g.Node{
 		h.P(
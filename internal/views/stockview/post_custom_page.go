@@ -0,0 +1,317 @@
+package stockview
+
+import (
+	"app/internal/components"
+	"app/internal/model"
+	"app/pkg/reqcontext"
+	"fmt"
+
+	"github.com/shopspring/decimal"
+	g "maragu.dev/gomponents"
+	h "maragu.dev/gomponents/html"
+)
+
+type PostCustomPageProps struct {
+	Ctx         reqcontext.ReqContext
+	SuccessText string
+	ErrorText   string
+	// PrintLabelsURL links to printing labels for the stock just posted
+	PrintLabelsURL string
+
+	CustomStockTransactionTypeID int
+	StockItemID                  int
+	FromLocation                 string
+	FromBin                      string
+	FromLotNumber                string
+	ToLocation                   string
+	ToBin                        string
+	ToLotNumber                  string
+	Qty                          decimal.Decimal
+	Unit                         string
+	SerialNumbers                string
+	UnitCost                     *decimal.Decimal
+	DemandReference              string
+	TransactionNote              string
+
+	QtyError             string
+	NegativeStockWarning bool
+
+	StockItems []model.StockItem
+	// TransactionTypes are the custom transaction types the user can post
+	TransactionTypes []model.CustomStockTransactionType
+}
+
+func PostCustomPage(p *PostCustomPageProps) g.Node {
+
+	var selectedType *model.CustomStockTransactionType
+	for i := range p.TransactionTypes {
+		if p.TransactionTypes[i].CustomStockTransactionTypeID == p.CustomStockTransactionTypeID {
+			selectedType = &p.TransactionTypes[i]
+		}
+	}
+
+	var form g.Node
+	if selectedType != nil {
+		form = postCustomForm(p, selectedType)
+	}
+
+	content := g.Group([]g.Node{
+		h.P(
+			h.Class("transaction-info"),
+			components.Icon(&components.IconProps{
+				Identifier: "information-outline",
+			}),
+			g.Text(
+				`Use this utility to post the transaction types defined by
+				admins. Choose a transaction type to see the accounts it posts
+				between and the fields it needs.`),
+		),
+
+		h.FormEl(
+			h.Method("GET"),
+
+			h.Div(
+				h.Class("form-row"),
+
+				h.Label(
+					g.Text("Transaction Type"),
+					h.Select(
+						h.Name("CustomStockTransactionTypeID"),
+						g.Attr("onchange", "this.form.submit()"),
+						h.Option(h.Value(""), g.Text("Select transaction type")),
+						g.Group(g.Map(p.TransactionTypes, func(t model.CustomStockTransactionType) g.Node {
+							return h.Option(
+								h.Value(fmt.Sprintf("%d", t.CustomStockTransactionTypeID)),
+								g.Text(t.Name),
+								g.If(p.CustomStockTransactionTypeID == t.CustomStockTransactionTypeID, h.Selected()),
+							)
+						})),
+					),
+				),
+			),
+		),
+
+		g.If(
+			len(p.TransactionTypes) == 0,
+			h.P(
+				h.Class("transaction-info"),
+				g.Text(`NOTE: there are no transaction types you can post. Admins
+					define them under Transaction Types.`),
+			),
+		),
+
+		form,
+	})
+
+	return postTransactionPageLayout(&postTransactionPageLayoutProps{
+		transactionType: "Custom",
+		content:         content,
+		ctx:             p.Ctx,
+		successText:     p.SuccessText,
+		errorText:       p.ErrorText,
+		printLabelsURL:  p.PrintLabelsURL,
+	})
+}
+
+// postCustomForm shows the fields of a posting of the custom transaction
+// type, asking for the to place only where it may differ
+func postCustomForm(p *PostCustomPageProps, t *model.CustomStockTransactionType) g.Node {
+
+	selectedStockItem := ""
+	if p.StockItemID != 0 {
+		selectedStockItem = fmt.Sprintf("%d", p.StockItemID)
+	}
+
+	optional := func(label string, field model.CustomTransactionField) string {
+		if t.Requires(field) {
+			return label
+		}
+		return label + " (optional)"
+	}
+
+	description := fmt.Sprintf("Posts from %s to %s.", t.FromAccount, t.ToAccount)
+	if t.Description != "" {
+		description = fmt.Sprintf("%s %s", t.Description, description)
+	}
+
+	return h.FormEl(
+		h.Method("POST"),
+
+		h.Input(
+			h.Type("hidden"),
+			h.Name("CustomStockTransactionTypeID"),
+			h.Value(fmt.Sprintf("%d", t.CustomStockTransactionTypeID)),
+		),
+
+		h.P(
+			h.Class("transaction-info"),
+			g.Text(description),
+		),
+
+		h.Div(
+			h.Class("form-row"),
+
+			h.Label(
+				g.Text("Stock Code"),
+
+				components.SearchSelect(&components.SearchSelectProps{
+					Name:                 "StockItemID",
+					Placeholder:          "Select Stock Code",
+					Mode:                 "single",
+					Options:              MapStockItemsToOptions(p.StockItems, selectedStockItem),
+					Selected:             selectedStockItem,
+					OptionsEndpoint:      "/get-stock-codes",
+					SearchQueryParamName: "SearchText",
+				}),
+			),
+		),
+
+		h.Div(
+			h.Class("form-row"),
+
+			h.Label(
+				g.Text("From Location"),
+				locationSelect("FromLocation", p.FromLocation),
+			),
+		),
+
+		h.Div(
+			h.Class("form-row"),
+
+			h.Label(
+				g.Text("From Bin"),
+				binSelect("FromBin", "FromLocation", p.FromBin),
+			),
+		),
+
+		h.Div(
+			h.Class("form-row"),
+
+			h.Label(
+				g.Text(optional("Lot Number", model.LotNumberCustomTransactionField)),
+				h.Input(
+					h.Type("text"),
+					h.Name("FromLotNumber"),
+					h.Value(p.FromLotNumber),
+					h.Placeholder("Enter lot number"),
+					h.AutoComplete("off"),
+				),
+			),
+		),
+
+		g.If(
+			t.AllowDifferentLocation,
+			h.Div(
+				h.Class("form-row"),
+
+				h.Label(
+					g.Text("To Location (optional, defaults to from location)"),
+					locationSelect("ToLocation", p.ToLocation),
+				),
+			),
+		),
+
+		g.If(
+			t.AllowDifferentLocation || t.AllowDifferentBin,
+			h.Div(
+				h.Class("form-row"),
+
+				h.Label(
+					g.Text("To Bin"),
+					binSelect("ToBin", "ToLocation", p.ToBin),
+				),
+			),
+		),
+
+		g.If(
+			t.AllowDifferentLot,
+			h.Div(
+				h.Class("form-row"),
+
+				h.Label(
+					g.Text("To Lot Number (optional, defaults to from lot)"),
+					h.Input(
+						h.Type("text"),
+						h.Name("ToLotNumber"),
+						h.Value(p.ToLotNumber),
+						h.Placeholder("Enter lot number"),
+						h.AutoComplete("off"),
+					),
+				),
+			),
+		),
+
+		h.Div(
+			h.Class("form-row"),
+
+			h.Label(
+				g.Text("Qty"),
+				h.Input(
+					h.Type("number"),
+					h.Min("0"),
+					h.Name("Qty"),
+					h.Step("any"),
+					g.If(p.Qty.GreaterThan(decimal.Zero), h.Value(p.Qty.String())),
+					h.Placeholder("Enter quantity"),
+					h.AutoComplete("off"),
+				),
+				negativeStockQtyHelper(p.QtyError),
+			),
+		),
+
+		unitRow(p.Unit),
+
+		g.If(
+			t.Requires(model.SerialNumbersCustomTransactionField),
+			h.P(
+				h.Class("transaction-info"),
+				g.Text("NOTE: this transaction type requires serial numbers."),
+			),
+		),
+
+		serialNumbersRow(p.SerialNumbers),
+
+		g.If(
+			t.ToAccount == model.StockStockAccount || t.Requires(model.UnitCostCustomTransactionField),
+			unitCostRow(p.UnitCost),
+		),
+
+		h.Div(
+			h.Class("form-row"),
+
+			h.Label(
+				g.Text(optional("Demand Reference", model.DemandReferenceCustomTransactionField)),
+				h.Input(
+					h.Type("text"),
+					h.Name("DemandReference"),
+					h.Value(p.DemandReference),
+					h.Placeholder("Enter order or job reference"),
+					h.AutoComplete("off"),
+				),
+			),
+		),
+
+		acknowledgeNegativeStockRow(p.NegativeStockWarning),
+
+		h.Div(
+			h.Class("form-row"),
+
+			h.Label(
+				g.Text(optional("Note", model.TransactionNoteCustomTransactionField)),
+				h.Textarea(
+					h.Name("TransactionNote"),
+					h.Placeholder("Enter transaction note"),
+					h.AutoComplete("off"),
+					g.Text(p.TransactionNote),
+				),
+			),
+		),
+
+		components.Button(
+			&components.ButtonProps{
+				ButtonType: "Primary",
+			},
+			g.Textf("Post %s Transaction", t.Name),
+		),
+	)
+}
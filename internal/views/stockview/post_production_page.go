@@ -15,15 +15,38 @@ type PostGenericPageProps struct {
 	Ctx         reqcontext.ReqContext
 	SuccessText string
 	ErrorText   string
+	// PrintLabelsURL links to printing labels for the stock just posted
+	PrintLabelsURL string
 
 	StockItemID     int
 	Location        string
 	Bin             string
 	LotNumber       string
 	Qty             decimal.Decimal
+	Unit            string
 	TransactionNote string
+	SerialNumbers   string
+	DemandReference string
 
 	IsStockAdjustment bool
+	// ShowFEFOSuggestions suggests where to take stock from once a stock
+	// code is selected
+	ShowFEFOSuggestions bool
+	// ShowDemandReference takes the demand the posting is for, which draws
+	// down the stock reserved for it
+	ShowDemandReference bool
+	// ShowUnitCost takes the cost of stock coming into STOCK
+	ShowUnitCost bool
+	UnitCost     *decimal.Decimal
+	// ShowBackflush takes where to consume the components of the bill of
+	// materials from, or with ReverseBackflush where to return them to
+	ShowBackflush     bool
+	ReverseBackflush  bool
+	BackflushLocation string
+	BackflushBin      string
+
+	QtyError             string
+	NegativeStockWarning bool
 
 	// Placeholders
 	StockCodePlaceholder string
@@ -35,6 +58,8 @@ type PostGenericPageProps struct {
 func PostProductionPage(p *PostGenericPageProps) g.Node {
 	p.StockCodePlaceholder = "Enter stock code to produce"
 	p.QtyPlaceholder = "Enter quantity to produce"
+	p.ShowUnitCost = true
+	p.ShowBackflush = true
 
 	content := g.Group([]g.Node{
 		h.P(
@@ -49,8 +74,9 @@ func PostProductionPage(p *PostGenericPageProps) g.Node {
 		),
 		h.P(
 			h.Class("transaction-info"),
-			g.Text(`NOTE: this utility does not consume stock and should be used for
-				corrections with caution.`),
+			g.Text(`NOTE: this utility only consumes stock when a backflush
+				location is chosen, using the bill of materials in effect and
+				taking the lots soonest to expire first.`),
 		),
 
 		h.FormEl(
@@ -72,6 +98,7 @@ func PostProductionPage(p *PostGenericPageProps) g.Node {
 		ctx:             p.Ctx,
 		successText:     p.SuccessText,
 		errorText:       p.ErrorText,
+		printLabelsURL:  p.PrintLabelsURL,
 	})
 }
 
@@ -101,18 +128,16 @@ func formPartialStockCodeLocBinLot(p *PostGenericPageProps) g.Node {
 			),
 		),
 
+		g.Iff(p.ShowFEFOSuggestions, func() g.Node {
+			return fefoSuggestionsRow("Location", "Bin")
+		}),
+
 		h.Div(
 			h.Class("form-row"),
 
 			h.Label(
 				g.Text("Location"),
-				h.Input(
-					h.Type("text"),
-					h.Name("Location"),
-					h.Value(p.Location),
-					h.Placeholder("Enter location"),
-					h.AutoComplete("off"),
-				),
+				locationSelect("Location", p.Location),
 			),
 		),
 
@@ -121,13 +146,7 @@ func formPartialStockCodeLocBinLot(p *PostGenericPageProps) g.Node {
 
 			h.Label(
 				g.Text("Bin"),
-				h.Input(
-					h.Type("text"),
-					h.Name("Bin"),
-					h.Value(p.Bin),
-					h.Placeholder("Enter bin"),
-					h.AutoComplete("off"),
-				),
+				binSelect("Bin", "Location", p.Bin),
 			),
 		),
 
@@ -163,9 +182,28 @@ func formPartialStockCodeLocBinLot(p *PostGenericPageProps) g.Node {
 					h.Placeholder(p.QtyPlaceholder),
 					h.AutoComplete("off"),
 				),
+				negativeStockQtyHelper(p.QtyError),
 			),
 		),
 
+		unitRow(p.Unit),
+
+		g.Iff(p.ShowUnitCost, func() g.Node {
+			return unitCostRow(p.UnitCost)
+		}),
+
+		serialNumbersRow(p.SerialNumbers),
+
+		g.Iff(p.ShowBackflush, func() g.Node {
+			return backflushRows(p.BackflushLocation, p.BackflushBin, p.ReverseBackflush)
+		}),
+
+		g.Iff(p.ShowDemandReference, func() g.Node {
+			return demandReferenceRow(p.DemandReference)
+		}),
+
+		acknowledgeNegativeStockRow(p.NegativeStockWarning),
+
 		h.Div(
 			h.Class("form-row"),
 
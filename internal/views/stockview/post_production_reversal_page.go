@@ -25,6 +25,8 @@ type PostProductionReversalPageProps struct {
 func PostProductionReversalPage(p *PostGenericPageProps) g.Node {
 	p.StockCodePlaceholder = "Enter stock code for production reversal"
 	p.QtyPlaceholder = "Enter quantity"
+	p.ShowBackflush = true
+	p.ReverseBackflush = true
 
 	content := g.Group([]g.Node{
 		h.P(
@@ -39,8 +41,11 @@ func PostProductionReversalPage(p *PostGenericPageProps) g.Node {
 		),
 		h.P(
 			h.Class("transaction-info"),
-			g.Text(`NOTE: this utility does not reverse the consumption of stock
-				and should be used for corrections with caution.`),
+			g.Text(`NOTE: this utility only returns consumed stock when a
+				backflush location is chosen, to the lots backflushed by
+				productions of the lot, and should be used for corrections
+				with caution. Reversing the production transaction itself
+				returns exactly what it backflushed.`),
 		),
 
 		h.FormEl(
@@ -0,0 +1,214 @@
+package stockview
+
+import (
+	"app/internal/components"
+	"app/internal/model"
+	"app/pkg/reqcontext"
+	"fmt"
+	"strings"
+
+	"github.com/shopspring/decimal"
+	g "maragu.dev/gomponents"
+	h "maragu.dev/gomponents/html"
+)
+
+type PostQuarantinePageProps struct {
+	Ctx         reqcontext.ReqContext
+	SuccessText string
+	ErrorText   string
+
+	TransactionType string
+	StockItemID     int
+	Location        string
+	Bin             string
+	LotNumber       string
+	Qty             decimal.Decimal
+	Unit            string
+	SerialNumbers   string
+	Reason          string
+	ScrapReasonID   int
+	TransactionNote string
+
+	QtyError             string
+	NegativeStockWarning bool
+
+	StockItems   []model.StockItem
+	ScrapReasons []model.ScrapReason
+}
+
+func PostQuarantinePage(p *PostQuarantinePageProps) g.Node {
+
+	selectedStockItem := ""
+	if p.StockItemID != 0 {
+		selectedStockItem = fmt.Sprintf("%d", p.StockItemID)
+	}
+
+	content := g.Group([]g.Node{
+		h.P(
+			h.Class("transaction-info"),
+			components.Icon(&components.IconProps{
+				Identifier: "information-outline",
+			}),
+			g.Text(
+				`Use this utility to place stock on QC hold, moving it from STOCK
+				to QUARANTINE at the same location and bin, and to release it
+				back to STOCK or reject it to SCRAP. Stock on hold is not
+				available to use.`),
+		),
+		h.P(
+			h.Class("transaction-info"),
+			g.Text(`NOTE: every posting needs a reason and is approved by the QC
+				approver posting it, so only QC approvers can post here.
+				Rejecting also needs a scrap reason for the waste report.`),
+		),
+
+		h.FormEl(
+			h.Method("POST"),
+
+			h.Div(
+				h.Class("form-row"),
+
+				h.Label(
+					g.Text("Action"),
+					h.Select(
+						h.Name("TransactionType"),
+						g.Group(g.Map(model.QuarantineTransactionTypes, func(t model.StockTransactionType) g.Node {
+							return h.Option(
+								h.Value(string(t)),
+								g.Text(strings.TrimPrefix(string(t), "Quarantine ")),
+								g.If(p.TransactionType == string(t), h.Selected()),
+							)
+						})),
+					),
+				),
+			),
+
+			h.Div(
+				h.Class("form-row"),
+
+				h.Label(
+					g.Text("Stock Code"),
+
+					components.SearchSelect(&components.SearchSelectProps{
+						Name:                 "StockItemID",
+						Placeholder:          "Select Stock Code",
+						Mode:                 "single",
+						Options:              MapStockItemsToOptions(p.StockItems, selectedStockItem),
+						Selected:             selectedStockItem,
+						OptionsEndpoint:      "/get-stock-codes",
+						SearchQueryParamName: "SearchText",
+					}),
+				),
+			),
+
+			h.Div(
+				h.Class("form-row"),
+
+				h.Label(
+					g.Text("Location"),
+					locationSelect("Location", p.Location),
+				),
+			),
+
+			h.Div(
+				h.Class("form-row"),
+
+				h.Label(
+					g.Text("Bin"),
+					binSelect("Bin", "Location", p.Bin),
+				),
+			),
+
+			h.Div(
+				h.Class("form-row"),
+
+				h.Label(
+					g.Text("Lot Number (only if lot tracked)"),
+					h.Input(
+						h.Type("text"),
+						h.Name("LotNumber"),
+						h.Value(p.LotNumber),
+						h.Placeholder("Enter lot number"),
+						h.AutoComplete("off"),
+					),
+				),
+			),
+
+			h.Div(
+				h.Class("form-row"),
+
+				h.Label(
+					g.Text("Qty"),
+					h.Input(
+						h.Type("number"),
+						h.Min("0"),
+						h.Name("Qty"),
+						h.Step("any"),
+						g.If(p.Qty.GreaterThan(decimal.Zero), h.Value(p.Qty.String())),
+						h.Placeholder("Enter quantity"),
+						h.AutoComplete("off"),
+					),
+					negativeStockQtyHelper(p.QtyError),
+				),
+			),
+
+			unitRow(p.Unit),
+
+			serialNumbersRow(p.SerialNumbers),
+
+			h.Div(
+				h.Class("form-row"),
+
+				h.Label(
+					g.Text("Reason"),
+					h.Textarea(
+						h.Name("Reason"),
+						h.Placeholder("Enter why the stock is held, released or rejected"),
+						h.AutoComplete("off"),
+						g.Text(p.Reason),
+					),
+				),
+			),
+
+			h.Div(
+				h.Class("form-row"),
+
+				h.Label(
+					g.Text("Scrap Reason (reject only)"),
+					scrapReasonSelect(p.ScrapReasons, p.ScrapReasonID),
+				),
+			),
+
+			acknowledgeNegativeStockRow(p.NegativeStockWarning),
+
+			h.Div(
+				h.Class("form-row"),
+
+				h.Label(
+					g.Text("Note (optional)"),
+					h.Textarea(
+						h.Name("TransactionNote"),
+						h.Placeholder("Enter transaction note"),
+						h.AutoComplete("off"),
+						g.Text(p.TransactionNote),
+					),
+				),
+			),
+
+			components.Button(
+				&components.ButtonProps{
+					ButtonType: "Primary",
+				},
+				g.Text("Post Quarantine Transaction"),
+			),
+		),
+	})
+
+	return postTransactionPageLayout(&postTransactionPageLayoutProps{
+		transactionType: "Quarantine",
+		content:         content,
+		ctx:             p.Ctx,
+		successText:     p.SuccessText,
+		errorText:       p.ErrorText,
+	})
+}
@@ -0,0 +1,180 @@
+package stockview
+
+import (
+	"app/internal/components"
+	"app/internal/model"
+	"app/pkg/reqcontext"
+	"fmt"
+
+	"github.com/shopspring/decimal"
+	g "maragu.dev/gomponents"
+	h "maragu.dev/gomponents/html"
+)
+
+type PostScrapPageProps struct {
+	Ctx         reqcontext.ReqContext
+	SuccessText string
+	ErrorText   string
+
+	StockItemID     int
+	Location        string
+	Bin             string
+	LotNumber       string
+	Qty             decimal.Decimal
+	Unit            string
+	SerialNumbers   string
+	ScrapReasonID   int
+	DemandReference string
+	TransactionNote string
+
+	QtyError             string
+	NegativeStockWarning bool
+
+	StockItems   []model.StockItem
+	ScrapReasons []model.ScrapReason
+}
+
+func PostScrapPage(p *PostScrapPageProps) g.Node {
+
+	selectedStockItem := ""
+	if p.StockItemID != 0 {
+		selectedStockItem = fmt.Sprintf("%d", p.StockItemID)
+	}
+
+	content := g.Group([]g.Node{
+		h.P(
+			h.Class("transaction-info"),
+			components.Icon(&components.IconProps{
+				Identifier: "information-outline",
+			}),
+			g.Text(
+				`Use this utility to scrap stock, moving it from STOCK to SCRAP
+				at the given location and bin. Scrapped stock shows on the
+				waste report under its scrap reason.`),
+		),
+		h.P(
+			h.Class("transaction-info"),
+			g.Text(`NOTE: use Stock Adjustment for count corrections, not for
+				stock that was damaged, expired or otherwise thrown away.`),
+		),
+
+		h.FormEl(
+			h.Method("POST"),
+
+			h.Div(
+				h.Class("form-row"),
+
+				h.Label(
+					g.Text("Stock Code"),
+
+					components.SearchSelect(&components.SearchSelectProps{
+						Name:                 "StockItemID",
+						Placeholder:          "Select Stock Code",
+						Mode:                 "single",
+						Options:              MapStockItemsToOptions(p.StockItems, selectedStockItem),
+						Selected:             selectedStockItem,
+						OptionsEndpoint:      "/get-stock-codes",
+						SearchQueryParamName: "SearchText",
+					}),
+				),
+			),
+
+			h.Div(
+				h.Class("form-row"),
+
+				h.Label(
+					g.Text("Location"),
+					locationSelect("Location", p.Location),
+				),
+			),
+
+			h.Div(
+				h.Class("form-row"),
+
+				h.Label(
+					g.Text("Bin"),
+					binSelect("Bin", "Location", p.Bin),
+				),
+			),
+
+			h.Div(
+				h.Class("form-row"),
+
+				h.Label(
+					g.Text("Lot Number (only if lot tracked)"),
+					h.Input(
+						h.Type("text"),
+						h.Name("LotNumber"),
+						h.Value(p.LotNumber),
+						h.Placeholder("Enter lot number"),
+						h.AutoComplete("off"),
+					),
+				),
+			),
+
+			h.Div(
+				h.Class("form-row"),
+
+				h.Label(
+					g.Text("Qty"),
+					h.Input(
+						h.Type("number"),
+						h.Min("0"),
+						h.Name("Qty"),
+						h.Step("any"),
+						g.If(p.Qty.GreaterThan(decimal.Zero), h.Value(p.Qty.String())),
+						h.Placeholder("Enter quantity"),
+						h.AutoComplete("off"),
+					),
+					negativeStockQtyHelper(p.QtyError),
+				),
+			),
+
+			unitRow(p.Unit),
+
+			serialNumbersRow(p.SerialNumbers),
+
+			h.Div(
+				h.Class("form-row"),
+
+				h.Label(
+					g.Text("Scrap Reason"),
+					scrapReasonSelect(p.ScrapReasons, p.ScrapReasonID),
+				),
+			),
+
+			demandReferenceRow(p.DemandReference),
+
+			acknowledgeNegativeStockRow(p.NegativeStockWarning),
+
+			h.Div(
+				h.Class("form-row"),
+
+				h.Label(
+					g.Text("Note (optional)"),
+					h.Textarea(
+						h.Name("TransactionNote"),
+						h.Placeholder("Enter transaction note"),
+						h.AutoComplete("off"),
+						g.Text(p.TransactionNote),
+					),
+				),
+			),
+
+			components.Button(
+				&components.ButtonProps{
+					ButtonType: "Primary",
+				},
+				g.Text("Post Scrap Transaction"),
+			),
+		),
+	})
+
+	return postTransactionPageLayout(&postTransactionPageLayoutProps{
+		transactionType: "Scrap",
+		content:         content,
+		ctx:             p.Ctx,
+		successText:     p.SuccessText,
+		errorText:       p.ErrorText,
+	})
+}
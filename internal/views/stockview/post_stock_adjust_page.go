@@ -25,6 +25,7 @@ type PostStockAdjustPageProps struct {
 func PostStockAdjustPage(p *PostGenericPageProps) g.Node {
 	p.StockCodePlaceholder = "Enter stock code for stock adjust"
 	p.QtyPlaceholder = "Enter quantity"
+	p.ShowUnitCost = true
 
 	content := g.Group([]g.Node{
 		h.P(
@@ -16,16 +16,23 @@ type PostStockMovementPageProps struct {
 	Ctx         reqcontext.ReqContext
 	SuccessText string
 	ErrorText   string
-	ReturnTo    *string
+	// PrintLabelsURL links to printing labels for the stock just posted
+	PrintLabelsURL string
+	ReturnTo       *string
 
 	StockItemID     int
 	LotNumber       string
 	Qty             decimal.Decimal
+	Unit            string
 	FromLocation    string
 	FromBin         string
 	ToLocation      string
 	ToBin           string
 	TransactionNote string
+	SerialNumbers   string
+
+	QtyError             string
+	NegativeStockWarning bool
 
 	StockItems []model.StockItem
 }
@@ -58,6 +65,8 @@ func PostStockMovementPage(p *PostStockMovementPageProps) g.Node {
 			),
 		),
 
+		fefoSuggestionsRow("FromLocation", "FromBin"),
+
 		h.Div(
 			h.Class("form-row"),
 
@@ -90,31 +99,26 @@ func PostStockMovementPage(p *PostStockMovementPageProps) g.Node {
 					h.Placeholder("Enter quantity"),
 					h.AutoComplete("off"),
 				),
+				negativeStockQtyHelper(p.QtyError),
 			),
 		),
 
+		unitRow(p.Unit),
+
+		serialNumbersRow(p.SerialNumbers),
+
+		acknowledgeNegativeStockRow(p.NegativeStockWarning),
+
 		h.Div(
 			h.Class("form-row"),
 
 			h.Label(
 				g.Text("From Location"),
-				h.Input(
-					h.Type("text"),
-					h.Name("FromLocation"),
-					h.Value(p.FromLocation),
-					h.Placeholder("Enter location (from)"),
-					h.AutoComplete("off"),
-				),
+				locationSelect("FromLocation", p.FromLocation),
 			),
 			h.Label(
 				g.Text("To Location"),
-				h.Input(
-					h.Type("text"),
-					h.Name("ToLocation"),
-					h.Value(p.ToLocation),
-					h.Placeholder("Enter location (to)"),
-					h.AutoComplete("off"),
-				),
+				locationSelect("ToLocation", p.ToLocation),
 			),
 		),
 
@@ -123,23 +127,11 @@ func PostStockMovementPage(p *PostStockMovementPageProps) g.Node {
 
 			h.Label(
 				g.Text("From Bin"),
-				h.Input(
-					h.Type("text"),
-					h.Name("FromBin"),
-					h.Value(p.FromBin),
-					h.Placeholder("Enter bin (from)"),
-					h.AutoComplete("off"),
-				),
+				binSelect("FromBin", "FromLocation", p.FromBin),
 			),
 			h.Label(
 				g.Text("To Bin"),
-				h.Input(
-					h.Type("text"),
-					h.Name("ToBin"),
-					h.Value(p.ToBin),
-					h.Placeholder("Enter bin (to)"),
-					h.AutoComplete("off"),
-				),
+				binSelect("ToBin", "ToLocation", p.ToBin),
 			),
 		),
 
@@ -181,6 +173,7 @@ func PostStockMovementPage(p *PostStockMovementPageProps) g.Node {
 		ctx:             p.Ctx,
 		successText:     p.SuccessText,
 		errorText:       p.ErrorText,
+		printLabelsURL:  p.PrintLabelsURL,
 	})
 }
 
@@ -3,8 +3,11 @@ package stockview
 import (
 	"app/internal/components"
 	"app/internal/layout"
+	"app/internal/model"
 	"app/pkg/reqcontext"
+	"fmt"
 
+	"github.com/shopspring/decimal"
 	g "maragu.dev/gomponents"
 	h "maragu.dev/gomponents/html"
 )
@@ -15,6 +18,8 @@ type postTransactionPageLayoutProps struct {
 	transactionType string
 	errorText       string
 	successText     string
+	// printLabelsURL links to printing labels for the stock just posted
+	printLabelsURL string
 }
 
 func postTransactionPageLayout(p *postTransactionPageLayoutProps) g.Node {
@@ -42,6 +47,15 @@ func postTransactionPageLayout(p *postTransactionPageLayoutProps) g.Node {
 	}, {
 		title:    "Stock Adjustment",
 		linkPart: "stock-adjustment",
+	}, {
+		title:    "Quarantine",
+		linkPart: "quarantine",
+	}, {
+		title:    "Scrap",
+		linkPart: "scrap",
+	}, {
+		title:    "Custom",
+		linkPart: "custom",
 	}}
 
 	content := components.Card(
@@ -74,6 +88,13 @@ func postTransactionPageLayout(p *postTransactionPageLayoutProps) g.Node {
 			h.Div(
 				h.Class("success-msg"),
 				g.Text(p.successText),
+				g.If(
+					p.printLabelsURL != "",
+					g.Group([]g.Node{
+						g.Text(" "),
+						h.A(h.Href(p.printLabelsURL), g.Text("Print labels")),
+					}),
+				),
 			),
 		),
 	)
@@ -104,3 +125,209 @@ func postTransactionPageLayout(p *postTransactionPageLayoutProps) g.Node {
 		},
 	})
 }
+
+// negativeStockQtyHelper renders a negative stock error beneath the qty input
+func negativeStockQtyHelper(qtyError string) g.Node {
+	return g.If(
+		qtyError != "",
+		h.Div(
+			h.Class("input-helper error"),
+			g.Text(qtyError),
+		),
+	)
+}
+
+// unitRow lets the quantity be entered in an alternate unit of the stock
+// item. It is converted to the base unit when posted.
+func unitRow(unit string) g.Node {
+	return h.Div(
+		h.Class("form-row"),
+
+		h.Label(
+			g.Text("Unit (optional, defaults to base unit)"),
+			h.Input(
+				h.Type("text"),
+				h.Name("Unit"),
+				h.Value(unit),
+				h.Placeholder("Enter unit"),
+				h.AutoComplete("off"),
+			),
+		),
+	)
+}
+
+// unitCostRow takes the cost of each base unit posted into STOCK. Stock
+// posted without a cost is valued at the current average cost.
+func unitCostRow(unitCost *decimal.Decimal) g.Node {
+	return h.Div(
+		h.Class("form-row"),
+
+		h.Label(
+			g.Text("Unit Cost (optional, per base unit)"),
+			h.Input(
+				h.Type("number"),
+				h.Min("0"),
+				h.Step("any"),
+				h.Name("UnitCost"),
+				g.If(unitCost != nil, h.Value(unitCost.String())),
+				h.Placeholder("Defaults to current average cost"),
+				h.AutoComplete("off"),
+			),
+		),
+	)
+}
+
+// backflushRows take the location and bin to consume the components of the
+// bill of materials from, or to return them to when reversing. Nothing is
+// consumed or returned if no location is chosen.
+func backflushRows(location string, bin string, reverse bool) g.Node {
+	locationLabel := "Backflush From Location (optional, consumes the bill of materials)"
+	binLabel := "Backflush From Bin"
+	if reverse {
+		locationLabel = "Return Backflush To Location (optional, returns the backflushed components)"
+		binLabel = "Return Backflush To Bin"
+	}
+
+	return g.Group([]g.Node{
+		h.Div(
+			h.Class("form-row"),
+
+			h.Label(
+				g.Text(locationLabel),
+				locationSelect("BackflushLocation", location),
+			),
+		),
+
+		h.Div(
+			h.Class("form-row"),
+
+			h.Label(
+				g.Text(binLabel),
+				binSelect("BackflushBin", "BackflushLocation", bin),
+			),
+		),
+	})
+}
+
+// serialNumbersRow takes the serial numbers moved of a serialised stock item,
+// separated by commas or new lines
+func serialNumbersRow(serialNumbers string) g.Node {
+	return h.Div(
+		h.Class("form-row"),
+
+		h.Label(
+			g.Text("Serial Numbers (serialised items only)"),
+			h.Textarea(
+				h.Name("SerialNumbers"),
+				h.Placeholder("Enter one serial number per unit"),
+				h.AutoComplete("off"),
+				g.Text(serialNumbers),
+			),
+		),
+	)
+}
+
+// demandReferenceRow takes the order or job a posting is for. Stock reserved
+// for it can be consumed by the posting.
+func demandReferenceRow(demandReference string) g.Node {
+	return h.Div(
+		h.Class("form-row"),
+
+		h.Label(
+			g.Text("Demand Reference (optional, to use reserved stock)"),
+			h.Input(
+				h.Type("text"),
+				h.Name("DemandReference"),
+				h.Value(demandReference),
+				h.Placeholder("Enter order or job reference"),
+				h.AutoComplete("off"),
+			),
+		),
+	)
+}
+
+// acknowledgeNegativeStockRow lets the user post anyway when the negative
+// stock policy only warns
+func acknowledgeNegativeStockRow(negativeStockWarning bool) g.Node {
+	return g.If(
+		negativeStockWarning,
+		h.Div(
+			h.Class("form-row"),
+
+			h.Label(
+				h.Class("checkbox"),
+				h.Input(
+					h.Type("checkbox"),
+					h.Name("AcknowledgeNegativeStock"),
+					h.Value("true"),
+				),
+				g.Text("Post anyway (acknowledge negative stock)"),
+			),
+		),
+	)
+}
+
+// scrapReasonSelect picks one of the active scrap reasons
+func scrapReasonSelect(scrapReasons []model.ScrapReason, selected int) g.Node {
+	return h.Select(
+		h.Name("ScrapReasonID"),
+		h.Option(h.Value(""), g.Text("Select scrap reason")),
+		g.Group(g.Map(scrapReasons, func(sr model.ScrapReason) g.Node {
+			text := sr.Code
+			if sr.Description != "" {
+				text = fmt.Sprintf("%s \u2013 %s", sr.Code, sr.Description)
+			}
+			return h.Option(
+				h.Value(fmt.Sprintf("%d", sr.ScrapReasonID)),
+				g.Text(text),
+				g.If(selected == sr.ScrapReasonID, h.Selected()),
+			)
+		})),
+	)
+}
+
+// locationSelect picks one of the active locations
+func locationSelect(name string, selected string) g.Node {
+	options := []components.SearchSelectOption{}
+	if selected != "" {
+		options = append(options, components.SearchSelectOption{
+			Text:  selected,
+			Value: selected,
+		})
+	}
+
+	return components.SearchSelect(&components.SearchSelectProps{
+		Name:            name,
+		Placeholder:     "Select location",
+		Mode:            "single",
+		Options:         options,
+		Selected:        selected,
+		OptionsEndpoint: "/stock/get-locations",
+	})
+}
+
+// binSelect picks one of the active bins of the location selected in the
+// named location field, or no bin. The bins are fetched each time it is
+// opened so that they follow the location.
+func binSelect(name string, locationField string, selected string) g.Node {
+	options := []components.SearchSelectOption{{
+		Text:  "No bin",
+		Value: "",
+	}}
+	if selected != "" {
+		options = append(options, components.SearchSelectOption{
+			Text:  selected,
+			Value: selected,
+		})
+	}
+
+	return components.SearchSelect(&components.SearchSelectProps{
+		Name:              name,
+		Placeholder:       "No bin",
+		Mode:              "single",
+		Options:           options,
+		Selected:          selected,
+		OptionsEndpoint:   "/stock/get-bins/" + locationField,
+		LoadOptionsOnOpen: true,
+	})
+}
@@ -0,0 +1,460 @@
+package stockview
+
+import (
+	"app/internal/components"
+	"app/internal/layout"
+	"app/internal/model"
+	"app/pkg/nilsafe"
+	"app/pkg/reqcontext"
+	"app/pkg/validate"
+	"fmt"
+	"net/url"
+	"time"
+
+	g "maragu.dev/gomponents"
+	c "maragu.dev/gomponents/components"
+	h "maragu.dev/gomponents/html"
+)
+
+type PurchaseOrderPageProps struct {
+	Ctx           reqcontext.ReqContext
+	PurchaseOrder model.PurchaseOrder
+	Lines         []model.PurchaseOrderLine
+	Receipts      []model.StockTransactionEntry
+	StockItems    []model.StockItem
+	CanEdit       bool
+	ErrorText     string
+
+	// Add line form state
+	LineValues           url.Values
+	LineValidationErrors validate.ValidationErrors
+	IsLineSubmission     bool
+}
+
+func PurchaseOrderPage(p *PurchaseOrderPageProps) g.Node {
+
+	po := p.PurchaseOrder
+	isDraft := po.Status == model.DraftPurchaseOrderStatus
+	isOpen := po.Status == model.OpenPurchaseOrderStatus
+
+	hasReceipts := false
+	for _, l := range p.Lines {
+		if !l.ReceivedQty.IsZero() {
+			hasReceipts = true
+			break
+		}
+	}
+
+	type attribute struct {
+		label string
+		value g.Node
+	}
+
+	note := "\u2013"
+	if po.Note != "" {
+		note = po.Note
+	}
+
+	createdBy := g.Group([]g.Node{
+		g.Textf("%s on ", nilsafe.Str(po.CreatedByUsername)),
+		h.Span(h.Class("local-datetime"), g.Text(po.CreatedAt.Format(time.RFC3339))),
+	})
+	updatedBy := g.Group([]g.Node{
+		g.Textf("%s on ", nilsafe.Str(po.UpdatedByUsername)),
+		h.Span(h.Class("local-datetime"), g.Text(po.UpdatedAt.Format(time.RFC3339))),
+	})
+
+	supplier := g.Textf("%s \u2013 %s", po.SupplierCode, po.SupplierName)
+	if p.CanEdit {
+		supplier = h.A(
+			h.Href(fmt.Sprintf("/stock/suppliers/%d", po.SupplierID)),
+			supplier,
+		)
+	}
+
+	attributes := []attribute{
+		{label: "Reference", value: g.Text(po.Reference)},
+		{label: "Supplier", value: supplier},
+		{label: "Order Date", value: g.Text(po.OrderDate.Format("2006-01-02"))},
+		{label: "Over Receipt Tolerance", value: g.Text(po.OverReceiptTolerance.Shift(2).String() + "%")},
+		{label: "Note", value: g.Text(note)},
+		{label: "Created By", value: createdBy},
+		{label: "Updated By", value: updatedBy},
+	}
+
+	content := g.Group([]g.Node{
+		h.Nav(
+			h.Class("stock-nav"),
+			h.A(h.Href("/stock/purchase-orders"), g.Text("Purchase orders")),
+			g.If(
+				isOpen && p.CanEdit,
+				h.A(
+					h.Href(fmt.Sprintf("/stock/purchase-orders/%d/receive", po.PurchaseOrderID)),
+					g.Text("Receive stock"),
+				),
+			),
+		),
+
+		h.Ul(
+			h.Class("attributes-list"),
+
+			g.Group(g.Map(attributes, func(a attribute) g.Node {
+				return h.Li(
+					components.Icon(&components.IconProps{
+						Identifier: "arrow-right-thin",
+					}),
+					h.Strong(g.Textf("%s: ", a.label)),
+					h.Span(a.value),
+				)
+			})),
+		),
+
+		g.If(
+			p.ErrorText != "",
+			h.Div(
+				h.Class("error-msg"),
+				g.Text(p.ErrorText),
+			),
+		),
+
+		h.H3(g.Text("Lines")),
+
+		purchaseOrderLinesTable(&purchaseOrderLinesTableProps{
+			purchaseOrderID: po.PurchaseOrderID,
+			lines:           p.Lines,
+			canEdit:         (isDraft || isOpen) && p.CanEdit,
+			canReceive:      isOpen && p.CanEdit,
+		}),
+
+		g.If(
+			(isDraft || isOpen) && p.CanEdit,
+			g.Group([]g.Node{
+				h.H3(g.Text("Add Line")),
+				addPurchaseOrderLineForm(&addPurchaseOrderLineFormProps{
+					purchaseOrderID:  po.PurchaseOrderID,
+					stockItems:       p.StockItems,
+					values:           p.LineValues,
+					validationErrors: p.LineValidationErrors,
+					isSubmission:     p.IsLineSubmission,
+				}),
+			}),
+		),
+
+		g.If(
+			len(p.Receipts) > 0,
+			g.Group([]g.Node{
+				h.H3(g.Text("Receipts")),
+				transactionsTable(&transactionsTableProps{
+					stockTransactions: p.Receipts,
+				}),
+			}),
+		),
+	})
+
+	return layout.Page(layout.PageProps{
+		Ctx:   p.Ctx,
+		Title: fmt.Sprintf("Purchase Order - %s", po.Reference),
+		Header: &layout.PageHeaderProps{
+			Title: h.Div(
+				h.Class("stock-document-page-title"),
+				h.H1(g.Textf("Purchase Order \u2013 %s", po.Reference)),
+				purchaseOrderStatusBadge(po.Status),
+			),
+			Actions: purchaseOrderActions(&purchaseOrderActionsProps{
+				purchaseOrderID: po.PurchaseOrderID,
+				canIssue:        isDraft && p.CanEdit && len(p.Lines) > 0,
+				canClose:        isOpen && p.CanEdit,
+				canCancel:       (isDraft || isOpen) && p.CanEdit && !hasReceipts,
+			}),
+		},
+		Content: content,
+		Breadcrumbs: []layout.Breadcrumb{
+			layout.HomeBreadcrumb,
+			{
+				IconIdentifier: "package-variant-closed",
+				Title:          "Stock",
+				URLPart:        "stock",
+			},
+			{
+				Title:   "Purchase Orders",
+				URLPart: "purchase-orders",
+			},
+			{
+				Title: po.Reference,
+			},
+		},
+		AppendHead: []g.Node{
+			components.InlineStyle("/internal/views/stockview/stock_table.css"),
+			components.InlineStyle("/internal/views/stockview/stock_document_page.css"),
+			components.InlineStyle("/internal/views/stockview/purchase_order_page.css"),
+			components.InlineScript("/internal/views/stockview/purchase_order_page.js"),
+		},
+	})
+}
+
+type purchaseOrderActionsProps struct {
+	purchaseOrderID int
+	canIssue        bool
+	canClose        bool
+	canCancel       bool
+}
+
+func purchaseOrderActions(p *purchaseOrderActionsProps) []g.Node {
+	actions := []g.Node{}
+
+	if p.canIssue {
+		actions = append(actions, h.Form(
+			h.Method("POST"),
+			h.Class("purchase-order-action-form"),
+			h.Action(fmt.Sprintf("/stock/purchase-orders/%d/issue", p.purchaseOrderID)),
+			g.Attr("data-confirm", "Issue this purchase order? Its lines will count as on order."),
+			h.Button(
+				h.Class("button primary"),
+				h.Type("submit"),
+				components.Icon(&components.IconProps{
+					Identifier: "check",
+				}),
+				g.Text("Issue Order"),
+			),
+		))
+	}
+
+	if p.canClose {
+		actions = append(actions, h.Form(
+			h.Method("POST"),
+			h.Class("purchase-order-action-form"),
+			h.Action(fmt.Sprintf("/stock/purchase-orders/%d/close", p.purchaseOrderID)),
+			g.Attr("data-confirm", "Close this purchase order? Nothing more will be expected against it."),
+			h.Button(
+				h.Class("button secondary"),
+				h.Type("submit"),
+				g.Text("Close Order"),
+			),
+		))
+	}
+
+	if p.canCancel {
+		actions = append(actions, h.Form(
+			h.Method("POST"),
+			h.Class("purchase-order-action-form"),
+			h.Action(fmt.Sprintf("/stock/purchase-orders/%d/cancel", p.purchaseOrderID)),
+			g.Attr("data-confirm", "Cancel this purchase order?"),
+			h.Button(
+				h.Class("button secondary"),
+				h.Type("submit"),
+				g.Text("Cancel Order"),
+			),
+		))
+	}
+
+	return actions
+}
+
+type purchaseOrderLinesTableProps struct {
+	purchaseOrderID int
+	lines           []model.PurchaseOrderLine
+	canEdit         bool
+	canReceive      bool
+}
+
+func purchaseOrderLinesTable(p *purchaseOrderLinesTableProps) g.Node {
+
+	columns := components.TableColumns{
+		{TitleContents: g.Text("Stock Code")},
+		{TitleContents: g.Text("Description")},
+		{TitleContents: g.Text("Ordered"), Classes: c.Classes{"text-right": true}},
+		{TitleContents: g.Text("Received"), Classes: c.Classes{"text-right": true}},
+		{TitleContents: g.Text("Outstanding"), Classes: c.Classes{"text-right": true}},
+		{TitleContents: g.Text("Due")},
+		{TitleContents: g.Text("Unit Price"), Classes: c.Classes{"text-right": true}},
+		{TitleContents: g.Text("Status")},
+	}
+	if p.canEdit {
+		columns = append(columns, components.TableColumn{TitleContents: g.Text("")})
+	}
+
+	var rows components.TableRows
+	for _, l := range p.lines {
+
+		unitPrice := "\u2013"
+		if l.UnitPrice != nil {
+			unitPrice = l.UnitPrice.String()
+		}
+
+		outstanding := "\u2013"
+		if l.Status == model.OpenPurchaseOrderLineStatus {
+			outstanding = quantityWithUnit(l.OutstandingQty(), l.Unit)
+		}
+
+		cells := []components.TableCell{
+			{Contents: components.StockItemAnchor(l.StockCode)},
+			{Contents: g.Text(l.Description)},
+			{Contents: g.Text(quantityWithUnit(l.Qty, l.Unit)), Classes: c.Classes{"text-right": true}},
+			{Contents: g.Text(quantityWithUnit(l.ReceivedQty, l.Unit)), Classes: c.Classes{"text-right": true}},
+			{Contents: g.Text(outstanding), Classes: c.Classes{"text-right": true}},
+			{Contents: g.Text(l.DueDate.Format("2006-01-02"))},
+			{Contents: g.Text(unitPrice), Classes: c.Classes{"text-right": true}},
+			{Contents: purchaseOrderLineStatusBadge(l.Status)},
+		}
+
+		if p.canEdit {
+			actions := []g.Node{}
+
+			if p.canReceive && l.Status != model.ClosedPurchaseOrderLineStatus {
+				receiveParams := url.Values{}
+				receiveParams.Set("PurchaseOrderLineID", fmt.Sprintf("%d", l.PurchaseOrderLineID))
+				actions = append(actions, h.A(
+					h.Class("button primary small"),
+					h.Href(fmt.Sprintf(
+						"/stock/purchase-orders/%d/receive?%s", p.purchaseOrderID, receiveParams.Encode(),
+					)),
+					g.Text("Receive"),
+				))
+			}
+
+			if p.canReceive && l.Status == model.OpenPurchaseOrderLineStatus && !l.ReceivedQty.IsZero() {
+				actions = append(actions, h.Form(
+					h.Method("POST"),
+					h.Class("purchase-order-action-form"),
+					h.Action(fmt.Sprintf(
+						"/stock/purchase-orders/%d/lines/%d/close", p.purchaseOrderID, l.PurchaseOrderLineID,
+					)),
+					g.Attr("data-confirm", "Close this line short? The outstanding quantity will no longer be expected."),
+					h.Button(
+						h.Class("button secondary small"),
+						h.Type("submit"),
+						g.Text("Close"),
+					),
+				))
+			}
+
+			if l.ReceivedQty.IsZero() {
+				actions = append(actions, h.Form(
+					h.Method("POST"),
+					h.Action(fmt.Sprintf(
+						"/stock/purchase-orders/%d/lines/%d/delete", p.purchaseOrderID, l.PurchaseOrderLineID,
+					)),
+					h.Button(
+						h.Class("button secondary small"),
+						h.Type("submit"),
+						g.Text("Remove"),
+					),
+				))
+			}
+
+			cells = append(cells, components.TableCell{
+				Contents: h.Div(h.Class("purchase-order-line-actions"), g.Group(actions)),
+			})
+		}
+
+		rows = append(rows, components.TableRow{
+			Cells: cells,
+		})
+	}
+
+	return components.Table(&components.TableProps{
+		Classes: c.Classes{"stock-table": true},
+		Columns: columns,
+		Rows:    rows,
+	})
+}
+
+type addPurchaseOrderLineFormProps struct {
+	purchaseOrderID  int
+	stockItems       []model.StockItem
+	values           url.Values
+	validationErrors validate.ValidationErrors
+	isSubmission     bool
+}
+
+func addPurchaseOrderLineForm(p *addPurchaseOrderLineFormProps) g.Node {
+
+	fieldError := func(key, label string) g.Node {
+		if !p.isSubmission {
+			return nil
+		}
+		errorText := p.validationErrors.GetError(key, label)
+		if errorText == "" {
+			return nil
+		}
+		return components.InputHelper(&components.InputHelperProps{
+			Label: errorText,
+			Type:  components.InputHelperTypeError,
+		})
+	}
+
+	selectedStockItem := p.values.Get("StockItemID")
+
+	return h.Form(
+		h.Method("POST"),
+		h.Class("form stock-document-line-form"),
+		h.Action(fmt.Sprintf("/stock/purchase-orders/%d/lines", p.purchaseOrderID)),
+
+		h.Div(
+			h.Label(
+				g.Text("Stock Code"),
+				components.SearchSelect(&components.SearchSelectProps{
+					Name:                 "StockItemID",
+					Placeholder:          "Select Stock Code",
+					Mode:                 "single",
+					Options:              MapStockItemsToOptions(p.stockItems, selectedStockItem),
+					Selected:             selectedStockItem,
+					OptionsEndpoint:      "/get-stock-codes",
+					SearchQueryParamName: "SearchText",
+				}),
+			),
+			fieldError("StockItemID", "Stock Code"),
+		),
+
+		h.Div(
+			h.Label(
+				g.Text("Qty (in base units)"),
+				h.Input(
+					h.Type("number"),
+					h.Min("0"),
+					h.Step("any"),
+					h.Name("Qty"),
+					h.Value(p.values.Get("Qty")),
+					h.Placeholder("Enter quantity"),
+					h.AutoComplete("off"),
+				),
+			),
+			fieldError("Qty", "Qty"),
+		),
+
+		h.Div(
+			h.Label(
+				g.Text("Due Date"),
+				h.Input(
+					h.Type("date"),
+					h.Name("DueDate"),
+					h.Value(p.values.Get("DueDate")),
+					h.AutoComplete("off"),
+				),
+			),
+			fieldError("DueDate", "Due Date"),
+		),
+
+		h.Div(
+			h.Label(
+				g.Text("Unit Price (optional, per base unit)"),
+				h.Input(
+					h.Type("number"),
+					h.Min("0"),
+					h.Step("any"),
+					h.Name("UnitPrice"),
+					h.Value(p.values.Get("UnitPrice")),
+					h.Placeholder("Defaults to current average cost on receipt"),
+					h.AutoComplete("off"),
+				),
+			),
+			fieldError("UnitPrice", "Unit Price"),
+		),
+
+		h.Button(
+			h.Class("button primary"),
+			h.Type("submit"),
+			g.Text("Add Line"),
+		),
+	)
+}
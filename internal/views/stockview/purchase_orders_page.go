@@ -0,0 +1,244 @@
+package stockview
+
+import (
+	"app/internal/components"
+	"app/internal/layout"
+	"app/internal/model"
+	"app/pkg/reqcontext"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	g "maragu.dev/gomponents"
+	c "maragu.dev/gomponents/components"
+	h "maragu.dev/gomponents/html"
+)
+
+type PurchaseOrdersPageProps struct {
+	Ctx                 reqcontext.ReqContext
+	PurchaseOrders      []model.PurchaseOrder
+	PurchaseOrdersCount int
+	Suppliers           []model.Supplier
+	Status              string
+	SupplierID          int
+	StockCode           string
+	Page                int
+	PageSize            int
+}
+
+// PurchaseOrdersURL links to the purchase orders with a line for the stock
+// code
+func PurchaseOrdersURL(stockCode string) string {
+	params := url.Values{}
+	params.Set("StockCode", stockCode)
+	return "/stock/purchase-orders?" + params.Encode()
+}
+
+func PurchaseOrdersPage(p *PurchaseOrdersPageProps) g.Node {
+
+	perms := p.Ctx.User.Permissions
+
+	content := h.FormEl(
+		h.Method("GET"),
+
+		h.Nav(
+			h.Class("stock-nav"),
+			h.A(h.Href("/stock"), g.Text("Stock levels")),
+			h.A(h.Href("/stock/replenishment"), g.Text("Replenishment")),
+			g.If(
+				perms.SupplyChain.Admin,
+				h.A(h.Href("/stock/suppliers"), g.Text("Suppliers")),
+			),
+			g.If(
+				perms.SupplyChain.Admin,
+				h.A(h.Href("/stock/purchase-orders/add"), g.Text("New purchase order")),
+			),
+		),
+
+		h.H3(g.Text("Purchase Orders")),
+
+		h.Div(
+			h.Class("stock-levels-filters"),
+
+			h.Label(
+				h.Class("filter"),
+				g.Text("Status"),
+				h.Select(
+					h.Class("lg"),
+					h.Name("Status"),
+					h.Option(h.Value(""), g.Text("All")),
+					g.Group(g.Map(model.PurchaseOrderStatuses, func(s model.PurchaseOrderStatus) g.Node {
+						return h.Option(
+							h.Value(string(s)),
+							g.Text(string(s)),
+							g.If(p.Status == string(s), h.Selected()),
+						)
+					})),
+				),
+			),
+
+			h.Label(
+				h.Class("filter"),
+				g.Text("Supplier"),
+				h.Select(
+					h.Class("lg"),
+					h.Name("SupplierID"),
+					h.Option(h.Value(""), g.Text("All")),
+					g.Group(g.Map(p.Suppliers, func(s model.Supplier) g.Node {
+						return h.Option(
+							h.Value(strconv.Itoa(s.SupplierID)),
+							g.Textf("%s \u2013 %s", s.Code, s.Name),
+							g.If(p.SupplierID == s.SupplierID, h.Selected()),
+						)
+					})),
+				),
+			),
+
+			h.Label(
+				h.Class("filter"),
+				g.Text("Stock Code"),
+				h.Input(
+					h.Class("lg"),
+					h.Name("StockCode"),
+					h.Value(p.StockCode),
+					h.AutoComplete("off"),
+					h.Placeholder("Stock code"),
+				),
+			),
+
+			h.Div(
+				h.Class("go-button-wrapper"),
+				components.Button(&components.ButtonProps{
+					ButtonType: components.ButtonPrimary,
+					Classes:    c.Classes{"go-button": true},
+					Size:       components.ButtonLg,
+				},
+					h.Type("button"),
+					g.Attr("onclick", "submitTableForm(this.form)"),
+					g.Text("GO"),
+				),
+			),
+		),
+
+		components.Divider(),
+
+		purchaseOrdersTable(&purchaseOrdersTableProps{
+			purchaseOrders:      p.PurchaseOrders,
+			purchaseOrdersCount: p.PurchaseOrdersCount,
+			page:                p.Page,
+			pageSize:            p.PageSize,
+		}),
+	)
+
+	return layout.Page(layout.PageProps{
+		Title:   "Purchase Orders",
+		Content: content,
+		Ctx:     p.Ctx,
+		Breadcrumbs: []layout.Breadcrumb{
+			layout.HomeBreadcrumb,
+			{
+				IconIdentifier: "package-variant-closed",
+				Title:          "Stock",
+				URLPart:        "stock",
+			},
+			{
+				Title: "Purchase Orders",
+			},
+		},
+		AppendHead: []g.Node{
+			components.InlineStyle("/internal/views/stockview/stock_table.css"),
+		},
+	})
+}
+
+type purchaseOrdersTableProps struct {
+	purchaseOrders      []model.PurchaseOrder
+	purchaseOrdersCount int
+	page                int
+	pageSize            int
+}
+
+func purchaseOrdersTable(p *purchaseOrdersTableProps) g.Node {
+
+	columns := components.TableColumns{
+		{TitleContents: g.Text("Reference")},
+		{TitleContents: g.Text("Supplier")},
+		{TitleContents: g.Text("Order Date")},
+		{TitleContents: g.Text("Status")},
+		{TitleContents: g.Text("Lines"), Classes: c.Classes{"text-right": true}},
+		{TitleContents: g.Text("Created By")},
+		{TitleContents: g.Text("Created")},
+	}
+
+	var rows components.TableRows
+	for _, po := range p.purchaseOrders {
+
+		purchaseOrderHref := fmt.Sprintf("/stock/purchase-orders/%d", po.PurchaseOrderID)
+
+		createdBy := "\u2013"
+		if po.CreatedByUsername != nil {
+			createdBy = *po.CreatedByUsername
+		}
+
+		rows = append(rows, components.TableRow{
+			Cells: []components.TableCell{
+				{Contents: h.A(h.Href(purchaseOrderHref), g.Text(po.Reference))},
+				{Contents: g.Textf("%s \u2013 %s", po.SupplierCode, po.SupplierName)},
+				{Contents: g.Text(po.OrderDate.Format("2006-01-02"))},
+				{Contents: purchaseOrderStatusBadge(po.Status)},
+				{Contents: g.Textf("%d", po.LineCount), Classes: c.Classes{"text-right": true}},
+				{Contents: g.Text(createdBy)},
+				{Contents: h.Span(h.Class("local-datetime"), g.Text(po.CreatedAt.Format(time.RFC3339)))},
+			},
+			HREF: purchaseOrderHref,
+		})
+	}
+
+	return components.Table(&components.TableProps{
+		Classes: c.Classes{"stock-table": true},
+		Columns: columns,
+		Rows:    rows,
+		Pagination: &components.TablePaginationProps{
+			TotalRecords:        p.purchaseOrdersCount,
+			PageSize:            p.pageSize,
+			CurrentPage:         p.page,
+			CurrentPageQueryKey: "Page",
+			PageSizeQueryKey:    "PageSize",
+		},
+	})
+}
+
+func purchaseOrderStatusBadge(status model.PurchaseOrderStatus) g.Node {
+
+	badgeType := components.BadgeSecondary
+	switch status {
+	case model.DraftPurchaseOrderStatus:
+		badgeType = components.BadgeWarning
+	case model.OpenPurchaseOrderStatus:
+		badgeType = components.BadgePrimary
+	case model.ClosedPurchaseOrderStatus:
+		badgeType = components.BadgeSuccess
+	}
+
+	return components.Badge(&components.BadgeProps{
+		Type: badgeType,
+		Size: components.BadgeSm,
+	}, g.Text(string(status)))
+}
+
+func purchaseOrderLineStatusBadge(status model.PurchaseOrderLineStatus) g.Node {
+
+	badgeType := components.BadgeSecondary
+	switch status {
+	case model.OpenPurchaseOrderLineStatus:
+		badgeType = components.BadgePrimary
+	case model.ReceivedPurchaseOrderLineStatus:
+		badgeType = components.BadgeSuccess
+	}
+
+	return components.Badge(&components.BadgeProps{
+		Type: badgeType,
+		Size: components.BadgeSm,
+	}, g.Text(string(status)))
+}
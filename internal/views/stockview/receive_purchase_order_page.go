@@ -0,0 +1,215 @@
+package stockview
+
+import (
+	"app/internal/components"
+	"app/internal/layout"
+	"app/internal/model"
+	"app/pkg/reqcontext"
+	"app/pkg/validate"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	g "maragu.dev/gomponents"
+	h "maragu.dev/gomponents/html"
+)
+
+type ReceivePurchaseOrderPageProps struct {
+	Ctx           reqcontext.ReqContext
+	PurchaseOrder model.PurchaseOrder
+	Lines         []model.PurchaseOrderLine
+	ErrorText     string
+
+	Values           url.Values
+	ValidationErrors validate.ValidationErrors
+	IsSubmission     bool
+}
+
+func ReceivePurchaseOrderPage(p *ReceivePurchaseOrderPageProps) g.Node {
+
+	po := p.PurchaseOrder
+
+	content := g.Group([]g.Node{
+		g.If(
+			p.ErrorText != "",
+			h.Div(
+				h.Class("error-msg"),
+				g.Text(p.ErrorText),
+			),
+		),
+
+		g.If(
+			po.Status != model.OpenPurchaseOrderStatus,
+			h.P(
+				h.Class("purchase-order-info"),
+				g.Textf("Stock can only be received against an open purchase order, this order is %s.", po.Status),
+			),
+		),
+
+		g.If(
+			po.Status == model.OpenPurchaseOrderStatus,
+			h.Div(
+				h.Class("add-stock-document-page"),
+				receivePurchaseOrderLineForm(p),
+			),
+		),
+	})
+
+	return layout.Page(layout.PageProps{
+		Ctx:     p.Ctx,
+		Title:   fmt.Sprintf("Receive Purchase Order - %s", po.Reference),
+		Header:  &layout.PageHeaderProps{},
+		Content: content,
+		Breadcrumbs: []layout.Breadcrumb{
+			layout.HomeBreadcrumb,
+			{
+				IconIdentifier: "package-variant-closed",
+				Title:          "Stock",
+				URLPart:        "stock",
+			},
+			{
+				Title:   "Purchase Orders",
+				URLPart: "purchase-orders",
+			},
+			{
+				Title:   po.Reference,
+				URLPart: strconv.Itoa(po.PurchaseOrderID),
+			},
+			{
+				Title: "Receive",
+			},
+		},
+		AppendHead: []g.Node{
+			components.InlineStyle("/internal/views/stockview/stock_document_page.css"),
+			components.InlineStyle("/internal/views/stockview/purchase_order_page.css"),
+		},
+	})
+}
+
+func receivePurchaseOrderLineForm(p *ReceivePurchaseOrderPageProps) g.Node {
+
+	fieldError := func(key, label string) g.Node {
+		if !p.IsSubmission {
+			return nil
+		}
+		errorText := p.ValidationErrors.GetError(key, label)
+		if errorText == "" {
+			return nil
+		}
+		return components.InputHelper(&components.InputHelperProps{
+			Label: errorText,
+			Type:  components.InputHelperTypeError,
+		})
+	}
+
+	textInput := func(key, label, placeholder string) g.Node {
+		return h.Div(
+			h.Label(
+				g.Text(label),
+				h.Input(
+					h.Type("text"),
+					h.Name(key),
+					h.Value(p.Values.Get(key)),
+					h.Placeholder(placeholder),
+					h.AutoComplete("off"),
+				),
+			),
+			fieldError(key, label),
+		)
+	}
+
+	selectedLine := p.Values.Get("PurchaseOrderLineID")
+
+	receivableLines := []model.PurchaseOrderLine{}
+	for _, l := range p.Lines {
+		if l.Status != model.ClosedPurchaseOrderLineStatus {
+			receivableLines = append(receivableLines, l)
+		}
+	}
+
+	return h.Form(
+		h.Method("POST"),
+		h.Class("form"),
+		h.Action(fmt.Sprintf("/stock/purchase-orders/%d/receive", p.PurchaseOrder.PurchaseOrderID)),
+
+		h.Div(
+			h.Label(
+				g.Text("Line"),
+				h.Select(
+					h.Name("PurchaseOrderLineID"),
+					h.Class("select"),
+					h.Option(h.Value(""), g.Text("Select line")),
+					g.Group(g.Map(receivableLines, func(l model.PurchaseOrderLine) g.Node {
+						value := strconv.Itoa(l.PurchaseOrderLineID)
+						return h.Option(
+							h.Value(value),
+							g.Textf(
+								"%s \u2013 %s outstanding, due %s",
+								l.StockCode,
+								quantityWithUnit(l.OutstandingQty(), l.Unit),
+								l.DueDate.Format("2006-01-02"),
+							),
+							g.If(selectedLine == value, h.Selected()),
+						)
+					})),
+				),
+			),
+			fieldError("PurchaseOrderLineID", "Line"),
+		),
+
+		h.Div(
+			h.Label(
+				g.Text("Qty (in base units)"),
+				h.Input(
+					h.Type("number"),
+					h.Min("0"),
+					h.Step("any"),
+					h.Name("Qty"),
+					h.Value(p.Values.Get("Qty")),
+					h.Placeholder("Enter quantity received"),
+					h.AutoComplete("off"),
+				),
+			),
+			fieldError("Qty", "Qty"),
+		),
+
+		h.Div(
+			h.Label(
+				g.Text("Location"),
+				locationSelect("Location", p.Values.Get("Location")),
+			),
+			fieldError("Location", "Location"),
+		),
+
+		h.Div(
+			h.Label(
+				g.Text("Bin"),
+				binSelect("Bin", "Location", p.Values.Get("Bin")),
+			),
+			fieldError("Bin", "Bin"),
+		),
+
+		textInput("LotNumber", "Lot Number (only if lot tracked)", "Enter lot number"),
+
+		h.Div(
+			h.Label(
+				g.Text("Serial Numbers (serialised items only)"),
+				h.Textarea(
+					h.Name("SerialNumbers"),
+					h.Placeholder("Enter one serial number per unit, separated by commas or new lines"),
+					h.AutoComplete("off"),
+					g.Text(p.Values.Get("SerialNumbers")),
+				),
+			),
+			fieldError("SerialNumbers", "Serial Numbers"),
+		),
+
+		textInput("TransactionNote", "Note (optional)", "Enter note, e.g. delivery note number"),
+
+		h.Button(
+			h.Class("button primary"),
+			h.Type("submit"),
+			g.Text("Receive Stock"),
+		),
+	)
+}
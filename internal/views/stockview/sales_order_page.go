@@ -0,0 +1,531 @@
+package stockview
+
+import (
+	"app/internal/components"
+	"app/internal/layout"
+	"app/internal/model"
+	"app/pkg/nilsafe"
+	"app/pkg/reqcontext"
+	"app/pkg/validate"
+	"fmt"
+	"net/url"
+	"time"
+
+	g "maragu.dev/gomponents"
+	c "maragu.dev/gomponents/components"
+	h "maragu.dev/gomponents/html"
+)
+
+type SalesOrderPageProps struct {
+	Ctx        reqcontext.ReqContext
+	SalesOrder model.SalesOrder
+	Lines      []model.SalesOrderLine
+	PickLists  []model.PickList
+	Dispatches []model.StockTransactionEntry
+	StockItems []model.StockItem
+	CanEdit    bool
+	ErrorText  string
+
+	// Add line form state
+	LineValues           url.Values
+	LineValidationErrors validate.ValidationErrors
+	IsLineSubmission     bool
+}
+
+func SalesOrderPage(p *SalesOrderPageProps) g.Node {
+
+	so := p.SalesOrder
+	isDraft := so.Status == model.DraftSalesOrderStatus
+	isOpen := so.Status == model.OpenSalesOrderStatus
+
+	hasDispatches := false
+	canPick := false
+	for _, l := range p.Lines {
+		if !l.DispatchedQty.IsZero() {
+			hasDispatches = true
+		}
+		if l.Status == model.OpenSalesOrderLineStatus && l.UnpickedQty().IsPositive() {
+			canPick = true
+		}
+	}
+
+	hasOpenPickLists := false
+	for _, pl := range p.PickLists {
+		if pl.Status == model.OpenPickListStatus {
+			hasOpenPickLists = true
+			break
+		}
+	}
+
+	type attribute struct {
+		label string
+		value g.Node
+	}
+
+	customerReference := "\u2013"
+	if so.CustomerReference != "" {
+		customerReference = so.CustomerReference
+	}
+
+	note := "\u2013"
+	if so.Note != "" {
+		note = so.Note
+	}
+
+	createdBy := g.Group([]g.Node{
+		g.Textf("%s on ", nilsafe.Str(so.CreatedByUsername)),
+		h.Span(h.Class("local-datetime"), g.Text(so.CreatedAt.Format(time.RFC3339))),
+	})
+	updatedBy := g.Group([]g.Node{
+		g.Textf("%s on ", nilsafe.Str(so.UpdatedByUsername)),
+		h.Span(h.Class("local-datetime"), g.Text(so.UpdatedAt.Format(time.RFC3339))),
+	})
+
+	customer := g.Textf("%s \u2013 %s", so.CustomerCode, so.CustomerName)
+	if p.CanEdit {
+		customer = h.A(
+			h.Href(fmt.Sprintf("/stock/customers/%d", so.CustomerID)),
+			customer,
+		)
+	}
+
+	attributes := []attribute{
+		{label: "Reference", value: g.Text(so.Reference)},
+		{label: "Customer", value: customer},
+		{label: "Customer Reference", value: g.Text(customerReference)},
+		{label: "Order Date", value: g.Text(so.OrderDate.Format("2006-01-02"))},
+		{label: "Note", value: g.Text(note)},
+		{label: "Created By", value: createdBy},
+		{label: "Updated By", value: updatedBy},
+	}
+
+	content := g.Group([]g.Node{
+		h.Nav(
+			h.Class("stock-nav"),
+			h.A(h.Href("/stock/sales-orders"), g.Text("Sales orders")),
+		),
+
+		h.Ul(
+			h.Class("attributes-list"),
+
+			g.Group(g.Map(attributes, func(a attribute) g.Node {
+				return h.Li(
+					components.Icon(&components.IconProps{
+						Identifier: "arrow-right-thin",
+					}),
+					h.Strong(g.Textf("%s: ", a.label)),
+					h.Span(a.value),
+				)
+			})),
+		),
+
+		g.If(
+			p.ErrorText != "",
+			h.Div(
+				h.Class("error-msg"),
+				g.Text(p.ErrorText),
+			),
+		),
+
+		h.H3(g.Text("Lines")),
+
+		salesOrderLinesTable(&salesOrderLinesTableProps{
+			salesOrderID: so.SalesOrderID,
+			lines:        p.Lines,
+			canEdit:      (isDraft || isOpen) && p.CanEdit,
+			canClose:     isOpen && p.CanEdit,
+		}),
+
+		g.If(
+			(isDraft || isOpen) && p.CanEdit,
+			g.Group([]g.Node{
+				h.H3(g.Text("Add Line")),
+				addSalesOrderLineForm(&addSalesOrderLineFormProps{
+					salesOrderID:     so.SalesOrderID,
+					stockItems:       p.StockItems,
+					values:           p.LineValues,
+					validationErrors: p.LineValidationErrors,
+					isSubmission:     p.IsLineSubmission,
+				}),
+			}),
+		),
+
+		g.If(
+			len(p.PickLists) > 0,
+			g.Group([]g.Node{
+				h.H3(g.Text("Pick Lists")),
+				pickListsTable(p.PickLists),
+			}),
+		),
+
+		g.If(
+			len(p.Dispatches) > 0,
+			g.Group([]g.Node{
+				h.H3(g.Text("Dispatches")),
+				transactionsTable(&transactionsTableProps{
+					stockTransactions: p.Dispatches,
+				}),
+			}),
+		),
+	})
+
+	return layout.Page(layout.PageProps{
+		Ctx:   p.Ctx,
+		Title: fmt.Sprintf("Sales Order - %s", so.Reference),
+		Header: &layout.PageHeaderProps{
+			Title: h.Div(
+				h.Class("stock-document-page-title"),
+				h.H1(g.Textf("Sales Order \u2013 %s", so.Reference)),
+				salesOrderStatusBadge(so.Status),
+			),
+			Actions: salesOrderActions(&salesOrderActionsProps{
+				salesOrderID: so.SalesOrderID,
+				canIssue:     isDraft && p.CanEdit && len(p.Lines) > 0,
+				canPick:      isOpen && p.CanEdit && canPick,
+				canClose:     isOpen && p.CanEdit && !hasOpenPickLists,
+				canCancel:    (isDraft || isOpen) && p.CanEdit && !hasDispatches && !hasOpenPickLists,
+			}),
+		},
+		Content: content,
+		Breadcrumbs: []layout.Breadcrumb{
+			layout.HomeBreadcrumb,
+			{
+				IconIdentifier: "package-variant-closed",
+				Title:          "Stock",
+				URLPart:        "stock",
+			},
+			{
+				Title:   "Sales Orders",
+				URLPart: "sales-orders",
+			},
+			{
+				Title: so.Reference,
+			},
+		},
+		AppendHead: []g.Node{
+			components.InlineStyle("/internal/views/stockview/stock_table.css"),
+			components.InlineStyle("/internal/views/stockview/stock_document_page.css"),
+			components.InlineStyle("/internal/views/stockview/sales_order_page.css"),
+			components.InlineScript("/internal/views/stockview/sales_order_page.js"),
+		},
+	})
+}
+
+type salesOrderActionsProps struct {
+	salesOrderID int
+	canIssue     bool
+	canPick      bool
+	canClose     bool
+	canCancel    bool
+}
+
+func salesOrderActions(p *salesOrderActionsProps) []g.Node {
+	actions := []g.Node{}
+
+	if p.canIssue {
+		actions = append(actions, h.Form(
+			h.Method("POST"),
+			h.Class("sales-order-action-form"),
+			h.Action(fmt.Sprintf("/stock/sales-orders/%d/issue", p.salesOrderID)),
+			g.Attr("data-confirm", "Issue this sales order? Pick lists can then be generated for it."),
+			h.Button(
+				h.Class("button primary"),
+				h.Type("submit"),
+				components.Icon(&components.IconProps{
+					Identifier: "check",
+				}),
+				g.Text("Issue Order"),
+			),
+		))
+	}
+
+	if p.canPick {
+		actions = append(actions, h.Form(
+			h.Method("POST"),
+			h.Class("sales-order-action-form"),
+			h.Action(fmt.Sprintf("/stock/sales-orders/%d/pick-lists", p.salesOrderID)),
+			g.Attr("data-confirm", "Generate a pick list for what is still to be picked of this order?"),
+			h.Button(
+				h.Class("button primary"),
+				h.Type("submit"),
+				components.Icon(&components.IconProps{
+					Identifier: "text-box-outline",
+				}),
+				g.Text("Generate Pick List"),
+			),
+		))
+	}
+
+	if p.canClose {
+		actions = append(actions, h.Form(
+			h.Method("POST"),
+			h.Class("sales-order-action-form"),
+			h.Action(fmt.Sprintf("/stock/sales-orders/%d/close", p.salesOrderID)),
+			g.Attr("data-confirm", "Close this sales order? Nothing more will be dispatched against it."),
+			h.Button(
+				h.Class("button secondary"),
+				h.Type("submit"),
+				g.Text("Close Order"),
+			),
+		))
+	}
+
+	if p.canCancel {
+		actions = append(actions, h.Form(
+			h.Method("POST"),
+			h.Class("sales-order-action-form"),
+			h.Action(fmt.Sprintf("/stock/sales-orders/%d/cancel", p.salesOrderID)),
+			g.Attr("data-confirm", "Cancel this sales order?"),
+			h.Button(
+				h.Class("button secondary"),
+				h.Type("submit"),
+				g.Text("Cancel Order"),
+			),
+		))
+	}
+
+	return actions
+}
+
+type salesOrderLinesTableProps struct {
+	salesOrderID int
+	lines        []model.SalesOrderLine
+	canEdit      bool
+	canClose     bool
+}
+
+func salesOrderLinesTable(p *salesOrderLinesTableProps) g.Node {
+
+	columns := components.TableColumns{
+		{TitleContents: g.Text("Stock Code")},
+		{TitleContents: g.Text("Description")},
+		{TitleContents: g.Text("Ordered"), Classes: c.Classes{"text-right": true}},
+		{TitleContents: g.Text("Dispatched"), Classes: c.Classes{"text-right": true}},
+		{TitleContents: g.Text("Picking"), Classes: c.Classes{"text-right": true}},
+		{TitleContents: g.Text("Outstanding"), Classes: c.Classes{"text-right": true}},
+		{TitleContents: g.Text("Due")},
+		{TitleContents: g.Text("Unit Price"), Classes: c.Classes{"text-right": true}},
+		{TitleContents: g.Text("Status")},
+	}
+	if p.canEdit {
+		columns = append(columns, components.TableColumn{TitleContents: g.Text("")})
+	}
+
+	var rows components.TableRows
+	for _, l := range p.lines {
+
+		unitPrice := "\u2013"
+		if l.UnitPrice != nil {
+			unitPrice = l.UnitPrice.String()
+		}
+
+		outstanding := "\u2013"
+		if l.Status == model.OpenSalesOrderLineStatus {
+			outstanding = quantityWithUnit(l.OutstandingQty(), l.Unit)
+		}
+
+		cells := []components.TableCell{
+			{Contents: components.StockItemAnchor(l.StockCode)},
+			{Contents: g.Text(l.Description)},
+			{Contents: g.Text(quantityWithUnit(l.Qty, l.Unit)), Classes: c.Classes{"text-right": true}},
+			{Contents: g.Text(quantityWithUnit(l.DispatchedQty, l.Unit)), Classes: c.Classes{"text-right": true}},
+			{Contents: g.Text(quantityWithUnit(l.PickingQty, l.Unit)), Classes: c.Classes{"text-right": true}},
+			{Contents: g.Text(outstanding), Classes: c.Classes{"text-right": true}},
+			{Contents: g.Text(l.DueDate.Format("2006-01-02"))},
+			{Contents: g.Text(unitPrice), Classes: c.Classes{"text-right": true}},
+			{Contents: salesOrderLineStatusBadge(l.Status)},
+		}
+
+		if p.canEdit {
+			actions := []g.Node{}
+
+			if p.canClose && l.Status == model.OpenSalesOrderLineStatus &&
+				!l.DispatchedQty.IsZero() && l.PickingQty.IsZero() {
+				actions = append(actions, h.Form(
+					h.Method("POST"),
+					h.Class("sales-order-action-form"),
+					h.Action(fmt.Sprintf(
+						"/stock/sales-orders/%d/lines/%d/close", p.salesOrderID, l.SalesOrderLineID,
+					)),
+					g.Attr("data-confirm", "Close this line short? The outstanding quantity will no longer be dispatched."),
+					h.Button(
+						h.Class("button secondary small"),
+						h.Type("submit"),
+						g.Text("Close"),
+					),
+				))
+			}
+
+			if l.DispatchedQty.IsZero() && l.PickingQty.IsZero() {
+				actions = append(actions, h.Form(
+					h.Method("POST"),
+					h.Action(fmt.Sprintf(
+						"/stock/sales-orders/%d/lines/%d/delete", p.salesOrderID, l.SalesOrderLineID,
+					)),
+					h.Button(
+						h.Class("button secondary small"),
+						h.Type("submit"),
+						g.Text("Remove"),
+					),
+				))
+			}
+
+			cells = append(cells, components.TableCell{
+				Contents: h.Div(h.Class("sales-order-line-actions"), g.Group(actions)),
+			})
+		}
+
+		rows = append(rows, components.TableRow{
+			Cells: cells,
+		})
+	}
+
+	return components.Table(&components.TableProps{
+		Classes: c.Classes{"stock-table": true},
+		Columns: columns,
+		Rows:    rows,
+	})
+}
+
+type addSalesOrderLineFormProps struct {
+	salesOrderID     int
+	stockItems       []model.StockItem
+	values           url.Values
+	validationErrors validate.ValidationErrors
+	isSubmission     bool
+}
+
+func addSalesOrderLineForm(p *addSalesOrderLineFormProps) g.Node {
+
+	fieldError := func(key, label string) g.Node {
+		if !p.isSubmission {
+			return nil
+		}
+		errorText := p.validationErrors.GetError(key, label)
+		if errorText == "" {
+			return nil
+		}
+		return components.InputHelper(&components.InputHelperProps{
+			Label: errorText,
+			Type:  components.InputHelperTypeError,
+		})
+	}
+
+	selectedStockItem := p.values.Get("StockItemID")
+
+	return h.Form(
+		h.Method("POST"),
+		h.Class("form stock-document-line-form"),
+		h.Action(fmt.Sprintf("/stock/sales-orders/%d/lines", p.salesOrderID)),
+
+		h.Div(
+			h.Label(
+				g.Text("Stock Code"),
+				components.SearchSelect(&components.SearchSelectProps{
+					Name:                 "StockItemID",
+					Placeholder:          "Select Stock Code",
+					Mode:                 "single",
+					Options:              MapStockItemsToOptions(p.stockItems, selectedStockItem),
+					Selected:             selectedStockItem,
+					OptionsEndpoint:      "/get-stock-codes",
+					SearchQueryParamName: "SearchText",
+				}),
+			),
+			fieldError("StockItemID", "Stock Code"),
+		),
+
+		h.Div(
+			h.Label(
+				g.Text("Qty (in base units)"),
+				h.Input(
+					h.Type("number"),
+					h.Min("0"),
+					h.Step("any"),
+					h.Name("Qty"),
+					h.Value(p.values.Get("Qty")),
+					h.Placeholder("Enter quantity"),
+					h.AutoComplete("off"),
+				),
+			),
+			fieldError("Qty", "Qty"),
+		),
+
+		h.Div(
+			h.Label(
+				g.Text("Due Date"),
+				h.Input(
+					h.Type("date"),
+					h.Name("DueDate"),
+					h.Value(p.values.Get("DueDate")),
+					h.AutoComplete("off"),
+				),
+			),
+			fieldError("DueDate", "Due Date"),
+		),
+
+		h.Div(
+			h.Label(
+				g.Text("Unit Price (optional, per base unit)"),
+				h.Input(
+					h.Type("number"),
+					h.Min("0"),
+					h.Step("any"),
+					h.Name("UnitPrice"),
+					h.Value(p.values.Get("UnitPrice")),
+					h.Placeholder("Enter price per base unit"),
+					h.AutoComplete("off"),
+				),
+			),
+			fieldError("UnitPrice", "Unit Price"),
+		),
+
+		h.Button(
+			h.Class("button primary"),
+			h.Type("submit"),
+			g.Text("Add Line"),
+		),
+	)
+}
+
+func pickListsTable(pickLists []model.PickList) g.Node {
+
+	columns := components.TableColumns{
+		{TitleContents: g.Text("Pick List")},
+		{TitleContents: g.Text("Status")},
+		{TitleContents: g.Text("Lines"), Classes: c.Classes{"text-right": true}},
+		{TitleContents: g.Text("Created By")},
+		{TitleContents: g.Text("Created")},
+		{TitleContents: g.Text("Dispatched By")},
+		{TitleContents: g.Text("Dispatched")},
+	}
+
+	var rows components.TableRows
+	for _, pl := range pickLists {
+
+		pickListHref := fmt.Sprintf("/stock/pick-lists/%d", pl.PickListID)
+
+		dispatchedAt := g.Text("\u2013")
+		if pl.DispatchedAt != nil {
+			dispatchedAt = h.Span(h.Class("local-datetime"), g.Text(pl.DispatchedAt.Format(time.RFC3339)))
+		}
+
+		rows = append(rows, components.TableRow{
+			Cells: []components.TableCell{
+				{Contents: h.A(h.Href(pickListHref), g.Textf("%d", pl.PickListID))},
+				{Contents: pickListStatusBadge(pl.Status)},
+				{Contents: g.Textf("%d", pl.LineCount), Classes: c.Classes{"text-right": true}},
+				{Contents: g.Text(nilsafe.Str(pl.CreatedByUsername))},
+				{Contents: h.Span(h.Class("local-datetime"), g.Text(pl.CreatedAt.Format(time.RFC3339)))},
+				{Contents: g.Text(nilsafe.Str(pl.DispatchedByUsername))},
+				{Contents: dispatchedAt},
+			},
+			HREF: pickListHref,
+		})
+	}
+
+	return components.Table(&components.TableProps{
+		Classes: c.Classes{"stock-table": true},
+		Columns: columns,
+		Rows:    rows,
+	})
+}
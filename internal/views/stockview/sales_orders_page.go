@@ -0,0 +1,265 @@
+package stockview
+
+import (
+	"app/internal/components"
+	"app/internal/layout"
+	"app/internal/model"
+	"app/pkg/reqcontext"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	g "maragu.dev/gomponents"
+	c "maragu.dev/gomponents/components"
+	h "maragu.dev/gomponents/html"
+)
+
+type SalesOrdersPageProps struct {
+	Ctx              reqcontext.ReqContext
+	SalesOrders      []model.SalesOrder
+	SalesOrdersCount int
+	Customers        []model.Customer
+	Status           string
+	CustomerID       int
+	StockCode        string
+	Page             int
+	PageSize         int
+}
+
+// SalesOrdersURL links to the sales orders with a line for the stock code
+func SalesOrdersURL(stockCode string) string {
+	params := url.Values{}
+	params.Set("StockCode", stockCode)
+	return "/stock/sales-orders?" + params.Encode()
+}
+
+func SalesOrdersPage(p *SalesOrdersPageProps) g.Node {
+
+	perms := p.Ctx.User.Permissions
+
+	content := h.FormEl(
+		h.Method("GET"),
+
+		h.Nav(
+			h.Class("stock-nav"),
+			h.A(h.Href("/stock"), g.Text("Stock levels")),
+			g.If(
+				perms.SupplyChain.Admin,
+				h.A(h.Href("/stock/customers"), g.Text("Customers")),
+			),
+			g.If(
+				perms.SupplyChain.Admin,
+				h.A(h.Href("/stock/sales-orders/add"), g.Text("New sales order")),
+			),
+		),
+
+		h.H3(g.Text("Sales Orders")),
+
+		h.Div(
+			h.Class("stock-levels-filters"),
+
+			h.Label(
+				h.Class("filter"),
+				g.Text("Status"),
+				h.Select(
+					h.Class("lg"),
+					h.Name("Status"),
+					h.Option(h.Value(""), g.Text("All")),
+					g.Group(g.Map(model.SalesOrderStatuses, func(s model.SalesOrderStatus) g.Node {
+						return h.Option(
+							h.Value(string(s)),
+							g.Text(string(s)),
+							g.If(p.Status == string(s), h.Selected()),
+						)
+					})),
+				),
+			),
+
+			h.Label(
+				h.Class("filter"),
+				g.Text("Customer"),
+				h.Select(
+					h.Class("lg"),
+					h.Name("CustomerID"),
+					h.Option(h.Value(""), g.Text("All")),
+					g.Group(g.Map(p.Customers, func(s model.Customer) g.Node {
+						return h.Option(
+							h.Value(strconv.Itoa(s.CustomerID)),
+							g.Textf("%s \u2013 %s", s.Code, s.Name),
+							g.If(p.CustomerID == s.CustomerID, h.Selected()),
+						)
+					})),
+				),
+			),
+
+			h.Label(
+				h.Class("filter"),
+				g.Text("Stock Code"),
+				h.Input(
+					h.Class("lg"),
+					h.Name("StockCode"),
+					h.Value(p.StockCode),
+					h.AutoComplete("off"),
+					h.Placeholder("Stock code"),
+				),
+			),
+
+			h.Div(
+				h.Class("go-button-wrapper"),
+				components.Button(&components.ButtonProps{
+					ButtonType: components.ButtonPrimary,
+					Classes:    c.Classes{"go-button": true},
+					Size:       components.ButtonLg,
+				},
+					h.Type("button"),
+					g.Attr("onclick", "submitTableForm(this.form)"),
+					g.Text("GO"),
+				),
+			),
+		),
+
+		components.Divider(),
+
+		salesOrdersTable(&salesOrdersTableProps{
+			salesOrders:      p.SalesOrders,
+			salesOrdersCount: p.SalesOrdersCount,
+			page:             p.Page,
+			pageSize:         p.PageSize,
+		}),
+	)
+
+	return layout.Page(layout.PageProps{
+		Title:   "Sales Orders",
+		Content: content,
+		Ctx:     p.Ctx,
+		Breadcrumbs: []layout.Breadcrumb{
+			layout.HomeBreadcrumb,
+			{
+				IconIdentifier: "package-variant-closed",
+				Title:          "Stock",
+				URLPart:        "stock",
+			},
+			{
+				Title: "Sales Orders",
+			},
+		},
+		AppendHead: []g.Node{
+			components.InlineStyle("/internal/views/stockview/stock_table.css"),
+		},
+	})
+}
+
+type salesOrdersTableProps struct {
+	salesOrders      []model.SalesOrder
+	salesOrdersCount int
+	page             int
+	pageSize         int
+}
+
+func salesOrdersTable(p *salesOrdersTableProps) g.Node {
+
+	columns := components.TableColumns{
+		{TitleContents: g.Text("Reference")},
+		{TitleContents: g.Text("Customer")},
+		{TitleContents: g.Text("Customer Reference")},
+		{TitleContents: g.Text("Order Date")},
+		{TitleContents: g.Text("Status")},
+		{TitleContents: g.Text("Lines"), Classes: c.Classes{"text-right": true}},
+		{TitleContents: g.Text("Created By")},
+		{TitleContents: g.Text("Created")},
+	}
+
+	var rows components.TableRows
+	for _, so := range p.salesOrders {
+
+		salesOrderHref := fmt.Sprintf("/stock/sales-orders/%d", so.SalesOrderID)
+
+		customerReference := "\u2013"
+		if so.CustomerReference != "" {
+			customerReference = so.CustomerReference
+		}
+
+		createdBy := "\u2013"
+		if so.CreatedByUsername != nil {
+			createdBy = *so.CreatedByUsername
+		}
+
+		rows = append(rows, components.TableRow{
+			Cells: []components.TableCell{
+				{Contents: h.A(h.Href(salesOrderHref), g.Text(so.Reference))},
+				{Contents: g.Textf("%s \u2013 %s", so.CustomerCode, so.CustomerName)},
+				{Contents: g.Text(customerReference)},
+				{Contents: g.Text(so.OrderDate.Format("2006-01-02"))},
+				{Contents: salesOrderStatusBadge(so.Status)},
+				{Contents: g.Textf("%d", so.LineCount), Classes: c.Classes{"text-right": true}},
+				{Contents: g.Text(createdBy)},
+				{Contents: h.Span(h.Class("local-datetime"), g.Text(so.CreatedAt.Format(time.RFC3339)))},
+			},
+			HREF: salesOrderHref,
+		})
+	}
+
+	return components.Table(&components.TableProps{
+		Classes: c.Classes{"stock-table": true},
+		Columns: columns,
+		Rows:    rows,
+		Pagination: &components.TablePaginationProps{
+			TotalRecords:        p.salesOrdersCount,
+			PageSize:            p.pageSize,
+			CurrentPage:         p.page,
+			CurrentPageQueryKey: "Page",
+			PageSizeQueryKey:    "PageSize",
+		},
+	})
+}
+
+func salesOrderStatusBadge(status model.SalesOrderStatus) g.Node {
+
+	badgeType := components.BadgeSecondary
+	switch status {
+	case model.DraftSalesOrderStatus:
+		badgeType = components.BadgeWarning
+	case model.OpenSalesOrderStatus:
+		badgeType = components.BadgePrimary
+	case model.ClosedSalesOrderStatus:
+		badgeType = components.BadgeSuccess
+	}
+
+	return components.Badge(&components.BadgeProps{
+		Type: badgeType,
+		Size: components.BadgeSm,
+	}, g.Text(string(status)))
+}
+
+func salesOrderLineStatusBadge(status model.SalesOrderLineStatus) g.Node {
+
+	badgeType := components.BadgeSecondary
+	switch status {
+	case model.OpenSalesOrderLineStatus:
+		badgeType = components.BadgePrimary
+	case model.DispatchedSalesOrderLineStatus:
+		badgeType = components.BadgeSuccess
+	}
+
+	return components.Badge(&components.BadgeProps{
+		Type: badgeType,
+		Size: components.BadgeSm,
+	}, g.Text(string(status)))
+}
+
+func pickListStatusBadge(status model.PickListStatus) g.Node {
+
+	badgeType := components.BadgeSecondary
+	switch status {
+	case model.OpenPickListStatus:
+		badgeType = components.BadgePrimary
+	case model.DispatchedPickListStatus:
+		badgeType = components.BadgeSuccess
+	}
+
+	return components.Badge(&components.BadgeProps{
+		Type: badgeType,
+		Size: components.BadgeSm,
+	}, g.Text(string(status)))
+}
@@ -0,0 +1,245 @@
+package stockview
+
+import (
+	"app/internal/components"
+	"app/internal/layout"
+	"app/internal/model"
+	"app/pkg/nilsafe"
+	"app/pkg/reqcontext"
+	"app/pkg/validate"
+	"fmt"
+	"net/url"
+	"time"
+
+	g "maragu.dev/gomponents"
+	c "maragu.dev/gomponents/components"
+	h "maragu.dev/gomponents/html"
+)
+
+type ScrapReasonsPageProps struct {
+	Ctx          reqcontext.ReqContext
+	ScrapReasons []model.ScrapReason
+	ShowArchived bool
+	ErrorText    string
+
+	// Add scrap reason form state
+	Values           url.Values
+	ValidationErrors validate.ValidationErrors
+	IsSubmission     bool
+}
+
+func ScrapReasonsPage(p *ScrapReasonsPageProps) g.Node {
+
+	content := g.Group([]g.Node{
+		h.FormEl(
+			h.Method("GET"),
+
+			h.Nav(
+				h.Class("stock-nav"),
+				h.A(h.Href("/stock"), g.Text("Stock levels")),
+				h.A(h.Href("/stock/transactions"), g.Text("See all transactions")),
+				h.A(h.Href("/stock/waste"), g.Text("Waste")),
+			),
+
+			h.H3(g.Text("Scrap Reasons")),
+
+			h.P(
+				h.Class("scrap-reasons-info"),
+				g.Text(`Every posting into SCRAP needs one of these reasons. Archived
+					reasons stay on the postings made with them and on the waste
+					report, but cannot be used for new postings.`),
+			),
+
+			h.Div(
+				h.Class("stock-levels-filters"),
+
+				h.Label(
+					h.Class("checkbox"),
+					h.Input(
+						h.Type("checkbox"),
+						h.Name("ShowArchived"),
+						h.Value("true"),
+						g.If(p.ShowArchived, h.Checked()),
+					),
+					g.Text("Show archived"),
+				),
+
+				h.Div(
+					h.Class("go-button-wrapper"),
+					components.Button(&components.ButtonProps{
+						ButtonType: components.ButtonPrimary,
+						Classes:    c.Classes{"go-button": true},
+						Size:       components.ButtonLg,
+					},
+						h.Type("button"),
+						g.Attr("onclick", "submitTableForm(this.form)"),
+						g.Text("GO"),
+					),
+				),
+			),
+		),
+
+		components.Divider(),
+
+		g.If(
+			p.ErrorText != "",
+			h.Div(
+				h.Class("error-msg"),
+				g.Text(p.ErrorText),
+			),
+		),
+
+		scrapReasonsTable(p.ScrapReasons),
+
+		h.H3(g.Text("Add Scrap Reason")),
+
+		addScrapReasonForm(p.Values, p.ValidationErrors, p.IsSubmission),
+	})
+
+	return layout.Page(layout.PageProps{
+		Title:   "Scrap Reasons",
+		Content: content,
+		Ctx:     p.Ctx,
+		Breadcrumbs: []layout.Breadcrumb{
+			layout.HomeBreadcrumb,
+			{
+				IconIdentifier: "package-variant-closed",
+				Title:          "Stock",
+				URLPart:        "stock",
+			},
+			{
+				Title: "Scrap Reasons",
+			},
+		},
+		AppendHead: []g.Node{
+			components.InlineStyle("/internal/views/stockview/stock_table.css"),
+			components.InlineStyle("/internal/views/stockview/scrap_reasons_page.css"),
+		},
+	})
+}
+
+func scrapReasonsTable(scrapReasons []model.ScrapReason) g.Node {
+
+	columns := components.TableColumns{
+		{TitleContents: g.Text("Code")},
+		{TitleContents: g.Text("Description")},
+		{TitleContents: g.Text("Status")},
+		{TitleContents: g.Text("Updated By")},
+		{TitleContents: g.Text("Updated")},
+		{TitleContents: g.Text("")},
+	}
+
+	var rows components.TableRows
+	for _, sr := range scrapReasons {
+
+		description := sr.Description
+		if description == "" {
+			description = "\u2013"
+		}
+
+		updatedBy := "\u2013"
+		if sr.UpdatedByUsername != nil {
+			updatedBy = nilsafe.Str(sr.UpdatedByUsername)
+		}
+
+		archiveText := "Archive"
+		if sr.IsArchived {
+			archiveText = "Restore"
+		}
+
+		rows = append(rows, components.TableRow{
+			Cells: []components.TableCell{
+				{Contents: g.Text(sr.Code)},
+				{Contents: g.Text(description)},
+				{Contents: archivedBadge(sr.IsArchived)},
+				{Contents: g.Text(updatedBy)},
+				{Contents: h.Span(h.Class("local-datetime"), g.Text(sr.UpdatedAt.Format(time.RFC3339)))},
+				{Contents: h.Form(
+					h.Method("POST"),
+					h.Action(fmt.Sprintf("/stock/scrap-reasons/%d", sr.ScrapReasonID)),
+					h.Input(
+						h.Type("hidden"),
+						h.Name("Description"),
+						h.Value(sr.Description),
+					),
+					g.If(!sr.IsArchived, h.Input(
+						h.Type("hidden"),
+						h.Name("IsArchived"),
+						h.Value("true"),
+					)),
+					h.Button(
+						h.Class("button secondary small"),
+						h.Type("submit"),
+						g.Text(archiveText),
+					),
+				)},
+			},
+		})
+	}
+
+	return components.Table(&components.TableProps{
+		Classes: c.Classes{"stock-table": true},
+		Columns: columns,
+		Rows:    rows,
+	})
+}
+
+func addScrapReasonForm(
+	values url.Values,
+	validationErrors validate.ValidationErrors,
+	isSubmission bool,
+) g.Node {
+
+	fieldError := func(key, label string) g.Node {
+		if !isSubmission {
+			return nil
+		}
+		errorText := validationErrors.GetError(key, label)
+		return g.If(
+			errorText != "",
+			components.InputHelper(&components.InputHelperProps{
+				Label: errorText,
+				Type:  components.InputHelperTypeError,
+			}),
+		)
+	}
+
+	return h.Form(
+		h.Method("POST"),
+		h.Class("form scrap-reason-form"),
+		h.Action("/stock/scrap-reasons"),
+
+		h.Div(
+			h.Label(
+				g.Text("Code"),
+				h.Input(
+					h.Type("text"),
+					h.Name("Code"),
+					h.Value(values.Get("Code")),
+					h.Placeholder("Enter code"),
+					h.AutoComplete("off"),
+				),
+			),
+			fieldError("Code", "Code"),
+		),
+
+		h.Div(
+			h.Label(
+				g.Text("Description (optional)"),
+				h.Input(
+					h.Type("text"),
+					h.Name("Description"),
+					h.Value(values.Get("Description")),
+					h.Placeholder("Enter description"),
+					h.AutoComplete("off"),
+				),
+			),
+		),
+
+		h.Button(
+			h.Class("button primary"),
+			h.Type("submit"),
+			g.Text("Add Scrap Reason"),
+		),
+	)
+}
@@ -0,0 +1,470 @@
+package stockview
+
+import (
+	"app/internal/components"
+	"app/internal/layout"
+	"app/internal/model"
+	"app/pkg/format"
+	"app/pkg/nilsafe"
+	"app/pkg/reqcontext"
+	"app/pkg/validate"
+	"fmt"
+	"net/url"
+	"time"
+
+	g "maragu.dev/gomponents"
+	c "maragu.dev/gomponents/components"
+	h "maragu.dev/gomponents/html"
+)
+
+type StockCountPageProps struct {
+	Ctx        reqcontext.ReqContext
+	StockCount model.StockCount
+	Lines      []model.StockCountLine
+	Entries    []model.StockTransactionEntry
+	StockItems []model.StockItem
+	// CanCount allows entering counted quantities, CanApprove allows
+	// approving or cancelling the count and always shows expected quantities
+	CanCount   bool
+	CanApprove bool
+	ErrorText  string
+
+	// Set when approval was stopped by a negative stock warning the user can
+	// acknowledge
+	NegativeStockWarning bool
+
+	// Add found stock form state
+	LineValues           url.Values
+	LineValidationErrors validate.ValidationErrors
+	IsLineSubmission     bool
+}
+
+func StockCountPage(p *StockCountPageProps) g.Node {
+
+	sc := p.StockCount
+	isCounting := sc.Status == model.CountingStockCountStatus
+
+	// blind counts hide expected quantities from counters until approved
+	showExpected := !sc.IsBlind || p.CanApprove || sc.Status == model.ApprovedStockCountStatus
+
+	type attribute struct {
+		label string
+		value g.Node
+	}
+
+	note := "\u2013"
+	if sc.Note != "" {
+		note = sc.Note
+	}
+
+	blind := "No"
+	if sc.IsBlind {
+		blind = "Yes"
+	}
+
+	approvedBy := g.Text("\u2013")
+	if sc.ApprovedAt != nil {
+		approvedBy = g.Group([]g.Node{
+			g.Textf("%s on ", nilsafe.Str(sc.ApprovedByUsername)),
+			h.Span(h.Class("local-datetime"), g.Text(sc.ApprovedAt.Format(time.RFC3339))),
+		})
+	}
+
+	attributes := []attribute{
+		{label: "Reference", value: g.Text(sc.Reference)},
+		{label: "Scope", value: g.Text(StockCountScope(&sc))},
+		{label: "Blind", value: g.Text(blind)},
+		{label: "Snapshot", value: h.Span(h.Class("local-datetime"), g.Text(sc.SnapshotAt.Format(time.RFC3339)))},
+		{label: "Counted", value: g.Textf("%d of %d lines", sc.CountedLineCount, sc.LineCount)},
+		{label: "Note", value: g.Text(note)},
+		{label: "Created By", value: g.Text(sc.CreatedByUsername)},
+		{label: "Approved By", value: approvedBy},
+	}
+
+	content := g.Group([]g.Node{
+		h.Ul(
+			h.Class("attributes-list"),
+
+			g.Group(g.Map(attributes, func(a attribute) g.Node {
+				return h.Li(
+					components.Icon(&components.IconProps{
+						Identifier: "arrow-right-thin",
+					}),
+					h.Strong(g.Textf("%s: ", a.label)),
+					h.Span(a.value),
+				)
+			})),
+		),
+
+		g.If(
+			p.ErrorText != "",
+			h.Div(
+				h.Class("error-msg"),
+				g.Text(p.ErrorText),
+			),
+		),
+
+		g.If(
+			isCounting && p.CanApprove && p.NegativeStockWarning,
+			h.Form(
+				h.Method("POST"),
+				h.Class("stock-count-action-form acknowledge-negative-stock-form"),
+				h.Action(fmt.Sprintf("/stock/counts/%d/approve", sc.StockCountID)),
+				g.Attr("data-confirm", "Approve this count even though it leaves negative stock?"),
+				h.Input(
+					h.Type("hidden"),
+					h.Name("AcknowledgeNegativeStock"),
+					h.Value("true"),
+				),
+				h.Button(
+					h.Class("button warning"),
+					h.Type("submit"),
+					g.Text("Approve Anyway"),
+				),
+			),
+		),
+
+		h.H3(g.Text("Lines")),
+
+		stockCountLinesForm(&stockCountLinesFormProps{
+			stockCountID: sc.StockCountID,
+			lines:        p.Lines,
+			canCount:     isCounting && p.CanCount,
+			showExpected: showExpected,
+		}),
+
+		g.If(
+			isCounting && p.CanCount,
+			g.Group([]g.Node{
+				h.H3(g.Text("Add Found Stock")),
+				h.P(g.Text("Record stock found at a place that was not expected when the count was created.")),
+				addStockCountLineForm(&addStockCountLineFormProps{
+					stockCountID:     sc.StockCountID,
+					stockItems:       p.StockItems,
+					values:           p.LineValues,
+					validationErrors: p.LineValidationErrors,
+					isSubmission:     p.IsLineSubmission,
+				}),
+			}),
+		),
+
+		g.If(
+			sc.Status == model.ApprovedStockCountStatus,
+			g.Group([]g.Node{
+				h.H3(g.Text("Ledger Entries")),
+				transactionsTable(&transactionsTableProps{
+					stockTransactions: p.Entries,
+				}),
+			}),
+		),
+	})
+
+	return layout.Page(layout.PageProps{
+		Ctx:   p.Ctx,
+		Title: fmt.Sprintf("Stock Count - %s", sc.Reference),
+		Header: &layout.PageHeaderProps{
+			Title: h.Div(
+				h.Class("stock-count-page-title"),
+				h.H1(g.Textf("Stock Count \u2013 %s", sc.Reference)),
+				stockCountStatusBadge(sc.Status),
+			),
+			Actions: stockCountActions(&stockCountActionsProps{
+				stockCountID: sc.StockCountID,
+				canReport:    showExpected,
+				canApprove:   isCounting && p.CanApprove && sc.CountedLineCount == sc.LineCount,
+				canCancel:    isCounting && p.CanApprove,
+			}),
+		},
+		Content: content,
+		Breadcrumbs: []layout.Breadcrumb{
+			layout.HomeBreadcrumb,
+			{
+				IconIdentifier: "package-variant-closed",
+				Title:          "Stock",
+				URLPart:        "stock",
+			},
+			{
+				Title:   "Counts",
+				URLPart: "counts",
+			},
+			{
+				Title: sc.Reference,
+			},
+		},
+		AppendHead: []g.Node{
+			components.InlineStyle("/internal/views/stockview/stock_table.css"),
+			components.InlineStyle("/internal/views/stockview/stock_count_page.css"),
+			components.InlineScript("/internal/views/stockview/stock_count_page.js"),
+		},
+	})
+}
+
+type stockCountActionsProps struct {
+	stockCountID int
+	canReport    bool
+	canApprove   bool
+	canCancel    bool
+}
+
+func stockCountActions(p *stockCountActionsProps) []g.Node {
+	actions := []g.Node{}
+
+	if p.canReport {
+		actions = append(actions, h.A(
+			h.Class("button secondary"),
+			h.Href(fmt.Sprintf("/stock/counts/%d/report", p.stockCountID)),
+			h.Target("_blank"),
+			g.Text("Variance Report"),
+		))
+	}
+
+	if p.canApprove {
+		actions = append(actions, h.Form(
+			h.Method("POST"),
+			h.Class("stock-count-action-form"),
+			h.Action(fmt.Sprintf("/stock/counts/%d/approve", p.stockCountID)),
+			g.Attr("data-confirm", "Approve this count and post all variances to the stock ledger?"),
+			h.Button(
+				h.Class("button primary"),
+				h.Type("submit"),
+				components.Icon(&components.IconProps{
+					Identifier: "check",
+				}),
+				g.Text("Approve Count"),
+			),
+		))
+	}
+
+	if p.canCancel {
+		actions = append(actions, h.Form(
+			h.Method("POST"),
+			h.Class("stock-count-action-form"),
+			h.Action(fmt.Sprintf("/stock/counts/%d/cancel", p.stockCountID)),
+			g.Attr("data-confirm", "Cancel this count? No variances will be posted."),
+			h.Button(
+				h.Class("button secondary"),
+				h.Type("submit"),
+				g.Text("Cancel Count"),
+			),
+		))
+	}
+
+	return actions
+}
+
+type stockCountLinesFormProps struct {
+	stockCountID int
+	lines        []model.StockCountLine
+	canCount     bool
+	showExpected bool
+}
+
+// stockCountLinesForm lists the count lines. While counting, each line has a
+// quantity input named Counted-<line ID> and the table is submitted as one
+// form.
+func stockCountLinesForm(p *stockCountLinesFormProps) g.Node {
+
+	columns := components.TableColumns{
+		{TitleContents: g.Text("Location")},
+		{TitleContents: g.Text("Bin")},
+		{TitleContents: g.Text("Stock Code")},
+		{TitleContents: g.Text("Lot Number")},
+		{TitleContents: g.Text("Unit")},
+	}
+	if p.showExpected {
+		columns = append(columns, components.TableColumn{
+			TitleContents: g.Text("Expected"), Classes: c.Classes{"text-right": true},
+		})
+	}
+	columns = append(columns,
+		components.TableColumn{TitleContents: g.Text("Counted"), Classes: c.Classes{"text-right": true}},
+	)
+	if p.showExpected {
+		columns = append(columns, components.TableColumn{
+			TitleContents: g.Text("Variance"), Classes: c.Classes{"text-right": true},
+		})
+	}
+	columns = append(columns, components.TableColumn{TitleContents: g.Text("Counted By")})
+
+	dashIfEmpty := func(s string) string {
+		if s == "" {
+			return "\u2013"
+		}
+		return s
+	}
+
+	var rows components.TableRows
+	for _, l := range p.lines {
+
+		counted := g.Text("\u2013")
+		if p.canCount {
+			value := ""
+			if l.CountedQty != nil {
+				value = l.CountedQty.String()
+			}
+			counted = h.Input(
+				h.Class("stock-count-qty-input"),
+				h.Type("number"),
+				h.Min("0"),
+				h.Step("any"),
+				h.Name(fmt.Sprintf("Counted-%d", l.StockCountLineID)),
+				h.Value(value),
+				h.AutoComplete("off"),
+			)
+		} else if l.CountedQty != nil {
+			counted = g.Text(format.DecimalWithCommas(l.CountedQty.String()))
+		}
+
+		cells := []components.TableCell{
+			{Contents: g.Text(l.Location)},
+			{Contents: g.Text(dashIfEmpty(l.Bin))},
+			{Contents: components.StockItemAnchor(l.StockCode)},
+			{Contents: g.Text(dashIfEmpty(l.LotNumber))},
+			{Contents: g.Text(l.Unit)},
+		}
+		if p.showExpected {
+			cells = append(cells, components.TableCell{
+				Contents: g.Text(format.DecimalWithCommas(l.ExpectedQty.String())),
+				Classes:  c.Classes{"text-right": true},
+			})
+		}
+		cells = append(cells, components.TableCell{
+			Contents: counted,
+			Classes:  c.Classes{"text-right": true},
+		})
+		if p.showExpected {
+			variance := l.Variance()
+			varianceText := "\u2013"
+			if variance != nil {
+				varianceText = format.DecimalWithCommas(variance.String())
+			}
+			cells = append(cells, components.TableCell{
+				Contents: g.Text(varianceText),
+				Classes: c.Classes{
+					"text-right":           true,
+					"stock-count-variance": variance != nil && !variance.IsZero(),
+				},
+			})
+		}
+		cells = append(cells, components.TableCell{
+			Contents: g.Text(nilsafe.Str(l.CountedByUsername)),
+		})
+
+		rows = append(rows, components.TableRow{
+			Cells: cells,
+		})
+	}
+
+	table := components.Table(&components.TableProps{
+		Classes: c.Classes{"stock-table": true},
+		Columns: columns,
+		Rows:    rows,
+	})
+
+	if !p.canCount {
+		return table
+	}
+
+	return h.Form(
+		h.Method("POST"),
+		h.Class("stock-count-lines-form"),
+		h.Action(fmt.Sprintf("/stock/counts/%d/counts", p.stockCountID)),
+		table,
+		h.Button(
+			h.Class("button primary"),
+			h.Type("submit"),
+			g.Text("Save Counts"),
+		),
+	)
+}
+
+type addStockCountLineFormProps struct {
+	stockCountID     int
+	stockItems       []model.StockItem
+	values           url.Values
+	validationErrors validate.ValidationErrors
+	isSubmission     bool
+}
+
+func addStockCountLineForm(p *addStockCountLineFormProps) g.Node {
+
+	fieldError := func(key, label string) g.Node {
+		if !p.isSubmission {
+			return nil
+		}
+		errorText := p.validationErrors.GetError(key, label)
+		if errorText == "" {
+			return nil
+		}
+		return components.InputHelper(&components.InputHelperProps{
+			Label: errorText,
+			Type:  components.InputHelperTypeError,
+		})
+	}
+
+	textInput := func(key, label, placeholder string) g.Node {
+		return h.Div(
+			h.Label(
+				g.Text(label),
+				h.Input(
+					h.Type("text"),
+					h.Name(key),
+					h.Value(p.values.Get(key)),
+					h.Placeholder(placeholder),
+					h.AutoComplete("off"),
+				),
+			),
+			fieldError(key, label),
+		)
+	}
+
+	selectedStockItem := p.values.Get("StockItemID")
+
+	return h.Form(
+		h.Method("POST"),
+		h.Class("form stock-count-line-form"),
+		h.Action(fmt.Sprintf("/stock/counts/%d/lines", p.stockCountID)),
+
+		h.Div(
+			h.Label(
+				g.Text("Stock Code"),
+				components.SearchSelect(&components.SearchSelectProps{
+					Name:                 "StockItemID",
+					Placeholder:          "Select Stock Code",
+					Mode:                 "single",
+					Options:              MapStockItemsToOptions(p.stockItems, selectedStockItem),
+					Selected:             selectedStockItem,
+					OptionsEndpoint:      "/get-stock-codes",
+					SearchQueryParamName: "SearchText",
+				}),
+			),
+			fieldError("StockItemID", "Stock Code"),
+		),
+
+		textInput("Location", "Location", "Enter location"),
+		textInput("Bin", "Bin", "Enter bin"),
+		textInput("LotNumber", "Lot Number (only if lot tracked)", "Enter lot number"),
+
+		h.Div(
+			h.Label(
+				g.Text("Counted Qty"),
+				h.Input(
+					h.Type("number"),
+					h.Min("0"),
+					h.Step("any"),
+					h.Name("CountedQty"),
+					h.Value(p.values.Get("CountedQty")),
+					h.Placeholder("Enter counted quantity"),
+					h.AutoComplete("off"),
+				),
+			),
+			fieldError("CountedQty", "Counted Qty"),
+		),
+
+		h.Button(
+			h.Class("button primary"),
+			h.Type("submit"),
+			g.Text("Add Line"),
+		),
+	)
+}
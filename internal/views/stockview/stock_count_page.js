@@ -0,0 +1,12 @@
+document.addEventListener("DOMContentLoaded", () => {
+  const actionForms = document.querySelectorAll(".stock-count-action-form");
+
+  actionForms.forEach((form) => {
+    form.addEventListener("submit", (event) => {
+      const confirmed = window.confirm(form.dataset.confirm);
+      if (!confirmed) {
+        event.preventDefault();
+      }
+    });
+  });
+});
@@ -0,0 +1,216 @@
+package stockview
+
+import (
+	"app/internal/components"
+	"app/internal/layout"
+	"app/internal/model"
+	"app/pkg/appsort"
+	"app/pkg/reqcontext"
+	"fmt"
+	"strings"
+	"time"
+
+	g "maragu.dev/gomponents"
+	c "maragu.dev/gomponents/components"
+	h "maragu.dev/gomponents/html"
+)
+
+type StockCountsPageProps struct {
+	Ctx              reqcontext.ReqContext
+	StockCounts      []model.StockCount
+	StockCountsCount int
+	Status           string
+	Sort             appsort.Sort
+	Page             int
+	PageSize         int
+}
+
+func StockCountsPage(p *StockCountsPageProps) g.Node {
+
+	perms := p.Ctx.User.Permissions
+
+	content := h.FormEl(
+		h.Method("GET"),
+
+		h.Nav(
+			h.Class("stock-nav"),
+			h.A(h.Href("/stock"), g.Text("Stock levels")),
+			h.A(h.Href("/stock/transactions"), g.Text("See all transactions")),
+			g.If(
+				perms.SupplyChain.Admin,
+				h.A(h.Href("/stock/counts/add"), g.Text("New count")),
+			),
+		),
+
+		h.H3(g.Text("Stock Counts")),
+
+		h.Div(
+			h.Class("stock-levels-filters"),
+
+			h.Label(
+				h.Class("filter"),
+				g.Text("Status"),
+				h.Select(
+					h.Class("lg"),
+					h.Name("Status"),
+					h.Option(h.Value(""), g.Text("All")),
+					g.Group(g.Map(model.StockCountStatuses, func(s model.StockCountStatus) g.Node {
+						return h.Option(
+							h.Value(string(s)),
+							g.Text(string(s)),
+							g.If(p.Status == string(s), h.Selected()),
+						)
+					})),
+				),
+			),
+
+			h.Div(
+				h.Class("go-button-wrapper"),
+				components.Button(&components.ButtonProps{
+					ButtonType: components.ButtonPrimary,
+					Classes:    c.Classes{"go-button": true},
+					Size:       components.ButtonLg,
+				},
+					h.Type("button"),
+					g.Attr("onclick", "submitTableForm(this.form)"),
+					g.Text("GO"),
+				),
+			),
+		),
+
+		components.Divider(),
+
+		stockCountsTable(&stockCountsTableProps{
+			stockCounts:      p.StockCounts,
+			stockCountsCount: p.StockCountsCount,
+			sort:             p.Sort,
+			page:             p.Page,
+			pageSize:         p.PageSize,
+		}),
+	)
+
+	return layout.Page(layout.PageProps{
+		Title:   "Stock Counts",
+		Content: content,
+		Ctx:     p.Ctx,
+		Breadcrumbs: []layout.Breadcrumb{
+			layout.HomeBreadcrumb,
+			{
+				IconIdentifier: "package-variant-closed",
+				Title:          "Stock",
+				URLPart:        "stock",
+			},
+			{
+				Title: "Counts",
+			},
+		},
+		AppendHead: []g.Node{
+			components.InlineStyle("/internal/views/stockview/stock_table.css"),
+		},
+	})
+}
+
+type stockCountsTableProps struct {
+	stockCounts      []model.StockCount
+	stockCountsCount int
+	sort             appsort.Sort
+	page             int
+	pageSize         int
+}
+
+func stockCountsTable(p *stockCountsTableProps) g.Node {
+
+	columns := components.TableColumns{
+		{TitleContents: g.Text("Reference"), SortKey: "Reference"},
+		{TitleContents: g.Text("Scope")},
+		{TitleContents: g.Text("Snapshot"), SortKey: "SnapshotAt"},
+		{TitleContents: g.Text("Status"), SortKey: "Status"},
+		{TitleContents: g.Text("Counted"), Classes: c.Classes{"text-right": true}},
+		{TitleContents: g.Text("Created By")},
+		{TitleContents: g.Text("Created"), SortKey: "CreatedAt"},
+	}
+
+	var rows components.TableRows
+	for _, sc := range p.stockCounts {
+
+		stockCountHref := fmt.Sprintf("/stock/counts/%d", sc.StockCountID)
+
+		reference := g.Text(sc.Reference)
+		if sc.IsBlind {
+			reference = g.Group([]g.Node{
+				g.Text(sc.Reference + " "),
+				components.Badge(&components.BadgeProps{
+					Type: components.BadgeSecondary,
+					Size: components.BadgeSm,
+				}, g.Text("Blind")),
+			})
+		}
+
+		rows = append(rows, components.TableRow{
+			Cells: []components.TableCell{
+				{Contents: h.A(h.Href(stockCountHref), reference)},
+				{Contents: g.Text(StockCountScope(&sc))},
+				{Contents: h.Span(h.Class("local-datetime"), g.Text(sc.SnapshotAt.Format(time.RFC3339)))},
+				{Contents: stockCountStatusBadge(sc.Status)},
+				{
+					Contents: g.Textf("%d / %d", sc.CountedLineCount, sc.LineCount),
+					Classes:  c.Classes{"text-right": true},
+				},
+				{Contents: g.Text(sc.CreatedByUsername)},
+				{Contents: h.Span(h.Class("local-datetime"), g.Text(sc.CreatedAt.Format(time.RFC3339)))},
+			},
+			HREF: stockCountHref,
+		})
+	}
+
+	return components.Table(&components.TableProps{
+		Classes: c.Classes{"stock-table": true},
+		Columns: columns,
+		Rows:    rows,
+		Sort:    p.sort,
+		Pagination: &components.TablePaginationProps{
+			TotalRecords:        p.stockCountsCount,
+			PageSize:            p.pageSize,
+			CurrentPage:         p.page,
+			CurrentPageQueryKey: "Page",
+			PageSizeQueryKey:    "PageSize",
+		},
+	})
+}
+
+// StockCountScope describes the locations and bins a count covers. Stock
+// items are summarised as a number as codes are not loaded with the count.
+func StockCountScope(sc *model.StockCount) string {
+
+	parts := []string{}
+	if len(sc.Locations) > 0 {
+		parts = append(parts, "Locations: "+strings.Join(sc.Locations, ", "))
+	}
+	if len(sc.Bins) > 0 {
+		parts = append(parts, "Bins: "+strings.Join(sc.Bins, ", "))
+	}
+	if len(sc.StockItemIDs) > 0 {
+		parts = append(parts, fmt.Sprintf("%d stock codes", len(sc.StockItemIDs)))
+	}
+
+	if len(parts) == 0 {
+		return "All stock"
+	}
+	return strings.Join(parts, "; ")
+}
+
+func stockCountStatusBadge(status model.StockCountStatus) g.Node {
+
+	badgeType := components.BadgeSecondary
+	switch status {
+	case model.CountingStockCountStatus:
+		badgeType = components.BadgeWarning
+	case model.ApprovedStockCountStatus:
+		badgeType = components.BadgeSuccess
+	}
+
+	return components.Badge(&components.BadgeProps{
+		Type: badgeType,
+		Size: components.BadgeSm,
+	}, g.Text(string(status)))
+}
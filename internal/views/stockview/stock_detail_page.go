@@ -3,22 +3,74 @@ package stockview
 import (
 	"app/internal/components"
 	"app/internal/layout"
+	"app/internal/model"
 	"app/pkg/reqcontext"
+	"net/url"
 
 	g "maragu.dev/gomponents"
 	h "maragu.dev/gomponents/html"
 )
 
+var StockDetailTransactionsPageSize = 50
+
 type StockDetailPageProps struct {
-	Ctx       reqcontext.ReqContext
-	StockCode string
+	Ctx               reqcontext.ReqContext
+	StockCode         string
+	StockTransactions []model.StockTransactionEntry
+	LotNumbers        []string
 }
 
 func StockDetailPage(p StockDetailPageProps) g.Node {
 
+	allTransactionsParams := url.Values{}
+	allTransactionsParams.Add("StockCode", p.StockCode)
+
+	lotsParams := url.Values{}
+	lotsParams.Add("StockCode", p.StockCode)
+
 	content := h.FormEl(
 
 		h.H3(g.Text(p.StockCode)),
+
+		h.P(
+			g.Textf("Latest %d ledger entries. ", StockDetailTransactionsPageSize),
+			h.A(
+				h.Href("/stock/transactions?"+allTransactionsParams.Encode()),
+				g.Text("See all transactions"),
+			),
+			g.Text(" "),
+			h.A(
+				h.Href(StockSerialsURL(p.StockCode)),
+				g.Text("See serials"),
+			),
+		),
+
+		transactionsTable(&transactionsTableProps{
+			stockTransactions: p.StockTransactions,
+			canReverse:        p.Ctx.User.Permissions.SupplyChain.Admin,
+		}),
+
+		g.If(
+			len(p.LotNumbers) > 0,
+			h.Div(
+				h.Class("stock-detail-lots"),
+				h.H3(g.Text("Lots")),
+				h.P(
+					g.Text("Select a lot to trace where it was used and where it came from. "),
+					h.A(
+						h.Href("/stock/lots?"+lotsParams.Encode()),
+						g.Text("See lot details and expiry dates"),
+					),
+				),
+				h.Ul(
+					g.Group(g.Map(p.LotNumbers, func(lotNumber string) g.Node {
+						return h.Li(
+							h.A(h.Href(LotTraceURL(p.StockCode, lotNumber)), g.Text(lotNumber)),
+						)
+					})),
+				),
+			),
+		),
 	)
 
 	return layout.Page(layout.PageProps{
@@ -38,6 +90,7 @@ func StockDetailPage(p StockDetailPageProps) g.Node {
 		},
 		AppendHead: []g.Node{
 			components.InlineStyle("/internal/views/stockview/stock_table.css"),
+			components.InlineScript("/internal/views/stockview/stock_transactions.js"),
 		},
 	})
 }
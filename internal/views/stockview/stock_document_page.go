@@ -0,0 +1,484 @@
+package stockview
+
+import (
+	"app/internal/components"
+	"app/internal/layout"
+	"app/internal/model"
+	"app/pkg/nilsafe"
+	"app/pkg/reqcontext"
+	"app/pkg/validate"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	g "maragu.dev/gomponents"
+	c "maragu.dev/gomponents/components"
+	h "maragu.dev/gomponents/html"
+)
+
+type StockDocumentPageProps struct {
+	Ctx           reqcontext.ReqContext
+	StockDocument model.StockDocument
+	Lines         []model.StockDocumentLine
+	Entries       []model.StockTransactionEntry
+	StockItems    []model.StockItem
+	CanEdit       bool
+	ErrorText     string
+
+	// Set when posting was stopped by a negative stock warning the user can
+	// acknowledge
+	NegativeStockWarning bool
+
+	// Add line form state
+	LineValues           url.Values
+	LineValidationErrors validate.ValidationErrors
+	IsLineSubmission     bool
+}
+
+func StockDocumentPage(p *StockDocumentPageProps) g.Node {
+
+	sd := p.StockDocument
+	isDraft := sd.Status == model.DraftStockDocumentStatus
+
+	type attribute struct {
+		label string
+		value g.Node
+	}
+
+	note := "\u2013"
+	if sd.Note != "" {
+		note = sd.Note
+	}
+
+	postedBy := g.Text("\u2013")
+	if sd.PostedAt != nil {
+		postedBy = g.Group([]g.Node{
+			g.Textf("%s on ", nilsafe.Str(sd.PostedByUsername)),
+			h.Span(h.Class("local-datetime"), g.Text(sd.PostedAt.Format(time.RFC3339))),
+		})
+	}
+
+	attributes := []attribute{
+		{label: "Type", value: g.Text(string(sd.DocumentType))},
+		{label: "Reference", value: g.Text(sd.Reference)},
+		{label: "Date", value: h.Span(h.Class("local-datetime"), g.Text(sd.DocumentDate.Format(time.RFC3339)))},
+		{label: "Note", value: g.Text(note)},
+		{label: "Created By", value: g.Text(sd.CreatedByUsername)},
+		{label: "Posted By", value: postedBy},
+	}
+
+	content := g.Group([]g.Node{
+		h.Ul(
+			h.Class("attributes-list"),
+
+			g.Group(g.Map(attributes, func(a attribute) g.Node {
+				return h.Li(
+					components.Icon(&components.IconProps{
+						Identifier: "arrow-right-thin",
+					}),
+					h.Strong(g.Textf("%s: ", a.label)),
+					h.Span(a.value),
+				)
+			})),
+		),
+
+		g.If(
+			p.ErrorText != "",
+			h.Div(
+				h.Class("error-msg"),
+				g.Text(p.ErrorText),
+			),
+		),
+
+		g.If(
+			isDraft && p.CanEdit && p.NegativeStockWarning,
+			h.Form(
+				h.Method("POST"),
+				h.Class("stock-document-action-form acknowledge-negative-stock-form"),
+				h.Action(fmt.Sprintf("/stock/documents/%d/post", sd.StockDocumentID)),
+				g.Attr("data-confirm", "Post this document even though it leaves negative stock?"),
+				h.Input(
+					h.Type("hidden"),
+					h.Name("AcknowledgeNegativeStock"),
+					h.Value("true"),
+				),
+				h.Button(
+					h.Class("button warning"),
+					h.Type("submit"),
+					g.Text("Post Anyway"),
+				),
+			),
+		),
+
+		h.H3(g.Text("Lines")),
+
+		stockDocumentLinesTable(&stockDocumentLinesTableProps{
+			stockDocumentID: sd.StockDocumentID,
+			lines:           p.Lines,
+			canRemove:       isDraft && p.CanEdit,
+		}),
+
+		g.If(
+			isDraft && p.CanEdit,
+			g.Group([]g.Node{
+				h.H3(g.Text("Add Line")),
+				addStockDocumentLineForm(&addStockDocumentLineFormProps{
+					stockDocumentID:  sd.StockDocumentID,
+					stockItems:       p.StockItems,
+					values:           p.LineValues,
+					validationErrors: p.LineValidationErrors,
+					isSubmission:     p.IsLineSubmission,
+				}),
+			}),
+		),
+
+		g.If(
+			sd.Status == model.PostedStockDocumentStatus,
+			g.Group([]g.Node{
+				h.H3(g.Text("Ledger Entries")),
+				transactionsTable(&transactionsTableProps{
+					stockTransactions: p.Entries,
+				}),
+			}),
+		),
+	})
+
+	return layout.Page(layout.PageProps{
+		Ctx:   p.Ctx,
+		Title: fmt.Sprintf("Stock Document - %s", sd.Reference),
+		Header: &layout.PageHeaderProps{
+			Title: h.Div(
+				h.Class("stock-document-page-title"),
+				h.H1(g.Textf("%s \u2013 %s", sd.DocumentType, sd.Reference)),
+				stockDocumentStatusBadge(sd.Status),
+			),
+			Actions: stockDocumentActions(&stockDocumentActionsProps{
+				stockDocumentID: sd.StockDocumentID,
+				canPost:         isDraft && p.CanEdit && len(p.Lines) > 0,
+				canCancel:       isDraft && p.CanEdit,
+			}),
+		},
+		Content: content,
+		Breadcrumbs: []layout.Breadcrumb{
+			layout.HomeBreadcrumb,
+			{
+				IconIdentifier: "package-variant-closed",
+				Title:          "Stock",
+				URLPart:        "stock",
+			},
+			{
+				Title:   "Documents",
+				URLPart: "documents",
+			},
+			{
+				Title: sd.Reference,
+			},
+		},
+		AppendHead: []g.Node{
+			components.InlineStyle("/internal/views/stockview/stock_table.css"),
+			components.InlineStyle("/internal/views/stockview/stock_document_page.css"),
+			components.InlineScript("/internal/views/stockview/stock_document_page.js"),
+		},
+	})
+}
+
+type stockDocumentActionsProps struct {
+	stockDocumentID int
+	canPost         bool
+	canCancel       bool
+}
+
+func stockDocumentActions(p *stockDocumentActionsProps) []g.Node {
+	actions := []g.Node{}
+
+	if p.canPost {
+		actions = append(actions, h.Form(
+			h.Method("POST"),
+			h.Class("stock-document-action-form"),
+			h.Action(fmt.Sprintf("/stock/documents/%d/post", p.stockDocumentID)),
+			g.Attr("data-confirm", "Post all lines of this document to the stock ledger?"),
+			h.Button(
+				h.Class("button primary"),
+				h.Type("submit"),
+				components.Icon(&components.IconProps{
+					Identifier: "check",
+				}),
+				g.Text("Post Document"),
+			),
+		))
+	}
+
+	if p.canCancel {
+		actions = append(actions, h.Form(
+			h.Method("POST"),
+			h.Class("stock-document-action-form"),
+			h.Action(fmt.Sprintf("/stock/documents/%d/cancel", p.stockDocumentID)),
+			g.Attr("data-confirm", "Cancel this document? It cannot be posted afterwards."),
+			h.Button(
+				h.Class("button secondary"),
+				h.Type("submit"),
+				g.Text("Cancel Document"),
+			),
+		))
+	}
+
+	return actions
+}
+
+type stockDocumentLinesTableProps struct {
+	stockDocumentID int
+	lines           []model.StockDocumentLine
+	canRemove       bool
+}
+
+func stockDocumentLinesTable(p *stockDocumentLinesTableProps) g.Node {
+
+	columns := components.TableColumns{
+		{TitleContents: g.Text("Transaction Type")},
+		{TitleContents: g.Text("Stock Code")},
+		{TitleContents: g.Text("Location")},
+		{TitleContents: g.Text("Bin")},
+		{TitleContents: g.Text("To Location")},
+		{TitleContents: g.Text("To Bin")},
+		{TitleContents: g.Text("Lot Number")},
+		{TitleContents: g.Text("Qty"), Classes: c.Classes{"text-right": true}},
+		{TitleContents: g.Text("Unit Cost"), Classes: c.Classes{"text-right": true}},
+		{TitleContents: g.Text("Serials")},
+		{TitleContents: g.Text("Note")},
+	}
+	if p.canRemove {
+		columns = append(columns, components.TableColumn{TitleContents: g.Text("")})
+	}
+
+	dashIfEmpty := func(s string) string {
+		if s == "" {
+			return "\u2013"
+		}
+		return s
+	}
+
+	var rows components.TableRows
+	for _, l := range p.lines {
+
+		isMovement := l.TransactionType == model.StockMovementTransactionType
+		toLocation, toBin := "\u2013", "\u2013"
+		if isMovement {
+			toLocation = l.ToLocation
+			toBin = dashIfEmpty(l.ToBin)
+		}
+
+		unitCost := "\u2013"
+		if l.UnitCost != nil {
+			unitCost = l.UnitCost.String()
+		}
+
+		cells := []components.TableCell{
+			{Contents: g.Text(string(l.TransactionType))},
+			{Contents: components.StockItemAnchor(l.StockCode)},
+			{Contents: g.Text(l.FromLocation)},
+			{Contents: g.Text(dashIfEmpty(l.FromBin))},
+			{Contents: g.Text(toLocation)},
+			{Contents: g.Text(toBin)},
+			{Contents: g.Text(dashIfEmpty(l.FromLotNumber))},
+			{Contents: g.Text(quantityWithUnit(l.Qty, l.Unit)), Classes: c.Classes{"text-right": true}},
+			{Contents: g.Text(unitCost), Classes: c.Classes{"text-right": true}},
+			{Contents: g.Text(dashIfEmpty(strings.Join(l.SerialNumbers, ", ")))},
+			{Contents: g.Text(dashIfEmpty(l.LineNote))},
+		}
+
+		if p.canRemove {
+			cells = append(cells, components.TableCell{
+				Contents: h.Form(
+					h.Method("POST"),
+					h.Action(fmt.Sprintf(
+						"/stock/documents/%d/lines/%d/delete", p.stockDocumentID, l.StockDocumentLineID,
+					)),
+					h.Button(
+						h.Class("button secondary small"),
+						h.Type("submit"),
+						g.Text("Remove"),
+					),
+				),
+			})
+		}
+
+		rows = append(rows, components.TableRow{
+			Cells: cells,
+		})
+	}
+
+	return components.Table(&components.TableProps{
+		Classes: c.Classes{"stock-table": true},
+		Columns: columns,
+		Rows:    rows,
+	})
+}
+
+type addStockDocumentLineFormProps struct {
+	stockDocumentID  int
+	stockItems       []model.StockItem
+	values           url.Values
+	validationErrors validate.ValidationErrors
+	isSubmission     bool
+}
+
+func addStockDocumentLineForm(p *addStockDocumentLineFormProps) g.Node {
+
+	fieldError := func(key, label string) g.Node {
+		if !p.isSubmission {
+			return nil
+		}
+		errorText := p.validationErrors.GetError(key, label)
+		if errorText == "" {
+			return nil
+		}
+		return components.InputHelper(&components.InputHelperProps{
+			Label: errorText,
+			Type:  components.InputHelperTypeError,
+		})
+	}
+
+	textInput := func(key, label, placeholder string) g.Node {
+		return h.Div(
+			h.Label(
+				g.Text(label),
+				h.Input(
+					h.Type("text"),
+					h.Name(key),
+					h.Value(p.values.Get(key)),
+					h.Placeholder(placeholder),
+					h.AutoComplete("off"),
+				),
+			),
+			fieldError(key, label),
+		)
+	}
+
+	selectInput := func(key, label string, input g.Node) g.Node {
+		return h.Div(
+			h.Label(
+				g.Text(label),
+				input,
+			),
+			fieldError(key, label),
+		)
+	}
+
+	transactionTypeValue := p.values.Get("TransactionType")
+	selectedStockItem := p.values.Get("StockItemID")
+
+	transactionTypes := []model.StockTransactionType{
+		model.StockMovementTransactionType,
+		model.ProductionTransactionType,
+		model.ProductionReversalTransactionType,
+		model.ConsumptionTransactionType,
+		model.ConsumptionReversalTransactionType,
+		model.StockAdjustUpTransactionType,
+		model.StockAdjustDownTransactionType,
+	}
+
+	return h.Form(
+		h.Method("POST"),
+		h.Class("form stock-document-line-form"),
+		h.Action(fmt.Sprintf("/stock/documents/%d/lines", p.stockDocumentID)),
+
+		h.Div(
+			h.Label(
+				g.Text("Transaction Type"),
+				h.Select(
+					h.Name("TransactionType"),
+					h.Class("select"),
+					g.Group(g.Map(transactionTypes, func(tt model.StockTransactionType) g.Node {
+						return h.Option(
+							h.Value(string(tt)),
+							g.Text(string(tt)),
+							g.If(transactionTypeValue == string(tt), h.Selected()),
+						)
+					})),
+				),
+			),
+			fieldError("TransactionType", "Transaction Type"),
+		),
+
+		h.Div(
+			h.Label(
+				g.Text("Stock Code"),
+				components.SearchSelect(&components.SearchSelectProps{
+					Name:                 "StockItemID",
+					Placeholder:          "Select Stock Code",
+					Mode:                 "single",
+					Options:              MapStockItemsToOptions(p.stockItems, selectedStockItem),
+					Selected:             selectedStockItem,
+					OptionsEndpoint:      "/get-stock-codes",
+					SearchQueryParamName: "SearchText",
+				}),
+			),
+			fieldError("StockItemID", "Stock Code"),
+		),
+
+		h.Div(
+			h.Label(
+				g.Text("Qty"),
+				h.Input(
+					h.Type("number"),
+					h.Min("0"),
+					h.Step("any"),
+					h.Name("Qty"),
+					h.Value(p.values.Get("Qty")),
+					h.Placeholder("Enter quantity"),
+					h.AutoComplete("off"),
+				),
+			),
+			fieldError("Qty", "Qty"),
+		),
+
+		textInput("Unit", "Unit (optional, defaults to base unit)", "Enter unit"),
+		selectInput("FromLocation", "Location",
+			locationSelect("FromLocation", p.values.Get("FromLocation"))),
+		selectInput("FromBin", "Bin",
+			binSelect("FromBin", "FromLocation", p.values.Get("FromBin"))),
+		textInput("FromLotNumber", "Lot Number (only if lot tracked)", "Enter lot number"),
+		selectInput("ToLocation", "To Location (stock movements only)",
+			locationSelect("ToLocation", p.values.Get("ToLocation"))),
+		selectInput("ToBin", "To Bin (stock movements only)",
+			binSelect("ToBin", "ToLocation", p.values.Get("ToBin"))),
+
+		h.Div(
+			h.Label(
+				g.Text("Serial Numbers (serialised items only)"),
+				h.Textarea(
+					h.Name("SerialNumbers"),
+					h.Placeholder("Enter one serial number per unit, separated by commas or new lines"),
+					h.AutoComplete("off"),
+					g.Text(p.values.Get("SerialNumbers")),
+				),
+			),
+			fieldError("SerialNumbers", "Serial Numbers"),
+		),
+
+		h.Div(
+			h.Label(
+				g.Text("Unit Cost (inbound lines only, per base unit)"),
+				h.Input(
+					h.Type("number"),
+					h.Min("0"),
+					h.Step("any"),
+					h.Name("UnitCost"),
+					h.Value(p.values.Get("UnitCost")),
+					h.Placeholder("Defaults to current average cost"),
+					h.AutoComplete("off"),
+				),
+			),
+			fieldError("UnitCost", "Unit Cost"),
+		),
+
+		textInput("LineNote", "Note (optional)", "Enter line note"),
+
+		h.Button(
+			h.Class("button primary"),
+			h.Type("submit"),
+			g.Text("Add Line"),
+		),
+	)
+}
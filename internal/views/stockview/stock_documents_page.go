@@ -0,0 +1,180 @@
+package stockview
+
+import (
+	"app/internal/components"
+	"app/internal/layout"
+	"app/internal/model"
+	"app/pkg/appsort"
+	"app/pkg/reqcontext"
+	"fmt"
+	"time"
+
+	g "maragu.dev/gomponents"
+	c "maragu.dev/gomponents/components"
+	h "maragu.dev/gomponents/html"
+)
+
+type StockDocumentsPageProps struct {
+	Ctx                 reqcontext.ReqContext
+	StockDocuments      []model.StockDocument
+	StockDocumentsCount int
+	Status              string
+	Sort                appsort.Sort
+	Page                int
+	PageSize            int
+}
+
+func StockDocumentsPage(p *StockDocumentsPageProps) g.Node {
+
+	perms := p.Ctx.User.Permissions
+
+	content := h.FormEl(
+		h.Method("GET"),
+
+		h.Nav(
+			h.Class("stock-nav"),
+			h.A(h.Href("/stock"), g.Text("Stock levels")),
+			h.A(h.Href("/stock/transactions"), g.Text("See all transactions")),
+			g.If(
+				perms.SupplyChain.Admin,
+				h.A(h.Href("/stock/documents/add"), g.Text("New document")),
+			),
+		),
+
+		h.H3(g.Text("Stock Documents")),
+
+		h.Div(
+			h.Class("stock-levels-filters"),
+
+			h.Label(
+				h.Class("filter"),
+				g.Text("Status"),
+				h.Select(
+					h.Class("lg"),
+					h.Name("Status"),
+					h.Option(h.Value(""), g.Text("All")),
+					g.Group(g.Map(model.StockDocumentStatuses, func(s model.StockDocumentStatus) g.Node {
+						return h.Option(
+							h.Value(string(s)),
+							g.Text(string(s)),
+							g.If(p.Status == string(s), h.Selected()),
+						)
+					})),
+				),
+			),
+
+			h.Div(
+				h.Class("go-button-wrapper"),
+				components.Button(&components.ButtonProps{
+					ButtonType: components.ButtonPrimary,
+					Classes:    c.Classes{"go-button": true},
+					Size:       components.ButtonLg,
+				},
+					h.Type("button"),
+					g.Attr("onclick", "submitTableForm(this.form)"),
+					g.Text("GO"),
+				),
+			),
+		),
+
+		components.Divider(),
+
+		stockDocumentsTable(&stockDocumentsTableProps{
+			stockDocuments:      p.StockDocuments,
+			stockDocumentsCount: p.StockDocumentsCount,
+			sort:                p.Sort,
+			page:                p.Page,
+			pageSize:            p.PageSize,
+		}),
+	)
+
+	return layout.Page(layout.PageProps{
+		Title:   "Stock Documents",
+		Content: content,
+		Ctx:     p.Ctx,
+		Breadcrumbs: []layout.Breadcrumb{
+			layout.HomeBreadcrumb,
+			{
+				IconIdentifier: "package-variant-closed",
+				Title:          "Stock",
+				URLPart:        "stock",
+			},
+			{
+				Title: "Documents",
+			},
+		},
+		AppendHead: []g.Node{
+			components.InlineStyle("/internal/views/stockview/stock_table.css"),
+		},
+	})
+}
+
+type stockDocumentsTableProps struct {
+	stockDocuments      []model.StockDocument
+	stockDocumentsCount int
+	sort                appsort.Sort
+	page                int
+	pageSize            int
+}
+
+func stockDocumentsTable(p *stockDocumentsTableProps) g.Node {
+
+	columns := components.TableColumns{
+		{TitleContents: g.Text("Reference"), SortKey: "Reference"},
+		{TitleContents: g.Text("Type"), SortKey: "DocumentType"},
+		{TitleContents: g.Text("Date"), SortKey: "DocumentDate"},
+		{TitleContents: g.Text("Status"), SortKey: "Status"},
+		{TitleContents: g.Text("Lines"), Classes: c.Classes{"text-right": true}},
+		{TitleContents: g.Text("Created By")},
+		{TitleContents: g.Text("Created"), SortKey: "CreatedAt"},
+	}
+
+	var rows components.TableRows
+	for _, sd := range p.stockDocuments {
+
+		stockDocumentHref := fmt.Sprintf("/stock/documents/%d", sd.StockDocumentID)
+
+		rows = append(rows, components.TableRow{
+			Cells: []components.TableCell{
+				{Contents: h.A(h.Href(stockDocumentHref), g.Text(sd.Reference))},
+				{Contents: g.Text(string(sd.DocumentType))},
+				{Contents: h.Span(h.Class("local-datetime"), g.Text(sd.DocumentDate.Format(time.RFC3339)))},
+				{Contents: stockDocumentStatusBadge(sd.Status)},
+				{Contents: g.Textf("%d", sd.LineCount), Classes: c.Classes{"text-right": true}},
+				{Contents: g.Text(sd.CreatedByUsername)},
+				{Contents: h.Span(h.Class("local-datetime"), g.Text(sd.CreatedAt.Format(time.RFC3339)))},
+			},
+			HREF: stockDocumentHref,
+		})
+	}
+
+	return components.Table(&components.TableProps{
+		Classes: c.Classes{"stock-table": true},
+		Columns: columns,
+		Rows:    rows,
+		Sort:    p.sort,
+		Pagination: &components.TablePaginationProps{
+			TotalRecords:        p.stockDocumentsCount,
+			PageSize:            p.pageSize,
+			CurrentPage:         p.page,
+			CurrentPageQueryKey: "Page",
+			PageSizeQueryKey:    "PageSize",
+		},
+	})
+}
+
+func stockDocumentStatusBadge(status model.StockDocumentStatus) g.Node {
+
+	badgeType := components.BadgeSecondary
+	switch status {
+	case model.DraftStockDocumentStatus:
+		badgeType = components.BadgeWarning
+	case model.PostedStockDocumentStatus:
+		badgeType = components.BadgeSuccess
+	}
+
+	return components.Badge(&components.BadgeProps{
+		Type: badgeType,
+		Size: components.BadgeSm,
+	}, g.Text(string(status)))
+}
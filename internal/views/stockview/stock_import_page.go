@@ -0,0 +1,295 @@
+package stockview
+
+import (
+	"app/internal/components"
+	"app/internal/layout"
+	"app/internal/model"
+	"app/pkg/nilsafe"
+	"app/pkg/reqcontext"
+	"fmt"
+	"strings"
+	"time"
+
+	g "maragu.dev/gomponents"
+	c "maragu.dev/gomponents/components"
+	h "maragu.dev/gomponents/html"
+)
+
+type StockImportPageProps struct {
+	Ctx         reqcontext.ReqContext
+	StockImport model.StockImport
+	Rows        []model.StockImportRow
+	CanEdit     bool
+	ErrorText   string
+
+	// NegativeStockWarning offers to post again, acknowledging that the
+	// import leaves negative stock
+	NegativeStockWarning bool
+}
+
+func StockImportPage(p *StockImportPageProps) g.Node {
+
+	si := p.StockImport
+	isPending := si.Status == model.PendingStockImportStatus
+
+	validRowCount := 0
+	for _, row := range p.Rows {
+		if len(row.Errors) == 0 {
+			validRowCount++
+		}
+	}
+
+	type attribute struct {
+		label string
+		value g.Node
+	}
+
+	uploadedBy := g.Group([]g.Node{
+		g.Textf("%s on ", nilsafe.Str(si.CreatedByUsername)),
+		h.Span(h.Class("local-datetime"), g.Text(si.CreatedAt.Format(time.RFC3339))),
+	})
+
+	postedBy := g.Text("\u2013")
+	if si.PostedAt != nil {
+		postedBy = g.Group([]g.Node{
+			g.Textf("%s on ", nilsafe.Str(si.PostedByUsername)),
+			h.Span(h.Class("local-datetime"), g.Text(si.PostedAt.Format(time.RFC3339))),
+		})
+	}
+
+	attributes := []attribute{
+		{label: "File", value: g.Text(si.Filename)},
+		{label: "Uploaded By", value: uploadedBy},
+		{label: "Posted By", value: postedBy},
+	}
+	if isPending {
+		attributes = append(attributes, attribute{
+			label: "Rows",
+			value: g.Textf("%d valid, %d with errors", validRowCount, len(p.Rows)-validRowCount),
+		})
+	}
+	if si.PostedRowCount != nil {
+		attributes = append(attributes, attribute{
+			label: "Rows Posted",
+			value: g.Textf("%d of %d", *si.PostedRowCount, si.RowCount),
+		})
+	}
+
+	content := g.Group([]g.Node{
+		h.Ul(
+			h.Class("attributes-list"),
+
+			g.Group(g.Map(attributes, func(a attribute) g.Node {
+				return h.Li(
+					components.Icon(&components.IconProps{
+						Identifier: "arrow-right-thin",
+					}),
+					h.Strong(g.Textf("%s: ", a.label)),
+					h.Span(a.value),
+				)
+			})),
+		),
+
+		g.If(
+			p.ErrorText != "",
+			h.Div(
+				h.Class("error-msg"),
+				g.Text(p.ErrorText),
+			),
+		),
+
+		g.If(
+			isPending && p.CanEdit && p.NegativeStockWarning,
+			h.Form(
+				h.Method("POST"),
+				h.Class("stock-document-action-form acknowledge-negative-stock-form"),
+				h.Action(fmt.Sprintf("/stock/imports/%d/post", si.StockImportID)),
+				g.Attr("data-confirm", "Post this import even though it leaves negative stock?"),
+				h.Input(
+					h.Type("hidden"),
+					h.Name("AcknowledgeNegativeStock"),
+					h.Value("true"),
+				),
+				h.Button(
+					h.Class("button warning"),
+					h.Type("submit"),
+					g.Text("Post Anyway"),
+				),
+			),
+		),
+
+		h.H3(g.Text("Rows")),
+
+		g.If(
+			isPending,
+			h.P(g.Text(`Rows with errors are skipped when the import is posted. To post them, fix
+				the file and upload it again as a new import.`)),
+		),
+
+		stockImportRowsTable(p.Rows, isPending),
+	})
+
+	return layout.Page(layout.PageProps{
+		Ctx:   p.Ctx,
+		Title: fmt.Sprintf("Stock Import %d", si.StockImportID),
+		Header: &layout.PageHeaderProps{
+			Title: h.Div(
+				h.Class("stock-document-page-title"),
+				h.H1(g.Textf("Stock Import %d", si.StockImportID)),
+				stockImportStatusBadge(si.Status),
+			),
+			Actions: stockImportActions(&stockImportActionsProps{
+				stockImportID: si.StockImportID,
+				validRowCount: validRowCount,
+				canPost:       isPending && p.CanEdit && validRowCount > 0,
+				canCancel:     isPending && p.CanEdit,
+			}),
+		},
+		Content: content,
+		Breadcrumbs: []layout.Breadcrumb{
+			layout.HomeBreadcrumb,
+			{
+				IconIdentifier: "package-variant-closed",
+				Title:          "Stock",
+				URLPart:        "stock",
+			},
+			{
+				Title:   "Imports",
+				URLPart: "imports",
+			},
+			{
+				Title: fmt.Sprintf("Import %d", si.StockImportID),
+			},
+		},
+		AppendHead: []g.Node{
+			components.InlineStyle("/internal/views/stockview/stock_table.css"),
+			components.InlineStyle("/internal/views/stockview/stock_document_page.css"),
+			components.InlineStyle("/internal/views/stockview/stock_import_page.css"),
+			components.InlineScript("/internal/views/stockview/stock_document_page.js"),
+		},
+	})
+}
+
+type stockImportActionsProps struct {
+	stockImportID int
+	validRowCount int
+	canPost       bool
+	canCancel     bool
+}
+
+func stockImportActions(p *stockImportActionsProps) []g.Node {
+	actions := []g.Node{}
+
+	if p.canPost {
+		actions = append(actions, h.Form(
+			h.Method("POST"),
+			h.Class("stock-document-action-form"),
+			h.Action(fmt.Sprintf("/stock/imports/%d/post", p.stockImportID)),
+			g.Attr("data-confirm", fmt.Sprintf("Post the %d valid rows of this import to the stock ledger?", p.validRowCount)),
+			h.Button(
+				h.Class("button primary"),
+				h.Type("submit"),
+				components.Icon(&components.IconProps{
+					Identifier: "check",
+				}),
+				g.Text("Post Valid Rows"),
+			),
+		))
+	}
+
+	if p.canCancel {
+		actions = append(actions, h.Form(
+			h.Method("POST"),
+			h.Class("stock-document-action-form"),
+			h.Action(fmt.Sprintf("/stock/imports/%d/cancel", p.stockImportID)),
+			g.Attr("data-confirm", "Cancel this import? It cannot be posted afterwards."),
+			h.Button(
+				h.Class("button secondary"),
+				h.Type("submit"),
+				g.Text("Cancel Import"),
+			),
+		))
+	}
+
+	return actions
+}
+
+func stockImportRowsTable(importRows []model.StockImportRow, showErrors bool) g.Node {
+
+	columns := components.TableColumns{
+		{TitleContents: g.Text("Row"), Classes: c.Classes{"text-right": true}},
+		{TitleContents: g.Text("Transaction Type")},
+		{TitleContents: g.Text("Stock Code")},
+		{TitleContents: g.Text("Qty"), Classes: c.Classes{"text-right": true}},
+		{TitleContents: g.Text("From")},
+		{TitleContents: g.Text("To")},
+		{TitleContents: g.Text("Serial Numbers")},
+		{TitleContents: g.Text("Unit Cost"), Classes: c.Classes{"text-right": true}},
+		{TitleContents: g.Text("Note")},
+	}
+	if showErrors {
+		columns = append(columns, components.TableColumn{TitleContents: g.Text("Errors")})
+	}
+
+	dashIfEmpty := func(v string) string {
+		if v == "" {
+			return "\u2013"
+		}
+		return v
+	}
+
+	place := func(location, bin, lotNumber string) string {
+		parts := []string{}
+		for _, part := range []string{location, bin, lotNumber} {
+			if part != "" {
+				parts = append(parts, part)
+			}
+		}
+		return dashIfEmpty(strings.Join(parts, " / "))
+	}
+
+	var rows components.TableRows
+	for _, row := range importRows {
+
+		qty := row.Qty
+		if row.Unit != "" {
+			qty = fmt.Sprintf("%s %s", row.Qty, row.Unit)
+		}
+
+		to := "\u2013"
+		if row.TransactionType == model.StockMovementTransactionType {
+			to = place(row.ToLocation, row.ToBin, row.ToLotNumber)
+		}
+
+		cells := []components.TableCell{
+			{Contents: g.Textf("%d", row.RowNumber), Classes: c.Classes{"text-right": true}},
+			{Contents: g.Text(dashIfEmpty(string(row.TransactionType)))},
+			{Contents: g.Text(dashIfEmpty(row.StockCode))},
+			{Contents: g.Text(dashIfEmpty(qty)), Classes: c.Classes{"text-right": true}},
+			{Contents: g.Text(place(row.FromLocation, row.FromBin, row.FromLotNumber))},
+			{Contents: g.Text(to)},
+			{Contents: g.Text(dashIfEmpty(strings.Join(row.SerialNumbers, ", ")))},
+			{Contents: g.Text(dashIfEmpty(row.UnitCost)), Classes: c.Classes{"text-right": true}},
+			{Contents: g.Text(dashIfEmpty(row.Note))},
+		}
+		if showErrors {
+			errorsCell := components.TableCell{Contents: g.Text("\u2013")}
+			if len(row.Errors) > 0 {
+				errorsCell = components.TableCell{
+					Contents: h.Span(h.Class("stock-import-row-errors"), g.Text(strings.Join(row.Errors, "; "))),
+				}
+			}
+			cells = append(cells, errorsCell)
+		}
+
+		rows = append(rows, components.TableRow{
+			Cells: cells,
+		})
+	}
+
+	return components.Table(&components.TableProps{
+		Classes: c.Classes{"stock-table": true},
+		Columns: columns,
+		Rows:    rows,
+	})
+}
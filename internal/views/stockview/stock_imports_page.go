@@ -0,0 +1,240 @@
+package stockview
+
+import (
+	"app/internal/components"
+	"app/internal/layout"
+	"app/internal/model"
+	"app/pkg/nilsafe"
+	"app/pkg/reqcontext"
+	"app/pkg/validate"
+	"fmt"
+	"strings"
+	"time"
+
+	g "maragu.dev/gomponents"
+	c "maragu.dev/gomponents/components"
+	h "maragu.dev/gomponents/html"
+)
+
+type StockImportsPageProps struct {
+	Ctx               reqcontext.ReqContext
+	StockImports      []model.StockImport
+	StockImportsCount int
+	Status            string
+	Page              int
+	PageSize          int
+	ValidationErrors  validate.ValidationErrors
+}
+
+func StockImportsPage(p *StockImportsPageProps) g.Node {
+
+	perms := p.Ctx.User.Permissions
+
+	content := g.Group([]g.Node{
+		h.Nav(
+			h.Class("stock-nav"),
+			h.A(h.Href("/stock"), g.Text("Stock levels")),
+			h.A(h.Href("/stock/transactions"), g.Text("See all transactions")),
+			h.A(h.Href("/stock/imports/template"), g.Text("Download template")),
+		),
+
+		g.If(
+			perms.SupplyChain.Admin,
+			g.Group([]g.Node{
+				h.H3(g.Text("Upload Import")),
+				uploadStockImportForm(p.ValidationErrors),
+			}),
+		),
+
+		h.H3(g.Text("Stock Imports")),
+
+		h.FormEl(
+			h.Method("GET"),
+
+			h.Div(
+				h.Class("stock-levels-filters"),
+
+				h.Label(
+					h.Class("filter"),
+					g.Text("Status"),
+					h.Select(
+						h.Class("lg"),
+						h.Name("Status"),
+						h.Option(h.Value(""), g.Text("All")),
+						g.Group(g.Map(model.StockImportStatuses, func(s model.StockImportStatus) g.Node {
+							return h.Option(
+								h.Value(string(s)),
+								g.Text(string(s)),
+								g.If(p.Status == string(s), h.Selected()),
+							)
+						})),
+					),
+				),
+
+				h.Div(
+					h.Class("go-button-wrapper"),
+					components.Button(&components.ButtonProps{
+						ButtonType: components.ButtonPrimary,
+						Classes:    c.Classes{"go-button": true},
+						Size:       components.ButtonLg,
+					},
+						h.Type("button"),
+						g.Attr("onclick", "submitTableForm(this.form)"),
+						g.Text("GO"),
+					),
+				),
+			),
+
+			components.Divider(),
+
+			stockImportsTable(&stockImportsTableProps{
+				stockImports:      p.StockImports,
+				stockImportsCount: p.StockImportsCount,
+				page:              p.Page,
+				pageSize:          p.PageSize,
+			}),
+		),
+	})
+
+	return layout.Page(layout.PageProps{
+		Title:   "Stock Imports",
+		Content: content,
+		Ctx:     p.Ctx,
+		Breadcrumbs: []layout.Breadcrumb{
+			layout.HomeBreadcrumb,
+			{
+				IconIdentifier: "package-variant-closed",
+				Title:          "Stock",
+				URLPart:        "stock",
+			},
+			{
+				Title: "Imports",
+			},
+		},
+		AppendHead: []g.Node{
+			components.InlineStyle("/internal/views/stockview/stock_table.css"),
+			components.InlineStyle("/internal/views/stockview/stock_document_page.css"),
+		},
+	})
+}
+
+func uploadStockImportForm(validationErrors validate.ValidationErrors) g.Node {
+
+	fileError := validationErrors.GetError("File", "File")
+
+	return h.Form(
+		h.Method("POST"),
+		h.Action("/stock/imports"),
+		h.EncType("multipart/form-data"),
+		h.Class("form stock-document-line-form"),
+
+		h.P(g.Textf(
+			"Upload a CSV file with a heading row. The %s columns are required and the others are optional: %s. "+
+				"Rows are checked before anything is posted, and the file is kept with the import.",
+			strings.Join(model.StockImportRequiredColumns, ", "),
+			strings.Join(model.StockImportColumns, ", "),
+		)),
+
+		h.Div(
+			h.Label(
+				g.Text("File"),
+				h.Input(
+					h.Type("file"),
+					h.Name("File"),
+					h.Accept(".csv,text/csv"),
+				),
+			),
+			g.If(
+				fileError != "",
+				components.InputHelper(&components.InputHelperProps{
+					Label: fileError,
+					Type:  components.InputHelperTypeError,
+				}),
+			),
+		),
+
+		h.Button(
+			h.Class("button primary"),
+			h.Type("submit"),
+			components.Icon(&components.IconProps{
+				Identifier: "upload",
+			}),
+			g.Text("Upload and Preview"),
+		),
+	)
+}
+
+type stockImportsTableProps struct {
+	stockImports      []model.StockImport
+	stockImportsCount int
+	page              int
+	pageSize          int
+}
+
+func stockImportsTable(p *stockImportsTableProps) g.Node {
+
+	columns := components.TableColumns{
+		{TitleContents: g.Text("Import")},
+		{TitleContents: g.Text("File")},
+		{TitleContents: g.Text("Status")},
+		{TitleContents: g.Text("Rows"), Classes: c.Classes{"text-right": true}},
+		{TitleContents: g.Text("Rows With Errors"), Classes: c.Classes{"text-right": true}},
+		{TitleContents: g.Text("Rows Posted"), Classes: c.Classes{"text-right": true}},
+		{TitleContents: g.Text("Uploaded By")},
+		{TitleContents: g.Text("Uploaded")},
+	}
+
+	var rows components.TableRows
+	for _, si := range p.stockImports {
+
+		stockImportHref := fmt.Sprintf("/stock/imports/%d", si.StockImportID)
+
+		postedRowCount := "\u2013"
+		if si.PostedRowCount != nil {
+			postedRowCount = fmt.Sprintf("%d", *si.PostedRowCount)
+		}
+
+		rows = append(rows, components.TableRow{
+			Cells: []components.TableCell{
+				{Contents: h.A(h.Href(stockImportHref), g.Textf("%d", si.StockImportID))},
+				{Contents: g.Text(si.Filename)},
+				{Contents: stockImportStatusBadge(si.Status)},
+				{Contents: g.Textf("%d", si.RowCount), Classes: c.Classes{"text-right": true}},
+				{Contents: g.Textf("%d", si.ErrorRowCount), Classes: c.Classes{"text-right": true}},
+				{Contents: g.Text(postedRowCount), Classes: c.Classes{"text-right": true}},
+				{Contents: g.Text(nilsafe.Str(si.CreatedByUsername))},
+				{Contents: h.Span(h.Class("local-datetime"), g.Text(si.CreatedAt.Format(time.RFC3339)))},
+			},
+			HREF: stockImportHref,
+		})
+	}
+
+	return components.Table(&components.TableProps{
+		Classes: c.Classes{"stock-table": true},
+		Columns: columns,
+		Rows:    rows,
+		Pagination: &components.TablePaginationProps{
+			TotalRecords:        p.stockImportsCount,
+			PageSize:            p.pageSize,
+			CurrentPage:         p.page,
+			CurrentPageQueryKey: "Page",
+			PageSizeQueryKey:    "PageSize",
+		},
+	})
+}
+
+func stockImportStatusBadge(status model.StockImportStatus) g.Node {
+
+	badgeType := components.BadgeSecondary
+	switch status {
+	case model.PendingStockImportStatus:
+		badgeType = components.BadgeWarning
+	case model.PostedStockImportStatus:
+		badgeType = components.BadgeSuccess
+	}
+
+	return components.Badge(&components.BadgeProps{
+		Type: badgeType,
+		Size: components.BadgeSm,
+	}, g.Text(string(status)))
+}
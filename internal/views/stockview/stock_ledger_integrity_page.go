@@ -0,0 +1,272 @@
+package stockview
+
+import (
+	"app/internal/components"
+	"app/internal/layout"
+	"app/internal/model"
+	"app/pkg/format"
+	"app/pkg/reqcontext"
+	"fmt"
+	"time"
+
+	g "maragu.dev/gomponents"
+	c "maragu.dev/gomponents/components"
+	h "maragu.dev/gomponents/html"
+)
+
+type StockLedgerIntegrityPageProps struct {
+	Ctx           reqcontext.ReqContext
+	Report        *model.StockLedgerIntegrityReport
+	RebuildResult *model.StockLedgerRebuildResult
+	ErrorText     string
+}
+
+func StockLedgerIntegrityPage(p *StockLedgerIntegrityPageProps) g.Node {
+
+	content := g.Group([]g.Node{
+		h.Nav(
+			h.Class("stock-nav"),
+			h.A(h.Href("/stock"), g.Text("Stock levels")),
+			h.A(h.Href("/stock/transactions"), g.Text("See all transactions")),
+		),
+
+		h.H3(g.Text("Stock Ledger Integrity")),
+
+		h.P(
+			h.Class("stock-ledger-integrity-info"),
+			g.Text(`The check recomputes every running total from entry
+				quantities, checks that each transaction nets to zero across its
+				entries and compares the stock balance table with the ledger.
+				Rebuilding rewrites running totals and balances from quantities
+				in a single transaction, postings wait until it has finished.`),
+		),
+
+		h.Div(
+			h.Class("stock-ledger-integrity-actions"),
+			h.Form(
+				h.Method("GET"),
+				h.Input(h.Type("hidden"), h.Name("Run"), h.Value("true")),
+				h.Button(
+					h.Class("button primary"),
+					h.Type("submit"),
+					g.Text("Run Check"),
+				),
+			),
+			h.Form(
+				h.Method("POST"),
+				h.Class("stock-ledger-rebuild-form"),
+				h.Action("/stock/ledger-integrity/rebuild"),
+				g.Attr("data-confirm", "Rebuild all running totals and stock balances from quantities?"),
+				h.Button(
+					h.Class("button warning"),
+					h.Type("submit"),
+					g.Text("Rebuild Running Totals"),
+				),
+			),
+		),
+
+		g.If(
+			p.ErrorText != "",
+			h.Div(
+				h.Class("error-msg"),
+				g.Text(p.ErrorText),
+			),
+		),
+
+		g.Iff(p.RebuildResult != nil, func() g.Node {
+			return h.Div(
+				h.Class("success-msg"),
+				g.Textf(
+					"Rebuild complete: %d running totals corrected and %d stock balances written.",
+					p.RebuildResult.UpdatedEntriesCount,
+					p.RebuildResult.BalancesCount,
+				),
+			)
+		}),
+
+		g.Iff(p.Report != nil, func() g.Node {
+			return stockLedgerIntegrityReport(p.Report)
+		}),
+	})
+
+	return layout.Page(layout.PageProps{
+		Title:   "Stock Ledger Integrity",
+		Content: content,
+		Ctx:     p.Ctx,
+		Breadcrumbs: []layout.Breadcrumb{
+			layout.HomeBreadcrumb,
+			{
+				IconIdentifier: "package-variant-closed",
+				Title:          "Stock",
+				URLPart:        "stock",
+			},
+			{
+				Title: "Ledger Integrity",
+			},
+		},
+		AppendHead: []g.Node{
+			components.InlineStyle("/internal/views/stockview/stock_table.css"),
+			components.InlineStyle("/internal/views/stockview/stock_ledger_integrity_page.css"),
+			components.InlineScript("/internal/views/stockview/stock_ledger_integrity_page.js"),
+		},
+	})
+}
+
+func stockLedgerIntegrityReport(r *model.StockLedgerIntegrityReport) g.Node {
+
+	shownOf := func(shown, count int) string {
+		if shown < count {
+			return fmt.Sprintf(" (showing first %d of %d)", shown, count)
+		}
+		return fmt.Sprintf(" (%d)", count)
+	}
+
+	return g.Group([]g.Node{
+		h.P(
+			g.Text("Checked at "),
+			h.Span(h.Class("local-datetime"), g.Text(r.CheckedAt.Format(time.RFC3339))),
+			g.Text(": "),
+			g.If(
+				r.IsClean(),
+				components.Badge(&components.BadgeProps{
+					Type: components.BadgeSuccess,
+					Size: components.BadgeSm,
+				}, g.Text("No problems found")),
+			),
+			g.If(
+				!r.IsClean(),
+				components.Badge(&components.BadgeProps{
+					Type: components.BadgeDanger,
+					Size: components.BadgeSm,
+				}, g.Text("Problems found")),
+			),
+		),
+
+		h.H3(g.Text("Divergent Running Totals" + shownOf(len(r.DivergentEntries), r.DivergentEntriesCount))),
+		divergentEntriesTable(r.DivergentEntries),
+
+		h.H3(g.Text("Unbalanced Transactions" + shownOf(len(r.UnbalancedTransactions), r.UnbalancedTransactionsCount))),
+		unbalancedTransactionsTable(r.UnbalancedTransactions),
+
+		h.H3(g.Text("Divergent Stock Balances" + shownOf(len(r.DivergentBalances), r.DivergentBalancesCount))),
+		divergentBalancesTable(r.DivergentBalances),
+	})
+}
+
+func divergentEntriesTable(entries []model.StockLedgerEntryDivergence) g.Node {
+
+	columns := components.TableColumns{
+		{TitleContents: g.Text("Entry")},
+		{TitleContents: g.Text("Transaction")},
+		{TitleContents: g.Text("Timestamp")},
+		{TitleContents: g.Text("Stock Code")},
+		{TitleContents: g.Text("Account")},
+		{TitleContents: g.Text("Location")},
+		{TitleContents: g.Text("Bin")},
+		{TitleContents: g.Text("Lot Number")},
+		{TitleContents: g.Text("Quantity"), Classes: c.Classes{"text-right": true}},
+		{TitleContents: g.Text("Running Total"), Classes: c.Classes{"text-right": true}},
+		{TitleContents: g.Text("Expected"), Classes: c.Classes{"text-right": true}},
+	}
+
+	var rows components.TableRows
+	for _, e := range entries {
+		rows = append(rows, components.TableRow{
+			Cells: []components.TableCell{
+				{Contents: g.Textf("%d", e.StockTransactionEntryID)},
+				{Contents: g.Textf("%d", e.StockTransactionID)},
+				{Contents: h.Span(h.Class("local-datetime"), g.Text(e.Timestamp.Format(time.RFC3339)))},
+				{Contents: components.StockItemAnchor(e.StockCode)},
+				{Contents: g.Text(string(e.Account))},
+				{Contents: g.Text(e.Location)},
+				{Contents: g.Text(e.Bin)},
+				{Contents: g.Text(e.LotNumber)},
+				{Contents: g.Text(format.DecimalWithCommas(e.Quantity.String())), Classes: c.Classes{"text-right": true}},
+				{Contents: g.Text(format.DecimalWithCommas(e.RunningTotal.String())), Classes: c.Classes{"text-right": true}},
+				{Contents: g.Text(format.DecimalWithCommas(e.ExpectedRunningTotal.String())), Classes: c.Classes{"text-right": true}},
+			},
+		})
+	}
+
+	return components.Table(&components.TableProps{
+		Classes: c.Classes{"stock-table": true},
+		Columns: columns,
+		Rows:    rows,
+	})
+}
+
+func unbalancedTransactionsTable(transactions []model.UnbalancedStockTransaction) g.Node {
+
+	columns := components.TableColumns{
+		{TitleContents: g.Text("Transaction")},
+		{TitleContents: g.Text("Type")},
+		{TitleContents: g.Text("Timestamp")},
+		{TitleContents: g.Text("Stock Code")},
+		{TitleContents: g.Text("Entries"), Classes: c.Classes{"text-right": true}},
+		{TitleContents: g.Text("Net Quantity"), Classes: c.Classes{"text-right": true}},
+	}
+
+	var rows components.TableRows
+	for _, t := range transactions {
+		rows = append(rows, components.TableRow{
+			Cells: []components.TableCell{
+				{Contents: g.Textf("%d", t.StockTransactionID)},
+				{Contents: g.Text(string(t.TransactionType))},
+				{Contents: h.Span(h.Class("local-datetime"), g.Text(t.Timestamp.Format(time.RFC3339)))},
+				{Contents: components.StockItemAnchor(t.StockCode)},
+				{Contents: g.Textf("%d", t.EntryCount), Classes: c.Classes{"text-right": true}},
+				{Contents: g.Text(format.DecimalWithCommas(t.NetQuantity.String())), Classes: c.Classes{"text-right": true}},
+			},
+		})
+	}
+
+	return components.Table(&components.TableProps{
+		Classes: c.Classes{"stock-table": true},
+		Columns: columns,
+		Rows:    rows,
+	})
+}
+
+func divergentBalancesTable(balances []model.StockBalanceDivergence) g.Node {
+
+	columns := components.TableColumns{
+		{TitleContents: g.Text("Stock Code")},
+		{TitleContents: g.Text("Account")},
+		{TitleContents: g.Text("Location")},
+		{TitleContents: g.Text("Bin")},
+		{TitleContents: g.Text("Lot Number")},
+		{TitleContents: g.Text("Balance"), Classes: c.Classes{"text-right": true}},
+		{TitleContents: g.Text("Ledger"), Classes: c.Classes{"text-right": true}},
+		{TitleContents: g.Text("Problem")},
+	}
+
+	var rows components.TableRows
+	for _, b := range balances {
+
+		problem := "Quantity differs"
+		if b.MissingInBalance {
+			problem = "Missing from balance table"
+		} else if b.MissingInLedger {
+			problem = "Not in ledger"
+		}
+
+		rows = append(rows, components.TableRow{
+			Cells: []components.TableCell{
+				{Contents: components.StockItemAnchor(b.StockCode)},
+				{Contents: g.Text(string(b.Account))},
+				{Contents: g.Text(b.Location)},
+				{Contents: g.Text(b.Bin)},
+				{Contents: g.Text(b.LotNumber)},
+				{Contents: g.Text(format.DecimalWithCommas(b.BalanceQuantity.String())), Classes: c.Classes{"text-right": true}},
+				{Contents: g.Text(format.DecimalWithCommas(b.LedgerQuantity.String())), Classes: c.Classes{"text-right": true}},
+				{Contents: g.Text(problem)},
+			},
+		})
+	}
+
+	return components.Table(&components.TableProps{
+		Classes: c.Classes{"stock-table": true},
+		Columns: columns,
+		Rows:    rows,
+	})
+}
@@ -0,0 +1,11 @@
+document.addEventListener("DOMContentLoaded", () => {
+  const rebuildForm = document.querySelector(".stock-ledger-rebuild-form");
+  if (!rebuildForm) return;
+
+  rebuildForm.addEventListener("submit", (event) => {
+    const confirmed = window.confirm(rebuildForm.dataset.confirm);
+    if (!confirmed) {
+      event.preventDefault();
+    }
+  });
+});
@@ -11,6 +11,7 @@ import (
 	"net/url"
 	"time"
 
+	"github.com/shopspring/decimal"
 	g "maragu.dev/gomponents"
 	c "maragu.dev/gomponents/components"
 	h "maragu.dev/gomponents/html"
@@ -21,6 +22,7 @@ var HomePageDefaultPageSize = 200
 type StockLevelsPageProps struct {
 	Ctx          reqcontext.ReqContext
 	StockLevels  *[]model.StockLevel
+	Availability *model.StockAvailability
 	Account      string
 	StockCode    string
 	Location     string
@@ -42,10 +44,55 @@ func StockLevelsPage(p StockLevelsPageProps) g.Node {
 		h.Nav(
 			h.Class("stock-nav"),
 			h.A(h.Href("/stock/transactions"), g.Text("See all transactions")),
+			h.A(h.Href("/stock/documents"), g.Text("Documents")),
+			h.A(h.Href("/stock/imports"), g.Text("Imports")),
+			h.A(h.Href("/stock/counts"), g.Text("Stock counts")),
+			h.A(h.Href("/stock/lots"), g.Text("Lots")),
+			h.A(h.Href("/stock/serials"), g.Text("Serials")),
+			h.A(h.Href("/stock/reservations"), g.Text("Reservations")),
+			h.A(h.Href("/stock/purchase-orders"), g.Text("Purchase orders")),
+			h.A(h.Href("/stock/sales-orders"), g.Text("Sales orders")),
+			h.A(h.Href("/stock/replenishment"), g.Text("Replenishment")),
+			h.A(h.Href("/stock/valuation"), g.Text("Valuation")),
+			h.A(h.Href("/stock/lot-trace"), g.Text("Lot trace")),
+			h.A(h.Href("/stock?Account="+string(model.QuarantineStockAccount)), g.Text("Quarantine")),
+			h.A(h.Href("/stock/waste"), g.Text("Waste")),
 			g.If(
 				perms.SupplyChain.Admin,
 				h.A(h.Href("/stock/post-transaction/stock-movement"), g.Text("Post transaction")),
 			),
+			g.If(
+				perms.SupplyChain.Admin || perms.SupplyChain.TeamMember,
+				h.A(h.Href("/stock/scan"), g.Text("Scan")),
+			),
+			g.If(
+				perms.SupplyChain.Admin,
+				h.A(h.Href("/stock/locations"), g.Text("Locations")),
+			),
+			g.If(
+				perms.SupplyChain.Admin,
+				h.A(h.Href("/stock/scrap-reasons"), g.Text("Scrap reasons")),
+			),
+			g.If(
+				perms.SupplyChain.Admin,
+				h.A(h.Href("/stock/transaction-types"), g.Text("Transaction types")),
+			),
+			g.If(
+				perms.SupplyChain.Admin,
+				h.A(h.Href("/stock/periods"), g.Text("Periods")),
+			),
+			g.If(
+				perms.SupplyChain.Admin,
+				h.A(h.Href("/stock/negative-stock-policies"), g.Text("Negative stock policies")),
+			),
+			g.If(
+				perms.SupplyChain.Admin,
+				h.A(h.Href("/stock/reorder-policies"), g.Text("Reorder policies")),
+			),
+			g.If(
+				perms.SupplyChain.Admin,
+				h.A(h.Href("/stock/ledger-integrity"), g.Text("Ledger integrity")),
+			),
 		),
 
 		h.H3(g.Text("Stock Levels")),
@@ -54,11 +101,18 @@ func StockLevelsPage(p StockLevelsPageProps) g.Node {
 
 		components.Divider(),
 
+		g.Iff(p.Availability != nil, func() g.Node {
+			return stockAvailabilitySummary(p.Availability)
+		}),
+
 		stockLevelsTable(&stockLevelsTableProps{
 			stockLevels: *p.StockLevels,
-			page:        p.Page,
-			pageSize:    p.PageSize,
-			total:       p.Total,
+			// reservations and QC holds are of current stock in the STOCK
+			// account only
+			showReserved: p.Account == string(model.StockStockAccount) && p.LTETimestamp == nil,
+			page:         p.Page,
+			pageSize:     p.PageSize,
+			total:        p.Total,
 		}),
 	)
 
@@ -184,11 +238,34 @@ func filters(
 	)
 }
 
+// stockAvailabilitySummary shows what is available of a stock code across
+// all locations
+func stockAvailabilitySummary(a *model.StockAvailability) g.Node {
+	return h.P(
+		h.Class("stock-availability"),
+		g.Textf(
+			"%s: %s on hand, %s reserved, %s available, %s on order, %s quarantined. ",
+			a.StockCode,
+			quantityWithUnit(a.OnHand, a.Unit),
+			quantityWithUnit(a.Reserved, a.Unit),
+			quantityWithUnit(a.Available(), a.Unit),
+			quantityWithUnit(a.OnOrder, a.Unit),
+			quantityWithUnit(a.Quarantined, a.Unit),
+		),
+		h.A(h.Href(StockReservationsURL(a.StockCode)), g.Text("See reservations")),
+		g.Text(" "),
+		h.A(h.Href(PurchaseOrdersURL(a.StockCode)), g.Text("See purchase orders")),
+		g.Text(" "),
+		h.A(h.Href(SalesOrdersURL(a.StockCode)), g.Text("See sales orders")),
+	)
+}
+
 type stockLevelsTableProps struct {
-	stockLevels []model.StockLevel
-	page        int
-	pageSize    int
-	total       int
+	stockLevels  []model.StockLevel
+	showReserved bool
+	page         int
+	pageSize     int
+	total        int
 }
 
 func stockLevelsTable(p *stockLevelsTableProps) g.Node {
@@ -204,20 +281,32 @@ func stockLevelsTable(p *stockLevelsTableProps) g.Node {
 	}, {
 		TitleContents: g.Text("Lot Number"),
 	}, {
-		TitleContents: g.Text("Stock Level"),
+		TitleContents: g.Text("Expiry"),
 	}, {
+		TitleContents: g.Text("Stock Level"),
+	}}
+	if p.showReserved {
+		columns = append(columns, components.TableColumns{{
+			TitleContents: g.Text("Reserved"),
+		}, {
+			TitleContents: g.Text("Available"),
+		}, {
+			TitleContents: g.Text("Quarantined"),
+		}}...)
+	}
+	columns = append(columns, components.TableColumns{{
 		TitleContents: g.Text("Timestamp"),
 	}, {
 		TitleContents: g.Text(""),
-	}}
+	}}...)
 
 	var rows components.TableRows
 
 	for _, sl := range p.stockLevels {
 
-		lotNumber := sl.LotNumber
-		if lotNumber == "" {
-			lotNumber = "\u2013"
+		lotNumber := g.Text("\u2013")
+		if sl.LotNumber != "" {
+			lotNumber = h.A(h.Href(StockLotsURL(sl.StockCode, sl.LotNumber)), g.Text(sl.LotNumber))
 		}
 
 		trxParams := url.Values{}
@@ -238,15 +327,30 @@ func stockLevelsTable(p *stockLevelsTableProps) g.Node {
 		}, {
 			Contents: g.Text(sl.Bin),
 		}, {
-			Contents: g.Text(lotNumber),
+			Contents: lotNumber,
 		}, {
-			Contents:   g.Text(format.DecimalWithCommas(sl.StockLevel.String())),
-			Attributes: []g.Node{h.StyleAttr("text-align:right;")},
+			Contents: lotExpiry(sl.ExpiryDate),
 		}, {
+			Contents:   g.Text(quantityWithUnit(sl.StockLevel, sl.Unit)),
+			Attributes: []g.Node{h.StyleAttr("text-align:right;")},
+		}}
+		if p.showReserved {
+			rowCells = append(rowCells, []components.TableCell{{
+				Contents:   g.Text(quantityWithUnit(sl.Reserved, sl.Unit)),
+				Attributes: []g.Node{h.StyleAttr("text-align:right;")},
+			}, {
+				Contents:   g.Text(quantityWithUnit(sl.Available(), sl.Unit)),
+				Attributes: []g.Node{h.StyleAttr("text-align:right;")},
+			}, {
+				Contents:   g.Text(quantityWithUnit(sl.Quarantined, sl.Unit)),
+				Attributes: []g.Node{h.StyleAttr("text-align:right;")},
+			}}...)
+		}
+		rowCells = append(rowCells, []components.TableCell{{
 			Contents: h.Span(h.Class("local-datetime"), g.Text(sl.Timestamp.Format(time.RFC3339))),
 		}, {
 			Contents: h.A(h.Href(transactionsLink), g.Text("Transactions")),
-		}}
+		}}...)
 
 		rows = append(rows, components.TableRow{
 			Cells:      rowCells,
@@ -266,3 +370,11 @@ func stockLevelsTable(p *stockLevelsTableProps) g.Node {
 		},
 	})
 }
+
+// quantityWithUnit formats a quantity held in the base unit for display
+func quantityWithUnit(qty decimal.Decimal, unit string) string {
+	if unit == "" {
+		return format.DecimalWithCommas(qty.String())
+	}
+	return format.DecimalWithCommas(qty.String()) + " " + unit
+}
@@ -0,0 +1,308 @@
+package stockview
+
+import (
+	"app/internal/components"
+	"app/internal/layout"
+	"app/internal/model"
+	"app/pkg/nilsafe"
+	"app/pkg/reqcontext"
+	"app/pkg/validate"
+	"fmt"
+	"net/url"
+	"time"
+
+	g "maragu.dev/gomponents"
+	c "maragu.dev/gomponents/components"
+	h "maragu.dev/gomponents/html"
+)
+
+type StockLocationPageProps struct {
+	Ctx           reqcontext.ReqContext
+	StockLocation model.StockLocation
+	Bins          []model.StockBin
+	ErrorText     string
+
+	// Save bin form state
+	Values           url.Values
+	ValidationErrors validate.ValidationErrors
+	IsSubmission     bool
+}
+
+func StockLocationPage(p *StockLocationPageProps) g.Node {
+
+	sl := p.StockLocation
+
+	type attribute struct {
+		label string
+		value g.Node
+	}
+
+	createdBy := g.Group([]g.Node{
+		g.Textf("%s on ", nilsafe.Str(sl.CreatedByUsername)),
+		h.Span(h.Class("local-datetime"), g.Text(sl.CreatedAt.Format(time.RFC3339))),
+	})
+	updatedBy := g.Group([]g.Node{
+		g.Textf("%s on ", nilsafe.Str(sl.UpdatedByUsername)),
+		h.Span(h.Class("local-datetime"), g.Text(sl.UpdatedAt.Format(time.RFC3339))),
+	})
+
+	stockLevelsParams := url.Values{}
+	stockLevelsParams.Set("Location", sl.Location)
+
+	attributes := []attribute{
+		{label: "Location", value: g.Text(sl.Location)},
+		{label: "Type", value: g.Text(string(sl.LocationType))},
+		{label: "Status", value: archivedBadge(sl.IsArchived)},
+		{label: "Created By", value: createdBy},
+		{label: "Updated By", value: updatedBy},
+	}
+
+	content := g.Group([]g.Node{
+		h.Nav(
+			h.Class("stock-nav"),
+			h.A(h.Href("/stock/locations"), g.Text("Locations")),
+			h.A(h.Href("/stock?"+stockLevelsParams.Encode()), g.Text("Stock levels")),
+		),
+
+		h.Ul(
+			h.Class("attributes-list"),
+
+			g.Group(g.Map(attributes, func(a attribute) g.Node {
+				return h.Li(
+					components.Icon(&components.IconProps{
+						Identifier: "arrow-right-thin",
+					}),
+					h.Strong(g.Textf("%s: ", a.label)),
+					h.Span(a.value),
+				)
+			})),
+		),
+
+		g.If(
+			p.ErrorText != "",
+			h.Div(
+				h.Class("error-msg"),
+				g.Text(p.ErrorText),
+			),
+		),
+
+		h.H3(g.Text("Edit Location")),
+
+		editStockLocationForm(&sl),
+
+		h.H3(g.Text("Bins")),
+
+		stockBinsTable(p.Bins),
+
+		h.H3(g.Text("Add or Update Bin")),
+
+		saveStockBinForm(sl.StockLocationID, p.Values, p.ValidationErrors, p.IsSubmission),
+	})
+
+	return layout.Page(layout.PageProps{
+		Ctx:     p.Ctx,
+		Title:   fmt.Sprintf("Location %s", sl.Location),
+		Content: content,
+		Breadcrumbs: []layout.Breadcrumb{
+			layout.HomeBreadcrumb,
+			{
+				IconIdentifier: "package-variant-closed",
+				Title:          "Stock",
+				URLPart:        "stock",
+			},
+			{
+				Title:   "Locations",
+				URLPart: "locations",
+			},
+			{
+				Title: sl.Location,
+			},
+		},
+		AppendHead: []g.Node{
+			components.InlineStyle("/internal/views/stockview/stock_table.css"),
+			components.InlineStyle("/internal/views/stockview/stock_lot_page.css"),
+			components.InlineStyle("/internal/views/stockview/stock_locations_page.css"),
+		},
+	})
+}
+
+func editStockLocationForm(sl *model.StockLocation) g.Node {
+	return h.Form(
+		h.Method("POST"),
+		h.Class("form stock-location-form"),
+		h.Action(fmt.Sprintf("/stock/locations/%d", sl.StockLocationID)),
+
+		h.Div(
+			h.Label(
+				g.Text("Description (optional)"),
+				h.Input(
+					h.Type("text"),
+					h.Name("Description"),
+					h.Value(sl.Description),
+					h.Placeholder("Enter description"),
+					h.AutoComplete("off"),
+				),
+			),
+		),
+
+		h.Div(
+			h.Label(
+				g.Text("Type"),
+				stockLocationTypeSelect(string(sl.LocationType)),
+			),
+		),
+
+		h.Label(
+			h.Class("checkbox"),
+			h.Input(
+				h.Type("checkbox"),
+				h.Name("IsArchived"),
+				h.Value("true"),
+				g.If(sl.IsArchived, h.Checked()),
+			),
+			g.Text("Archived"),
+		),
+
+		h.P(
+			h.Class("stock-location-info"),
+			g.Text(`Stock can only be posted to locations and bins that are
+				active. Archived ones can still have stock taken out so that
+				they can be emptied.`),
+		),
+
+		h.Button(
+			h.Class("button primary"),
+			h.Type("submit"),
+			g.Text("Save Location"),
+		),
+	)
+}
+
+func stockBinsTable(bins []model.StockBin) g.Node {
+
+	columns := components.TableColumns{
+		{TitleContents: g.Text("Bin")},
+		{TitleContents: g.Text("Capacity"), Classes: c.Classes{"text-right": true}},
+		{TitleContents: g.Text("In Stock"), Classes: c.Classes{"text-right": true}},
+		{TitleContents: g.Text("Status")},
+		{TitleContents: g.Text("Updated")},
+	}
+
+	var rows components.TableRows
+	for _, b := range bins {
+
+		capacity := "Unlimited"
+		if b.Capacity != nil {
+			capacity = b.Capacity.String()
+		}
+
+		status := archivedBadge(b.IsArchived)
+		if b.IsOverCapacity() {
+			status = g.Group([]g.Node{
+				status,
+				g.Text(" "),
+				components.Badge(&components.BadgeProps{
+					Type: components.BadgeDanger,
+					Size: components.BadgeSm,
+				}, g.Text("Over capacity")),
+			})
+		}
+
+		rows = append(rows, components.TableRow{
+			Cells: []components.TableCell{
+				{Contents: g.Text(b.Bin)},
+				{
+					Contents: g.Text(capacity),
+					Classes:  c.Classes{"text-right": true},
+				},
+				{
+					Contents: g.Text(b.StockLevel.String()),
+					Classes:  c.Classes{"text-right": true},
+				},
+				{Contents: status},
+				{Contents: h.Span(h.Class("local-datetime"), g.Text(b.UpdatedAt.Format(time.RFC3339)))},
+			},
+		})
+	}
+
+	return components.Table(&components.TableProps{
+		Classes: c.Classes{"stock-table": true},
+		Columns: columns,
+		Rows:    rows,
+	})
+}
+
+func saveStockBinForm(
+	stockLocationID int,
+	values url.Values,
+	validationErrors validate.ValidationErrors,
+	isSubmission bool,
+) g.Node {
+
+	fieldError := func(key, label string) g.Node {
+		if !isSubmission {
+			return nil
+		}
+		errorText := validationErrors.GetError(key, label)
+		return g.If(
+			errorText != "",
+			components.InputHelper(&components.InputHelperProps{
+				Label: errorText,
+				Type:  components.InputHelperTypeError,
+			}),
+		)
+	}
+
+	return h.Form(
+		h.Method("POST"),
+		h.Class("form stock-location-form"),
+		h.Action(fmt.Sprintf("/stock/locations/%d/bins", stockLocationID)),
+
+		h.Div(
+			h.Label(
+				g.Text("Bin"),
+				h.Input(
+					h.Type("text"),
+					h.Name("Bin"),
+					h.Value(values.Get("Bin")),
+					h.Placeholder("Enter bin"),
+					h.AutoComplete("off"),
+				),
+			),
+			fieldError("Bin", "Bin"),
+		),
+
+		h.Div(
+			h.Label(
+				g.Text("Capacity (optional, in base units across all items)"),
+				h.Input(
+					h.Type("number"),
+					h.Min("0"),
+					h.Step("any"),
+					h.Name("Capacity"),
+					h.Value(values.Get("Capacity")),
+					h.Placeholder("Unlimited"),
+					h.AutoComplete("off"),
+				),
+			),
+			fieldError("Capacity", "Capacity"),
+		),
+
+		h.Label(
+			h.Class("checkbox"),
+			h.Input(
+				h.Type("checkbox"),
+				h.Name("IsArchived"),
+				h.Value("true"),
+				g.If(values.Get("IsArchived") == "true", h.Checked()),
+			),
+			g.Text("Archived"),
+		),
+
+		h.Button(
+			h.Class("button primary"),
+			h.Type("submit"),
+			g.Text("Save Bin"),
+		),
+	)
+}
@@ -0,0 +1,284 @@
+package stockview
+
+import (
+	"app/internal/components"
+	"app/internal/layout"
+	"app/internal/model"
+	"app/pkg/reqcontext"
+	"app/pkg/validate"
+	"fmt"
+	"net/url"
+
+	g "maragu.dev/gomponents"
+	c "maragu.dev/gomponents/components"
+	h "maragu.dev/gomponents/html"
+)
+
+type StockLocationsPageProps struct {
+	Ctx                 reqcontext.ReqContext
+	StockLocations      []model.StockLocation
+	StockLocationsCount int
+	SearchText          string
+	ShowArchived        bool
+	Page                int
+	PageSize            int
+	ErrorText           string
+
+	// Add location form state
+	Values           url.Values
+	ValidationErrors validate.ValidationErrors
+	IsSubmission     bool
+}
+
+func StockLocationsPage(p *StockLocationsPageProps) g.Node {
+
+	content := g.Group([]g.Node{
+		h.FormEl(
+			h.Method("GET"),
+
+			h.Nav(
+				h.Class("stock-nav"),
+				h.A(h.Href("/stock"), g.Text("Stock levels")),
+				h.A(h.Href("/stock/transactions"), g.Text("See all transactions")),
+			),
+
+			h.H3(g.Text("Locations")),
+
+			h.Div(
+				h.Class("stock-levels-filters"),
+
+				h.Label(
+					h.Class("filter"),
+					g.Text("Search"),
+					h.Input(
+						h.Class("lg"),
+						h.Name("SearchText"),
+						h.Value(p.SearchText),
+						h.AutoComplete("off"),
+						h.Placeholder("Location or description"),
+					),
+				),
+
+				h.Label(
+					h.Class("checkbox"),
+					h.Input(
+						h.Type("checkbox"),
+						h.Name("ShowArchived"),
+						h.Value("true"),
+						g.If(p.ShowArchived, h.Checked()),
+					),
+					g.Text("Show archived"),
+				),
+
+				h.Div(
+					h.Class("go-button-wrapper"),
+					components.Button(&components.ButtonProps{
+						ButtonType: components.ButtonPrimary,
+						Classes:    c.Classes{"go-button": true},
+						Size:       components.ButtonLg,
+					},
+						h.Type("button"),
+						g.Attr("onclick", "submitTableForm(this.form)"),
+						g.Text("GO"),
+					),
+				),
+			),
+
+			components.Divider(),
+
+			stockLocationsTable(&stockLocationsTableProps{
+				stockLocations:      p.StockLocations,
+				stockLocationsCount: p.StockLocationsCount,
+				page:                p.Page,
+				pageSize:            p.PageSize,
+			}),
+		),
+
+		g.If(
+			p.ErrorText != "",
+			h.Div(
+				h.Class("error-msg"),
+				g.Text(p.ErrorText),
+			),
+		),
+
+		h.H3(g.Text("Add Location")),
+
+		addStockLocationForm(p.Values, p.ValidationErrors, p.IsSubmission),
+	})
+
+	return layout.Page(layout.PageProps{
+		Title:   "Locations",
+		Content: content,
+		Ctx:     p.Ctx,
+		Breadcrumbs: []layout.Breadcrumb{
+			layout.HomeBreadcrumb,
+			{
+				IconIdentifier: "package-variant-closed",
+				Title:          "Stock",
+				URLPart:        "stock",
+			},
+			{
+				Title: "Locations",
+			},
+		},
+		AppendHead: []g.Node{
+			components.InlineStyle("/internal/views/stockview/stock_table.css"),
+			components.InlineStyle("/internal/views/stockview/stock_locations_page.css"),
+		},
+	})
+}
+
+type stockLocationsTableProps struct {
+	stockLocations      []model.StockLocation
+	stockLocationsCount int
+	page                int
+	pageSize            int
+}
+
+func stockLocationsTable(p *stockLocationsTableProps) g.Node {
+
+	columns := components.TableColumns{
+		{TitleContents: g.Text("Location")},
+		{TitleContents: g.Text("Description")},
+		{TitleContents: g.Text("Type")},
+		{TitleContents: g.Text("Bins"), Classes: c.Classes{"text-right": true}},
+		{TitleContents: g.Text("Status")},
+	}
+
+	var rows components.TableRows
+	for _, sl := range p.stockLocations {
+
+		stockLocationHref := fmt.Sprintf("/stock/locations/%d", sl.StockLocationID)
+
+		description := sl.Description
+		if description == "" {
+			description = "\u2013"
+		}
+
+		rows = append(rows, components.TableRow{
+			Cells: []components.TableCell{
+				{Contents: h.A(h.Href(stockLocationHref), g.Text(sl.Location))},
+				{Contents: g.Text(description)},
+				{Contents: g.Text(string(sl.LocationType))},
+				{
+					Contents: g.Textf("%d", sl.BinCount),
+					Classes:  c.Classes{"text-right": true},
+				},
+				{Contents: archivedBadge(sl.IsArchived)},
+			},
+			HREF: stockLocationHref,
+		})
+	}
+
+	return components.Table(&components.TableProps{
+		Classes: c.Classes{"stock-table": true},
+		Columns: columns,
+		Rows:    rows,
+		Pagination: &components.TablePaginationProps{
+			TotalRecords:        p.stockLocationsCount,
+			PageSize:            p.pageSize,
+			CurrentPage:         p.page,
+			CurrentPageQueryKey: "Page",
+			PageSizeQueryKey:    "PageSize",
+		},
+	})
+}
+
+func addStockLocationForm(
+	values url.Values,
+	validationErrors validate.ValidationErrors,
+	isSubmission bool,
+) g.Node {
+
+	locationType := values.Get("LocationType")
+
+	fieldError := func(key, label string) g.Node {
+		if !isSubmission {
+			return nil
+		}
+		errorText := validationErrors.GetError(key, label)
+		return g.If(
+			errorText != "",
+			components.InputHelper(&components.InputHelperProps{
+				Label: errorText,
+				Type:  components.InputHelperTypeError,
+			}),
+		)
+	}
+
+	return h.Form(
+		h.Method("POST"),
+		h.Class("form stock-location-form"),
+		h.Action("/stock/locations"),
+
+		h.Div(
+			h.Label(
+				g.Text("Location"),
+				h.Input(
+					h.Type("text"),
+					h.Name("Location"),
+					h.Value(values.Get("Location")),
+					h.Placeholder("Enter location"),
+					h.AutoComplete("off"),
+				),
+			),
+			fieldError("Location", "Location"),
+		),
+
+		h.Div(
+			h.Label(
+				g.Text("Description (optional)"),
+				h.Input(
+					h.Type("text"),
+					h.Name("Description"),
+					h.Value(values.Get("Description")),
+					h.Placeholder("Enter description"),
+					h.AutoComplete("off"),
+				),
+			),
+		),
+
+		h.Div(
+			h.Label(
+				g.Text("Type"),
+				stockLocationTypeSelect(locationType),
+			),
+			fieldError("LocationType", "Type"),
+		),
+
+		h.Button(
+			h.Class("button primary"),
+			h.Type("submit"),
+			g.Text("Add Location"),
+		),
+	)
+}
+
+func stockLocationTypeSelect(selected string) g.Node {
+	return h.Select(
+		h.Name("LocationType"),
+		h.Class("select"),
+		g.Group(g.Map(model.StockLocationTypes, func(t model.StockLocationType) g.Node {
+			return h.Option(
+				h.Value(string(t)),
+				g.Text(string(t)),
+				g.If(selected == string(t), h.Selected()),
+			)
+		})),
+	)
+}
+
+func archivedBadge(isArchived bool) g.Node {
+	if isArchived {
+		return components.Badge(&components.BadgeProps{
+			Type: components.BadgeSecondary,
+			Size: components.BadgeSm,
+		}, g.Text("Archived"))
+	}
+
+	return components.Badge(&components.BadgeProps{
+		Type: components.BadgeSuccess,
+		Size: components.BadgeSm,
+	}, g.Text("Active"))
+}
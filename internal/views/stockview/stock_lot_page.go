@@ -0,0 +1,235 @@
+package stockview
+
+import (
+	"app/internal/components"
+	"app/internal/layout"
+	"app/internal/model"
+	"app/pkg/nilsafe"
+	"app/pkg/reqcontext"
+	"app/pkg/validate"
+	"fmt"
+	"net/url"
+	"time"
+
+	g "maragu.dev/gomponents"
+	h "maragu.dev/gomponents/html"
+)
+
+type StockLotPageProps struct {
+	Ctx      reqcontext.ReqContext
+	StockLot model.StockLot
+	CanEdit  bool
+
+	// Edit form state
+	Values           url.Values
+	ValidationErrors validate.ValidationErrors
+	IsSubmission     bool
+}
+
+func StockLotPage(p *StockLotPageProps) g.Node {
+
+	sl := p.StockLot
+
+	type attribute struct {
+		label string
+		value g.Node
+	}
+
+	supplierLotNumber := "\u2013"
+	if sl.SupplierLotNumber != "" {
+		supplierLotNumber = sl.SupplierLotNumber
+	}
+
+	updatedBy := g.Text("\u2013")
+	if sl.UpdatedAt != nil {
+		updatedBy = g.Group([]g.Node{
+			g.Textf("%s on ", nilsafe.Str(sl.UpdatedByUsername)),
+			h.Span(h.Class("local-datetime"), g.Text(sl.UpdatedAt.Format(time.RFC3339))),
+		})
+	}
+
+	transactionsParams := url.Values{}
+	transactionsParams.Set("StockCode", sl.StockCode)
+	transactionsParams.Set("LotNumber", sl.LotNumber)
+
+	attributes := []attribute{
+		{label: "Stock Code", value: components.StockItemAnchor(sl.StockCode)},
+		{label: "Lot Number", value: g.Text(sl.LotNumber)},
+		{label: "Supplier Lot", value: g.Text(supplierLotNumber)},
+		{label: "Manufactured", value: g.Text(lotDate(sl.ManufactureDate))},
+		{label: "Expiry", value: lotExpiry(sl.ExpiryDate)},
+		{label: "Status", value: stockLotStatusBadge(sl.Status)},
+		{label: "In Stock", value: g.Text(quantityWithUnit(sl.StockQty, sl.Unit))},
+		{label: "Updated By", value: updatedBy},
+	}
+
+	content := g.Group([]g.Node{
+		h.Nav(
+			h.Class("stock-nav"),
+			h.A(h.Href("/stock/lots"), g.Text("Lots")),
+			h.A(h.Href("/stock/transactions?"+transactionsParams.Encode()), g.Text("Transactions")),
+			h.A(h.Href(LotTraceURL(sl.StockCode, sl.LotNumber)), g.Text("Lot trace")),
+		),
+
+		h.Ul(
+			h.Class("attributes-list"),
+
+			g.Group(g.Map(attributes, func(a attribute) g.Node {
+				return h.Li(
+					components.Icon(&components.IconProps{
+						Identifier: "arrow-right-thin",
+					}),
+					h.Strong(g.Textf("%s: ", a.label)),
+					h.Span(a.value),
+				)
+			})),
+		),
+
+		g.If(
+			p.CanEdit,
+			g.Group([]g.Node{
+				h.H3(g.Text("Edit Lot")),
+				stockLotForm(&p.StockLot, p.Values, p.ValidationErrors, p.IsSubmission),
+			}),
+		),
+	})
+
+	return layout.Page(layout.PageProps{
+		Ctx:     p.Ctx,
+		Title:   fmt.Sprintf("Lot %s", sl.LotNumber),
+		Content: content,
+		Breadcrumbs: []layout.Breadcrumb{
+			layout.HomeBreadcrumb,
+			{
+				IconIdentifier: "package-variant-closed",
+				Title:          "Stock",
+				URLPart:        "stock",
+			},
+			{
+				Title:   "Lots",
+				URLPart: "lots",
+			},
+			{
+				Title: fmt.Sprintf("%s %s", sl.StockCode, sl.LotNumber),
+			},
+		},
+		AppendHead: []g.Node{
+			components.InlineStyle("/internal/views/stockview/stock_table.css"),
+			components.InlineStyle("/internal/views/stockview/stock_lot_page.css"),
+		},
+	})
+}
+
+func stockLotForm(
+	sl *model.StockLot,
+	values url.Values,
+	validationErrors validate.ValidationErrors,
+	isSubmission bool,
+) g.Node {
+
+	// the form starts from the lot and keeps what was entered on a failed
+	// submission
+	value := func(key, current string) string {
+		if isSubmission {
+			return values.Get(key)
+		}
+		return current
+	}
+
+	dateValue := func(date *time.Time) string {
+		if date == nil {
+			return ""
+		}
+		return date.Format("2006-01-02")
+	}
+
+	fieldError := func(key, label string) g.Node {
+		if !isSubmission {
+			return nil
+		}
+		errorText := validationErrors.GetError(key, label)
+		if errorText == "" {
+			return nil
+		}
+		return components.InputHelper(&components.InputHelperProps{
+			Label: errorText,
+			Type:  components.InputHelperTypeError,
+		})
+	}
+
+	status := value("Status", string(sl.Status))
+
+	return h.Form(
+		h.Method("POST"),
+		h.Class("form stock-lot-form"),
+		h.Action(fmt.Sprintf("/stock/lots/%d", sl.StockLotID)),
+
+		h.Div(
+			h.Label(
+				g.Text("Supplier Lot (optional)"),
+				h.Input(
+					h.Type("text"),
+					h.Name("SupplierLotNumber"),
+					h.Value(value("SupplierLotNumber", sl.SupplierLotNumber)),
+					h.Placeholder("Enter supplier lot number"),
+					h.AutoComplete("off"),
+				),
+			),
+			fieldError("SupplierLotNumber", "Supplier Lot"),
+		),
+
+		h.Div(
+			h.Label(
+				g.Text("Manufacture Date (optional)"),
+				h.Input(
+					h.Type("date"),
+					h.Name("ManufactureDate"),
+					h.Value(value("ManufactureDate", dateValue(sl.ManufactureDate))),
+				),
+			),
+			fieldError("ManufactureDate", "Manufacture Date"),
+		),
+
+		h.Div(
+			h.Label(
+				g.Text("Expiry Date (optional)"),
+				h.Input(
+					h.Type("date"),
+					h.Name("ExpiryDate"),
+					h.Value(value("ExpiryDate", dateValue(sl.ExpiryDate))),
+				),
+			),
+			fieldError("ExpiryDate", "Expiry Date"),
+		),
+
+		h.Div(
+			h.Label(
+				g.Text("Status"),
+				h.Select(
+					h.Name("Status"),
+					g.Group(g.Map(model.StockLotStatuses, func(s model.StockLotStatus) g.Node {
+						return h.Option(
+							h.Value(string(s)),
+							g.Text(string(s)),
+							g.If(status == string(s), h.Selected()),
+						)
+					})),
+				),
+			),
+			fieldError("Status", "Status"),
+		),
+
+		h.P(
+			h.Class("stock-lot-info"),
+			g.Textf(`Lots that are not Available or are past their expiry date are
+				not suggested when posting. The supply chain team is notified of lots
+				in stock %d days before they expire.`, model.StockLotExpiryWarningDays),
+		),
+
+		h.Button(
+			h.Class("button primary"),
+			h.Type("submit"),
+			g.Text("Save Lot"),
+		),
+	)
+}
@@ -0,0 +1,276 @@
+package stockview
+
+import (
+	"app/internal/components"
+	"app/internal/layout"
+	"app/internal/model"
+	"app/pkg/reqcontext"
+	"fmt"
+	"net/url"
+	"time"
+
+	g "maragu.dev/gomponents"
+	c "maragu.dev/gomponents/components"
+	h "maragu.dev/gomponents/html"
+)
+
+type StockLotsPageProps struct {
+	Ctx                reqcontext.ReqContext
+	StockLots          []model.StockLot
+	StockLotsCount     int
+	StockCode          string
+	LotNumber          string
+	Status             string
+	ExpiringWithinDays int
+	Page               int
+	PageSize           int
+}
+
+func StockLotsPage(p *StockLotsPageProps) g.Node {
+
+	expiringWithinDays := ""
+	if p.ExpiringWithinDays > 0 {
+		expiringWithinDays = fmt.Sprintf("%d", p.ExpiringWithinDays)
+	}
+
+	content := h.FormEl(
+		h.Method("GET"),
+
+		h.Nav(
+			h.Class("stock-nav"),
+			h.A(h.Href("/stock"), g.Text("Stock levels")),
+			h.A(h.Href("/stock/transactions"), g.Text("See all transactions")),
+			h.A(h.Href("/stock/lot-trace"), g.Text("Lot trace")),
+			h.A(
+				h.Href(fmt.Sprintf("/stock/lots?ExpiringWithinDays=%d", model.StockLotExpiryWarningDays)),
+				g.Textf("Expiring within %d days", model.StockLotExpiryWarningDays),
+			),
+		),
+
+		h.H3(g.Text("Lots")),
+
+		h.Div(
+			h.Class("stock-levels-filters"),
+
+			h.Label(
+				h.Class("filter"),
+				g.Text("Stock Code"),
+				h.Input(
+					h.Class("lg"),
+					h.Name("StockCode"),
+					h.Value(p.StockCode),
+					h.AutoComplete("off"),
+					h.Placeholder("Enter stock code"),
+				),
+			),
+
+			h.Label(
+				h.Class("filter"),
+				g.Text("Lot Number"),
+				h.Input(
+					h.Class("lg"),
+					h.Name("LotNumber"),
+					h.Value(p.LotNumber),
+					h.AutoComplete("off"),
+					h.Placeholder("Enter lot number"),
+				),
+			),
+
+			h.Label(
+				h.Class("filter"),
+				g.Text("Status"),
+				h.Select(
+					h.Class("lg"),
+					h.Name("Status"),
+					h.Option(h.Value(""), g.Text("All")),
+					g.Group(g.Map(model.StockLotStatuses, func(s model.StockLotStatus) g.Node {
+						return h.Option(
+							h.Value(string(s)),
+							g.Text(string(s)),
+							g.If(p.Status == string(s), h.Selected()),
+						)
+					})),
+				),
+			),
+
+			h.Label(
+				h.Class("filter"),
+				g.Text("Expiring Within (days)"),
+				h.Input(
+					h.Class("lg"),
+					h.Type("number"),
+					h.Min("0"),
+					h.Name("ExpiringWithinDays"),
+					h.Value(expiringWithinDays),
+					h.AutoComplete("off"),
+					h.Placeholder("Any expiry"),
+				),
+			),
+
+			h.Div(
+				h.Class("go-button-wrapper"),
+				components.Button(&components.ButtonProps{
+					ButtonType: components.ButtonPrimary,
+					Classes:    c.Classes{"go-button": true},
+					Size:       components.ButtonLg,
+				},
+					h.Type("button"),
+					g.Attr("onclick", "submitTableForm(this.form)"),
+					g.Text("GO"),
+				),
+			),
+		),
+
+		components.Divider(),
+
+		stockLotsTable(&stockLotsTableProps{
+			stockLots:      p.StockLots,
+			stockLotsCount: p.StockLotsCount,
+			page:           p.Page,
+			pageSize:       p.PageSize,
+		}),
+	)
+
+	return layout.Page(layout.PageProps{
+		Title:   "Lots",
+		Content: content,
+		Ctx:     p.Ctx,
+		Breadcrumbs: []layout.Breadcrumb{
+			layout.HomeBreadcrumb,
+			{
+				IconIdentifier: "package-variant-closed",
+				Title:          "Stock",
+				URLPart:        "stock",
+			},
+			{
+				Title: "Lots",
+			},
+		},
+		AppendHead: []g.Node{
+			components.InlineStyle("/internal/views/stockview/stock_table.css"),
+		},
+	})
+}
+
+type stockLotsTableProps struct {
+	stockLots      []model.StockLot
+	stockLotsCount int
+	page           int
+	pageSize       int
+}
+
+func stockLotsTable(p *stockLotsTableProps) g.Node {
+
+	columns := components.TableColumns{
+		{TitleContents: g.Text("Stock Code")},
+		{TitleContents: g.Text("Lot Number")},
+		{TitleContents: g.Text("Supplier Lot")},
+		{TitleContents: g.Text("Manufactured")},
+		{TitleContents: g.Text("Expiry")},
+		{TitleContents: g.Text("Status")},
+		{TitleContents: g.Text("In Stock"), Classes: c.Classes{"text-right": true}},
+	}
+
+	var rows components.TableRows
+	for _, sl := range p.stockLots {
+
+		stockLotHref := fmt.Sprintf("/stock/lots/%d", sl.StockLotID)
+
+		supplierLotNumber := sl.SupplierLotNumber
+		if supplierLotNumber == "" {
+			supplierLotNumber = "\u2013"
+		}
+
+		rows = append(rows, components.TableRow{
+			Cells: []components.TableCell{
+				{Contents: components.StockItemAnchor(sl.StockCode)},
+				{Contents: h.A(h.Href(stockLotHref), g.Text(sl.LotNumber))},
+				{Contents: g.Text(supplierLotNumber)},
+				{Contents: g.Text(lotDate(sl.ManufactureDate))},
+				{Contents: lotExpiry(sl.ExpiryDate)},
+				{Contents: stockLotStatusBadge(sl.Status)},
+				{
+					Contents: g.Text(quantityWithUnit(sl.StockQty, sl.Unit)),
+					Classes:  c.Classes{"text-right": true},
+				},
+			},
+			HREF: stockLotHref,
+		})
+	}
+
+	return components.Table(&components.TableProps{
+		Classes: c.Classes{"stock-table": true},
+		Columns: columns,
+		Rows:    rows,
+		Pagination: &components.TablePaginationProps{
+			TotalRecords:        p.stockLotsCount,
+			PageSize:            p.pageSize,
+			CurrentPage:         p.page,
+			CurrentPageQueryKey: "Page",
+			PageSizeQueryKey:    "PageSize",
+		},
+	})
+}
+
+// StockLotsURL is the lots page filtered to a lot of a stock code
+func StockLotsURL(stockCode, lotNumber string) string {
+	params := url.Values{}
+	params.Add("StockCode", stockCode)
+	params.Add("LotNumber", lotNumber)
+	return "/stock/lots?" + params.Encode()
+}
+
+// lotDate formats a lot date, which has no time of day
+func lotDate(date *time.Time) string {
+	if date == nil {
+		return "\u2013"
+	}
+	return date.Format("2006-01-02")
+}
+
+// lotExpiry shows an expiry date, flagging lots that have expired or are
+// approaching expiry
+func lotExpiry(expiryDate *time.Time) g.Node {
+	if expiryDate == nil {
+		return g.Text("\u2013")
+	}
+
+	now := time.Now()
+	warnFrom := now.AddDate(0, 0, model.StockLotExpiryWarningDays)
+
+	var badge g.Node
+	if model.IsStockLotExpired(expiryDate, now) {
+		badge = components.Badge(&components.BadgeProps{
+			Type: components.BadgeDanger,
+			Size: components.BadgeSm,
+		}, g.Text("Expired"))
+	} else if model.IsStockLotExpired(expiryDate, warnFrom) {
+		badge = components.Badge(&components.BadgeProps{
+			Type: components.BadgeWarning,
+			Size: components.BadgeSm,
+		}, g.Text("Expiring"))
+	}
+
+	return g.Group([]g.Node{
+		g.Text(expiryDate.Format("2006-01-02") + " "),
+		badge,
+	})
+}
+
+func stockLotStatusBadge(status model.StockLotStatus) g.Node {
+
+	badgeType := components.BadgeSecondary
+	switch status {
+	case model.AvailableStockLotStatus:
+		badgeType = components.BadgeSuccess
+	case model.OnHoldStockLotStatus:
+		badgeType = components.BadgeWarning
+	case model.RejectedStockLotStatus:
+		badgeType = components.BadgeDanger
+	}
+
+	return components.Badge(&components.BadgeProps{
+		Type: badgeType,
+		Size: components.BadgeSm,
+	}, g.Text(string(status)))
+}
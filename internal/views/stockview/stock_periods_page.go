@@ -0,0 +1,321 @@
+package stockview
+
+import (
+	"app/internal/components"
+	"app/internal/layout"
+	"app/internal/model"
+	"app/pkg/nilsafe"
+	"app/pkg/reqcontext"
+	"app/pkg/validate"
+	"net/url"
+	"time"
+
+	g "maragu.dev/gomponents"
+	c "maragu.dev/gomponents/components"
+	h "maragu.dev/gomponents/html"
+)
+
+// The forms of the stock periods page, to show validation errors against the
+// one submitted
+const (
+	CloseStockPeriodForm  = "Close"
+	ReopenStockPeriodForm = "Reopen"
+)
+
+type StockPeriodsPageProps struct {
+	Ctx       reqcontext.ReqContext
+	Periods   []model.StockPeriod
+	Changes   []model.StockPeriodChange
+	ErrorText string
+
+	// Close and reopen form state
+	SubmittedForm    string
+	Values           url.Values
+	ValidationErrors validate.ValidationErrors
+}
+
+func StockPeriodsPage(p *StockPeriodsPageProps) g.Node {
+
+	closedPeriods := []model.StockPeriod{}
+	for _, period := range p.Periods {
+		if period.IsClosed {
+			closedPeriods = append(closedPeriods, period)
+		}
+	}
+
+	content := g.Group([]g.Node{
+		h.Nav(
+			h.Class("stock-nav"),
+			h.A(h.Href("/stock"), g.Text("Stock levels")),
+			h.A(h.Href("/stock/transactions"), g.Text("See all transactions")),
+		),
+
+		h.H3(g.Text("Periods")),
+
+		h.P(
+			h.Class("stock-periods-info"),
+			g.Text(`A closed period is a month of the ledger that no posting can
+				be back-dated into, so its running totals cannot change. As a
+				back-dated posting changes every later running total, closing
+				a month also closes the months before it. Only months that
+				have ended can be closed. Months are in UTC. The latest closed
+				period can be reopened with a reason, which is kept in the
+				history below.`),
+		),
+
+		g.If(
+			p.ErrorText != "",
+			h.Div(
+				h.Class("error-msg"),
+				g.Text(p.ErrorText),
+			),
+		),
+
+		stockPeriodsTable(p.Periods),
+
+		h.H3(g.Text("Close Period")),
+
+		closeStockPeriodForm(p),
+
+		g.If(
+			len(closedPeriods) > 0,
+			h.H3(g.Text("Reopen Period")),
+		),
+
+		g.If(
+			len(closedPeriods) > 0,
+			reopenStockPeriodForm(p, closedPeriods),
+		),
+
+		h.H3(g.Text("History")),
+
+		stockPeriodChangesTable(p.Changes),
+	})
+
+	return layout.Page(layout.PageProps{
+		Title:   "Periods",
+		Content: content,
+		Ctx:     p.Ctx,
+		Breadcrumbs: []layout.Breadcrumb{
+			layout.HomeBreadcrumb,
+			{
+				IconIdentifier: "package-variant-closed",
+				Title:          "Stock",
+				URLPart:        "stock",
+			},
+			{
+				Title: "Periods",
+			},
+		},
+		AppendHead: []g.Node{
+			components.InlineStyle("/internal/views/stockview/stock_table.css"),
+			components.InlineStyle("/internal/views/stockview/stock_periods_page.css"),
+		},
+	})
+}
+
+func stockPeriodBadge(isClosed bool) g.Node {
+	if isClosed {
+		return components.Badge(&components.BadgeProps{
+			Type: components.BadgeSecondary,
+			Size: components.BadgeSm,
+		}, g.Text("Closed"))
+	}
+
+	return components.Badge(&components.BadgeProps{
+		Type: components.BadgeSuccess,
+		Size: components.BadgeSm,
+	}, g.Text("Open"))
+}
+
+func stockPeriodsTable(periods []model.StockPeriod) g.Node {
+
+	columns := components.TableColumns{
+		{TitleContents: g.Text("Period")},
+		{TitleContents: g.Text("Status")},
+		{TitleContents: g.Text("Updated By")},
+		{TitleContents: g.Text("Updated At")},
+	}
+
+	var rows components.TableRows
+	for _, period := range periods {
+		rows = append(rows, components.TableRow{
+			Cells: []components.TableCell{
+				{Contents: g.Text(model.FormatStockPeriod(period.Period))},
+				{Contents: stockPeriodBadge(period.IsClosed)},
+				{Contents: g.Text(nilsafe.Str(period.UpdatedByUsername))},
+				{Contents: h.Span(h.Class("local-datetime"), g.Text(period.UpdatedAt.Format(time.RFC3339)))},
+			},
+		})
+	}
+
+	return components.Table(&components.TableProps{
+		Classes: c.Classes{"stock-table": true},
+		Columns: columns,
+		Rows:    rows,
+	})
+}
+
+func stockPeriodChangesTable(changes []model.StockPeriodChange) g.Node {
+
+	columns := components.TableColumns{
+		{TitleContents: g.Text("Period")},
+		{TitleContents: g.Text("Change")},
+		{TitleContents: g.Text("Reason")},
+		{TitleContents: g.Text("By")},
+		{TitleContents: g.Text("At")},
+	}
+
+	var rows components.TableRows
+	for _, change := range changes {
+		action := "Reopened"
+		if change.IsClosed {
+			action = "Closed"
+		}
+
+		reason := change.Reason
+		if reason == "" {
+			reason = "\u2013"
+		}
+
+		rows = append(rows, components.TableRow{
+			Cells: []components.TableCell{
+				{Contents: g.Text(model.FormatStockPeriod(change.Period))},
+				{Contents: g.Text(action)},
+				{Contents: g.Text(reason)},
+				{Contents: g.Text(nilsafe.Str(change.ChangeByUsername))},
+				{Contents: h.Span(h.Class("local-datetime"), g.Text(change.ChangedAt.Format(time.RFC3339)))},
+			},
+		})
+	}
+
+	return components.Table(&components.TableProps{
+		Classes: c.Classes{"stock-table": true},
+		Columns: columns,
+		Rows:    rows,
+	})
+}
+
+// stockPeriodFieldError returns the validation error of the field if the
+// form was the one submitted
+func stockPeriodFieldError(p *StockPeriodsPageProps, form string, key string, label string) g.Node {
+	if p.SubmittedForm != form {
+		return nil
+	}
+	errorText := p.ValidationErrors.GetError(key, label)
+	return g.If(
+		errorText != "",
+		components.InputHelper(&components.InputHelperProps{
+			Label: errorText,
+			Type:  components.InputHelperTypeError,
+		}),
+	)
+}
+
+func closeStockPeriodForm(p *StockPeriodsPageProps) g.Node {
+
+	value := func(key string) string {
+		if p.SubmittedForm != CloseStockPeriodForm {
+			return ""
+		}
+		return p.Values.Get(key)
+	}
+
+	// default to last month, the period most often closed
+	period := value("Period")
+	if period == "" {
+		period = model.StockPeriodOf(time.Now()).AddDate(0, -1, 0).Format("2006-01")
+	}
+
+	return h.Form(
+		h.Method("POST"),
+		h.Class("form stock-period-form"),
+		h.Action("/stock/periods/close"),
+
+		h.Div(
+			h.Label(
+				g.Text("Period"),
+				h.Input(
+					h.Type("month"),
+					h.Name("Period"),
+					h.Value(period),
+				),
+			),
+			stockPeriodFieldError(p, CloseStockPeriodForm, "Period", "Period"),
+		),
+
+		h.Div(
+			h.Label(
+				g.Text("Note (optional)"),
+				h.Input(
+					h.Type("text"),
+					h.Name("Reason"),
+					h.Value(value("Reason")),
+					h.Placeholder("Enter note"),
+					h.AutoComplete("off"),
+				),
+			),
+		),
+
+		h.Button(
+			h.Class("button primary"),
+			h.Type("submit"),
+			g.Text("Close Period"),
+		),
+	)
+}
+
+func reopenStockPeriodForm(p *StockPeriodsPageProps, closedPeriods []model.StockPeriod) g.Node {
+
+	value := func(key string) string {
+		if p.SubmittedForm != ReopenStockPeriodForm {
+			return ""
+		}
+		return p.Values.Get(key)
+	}
+
+	return h.Form(
+		h.Method("POST"),
+		h.Class("form stock-period-form"),
+		h.Action("/stock/periods/reopen"),
+
+		h.Div(
+			h.Label(
+				g.Text("Period"),
+				h.Select(
+					h.Name("Period"),
+					h.Class("select"),
+					g.Group(g.Map(closedPeriods, func(period model.StockPeriod) g.Node {
+						month := period.Period.Format("2006-01")
+						return h.Option(
+							h.Value(month),
+							g.Text(model.FormatStockPeriod(period.Period)),
+							g.If(value("Period") == month, h.Selected()),
+						)
+					})),
+				),
+			),
+			stockPeriodFieldError(p, ReopenStockPeriodForm, "Period", "Period"),
+		),
+
+		h.Div(
+			h.Label(
+				g.Text("Reason"),
+				h.Input(
+					h.Type("text"),
+					h.Name("Reason"),
+					h.Value(value("Reason")),
+					h.Placeholder("Enter why the period is being reopened"),
+					h.AutoComplete("off"),
+				),
+			),
+			stockPeriodFieldError(p, ReopenStockPeriodForm, "Reason", "Reason"),
+		),
+
+		h.Button(
+			h.Class("button primary"),
+			h.Type("submit"),
+			g.Text("Reopen Period"),
+		),
+	)
+}
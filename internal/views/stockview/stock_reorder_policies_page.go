@@ -0,0 +1,280 @@
+package stockview
+
+import (
+	"app/internal/components"
+	"app/internal/layout"
+	"app/internal/model"
+	"app/pkg/nilsafe"
+	"app/pkg/reqcontext"
+	"app/pkg/validate"
+	"fmt"
+	"net/url"
+	"time"
+
+	g "maragu.dev/gomponents"
+	c "maragu.dev/gomponents/components"
+	h "maragu.dev/gomponents/html"
+)
+
+type StockReorderPoliciesPageProps struct {
+	Ctx        reqcontext.ReqContext
+	Policies   []model.StockReorderPolicy
+	StockItems []model.StockItem
+	Teams      []model.Team
+	ErrorText  string
+
+	// Save policy form state
+	Values           url.Values
+	ValidationErrors validate.ValidationErrors
+	IsSubmission     bool
+}
+
+func StockReorderPoliciesPage(p *StockReorderPoliciesPageProps) g.Node {
+
+	content := g.Group([]g.Node{
+		h.Nav(
+			h.Class("stock-nav"),
+			h.A(h.Href("/stock"), g.Text("Stock levels")),
+			h.A(h.Href("/stock/replenishment"), g.Text("Replenishment")),
+			h.A(h.Href("/stock/reservations"), g.Text("Reservations")),
+		),
+
+		h.H3(g.Text("Reorder Policies")),
+
+		h.P(
+			h.Class("stock-reorder-policies-info"),
+			g.Text(`A policy sets the min, reorder point and max of a stock
+				item in its base unit, at one location or across all of them.
+				When stock available, on hand less reserved, falls to the
+				reorder point the team is notified and the item is listed for
+				replenishment up to the max. The supply chain team is notified
+				if no team is set.`),
+		),
+
+		g.If(
+			p.ErrorText != "",
+			h.Div(
+				h.Class("error-msg"),
+				g.Text(p.ErrorText),
+			),
+		),
+
+		stockReorderPoliciesTable(p.Policies),
+
+		h.H3(g.Text("Add or Update Policy")),
+
+		saveStockReorderPolicyForm(&saveStockReorderPolicyFormProps{
+			stockItems:       p.StockItems,
+			teams:            p.Teams,
+			values:           p.Values,
+			validationErrors: p.ValidationErrors,
+			isSubmission:     p.IsSubmission,
+		}),
+	})
+
+	return layout.Page(layout.PageProps{
+		Title:   "Reorder Policies",
+		Content: content,
+		Ctx:     p.Ctx,
+		Breadcrumbs: []layout.Breadcrumb{
+			layout.HomeBreadcrumb,
+			{
+				IconIdentifier: "package-variant-closed",
+				Title:          "Stock",
+				URLPart:        "stock",
+			},
+			{
+				Title: "Reorder Policies",
+			},
+		},
+		AppendHead: []g.Node{
+			components.InlineStyle("/internal/views/stockview/stock_table.css"),
+			components.InlineStyle("/internal/views/stockview/stock_reorder_policies_page.css"),
+		},
+	})
+}
+
+func stockReorderPoliciesTable(policies []model.StockReorderPolicy) g.Node {
+
+	columns := components.TableColumns{
+		{TitleContents: g.Text("Stock Code")},
+		{TitleContents: g.Text("Location")},
+		{TitleContents: g.Text("Min")},
+		{TitleContents: g.Text("Reorder Point")},
+		{TitleContents: g.Text("Max")},
+		{TitleContents: g.Text("Notify")},
+		{TitleContents: g.Text("Status")},
+		{TitleContents: g.Text("Updated By")},
+		{TitleContents: g.Text("Updated")},
+		{TitleContents: g.Text("")},
+	}
+
+	var rows components.TableRows
+	for _, rp := range policies {
+
+		location := "All"
+		if rp.Location != nil {
+			location = *rp.Location
+		}
+
+		notify := "Supply chain"
+		if rp.NotifyTeamName != nil {
+			notify = *rp.NotifyTeamName
+		}
+
+		updatedBy := "\u2013"
+		if rp.UpdatedByUsername != nil {
+			updatedBy = nilsafe.Str(rp.UpdatedByUsername)
+		}
+
+		status := components.Badge(&components.BadgeProps{
+			Type: components.BadgeSuccess,
+			Size: components.BadgeSm,
+		}, g.Text("OK"))
+		if rp.IsBelowReorderPoint {
+			status = components.Badge(&components.BadgeProps{
+				Type: components.BadgeWarning,
+				Size: components.BadgeSm,
+			}, g.Text("Reorder"))
+		}
+
+		rows = append(rows, components.TableRow{
+			Cells: []components.TableCell{
+				{Contents: components.StockItemAnchor(rp.StockCode)},
+				{Contents: g.Text(location)},
+				{Contents: g.Text(quantityWithUnit(rp.MinQty, rp.Unit))},
+				{Contents: g.Text(quantityWithUnit(rp.ReorderPoint, rp.Unit))},
+				{Contents: g.Text(quantityWithUnit(rp.MaxQty, rp.Unit))},
+				{Contents: g.Text(notify)},
+				{Contents: status},
+				{Contents: g.Text(updatedBy)},
+				{Contents: h.Span(h.Class("local-datetime"), g.Text(rp.UpdatedAt.Format(time.RFC3339)))},
+				{Contents: h.Form(
+					h.Method("POST"),
+					h.Action(fmt.Sprintf("/stock/reorder-policies/%d/delete", rp.StockReorderPolicyID)),
+					h.Button(
+						h.Class("button secondary small"),
+						h.Type("submit"),
+						g.Text("Remove"),
+					),
+				)},
+			},
+		})
+	}
+
+	return components.Table(&components.TableProps{
+		Classes: c.Classes{"stock-table": true},
+		Columns: columns,
+		Rows:    rows,
+	})
+}
+
+type saveStockReorderPolicyFormProps struct {
+	stockItems       []model.StockItem
+	teams            []model.Team
+	values           url.Values
+	validationErrors validate.ValidationErrors
+	isSubmission     bool
+}
+
+func saveStockReorderPolicyForm(p *saveStockReorderPolicyFormProps) g.Node {
+
+	selectedStockItem := p.values.Get("StockItemID")
+	selectedTeam := p.values.Get("NotifyTeamID")
+
+	fieldError := func(key, label string) g.Node {
+		if !p.isSubmission {
+			return nil
+		}
+		errorText := p.validationErrors.GetError(key, label)
+		return g.If(
+			errorText != "",
+			components.InputHelper(&components.InputHelperProps{
+				Label: errorText,
+				Type:  components.InputHelperTypeError,
+			}),
+		)
+	}
+
+	qtyField := func(key, label string) g.Node {
+		return h.Div(
+			h.Label(
+				g.Text(label),
+				h.Input(
+					h.Type("number"),
+					h.Min("0"),
+					h.Step("any"),
+					h.Name(key),
+					h.Value(p.values.Get(key)),
+					h.Placeholder("Enter quantity in base unit"),
+					h.AutoComplete("off"),
+				),
+			),
+			fieldError(key, label),
+		)
+	}
+
+	return h.Form(
+		h.Method("POST"),
+		h.Class("form stock-reorder-policy-form"),
+		h.Action("/stock/reorder-policies"),
+
+		h.Div(
+			h.Label(
+				g.Text("Stock Code"),
+				components.SearchSelect(&components.SearchSelectProps{
+					Name:                 "StockItemID",
+					Placeholder:          "Select Stock Code",
+					Mode:                 "single",
+					Options:              MapStockItemsToOptions(p.stockItems, selectedStockItem),
+					Selected:             selectedStockItem,
+					OptionsEndpoint:      "/get-stock-codes",
+					SearchQueryParamName: "SearchText",
+				}),
+			),
+			fieldError("StockItemID", "Stock Code"),
+		),
+
+		h.Div(
+			h.Label(
+				g.Text("Location (leave blank for all)"),
+				h.Input(
+					h.Type("text"),
+					h.Name("Location"),
+					h.Value(p.values.Get("Location")),
+					h.Placeholder("Enter location"),
+					h.AutoComplete("off"),
+				),
+			),
+		),
+
+		qtyField("MinQty", "Min"),
+		qtyField("ReorderPoint", "Reorder Point"),
+		qtyField("MaxQty", "Max"),
+
+		h.Div(
+			h.Label(
+				g.Text("Notify Team"),
+				h.Select(
+					h.Name("NotifyTeamID"),
+					h.Class("select"),
+					h.Option(h.Value(""), g.Text("Supply chain")),
+					g.Group(g.Map(p.teams, func(t model.Team) g.Node {
+						teamID := fmt.Sprintf("%d", t.TeamID)
+						return h.Option(
+							h.Value(teamID),
+							g.Text(t.TeamName),
+							g.If(selectedTeam == teamID, h.Selected()),
+						)
+					})),
+				),
+			),
+		),
+
+		h.Button(
+			h.Class("button primary"),
+			h.Type("submit"),
+			g.Text("Save Policy"),
+		),
+	)
+}
@@ -0,0 +1,114 @@
+package stockview
+
+import (
+	"app/internal/components"
+	"app/internal/layout"
+	"app/internal/model"
+	"app/pkg/reqcontext"
+
+	g "maragu.dev/gomponents"
+	c "maragu.dev/gomponents/components"
+	h "maragu.dev/gomponents/html"
+)
+
+type StockReplenishmentPageProps struct {
+	Ctx            reqcontext.ReqContext
+	Replenishments []model.StockReplenishment
+}
+
+func StockReplenishmentPage(p *StockReplenishmentPageProps) g.Node {
+
+	content := g.Group([]g.Node{
+		h.Nav(
+			h.Class("stock-nav"),
+			h.A(h.Href("/stock"), g.Text("Stock levels")),
+			h.A(h.Href("/stock/reservations"), g.Text("Reservations")),
+			g.If(
+				p.Ctx.User.Permissions.SupplyChain.Admin,
+				h.A(h.Href("/stock/reorder-policies"), g.Text("Reorder policies")),
+			),
+		),
+
+		h.H3(g.Text("Replenishment Needed")),
+
+		stockReplenishmentTable(p.Replenishments),
+	})
+
+	return layout.Page(layout.PageProps{
+		Title:   "Replenishment",
+		Content: content,
+		Ctx:     p.Ctx,
+		Breadcrumbs: []layout.Breadcrumb{
+			layout.HomeBreadcrumb,
+			{
+				IconIdentifier: "package-variant-closed",
+				Title:          "Stock",
+				URLPart:        "stock",
+			},
+			{
+				Title: "Replenishment",
+			},
+		},
+		AppendHead: []g.Node{
+			components.InlineStyle("/internal/views/stockview/stock_table.css"),
+		},
+	})
+}
+
+func stockReplenishmentTable(replenishments []model.StockReplenishment) g.Node {
+
+	columns := components.TableColumns{
+		{TitleContents: g.Text("Stock Code")},
+		{TitleContents: g.Text("Description")},
+		{TitleContents: g.Text("Location")},
+		{TitleContents: g.Text("On Hand")},
+		{TitleContents: g.Text("Reserved")},
+		{TitleContents: g.Text("Available")},
+		{TitleContents: g.Text("On Order")},
+		{TitleContents: g.Text("Min")},
+		{TitleContents: g.Text("Reorder Point")},
+		{TitleContents: g.Text("Max")},
+		{TitleContents: g.Text("Suggested Order")},
+	}
+
+	right := []g.Node{h.StyleAttr("text-align:right;")}
+
+	var rows components.TableRows
+	for _, sr := range replenishments {
+
+		location := "All"
+		if sr.Location != nil {
+			location = *sr.Location
+		}
+
+		available := g.Text(quantityWithUnit(sr.Available(), sr.Unit))
+		if sr.IsBelowMin() {
+			available = components.Badge(&components.BadgeProps{
+				Type: components.BadgeDanger,
+				Size: components.BadgeSm,
+			}, available)
+		}
+
+		rows = append(rows, components.TableRow{
+			Cells: []components.TableCell{
+				{Contents: components.StockItemAnchor(sr.StockCode)},
+				{Contents: g.Text(sr.Description)},
+				{Contents: g.Text(location)},
+				{Contents: g.Text(quantityWithUnit(sr.OnHand, sr.Unit)), Attributes: right},
+				{Contents: g.Text(quantityWithUnit(sr.Reserved, sr.Unit)), Attributes: right},
+				{Contents: available, Attributes: right},
+				{Contents: g.Text(quantityWithUnit(sr.OnOrder, sr.Unit)), Attributes: right},
+				{Contents: g.Text(quantityWithUnit(sr.MinQty, sr.Unit)), Attributes: right},
+				{Contents: g.Text(quantityWithUnit(sr.ReorderPoint, sr.Unit)), Attributes: right},
+				{Contents: g.Text(quantityWithUnit(sr.MaxQty, sr.Unit)), Attributes: right},
+				{Contents: h.Strong(g.Text(quantityWithUnit(sr.SuggestedQty(), sr.Unit))), Attributes: right},
+			},
+		})
+	}
+
+	return components.Table(&components.TableProps{
+		Classes: c.Classes{"stock-table": true},
+		Columns: columns,
+		Rows:    rows,
+	})
+}
@@ -0,0 +1,356 @@
+package stockview
+
+import (
+	"app/internal/components"
+	"app/internal/layout"
+	"app/internal/model"
+	"app/pkg/reqcontext"
+	"app/pkg/validate"
+	"fmt"
+	"net/url"
+	"time"
+
+	g "maragu.dev/gomponents"
+	c "maragu.dev/gomponents/components"
+	h "maragu.dev/gomponents/html"
+)
+
+type StockReservationsPageProps struct {
+	Ctx                    reqcontext.ReqContext
+	StockReservations      []model.StockReservation
+	StockReservationsCount int
+	StockItems             []model.StockItem
+	StockCode              string
+	DemandReference        string
+	Status                 string
+	Page                   int
+	PageSize               int
+	ErrorText              string
+
+	// Add reservation form state
+	Values           url.Values
+	ValidationErrors validate.ValidationErrors
+	IsSubmission     bool
+}
+
+func StockReservationsPage(p *StockReservationsPageProps) g.Node {
+
+	isAdmin := p.Ctx.User.Permissions.SupplyChain.Admin
+
+	content := g.Group([]g.Node{
+		h.Nav(
+			h.Class("stock-nav"),
+			h.A(h.Href("/stock"), g.Text("Stock levels")),
+			h.A(h.Href("/stock/transactions"), g.Text("See all transactions")),
+			h.A(h.Href("/stock/documents"), g.Text("Documents")),
+		),
+
+		h.H3(g.Text("Reservations")),
+
+		h.P(
+			h.Class("stock-reservations-info"),
+			g.Text(`A reservation holds stock on hand for a demand, such as an
+				order or a job. Reserved stock cannot be consumed or moved out
+				of the STOCK account other than by postings made against the
+				same demand reference, which draw the reservation down.`),
+		),
+
+		g.If(
+			p.ErrorText != "",
+			h.Div(
+				h.Class("error-msg"),
+				g.Text(p.ErrorText),
+			),
+		),
+
+		h.FormEl(
+			h.Method("GET"),
+
+			h.Div(
+				h.Class("stock-levels-filters"),
+
+				h.Label(
+					h.Class("filter"),
+					g.Text("Stock Code"),
+					h.Input(
+						h.Class("lg"),
+						h.Name("StockCode"),
+						h.Value(p.StockCode),
+						h.AutoComplete("off"),
+						h.Placeholder("Enter stock code"),
+					),
+				),
+
+				h.Label(
+					h.Class("filter"),
+					g.Text("Demand Reference"),
+					h.Input(
+						h.Class("lg"),
+						h.Name("DemandReference"),
+						h.Value(p.DemandReference),
+						h.AutoComplete("off"),
+						h.Placeholder("Enter demand reference"),
+					),
+				),
+
+				h.Label(
+					h.Class("filter"),
+					g.Text("Status"),
+					h.Select(
+						h.Class("lg"),
+						h.Name("Status"),
+						h.Option(h.Value(""), g.Text("All")),
+						g.Group(g.Map(model.StockReservationStatuses, func(s model.StockReservationStatus) g.Node {
+							return h.Option(
+								h.Value(string(s)),
+								g.Text(string(s)),
+								g.If(p.Status == string(s), h.Selected()),
+							)
+						})),
+					),
+				),
+
+				h.Div(
+					h.Class("go-button-wrapper"),
+					components.Button(&components.ButtonProps{
+						ButtonType: components.ButtonPrimary,
+						Classes:    c.Classes{"go-button": true},
+						Size:       components.ButtonLg,
+					},
+						h.Type("button"),
+						g.Attr("onclick", "submitTableForm(this.form)"),
+						g.Text("GO"),
+					),
+				),
+			),
+
+			components.Divider(),
+
+			stockReservationsTable(&stockReservationsTableProps{
+				stockReservations:      p.StockReservations,
+				stockReservationsCount: p.StockReservationsCount,
+				page:                   p.Page,
+				pageSize:               p.PageSize,
+				canRelease:             isAdmin,
+			}),
+		),
+
+		g.If(
+			isAdmin,
+			g.Group([]g.Node{
+				h.H3(g.Text("Add Reservation")),
+
+				addStockReservationForm(&addStockReservationFormProps{
+					stockItems:       p.StockItems,
+					values:           p.Values,
+					validationErrors: p.ValidationErrors,
+					isSubmission:     p.IsSubmission,
+				}),
+			}),
+		),
+	})
+
+	return layout.Page(layout.PageProps{
+		Title:   "Reservations",
+		Content: content,
+		Ctx:     p.Ctx,
+		Breadcrumbs: []layout.Breadcrumb{
+			layout.HomeBreadcrumb,
+			{
+				IconIdentifier: "package-variant-closed",
+				Title:          "Stock",
+				URLPart:        "stock",
+			},
+			{
+				Title: "Reservations",
+			},
+		},
+		AppendHead: []g.Node{
+			components.InlineStyle("/internal/views/stockview/stock_table.css"),
+			components.InlineStyle("/internal/views/stockview/stock_reservations_page.css"),
+		},
+	})
+}
+
+type stockReservationsTableProps struct {
+	stockReservations      []model.StockReservation
+	stockReservationsCount int
+	page                   int
+	pageSize               int
+	canRelease             bool
+}
+
+func stockReservationsTable(p *stockReservationsTableProps) g.Node {
+
+	columns := components.TableColumns{
+		{TitleContents: g.Text("Stock Code")},
+		{TitleContents: g.Text("Location")},
+		{TitleContents: g.Text("Bin")},
+		{TitleContents: g.Text("Lot Number")},
+		{TitleContents: g.Text("Demand Reference")},
+		{TitleContents: g.Text("Reserved")},
+		{TitleContents: g.Text("Outstanding")},
+		{TitleContents: g.Text("Status")},
+		{TitleContents: g.Text("Created By")},
+		{TitleContents: g.Text("Created")},
+		{TitleContents: g.Text("")},
+	}
+
+	var rows components.TableRows
+	for _, sr := range p.stockReservations {
+
+		location, bin, lotNumber := "All", "All", "All"
+		if sr.Location != "" {
+			location = sr.Location
+		}
+		if sr.Bin != "" {
+			bin = sr.Bin
+		}
+		if sr.LotNumber != "" {
+			lotNumber = sr.LotNumber
+		}
+
+		// the table sits in the filter form, so release posts by overriding
+		// the form action rather than with a nested form
+		var releaseButton g.Node
+		if p.canRelease && sr.Status == model.OpenStockReservationStatus {
+			releaseButton = h.Button(
+				h.Class("button secondary small"),
+				h.Type("submit"),
+				h.FormAction(fmt.Sprintf("/stock/reservations/%d/release", sr.StockReservationID)),
+				h.FormMethod("POST"),
+				g.Text("Release"),
+			)
+		}
+
+		rows = append(rows, components.TableRow{
+			Cells: []components.TableCell{
+				{Contents: components.StockItemAnchor(sr.StockCode)},
+				{Contents: g.Text(location)},
+				{Contents: g.Text(bin)},
+				{Contents: g.Text(lotNumber)},
+				{Contents: g.Text(sr.DemandReference)},
+				{Contents: g.Text(quantityWithUnit(sr.ReservedQty, sr.Unit))},
+				{Contents: g.Text(quantityWithUnit(sr.Qty, sr.Unit))},
+				{Contents: stockReservationStatusBadge(sr.Status)},
+				{Contents: g.Text(sr.CreatedByUsername)},
+				{Contents: h.Span(h.Class("local-datetime"), g.Text(sr.CreatedAt.Format(time.RFC3339)))},
+				{Contents: releaseButton},
+			},
+		})
+	}
+
+	return components.Table(&components.TableProps{
+		Classes: c.Classes{"stock-table": true},
+		Columns: columns,
+		Rows:    rows,
+		Pagination: &components.TablePaginationProps{
+			TotalRecords:        p.stockReservationsCount,
+			PageSize:            p.pageSize,
+			CurrentPage:         p.page,
+			CurrentPageQueryKey: "Page",
+			PageSizeQueryKey:    "PageSize",
+		},
+	})
+}
+
+func stockReservationStatusBadge(status model.StockReservationStatus) g.Node {
+	badgeType := components.BadgePrimary
+	switch status {
+	case model.FulfilledStockReservationStatus:
+		badgeType = components.BadgeSuccess
+	case model.ReleasedStockReservationStatus:
+		badgeType = components.BadgeSecondary
+	}
+
+	return components.Badge(&components.BadgeProps{
+		Type: badgeType,
+		Size: components.BadgeSm,
+	}, g.Text(string(status)))
+}
+
+type addStockReservationFormProps struct {
+	stockItems       []model.StockItem
+	values           url.Values
+	validationErrors validate.ValidationErrors
+	isSubmission     bool
+}
+
+func addStockReservationForm(p *addStockReservationFormProps) g.Node {
+
+	selectedStockItem := p.values.Get("StockItemID")
+
+	fieldError := func(key, label string) g.Node {
+		if !p.isSubmission {
+			return nil
+		}
+		errorText := p.validationErrors.GetError(key, label)
+		return g.If(
+			errorText != "",
+			components.InputHelper(&components.InputHelperProps{
+				Label: errorText,
+				Type:  components.InputHelperTypeError,
+			}),
+		)
+	}
+
+	textField := func(key, label, placeholder string) g.Node {
+		return h.Div(
+			h.Label(
+				g.Text(label),
+				h.Input(
+					h.Type("text"),
+					h.Name(key),
+					h.Value(p.values.Get(key)),
+					h.Placeholder(placeholder),
+					h.AutoComplete("off"),
+				),
+			),
+			fieldError(key, label),
+		)
+	}
+
+	return h.Form(
+		h.Method("POST"),
+		h.Class("form stock-reservation-form"),
+		h.Action("/stock/reservations"),
+
+		h.Div(
+			h.Label(
+				g.Text("Stock Code"),
+				components.SearchSelect(&components.SearchSelectProps{
+					Name:                 "StockItemID",
+					Placeholder:          "Select Stock Code",
+					Mode:                 "single",
+					Options:              MapStockItemsToOptions(p.stockItems, selectedStockItem),
+					Selected:             selectedStockItem,
+					OptionsEndpoint:      "/get-stock-codes",
+					SearchQueryParamName: "SearchText",
+				}),
+			),
+			fieldError("StockItemID", "Stock Code"),
+		),
+
+		textField("Location", "Location (leave blank for all)", "Enter location"),
+		textField("Bin", "Bin (leave blank for all)", "Enter bin"),
+		textField("LotNumber", "Lot Number (leave blank for all)", "Enter lot number"),
+		textField("Qty", "Quantity", "Enter quantity"),
+		textField("Unit", "Unit (optional, defaults to base unit)", "Enter unit"),
+		textField("DemandReference", "Demand Reference", "Enter order or job reference"),
+		textField("Note", "Note", "Enter note"),
+
+		h.Button(
+			h.Class("button primary"),
+			h.Type("submit"),
+			g.Text("Reserve"),
+		),
+	)
+}
+
+// StockReservationsURL is the open reservations of a stock code
+func StockReservationsURL(stockCode string) string {
+	params := url.Values{}
+	params.Add("StockCode", stockCode)
+	params.Add("Status", string(model.OpenStockReservationStatus))
+	return "/stock/reservations?" + params.Encode()
+}
@@ -0,0 +1,339 @@
+package stockview
+
+import (
+	"app/internal/components"
+	"app/internal/layout"
+	"app/internal/model"
+	"app/pkg/format"
+	"app/pkg/reqcontext"
+	"maps"
+	"net/url"
+	"slices"
+
+	g "maragu.dev/gomponents"
+	h "maragu.dev/gomponents/html"
+)
+
+type StockScanPageProps struct {
+	Ctx         reqcontext.ReqContext
+	State       model.StockScanState
+	SuccessText string
+	ErrorText   string
+	// Scan is the invalid scan of the current step, shown to correct it
+	Scan     string
+	IsPosted bool
+	// SourceStockLevels are the lots of the stock item at the source
+	SourceStockLevels []model.StockLevel
+}
+
+func StockScanPage(p *StockScanPageProps) g.Node {
+
+	var content g.Node
+	switch {
+	case p.State.Flow == "":
+		content = stockScanFlows()
+	case p.IsPosted:
+		content = stockScanPosted(p)
+	default:
+		content = stockScanStep(p)
+	}
+
+	return layout.Page(layout.PageProps{
+		Title:   "Scan",
+		Content: content,
+		Ctx:     p.Ctx,
+		Breadcrumbs: []layout.Breadcrumb{
+			layout.HomeBreadcrumb,
+			{
+				IconIdentifier: "package-variant-closed",
+				Title:          "Stock",
+				URLPart:        "stock",
+			},
+			{
+				Title: "Scan",
+			},
+		},
+		AppendHead: []g.Node{
+			components.InlineStyle("/internal/views/stockview/stock_scan_page.css"),
+		},
+	})
+}
+
+func stockScanFlows() g.Node {
+	return h.Div(
+		h.Class("stock-scan"),
+
+		h.H3(g.Text("Scan")),
+
+		h.P(g.Text(`Post stock one step at a time by scanning labels. Each scan
+			is checked before moving on to the next step.`)),
+
+		h.Div(
+			h.Class("stock-scan-flows"),
+			g.Group(g.Map(model.StockScanFlows, func(f model.StockScanFlow) g.Node {
+				return h.A(
+					h.Class("button primary"),
+					h.Href("/stock/scan?"+url.Values{"Flow": {string(f)}}.Encode()),
+					g.Text(string(f)),
+				)
+			})),
+		),
+	)
+}
+
+func stockScanStep(p *StockScanPageProps) g.Node {
+
+	s := p.State
+	step := s.CurrentStep()
+
+	return h.Div(
+		h.Class("stock-scan"),
+
+		h.H3(g.Textf("%s: %s", s.Flow, step)),
+
+		stockScanProgress(&s),
+
+		g.If(
+			p.ErrorText != "",
+			h.Div(
+				h.Class("error-msg"),
+				g.Text(p.ErrorText),
+			),
+		),
+
+		g.If(
+			step == model.ConfirmStockScanStep,
+			stockScanConfirmForm(&s),
+		),
+		g.If(
+			step != model.ConfirmStockScanStep,
+			stockScanForm(&s, step, p.Scan),
+		),
+
+		g.If(
+			len(p.SourceStockLevels) > 0 && step != model.ConfirmStockScanStep,
+			stockScanSourceLevels(&s, p.SourceStockLevels),
+		),
+
+		h.Div(
+			h.Class("stock-scan-links"),
+			h.A(
+				h.Href("/stock/scan?"+url.Values{"Flow": {string(s.Flow)}}.Encode()),
+				g.Text("Start again"),
+			),
+			h.A(h.Href("/stock/scan"), g.Text("Change what to post")),
+		),
+	)
+}
+
+// stockScanProgress lists the steps of the flow with what was scanned for
+// those that are done
+func stockScanProgress(s *model.StockScanState) g.Node {
+
+	current := s.CurrentStep()
+
+	value := func(step model.StockScanStep) string {
+		switch step {
+		case model.ItemStockScanStep:
+			return s.StockCode
+		case model.SourceBinStockScanStep:
+			return model.StockPlaceScan(s.FromLocation, s.FromBin)
+		case model.LotStockScanStep:
+			if s.NoLot {
+				return "No lot"
+			}
+			return s.LotNumber
+		case model.QtyStockScanStep:
+			if s.Qty != nil {
+				return format.DecimalWithCommas(s.Qty.String())
+			}
+		case model.DestinationStockScanStep:
+			return model.StockPlaceScan(s.ToLocation, s.ToBin)
+		}
+		return ""
+	}
+
+	return h.Ol(
+		h.Class("stock-scan-steps"),
+		g.Group(g.Map(s.Flow.Steps(), func(step model.StockScanStep) g.Node {
+			class := ""
+			switch {
+			case step == current:
+				class = "current"
+			case s.IsStepDone(step):
+				class = "done"
+			}
+
+			return h.Li(
+				g.If(class != "", h.Class(class)),
+				h.Span(g.Text(string(step))),
+				g.If(s.IsStepDone(step), h.Strong(g.Text(value(step)))),
+			)
+		})),
+	)
+}
+
+// stockScanStateInputs carry the state of the flow to the next step
+func stockScanStateInputs(s *model.StockScanState) g.Node {
+	values := s.Values()
+
+	var inputs []g.Node
+	for _, key := range slices.Sorted(maps.Keys(values)) {
+		inputs = append(inputs, h.Input(
+			h.Type("hidden"),
+			h.Name(key),
+			h.Value(values.Get(key)),
+		))
+	}
+	return g.Group(inputs)
+}
+
+func stockScanForm(s *model.StockScanState, step model.StockScanStep, scan string) g.Node {
+
+	placeholder := map[model.StockScanStep]string{
+		model.ItemStockScanStep:        "Scan item label",
+		model.SourceBinStockScanStep:   "Scan bin label, e.g. STORES/A1",
+		model.LotStockScanStep:         "Scan lot label",
+		model.QtyStockScanStep:         "Enter quantity",
+		model.DestinationStockScanStep: "Scan bin label, e.g. STORES/A1",
+	}[step]
+
+	input := h.Input(
+		h.Class("stock-scan-input"),
+		h.Type("text"),
+		h.Name("Scan"),
+		h.Value(scan),
+		h.Placeholder(placeholder),
+		h.AutoComplete("off"),
+		h.AutoFocus(),
+	)
+	if step == model.QtyStockScanStep {
+		input = h.Input(
+			h.Class("stock-scan-input"),
+			h.Type("number"),
+			h.Min("0"),
+			h.Step("any"),
+			g.Attr("inputmode", "decimal"),
+			h.Name("Scan"),
+			h.Value(scan),
+			h.Placeholder(placeholder),
+			h.AutoComplete("off"),
+			h.AutoFocus(),
+		)
+	}
+
+	return h.Form(
+		h.Method("GET"),
+		h.Action("/stock/scan"),
+		h.Class("stock-scan-form"),
+
+		stockScanStateInputs(s),
+
+		h.Div(
+			h.Class("stock-scan-input-row"),
+
+			input,
+
+			g.If(
+				step != model.QtyStockScanStep,
+				h.A(
+					h.Class("button secondary camera-button"),
+					h.Href("/camera-scanner?field=Scan"),
+					h.Title("Scan with camera"),
+					components.Icon(&components.IconProps{
+						Identifier: "camera",
+					}),
+				),
+			),
+		),
+
+		components.Button(
+			&components.ButtonProps{
+				ButtonType: "Primary",
+			},
+			h.Type("submit"),
+			g.Text("Next"),
+		),
+
+		g.If(
+			step == model.LotStockScanStep,
+			h.Button(
+				h.Class("button secondary"),
+				h.Type("submit"),
+				h.Name("ScanNoLot"),
+				h.Value("true"),
+				g.Text("No lot"),
+			),
+		),
+	)
+}
+
+func stockScanConfirmForm(s *model.StockScanState) g.Node {
+	return h.Form(
+		h.Method("POST"),
+		h.Action("/stock/scan"),
+		h.Class("stock-scan-form"),
+
+		stockScanStateInputs(s),
+
+		components.Button(
+			&components.ButtonProps{
+				ButtonType: "Primary",
+			},
+			h.Type("submit"),
+			g.Textf("Post %s", s.Flow),
+		),
+	)
+}
+
+// stockScanSourceLevels shows the lots of the stock item at the source
+func stockScanSourceLevels(s *model.StockScanState, levels []model.StockLevel) g.Node {
+	return h.Div(
+		h.Class("stock-scan-levels"),
+		h.H4(g.Textf("%s in %s", s.StockCode, model.StockPlaceScan(s.FromLocation, s.FromBin))),
+		h.Ul(
+			g.Group(g.Map(levels, func(l model.StockLevel) g.Node {
+				lot := "No lot"
+				if l.LotNumber != "" {
+					lot = l.LotNumber
+				}
+				return h.Li(
+					h.Span(g.Text(lot)),
+					h.Strong(g.Text(quantityWithUnit(l.StockLevel, l.Unit))),
+				)
+			})),
+		),
+	)
+}
+
+func stockScanPosted(p *StockScanPageProps) g.Node {
+
+	s := p.State
+
+	return h.Div(
+		h.Class("stock-scan"),
+
+		h.H3(g.Text(string(s.Flow))),
+
+		stockScanProgress(&s),
+
+		h.Div(
+			h.Class("success-msg"),
+			g.Text(p.SuccessText),
+		),
+
+		h.Div(
+			h.Class("stock-scan-flows"),
+			h.A(
+				h.Class("button primary"),
+				h.Href("/stock/scan?"+url.Values{"Flow": {string(s.Flow)}}.Encode()),
+				g.Textf("Scan another %s", s.Flow),
+			),
+			h.A(
+				h.Class("button secondary"),
+				h.Href("/stock/scan"),
+				g.Text("Post something else"),
+			),
+		),
+	)
+}
@@ -0,0 +1,135 @@
+package stockview
+
+import (
+	"app/internal/components"
+	"app/internal/layout"
+	"app/internal/model"
+	"app/pkg/reqcontext"
+	"fmt"
+	"time"
+
+	g "maragu.dev/gomponents"
+	c "maragu.dev/gomponents/components"
+	h "maragu.dev/gomponents/html"
+)
+
+type StockSerialPageProps struct {
+	Ctx         reqcontext.ReqContext
+	StockSerial model.StockSerial
+	Movements   []model.StockSerialMovement
+}
+
+func StockSerialPage(p *StockSerialPageProps) g.Node {
+
+	ss := p.StockSerial
+
+	type attribute struct {
+		label string
+		value g.Node
+	}
+
+	position := g.Text("Not posted")
+	lastMoved := g.Text("\u2013")
+	if ss.Position != nil {
+		position = g.Text(ss.Position.String())
+		lastMoved = h.Span(
+			h.Class("local-datetime"),
+			g.Text(ss.Position.Timestamp.Format(time.RFC3339)),
+		)
+	}
+
+	attributes := []attribute{
+		{label: "Stock Code", value: components.StockItemAnchor(ss.StockCode)},
+		{label: "Serial Number", value: g.Text(ss.SerialNumber)},
+		{label: "Current Location", value: position},
+		{label: "Last Moved", value: lastMoved},
+	}
+
+	content := g.Group([]g.Node{
+		h.Nav(
+			h.Class("stock-nav"),
+			h.A(h.Href("/stock/serials"), g.Text("Serials")),
+			h.A(h.Href(StockSerialsURL(ss.StockCode)), g.Textf("Serials of %s", ss.StockCode)),
+		),
+
+		h.Ul(
+			h.Class("attributes-list"),
+
+			g.Group(g.Map(attributes, func(a attribute) g.Node {
+				return h.Li(
+					components.Icon(&components.IconProps{
+						Identifier: "arrow-right-thin",
+					}),
+					h.Strong(g.Textf("%s: ", a.label)),
+					h.Span(a.value),
+				)
+			})),
+		),
+
+		h.H3(g.Text("Movement History")),
+
+		stockSerialMovementsTable(p.Movements),
+	})
+
+	return layout.Page(layout.PageProps{
+		Ctx:     p.Ctx,
+		Title:   fmt.Sprintf("Serial %s", ss.SerialNumber),
+		Content: content,
+		Breadcrumbs: []layout.Breadcrumb{
+			layout.HomeBreadcrumb,
+			{
+				IconIdentifier: "package-variant-closed",
+				Title:          "Stock",
+				URLPart:        "stock",
+			},
+			{
+				Title:   "Serials",
+				URLPart: "serials",
+			},
+			{
+				Title: fmt.Sprintf("%s %s", ss.StockCode, ss.SerialNumber),
+			},
+		},
+		AppendHead: []g.Node{
+			components.InlineStyle("/internal/views/stockview/stock_table.css"),
+			components.InlineStyle("/internal/views/stockview/stock_lot_page.css"),
+		},
+	})
+}
+
+func stockSerialMovementsTable(movements []model.StockSerialMovement) g.Node {
+
+	columns := components.TableColumns{
+		{TitleContents: g.Text("Transaction")},
+		{TitleContents: g.Text("Type")},
+		{TitleContents: g.Text("From")},
+		{TitleContents: g.Text("To")},
+		{TitleContents: g.Text("By")},
+		{TitleContents: g.Text("Timestamp")},
+	}
+
+	var rows components.TableRows
+	for _, m := range movements {
+		transactionHref := fmt.Sprintf("/stock/transactions?StockTransactionID=%d", m.StockTransactionID)
+
+		rows = append(rows, components.TableRow{
+			Cells: []components.TableCell{
+				{Contents: h.A(h.Href(transactionHref), g.Textf("#%d", m.StockTransactionID))},
+				{Contents: g.Text(string(m.TransactionType))},
+				{Contents: g.Text(m.From.String())},
+				{Contents: g.Text(m.To.String())},
+				{Contents: g.Text(m.TransactionByUsername)},
+				{Contents: h.Span(
+					h.Class("local-datetime"),
+					g.Text(m.Timestamp.Format(time.RFC3339)),
+				)},
+			},
+		})
+	}
+
+	return components.Table(&components.TableProps{
+		Classes: c.Classes{"stock-table": true},
+		Columns: columns,
+		Rows:    rows,
+	})
+}
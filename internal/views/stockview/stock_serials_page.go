@@ -0,0 +1,216 @@
+package stockview
+
+import (
+	"app/internal/components"
+	"app/internal/layout"
+	"app/internal/model"
+	"app/pkg/reqcontext"
+	"fmt"
+	"net/url"
+	"time"
+
+	g "maragu.dev/gomponents"
+	c "maragu.dev/gomponents/components"
+	h "maragu.dev/gomponents/html"
+)
+
+type StockSerialsPageProps struct {
+	Ctx               reqcontext.ReqContext
+	StockSerials      []model.StockSerial
+	StockSerialsCount int
+	StockCode         string
+	SerialNumber      string
+	Account           string
+	Location          string
+	Page              int
+	PageSize          int
+}
+
+func StockSerialsPage(p *StockSerialsPageProps) g.Node {
+
+	content := h.FormEl(
+		h.Method("GET"),
+
+		h.Nav(
+			h.Class("stock-nav"),
+			h.A(h.Href("/stock"), g.Text("Stock levels")),
+			h.A(h.Href("/stock/transactions"), g.Text("See all transactions")),
+			h.A(h.Href("/stock/lots"), g.Text("Lots")),
+		),
+
+		h.H3(g.Text("Serials")),
+
+		h.Div(
+			h.Class("stock-levels-filters"),
+
+			h.Label(
+				h.Class("filter"),
+				g.Text("Stock Code"),
+				h.Input(
+					h.Class("lg"),
+					h.Name("StockCode"),
+					h.Value(p.StockCode),
+					h.AutoComplete("off"),
+					h.Placeholder("Enter stock code"),
+				),
+			),
+
+			h.Label(
+				h.Class("filter"),
+				g.Text("Serial Number"),
+				h.Input(
+					h.Class("lg"),
+					h.Name("SerialNumber"),
+					h.Value(p.SerialNumber),
+					h.AutoComplete("off"),
+					h.Placeholder("Enter serial number"),
+				),
+			),
+
+			h.Label(
+				h.Class("filter"),
+				g.Text("Account"),
+				h.Select(
+					h.Class("lg"),
+					h.Name("Account"),
+					h.Option(h.Value(""), g.Text("All")),
+					g.Group(g.Map(model.StockAccounts, func(a model.StockAccount) g.Node {
+						return h.Option(
+							h.Value(string(a)),
+							g.Text(string(a)),
+							g.If(p.Account == string(a), h.Selected()),
+						)
+					})),
+				),
+			),
+
+			h.Label(
+				h.Class("filter"),
+				g.Text("Location"),
+				h.Input(
+					h.Class("lg"),
+					h.Name("Location"),
+					h.Value(p.Location),
+					h.AutoComplete("off"),
+					h.Placeholder("Enter location"),
+				),
+			),
+
+			h.Div(
+				h.Class("go-button-wrapper"),
+				components.Button(&components.ButtonProps{
+					ButtonType: components.ButtonPrimary,
+					Classes:    c.Classes{"go-button": true},
+					Size:       components.ButtonLg,
+				},
+					h.Type("button"),
+					g.Attr("onclick", "submitTableForm(this.form)"),
+					g.Text("GO"),
+				),
+			),
+		),
+
+		components.Divider(),
+
+		stockSerialsTable(&stockSerialsTableProps{
+			stockSerials:      p.StockSerials,
+			stockSerialsCount: p.StockSerialsCount,
+			page:              p.Page,
+			pageSize:          p.PageSize,
+		}),
+	)
+
+	return layout.Page(layout.PageProps{
+		Title:   "Serials",
+		Content: content,
+		Ctx:     p.Ctx,
+		Breadcrumbs: []layout.Breadcrumb{
+			layout.HomeBreadcrumb,
+			{
+				IconIdentifier: "package-variant-closed",
+				Title:          "Stock",
+				URLPart:        "stock",
+			},
+			{
+				Title: "Serials",
+			},
+		},
+		AppendHead: []g.Node{
+			components.InlineStyle("/internal/views/stockview/stock_table.css"),
+		},
+	})
+}
+
+type stockSerialsTableProps struct {
+	stockSerials      []model.StockSerial
+	stockSerialsCount int
+	page              int
+	pageSize          int
+}
+
+func stockSerialsTable(p *stockSerialsTableProps) g.Node {
+
+	columns := components.TableColumns{
+		{TitleContents: g.Text("Stock Code")},
+		{TitleContents: g.Text("Serial Number")},
+		{TitleContents: g.Text("Account")},
+		{TitleContents: g.Text("Location")},
+		{TitleContents: g.Text("Bin")},
+		{TitleContents: g.Text("Lot Number")},
+		{TitleContents: g.Text("Last Moved")},
+	}
+
+	var rows components.TableRows
+	for _, s := range p.stockSerials {
+
+		stockSerialHref := fmt.Sprintf("/stock/serials/%d", s.StockSerialID)
+
+		account, location, bin, lotNumber := "\u2013", "\u2013", "\u2013", "\u2013"
+		lastMoved := g.Text("\u2013")
+		if s.Position != nil {
+			account = string(s.Position.Account)
+			location = s.Position.Location
+			bin = s.Position.Bin
+			if s.Position.LotNumber != "" {
+				lotNumber = s.Position.LotNumber
+			}
+			lastMoved = h.Span(
+				h.Class("local-datetime"),
+				g.Text(s.Position.Timestamp.Format(time.RFC3339)),
+			)
+		}
+
+		rows = append(rows, components.TableRow{
+			Cells: []components.TableCell{
+				{Contents: components.StockItemAnchor(s.StockCode)},
+				{Contents: h.A(h.Href(stockSerialHref), g.Text(s.SerialNumber))},
+				{Contents: g.Text(account)},
+				{Contents: g.Text(location)},
+				{Contents: g.Text(bin)},
+				{Contents: g.Text(lotNumber)},
+				{Contents: lastMoved},
+			},
+			HREF: stockSerialHref,
+		})
+	}
+
+	return components.Table(&components.TableProps{
+		Classes: c.Classes{"stock-table": true},
+		Columns: columns,
+		Rows:    rows,
+		Pagination: &components.TablePaginationProps{
+			TotalRecords:        p.stockSerialsCount,
+			PageSize:            p.pageSize,
+			CurrentPage:         p.page,
+			CurrentPageQueryKey: "Page",
+			PageSizeQueryKey:    "PageSize",
+		},
+	})
+}
+
+// StockSerialsURL is the serials page filtered to a stock code
+func StockSerialsURL(stockCode string) string {
+	params := url.Values{}
+	params.Add("StockCode", stockCode)
+	return "/stock/serials?" + params.Encode()
+}
@@ -5,7 +5,6 @@ import (
 	"app/internal/layout"
 	"app/internal/model"
 	"app/pkg/appsort"
-	"app/pkg/format"
 	"app/pkg/reqcontext"
 	"fmt"
 	"net/url"
@@ -27,9 +26,18 @@ type StockTransactionsPageProps struct {
 	Bin               string
 	LotNumber         string
 	LTETimestamp      *time.Time
-	Page              int
-	PageSize          int
-	Total             int
+	// StockTransactionID is set when showing a single transaction and its
+	// reversal
+	StockTransactionID int
+	Page               int
+	PageSize           int
+	Total              int
+
+	ErrorText string
+	// Set when a reversal was stopped by a negative stock warning the user
+	// can acknowledge
+	NegativeStockWarning      bool
+	ReverseStockTransactionID int
 }
 
 func StockTransactionsPage(p *StockTransactionsPageProps) g.Node {
@@ -41,6 +49,7 @@ func StockTransactionsPage(p *StockTransactionsPageProps) g.Node {
 
 		h.Nav(
 			h.Class("stock-nav"),
+			h.A(h.Href("/stock/documents"), g.Text("Documents")),
 			g.If(
 				perms.SupplyChain.Admin,
 				h.A(h.Href("/stock/post-transaction/stock-movement"), g.Text("Post transaction")),
@@ -53,11 +62,42 @@ func StockTransactionsPage(p *StockTransactionsPageProps) g.Node {
 
 		components.Divider(),
 
+		g.If(
+			p.StockTransactionID != 0,
+			h.P(
+				g.Textf("Showing transaction %d and any reversal of it. ", p.StockTransactionID),
+				h.A(h.Href("/stock/transactions"), g.Text("Show all transactions")),
+			),
+		),
+
+		g.If(
+			p.ErrorText != "",
+			h.Div(
+				h.Class("error-msg"),
+				g.Text(p.ErrorText),
+			),
+		),
+
+		g.If(
+			p.NegativeStockWarning && perms.SupplyChain.Admin,
+			h.Button(
+				h.Class("button warning stock-transaction-reverse-button"),
+				h.Type("submit"),
+				g.Attr("formmethod", "POST"),
+				g.Attr("formaction", fmt.Sprintf(
+					"/stock/transactions/%d/reverse?AcknowledgeNegativeStock=true", p.ReverseStockTransactionID,
+				)),
+				g.Attr("data-confirm", "Reverse this transaction even though it leaves negative stock?"),
+				g.Text("Reverse Anyway"),
+			),
+		),
+
 		transactionsTable(&transactionsTableProps{
 			stockTransactions: *p.StockTransactions,
 			page:              p.Page,
 			pageSize:          p.PageSize,
 			total:             p.Total,
+			canReverse:        perms.SupplyChain.Admin,
 		}),
 	)
 
@@ -78,6 +118,7 @@ func StockTransactionsPage(p *StockTransactionsPageProps) g.Node {
 		},
 		AppendHead: []g.Node{
 			components.InlineStyle("/internal/views/stockview/stock_table.css"),
+			components.InlineScript("/internal/views/stockview/stock_transactions.js"),
 		},
 	})
 }
@@ -87,6 +128,9 @@ type transactionsTableProps struct {
 	page              int
 	pageSize          int
 	total             int
+	// canReverse shows a reverse button on transactions that can be reversed.
+	// The table must be rendered inside a form for the button to submit.
+	canReverse bool
 }
 
 func transactionsTable(p *transactionsTableProps) g.Node {
@@ -115,6 +159,8 @@ func transactionsTable(p *transactionsTableProps) g.Node {
 		TitleContents: g.Text("Timestamp"),
 	}, {
 		TitleContents: g.Text("By"),
+	}, {
+		TitleContents: g.Text("Reversal"),
 	}, {
 		TitleContents: g.Text(""),
 	}}
@@ -123,11 +169,6 @@ func transactionsTable(p *transactionsTableProps) g.Node {
 
 	for _, st := range p.stockTransactions {
 
-		lotNumber := st.LotNumber
-		if lotNumber == "" {
-			lotNumber = "\u2013"
-		}
-
 		trxParams := url.Values{}
 		trxParams.Add("Account", string(st.Account))
 		trxParams.Add("StockCode", st.StockCode)
@@ -142,7 +183,7 @@ func transactionsTable(p *transactionsTableProps) g.Node {
 		}, {
 			Contents: g.Text(string(st.Account)),
 		}, {
-			Contents: g.Text(string(st.TransactionType)),
+			Contents: transactionType(st),
 		}, {
 			Contents: components.StockItemAnchor(st.StockCode),
 		}, {
@@ -150,12 +191,12 @@ func transactionsTable(p *transactionsTableProps) g.Node {
 		}, {
 			Contents: g.Text(st.Bin),
 		}, {
-			Contents: g.Text(lotNumber),
+			Contents: lotTraceAnchor(st.StockCode, st.LotNumber),
 		}, {
 			Contents: g.Group([]g.Node{
 				g.If(
 					st.Quantity.IsNegative(),
-					g.Text(format.DecimalWithCommas(st.Quantity.Abs().String())),
+					g.Text(quantityWithUnit(st.Quantity.Abs(), st.Unit)),
 				),
 				g.If(
 					st.Quantity.IsPositive(),
@@ -167,7 +208,7 @@ func transactionsTable(p *transactionsTableProps) g.Node {
 			Contents: g.Group([]g.Node{
 				g.If(
 					st.Quantity.IsPositive(),
-					g.Text(format.DecimalWithCommas(st.Quantity.String())),
+					g.Text(quantityWithUnit(st.Quantity, st.Unit)),
 				),
 				g.If(
 					st.Quantity.IsNegative(),
@@ -176,12 +217,14 @@ func transactionsTable(p *transactionsTableProps) g.Node {
 			}),
 			Attributes: []g.Node{h.StyleAttr("text-align:right;")},
 		}, {
-			Contents:   g.Text(format.DecimalWithCommas(st.RunningTotal.String())),
+			Contents:   g.Text(quantityWithUnit(st.RunningTotal, st.Unit)),
 			Attributes: []g.Node{h.StyleAttr("text-align:right;")},
 		}, {
 			Contents: h.Span(h.Class("local-datetime"), g.Text(st.Timestamp.Format(time.RFC3339))),
 		}, {
 			Contents: g.Text(st.TransactionByUsername),
+		}, {
+			Contents: transactionReversal(st, p.canReverse),
 		}, {
 			Contents: h.A(h.Href(transactionsLink), g.Text("Transactions")),
 		}}
@@ -192,15 +235,79 @@ func transactionsTable(p *transactionsTableProps) g.Node {
 		})
 	}
 
-	return components.Table(&components.TableProps{
-		Classes: c.Classes{"stock-table": true},
-		Columns: columns,
-		Rows:    rows,
-		Sort:    []appsort.SortItem{},
-		Pagination: &components.TablePaginationProps{
+	// tables without a page size show every entry they are given
+	var pagination *components.TablePaginationProps
+	if p.pageSize > 0 {
+		pagination = &components.TablePaginationProps{
 			TotalRecords: p.total,
 			CurrentPage:  p.page,
 			PageSize:     p.pageSize,
-		},
+		}
+	}
+
+	return components.Table(&components.TableProps{
+		Classes:    c.Classes{"stock-table": true},
+		Columns:    columns,
+		Rows:       rows,
+		Sort:       []appsort.SortItem{},
+		Pagination: pagination,
 	})
 }
+
+// transactionType shows the transaction type along with the reason and QC
+// approver of quarantine postings, and the scrap reason of scrap postings
+func transactionType(st model.StockTransactionEntry) g.Node {
+	nodes := []g.Node{g.Text(string(st.TransactionType))}
+
+	if st.Reason != "" {
+		approval := st.Reason
+		if st.ApprovedByUsername != nil {
+			approval = fmt.Sprintf("%s (approved by %s)", st.Reason, *st.ApprovedByUsername)
+		}
+		nodes = append(nodes, h.Br(), h.Small(g.Text(approval)))
+	}
+
+	if st.ScrapReasonCode != nil {
+		nodes = append(nodes, h.Br(), h.Small(g.Textf("Scrap reason: %s", *st.ScrapReasonCode)))
+	}
+
+	return g.Group(nodes)
+}
+
+// transactionReversal links a reversal and the transaction it reverses, or
+// offers to reverse the transaction
+func transactionReversal(st model.StockTransactionEntry, canReverse bool) g.Node {
+
+	transactionHref := func(stockTransactionID int) string {
+		return fmt.Sprintf("/stock/transactions?StockTransactionID=%d", stockTransactionID)
+	}
+
+	if st.ReversesStockTransactionID != nil {
+		return h.A(
+			h.Href(transactionHref(*st.ReversesStockTransactionID)),
+			g.Textf("Reverses #%d", *st.ReversesStockTransactionID),
+		)
+	}
+
+	if st.ReversedByStockTransactionID != nil {
+		return h.A(
+			h.Href(transactionHref(st.StockTransactionID)),
+			g.Textf("Reversed by #%d", *st.ReversedByStockTransactionID),
+		)
+	}
+
+	if !canReverse {
+		return g.Text("\u2013")
+	}
+
+	return h.Button(
+		h.Class("button secondary small stock-transaction-reverse-button"),
+		h.Type("submit"),
+		g.Attr("formmethod", "POST"),
+		g.Attr("formaction", fmt.Sprintf("/stock/transactions/%d/reverse", st.StockTransactionID)),
+		g.Attr("data-confirm", fmt.Sprintf(
+			"Reverse transaction %d? Mirror entries will be posted now.", st.StockTransactionID,
+		)),
+		g.Text("Reverse"),
+	)
+}
@@ -0,0 +1,178 @@
+package stockview
+
+import (
+	"app/internal/components"
+	"app/internal/layout"
+	"app/internal/model"
+	"app/pkg/nilsafe"
+	"app/pkg/reqcontext"
+	"app/pkg/validate"
+	"fmt"
+	"time"
+
+	g "maragu.dev/gomponents"
+	h "maragu.dev/gomponents/html"
+)
+
+type StockTransactionTypePageProps struct {
+	Ctx             reqcontext.ReqContext
+	TransactionType model.CustomStockTransactionType
+	ErrorText       string
+
+	ValidationErrors validate.ValidationErrors
+	IsSubmission     bool
+}
+
+func StockTransactionTypePage(p *StockTransactionTypePageProps) g.Node {
+
+	t := p.TransactionType
+
+	type attribute struct {
+		label string
+		value g.Node
+	}
+
+	createdBy := g.Group([]g.Node{
+		g.Textf("%s on ", nilsafe.Str(t.CreatedByUsername)),
+		h.Span(h.Class("local-datetime"), g.Text(t.CreatedAt.Format(time.RFC3339))),
+	})
+	updatedBy := g.Group([]g.Node{
+		g.Textf("%s on ", nilsafe.Str(t.UpdatedByUsername)),
+		h.Span(h.Class("local-datetime"), g.Text(t.UpdatedAt.Format(time.RFC3339))),
+	})
+
+	attributes := []attribute{
+		{label: "Name", value: g.Text(t.Name)},
+		{label: "From Account", value: g.Text(string(t.FromAccount))},
+		{label: "To Account", value: g.Text(string(t.ToAccount))},
+		{label: "Status", value: archivedBadge(t.IsArchived)},
+		{label: "Created By", value: createdBy},
+		{label: "Updated By", value: updatedBy},
+	}
+
+	fieldError := func(key, label string) g.Node {
+		if !p.IsSubmission {
+			return nil
+		}
+		errorText := p.ValidationErrors.GetError(key, label)
+		return g.If(
+			errorText != "",
+			components.InputHelper(&components.InputHelperProps{
+				Label: errorText,
+				Type:  components.InputHelperTypeError,
+			}),
+		)
+	}
+
+	var requiredFields []string
+	for _, f := range t.RequiredFields {
+		requiredFields = append(requiredFields, string(f))
+	}
+
+	content := g.Group([]g.Node{
+		h.Nav(
+			h.Class("stock-nav"),
+			h.A(h.Href("/stock/transaction-types"), g.Text("Transaction types")),
+			h.A(
+				h.Href(fmt.Sprintf("/stock/post-transaction/custom?CustomStockTransactionTypeID=%d", t.CustomStockTransactionTypeID)),
+				g.Text("Post"),
+			),
+		),
+
+		h.Ul(
+			h.Class("attributes-list"),
+
+			g.Group(g.Map(attributes, func(a attribute) g.Node {
+				return h.Li(
+					components.Icon(&components.IconProps{
+						Identifier: "arrow-right-thin",
+					}),
+					h.Strong(g.Textf("%s: ", a.label)),
+					h.Span(a.value),
+				)
+			})),
+		),
+
+		g.If(
+			p.ErrorText != "",
+			h.Div(
+				h.Class("error-msg"),
+				g.Text(p.ErrorText),
+			),
+		),
+
+		h.H3(g.Text("Edit Transaction Type")),
+
+		h.Form(
+			h.Method("POST"),
+			h.Class("form stock-transaction-type-form"),
+			h.Action(fmt.Sprintf("/stock/transaction-types/%d", t.CustomStockTransactionTypeID)),
+
+			h.Div(
+				h.Label(
+					g.Text("Description (optional)"),
+					h.Input(
+						h.Type("text"),
+						h.Name("Description"),
+						h.Value(t.Description),
+						h.Placeholder("Enter description"),
+						h.AutoComplete("off"),
+					),
+				),
+			),
+
+			customTransactionTypeRulesFields(&customTransactionTypeRulesProps{
+				allowDifferentLocation: t.AllowDifferentLocation,
+				allowDifferentBin:      t.AllowDifferentBin,
+				allowDifferentLot:      t.AllowDifferentLot,
+				requiredFields:         requiredFields,
+				requiredPermission:     string(t.RequiredPermission),
+				fieldError:             fieldError,
+			}),
+
+			fieldError("ToAccount", "To account"),
+
+			h.Label(
+				h.Class("checkbox"),
+				h.Input(
+					h.Type("checkbox"),
+					h.Name("IsArchived"),
+					h.Value("true"),
+					g.If(t.IsArchived, h.Checked()),
+				),
+				g.Text("Archived"),
+			),
+
+			h.Button(
+				h.Class("button primary"),
+				h.Type("submit"),
+				g.Text("Save Transaction Type"),
+			),
+		),
+	})
+
+	return layout.Page(layout.PageProps{
+		Ctx:     p.Ctx,
+		Title:   fmt.Sprintf("Transaction Type %s", t.Name),
+		Content: content,
+		Breadcrumbs: []layout.Breadcrumb{
+			layout.HomeBreadcrumb,
+			{
+				IconIdentifier: "package-variant-closed",
+				Title:          "Stock",
+				URLPart:        "stock",
+			},
+			{
+				Title:   "Transaction Types",
+				URLPart: "transaction-types",
+			},
+			{
+				Title: t.Name,
+			},
+		},
+		AppendHead: []g.Node{
+			components.InlineStyle("/internal/views/stockview/stock_lot_page.css"),
+			components.InlineStyle("/internal/views/stockview/stock_transaction_types_page.css"),
+		},
+	})
+}
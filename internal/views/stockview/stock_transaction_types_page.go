@@ -0,0 +1,362 @@
+package stockview
+
+import (
+	"app/internal/components"
+	"app/internal/layout"
+	"app/internal/model"
+	"app/pkg/reqcontext"
+	"app/pkg/validate"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+
+	g "maragu.dev/gomponents"
+	c "maragu.dev/gomponents/components"
+	h "maragu.dev/gomponents/html"
+)
+
+type StockTransactionTypesPageProps struct {
+	Ctx              reqcontext.ReqContext
+	TransactionTypes []model.CustomStockTransactionType
+	ShowArchived     bool
+	ErrorText        string
+
+	// Add transaction type form state
+	Values           url.Values
+	ValidationErrors validate.ValidationErrors
+	IsSubmission     bool
+}
+
+func StockTransactionTypesPage(p *StockTransactionTypesPageProps) g.Node {
+
+	content := g.Group([]g.Node{
+		h.FormEl(
+			h.Method("GET"),
+
+			h.Nav(
+				h.Class("stock-nav"),
+				h.A(h.Href("/stock"), g.Text("Stock levels")),
+				h.A(h.Href("/stock/transactions"), g.Text("See all transactions")),
+				h.A(h.Href("/stock/post-transaction/custom"), g.Text("Post custom transaction")),
+			),
+
+			h.H3(g.Text("Transaction Types")),
+
+			h.P(
+				h.Class("stock-transaction-types-info"),
+				g.Text(`Transaction types defined here are posted from the Custom
+					page of Post Transaction alongside the built in types. Each
+					moves stock from one account to another, at the same place
+					unless the location, bin or lot may differ. The name and
+					accounts cannot be changed once added; archive a type to stop
+					it being posted.`),
+			),
+
+			h.Div(
+				h.Class("stock-levels-filters"),
+
+				h.Label(
+					h.Class("checkbox"),
+					h.Input(
+						h.Type("checkbox"),
+						h.Name("ShowArchived"),
+						h.Value("true"),
+						g.If(p.ShowArchived, h.Checked()),
+					),
+					g.Text("Show archived"),
+				),
+
+				h.Div(
+					h.Class("go-button-wrapper"),
+					components.Button(&components.ButtonProps{
+						ButtonType: components.ButtonPrimary,
+						Classes:    c.Classes{"go-button": true},
+						Size:       components.ButtonLg,
+					},
+						h.Type("button"),
+						g.Attr("onclick", "submitTableForm(this.form)"),
+						g.Text("GO"),
+					),
+				),
+			),
+		),
+
+		components.Divider(),
+
+		g.If(
+			p.ErrorText != "",
+			h.Div(
+				h.Class("error-msg"),
+				g.Text(p.ErrorText),
+			),
+		),
+
+		stockTransactionTypesTable(p.TransactionTypes),
+
+		h.H3(g.Text("Add Transaction Type")),
+
+		addStockTransactionTypeForm(p.Values, p.ValidationErrors, p.IsSubmission),
+	})
+
+	return layout.Page(layout.PageProps{
+		Title:   "Transaction Types",
+		Content: content,
+		Ctx:     p.Ctx,
+		Breadcrumbs: []layout.Breadcrumb{
+			layout.HomeBreadcrumb,
+			{
+				IconIdentifier: "package-variant-closed",
+				Title:          "Stock",
+				URLPart:        "stock",
+			},
+			{
+				Title: "Transaction Types",
+			},
+		},
+		AppendHead: []g.Node{
+			components.InlineStyle("/internal/views/stockview/stock_table.css"),
+			components.InlineStyle("/internal/views/stockview/stock_transaction_types_page.css"),
+		},
+	})
+}
+
+func stockTransactionTypesTable(transactionTypes []model.CustomStockTransactionType) g.Node {
+
+	columns := components.TableColumns{
+		{TitleContents: g.Text("Name")},
+		{TitleContents: g.Text("From")},
+		{TitleContents: g.Text("To")},
+		{TitleContents: g.Text("May Differ")},
+		{TitleContents: g.Text("Required Fields")},
+		{TitleContents: g.Text("Permission")},
+		{TitleContents: g.Text("Status")},
+	}
+
+	var rows components.TableRows
+	for _, t := range transactionTypes {
+
+		transactionTypeHref := fmt.Sprintf("/stock/transaction-types/%d", t.CustomStockTransactionTypeID)
+
+		rows = append(rows, components.TableRow{
+			Cells: []components.TableCell{
+				{Contents: h.A(h.Href(transactionTypeHref), g.Text(t.Name))},
+				{Contents: g.Text(string(t.FromAccount))},
+				{Contents: g.Text(string(t.ToAccount))},
+				{Contents: g.Text(customTransactionTypeDifferences(&t))},
+				{Contents: g.Text(customTransactionTypeRequiredFields(&t))},
+				{Contents: g.Text(string(t.RequiredPermission))},
+				{Contents: archivedBadge(t.IsArchived)},
+			},
+			HREF: transactionTypeHref,
+		})
+	}
+
+	return components.Table(&components.TableProps{
+		Classes: c.Classes{"stock-table": true},
+		Columns: columns,
+		Rows:    rows,
+	})
+}
+
+// customTransactionTypeDifferences lists the parts of the place a custom
+// transaction type may post to that may differ from the place it posts from
+func customTransactionTypeDifferences(t *model.CustomStockTransactionType) string {
+	var differences []string
+	if t.AllowDifferentLocation {
+		differences = append(differences, "Location")
+	}
+	if t.AllowDifferentBin {
+		differences = append(differences, "Bin")
+	}
+	if t.AllowDifferentLot {
+		differences = append(differences, "Lot")
+	}
+
+	if len(differences) == 0 {
+		return "\u2013"
+	}
+	return strings.Join(differences, ", ")
+}
+
+func customTransactionTypeRequiredFields(t *model.CustomStockTransactionType) string {
+	if len(t.RequiredFields) == 0 {
+		return "\u2013"
+	}
+
+	var fields []string
+	for _, f := range t.RequiredFields {
+		fields = append(fields, string(f))
+	}
+	return strings.Join(fields, ", ")
+}
+
+func addStockTransactionTypeForm(
+	values url.Values,
+	validationErrors validate.ValidationErrors,
+	isSubmission bool,
+) g.Node {
+
+	fieldError := func(key, label string) g.Node {
+		if !isSubmission {
+			return nil
+		}
+		errorText := validationErrors.GetError(key, label)
+		return g.If(
+			errorText != "",
+			components.InputHelper(&components.InputHelperProps{
+				Label: errorText,
+				Type:  components.InputHelperTypeError,
+			}),
+		)
+	}
+
+	return h.Form(
+		h.Method("POST"),
+		h.Class("form stock-transaction-type-form"),
+		h.Action("/stock/transaction-types"),
+
+		h.Div(
+			h.Label(
+				g.Text("Name"),
+				h.Input(
+					h.Type("text"),
+					h.Name("Name"),
+					h.Value(values.Get("Name")),
+					h.Placeholder("Enter name"),
+					h.AutoComplete("off"),
+				),
+			),
+			fieldError("Name", "Name"),
+		),
+
+		h.Div(
+			h.Label(
+				g.Text("Description (optional)"),
+				h.Input(
+					h.Type("text"),
+					h.Name("Description"),
+					h.Value(values.Get("Description")),
+					h.Placeholder("Enter description"),
+					h.AutoComplete("off"),
+				),
+			),
+		),
+
+		h.Div(
+			h.Label(
+				g.Text("From Account"),
+				stockAccountSelect("FromAccount", values.Get("FromAccount")),
+			),
+			fieldError("FromAccount", "From account"),
+		),
+
+		h.Div(
+			h.Label(
+				g.Text("To Account"),
+				stockAccountSelect("ToAccount", values.Get("ToAccount")),
+			),
+			fieldError("ToAccount", "To account"),
+		),
+
+		customTransactionTypeRulesFields(&customTransactionTypeRulesProps{
+			allowDifferentLocation: values.Get("AllowDifferentLocation") == "true",
+			allowDifferentBin:      values.Get("AllowDifferentBin") == "true",
+			allowDifferentLot:      values.Get("AllowDifferentLot") == "true",
+			requiredFields:         values["RequiredFields"],
+			requiredPermission:     values.Get("RequiredPermission"),
+			fieldError:             fieldError,
+		}),
+
+		h.Button(
+			h.Class("button primary"),
+			h.Type("submit"),
+			g.Text("Add Transaction Type"),
+		),
+	)
+}
+
+func stockAccountSelect(name string, selected string) g.Node {
+	return h.Select(
+		h.Name(name),
+		h.Class("select"),
+		g.Group(g.Map(model.StockAccounts, func(a model.StockAccount) g.Node {
+			return h.Option(
+				h.Value(string(a)),
+				g.Text(string(a)),
+				g.If(selected == string(a), h.Selected()),
+			)
+		})),
+	)
+}
+
+type customTransactionTypeRulesProps struct {
+	allowDifferentLocation bool
+	allowDifferentBin      bool
+	allowDifferentLot      bool
+	requiredFields         []string
+	requiredPermission     string
+	fieldError             func(key, label string) g.Node
+}
+
+// customTransactionTypeRulesFields are the fields of a custom transaction
+// type that can still be changed once it is added
+func customTransactionTypeRulesFields(p *customTransactionTypeRulesProps) g.Node {
+
+	checkbox := func(name string, checked bool, label string) g.Node {
+		return h.Label(
+			h.Class("checkbox"),
+			h.Input(
+				h.Type("checkbox"),
+				h.Name(name),
+				h.Value("true"),
+				g.If(checked, h.Checked()),
+			),
+			g.Text(label),
+		)
+	}
+
+	return g.Group([]g.Node{
+		h.FieldSet(
+			h.Legend(g.Text("May differ from the place posted from")),
+			checkbox("AllowDifferentLocation", p.allowDifferentLocation, "Location"),
+			checkbox("AllowDifferentBin", p.allowDifferentBin, "Bin"),
+			checkbox("AllowDifferentLot", p.allowDifferentLot, "Lot"),
+		),
+
+		h.FieldSet(
+			h.Legend(g.Text("Required fields")),
+			g.Group(g.Map(model.CustomTransactionFields, func(f model.CustomTransactionField) g.Node {
+				return h.Label(
+					h.Class("checkbox"),
+					h.Input(
+						h.Type("checkbox"),
+						h.Name("RequiredFields"),
+						h.Value(string(f)),
+						g.If(slices.Contains(p.requiredFields, string(f)), h.Checked()),
+					),
+					g.Text(string(f)),
+				)
+			})),
+			p.fieldError("RequiredFields", "Required fields"),
+		),
+
+		h.Div(
+			h.Label(
+				g.Text("Required Permission (supply chain)"),
+				h.Select(
+					h.Name("RequiredPermission"),
+					h.Class("select"),
+					g.Group(g.Map(model.CustomTransactionPermissions, func(perm model.CustomTransactionPermission) g.Node {
+						return h.Option(
+							h.Value(string(perm)),
+							g.Text(string(perm)),
+							g.If(p.requiredPermission == string(perm), h.Selected()),
+						)
+					})),
+				),
+			),
+			p.fieldError("RequiredPermission", "Required permission"),
+		),
+	})
+}
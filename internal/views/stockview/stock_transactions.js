@@ -0,0 +1,14 @@
+document.addEventListener("DOMContentLoaded", () => {
+  const reverseButtons = document.querySelectorAll(
+    ".stock-transaction-reverse-button",
+  );
+
+  reverseButtons.forEach((button) => {
+    button.addEventListener("click", (event) => {
+      const confirmed = window.confirm(button.dataset.confirm);
+      if (!confirmed) {
+        event.preventDefault();
+      }
+    });
+  });
+});
@@ -0,0 +1,177 @@
+package stockview
+
+import (
+	"app/internal/components"
+	"app/internal/layout"
+	"app/internal/model"
+	"app/pkg/format"
+	"app/pkg/reqcontext"
+	"time"
+
+	g "maragu.dev/gomponents"
+	c "maragu.dev/gomponents/components"
+	h "maragu.dev/gomponents/html"
+)
+
+type StockValuationPageProps struct {
+	Ctx          reqcontext.ReqContext
+	Valuation    []model.StockValuation
+	Summary      model.StockValuationSummary
+	StockCode    string
+	Location     string
+	LTETimestamp *time.Time
+	Page         int
+	PageSize     int
+}
+
+func StockValuationPage(p *StockValuationPageProps) g.Node {
+
+	lteTimestampStr := ""
+	if p.LTETimestamp != nil {
+		lteTimestampStr = p.LTETimestamp.Format("2006-01-02T15:04")
+	}
+
+	content := h.FormEl(
+		h.Method("GET"),
+
+		h.Nav(
+			h.Class("stock-nav"),
+			h.A(h.Href("/stock"), g.Text("Stock levels")),
+			h.A(h.Href("/stock/transactions"), g.Text("See all transactions")),
+		),
+
+		h.H3(g.Text("Stock Valuation")),
+
+		h.Div(
+			h.Class("stock-levels-filters"),
+
+			h.Label(
+				h.Class("filter"),
+				g.Text("Stock Code"),
+				h.Input(
+					h.Class("lg"),
+					h.Name("StockCode"),
+					h.Value(p.StockCode),
+					h.AutoComplete("off"),
+					h.Placeholder("Enter stock code"),
+				),
+			),
+
+			h.Label(
+				h.Class("filter"),
+				g.Text("Location"),
+				h.Input(
+					h.Class("lg"),
+					h.Name("Location"),
+					h.Value(p.Location),
+					h.AutoComplete("off"),
+					h.Placeholder("Enter location"),
+				),
+			),
+
+			h.Label(
+				h.Class("filter"),
+				g.Text("Date/Time Limit"),
+				h.Input(
+					h.Class("lg"),
+					h.Type("datetime-local"),
+					h.Name("LTETimestamp"),
+					h.Value(lteTimestampStr),
+					h.AutoComplete("off"),
+				),
+			),
+
+			h.Div(
+				h.Class("go-button-wrapper"),
+				components.Button(&components.ButtonProps{
+					ButtonType: components.ButtonPrimary,
+					Classes:    c.Classes{"go-button": true},
+					Size:       components.ButtonLg,
+				},
+					h.Type("button"),
+					g.Attr("onclick", "submitTableForm(this.form)"),
+					g.Text("GO"),
+				),
+			),
+		),
+
+		components.Divider(),
+
+		h.P(
+			h.Class("stock-availability"),
+			g.Textf("Total value: %s", format.DecimalWithCommas(p.Summary.Value.StringFixed(2))),
+		),
+
+		stockValuationTable(p),
+	)
+
+	return layout.Page(layout.PageProps{
+		Title:   "Stock Valuation",
+		Content: content,
+		Ctx:     p.Ctx,
+		Breadcrumbs: []layout.Breadcrumb{
+			layout.HomeBreadcrumb,
+			{
+				IconIdentifier: "package-variant-closed",
+				Title:          "Stock",
+				URLPart:        "stock",
+			},
+			{
+				Title: "Valuation",
+			},
+		},
+		AppendHead: []g.Node{
+			components.InlineStyle("/internal/views/stockview/stock_table.css"),
+		},
+	})
+}
+
+func stockValuationTable(p *StockValuationPageProps) g.Node {
+
+	columns := components.TableColumns{
+		{TitleContents: g.Text("Stock Code")},
+		{TitleContents: g.Text("Description")},
+		{TitleContents: g.Text("Costing Method")},
+		{TitleContents: g.Text("Location")},
+		{TitleContents: g.Text("Qty"), Classes: c.Classes{"text-right": true}},
+		{TitleContents: g.Text("Unit Cost"), Classes: c.Classes{"text-right": true}},
+		{TitleContents: g.Text("Value"), Classes: c.Classes{"text-right": true}},
+	}
+
+	var rows components.TableRows
+	for _, v := range p.Valuation {
+		rows = append(rows, components.TableRow{
+			Cells: []components.TableCell{
+				{Contents: components.StockItemAnchor(v.StockCode)},
+				{Contents: g.Text(v.Description)},
+				{Contents: g.Text(string(v.CostingMethod))},
+				{Contents: g.Text(v.Location)},
+				{
+					Contents: g.Text(quantityWithUnit(v.Qty, v.Unit)),
+					Classes:  c.Classes{"text-right": true},
+				},
+				{
+					Contents: g.Text(format.DecimalWithCommas(v.UnitCost().StringFixed(4))),
+					Classes:  c.Classes{"text-right": true},
+				},
+				{
+					Contents: g.Text(format.DecimalWithCommas(v.Value.StringFixed(2))),
+					Classes:  c.Classes{"text-right": true},
+				},
+			},
+		})
+	}
+
+	return components.Table(&components.TableProps{
+		Classes: c.Classes{"stock-table": true},
+		Columns: columns,
+		Rows:    rows,
+		Pagination: &components.TablePaginationProps{
+			TotalRecords:        p.Summary.Count,
+			PageSize:            p.PageSize,
+			CurrentPage:         p.Page,
+			CurrentPageQueryKey: "Page",
+			PageSizeQueryKey:    "PageSize",
+		},
+	})
+}
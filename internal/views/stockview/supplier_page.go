@@ -0,0 +1,131 @@
+package stockview
+
+import (
+	"app/internal/components"
+	"app/internal/layout"
+	"app/internal/model"
+	"app/pkg/nilsafe"
+	"app/pkg/reqcontext"
+	"app/pkg/validate"
+	"fmt"
+	"net/url"
+	"time"
+
+	g "maragu.dev/gomponents"
+	h "maragu.dev/gomponents/html"
+)
+
+type SupplierPageProps struct {
+	Ctx       reqcontext.ReqContext
+	Supplier  model.Supplier
+	ErrorText string
+
+	// Edit supplier form state
+	Values           url.Values
+	ValidationErrors validate.ValidationErrors
+	IsSubmission     bool
+}
+
+func SupplierPage(p *SupplierPageProps) g.Node {
+
+	s := p.Supplier
+
+	type attribute struct {
+		label string
+		value g.Node
+	}
+
+	createdBy := g.Group([]g.Node{
+		g.Textf("%s on ", nilsafe.Str(s.CreatedByUsername)),
+		h.Span(h.Class("local-datetime"), g.Text(s.CreatedAt.Format(time.RFC3339))),
+	})
+	updatedBy := g.Group([]g.Node{
+		g.Textf("%s on ", nilsafe.Str(s.UpdatedByUsername)),
+		h.Span(h.Class("local-datetime"), g.Text(s.UpdatedAt.Format(time.RFC3339))),
+	})
+
+	purchaseOrdersParams := url.Values{}
+	purchaseOrdersParams.Set("SupplierID", fmt.Sprintf("%d", s.SupplierID))
+
+	attributes := []attribute{
+		{label: "Code", value: g.Text(s.Code)},
+		{label: "Name", value: g.Text(s.Name)},
+		{label: "Over Receipt Tolerance", value: g.Text(overReceiptTolerancePercent(s))},
+		{label: "Status", value: archivedBadge(s.IsArchived)},
+		{label: "Created By", value: createdBy},
+		{label: "Updated By", value: updatedBy},
+	}
+
+	content := g.Group([]g.Node{
+		h.Nav(
+			h.Class("stock-nav"),
+			h.A(h.Href("/stock/suppliers"), g.Text("Suppliers")),
+			h.A(
+				h.Href("/stock/purchase-orders?"+purchaseOrdersParams.Encode()),
+				g.Text("Purchase orders"),
+			),
+			g.If(
+				!s.IsArchived,
+				h.A(
+					h.Href("/stock/purchase-orders/add?"+purchaseOrdersParams.Encode()),
+					g.Text("New purchase order"),
+				),
+			),
+		),
+
+		h.Ul(
+			h.Class("attributes-list"),
+
+			g.Group(g.Map(attributes, func(a attribute) g.Node {
+				return h.Li(
+					components.Icon(&components.IconProps{
+						Identifier: "arrow-right-thin",
+					}),
+					h.Strong(g.Textf("%s: ", a.label)),
+					h.Span(a.value),
+				)
+			})),
+		),
+
+		g.If(
+			p.ErrorText != "",
+			h.Div(
+				h.Class("error-msg"),
+				g.Text(p.ErrorText),
+			),
+		),
+
+		h.H3(g.Text("Edit Supplier")),
+
+		supplierForm(&supplierFormProps{
+			action:           fmt.Sprintf("/stock/suppliers/%d", s.SupplierID),
+			values:           p.Values,
+			validationErrors: p.ValidationErrors,
+			isSubmission:     p.IsSubmission,
+		}),
+	})
+
+	return layout.Page(layout.PageProps{
+		Ctx:     p.Ctx,
+		Title:   fmt.Sprintf("Supplier %s", s.Code),
+		Content: content,
+		Breadcrumbs: []layout.Breadcrumb{
+			layout.HomeBreadcrumb,
+			{
+				IconIdentifier: "package-variant-closed",
+				Title:          "Stock",
+				URLPart:        "stock",
+			},
+			{
+				Title:   "Suppliers",
+				URLPart: "suppliers",
+			},
+			{
+				Title: s.Code,
+			},
+		},
+		AppendHead: []g.Node{
+			components.InlineStyle("/internal/views/stockview/purchase_order_page.css"),
+		},
+	})
+}
@@ -0,0 +1,300 @@
+package stockview
+
+import (
+	"app/internal/components"
+	"app/internal/layout"
+	"app/internal/model"
+	"app/pkg/reqcontext"
+	"app/pkg/validate"
+	"fmt"
+	"net/url"
+
+	g "maragu.dev/gomponents"
+	c "maragu.dev/gomponents/components"
+	h "maragu.dev/gomponents/html"
+)
+
+type SuppliersPageProps struct {
+	Ctx            reqcontext.ReqContext
+	Suppliers      []model.Supplier
+	SuppliersCount int
+	SearchText     string
+	ShowArchived   bool
+	Page           int
+	PageSize       int
+	ErrorText      string
+
+	// Add supplier form state
+	Values           url.Values
+	ValidationErrors validate.ValidationErrors
+	IsSubmission     bool
+}
+
+func SuppliersPage(p *SuppliersPageProps) g.Node {
+
+	content := g.Group([]g.Node{
+		h.FormEl(
+			h.Method("GET"),
+
+			h.Nav(
+				h.Class("stock-nav"),
+				h.A(h.Href("/stock"), g.Text("Stock levels")),
+				h.A(h.Href("/stock/purchase-orders"), g.Text("Purchase orders")),
+			),
+
+			h.H3(g.Text("Suppliers")),
+
+			h.Div(
+				h.Class("stock-levels-filters"),
+
+				h.Label(
+					h.Class("filter"),
+					g.Text("Search"),
+					h.Input(
+						h.Class("lg"),
+						h.Name("SearchText"),
+						h.Value(p.SearchText),
+						h.AutoComplete("off"),
+						h.Placeholder("Code or name"),
+					),
+				),
+
+				h.Label(
+					h.Class("checkbox"),
+					h.Input(
+						h.Type("checkbox"),
+						h.Name("ShowArchived"),
+						h.Value("true"),
+						g.If(p.ShowArchived, h.Checked()),
+					),
+					g.Text("Show archived"),
+				),
+
+				h.Div(
+					h.Class("go-button-wrapper"),
+					components.Button(&components.ButtonProps{
+						ButtonType: components.ButtonPrimary,
+						Classes:    c.Classes{"go-button": true},
+						Size:       components.ButtonLg,
+					},
+						h.Type("button"),
+						g.Attr("onclick", "submitTableForm(this.form)"),
+						g.Text("GO"),
+					),
+				),
+			),
+
+			components.Divider(),
+
+			suppliersTable(&suppliersTableProps{
+				suppliers:      p.Suppliers,
+				suppliersCount: p.SuppliersCount,
+				page:           p.Page,
+				pageSize:       p.PageSize,
+			}),
+		),
+
+		g.If(
+			p.ErrorText != "",
+			h.Div(
+				h.Class("error-msg"),
+				g.Text(p.ErrorText),
+			),
+		),
+
+		h.H3(g.Text("Add Supplier")),
+
+		supplierForm(&supplierFormProps{
+			action:           "/stock/suppliers",
+			isNew:            true,
+			values:           p.Values,
+			validationErrors: p.ValidationErrors,
+			isSubmission:     p.IsSubmission,
+		}),
+	})
+
+	return layout.Page(layout.PageProps{
+		Title:   "Suppliers",
+		Content: content,
+		Ctx:     p.Ctx,
+		Breadcrumbs: []layout.Breadcrumb{
+			layout.HomeBreadcrumb,
+			{
+				IconIdentifier: "package-variant-closed",
+				Title:          "Stock",
+				URLPart:        "stock",
+			},
+			{
+				Title: "Suppliers",
+			},
+		},
+		AppendHead: []g.Node{
+			components.InlineStyle("/internal/views/stockview/stock_table.css"),
+			components.InlineStyle("/internal/views/stockview/purchase_order_page.css"),
+		},
+	})
+}
+
+type suppliersTableProps struct {
+	suppliers      []model.Supplier
+	suppliersCount int
+	page           int
+	pageSize       int
+}
+
+func suppliersTable(p *suppliersTableProps) g.Node {
+
+	columns := components.TableColumns{
+		{TitleContents: g.Text("Code")},
+		{TitleContents: g.Text("Name")},
+		{TitleContents: g.Text("Email")},
+		{TitleContents: g.Text("Phone")},
+		{TitleContents: g.Text("Over Receipt Tolerance"), Classes: c.Classes{"text-right": true}},
+		{TitleContents: g.Text("Status")},
+	}
+
+	dashIfEmpty := func(s string) string {
+		if s == "" {
+			return "\u2013"
+		}
+		return s
+	}
+
+	var rows components.TableRows
+	for _, s := range p.suppliers {
+
+		supplierHref := fmt.Sprintf("/stock/suppliers/%d", s.SupplierID)
+
+		rows = append(rows, components.TableRow{
+			Cells: []components.TableCell{
+				{Contents: h.A(h.Href(supplierHref), g.Text(s.Code))},
+				{Contents: g.Text(s.Name)},
+				{Contents: g.Text(dashIfEmpty(s.Email))},
+				{Contents: g.Text(dashIfEmpty(s.Phone))},
+				{
+					Contents: g.Text(overReceiptTolerancePercent(s)),
+					Classes:  c.Classes{"text-right": true},
+				},
+				{Contents: archivedBadge(s.IsArchived)},
+			},
+			HREF: supplierHref,
+		})
+	}
+
+	return components.Table(&components.TableProps{
+		Classes: c.Classes{"stock-table": true},
+		Columns: columns,
+		Rows:    rows,
+		Pagination: &components.TablePaginationProps{
+			TotalRecords:        p.suppliersCount,
+			PageSize:            p.pageSize,
+			CurrentPage:         p.page,
+			CurrentPageQueryKey: "Page",
+			PageSizeQueryKey:    "PageSize",
+		},
+	})
+}
+
+// overReceiptTolerancePercent shows the stored fraction as a percentage
+func overReceiptTolerancePercent(s model.Supplier) string {
+	return s.OverReceiptTolerance.Shift(2).String() + "%"
+}
+
+type supplierFormProps struct {
+	action           string
+	isNew            bool
+	values           url.Values
+	validationErrors validate.ValidationErrors
+	isSubmission     bool
+}
+
+func supplierForm(p *supplierFormProps) g.Node {
+
+	fieldError := func(key, label string) g.Node {
+		if !p.isSubmission {
+			return nil
+		}
+		errorText := p.validationErrors.GetError(key, label)
+		if errorText == "" {
+			return nil
+		}
+		return components.InputHelper(&components.InputHelperProps{
+			Label: errorText,
+			Type:  components.InputHelperTypeError,
+		})
+	}
+
+	textInput := func(key, label, placeholder string) g.Node {
+		return h.Div(
+			h.Label(
+				g.Text(label),
+				h.Input(
+					h.Type("text"),
+					h.Name(key),
+					h.Value(p.values.Get(key)),
+					h.Placeholder(placeholder),
+					h.AutoComplete("off"),
+				),
+			),
+			fieldError(key, label),
+		)
+	}
+
+	submitText := "Save Supplier"
+	if p.isNew {
+		submitText = "Add Supplier"
+	}
+
+	return h.Form(
+		h.Method("POST"),
+		h.Class("form purchase-order-form"),
+		h.Action(p.action),
+
+		g.If(p.isNew, textInput("Code", "Code", "Enter supplier code")),
+		textInput("Name", "Name", "Enter supplier name"),
+		textInput("Email", "Email (optional)", "Enter email address"),
+		textInput("Phone", "Phone (optional)", "Enter phone number"),
+
+		h.Div(
+			h.Label(
+				g.Text("Over Receipt Tolerance (fraction of the ordered quantity, e.g. 0.05 for 5%)"),
+				h.Input(
+					h.Type("number"),
+					h.Min("0"),
+					h.Step("any"),
+					h.Name("OverReceiptTolerance"),
+					h.Value(p.values.Get("OverReceiptTolerance")),
+					h.Placeholder("0"),
+					h.AutoComplete("off"),
+				),
+			),
+			fieldError("OverReceiptTolerance", "Over Receipt Tolerance"),
+		),
+
+		g.If(
+			!p.isNew,
+			h.Label(
+				h.Class("checkbox"),
+				h.Input(
+					h.Type("checkbox"),
+					h.Name("IsArchived"),
+					h.Value("true"),
+					g.If(p.values.Get("IsArchived") == "true", h.Checked()),
+				),
+				g.Text("Archived"),
+			),
+		),
+
+		h.P(
+			h.Class("purchase-order-info"),
+			g.Text(`Archived suppliers cannot be used on new purchase orders.
+				Orders already placed with them can still be received.`),
+		),
+
+		h.Button(
+			h.Class("button primary"),
+			h.Type("submit"),
+			g.Text(submitText),
+		),
+	)
+}
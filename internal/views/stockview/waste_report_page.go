@@ -0,0 +1,355 @@
+package stockview
+
+import (
+	"app/internal/components"
+	"app/internal/layout"
+	"app/internal/model"
+	"app/pkg/format"
+	"app/pkg/reqcontext"
+	"fmt"
+
+	"github.com/shopspring/decimal"
+	g "maragu.dev/gomponents"
+	c "maragu.dev/gomponents/components"
+	h "maragu.dev/gomponents/html"
+)
+
+type WasteReportPageProps struct {
+	Ctx          reqcontext.ReqContext
+	Report       model.WasteReport
+	Input        model.GetWasteReportInput
+	ScrapReasons []model.ScrapReason
+}
+
+func WasteReportPage(p *WasteReportPageProps) g.Node {
+
+	content := h.FormEl(
+		h.Method("GET"),
+
+		h.Nav(
+			h.Class("stock-nav"),
+			h.A(h.Href("/stock"), g.Text("Stock levels")),
+			h.A(h.Href("/stock/transactions?Account=SCRAP"), g.Text("Scrap transactions")),
+			g.If(
+				p.Ctx.User.Permissions.SupplyChain.Admin,
+				h.A(h.Href("/stock/scrap-reasons"), g.Text("Scrap reasons")),
+			),
+		),
+
+		h.H3(g.Text("Waste")),
+
+		h.Div(
+			h.Class("stock-levels-filters"),
+
+			h.Label(
+				h.Class("filter"),
+				g.Text("From"),
+				h.Input(
+					h.Class("lg"),
+					h.Type("date"),
+					h.Name("FromDate"),
+					h.Value(p.Input.FromDate.Format("2006-01-02")),
+				),
+			),
+
+			h.Label(
+				h.Class("filter"),
+				g.Text("To"),
+				h.Input(
+					h.Class("lg"),
+					h.Type("date"),
+					h.Name("ToDate"),
+					h.Value(p.Input.ToDate.Format("2006-01-02")),
+				),
+			),
+
+			h.Label(
+				h.Class("filter"),
+				g.Text("Stock Code"),
+				h.Input(
+					h.Class("lg"),
+					h.Name("StockCode"),
+					h.Value(p.Input.StockCode),
+					h.AutoComplete("off"),
+					h.Placeholder("Enter stock code"),
+				),
+			),
+
+			h.Label(
+				h.Class("filter"),
+				g.Text("Location"),
+				h.Input(
+					h.Class("lg"),
+					h.Name("Location"),
+					h.Value(p.Input.Location),
+					h.AutoComplete("off"),
+					h.Placeholder("Enter location"),
+				),
+			),
+
+			h.Label(
+				h.Class("filter"),
+				g.Text("Scrap Reason"),
+				h.Select(
+					h.Name("ScrapReasonID"),
+					h.Option(h.Value(""), g.Text("All")),
+					g.Group(g.Map(p.ScrapReasons, func(sr model.ScrapReason) g.Node {
+						return h.Option(
+							h.Value(fmt.Sprintf("%d", sr.ScrapReasonID)),
+							g.Text(sr.Code),
+							g.If(p.Input.ScrapReasonID == sr.ScrapReasonID, h.Selected()),
+						)
+					})),
+				),
+			),
+
+			h.Label(
+				h.Class("filter"),
+				g.Text("Trend By"),
+				h.Select(
+					h.Name("TrendPeriod"),
+					g.Group(g.Map(model.WastePeriods, func(wp model.WastePeriod) g.Node {
+						return h.Option(
+							h.Value(string(wp)),
+							g.Text(string(wp)),
+							g.If(p.Input.TrendPeriod == wp, h.Selected()),
+						)
+					})),
+				),
+			),
+
+			h.Div(
+				h.Class("go-button-wrapper"),
+				components.Button(&components.ButtonProps{
+					ButtonType: components.ButtonPrimary,
+					Classes:    c.Classes{"go-button": true},
+					Size:       components.ButtonLg,
+				},
+					h.Type("button"),
+					g.Attr("onclick", "submitTableForm(this.form)"),
+					g.Text("GO"),
+				),
+			),
+		),
+
+		components.Divider(),
+
+		h.P(
+			h.Class("stock-availability"),
+			g.Textf(
+				"Total waste: %s from %d postings",
+				format.DecimalWithCommas(p.Report.Summary.Value.StringFixed(2)),
+				p.Report.Summary.PostingCount,
+			),
+		),
+
+		h.Div(
+			h.Class("waste-overview"),
+
+			h.Div(
+				h.H4(g.Textf("Trend by %s", p.Input.TrendPeriod)),
+				wasteTrendTable(p.Report.Trend, p.Input.TrendPeriod),
+			),
+
+			h.Div(
+				h.H4(g.Text("Top Offenders")),
+				wasteOffendersTable(p.Report.TopOffenders, p.Report.Summary.Value),
+			),
+		),
+
+		h.H4(g.Text("By Stock Item, Reason and Location")),
+
+		wasteLinesTable(p),
+	)
+
+	return layout.Page(layout.PageProps{
+		Title:   "Waste",
+		Content: content,
+		Ctx:     p.Ctx,
+		Breadcrumbs: []layout.Breadcrumb{
+			layout.HomeBreadcrumb,
+			{
+				IconIdentifier: "package-variant-closed",
+				Title:          "Stock",
+				URLPart:        "stock",
+			},
+			{
+				Title: "Waste",
+			},
+		},
+		AppendHead: []g.Node{
+			components.InlineStyle("/internal/views/stockview/stock_table.css"),
+			components.InlineStyle("/internal/views/stockview/waste_report_page.css"),
+		},
+	})
+}
+
+// wasteTrendTable shows the waste of each period with a bar scaled to the
+// period with the most waste
+func wasteTrendTable(trend []model.WasteTrendPeriod, period model.WastePeriod) g.Node {
+
+	maxValue := decimal.Zero
+	for _, t := range trend {
+		if t.Value.GreaterThan(maxValue) {
+			maxValue = t.Value
+		}
+	}
+
+	dateFormat := "2006-01-02"
+	if period == model.MonthWastePeriod {
+		dateFormat = "Jan 2006"
+	}
+
+	columns := components.TableColumns{
+		{TitleContents: g.Text("Period")},
+		{TitleContents: g.Text("Qty"), Classes: c.Classes{"text-right": true}},
+		{TitleContents: g.Text("Postings"), Classes: c.Classes{"text-right": true}},
+		{TitleContents: g.Text("Value"), Classes: c.Classes{"text-right": true}},
+		{TitleContents: g.Text("")},
+	}
+
+	var rows components.TableRows
+	for _, t := range trend {
+
+		qty := "\u2013"
+		if t.Qty != nil {
+			qty = format.DecimalWithCommas(t.Qty.String())
+		}
+
+		barWidth := decimal.Zero
+		if maxValue.IsPositive() && t.Value.IsPositive() {
+			barWidth = t.Value.Div(maxValue).Mul(decimal.NewFromInt(100))
+		}
+
+		rows = append(rows, components.TableRow{
+			Cells: []components.TableCell{
+				{Contents: g.Text(t.PeriodStart.Format(dateFormat))},
+				{
+					Contents: g.Text(qty),
+					Classes:  c.Classes{"text-right": true},
+				},
+				{
+					Contents: g.Textf("%d", t.PostingCount),
+					Classes:  c.Classes{"text-right": true},
+				},
+				{
+					Contents: g.Text(format.DecimalWithCommas(t.Value.StringFixed(2))),
+					Classes:  c.Classes{"text-right": true},
+				},
+				{Contents: h.Div(
+					h.Class("waste-bar"),
+					h.Style(fmt.Sprintf("width: %s%%", barWidth.StringFixed(1))),
+				)},
+			},
+		})
+	}
+
+	return components.Table(&components.TableProps{
+		Classes: c.Classes{"stock-table": true, "waste-trend-table": true},
+		Columns: columns,
+		Rows:    rows,
+	})
+}
+
+// wasteOffendersTable shows the stock items with the most waste and their
+// share of the total
+func wasteOffendersTable(offenders []model.WasteOffender, totalValue decimal.Decimal) g.Node {
+
+	columns := components.TableColumns{
+		{TitleContents: g.Text("Stock Code")},
+		{TitleContents: g.Text("Description")},
+		{TitleContents: g.Text("Qty"), Classes: c.Classes{"text-right": true}},
+		{TitleContents: g.Text("Value"), Classes: c.Classes{"text-right": true}},
+		{TitleContents: g.Text("Share"), Classes: c.Classes{"text-right": true}},
+	}
+
+	var rows components.TableRows
+	for _, o := range offenders {
+
+		share := "\u2013"
+		if totalValue.IsPositive() {
+			share = o.Value.Div(totalValue).Mul(decimal.NewFromInt(100)).StringFixed(1) + "%"
+		}
+
+		rows = append(rows, components.TableRow{
+			Cells: []components.TableCell{
+				{Contents: components.StockItemAnchor(o.StockCode)},
+				{Contents: g.Text(o.Description)},
+				{
+					Contents: g.Text(quantityWithUnit(o.Qty, o.Unit)),
+					Classes:  c.Classes{"text-right": true},
+				},
+				{
+					Contents: g.Text(format.DecimalWithCommas(o.Value.StringFixed(2))),
+					Classes:  c.Classes{"text-right": true},
+				},
+				{
+					Contents: g.Text(share),
+					Classes:  c.Classes{"text-right": true},
+				},
+			},
+		})
+	}
+
+	return components.Table(&components.TableProps{
+		Classes: c.Classes{"stock-table": true},
+		Columns: columns,
+		Rows:    rows,
+	})
+}
+
+func wasteLinesTable(p *WasteReportPageProps) g.Node {
+
+	columns := components.TableColumns{
+		{TitleContents: g.Text("Stock Code")},
+		{TitleContents: g.Text("Description")},
+		{TitleContents: g.Text("Scrap Reason")},
+		{TitleContents: g.Text("Location")},
+		{TitleContents: g.Text("Qty"), Classes: c.Classes{"text-right": true}},
+		{TitleContents: g.Text("Postings"), Classes: c.Classes{"text-right": true}},
+		{TitleContents: g.Text("Value"), Classes: c.Classes{"text-right": true}},
+	}
+
+	var rows components.TableRows
+	for _, l := range p.Report.Lines {
+
+		scrapReason := "\u2013"
+		if l.ScrapReasonCode != nil {
+			scrapReason = *l.ScrapReasonCode
+		}
+
+		rows = append(rows, components.TableRow{
+			Cells: []components.TableCell{
+				{Contents: components.StockItemAnchor(l.StockCode)},
+				{Contents: g.Text(l.Description)},
+				{Contents: g.Text(scrapReason)},
+				{Contents: g.Text(l.Location)},
+				{
+					Contents: g.Text(quantityWithUnit(l.Qty, l.Unit)),
+					Classes:  c.Classes{"text-right": true},
+				},
+				{
+					Contents: g.Textf("%d", l.PostingCount),
+					Classes:  c.Classes{"text-right": true},
+				},
+				{
+					Contents: g.Text(format.DecimalWithCommas(l.Value.StringFixed(2))),
+					Classes:  c.Classes{"text-right": true},
+				},
+			},
+		})
+	}
+
+	return components.Table(&components.TableProps{
+		Classes: c.Classes{"stock-table": true},
+		Columns: columns,
+		Rows:    rows,
+		Pagination: &components.TablePaginationProps{
+			TotalRecords:        p.Report.Summary.Count,
+			PageSize:            p.Input.PageSize,
+			CurrentPage:         p.Input.Page,
+			CurrentPageQueryKey: "Page",
+			PageSizeQueryKey:    "PageSize",
+		},
+	})
+}
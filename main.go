@@ -51,6 +51,17 @@ func main() {
 	}
 	defer pgPool.Close() // Always close the pool when done
 
+	// Stock ledger maintenance runs as a one-off command instead of the server,
+	// e.g. `app stock-ledger check` or `app stock-ledger rebuild`
+	if len(os.Args) > 1 && os.Args[1] == "stock-ledger" {
+		stockLedgerIntegrityService := service.NewStockLedgerIntegrityService(
+			pgPool, repository.NewStockLedgerIntegrityRepository(),
+		)
+		exitCode := runStockLedgerCommand(stockLedgerIntegrityService, os.Args[2:])
+		pgPool.Close()
+		os.Exit(exitCode)
+	}
+
 	// Initialise some things for start up
 	err = cookie.InitCookieInstance()
 	if err != nil {
@@ -86,39 +97,81 @@ func main() {
 	authRepository := repository.NewAuthRepository()
 	fileRepository := repository.NewFileRepository(swiftContainer, secretKey)
 	commentRepository := repository.NewCommentRepository(fileRepository)
+	customerRepository := repository.NewCustomerRepository()
 	galleryRepository := repository.NewGalleryRepository(secretKey, fileRepository)
 	notificationRepository := repository.NewNotificationRepository()
 	printNodeService := service.NewPrintNodeService(printNodeAPIKey)
 	pdfRepository := repository.NewPDFRepository()
 	pdfService := service.NewPDFService(pgPool, swiftConn, fileRepository, pdfRepository, printNodeService)
+	negativeStockPolicyRepository := repository.NewNegativeStockPolicyRepository()
+	purchaseOrderRepository := repository.NewPurchaseOrderRepository()
 	resourceRepository := repository.NewResourceRepository()
+	salesOrderRepository := repository.NewSalesOrderRepository()
 	serviceRepository := repository.NewServiceRepository()
+	stockCountRepository := repository.NewStockCountRepository()
+	stockDocumentRepository := repository.NewStockDocumentRepository()
+	stockGenealogyRepository := repository.NewStockGenealogyRepository()
+	stockImportRepository := repository.NewStockImportRepository()
+	stockLedgerIntegrityRepository := repository.NewStockLedgerIntegrityRepository()
+	stockBOMRepository := repository.NewStockBOMRepository()
+	stockCostRepository := repository.NewStockCostRepository()
+	stockLocationRepository := repository.NewStockLocationRepository()
+	stockLotRepository := repository.NewStockLotRepository()
+	stockPeriodRepository := repository.NewStockPeriodRepository()
+	stockReorderRepository := repository.NewStockReorderRepository()
+	stockReservationRepository := repository.NewStockReservationRepository()
+	stockScrapRepository := repository.NewStockScrapRepository()
+	stockSerialRepository := repository.NewStockSerialRepository()
 	stockTrxRepository := repository.NewStockTransactionRepository()
+	stockTransactionTypeRepository := repository.NewStockTransactionTypeRepository()
 	teamRepository := repository.NewTeamRepository()
 	stockItemRepository := repository.NewStockItemRepository()
+	supplierRepository := repository.NewSupplierRepository()
 	userRepository := repository.NewUserRepository()
 	searchRepository := repository.NewSearchRepository()
 
 	// Instantiate services
 	notificationService := service.NewNotificationService(pgPool, notificationRepository)
+	stockReorderService := service.NewStockReorderService(pgPool, stockItemRepository, stockReorderRepository, teamRepository, userRepository, notificationService)
+	stockTransactionService := service.NewStockTransactionService(pgPool, negativeStockPolicyRepository, purchaseOrderRepository, salesOrderRepository, stockBOMRepository, stockCostRepository, stockGenealogyRepository, stockItemRepository, stockLocationRepository, stockLotRepository, stockPeriodRepository, stockReservationRepository, stockScrapRepository, stockSerialRepository, stockTrxRepository, stockTransactionTypeRepository, userRepository, stockReorderService)
+	stockLotService := service.NewStockLotService(pgPool, stockLotRepository, userRepository, notificationService)
+	fileService := service.NewFileService(pgPool, swiftConn, fileRepository)
 
 	services := &router.Services{
-		AndonService:            *service.NewAndonService(pgPool, swiftConn, andonRepository, commentRepository, galleryRepository, teamRepository, notificationService),
-		AndonIssueService:       *service.NewAndonIssueService(pgPool, andonIssueRepository),
-		AuthService:             *service.NewAuthService(pgPool, authRepository),
-		CommentService:          *service.NewCommentService(pgPool, swiftConn, commentRepository, userRepository, notificationService),
-		FileService:             *service.NewFileService(pgPool, swiftConn, fileRepository),
-		GalleryService:          *service.NewGalleryService(pgPool, swiftConn, appHMAC, fileRepository, galleryRepository),
-		NotificationService:     *notificationService,
-		PDFService:              *pdfService,
-		PrintNodeService:        *printNodeService,
-		ResourceService:         *service.NewResourceService(pgPool, commentRepository, galleryRepository, resourceRepository, serviceRepository),
-		SearchService:           *service.NewSearchService(pgPool, searchRepository),
-		ServicesService:         *service.NewServicesService(pgPool, commentRepository, galleryRepository, resourceRepository, serviceRepository),
-		StockItemService:        *service.NewStockItemService(pgPool, swiftConn, galleryRepository, stockItemRepository, commentRepository),
-		StockTransactionService: *service.NewStockTransactionService(pgPool, stockTrxRepository),
-		TeamService:             *service.NewTeamService(pgPool, teamRepository, userRepository),
-		UserService:             *service.NewUserService(pgPool, userRepository),
+		AndonService:                *service.NewAndonService(pgPool, swiftConn, andonRepository, commentRepository, galleryRepository, teamRepository, notificationService),
+		AndonIssueService:           *service.NewAndonIssueService(pgPool, andonIssueRepository),
+		AuthService:                 *service.NewAuthService(pgPool, authRepository),
+		CommentService:              *service.NewCommentService(pgPool, swiftConn, commentRepository, userRepository, notificationService),
+		FileService:                 *fileService,
+		GalleryService:              *service.NewGalleryService(pgPool, swiftConn, appHMAC, fileRepository, galleryRepository),
+		NotificationService:         *notificationService,
+		PDFService:                  *pdfService,
+		PrintNodeService:            *printNodeService,
+		PurchaseOrderService:        *service.NewPurchaseOrderService(pgPool, purchaseOrderRepository, stockItemRepository, stockTrxRepository, supplierRepository, stockTransactionService),
+		ResourceService:             *service.NewResourceService(pgPool, commentRepository, galleryRepository, resourceRepository, serviceRepository),
+		SalesOrderService:           *service.NewSalesOrderService(pgPool, customerRepository, salesOrderRepository, stockItemRepository, stockLocationRepository, stockLotRepository, stockReservationRepository, stockTrxRepository, stockTransactionService),
+		SearchService:               *service.NewSearchService(pgPool, searchRepository),
+		ServicesService:             *service.NewServicesService(pgPool, commentRepository, galleryRepository, resourceRepository, serviceRepository),
+		StockBOMService:             *service.NewStockBOMService(pgPool, stockBOMRepository, stockItemRepository),
+		StockCostService:            *service.NewStockCostService(pgPool, stockCostRepository),
+		StockCountService:           *service.NewStockCountService(pgPool, stockCountRepository, stockItemRepository, stockTrxRepository, stockTransactionService),
+		StockDocumentService:        *service.NewStockDocumentService(pgPool, stockDocumentRepository, stockGenealogyRepository, stockItemRepository, stockTrxRepository, stockTransactionService),
+		StockGenealogyService:       *service.NewStockGenealogyService(pgPool, stockGenealogyRepository, stockItemRepository),
+		StockImportService:          *service.NewStockImportService(pgPool, fileService, stockImportRepository, stockItemRepository, stockTransactionService),
+		StockLedgerIntegrityService: *service.NewStockLedgerIntegrityService(pgPool, stockLedgerIntegrityRepository),
+		StockLocationService:        *service.NewStockLocationService(pgPool, stockLocationRepository),
+		StockLotService:             *stockLotService,
+		StockPeriodService:          *service.NewStockPeriodService(pgPool, stockPeriodRepository),
+		StockReorderService:         *stockReorderService,
+		StockReservationService:     *service.NewStockReservationService(pgPool, stockItemRepository, stockReservationRepository, stockTransactionService),
+		StockScanService:            *service.NewStockScanService(pgPool, stockItemRepository, stockLocationRepository, stockTrxRepository, stockTransactionService),
+		StockScrapService:           *service.NewStockScrapService(pgPool, stockScrapRepository),
+		StockSerialService:          *service.NewStockSerialService(pgPool, stockSerialRepository),
+		StockItemService:            *service.NewStockItemService(pgPool, swiftConn, galleryRepository, stockItemRepository, commentRepository),
+		StockTransactionService:     *stockTransactionService,
+		StockTransactionTypeService: *service.NewStockTransactionTypeService(pgPool, stockTransactionTypeRepository),
+		TeamService:                 *service.NewTeamService(pgPool, teamRepository, userRepository),
+		UserService:                 *service.NewUserService(pgPool, userRepository),
 	}
 
 	// define server
@@ -131,6 +184,13 @@ func main() {
 	pdf.InitChromium()
 	defer pdf.ShutdownChromium()
 
+	// Notify the supply chain team of lots approaching expiry in the background
+	go runStockLotExpiryNotifier(context.Background(), stockLotService)
+
+	// Check stock against reorder points on a schedule as well as after each
+	// posting, so policy changes and missed checks are caught up
+	go runStockReorderNotifier(context.Background(), stockReorderService)
+
 	// Bind to a port and pass our router in
 	fmt.Println("Local: 		https://localhost:3000")
 	ip, err := localip.GetLocalIP()
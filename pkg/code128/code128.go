@@ -0,0 +1,95 @@
+// Package code128 encodes text as a Code 128 barcode using code set B, which
+// covers the printable ASCII characters.
+package code128
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	startB = 104
+	stop   = 106
+)
+
+// patterns are the widths of the alternating bars and spaces of each symbol
+// value, starting with a bar. Every symbol is 11 modules wide except the stop
+// symbol, which is 13.
+var patterns = [...]string{
+	"212222", "222122", "222221", "121223", "121322", "131222", "122213", "122312",
+	"132212", "221213", "221312", "231212", "112232", "122132", "122231", "113222",
+	"123122", "123221", "223211", "221132", "221231", "213212", "223112", "312131",
+	"311222", "321122", "321221", "312212", "322112", "322211", "212123", "212321",
+	"232121", "111323", "131123", "131321", "112313", "132113", "132311", "211313",
+	"231113", "231311", "112133", "112331", "132131", "113123", "113321", "133121",
+	"313121", "211331", "231131", "213113", "213311", "213131", "311123", "311321",
+	"331121", "312113", "312311", "332111", "314111", "221411", "431111", "111224",
+	"111422", "121124", "121421", "141122", "141221", "112214", "112412", "122114",
+	"122411", "142112", "142211", "241211", "221114", "413111", "241112", "134111",
+	"111242", "121142", "121241", "114212", "124112", "124211", "411212", "421112",
+	"421211", "212141", "214121", "412121", "111143", "111341", "131141", "114113",
+	"114311", "411113", "411311", "113141", "114131", "311141", "411131", "211412",
+	"211214", "211232", "2331112",
+}
+
+// Values returns the symbol values of the barcode for text: the start symbol,
+// a symbol for each character, the check symbol and the stop symbol.
+func Values(text string) ([]int, error) {
+	if text == "" {
+		return nil, fmt.Errorf("code128: nothing to encode")
+	}
+
+	values := []int{startB}
+	checksum := startB
+	for i, r := range text {
+		if r < ' ' || r > '~' {
+			return nil, fmt.Errorf("code128: cannot encode %q", r)
+		}
+		value := int(r - ' ')
+		values = append(values, value)
+		checksum += value * (i + 1)
+	}
+
+	return append(values, checksum%103, stop), nil
+}
+
+// Encode returns the modules of the barcode for text from left to right, true
+// for a bar and false for a space. It does not include the quiet zones either
+// side, which should be at least 10 modules wide.
+func Encode(text string) ([]bool, error) {
+	values, err := Values(text)
+	if err != nil {
+		return nil, err
+	}
+
+	var modules []bool
+	for _, v := range values {
+		for i, w := range patterns[v] {
+			isBar := i%2 == 0
+			for range int(w - '0') {
+				modules = append(modules, isBar)
+			}
+		}
+	}
+
+	return modules, nil
+}
+
+// String returns the modules of the barcode for text as 1 for a bar and 0 for
+// a space
+func String(text string) (string, error) {
+	modules, err := Encode(text)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	for _, m := range modules {
+		if m {
+			sb.WriteByte('1')
+		} else {
+			sb.WriteByte('0')
+		}
+	}
+	return sb.String(), nil
+}
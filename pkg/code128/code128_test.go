@@ -0,0 +1,69 @@
+package code128
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestPatternWidths(t *testing.T) {
+	for v, p := range patterns {
+		total, bars := 0, 0
+		for i, w := range p {
+			total += int(w - '0')
+			if i%2 == 0 {
+				bars += int(w - '0')
+			}
+		}
+
+		expected := 11
+		if v == stop {
+			expected = 13
+		}
+		if total != expected {
+			t.Fatalf("expected symbol %d to be %d modules wide, got %d", v, expected, total)
+		}
+		if bars%2 != 0 {
+			t.Fatalf("expected symbol %d to have an even number of bar modules, got %d", v, bars)
+		}
+	}
+}
+
+func TestValues(t *testing.T) {
+	values, err := Values("ABC")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	// check symbol is (104 + 33*1 + 34*2 + 35*3) mod 103
+	expected := []int{104, 33, 34, 35, 1, 106}
+	if !slices.Equal(values, expected) {
+		t.Fatalf("expected values %v, got %v", expected, values)
+	}
+}
+
+func TestString(t *testing.T) {
+	s, err := String("A")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	expected := "11010010000" + // start B
+		"10100011000" + // A
+		"10001011000" + // check symbol 34
+		"1100011101011" // stop
+	if s != expected {
+		t.Fatalf("expected %s, got %s", expected, s)
+	}
+}
+
+func TestEncodeInvalid(t *testing.T) {
+	if _, err := Encode(""); err == nil {
+		t.Fatalf("expected an error encoding empty text")
+	}
+	if _, err := Encode("A\tB"); err == nil {
+		t.Fatalf("expected an error encoding a tab")
+	}
+	if _, err := Encode("£"); err == nil {
+		t.Fatalf("expected an error encoding a non ASCII character")
+	}
+}
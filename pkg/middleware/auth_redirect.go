@@ -17,7 +17,10 @@ func AuthRedirect(next http.Handler) http.Handler {
 		_, ok := r.Context().Value(reqcontext.ReqContextKeyUser).(model.User)
 		if !ok {
 			if strings.HasPrefix(r.URL.Path, "/api/") {
-				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				// the same body as the other errors of the JSON API
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusUnauthorized)
+				_, _ = w.Write([]byte(`{"Error":"Unauthorized"}` + "\n"))
 				return
 			}
 			http.Redirect(w, r, "/auth/password", http.StatusSeeOther)
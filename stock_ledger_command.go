@@ -0,0 +1,81 @@
+package main
+
+import (
+	"app/internal/service"
+	"context"
+	"fmt"
+	"os"
+)
+
+// runStockLedgerCommand checks or rebuilds the stock ledger from the command
+// line and returns the process exit code. A check that finds problems exits
+// with 1 so it can be used from scheduled jobs.
+func runStockLedgerCommand(
+	stockLedgerIntegrityService *service.StockLedgerIntegrityService,
+	args []string,
+) int {
+	usage := "usage: app stock-ledger check|rebuild"
+
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, usage)
+		return 2
+	}
+
+	ctx := context.Background()
+
+	switch args[0] {
+	case "check":
+		report, err := stockLedgerIntegrityService.CheckStockLedger(ctx)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error checking stock ledger: %v\n", err)
+			return 1
+		}
+
+		fmt.Printf("Divergent running totals: %d\n", report.DivergentEntriesCount)
+		for _, e := range report.DivergentEntries {
+			fmt.Printf(
+				"  entry %d (transaction %d) %s %s %s/%s/%s: running total %s, expected %s\n",
+				e.StockTransactionEntryID, e.StockTransactionID, e.StockCode, e.Account,
+				e.Location, e.Bin, e.LotNumber, e.RunningTotal, e.ExpectedRunningTotal,
+			)
+		}
+
+		fmt.Printf("Unbalanced transactions: %d\n", report.UnbalancedTransactionsCount)
+		for _, t := range report.UnbalancedTransactions {
+			fmt.Printf(
+				"  transaction %d %s %s: %d entries netting to %s\n",
+				t.StockTransactionID, t.TransactionType, t.StockCode, t.EntryCount, t.NetQuantity,
+			)
+		}
+
+		fmt.Printf("Divergent stock balances: %d\n", report.DivergentBalancesCount)
+		for _, b := range report.DivergentBalances {
+			fmt.Printf(
+				"  %s %s %s/%s/%s: balance %s, ledger %s\n",
+				b.StockCode, b.Account, b.Location, b.Bin, b.LotNumber, b.BalanceQuantity, b.LedgerQuantity,
+			)
+		}
+
+		if !report.IsClean() {
+			return 1
+		}
+		return 0
+
+	case "rebuild":
+		result, err := stockLedgerIntegrityService.RebuildStockLedger(ctx)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error rebuilding stock ledger: %v\n", err)
+			return 1
+		}
+
+		fmt.Printf(
+			"Rebuild complete: %d running totals corrected and %d stock balances written\n",
+			result.UpdatedEntriesCount, result.BalancesCount,
+		)
+		return 0
+
+	default:
+		fmt.Fprintln(os.Stderr, usage)
+		return 2
+	}
+}
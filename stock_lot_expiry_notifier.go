@@ -0,0 +1,37 @@
+package main
+
+import (
+	"app/internal/service"
+	"context"
+	"log"
+	"time"
+)
+
+// stockLotExpiryCheckInterval is how often lots are checked for approaching
+// expiry. Lots are only notified once, so this only sets how soon after a
+// lot comes within the warning period the supply chain team hears about it.
+const stockLotExpiryCheckInterval = time.Hour
+
+// runStockLotExpiryNotifier notifies the supply chain team of lots
+// approaching expiry at start up and then on every interval until the
+// context is cancelled
+func runStockLotExpiryNotifier(
+	ctx context.Context,
+	stockLotService *service.StockLotService,
+) {
+	ticker := time.NewTicker(stockLotExpiryCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		err := stockLotService.NotifyExpiringStockLots(ctx)
+		if err != nil {
+			log.Println("error notifying expiring stock lots:", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
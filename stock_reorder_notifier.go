@@ -0,0 +1,36 @@
+package main
+
+import (
+	"app/internal/service"
+	"context"
+	"log"
+	"time"
+)
+
+// stockReorderCheckInterval is how often all stock is checked against the
+// reorder policies. Postings check the stock they move straight away, so
+// this catches changes made outside of postings.
+const stockReorderCheckInterval = time.Hour
+
+// runStockReorderNotifier checks all stock against the reorder policies at
+// start up and then on every interval until the context is cancelled
+func runStockReorderNotifier(
+	ctx context.Context,
+	stockReorderService *service.StockReorderService,
+) {
+	ticker := time.NewTicker(stockReorderCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		err := stockReorderService.CheckStockReorder(ctx, nil)
+		if err != nil {
+			log.Println("error checking stock reorder:", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}